@@ -8,10 +8,12 @@ import (
 	"squirrel-dev/internal/pkg/response"
 	applicationModule "squirrel-dev/internal/squ-agent/module/application"
 	configModule "squirrel-dev/internal/squ-agent/module/config"
+	dockerModule "squirrel-dev/internal/squ-agent/module/docker"
 	healthModule "squirrel-dev/internal/squ-agent/module/health"
 	monitorModule "squirrel-dev/internal/squ-agent/module/monitor"
 	scriptModule "squirrel-dev/internal/squ-agent/module/script"
 	serverModule "squirrel-dev/internal/squ-agent/module/server"
+	tunnelModule "squirrel-dev/internal/squ-agent/module/tunnel"
 )
 
 // registerHTTPRoutes 统一挂载所有 HTTP 路由。
@@ -24,6 +26,8 @@ func (a *App) registerHTTPRoutes() {
 	v1 := a.Gin.Group("/api/v1")
 	healthModule.RegisterHTTP(v1)
 	serverModule.RegisterHTTP(v1, serverModule.Dependencies{})
+	tunnelModule.RegisterHTTP(v1)
+	dockerModule.RegisterHTTP(v1, dockerModule.Dependencies{})
 	if a.AgentDB != nil {
 		configModule.RegisterHTTP(v1, a.AgentDB.GetDB())
 	}
@@ -3,4 +3,6 @@ package config
 type Common struct {
 	ComposePath string
 	ScriptsPath string
+	// BundlesPath holds the image bundles of air-gapped deployments.
+	BundlesPath string
 }
@@ -3,89 +3,120 @@ package jobs
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"strings"
+
+	"go.uber.org/zap"
 
 	"squirrel-dev/internal/squ-agent/module/application/domain"
-	"squirrel-dev/pkg/execute"
 	"squirrel-dev/pkg/utils"
 )
 
-type composeProject struct {
-	Name        string `json:"Name"`
-	Status      string `json:"Status"`
-	ConfigFiles string `json:"ConfigFiles"`
+// pollApplicationStatus is the status job. It only runs while the docker
+// event stream is down; while it is up, container events and
+// resyncIfWatching keep every deployment current.
+func (j *Jobs) pollApplicationStatus() {
+	if j.watching.Load() {
+		return
+	}
+	j.checkApplicationStatus()
 }
 
+// checkApplicationStatus reconciles and reports every deployment. While the
+// docker event stream is up it works from the containers last observed
+// through events and resyncs, otherwise it inspects them itself.
 func (j *Jobs) checkApplicationStatus() {
 	ctx := context.Background()
+	j.statusMu.Lock()
+	defer j.statusMu.Unlock()
 	applications, err := j.applications.List(ctx)
 	if err != nil {
 		return
 	}
 	for _, app := range applications {
-		status := j.containerStatus(app.DeployID)
-		shouldUpdate := app.OldStatus != status || status == domain.StatusStarting || status == domain.StatusFailed
-		if !shouldUpdate {
-			continue
-		}
-		updated := app
-		updated.Status = status
-		updated.OldStatus = app.Status
-		url := utils.GenAgentUrl(
-			j.config.Apiserver.Http.Scheme,
-			j.config.Apiserver.Http.Server,
-			0,
-			j.config.Apiserver.Http.BaseUri,
-			uriAppReport,
-		)
-		_, _ = j.http.Post(url, applicationStatusReport{DeployID: updated.DeployID, Status: status}, nil)
-		_ = j.applications.Update(ctx, &updated)
+		status, containers := j.observation(app.DeployID)
+		j.updateApplication(ctx, app, status, containers)
 	}
 }
 
-func (j *Jobs) containerStatus(deployID uint64) string {
-	status := j.checkComposeStatus("docker", "compose", deployID)
-	if status != "" {
-		return status
+// updateApplication reconciles app with the observed status and containers
+// and reports them when anything changed. Callers hold statusMu.
+func (j *Jobs) updateApplication(ctx context.Context, app domain.Application, status string, containers []domain.Container) {
+	if j.reconciler != nil && containers != nil {
+		j.reconciler.reconcile(app, status)
+	}
+	changed := j.containersChanged(app.DeployID, containers) || j.healthChanged(app)
+	// OldStatus holds the status last reported. A redeploy sets Status back
+	// to starting meanwhile, so compare both to report the new state.
+	if !changed && app.OldStatus == status && app.Status == status {
+		return
 	}
-	status = j.checkComposeStatus("docker-compose", "", deployID)
-	if status != "" {
-		return status
+	updated := app
+	updated.Status = status
+	updated.OldStatus = status
+	url := utils.GenAgentUrl(
+		j.config.Apiserver.Http.Scheme,
+		j.config.Apiserver.Http.Server,
+		0,
+		j.config.Apiserver.Http.BaseUri,
+		uriAppReport,
+	)
+	report := applicationStatusReport{DeployID: updated.DeployID, Status: status, Containers: containers,
+		Health: app.Health, HealthMessage: app.HealthMessage}
+	if _, err := j.http.Post(url, report, nil); err != nil {
+		// Report the status, the containers and the health again on the
+		// next run.
+		j.forgetContainers(app.DeployID)
+		return
 	}
-	return domain.StatusFailed
+	_ = j.applications.Update(ctx, &updated)
 }
 
-func (j *Jobs) checkComposeStatus(command, prefix string, deployID uint64) string {
-	var args []string
-	if prefix == "" {
-		args = []string{"ls", "--all", "--format", "json"}
-	} else {
-		args = []string{prefix, "ls", "--all", "--format", "json"}
-	}
-	output, _, err := execute.CommandError(command, args...)
+// containerStatus returns the status of a deployment and its containers. The
+// containers are nil when docker could not be queried.
+func (j *Jobs) containerStatus(deployID uint64) (string, []domain.Container) {
+	containers, err := j.containers.Containers(deployID)
 	if err != nil {
-		// Preserve the old behavior: this non-empty failure status means the
-		// docker-compose fallback in containerStatus is effectively unreachable.
-		return domain.StatusFailed
+		zap.L().Warn("failed to inspect containers", zap.Uint64("deploy_id", deployID), zap.Error(err))
+		return domain.StatusFailed, nil
+	}
+	if containers == nil {
+		containers = []domain.Container{}
 	}
-	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &j.composeProjects); err != nil {
-		return domain.StatusFailed
+	return domain.Summarize(containers), containers
+}
+
+// containersChanged reports whether containers differ from the last snapshot
+// sent for deployID and remembers them.
+func (j *Jobs) containersChanged(deployID uint64, containers []domain.Container) bool {
+	if containers == nil {
+		return false
+	}
+	data, err := json.Marshal(containers)
+	if err != nil {
+		return false
 	}
-	expected := fmt.Sprintf("%d/docker-compose.yml", deployID)
-	for _, project := range j.composeProjects {
-		if !strings.Contains(project.ConfigFiles, expected) {
-			continue
-		}
-		status := strings.ToLower(project.Status)
-		switch {
-		case strings.HasPrefix(status, "running"):
-			return domain.StatusRunning
-		case strings.HasPrefix(status, "exited"):
-			return domain.StatusStopped
-		case strings.HasPrefix(status, "paused"):
-			return domain.StatusPaused
-		}
+	j.reportedMu.Lock()
+	defer j.reportedMu.Unlock()
+	if j.reported[deployID] == string(data) {
+		return false
 	}
-	return domain.StatusUndeploy
+	j.reported[deployID] = string(data)
+	return true
+}
+
+// healthChanged reports whether the health of app differs from the last one
+// sent and remembers it.
+func (j *Jobs) healthChanged(app domain.Application) bool {
+	health := app.Health + "\x00" + app.HealthMessage
+	j.reportedMu.Lock()
+	defer j.reportedMu.Unlock()
+	previous, ok := j.reportedHealth[app.DeployID]
+	j.reportedHealth[app.DeployID] = health
+	return ok && previous != health || !ok && app.Health != ""
+}
+
+func (j *Jobs) forgetContainers(deployID uint64) {
+	j.reportedMu.Lock()
+	defer j.reportedMu.Unlock()
+	delete(j.reported, deployID)
+	delete(j.reportedHealth, deployID)
 }
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"squirrel-dev/internal/squ-agent/config"
+	applicationDomain "squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/pkg/httpclient"
+)
+
+type applicationRepositoryStub struct {
+	apps []applicationDomain.Application
+}
+
+func (s *applicationRepositoryStub) List(context.Context) ([]applicationDomain.Application, error) {
+	return s.apps, nil
+}
+func (s *applicationRepositoryStub) Get(context.Context, uint) (applicationDomain.Application, error) {
+	return applicationDomain.Application{}, nil
+}
+func (s *applicationRepositoryStub) GetByDeployID(_ context.Context, deployID uint64) (applicationDomain.Application, error) {
+	for _, app := range s.apps {
+		if app.DeployID == deployID {
+			return app, nil
+		}
+	}
+	return applicationDomain.Application{}, errors.New("record not found")
+}
+func (s *applicationRepositoryStub) Delete(context.Context, uint) error { return nil }
+func (s *applicationRepositoryStub) Add(context.Context, *applicationDomain.Application) error {
+	return nil
+}
+func (s *applicationRepositoryStub) Update(_ context.Context, app *applicationDomain.Application) error {
+	for i := range s.apps {
+		if s.apps[i].ID == app.ID {
+			s.apps[i] = *app
+		}
+	}
+	return nil
+}
+func (s *applicationRepositoryStub) UpdateHealth(context.Context, uint64, string, string) error {
+	return nil
+}
+func (s *applicationRepositoryStub) UpdateDesired(context.Context, uint64, string) error {
+	return nil
+}
+func (s *applicationRepositoryStub) UpdateReconcile(context.Context, uint64, bool) error {
+	return nil
+}
+func (s *applicationRepositoryStub) UpdatePushed(context.Context, uint64, applicationDomain.FileHashes) error {
+	return nil
+}
+func (s *applicationRepositoryStub) Transaction(_ context.Context, fn func(applicationDomain.Repository) error) error {
+	return fn(s)
+}
+
+type inspectorStub map[uint64][]applicationDomain.Container
+
+func (s inspectorStub) Containers(deployID uint64) ([]applicationDomain.Container, error) {
+	return s[deployID], nil
+}
+
+type statusPosterStub struct {
+	reports []applicationStatusReport
+}
+
+func (p *statusPosterStub) Post(_ string, value any, _ httpclient.Header) ([]byte, error) {
+	p.reports = append(p.reports, value.(applicationStatusReport))
+	return nil, nil
+}
+
+func TestCheckApplicationStatusReportsContainers(t *testing.T) {
+	running := applicationDomain.Container{Service: "web", State: applicationDomain.ContainerRunning}
+	crashing := applicationDomain.Container{Service: "worker", State: applicationDomain.ContainerRestarting, RestartCount: 3}
+	inspector := inspectorStub{42: {running, running, crashing}}
+	repository := &applicationRepositoryStub{apps: []applicationDomain.Application{
+		{ID: 1, DeployID: 42, Status: applicationDomain.StatusRunning, OldStatus: applicationDomain.StatusRunning},
+	}}
+	poster := &statusPosterStub{}
+	instance := &Jobs{
+		config:         &config.Config{},
+		applications:   repository,
+		containers:     inspector,
+		http:           poster,
+		reported:       map[uint64]string{},
+		reportedHealth: map[uint64]string{},
+	}
+
+	instance.checkApplicationStatus()
+	if len(poster.reports) != 1 || poster.reports[0].Status != applicationDomain.StatusDegraded || len(poster.reports[0].Containers) != 3 {
+		t.Fatalf("reports = %#v", poster.reports)
+	}
+
+	// Nothing changed, so nothing is reported.
+	repository.apps[0].OldStatus = applicationDomain.StatusDegraded
+	instance.checkApplicationStatus()
+	if len(poster.reports) != 1 {
+		t.Fatalf("unchanged state was reported again: %#v", poster.reports)
+	}
+
+	// A restart only changes the restart count but is still reported.
+	crashing.RestartCount = 4
+	inspector[42] = []applicationDomain.Container{running, running, crashing}
+	instance.checkApplicationStatus()
+	if len(poster.reports) != 2 || poster.reports[1].Containers[2].RestartCount != 4 {
+		t.Fatalf("reports = %#v", poster.reports)
+	}
+
+	// A failed health check is reported although the containers did not
+	// change.
+	repository.apps[0].OldStatus = applicationDomain.StatusDegraded
+	repository.apps[0].Health = applicationDomain.HealthUnhealthy
+	repository.apps[0].HealthMessage = "http http://127.0.0.1/: status 503; restored the previous deployment"
+	instance.checkApplicationStatus()
+	if len(poster.reports) != 3 || poster.reports[2].Health != applicationDomain.HealthUnhealthy || poster.reports[2].HealthMessage == "" {
+		t.Fatalf("reports = %#v", poster.reports)
+	}
+}
+
+// brokenInspector fails like an unreachable docker daemon and counts the
+// inspections.
+type brokenInspector struct{ calls int }
+
+func (s *brokenInspector) Containers(uint64) ([]applicationDomain.Container, error) {
+	s.calls++
+	return nil, errors.New("cannot connect to the docker daemon")
+}
+
+func TestUnchangedFailedDeploymentIsNotReported(t *testing.T) {
+	inspector := &brokenInspector{}
+	repository := &applicationRepositoryStub{apps: []applicationDomain.Application{
+		{ID: 1, DeployID: 42, Status: applicationDomain.StatusStarting, OldStatus: applicationDomain.StatusRunning},
+	}}
+	poster := &statusPosterStub{}
+	instance := &Jobs{
+		config:         &config.Config{},
+		applications:   repository,
+		containers:     inspector,
+		http:           poster,
+		reported:       map[uint64]string{},
+		reportedHealth: map[uint64]string{},
+	}
+
+	for range 3 {
+		instance.pollApplicationStatus()
+	}
+	if len(poster.reports) != 1 || poster.reports[0].Status != applicationDomain.StatusFailed {
+		t.Fatalf("reports = %#v", poster.reports)
+	}
+
+	// The event stream and its resync report while it is up.
+	instance.watching.Store(true)
+	calls := inspector.calls
+	instance.pollApplicationStatus()
+	if inspector.calls != calls || len(poster.reports) != 1 {
+		t.Fatalf("status job ran while watching events: inspections %d, reports %d", inspector.calls-calls, len(poster.reports))
+	}
+}
@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+// Container events of one compose action arrive in bursts, so a deployment
+// is refreshed once eventSettle after the first of them. A failed event
+// stream is opened again after a pause that grows from minWatchBackoff to
+// maxWatchBackoff.
+var (
+	eventSettle     = 500 * time.Millisecond
+	minWatchBackoff = time.Second
+	maxWatchBackoff = 30 * time.Second
+)
+
+// observed is what the containers of a deployment looked like when they
+// were last inspected.
+type observed struct {
+	status     string
+	containers []domain.Container
+}
+
+// watchContainerEvents follows docker events until ctx ends, opening the
+// stream again whenever it fails. The status job inspects containers itself
+// while the stream is down.
+func (j *Jobs) watchContainerEvents(ctx context.Context) {
+	backoff := minWatchBackoff
+	for {
+		err := j.events.Watch(ctx, func() {
+			backoff = minWatchBackoff
+			// Events may have been missed while the stream was down.
+			j.resyncApplications()
+			j.watching.Store(true)
+			j.checkApplicationStatus()
+		}, j.containerChanged)
+		j.watching.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		zap.L().Warn("docker event stream failed, inspecting containers periodically",
+			zap.Duration("retry_in", backoff), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxWatchBackoff)
+	}
+}
+
+// containerChanged schedules a refresh of the deployment with deployID.
+func (j *Jobs) containerChanged(deployID uint64) {
+	j.pendingMu.Lock()
+	defer j.pendingMu.Unlock()
+	if j.pending[deployID] {
+		return
+	}
+	j.pending[deployID] = true
+	time.AfterFunc(eventSettle, func() {
+		j.pendingMu.Lock()
+		delete(j.pending, deployID)
+		j.pendingMu.Unlock()
+		j.refreshApplication(deployID)
+	})
+}
+
+// refreshApplication inspects the containers of one deployment and reports
+// its status right away. Projects the agent does not know are ignored.
+func (j *Jobs) refreshApplication(deployID uint64) {
+	ctx := context.Background()
+	status, containers := j.observe(deployID)
+	j.statusMu.Lock()
+	defer j.statusMu.Unlock()
+	app, err := j.applications.GetByDeployID(ctx, deployID)
+	if err != nil {
+		return
+	}
+	j.updateApplication(ctx, app, status, containers)
+}
+
+// resyncApplications inspects the containers of every deployment again, in
+// case an event was missed, and replaces what was observed before.
+func (j *Jobs) resyncApplications() {
+	applications, err := j.applications.List(context.Background())
+	if err != nil {
+		return
+	}
+	values := map[uint64]observed{}
+	for _, app := range applications {
+		status, containers := j.containerStatus(app.DeployID)
+		if containers != nil {
+			values[app.DeployID] = observed{status: status, containers: containers}
+		}
+	}
+	j.observedMu.Lock()
+	j.observed = values
+	j.observedMu.Unlock()
+}
+
+// resyncIfWatching resyncs and reports all deployments while the event
+// stream is up.
+func (j *Jobs) resyncIfWatching() {
+	if !j.watching.Load() {
+		return
+	}
+	j.resyncApplications()
+	j.checkApplicationStatus()
+}
+
+// observation returns the last observed status and containers of a
+// deployment while the event stream is up, and inspects them otherwise.
+func (j *Jobs) observation(deployID uint64) (string, []domain.Container) {
+	if j.watching.Load() {
+		j.observedMu.Lock()
+		value, ok := j.observed[deployID]
+		j.observedMu.Unlock()
+		if ok {
+			return value.status, value.containers
+		}
+	}
+	return j.observe(deployID)
+}
+
+// observe inspects the containers of a deployment and keeps them for the
+// status job. Failed inspections are not kept so that they are retried.
+func (j *Jobs) observe(deployID uint64) (string, []domain.Container) {
+	status, containers := j.containerStatus(deployID)
+	j.observedMu.Lock()
+	defer j.observedMu.Unlock()
+	if containers == nil {
+		delete(j.observed, deployID)
+	} else if j.observed != nil {
+		j.observed[deployID] = observed{status: status, containers: containers}
+	}
+	return status, containers
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"squirrel-dev/internal/squ-agent/config"
+	applicationDomain "squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/pkg/httpclient"
+)
+
+// watcherStub opens the stream right away and passes on the deploy IDs sent
+// to changes.
+type watcherStub struct {
+	ready   chan struct{}
+	changes chan uint64
+}
+
+func (w *watcherStub) Watch(ctx context.Context, ready func(), changed func(uint64)) error {
+	ready()
+	close(w.ready)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case deployID := <-w.changes:
+			changed(deployID)
+		}
+	}
+}
+
+// countingInspector counts the inspections of each deployment.
+type countingInspector struct {
+	mu         sync.Mutex
+	containers map[uint64][]applicationDomain.Container
+	calls      int
+}
+
+func (s *countingInspector) Containers(deployID uint64) ([]applicationDomain.Container, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return s.containers[deployID], nil
+}
+
+func (s *countingInspector) set(deployID uint64, containers ...applicationDomain.Container) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.containers[deployID] = containers
+}
+
+func (s *countingInspector) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+type lockedPosterStub struct {
+	mu      sync.Mutex
+	reports []applicationStatusReport
+}
+
+func (p *lockedPosterStub) Post(_ string, value any, _ httpclient.Header) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reports = append(p.reports, value.(applicationStatusReport))
+	return nil, nil
+}
+
+func (p *lockedPosterStub) last() (applicationStatusReport, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.reports) == 0 {
+		return applicationStatusReport{}, 0
+	}
+	return p.reports[len(p.reports)-1], len(p.reports)
+}
+
+func TestContainerEventsReportStatusWithoutPolling(t *testing.T) {
+	settle := eventSettle
+	eventSettle = 10 * time.Millisecond
+	defer func() { eventSettle = settle }()
+
+	running := applicationDomain.Container{Service: "web", State: applicationDomain.ContainerRunning}
+	exited := applicationDomain.Container{Service: "web", State: applicationDomain.ContainerExited, ExitCode: 137}
+	inspector := &countingInspector{containers: map[uint64][]applicationDomain.Container{42: {running}}}
+	repository := &applicationRepositoryStub{apps: []applicationDomain.Application{
+		{ID: 1, DeployID: 42, Status: applicationDomain.StatusStarting, OldStatus: applicationDomain.StatusStarting},
+	}}
+	poster := &lockedPosterStub{}
+	watcher := &watcherStub{ready: make(chan struct{}), changes: make(chan uint64)}
+	instance := &Jobs{
+		config:         &config.Config{},
+		applications:   repository,
+		containers:     inspector,
+		events:         watcher,
+		http:           poster,
+		reported:       map[uint64]string{},
+		reportedHealth: map[uint64]string{},
+		observed:       map[uint64]observed{},
+		pending:        map[uint64]bool{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		instance.watchContainerEvents(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Opening the stream resyncs and reports every deployment.
+	<-watcher.ready
+	if report, count := poster.last(); count != 1 || report.Status != applicationDomain.StatusRunning {
+		t.Fatalf("report = %+v, count = %d", report, count)
+	}
+	if inspector.count() != 1 {
+		t.Fatalf("inspections = %d, want 1", inspector.count())
+	}
+
+	// The status job works from what was observed.
+	instance.checkApplicationStatus()
+	instance.checkApplicationStatus()
+	if inspector.count() != 1 {
+		t.Fatalf("status job inspected containers while watching events: %d", inspector.count())
+	}
+
+	// A burst of events is reported once, right after it settled.
+	inspector.set(42, exited)
+	_, before := poster.last()
+	for range 3 {
+		watcher.changes <- 42
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		report, count := poster.last()
+		if count > before && report.Status == applicationDomain.StatusStopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("report = %+v, count = %d", report, count)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if inspector.count() != 2 {
+		t.Fatalf("inspections = %d, want 2", inspector.count())
+	}
+
+	// Events of projects the agent did not deploy are ignored.
+	watcher.changes <- 7
+	time.Sleep(3 * eventSettle)
+	if _, count := poster.last(); count != before+1 {
+		t.Fatalf("reports = %d, want %d", count, before+1)
+	}
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/pkg/utils"
+)
+
+const uriFilesReport = "/deployment/report/files"
+
+// fileStateReport carries the hashes of a deployment's files on disk and as
+// the agent last wrote them. A missing file has an empty hash.
+type fileStateReport struct {
+	DeployID          uint64 `json:"deploy_id"`
+	ComposeHash       string `json:"compose_hash"`
+	EnvHash           string `json:"env_hash"`
+	PushedComposeHash string `json:"pushed_compose_hash"`
+	PushedEnvHash     string `json:"pushed_env_hash"`
+}
+
+// checkFiles reports the file hashes of each deployment whenever they differ
+// from what was last accepted by the apiserver.
+func (j *Jobs) checkFiles() {
+	ctx := context.Background()
+	applications, err := j.applications.List(ctx)
+	if err != nil {
+		return
+	}
+	url := utils.GenAgentUrl(
+		j.config.Apiserver.Http.Scheme,
+		j.config.Apiserver.Http.Server,
+		0,
+		j.config.Apiserver.Http.BaseUri,
+		uriFilesReport,
+	)
+	for _, app := range applications {
+		files, err := j.runtime.Files(app.DeployID)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			zap.L().Warn("failed to read deployment files", zap.Uint64("deploy_id", app.DeployID), zap.Error(err))
+			continue
+		}
+		hashes := files.Hashes()
+		report := fileStateReport{DeployID: app.DeployID, ComposeHash: hashes.Compose, EnvHash: hashes.Env,
+			PushedComposeHash: app.Pushed.Compose, PushedEnvHash: app.Pushed.Env}
+		if !j.filesChanged(report) {
+			continue
+		}
+		body, err := j.http.Post(url, report, nil)
+		var result response.Response
+		if err == nil {
+			err = json.Unmarshal(body, &result)
+		}
+		if err != nil || result.Code != 0 {
+			j.forgetFiles(app.DeployID)
+		}
+	}
+}
+
+// filesChanged reports whether report differs from the last one sent for its
+// deploy ID and remembers it.
+func (j *Jobs) filesChanged(report fileStateReport) bool {
+	j.reportedMu.Lock()
+	defer j.reportedMu.Unlock()
+	if j.reportedFiles[report.DeployID] == report {
+		return false
+	}
+	j.reportedFiles[report.DeployID] = report
+	return true
+}
+
+func (j *Jobs) forgetFiles(deployID uint64) {
+	j.reportedMu.Lock()
+	defer j.reportedMu.Unlock()
+	delete(j.reportedFiles, deployID)
+}
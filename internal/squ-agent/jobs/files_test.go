@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"squirrel-dev/internal/squ-agent/config"
+	applicationDomain "squirrel-dev/internal/squ-agent/module/application/domain"
+	applicationInfra "squirrel-dev/internal/squ-agent/module/application/infra"
+	"squirrel-dev/pkg/httpclient"
+)
+
+type filesPosterStub struct {
+	reports []fileStateReport
+	fail    bool
+}
+
+func (p *filesPosterStub) Post(_ string, value any, _ httpclient.Header) ([]byte, error) {
+	if p.fail {
+		return nil, errors.New("connection refused")
+	}
+	p.reports = append(p.reports, value.(fileStateReport))
+	return []byte(`{"code":0,"message":"success"}`), nil
+}
+
+func TestCheckFilesReportsChangedHashes(t *testing.T) {
+	runtime := applicationInfra.NewComposeRuntime(t.TempDir())
+	path, _, err := runtime.Prepare(7, "services: {}\n", []map[string]string{{"TOKEN": "abc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := runtime.Files(7)
+	pushed := files.Hashes()
+	repository := &applicationRepositoryStub{apps: []applicationDomain.Application{{ID: 1, DeployID: 7, Pushed: pushed}}}
+	poster := &filesPosterStub{fail: true}
+	instance := &Jobs{config: &config.Config{}, applications: repository, runtime: runtime, http: poster,
+		reportedFiles: map[uint64]fileStateReport{}}
+
+	// A report that failed is sent again.
+	instance.checkFiles()
+	poster.fail = false
+	instance.checkFiles()
+	instance.checkFiles()
+	if len(poster.reports) != 1 || poster.reports[0].EnvHash != pushed.Env || poster.reports[0].PushedComposeHash != pushed.Compose {
+		t.Fatalf("reports = %+v", poster.reports)
+	}
+
+	// A hand edit changes the hash on disk but not the pushed one.
+	if err := os.WriteFile(filepath.Join(path, ".env"), []byte("TOKEN=changed\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	instance.checkFiles()
+	if len(poster.reports) != 2 || poster.reports[1].EnvHash == pushed.Env || poster.reports[1].PushedEnvHash != pushed.Env {
+		t.Fatalf("reports = %+v", poster.reports)
+	}
+}
@@ -3,9 +3,12 @@ package jobs
 import (
 	"context"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	cronV3 "github.com/robfig/cron/v3"
+	"go.uber.org/zap"
 
 	"squirrel-dev/internal/pkg/cache"
 	"squirrel-dev/internal/pkg/database"
@@ -26,15 +29,38 @@ type HTTPPoster interface {
 }
 
 type Jobs struct {
-	config          *config.Config
-	cron            *cronV3.Cron
-	cache           cache.Cache
-	applications    applicationDomain.Repository
-	scriptTasks     scriptDomain.Repository
-	configs         configDomain.Repository
-	monitors        monitorDomain.Repository
-	http            HTTPPoster
-	composeProjects []composeProject
+	config       *config.Config
+	cron         *cronV3.Cron
+	cache        cache.Cache
+	applications applicationDomain.Repository
+	scriptTasks  scriptDomain.Repository
+	configs      configDomain.Repository
+	monitors     monitorDomain.Repository
+	http         HTTPPoster
+	containers   applicationDomain.ContainerInspector
+	events       applicationDomain.ContainerWatcher
+	runtime      applicationDomain.ComposeRuntime
+	drift        applicationDomain.DriftRepository
+	reconciler   *reconciler
+
+	// reportedMu guards reported, reportedHealth and reportedFiles, the
+	// last container snapshot, health and file hashes sent for each deploy
+	// ID.
+	reportedMu     sync.Mutex
+	reported       map[uint64]string
+	reportedHealth map[uint64]string
+	reportedFiles  map[uint64]fileStateReport
+
+	// statusMu serializes status updates from the status job and from
+	// container events. watching is set while the docker event stream is
+	// up; observed then holds the containers last seen for each deploy ID
+	// and pending the deploy IDs with a refresh scheduled.
+	statusMu   sync.Mutex
+	watching   atomic.Bool
+	observedMu sync.Mutex
+	observed   map[uint64]observed
+	pendingMu  sync.Mutex
+	pending    map[uint64]bool
 }
 
 func New(
@@ -42,25 +68,51 @@ func New(
 	cacheClient cache.Cache,
 	agentDB, appDB, scriptTaskDB, monitorDB database.DB,
 ) *Jobs {
-	return &Jobs{
-		config:       conf,
-		cron:         cronV3.New(cronV3.WithSeconds()),
-		cache:        cacheClient,
-		applications: applicationInfra.NewRepository(appDB.GetDB()),
-		scriptTasks:  scriptInfra.NewRepository(scriptTaskDB.GetDB()),
-		configs:      configInfra.NewRepository(agentDB.GetDB()),
-		monitors:     monitorInfra.NewRepository(monitorDB.GetDB()),
-		http:         httpclient.NewClient(10 * time.Second),
+	drift := applicationInfra.NewDriftRepository(appDB.GetDB())
+	runtime := applicationInfra.NewComposeRuntime(conf.Common.ComposePath)
+	jobs := &Jobs{
+		config:         conf,
+		cron:           cronV3.New(cronV3.WithSeconds()),
+		cache:          cacheClient,
+		applications:   applicationInfra.NewRepository(appDB.GetDB()),
+		scriptTasks:    scriptInfra.NewRepository(scriptTaskDB.GetDB()),
+		configs:        configInfra.NewRepository(agentDB.GetDB()),
+		monitors:       monitorInfra.NewRepository(monitorDB.GetDB()),
+		http:           httpclient.NewClient(10 * time.Second),
+		containers:     applicationInfra.NewDockerInspector(),
+		runtime:        runtime,
+		drift:          drift,
+		reconciler:     newReconciler(runtime, drift),
+		reported:       map[uint64]string{},
+		reportedHealth: map[uint64]string{},
+		reportedFiles:  map[uint64]fileStateReport{},
+		observed:       map[uint64]observed{},
+		pending:        map[uint64]bool{},
+	}
+	if events, err := applicationInfra.NewDockerEvents(); err != nil {
+		zap.L().Warn("failed to create docker client, inspecting containers periodically", zap.Error(err))
+	} else {
+		jobs.events = events
 	}
+	return jobs
 }
 
 func (j *Jobs) Start() error {
-	if _, err := j.cron.AddFunc("*/5 * * * * *", j.checkApplicationStatus); err != nil {
+	if _, err := j.cron.AddFunc("*/5 * * * * *", j.pollApplicationStatus); err != nil {
 		return err
 	}
 	if _, err := j.cron.AddFunc("*/5 * * * * *", j.reportScriptResults); err != nil {
 		return err
 	}
+	if _, err := j.cron.AddFunc("*/5 * * * * *", j.reportDriftEvents); err != nil {
+		return err
+	}
+	if _, err := j.cron.AddFunc("0 * * * * *", j.checkFiles); err != nil {
+		return err
+	}
+	if _, err := j.cron.AddFunc("30 * * * * *", j.resyncIfWatching); err != nil {
+		return err
+	}
 	if err := j.registerMonitorCollection(); err != nil {
 		return err
 	}
@@ -68,6 +120,9 @@ func (j *Jobs) Start() error {
 		return err
 	}
 	go j.refreshMonitorStatsCache()
+	if j.events != nil {
+		go j.watchContainerEvents(context.Background())
+	}
 	j.cron.Start()
 	return nil
 }
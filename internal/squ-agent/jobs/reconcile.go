@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	applicationDomain "squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+// A deployment is corrected once it drifted for reconcileGrace, which
+// leaves time for stops and starts in progress. Further corrections back
+// off from reconcileBackoff, doubling up to maxReconcileBackoff. After
+// maxReconcileAttempts corrections that did not bring a deployment back to
+// its desired state the agent gives up until the deployment reaches it
+// again or its desired state changes.
+const (
+	reconcileGrace       = 30 * time.Second
+	reconcileBackoff     = 10 * time.Second
+	maxReconcileBackoff  = 5 * time.Minute
+	maxReconcileAttempts = 5
+)
+
+// reconcileState tracks the corrections of one deployment since it was last
+// seen in its desired state.
+type reconcileState struct {
+	desired  string
+	attempts int
+	next     time.Time
+	running  bool
+	gaveUp   bool
+}
+
+type reconciler struct {
+	runtime applicationDomain.ComposeRuntime
+	events  applicationDomain.DriftRepository
+	now     func() time.Time
+
+	mu     sync.Mutex
+	states map[uint64]*reconcileState
+	// wg tracks running corrections for tests.
+	wg sync.WaitGroup
+}
+
+func newReconciler(runtime applicationDomain.ComposeRuntime, events applicationDomain.DriftRepository) *reconciler {
+	return &reconciler{runtime: runtime, events: events, now: time.Now, states: map[uint64]*reconcileState{}}
+}
+
+// reconcile compares a deployment observed in status with its desired state
+// and starts a correction in the background when one is due. Deployments
+// that are being deployed or started are left alone.
+func (r *reconciler) reconcile(app applicationDomain.Application, observed string) {
+	if !app.Reconcile || app.Desired == "" || app.Status == applicationDomain.StatusStarting {
+		r.forget(app.DeployID)
+		return
+	}
+	action := applicationDomain.ReconcileAction(app.Desired, observed)
+
+	r.mu.Lock()
+	state := r.states[app.DeployID]
+	if state == nil || state.desired != app.Desired {
+		state = &reconcileState{desired: app.Desired}
+		r.states[app.DeployID] = state
+	}
+	if action == "" {
+		if !state.running {
+			*state = reconcileState{desired: app.Desired}
+		}
+		r.mu.Unlock()
+		return
+	}
+	now := r.now()
+	if state.next.IsZero() {
+		state.next = now.Add(reconcileGrace)
+	}
+	if state.running || state.gaveUp || now.Before(state.next) {
+		r.mu.Unlock()
+		return
+	}
+	event := applicationDomain.DriftEvent{DeployID: app.DeployID, Observed: observed, Desired: app.Desired, Attempt: state.attempts + 1}
+	if state.attempts >= maxReconcileAttempts {
+		state.gaveUp = true
+		r.mu.Unlock()
+		event.Action, event.Attempt = applicationDomain.ActionGiveUp, state.attempts
+		event.Error = "deployment keeps drifting from its desired state; reconciling is paused until it is started or stopped again"
+		r.record(event)
+		return
+	}
+	state.attempts++
+	state.running = true
+	state.next = now.Add(backoff(state.attempts))
+	r.mu.Unlock()
+
+	event.Action = action
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if err := r.correct(app.DeployID, action); err != nil {
+			event.Error = err.Error()
+		}
+		r.mu.Lock()
+		state.running = false
+		r.mu.Unlock()
+		r.record(event)
+	}()
+}
+
+func (r *reconciler) correct(deployID uint64, action string) error {
+	if !r.runtime.ComposeFileExists(deployID) {
+		return errors.New("docker-compose file not found")
+	}
+	ctx := context.Background()
+	unlock, err := r.runtime.Lock(ctx, deployID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if action == applicationDomain.ActionStart {
+		return r.runtime.Up(ctx, deployID, discardOutput{})
+	}
+	return r.runtime.Stop(ctx, deployID, discardOutput{})
+}
+
+func (r *reconciler) record(event applicationDomain.DriftEvent) {
+	if event.Error != "" {
+		zap.L().Warn("deployment drifted from its desired state",
+			zap.Uint64("deploy_id", event.DeployID),
+			zap.String("observed", event.Observed),
+			zap.String("desired", event.Desired),
+			zap.String("action", event.Action),
+			zap.Int("attempt", event.Attempt),
+			zap.String("error", event.Error),
+		)
+	}
+	if err := r.events.Add(context.Background(), &event); err != nil {
+		zap.L().Error("failed to record drift event", zap.Uint64("deploy_id", event.DeployID), zap.Error(err))
+	}
+}
+
+// forget drops the state of a deployment that is not reconciled, so that
+// turning reconciling on again starts over.
+func (r *reconciler) forget(deployID uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if state := r.states[deployID]; state != nil && !state.running {
+		delete(r.states, deployID)
+	}
+}
+
+func backoff(attempts int) time.Duration {
+	delay := reconcileBackoff
+	for i := 1; i < attempts && delay < maxReconcileBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxReconcileBackoff)
+}
+
+// discardOutput drops the output of corrections. Their errors carry what
+// compose wrote to stderr for the event.
+type discardOutput struct{}
+
+func (discardOutput) Stdout() io.Writer { return io.Discard }
+func (discardOutput) Stderr() io.Writer { return io.Discard }
@@ -0,0 +1,183 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"squirrel-dev/internal/squ-agent/config"
+	applicationDomain "squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/pkg/httpclient"
+)
+
+// composeStub fails every up with err, which ends with compose's stderr
+// like the errors of the real runtime.
+type composeStub struct {
+	mu      sync.Mutex
+	actions []string
+	err     error
+}
+
+func (c *composeStub) DockerInstalled() bool  { return true }
+func (c *composeStub) ComposeAvailable() bool { return true }
+func (c *composeStub) Prepare(uint64, string, []map[string]string) (string, string, error) {
+	return "", "", nil
+}
+func (c *composeStub) ComposeFileExists(uint64) bool { return true }
+func (c *composeStub) Lock(context.Context, uint64) (func(), error) {
+	return func() {}, nil
+}
+func (c *composeStub) Up(context.Context, uint64, applicationDomain.Output) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.actions = append(c.actions, "up")
+	return c.err
+}
+func (c *composeStub) Start(context.Context, uint64, applicationDomain.Output) error { return nil }
+func (c *composeStub) Stop(context.Context, uint64, applicationDomain.Output) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.actions = append(c.actions, "stop")
+	return nil
+}
+func (c *composeStub) Logs(context.Context, uint64, applicationDomain.LogOptions, applicationDomain.Output) error {
+	return nil
+}
+func (c *composeStub) Images(context.Context, uint64) ([]string, error) { return nil, nil }
+func (c *composeStub) Path(uint64) string                               { return "/compose/7" }
+func (c *composeStub) Snapshot(uint64) (bool, error)                    { return false, nil }
+func (c *composeStub) Restore(uint64) error                             { return nil }
+func (c *composeStub) Files(uint64) (applicationDomain.Files, error) {
+	return applicationDomain.Files{}, nil
+}
+
+type driftStub struct {
+	mu       sync.Mutex
+	events   []applicationDomain.DriftEvent
+	reported []uint
+}
+
+func (d *driftStub) Add(_ context.Context, event *applicationDomain.DriftEvent) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	event.ID = uint(len(d.events) + 1)
+	d.events = append(d.events, *event)
+	return nil
+}
+func (d *driftStub) Unreported(context.Context) ([]applicationDomain.DriftEvent, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var result []applicationDomain.DriftEvent
+	for _, event := range d.events {
+		if !slices.Contains(d.reported, event.ID) {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+func (d *driftStub) MarkReported(_ context.Context, id uint) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reported = append(d.reported, id)
+	return nil
+}
+
+func TestReconcileRestoresDesiredStateWithBackoff(t *testing.T) {
+	runtime := &composeStub{err: errors.New("exit status 1: Error response from daemon: port is already allocated")}
+	events := &driftStub{}
+	r := newReconciler(runtime, events)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	app := applicationDomain.Application{DeployID: 7, Status: applicationDomain.StatusStopped,
+		Desired: applicationDomain.DesiredRunning, Reconcile: true}
+	tick := func(after time.Duration, observed string) {
+		now = now.Add(after)
+		r.reconcile(app, observed)
+		r.wg.Wait()
+	}
+
+	// Drift is left alone during the grace period.
+	tick(0, applicationDomain.StatusUndeploy)
+	tick(20*time.Second, applicationDomain.StatusUndeploy)
+	if len(runtime.actions) != 0 {
+		t.Fatalf("actions during grace = %v", runtime.actions)
+	}
+	// Then corrections back off: 10s, 20s, 40s, 80s.
+	for _, wait := range []time.Duration{10 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second} {
+		tick(wait-time.Second, applicationDomain.StatusUndeploy)
+		tick(time.Second, applicationDomain.StatusUndeploy)
+	}
+	if len(runtime.actions) != maxReconcileAttempts {
+		t.Fatalf("actions = %v, want %d ups", runtime.actions, maxReconcileAttempts)
+	}
+	// A deployment that keeps failing is given up on once.
+	tick(10*time.Minute, applicationDomain.StatusUndeploy)
+	tick(10*time.Minute, applicationDomain.StatusUndeploy)
+	if len(runtime.actions) != maxReconcileAttempts || len(events.events) != maxReconcileAttempts+1 {
+		t.Fatalf("actions = %v, events = %+v", runtime.actions, events.events)
+	}
+	first, last := events.events[0], events.events[len(events.events)-1]
+	want := applicationDomain.DriftEvent{ID: 1, DeployID: 7, Observed: applicationDomain.StatusUndeploy,
+		Desired: applicationDomain.DesiredRunning, Action: applicationDomain.ActionStart, Attempt: 1,
+		Error: "exit status 1: Error response from daemon: port is already allocated"}
+	if !reflect.DeepEqual(first, want) {
+		t.Fatalf("first event = %+v\nwant          %+v", first, want)
+	}
+	if last.Action != applicationDomain.ActionGiveUp || last.Attempt != maxReconcileAttempts {
+		t.Fatalf("last event = %+v", last)
+	}
+
+	// Reaching the desired state starts over; a stop is corrected too.
+	tick(time.Second, applicationDomain.StatusRunning)
+	app.Desired = applicationDomain.DesiredStopped
+	tick(time.Second, applicationDomain.StatusRunning)
+	tick(reconcileGrace, applicationDomain.StatusRunning)
+	if runtime.actions[len(runtime.actions)-1] != "stop" {
+		t.Fatalf("actions = %v", runtime.actions)
+	}
+
+	// Deployments without reconciling are never touched.
+	app.Reconcile = false
+	count := len(runtime.actions)
+	tick(time.Hour, applicationDomain.StatusRunning)
+	tick(time.Hour, applicationDomain.StatusRunning)
+	if len(runtime.actions) != count {
+		t.Fatalf("actions = %v", runtime.actions)
+	}
+}
+
+type driftPosterStub struct {
+	reports []driftEventReport
+	fail    bool
+}
+
+func (p *driftPosterStub) Post(_ string, value any, _ httpclient.Header) ([]byte, error) {
+	if p.fail {
+		return nil, errors.New("connection refused")
+	}
+	p.reports = append(p.reports, value.(driftEventReport))
+	return []byte(`{"code":0,"message":"success"}`), nil
+}
+
+func TestReportDriftEvents(t *testing.T) {
+	events := &driftStub{}
+	for _, action := range []string{applicationDomain.ActionStart, applicationDomain.ActionGiveUp} {
+		_ = events.Add(context.Background(), &applicationDomain.DriftEvent{DeployID: 7, Action: action})
+	}
+	poster := &driftPosterStub{fail: true}
+	instance := &Jobs{config: &config.Config{}, drift: events, http: poster}
+
+	instance.reportDriftEvents()
+	if len(events.reported) != 0 {
+		t.Fatalf("reported = %v", events.reported)
+	}
+	poster.fail = false
+	instance.reportDriftEvents()
+	if len(poster.reports) != 2 || poster.reports[1].Action != applicationDomain.ActionGiveUp || !reflect.DeepEqual(events.reported, []uint{1, 2}) {
+		t.Fatalf("reports = %+v, reported = %v", poster.reports, events.reported)
+	}
+}
@@ -3,21 +3,41 @@ package jobs
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"squirrel-dev/internal/pkg/response"
+	applicationDomain "squirrel-dev/internal/squ-agent/module/application/domain"
 	"squirrel-dev/pkg/utils"
 )
 
 const (
 	uriScriptResults = "/scripts/receive-result"
 	uriAppReport     = "/deployment/report"
+	uriDriftReport   = "/deployment/report/drift"
 )
 
+// applicationStatusReport carries the containers of the deployment. They are
+// null when docker could not be queried and an empty list when the project has
+// no containers. Health is the result of the last deploy's health check.
 type applicationStatusReport struct {
-	ApplicationID uint   `json:"application_id"`
-	ServerID      uint   `json:"server_id"`
-	Status        string `json:"status"`
-	DeployID      uint64 `json:"deploy_id"`
+	ApplicationID uint                          `json:"application_id"`
+	ServerID      uint                          `json:"server_id"`
+	Status        string                        `json:"status"`
+	DeployID      uint64                        `json:"deploy_id"`
+	Containers    []applicationDomain.Container `json:"containers"`
+	Health        string                        `json:"health"`
+	HealthMessage string                        `json:"health_message"`
+}
+
+// driftEventReport is one corrective action of the reconcile loop.
+type driftEventReport struct {
+	DeployID  uint64    `json:"deploy_id"`
+	Observed  string    `json:"observed"`
+	Desired   string    `json:"desired"`
+	Action    string    `json:"action"`
+	Attempt   int       `json:"attempt"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type scriptResultReport struct {
@@ -58,3 +78,34 @@ func (j *Jobs) reportScriptResults() {
 		}
 	}
 }
+
+// reportDriftEvents sends drift events in the order they happened and stops
+// at the first one the apiserver does not accept, to retry it next time.
+func (j *Jobs) reportDriftEvents() {
+	ctx := context.Background()
+	events, err := j.drift.Unreported(ctx)
+	if err != nil {
+		return
+	}
+	url := utils.GenAgentUrl(
+		j.config.Apiserver.Http.Scheme,
+		j.config.Apiserver.Http.Server,
+		0,
+		j.config.Apiserver.Http.BaseUri,
+		uriDriftReport,
+	)
+	for _, event := range events {
+		body, err := j.http.Post(url, driftEventReport{
+			DeployID: event.DeployID, Observed: event.Observed, Desired: event.Desired,
+			Action: event.Action, Attempt: event.Attempt, Error: event.Error, CreatedAt: event.CreatedAt,
+		}, nil)
+		if err != nil {
+			return
+		}
+		var result response.Response
+		if err := json.Unmarshal(body, &result); err != nil || result.Code != 0 {
+			return
+		}
+		_ = j.drift.MarkReported(ctx, event.ID)
+	}
+}
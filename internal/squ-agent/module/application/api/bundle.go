@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-agent/module/application/api/req"
+	"squirrel-dev/internal/squ-agent/module/application/api/res"
+	"squirrel-dev/internal/squ-agent/module/application/application"
+)
+
+// chunkChecksumHeader carries the sha256 of the chunk in the request body.
+const chunkChecksumHeader = "X-Chunk-Checksum"
+
+// SaveBundle pulls images and saves them to a bundle on this agent. A failed
+// save carries its reason as data.
+func (h *Handler) SaveBundle(c *gin.Context) {
+	var value req.SaveBundle
+	if err := c.ShouldBindJSON(&value); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(response.ErrCodeParameter))
+		return
+	}
+	bundle, err := h.service.SaveBundle(c.Request.Context(), value.Images, toRegistryAuth(value.Registry))
+	if err != nil {
+		result := response.Error(res.ErrBundleSave)
+		result.Data = err.Error()
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(fromBundle(bundle)))
+}
+
+func (h *Handler) Bundle(c *gin.Context) {
+	bundle, err := h.service.Bundle(c.Param("checksum"))
+	writeResult(c, fromBundle(bundle), err)
+}
+
+// DownloadBundle serves a complete bundle; ranges let a download resume.
+func (h *Handler) DownloadBundle(c *gin.Context) {
+	checksum := c.Param("checksum")
+	path, err := h.service.BundlePath(checksum)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.FileAttachment(path, checksum+".tar")
+}
+
+// AppendBundle takes the chunk of a bundle that starts at the offset query.
+func (h *Handler) AppendBundle(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, response.Error(response.ErrCodeParameter))
+		return
+	}
+	chunk, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, application.MaxBundleChunk))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidChunk))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(response.ErrCodeParameter))
+		return
+	}
+	bundle, err := h.service.AppendBundle(c.Param("checksum"), offset, chunk, c.GetHeader(chunkChecksumHeader))
+	writeResult(c, fromBundle(bundle), err)
+}
+
+func (h *Handler) CompleteBundle(c *gin.Context) {
+	bundle, err := h.service.CompleteBundle(c.Param("checksum"))
+	writeResult(c, fromBundle(bundle), err)
+}
@@ -11,6 +11,7 @@ import (
 	"squirrel-dev/internal/squ-agent/module/application/api/req"
 	"squirrel-dev/internal/squ-agent/module/application/api/res"
 	"squirrel-dev/internal/squ-agent/module/application/application"
+	"squirrel-dev/internal/squ-agent/module/application/domain"
 	"squirrel-dev/pkg/utils"
 )
 
@@ -41,6 +42,20 @@ func deploymentID(c *gin.Context) (uint64, bool) {
 	return value, true
 }
 
+// excludedDeployID reads the optional exclude query; zero excludes nothing.
+func excludedDeployID(c *gin.Context) (uint64, bool) {
+	value := c.Query("exclude")
+	if value == "" {
+		return 0, true
+	}
+	id, err := utils.StringToUint64(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(response.ErrCodeParameter))
+		return 0, false
+	}
+	return id, true
+}
+
 func writeResult(c *gin.Context, data any, err error) {
 	if err != nil {
 		writeError(c, err)
@@ -66,6 +81,26 @@ func writeError(c *gin.Context, err error) {
 		code = res.ErrComposeCreate
 	case errors.Is(err, application.ErrComposeStop):
 		code = res.ErrComposeStop
+	case errors.Is(err, domain.ErrOutputNotFound):
+		code = res.ErrOutputNotFound
+	case errors.Is(err, domain.ErrInvalidLogOptions):
+		code = res.ErrInvalidLogOptions
+	case errors.Is(err, application.ErrComposeFileMissing):
+		code = res.ErrComposeFileMissing
+	case errors.Is(err, application.ErrUnsupportedType):
+		code = res.ErrUnsupportedType
+	case errors.Is(err, application.ErrHostInspect):
+		code = res.ErrHostInspect
+	case errors.Is(err, application.ErrFilesRead):
+		code = res.ErrFilesRead
+	case errors.Is(err, domain.ErrBundleNotFound):
+		code = res.ErrBundleNotFound
+	case errors.Is(err, application.ErrInvalidChunk), errors.Is(err, domain.ErrBundleOffset):
+		code = res.ErrInvalidChunk
+	case errors.Is(err, domain.ErrBundleChecksum):
+		code = res.ErrBundleChecksum
+	case errors.Is(err, application.ErrBundleMissing):
+		code = res.ErrBundleMissing
 	case err == gorm.ErrRecordNotFound:
 		code = response.ErrSQLNotFound
 	case err == gorm.ErrDuplicatedKey:
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-agent/module/application/api/req"
 	"squirrel-dev/internal/squ-agent/module/application/api/res"
 	appService "squirrel-dev/internal/squ-agent/module/application/application"
 )
@@ -65,6 +69,56 @@ func (h *Handler) Update(c *gin.Context) {
 	writeResult(c, "success", err)
 }
 
+// Deployed returns what the agent last deployed under a deploy ID, for
+// previews on the apiserver.
+func (h *Handler) Deployed(c *gin.Context) {
+	deployID, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	value, err := h.service.GetByDeployID(c.Request.Context(), deployID)
+	writeResult(c, toDeployed(value), err)
+}
+
+// Files returns the files of a deployment on disk. Env values stay on the
+// host; only their digests are returned.
+func (h *Handler) Files(c *gin.Context) {
+	deployID, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	app, files, err := h.service.Files(c.Request.Context(), deployID)
+	writeResult(c, toFiles(app, files), err)
+}
+
+// Resources returns the resources in use on the host. The exclude query
+// leaves out those of one deploy ID.
+func (h *Handler) Resources(c *gin.Context) {
+	exclude, ok := excludedDeployID(c)
+	if !ok {
+		return
+	}
+	value, err := h.service.HostResources(exclude)
+	writeResult(c, toHostResources(value), err)
+}
+
+// RegistryLogin checks registry credentials with the docker engine of the
+// host. A failed login carries the registry's reason as data.
+func (h *Handler) RegistryLogin(c *gin.Context) {
+	var value req.Registry
+	if err := c.ShouldBindJSON(&value); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(response.ErrCodeParameter))
+		return
+	}
+	if err := h.service.Login(c.Request.Context(), *toRegistryAuth(&value)); err != nil {
+		result := response.Error(res.ErrRegistryLogin)
+		result.Data = err.Error()
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success("success"))
+}
+
 func (h *Handler) Start(c *gin.Context) {
 	deployID, ok := deploymentID(c)
 	if !ok {
@@ -81,6 +135,21 @@ func (h *Handler) Stop(c *gin.Context) {
 	writeApplicationResult(c, h.service.Stop(c.Request.Context(), deployID))
 }
 
+// Reconcile turns reconciling of a deployment on or off.
+func (h *Handler) Reconcile(c *gin.Context) {
+	deployID, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	var value req.Reconcile
+	if err := c.ShouldBindJSON(&value); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(response.ErrCodeParameter))
+		return
+	}
+	err := h.service.SetReconcile(c.Request.Context(), deployID, value.Enabled)
+	writeResult(c, "success", err)
+}
+
 func (h *Handler) DeleteByDeployID(c *gin.Context) {
 	deployID, ok := deploymentID(c)
 	if !ok {
@@ -2,10 +2,14 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -13,6 +17,7 @@ import (
 	"squirrel-dev/internal/squ-agent/module/application/api/res"
 	appService "squirrel-dev/internal/squ-agent/module/application/application"
 	"squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/internal/squ-agent/module/application/infra"
 )
 
 type fakeRepository struct {
@@ -50,6 +55,38 @@ func (f *fakeRepository) Update(_ context.Context, app *domain.Application) erro
 	}
 	return nil
 }
+func (f *fakeRepository) UpdateHealth(_ context.Context, deployID uint64, health, message string) error {
+	for i := range f.apps {
+		if f.apps[i].DeployID == deployID {
+			f.apps[i].Health, f.apps[i].HealthMessage = health, message
+		}
+	}
+	return nil
+}
+func (f *fakeRepository) UpdateDesired(_ context.Context, deployID uint64, desired string) error {
+	for i := range f.apps {
+		if f.apps[i].DeployID == deployID {
+			f.apps[i].Desired = desired
+		}
+	}
+	return nil
+}
+func (f *fakeRepository) UpdateReconcile(_ context.Context, deployID uint64, reconcile bool) error {
+	for i := range f.apps {
+		if f.apps[i].DeployID == deployID {
+			f.apps[i].Reconcile = reconcile
+		}
+	}
+	return nil
+}
+func (f *fakeRepository) UpdatePushed(_ context.Context, deployID uint64, hashes domain.FileHashes) error {
+	for i := range f.apps {
+		if f.apps[i].DeployID == deployID {
+			f.apps[i].Pushed = hashes
+		}
+	}
+	return nil
+}
 func (f *fakeRepository) Transaction(ctx context.Context, fn func(domain.Repository) error) error {
 	return fn(f)
 }
@@ -63,6 +100,7 @@ type fakeRuntime struct {
 	compose bool
 	exists  bool
 	stopped bool
+	logs    domain.LogOptions
 }
 
 func (f *fakeRuntime) DockerInstalled() bool  { return f.docker }
@@ -70,11 +108,30 @@ func (f *fakeRuntime) ComposeAvailable() bool { return f.compose }
 func (f *fakeRuntime) Prepare(uint64, string, []map[string]string) (string, string, error) {
 	return "/compose/1", "docker-compose.yml", nil
 }
-func (f *fakeRuntime) ComposeFileExists(uint64) bool { return f.exists }
-func (f *fakeRuntime) Up(string, string) error       { return nil }
-func (f *fakeRuntime) Start(string, string) error    { return nil }
-func (f *fakeRuntime) Stop(string, string) error     { f.stopped = true; return nil }
-func (f *fakeRuntime) Path(uint64) string            { return "/compose/1" }
+func (f *fakeRuntime) ComposeFileExists(uint64) bool                      { return f.exists }
+func (f *fakeRuntime) Lock(context.Context, uint64) (func(), error)       { return func() {}, nil }
+func (f *fakeRuntime) Up(context.Context, uint64, domain.Output) error    { return nil }
+func (f *fakeRuntime) Start(context.Context, uint64, domain.Output) error { return nil }
+func (f *fakeRuntime) Stop(context.Context, uint64, domain.Output) error {
+	f.stopped = true
+	return nil
+}
+func (f *fakeRuntime) Images(context.Context, uint64) ([]string, error) { return nil, nil }
+func (f *fakeRuntime) Path(uint64) string                               { return "/compose/1" }
+func (f *fakeRuntime) Snapshot(uint64) (bool, error)                    { return f.exists, nil }
+func (f *fakeRuntime) Restore(uint64) error                             { return nil }
+func (f *fakeRuntime) Files(uint64) (domain.Files, error) {
+	if !f.exists {
+		return domain.Files{}, os.ErrNotExist
+	}
+	return domain.Files{Compose: "services: {}\n", Env: "# edited by hand\nTOKEN=abc\n"}, nil
+}
+func (f *fakeRuntime) Logs(_ context.Context, _ uint64, options domain.LogOptions, output domain.Output) error {
+	f.logs = options
+	_, _ = output.Stdout().Write([]byte("web-1  | listening on :80\nweb-1  | GET /"))
+	_, _ = output.Stderr().Write([]byte("no such service: db\n"))
+	return errors.New("exit status 1")
+}
 
 func TestApplicationHTTPContract(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -86,13 +143,15 @@ func TestApplicationHTTPContract(t *testing.T) {
 		Content: "services: {}", Version: "1.2.3", DeployID: 42,
 		Env: []map[string]string{{"SECRET": "not-returned"}},
 	}}}
-	service := appService.NewService(repository, fakeConfigStore{}, &fakeRuntime{})
+	service := appService.NewService(repository, fakeConfigStore{}, &fakeRuntime{}, infra.NewOutputStore(t.TempDir()), nil, nil, nil, nil, nil)
 	engine := gin.New()
 	RegisterRoutes(engine.Group("/api/v1"), NewHandler(service))
 
 	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application", "", http.StatusOK, `{"code":0,"message":"success","data":[{"id":1,"name":"demo","description":"desc","type":"compose","status":"stopped","content":"services: {}","version":"1.2.3"}]}`)
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/deployed/42", "", http.StatusOK, `{"code":0,"message":"success","data":{"deploy_id":42,"status":"stopped","content":"services: {}","env_digests":{"SECRET":"8f6b9b192da2340808efc6a3b4453873cb52373c3117038c67362b3473adaf09"}}}`)
 	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/bad", "", http.StatusBadRequest, `{"code":41001,"message":"parameter error"}`)
 	assertApplicationRequest(t, engine, http.MethodPost, "/api/v1/application", `{}`, http.StatusOK, `{"code":10001,"message":"docker not installed"}`)
+	assertApplicationRequest(t, engine, http.MethodPost, "/api/v1/application", `{"type":"k8s_manifest"}`, http.StatusOK, `{"code":10009,"message":"application type is not supported by the agent"}`)
 }
 
 func TestDeleteRunningApplicationPreservesLegacyStopWithoutDelete(t *testing.T) {
@@ -104,7 +163,7 @@ func TestDeleteRunningApplicationPreservesLegacyStopWithoutDelete(t *testing.T)
 		ID: 1, Name: "demo", Status: domain.StatusRunning, DeployID: 42,
 	}}}
 	runtime := &fakeRuntime{exists: true}
-	service := appService.NewService(repository, fakeConfigStore{}, runtime)
+	service := appService.NewService(repository, fakeConfigStore{}, runtime, infra.NewOutputStore(t.TempDir()), nil, nil, nil, nil, nil)
 	engine := gin.New()
 	RegisterRoutes(engine.Group("/api/v1"), NewHandler(service))
 
@@ -117,6 +176,134 @@ func TestDeleteRunningApplicationPreservesLegacyStopWithoutDelete(t *testing.T)
 	}
 }
 
+func TestOutputStreamFollowsRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	res.RegisterCode()
+
+	outputs := infra.NewOutputStore(t.TempDir())
+	service := appService.NewService(&fakeRepository{}, fakeConfigStore{}, &fakeRuntime{}, outputs, nil, nil, nil, nil, nil)
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/api/v1"), NewHandler(service))
+
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/output/42", "", http.StatusOK, `{"code":10006,"message":"no compose output recorded for this deployment"}`)
+
+	recorder := outputs.Begin(42, "up")
+	_, _ = recorder.Stderr().Write([]byte("pulling web\n"))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = recorder.Stderr().Write([]byte("Error: port is already allocated\n"))
+		recorder.Finish(errors.New("exit status 1"))
+	}()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/application/output/42/stream", nil)
+	writer := httptest.NewRecorder()
+	engine.ServeHTTP(writer, request)
+	body := writer.Body.String()
+	for _, expected := range []string{
+		"event:run\ndata:{\"deploy_id\":42,\"action\":\"up\",\"status\":\"running\"",
+		`"stream":"stderr","text":"pulling web"`,
+		`"text":"Error: port is already allocated"`,
+		"event:end\ndata:{\"deploy_id\":42,\"action\":\"up\",\"status\":\"failed\",\"error\":\"exit status 1\"",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("stream %q does not contain %q", body, expected)
+		}
+	}
+	if !strings.HasPrefix(writer.Header().Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("content type = %q", writer.Header().Get("Content-Type"))
+	}
+}
+
+func TestLogsStreamsLines(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	res.RegisterCode()
+
+	repository := &fakeRepository{apps: []domain.Application{{ID: 1, Name: "demo", DeployID: 42}}}
+	runtime := &fakeRuntime{exists: true}
+	service := appService.NewService(repository, fakeConfigStore{}, runtime, infra.NewOutputStore(t.TempDir()), nil, nil, nil, nil, nil)
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/api/v1"), NewHandler(service))
+
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/logs/42?tail=-1", "", http.StatusOK, `{"code":10007,"message":"invalid log options"}`)
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/logs/42?service=--help", "", http.StatusOK, `{"code":10007,"message":"invalid log options"}`)
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/logs/42?since=yesterday", "", http.StatusOK, `{"code":10007,"message":"invalid log options"}`)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/application/logs/42?service=web&service=db&tail=100&since=10m&until=2026-01-02T03:04:05Z&timestamps=true&follow=true", nil)
+	writer := httptest.NewRecorder()
+	engine.ServeHTTP(writer, request)
+	want := domain.LogOptions{Services: []string{"web", "db"}, Tail: 100, Since: "10m", Until: "2026-01-02T03:04:05Z", Timestamps: true, Follow: true}
+	if !reflect.DeepEqual(runtime.logs, want) {
+		t.Fatalf("options = %#v, want %#v", runtime.logs, want)
+	}
+	body := writer.Body.String()
+	for _, expected := range []string{
+		"event:line\ndata:{\"stream\":\"stdout\",\"text\":\"web-1  | listening on :80\"}",
+		`"stream":"stderr","text":"no such service: db"`,
+		`"stream":"stdout","text":"web-1  | GET /"`,
+		"event:end\ndata:{\"error\":\"exit status 1\"}",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("stream %q does not contain %q", body, expected)
+		}
+	}
+
+	runtime.exists = false
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/logs/42", "", http.StatusOK, `{"code":10008,"message":"docker-compose file not found"}`)
+}
+
+type hostStub struct{ excluded uint64 }
+
+func (h *hostStub) Resources(exclude uint64) (domain.HostResources, error) {
+	h.excluded = exclude
+	return domain.HostResources{
+		Listeners:  []domain.Listener{{Protocol: "tcp", IP: "0.0.0.0", Port: 80}},
+		Containers: []string{"db"},
+		Volumes:    []string{"data"},
+		Networks:   []string{"bridge"},
+	}, nil
+}
+
+func TestResourcesReportsHostState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	res.RegisterCode()
+
+	host := &hostStub{}
+	service := appService.NewService(&fakeRepository{}, fakeConfigStore{}, &fakeRuntime{}, infra.NewOutputStore(t.TempDir()), nil, host, nil, nil, nil)
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/api/v1"), NewHandler(service))
+
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/resources?exclude=web", "", http.StatusBadRequest, `{"code":41001,"message":"parameter error"}`)
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/resources?exclude=42", "", http.StatusOK,
+		`{"code":0,"message":"success","data":{"listeners":[{"protocol":"tcp","ip":"0.0.0.0","port":80}],"containers":["db"],"volumes":["data"],"networks":["bridge"]}}`)
+	if host.excluded != 42 {
+		t.Fatalf("excluded = %d, want 42", host.excluded)
+	}
+}
+
+func TestFilesReturnsDigestsOfEnvValues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	res.RegisterCode()
+
+	repository := &fakeRepository{apps: []domain.Application{{ID: 1, DeployID: 7, Pushed: domain.FileHashes{Compose: "c1", Env: "e1"}}}}
+	runtime := &fakeRuntime{exists: true}
+	service := appService.NewService(repository, fakeConfigStore{}, runtime, infra.NewOutputStore(t.TempDir()), nil, nil, nil, nil, nil)
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/api/v1"), NewHandler(service))
+
+	files, _ := runtime.Files(7)
+	hashes := files.Hashes()
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/files/7", "", http.StatusOK,
+		`{"code":0,"message":"success","data":{"deploy_id":7,"content":"services: {}\n",`+
+			`"env_digests":{"TOKEN":"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},`+
+			`"compose_hash":"`+hashes.Compose+`","env_hash":"`+hashes.Env+`","pushed_compose_hash":"c1","pushed_env_hash":"e1"}}`)
+	runtime.exists = false
+	assertApplicationRequest(t, engine, http.MethodGet, "/api/v1/application/files/7", "", http.StatusOK,
+		`{"code":10008,"message":"docker-compose file not found"}`)
+}
+
 func assertApplicationRequest(t *testing.T, engine http.Handler, method, path, body string, status int, expected string) {
 	t.Helper()
 	request := httptest.NewRequest(method, path, strings.NewReader(body))
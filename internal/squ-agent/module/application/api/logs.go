@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/application/api/req"
+	"squirrel-dev/internal/squ-agent/module/application/api/res"
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+// maxLogLine splits longer log lines so one runaway line cannot grow the
+// buffer without bound.
+const maxLogLine = 64 << 10
+
+// Logs streams container logs as server-sent events: one "line" event per
+// log line and a final "end" event. Without follow the stream ends with the
+// logs; with follow it ends when the client disconnects or the containers go
+// away.
+func (h *Handler) Logs(c *gin.Context) {
+	deployID, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	options, err := logOptions(c)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	run, err := h.service.Logs(c.Request.Context(), deployID, options)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	events := &eventWriter{c: c}
+	stdout := &logWriter{events: events, stream: domain.OutputStdout}
+	stderr := &logWriter{events: events, stream: domain.OutputStderr}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(outputKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				events.comment("keep-alive")
+			case <-done:
+				return
+			}
+		}
+	}()
+	err = run(logOutput{stdout: stdout, stderr: stderr})
+	close(done)
+	stdout.flush()
+	stderr.flush()
+	end := res.LogEnd{}
+	if err != nil {
+		zap.L().Warn("docker compose logs failed", zap.Uint64("deploy_id", deployID), zap.Error(err))
+		end.Error = err.Error()
+	}
+	events.event("end", end)
+}
+
+func logOptions(c *gin.Context) (domain.LogOptions, error) {
+	var query req.Logs
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return domain.LogOptions{}, domain.ErrInvalidLogOptions
+	}
+	tail := -1
+	if query.Tail != "" && query.Tail != "all" {
+		value, err := strconv.Atoi(query.Tail)
+		if err != nil || value < 0 {
+			return domain.LogOptions{}, domain.ErrInvalidLogOptions
+		}
+		tail = value
+	}
+	return domain.LogOptions{
+		Services:   query.Service,
+		Tail:       tail,
+		Since:      query.Since,
+		Until:      query.Until,
+		Timestamps: query.Timestamps,
+		Follow:     query.Follow,
+	}, nil
+}
+
+// eventWriter serialises events from the stdout and stderr writers and the
+// keep-alive ticker.
+type eventWriter struct {
+	mu sync.Mutex
+	c  *gin.Context
+}
+
+func (w *eventWriter) event(name string, data any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.c.SSEvent(name, data)
+	w.c.Writer.Flush()
+}
+
+func (w *eventWriter) comment(text string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, _ = w.c.Writer.WriteString(": " + text + "\n\n")
+	w.c.Writer.Flush()
+}
+
+type logOutput struct {
+	stdout, stderr *logWriter
+}
+
+func (o logOutput) Stdout() io.Writer { return o.stdout }
+func (o logOutput) Stderr() io.Writer { return o.stderr }
+
+// logWriter sends every complete line written to it as a "line" event.
+type logWriter struct {
+	events *eventWriter
+	stream string
+	buf    []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.send(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	for len(w.buf) >= maxLogLine {
+		w.send(w.buf[:maxLogLine])
+		w.buf = w.buf[maxLogLine:]
+	}
+	return len(p), nil
+}
+
+func (w *logWriter) flush() {
+	if len(w.buf) > 0 {
+		w.send(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *logWriter) send(line []byte) {
+	w.events.event("line", res.LogLine{Stream: w.stream, Text: string(bytes.TrimSuffix(line, []byte("\r")))})
+}
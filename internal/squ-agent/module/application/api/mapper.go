@@ -1,6 +1,10 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
 	"squirrel-dev/internal/squ-agent/module/application/api/req"
 	"squirrel-dev/internal/squ-agent/module/application/api/res"
 	"squirrel-dev/internal/squ-agent/module/application/application"
@@ -19,17 +23,107 @@ func toApplicationRequest(value req.Application) application.Request {
 		ServerID:    value.ServerID,
 		DeployID:    value.DeployID,
 		Env:         value.Env,
+		Secrets:     value.Secrets,
+		HealthCheck: toHealthCheck(value.HealthCheck),
+		Reconcile:   value.Reconcile,
+		Registry:    toRegistryAuth(value.Registry),
+		Bundle:      value.Bundle,
 	}
 }
 
+func toRegistryAuth(value *req.Registry) *domain.RegistryAuth {
+	if value == nil {
+		return nil
+	}
+	return &domain.RegistryAuth{Server: value.Server, Username: value.Username, Password: value.Password}
+}
+
+func toHealthCheck(value *req.HealthCheck) *domain.HealthCheck {
+	if value == nil {
+		return nil
+	}
+	result := &domain.HealthCheck{Timeout: value.Timeout}
+	for _, check := range value.Checks {
+		result.Checks = append(result.Checks, domain.Check{
+			Type: check.Type, URL: check.URL, Status: check.Status, Address: check.Address,
+		})
+	}
+	return result
+}
+
 func fromDomain(value domain.Application) res.Application {
 	return res.Application{
-		ID:          value.ID,
-		Name:        value.Name,
-		Description: value.Description,
-		Type:        value.Type,
-		Status:      value.Status,
-		Content:     value.Content,
-		Version:     value.Version,
+		ID:            value.ID,
+		Name:          value.Name,
+		Description:   value.Description,
+		Type:          value.Type,
+		Status:        value.Status,
+		Content:       value.Content,
+		Version:       value.Version,
+		Health:        value.Health,
+		HealthMessage: value.HealthMessage,
+		Desired:       value.Desired,
+		Reconcile:     value.Reconcile,
 	}
 }
+
+// toDeployed digests env values; they can be credentials and the apiserver
+// only needs to know which ones changed.
+func toDeployed(value domain.Application) res.Deployed {
+	result := res.Deployed{DeployID: value.DeployID, Status: value.Status, Content: value.Content,
+		EnvDigests: map[string]string{}}
+	for _, item := range value.Env {
+		for key, env := range item {
+			sum := sha256.Sum256([]byte(env))
+			result.EnvDigests[key] = hex.EncodeToString(sum[:])
+		}
+	}
+	return result
+}
+
+func toFiles(app domain.Application, files domain.Files) res.Files {
+	hashes := files.Hashes()
+	result := res.Files{DeployID: app.DeployID, Content: files.Compose, EnvDigests: map[string]string{},
+		ComposeHash: hashes.Compose, EnvHash: hashes.Env,
+		PushedComposeHash: app.Pushed.Compose, PushedEnvHash: app.Pushed.Env}
+	for key, value := range files.EnvValues() {
+		sum := sha256.Sum256([]byte(value))
+		result.EnvDigests[key] = hex.EncodeToString(sum[:])
+	}
+	return result
+}
+
+func toHostResources(value domain.HostResources) res.HostResources {
+	result := res.HostResources{Listeners: []res.Listener{}, Containers: value.Containers,
+		Volumes: value.Volumes, Networks: value.Networks}
+	for _, listener := range value.Listeners {
+		result.Listeners = append(result.Listeners, res.Listener{Protocol: listener.Protocol, IP: listener.IP, Port: listener.Port})
+	}
+	return result
+}
+
+func fromRun(value domain.Run) res.Run {
+	result := res.Run{
+		DeployID:  value.DeployID,
+		Action:    value.Action,
+		Status:    value.Status,
+		Error:     value.Error,
+		StartedAt: value.StartedAt.Format(time.RFC3339Nano),
+		Truncated: value.Truncated,
+	}
+	if value.FinishedAt != nil {
+		result.FinishedAt = value.FinishedAt.Format(time.RFC3339Nano)
+	}
+	for _, line := range value.Lines {
+		result.Lines = append(result.Lines, fromOutputLine(line))
+	}
+	return result
+}
+
+func fromOutputLine(value domain.OutputLine) res.OutputLine {
+	return res.OutputLine{Time: value.Time.Format(time.RFC3339Nano), Stream: value.Stream, Text: value.Text}
+}
+
+func fromBundle(value domain.Bundle) res.Bundle {
+	return res.Bundle{Checksum: value.Checksum, Size: value.Size, Complete: value.Complete}
+}
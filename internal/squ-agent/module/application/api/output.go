@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// outputKeepAlive is how often an idle output stream sends an SSE comment so
+// that proxies in between do not close it.
+const outputKeepAlive = 15 * time.Second
+
+func (h *Handler) Output(c *gin.Context) {
+	deployID, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	run, err := h.service.Output(deployID)
+	writeResult(c, fromRun(run), err)
+}
+
+// OutputStream sends the current or last compose run as server-sent events:
+// a "run" event with the run's metadata, one "line" event per output line
+// and an "end" event with the final metadata. An "end" event whose status is
+// still running means the client fell behind and should reconnect.
+func (h *Handler) OutputStream(c *gin.Context) {
+	deployID, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	run, lines, cancel, err := h.service.WatchOutput(deployID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	meta := fromRun(run)
+	meta.Lines = nil
+	c.SSEvent("run", meta)
+	for _, line := range run.Lines {
+		c.SSEvent("line", fromOutputLine(line))
+	}
+	if lines == nil {
+		c.SSEvent("end", meta)
+		c.Writer.Flush()
+		return
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(outputKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				final, err := h.service.Output(deployID)
+				if err != nil {
+					final = run
+				}
+				meta := fromRun(final)
+				meta.Lines = nil
+				c.SSEvent("end", meta)
+				c.Writer.Flush()
+				return
+			}
+			c.SSEvent("line", fromOutputLine(line))
+			c.Writer.Flush()
+		case <-ticker.C:
+			_, _ = c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -11,4 +11,53 @@ type Application struct {
 	ServerID    uint                `json:"server_id"`
 	DeployID    uint64              `json:"deploy_id"`
 	Env         []map[string]string `json:"env"`
+	Secrets     map[string]string   `json:"secrets"`
+	HealthCheck *HealthCheck        `json:"health_check"`
+	Reconcile   bool                `json:"reconcile"`
+	Registry    *Registry           `json:"registry"`
+	// Bundle is the checksum of the image bundle to load before up.
+	Bundle string `json:"bundle"`
+}
+
+// Registry holds the credentials of a private registry. Server is the
+// registry host, with an optional port.
+type Registry struct {
+	Server   string `json:"server" binding:"required"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Reconcile turns restoring the desired state of a deployment on or off.
+type Reconcile struct {
+	Enabled bool `json:"enabled"`
+}
+
+// HealthCheck is verified after the deploy. Timeout is in seconds.
+type HealthCheck struct {
+	Timeout int     `json:"timeout"`
+	Checks  []Check `json:"checks"`
+}
+
+type Check struct {
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Status  int    `json:"status"`
+	Address string `json:"address"`
+}
+
+// Logs holds the query of a log request. Tail is a line count or "all".
+type Logs struct {
+	Service    []string `form:"service"`
+	Tail       string   `form:"tail"`
+	Since      string   `form:"since"`
+	Until      string   `form:"until"`
+	Timestamps bool     `form:"timestamps"`
+	Follow     bool     `form:"follow"`
+}
+
+// SaveBundle pulls Images, with the credentials of Registry for its own
+// images, and saves them to a bundle.
+type SaveBundle struct {
+	Images   []string  `json:"images" binding:"required"`
+	Registry *Registry `json:"registry"`
 }
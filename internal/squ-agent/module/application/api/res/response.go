@@ -8,4 +8,85 @@ type Application struct {
 	Status      string `json:"status"`
 	Content     string `json:"content"`
 	Version     string `json:"version"`
+	// Health is the result of the health check of the last deploy.
+	Health        string `json:"health,omitempty"`
+	HealthMessage string `json:"health_message,omitempty"`
+	// Desired is the state reconciling restores when Reconcile is set.
+	Desired   string `json:"desired,omitempty"`
+	Reconcile bool   `json:"reconcile,omitempty"`
+}
+
+// Deployed is the compose project an agent last deployed. EnvDigests holds
+// the SHA-256 of each env value instead of the value.
+type Deployed struct {
+	DeployID   uint64            `json:"deploy_id"`
+	Status     string            `json:"status"`
+	Content    string            `json:"content"`
+	EnvDigests map[string]string `json:"env_digests"`
+}
+
+// Files are the compose file of a deployment as it is on disk and the
+// SHA-256 of each value of its env file, with the hashes of both files now
+// and as the agent last wrote them.
+type Files struct {
+	DeployID          uint64            `json:"deploy_id"`
+	Content           string            `json:"content"`
+	EnvDigests        map[string]string `json:"env_digests"`
+	ComposeHash       string            `json:"compose_hash"`
+	EnvHash           string            `json:"env_hash"`
+	PushedComposeHash string            `json:"pushed_compose_hash"`
+	PushedEnvHash     string            `json:"pushed_env_hash"`
+}
+
+// HostResources are the listening sockets and docker objects of a host.
+type HostResources struct {
+	Listeners  []Listener `json:"listeners"`
+	Containers []string   `json:"containers"`
+	Volumes    []string   `json:"volumes"`
+	Networks   []string   `json:"networks"`
+}
+
+type Listener struct {
+	Protocol string `json:"protocol"`
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+}
+
+// OutputLine is one line of compose output.
+type OutputLine struct {
+	Time   string `json:"time"`
+	Stream string `json:"stream"`
+	Text   string `json:"text"`
+}
+
+// Run is the output of one compose action. Lines is omitted from the run
+// event of an output stream, which sends every line as its own event.
+type Run struct {
+	DeployID   uint64       `json:"deploy_id"`
+	Action     string       `json:"action"`
+	Status     string       `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	StartedAt  string       `json:"started_at"`
+	FinishedAt string       `json:"finished_at,omitempty"`
+	Truncated  bool         `json:"truncated,omitempty"`
+	Lines      []OutputLine `json:"lines,omitempty"`
+}
+
+// LogLine is one line of container logs.
+type LogLine struct {
+	Stream string `json:"stream"`
+	Text   string `json:"text"`
+}
+
+// LogEnd closes a log stream. Error is set when docker compose failed.
+type LogEnd struct {
+	Error string `json:"error,omitempty"`
+}
+
+// Bundle is the state of an image bundle on the agent. Size counts the bytes
+// received so far; a transfer continues from there.
+type Bundle struct {
+	Checksum string `json:"checksum"`
+	Size     int64  `json:"size"`
+	Complete bool   `json:"complete"`
 }
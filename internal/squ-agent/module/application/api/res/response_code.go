@@ -8,6 +8,18 @@ const (
 	ErrComposeStart       = 10003
 	ErrComposeCreate      = 10004
 	ErrComposeStop        = 10005
+	ErrOutputNotFound     = 10006
+	ErrInvalidLogOptions  = 10007
+	ErrComposeFileMissing = 10008
+	ErrUnsupportedType    = 10009
+	ErrHostInspect        = 10010
+	ErrFilesRead          = 10011
+	ErrRegistryLogin      = 10012
+	ErrBundleNotFound     = 10013
+	ErrInvalidChunk       = 10014
+	ErrBundleChecksum     = 10015
+	ErrBundleSave         = 10016
+	ErrBundleMissing      = 10017
 )
 
 func RegisterCode() {
@@ -16,4 +28,16 @@ func RegisterCode() {
 	response.Register(ErrComposeStart, "docker-compose start failed")
 	response.Register(ErrComposeCreate, "docker-compose file creation failed")
 	response.Register(ErrComposeStop, "docker-compose stop failed")
+	response.Register(ErrOutputNotFound, "no compose output recorded for this deployment")
+	response.Register(ErrInvalidLogOptions, "invalid log options")
+	response.Register(ErrComposeFileMissing, "docker-compose file not found")
+	response.Register(ErrUnsupportedType, "application type is not supported by the agent")
+	response.Register(ErrHostInspect, "failed to inspect host resources")
+	response.Register(ErrFilesRead, "failed to read deployment files")
+	response.Register(ErrRegistryLogin, "registry login failed")
+	response.Register(ErrBundleNotFound, "image bundle not found")
+	response.Register(ErrInvalidChunk, "invalid image bundle chunk")
+	response.Register(ErrBundleChecksum, "image bundle checksum mismatch")
+	response.Register(ErrBundleSave, "failed to save image bundle")
+	response.Register(ErrBundleMissing, "image bundle is not on this agent")
 }
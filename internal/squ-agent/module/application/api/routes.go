@@ -11,4 +11,17 @@ func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
 	group.POST("/application/start/:deployId", handler.Start)
 	group.POST("/application/stop/:deployId", handler.Stop)
 	group.POST("/application/delete/:deployId", handler.DeleteByDeployID)
+	group.POST("/application/reconcile/:deployId", handler.Reconcile)
+	group.GET("/application/output/:deployId", handler.Output)
+	group.GET("/application/output/:deployId/stream", handler.OutputStream)
+	group.GET("/application/logs/:deployId", handler.Logs)
+	group.GET("/application/deployed/:deployId", handler.Deployed)
+	group.GET("/application/files/:deployId", handler.Files)
+	group.GET("/application/resources", handler.Resources)
+	group.POST("/application/registry/login", handler.RegistryLogin)
+	group.POST("/application/bundle/save", handler.SaveBundle)
+	group.GET("/application/bundle/:checksum", handler.Bundle)
+	group.GET("/application/bundle/:checksum/download", handler.DownloadBundle)
+	group.POST("/application/bundle/:checksum/chunk", handler.AppendBundle)
+	group.POST("/application/bundle/:checksum/complete", handler.CompleteBundle)
 }
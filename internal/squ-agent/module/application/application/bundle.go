@@ -0,0 +1,145 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+var (
+	ErrBundleMissing = errors.New("image bundle is not on this agent")
+	ErrBundleSave    = errors.New("failed to save image bundle")
+	ErrInvalidChunk  = errors.New("invalid image bundle chunk")
+)
+
+// MaxBundleChunk bounds the chunks of a bundle transfer, which are held in
+// memory while their checksum is checked.
+const MaxBundleChunk = 64 << 20
+
+// SaveBundle pulls images and saves them to a bundle, for a host without
+// registry access to load. Images of the private registry auth are pulled
+// with it; the others are pulled anonymously.
+func (s *Service) SaveBundle(ctx context.Context, images []string, auth *domain.RegistryAuth) (domain.Bundle, error) {
+	if s.registry == nil || s.archiver == nil || s.bundles == nil {
+		return domain.Bundle{}, fmt.Errorf("%w: %v", ErrBundleSave, errNoDockerClient)
+	}
+	if len(images) == 0 {
+		return domain.Bundle{}, fmt.Errorf("%w: no images", ErrBundleSave)
+	}
+	if auth != nil {
+		if err := s.registry.Login(ctx, *auth); err != nil {
+			return domain.Bundle{}, fmt.Errorf("%w: login to %s failed: %v", ErrBundleSave, auth.Host(), err)
+		}
+	}
+	for _, image := range images {
+		credentials := domain.RegistryAuth{}
+		if auth != nil && auth.Serves(image) {
+			credentials = *auth
+		}
+		if err := s.registry.Pull(ctx, image, credentials, discardOutput{}); err != nil {
+			zap.L().Warn("failed to pull image for bundle", zap.String("image", image), zap.Error(err))
+			return domain.Bundle{}, fmt.Errorf("%w: failed to pull %s: %v", ErrBundleSave, image, err)
+		}
+	}
+	bundle, err := s.bundles.Save(func(path string) error { return s.archiver.Save(ctx, images, path) })
+	if err != nil {
+		zap.L().Error("failed to save image bundle", zap.Strings("images", images), zap.Error(err))
+		return domain.Bundle{}, fmt.Errorf("%w: %v", ErrBundleSave, err)
+	}
+	zap.L().Info("image bundle saved",
+		zap.String("checksum", bundle.Checksum),
+		zap.Int64("size", bundle.Size),
+		zap.Strings("images", images),
+	)
+	return bundle, nil
+}
+
+func (s *Service) Bundle(checksum string) (domain.Bundle, error) {
+	if s.bundles == nil {
+		return domain.Bundle{}, domain.ErrBundleNotFound
+	}
+	return s.bundles.Get(checksum)
+}
+
+// BundlePath returns the file of a complete bundle for download.
+func (s *Service) BundlePath(checksum string) (string, error) {
+	if s.bundles == nil {
+		return "", domain.ErrBundleNotFound
+	}
+	return s.bundles.Path(checksum)
+}
+
+// AppendBundle adds a chunk of a bundle transfer after checking it against
+// chunkChecksum, the sha256 of the chunk.
+func (s *Service) AppendBundle(checksum string, offset int64, chunk []byte, chunkChecksum string) (domain.Bundle, error) {
+	if s.bundles == nil {
+		return domain.Bundle{}, domain.ErrBundleNotFound
+	}
+	if len(chunk) == 0 || len(chunk) > MaxBundleChunk {
+		return domain.Bundle{}, fmt.Errorf("%w: %d bytes", ErrInvalidChunk, len(chunk))
+	}
+	sum := sha256.Sum256(chunk)
+	if actual := hex.EncodeToString(sum[:]); actual != chunkChecksum {
+		return domain.Bundle{}, fmt.Errorf("%w: checksum %s, want %s", ErrInvalidChunk, actual, chunkChecksum)
+	}
+	return s.bundles.Append(checksum, offset, chunk)
+}
+
+func (s *Service) CompleteBundle(checksum string) (domain.Bundle, error) {
+	if s.bundles == nil {
+		return domain.Bundle{}, domain.ErrBundleNotFound
+	}
+	bundle, err := s.bundles.Complete(checksum)
+	if err != nil {
+		zap.L().Warn("image bundle is incomplete", zap.String("checksum", checksum), zap.Error(err))
+		return bundle, err
+	}
+	zap.L().Info("image bundle received", zap.String("checksum", checksum), zap.Int64("size", bundle.Size))
+	return bundle, nil
+}
+
+// checkBundle refuses a deploy whose bundle has not arrived, before any of
+// its files are written.
+func (s *Service) checkBundle(checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	if s.archiver == nil {
+		return fmt.Errorf("%w: %v", ErrBundleMissing, errNoDockerClient)
+	}
+	if _, err := s.BundlePath(checksum); err != nil {
+		return fmt.Errorf("%w: %s", ErrBundleMissing, checksum)
+	}
+	return nil
+}
+
+// loadBundle loads the images of a bundle into docker so that compose up
+// finds them without a registry.
+func (s *Service) loadBundle(ctx context.Context, checksum string, output domain.Output) error {
+	if checksum == "" {
+		return nil
+	}
+	if err := s.checkBundle(checksum); err != nil {
+		return err
+	}
+	path, _ := s.bundles.Path(checksum)
+	fmt.Fprintf(output.Stdout(), "loading image bundle %s\n", checksum)
+	if err := s.archiver.Load(ctx, path, output); err != nil {
+		return fmt.Errorf("failed to load image bundle %s: %w", checksum, err)
+	}
+	return nil
+}
+
+// discardOutput drops the progress of the pulls of a bundle; their errors
+// carry what matters.
+type discardOutput struct{}
+
+func (discardOutput) Stdout() io.Writer { return io.Discard }
+func (discardOutput) Stderr() io.Writer { return io.Discard }
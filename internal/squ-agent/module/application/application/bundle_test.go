@@ -0,0 +1,91 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/internal/squ-agent/module/application/infra"
+)
+
+// archiverStub writes the image names as the archive and records loads
+// together with the ups of runtime, to check their order.
+type archiverStub struct {
+	mu      sync.Mutex
+	runtime *runtimeStub
+	events  []string
+}
+
+func (a *archiverStub) Save(_ context.Context, images []string, path string) error {
+	return os.WriteFile(path, []byte(fmt.Sprint(images)), 0o600)
+}
+func (a *archiverStub) Load(_ context.Context, path string, _ domain.Output) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.runtime.mu.Lock()
+	defer a.runtime.mu.Unlock()
+	a.events = append(a.events, fmt.Sprintf("load %d ups", len(a.runtime.ups)))
+	return nil
+}
+
+func TestDeployLoadsBundleBeforeUp(t *testing.T) {
+	repository := &repositoryStub{}
+	runtime := &runtimeStub{}
+	archiver := &archiverStub{runtime: runtime}
+	bundles := infra.NewBundleStore(t.TempDir())
+	service := NewService(repository, configStub{}, runtime, infra.NewOutputStore(t.TempDir()), nil, nil, &registryStub{}, bundles, archiver)
+	ctx := context.Background()
+
+	content := []byte("nginx:1.27")
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	if result := service.Add(ctx, Request{Name: "web", DeployID: 9, Content: "nginx:1.27", Bundle: checksum}); !errors.Is(result.Err, ErrBundleMissing) {
+		t.Fatalf("Add() before the bundle arrived error = %v", result.Err)
+	}
+
+	if _, err := service.AppendBundle(checksum, 0, content, "0000"); !errors.Is(err, ErrInvalidChunk) {
+		t.Fatalf("AppendBundle() with a wrong chunk checksum error = %v", err)
+	}
+	if _, err := service.AppendBundle(checksum, 0, content, checksum); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CompleteBundle(checksum); err != nil {
+		t.Fatal(err)
+	}
+	if result := service.Add(ctx, Request{Name: "web", DeployID: 9, Content: "nginx:1.27", Bundle: checksum}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	waitStatus(t, repository, 9, func(domain.Application) bool {
+		run, err := service.Output(9)
+		return err == nil && run.Status == domain.RunSucceeded
+	})
+	archiver.mu.Lock()
+	defer archiver.mu.Unlock()
+	if !slices.Equal(archiver.events, []string{"load 0 ups"}) || len(runtime.ups) != 1 {
+		t.Fatalf("events = %v, ups = %v", archiver.events, runtime.ups)
+	}
+}
+
+func TestSaveBundlePullsImages(t *testing.T) {
+	registry := &registryStub{}
+	bundles := infra.NewBundleStore(t.TempDir())
+	service := NewService(&repositoryStub{}, configStub{}, &runtimeStub{}, infra.NewOutputStore(t.TempDir()), nil, nil, registry, bundles, &archiverStub{})
+
+	bundle, err := service.SaveBundle(context.Background(), []string{"nginx:1.27", "redis:7"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bundle.Complete || !slices.Equal(registry.pulls, []string{"nginx:1.27", "redis:7"}) {
+		t.Fatalf("bundle = %+v, pulls = %v", bundle, registry.pulls)
+	}
+	if path, err := service.BundlePath(bundle.Checksum); err != nil || path == "" {
+		t.Fatalf("path = %q, err = %v", path, err)
+	}
+}
@@ -0,0 +1,40 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+// Files returns a deployment with its files as they are on disk.
+func (s *Service) Files(ctx context.Context, deployID uint64) (domain.Application, domain.Files, error) {
+	app, err := s.repository.GetByDeployID(ctx, deployID)
+	if err != nil {
+		return domain.Application{}, domain.Files{}, err
+	}
+	files, err := s.runtime.Files(deployID)
+	if errors.Is(err, os.ErrNotExist) {
+		return domain.Application{}, domain.Files{}, ErrComposeFileMissing
+	}
+	if err != nil {
+		return domain.Application{}, domain.Files{}, fmt.Errorf("%w: %v", ErrFilesRead, err)
+	}
+	return app, files, nil
+}
+
+// recordPushed remembers the files just written for a deployment so that
+// later edits on the host show up as drift.
+func (s *Service) recordPushed(ctx context.Context, deployID uint64) {
+	files, err := s.runtime.Files(deployID)
+	if err == nil {
+		err = s.repository.UpdatePushed(ctx, deployID, files.Hashes())
+	}
+	if err != nil {
+		zap.L().Error("failed to record deployment files", zap.Uint64("deploy_id", deployID), zap.Error(err))
+	}
+}
@@ -0,0 +1,103 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+// healthInterval is the pause between two rounds of health checks.
+var healthInterval = 2 * time.Second
+
+// snapshot keeps the files of the running deployment before a redeploy with
+// a health check overwrites them and returns the application to restore if
+// the new one turns out unhealthy.
+func (s *Service) snapshot(previous domain.Application, check *domain.HealthCheck) (*domain.Application, error) {
+	if check == nil {
+		return nil, nil
+	}
+	kept, err := s.runtime.Snapshot(previous.DeployID)
+	if err != nil || !kept {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+// waitHealthy runs all checks every healthInterval until they pass together
+// or the timeout of check ends, and returns the last failure.
+func (s *Service) waitHealthy(ctx context.Context, deployID uint64, check domain.HealthCheck, output domain.Output) error {
+	ctx, cancel := context.WithTimeout(ctx, check.Duration())
+	defer cancel()
+	for {
+		err := s.checkOnce(ctx, deployID, check.Checks)
+		if err == nil {
+			fmt.Fprintln(output.Stdout(), "health checks passed")
+			return nil
+		}
+		fmt.Fprintf(output.Stderr(), "health check failed: %v\n", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("health checks did not pass within %s: %w", check.Duration(), err)
+		case <-time.After(healthInterval):
+		}
+	}
+}
+
+func (s *Service) checkOnce(ctx context.Context, deployID uint64, checks []domain.Check) error {
+	var errs []error
+	for _, check := range checks {
+		if err := s.health.Check(ctx, deployID, check); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// rollback brings back the previous deployment after the one with deployID
+// failed its health check and records why. Without a previous deployment
+// the application is only marked failed. The caller holds the lock of the
+// deployment.
+func (s *Service) rollback(ctx context.Context, deployID uint64, previous *domain.Application, cause error) {
+	reason := cause.Error()
+	zap.L().Warn("deployment is unhealthy", zap.Uint64("deploy_id", deployID), zap.Error(cause))
+	if previous == nil {
+		s.setHealth(ctx, deployID, domain.HealthUnhealthy, reason+"; no previous deployment to restore")
+		s.updateStatusToFailed(ctx, deployID)
+		return
+	}
+	err := s.compose(deployID, "rollback", func(output domain.Output) error {
+		fmt.Fprintf(output.Stderr(), "restoring the previous deployment: %s\n", reason)
+		if err := s.runtime.Restore(deployID); err != nil {
+			return err
+		}
+		return s.runtime.Up(ctx, deployID, output)
+	})
+	if err != nil {
+		zap.L().Error("failed to restore previous deployment", zap.Uint64("deploy_id", deployID), zap.Error(err))
+		s.setHealth(ctx, deployID, domain.HealthUnhealthy, fmt.Sprintf("%s; rollback failed: %v", reason, err))
+		s.updateStatusToFailed(ctx, deployID)
+		return
+	}
+	s.recordPushed(ctx, deployID)
+	restored := *previous
+	restored.Status = domain.StatusStarting
+	if err := s.repository.Update(ctx, &restored); err != nil {
+		zap.L().Error("failed to record restored deployment", zap.Uint64("deploy_id", deployID), zap.Error(err))
+	}
+	s.setHealth(ctx, deployID, domain.HealthUnhealthy, reason+"; restored the previous deployment")
+}
+
+func (s *Service) setHealth(ctx context.Context, deployID uint64, health, message string) {
+	if err := s.repository.UpdateHealth(ctx, deployID, health, message); err != nil {
+		zap.L().Error("failed to record deployment health",
+			zap.Uint64("deploy_id", deployID),
+			zap.String("health", health),
+			zap.Error(err),
+		)
+	}
+}
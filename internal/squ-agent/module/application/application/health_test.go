@@ -0,0 +1,274 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/internal/squ-agent/module/application/infra"
+)
+
+type repositoryStub struct {
+	mu   sync.Mutex
+	apps []domain.Application
+}
+
+func (r *repositoryStub) List(context.Context) ([]domain.Application, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.Application(nil), r.apps...), nil
+}
+func (r *repositoryStub) Get(context.Context, uint) (domain.Application, error) {
+	return domain.Application{}, gorm.ErrRecordNotFound
+}
+func (r *repositoryStub) GetByDeployID(_ context.Context, deployID uint64) (domain.Application, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, app := range r.apps {
+		if app.DeployID == deployID {
+			return app, nil
+		}
+	}
+	return domain.Application{}, gorm.ErrRecordNotFound
+}
+func (r *repositoryStub) Delete(context.Context, uint) error { return nil }
+func (r *repositoryStub) Add(_ context.Context, app *domain.Application) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	app.ID = uint(len(r.apps) + 1)
+	r.apps = append(r.apps, *app)
+	return nil
+}
+func (r *repositoryStub) Update(_ context.Context, app *domain.Application) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.apps {
+		if r.apps[i].ID == app.ID {
+			app.Health, app.HealthMessage, app.Pushed = r.apps[i].Health, r.apps[i].HealthMessage, r.apps[i].Pushed
+			r.apps[i] = *app
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) UpdateHealth(_ context.Context, deployID uint64, health, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.apps {
+		if r.apps[i].DeployID == deployID {
+			r.apps[i].Health, r.apps[i].HealthMessage = health, message
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) UpdateDesired(_ context.Context, deployID uint64, desired string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.apps {
+		if r.apps[i].DeployID == deployID {
+			r.apps[i].Desired = desired
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) UpdateReconcile(_ context.Context, deployID uint64, reconcile bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.apps {
+		if r.apps[i].DeployID == deployID {
+			r.apps[i].Reconcile = reconcile
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) UpdatePushed(_ context.Context, deployID uint64, hashes domain.FileHashes) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.apps {
+		if r.apps[i].DeployID == deployID {
+			r.apps[i].Pushed = hashes
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) Transaction(_ context.Context, fn func(domain.Repository) error) error {
+	return fn(r)
+}
+
+type configStub struct{}
+
+func (configStub) Save(context.Context, string, string) error { return nil }
+
+// runtimeStub remembers the content of the compose file like a deployment
+// directory would.
+type runtimeStub struct {
+	mu       sync.Mutex
+	content  string
+	previous string
+	ups      []string
+	lock     chan struct{}
+}
+
+// Lock serializes all deployments of the stub; the tests use one each.
+func (r *runtimeStub) Lock(ctx context.Context, _ uint64) (func(), error) {
+	r.mu.Lock()
+	if r.lock == nil {
+		r.lock = make(chan struct{}, 1)
+	}
+	lock := r.lock
+	r.mu.Unlock()
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *runtimeStub) DockerInstalled() bool  { return true }
+func (r *runtimeStub) ComposeAvailable() bool { return true }
+func (r *runtimeStub) Prepare(_ uint64, content string, _ []map[string]string) (string, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.content = content
+	return "/compose/1", "docker-compose.yml", nil
+}
+func (r *runtimeStub) ComposeFileExists(uint64) bool { return true }
+func (r *runtimeStub) Up(context.Context, uint64, domain.Output) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ups = append(r.ups, r.content)
+	return nil
+}
+func (r *runtimeStub) Start(context.Context, uint64, domain.Output) error { return nil }
+func (r *runtimeStub) Stop(context.Context, uint64, domain.Output) error  { return nil }
+func (r *runtimeStub) Logs(context.Context, uint64, domain.LogOptions, domain.Output) error {
+	return nil
+}
+func (r *runtimeStub) Path(uint64) string { return "/compose/1" }
+func (r *runtimeStub) Snapshot(uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.previous = r.content
+	return r.content != "", nil
+}
+func (r *runtimeStub) Restore(uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.content = r.previous
+	return nil
+}
+
+// Images reads the compose content as a list of images.
+func (r *runtimeStub) Images(context.Context, uint64) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Fields(r.content), nil
+}
+func (r *runtimeStub) Files(uint64) (domain.Files, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return domain.Files{Compose: r.content}, nil
+}
+
+// checkerStub fails HTTP checks against content "broken".
+type checkerStub struct{ runtime *runtimeStub }
+
+func (c checkerStub) Check(_ context.Context, _ uint64, check domain.Check) error {
+	c.runtime.mu.Lock()
+	defer c.runtime.mu.Unlock()
+	if check.Type == domain.CheckHTTP && c.runtime.content == "broken" {
+		return errors.New("http http://127.0.0.1:8080/health: status 503")
+	}
+	return nil
+}
+
+func waitHealth(t *testing.T, repository *repositoryStub, deployID uint64, health string) domain.Application {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		app, _ := repository.GetByDeployID(context.Background(), deployID)
+		if app.Health == health {
+			return app
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	app, _ := repository.GetByDeployID(context.Background(), deployID)
+	t.Fatalf("health = %q (%s), want %q", app.Health, app.HealthMessage, health)
+	return app
+}
+
+func TestUnhealthyRedeployRestoresPreviousDeployment(t *testing.T) {
+	interval := healthInterval
+	healthInterval = time.Millisecond
+	defer func() { healthInterval = interval }()
+
+	repository := &repositoryStub{}
+	runtime := &runtimeStub{}
+	service := NewService(repository, configStub{}, runtime, infra.NewOutputStore(t.TempDir()), checkerStub{runtime: runtime}, nil, nil, nil, nil)
+	check := &domain.HealthCheck{Timeout: 1, Checks: []domain.Check{
+		{Type: domain.CheckCompose},
+		{Type: domain.CheckHTTP, URL: "http://127.0.0.1:8080/health"},
+	}}
+	ctx := context.Background()
+
+	if result := service.Add(ctx, Request{Name: "web", DeployID: 7, Content: "good", HealthCheck: check}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	waitHealth(t, repository, 7, domain.HealthHealthy)
+
+	if result := service.Add(ctx, Request{Name: "web", DeployID: 7, Content: "broken", HealthCheck: check}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	app := waitHealth(t, repository, 7, domain.HealthUnhealthy)
+	if !strings.Contains(app.HealthMessage, "status 503") || !strings.Contains(app.HealthMessage, "restored the previous deployment") {
+		t.Fatalf("health message = %q", app.HealthMessage)
+	}
+	if app.Content != "good" {
+		t.Fatalf("content = %q, want the restored one", app.Content)
+	}
+	if want := (domain.Files{Compose: "good"}).Hashes(); app.Pushed != want {
+		t.Fatalf("pushed = %+v, want the restored files %+v", app.Pushed, want)
+	}
+	runtime.mu.Lock()
+	defer runtime.mu.Unlock()
+	if strings.Join(runtime.ups, ",") != "good,broken,good" {
+		t.Fatalf("ups = %v", runtime.ups)
+	}
+	run, err := service.Output(7)
+	if err != nil || run.Action != "rollback" || run.Status != domain.RunSucceeded {
+		t.Fatalf("run = %+v, err = %v", run, err)
+	}
+}
+
+func TestUnhealthyFirstDeployFails(t *testing.T) {
+	interval := healthInterval
+	healthInterval = time.Millisecond
+	defer func() { healthInterval = interval }()
+
+	repository := &repositoryStub{}
+	runtime := &runtimeStub{}
+	service := NewService(repository, configStub{}, runtime, infra.NewOutputStore(t.TempDir()), checkerStub{runtime: runtime}, nil, nil, nil, nil)
+	check := &domain.HealthCheck{Timeout: 1, Checks: []domain.Check{{Type: domain.CheckHTTP, URL: "http://127.0.0.1:8080/health"}}}
+
+	if result := service.Add(context.Background(), Request{Name: "web", DeployID: 8, Content: "broken", HealthCheck: check}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	app := waitHealth(t, repository, 8, domain.HealthUnhealthy)
+	if !strings.Contains(app.HealthMessage, "no previous deployment") {
+		t.Fatalf("health message = %q", app.HealthMessage)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for app.Status != domain.StatusFailed && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		app, _ = repository.GetByDeployID(context.Background(), 8)
+	}
+	if app.Status != domain.StatusFailed {
+		t.Fatalf("status = %q, want %q", app.Status, domain.StatusFailed)
+	}
+}
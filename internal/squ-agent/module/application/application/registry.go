@@ -0,0 +1,56 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+var errNoDockerClient = errors.New("docker client is not available")
+
+// Login checks registry credentials on behalf of the apiserver.
+func (s *Service) Login(ctx context.Context, auth domain.RegistryAuth) error {
+	if s.registry == nil {
+		return fmt.Errorf("%w: %v", ErrRegistryLogin, errNoDockerClient)
+	}
+	if err := s.registry.Login(ctx, auth); err != nil {
+		zap.L().Warn("registry login failed", zap.String("registry", auth.Host()), zap.Error(err))
+		return fmt.Errorf("%w: %v", ErrRegistryLogin, err)
+	}
+	return nil
+}
+
+// pullImages logs in to the private registry of a deployment and pulls the
+// images of the project that come from it, so that compose up finds them
+// and does not need credentials of its own. Other images are left to
+// compose.
+func (s *Service) pullImages(ctx context.Context, deployID uint64, auth *domain.RegistryAuth, output domain.Output) error {
+	if auth == nil {
+		return nil
+	}
+	if s.registry == nil {
+		return errNoDockerClient
+	}
+	fmt.Fprintf(output.Stdout(), "logging in to %s\n", auth.Host())
+	if err := s.registry.Login(ctx, *auth); err != nil {
+		return fmt.Errorf("login to %s failed: %w", auth.Host(), err)
+	}
+	images, err := s.runtime.Images(ctx, deployID)
+	if err != nil {
+		return fmt.Errorf("failed to list images: %w", err)
+	}
+	for _, image := range images {
+		if !auth.Serves(image) {
+			continue
+		}
+		fmt.Fprintf(output.Stdout(), "pulling %s\n", image)
+		if err := s.registry.Pull(ctx, image, *auth, output); err != nil {
+			return fmt.Errorf("failed to pull %s: %w", image, err)
+		}
+	}
+	return nil
+}
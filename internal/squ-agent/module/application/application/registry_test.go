@@ -0,0 +1,102 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/internal/squ-agent/module/application/infra"
+)
+
+type registryStub struct {
+	mu       sync.Mutex
+	loginErr error
+	logins   []domain.RegistryAuth
+	pulls    []string
+}
+
+func (r *registryStub) Login(_ context.Context, auth domain.RegistryAuth) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logins = append(r.logins, auth)
+	return r.loginErr
+}
+func (r *registryStub) Pull(_ context.Context, image string, _ domain.RegistryAuth, _ domain.Output) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pulls = append(r.pulls, image)
+	return nil
+}
+
+func waitStatus(t *testing.T, repository *repositoryStub, deployID uint64, done func(domain.Application) bool) domain.Application {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		app, _ := repository.GetByDeployID(context.Background(), deployID)
+		if done(app) {
+			return app
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	app, _ := repository.GetByDeployID(context.Background(), deployID)
+	t.Fatalf("deployment did not finish: %+v", app)
+	return app
+}
+
+func TestDeployPullsImagesOfPrivateRegistry(t *testing.T) {
+	repository := &repositoryStub{}
+	runtime := &runtimeStub{}
+	registry := &registryStub{}
+	outputs := infra.NewOutputStore(t.TempDir())
+	service := NewService(repository, configStub{}, runtime, outputs, nil, nil, registry, nil, nil)
+	auth := &domain.RegistryAuth{Server: "https://registry.example.com/", Username: "ci", Password: "hunter2"}
+	content := "registry.example.com/shop/web:1 nginx:1.27 registry.example.com/shop/worker:1"
+
+	if result := service.Add(context.Background(), Request{Name: "shop", DeployID: 7, Content: content, Registry: auth}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	waitStatus(t, repository, 7, func(domain.Application) bool {
+		run, err := service.Output(7)
+		return err == nil && run.Status == domain.RunSucceeded
+	})
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if len(registry.logins) != 1 || registry.logins[0] != *auth {
+		t.Fatalf("logins = %+v", registry.logins)
+	}
+	if want := []string{"registry.example.com/shop/web:1", "registry.example.com/shop/worker:1"}; !slices.Equal(registry.pulls, want) {
+		t.Fatalf("pulls = %v, want %v", registry.pulls, want)
+	}
+	if len(runtime.ups) != 1 {
+		t.Fatalf("ups = %v", runtime.ups)
+	}
+}
+
+func TestFailedRegistryLoginFailsDeploy(t *testing.T) {
+	repository := &repositoryStub{}
+	runtime := &runtimeStub{}
+	registry := &registryStub{loginErr: errors.New("unauthorized: incorrect username or password")}
+	service := NewService(repository, configStub{}, runtime, infra.NewOutputStore(t.TempDir()), nil, nil, registry, nil, nil)
+	auth := &domain.RegistryAuth{Server: "registry.example.com", Username: "ci", Password: "wrong"}
+
+	if result := service.Add(context.Background(), Request{Name: "shop", DeployID: 8, Content: "registry.example.com/shop/web:1", Registry: auth}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	waitStatus(t, repository, 8, func(app domain.Application) bool { return app.Status == domain.StatusFailed })
+	run, err := service.Output(8)
+	if err != nil || !strings.Contains(run.Error, "incorrect username or password") {
+		t.Fatalf("run = %+v, err = %v", run, err)
+	}
+	if len(runtime.ups) != 0 || len(registry.pulls) != 0 {
+		t.Fatalf("ups = %v, pulls = %v", runtime.ups, registry.pulls)
+	}
+
+	if err := service.Login(context.Background(), *auth); !errors.Is(err, ErrRegistryLogin) {
+		t.Fatalf("Login() error = %v, want %v", err, ErrRegistryLogin)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -18,6 +19,13 @@ var (
 	ErrComposeCreate         = errors.New("docker-compose file creation failed")
 	ErrComposeStop           = errors.New("docker-compose stop failed")
 	ErrLegacyDeleteShortStop = errors.New("legacy delete returned stop result")
+	ErrComposeFileMissing    = errors.New("docker-compose file not found")
+	// ErrUnsupportedType rejects Kubernetes applications, which the
+	// apiserver applies to clusters itself.
+	ErrUnsupportedType = errors.New("application type is not supported by the agent")
+	ErrHostInspect     = errors.New("failed to inspect host resources")
+	ErrFilesRead       = errors.New("failed to read deployment files")
+	ErrRegistryLogin   = errors.New("registry login failed")
 )
 
 type Request struct {
@@ -31,6 +39,21 @@ type Request struct {
 	ServerID    uint
 	DeployID    uint64
 	Env         []map[string]string
+	// Secrets are secret values by name that the apiserver resolved for
+	// this deploy. They are added to the env file only and never stored.
+	Secrets map[string]string
+	// HealthCheck is verified after the project is up. A redeploy that
+	// fails it is replaced by the previous deployment.
+	HealthCheck *domain.HealthCheck
+	// Reconcile lets the agent restore the desired state of the deployment
+	// when it drifts.
+	Reconcile bool
+	// Registry holds the credentials to pull the images of the deployment
+	// from a private registry. Like Secrets they are never stored.
+	Registry *domain.RegistryAuth
+	// Bundle is the checksum of an image bundle that is loaded into docker
+	// before the project is up, for hosts without registry access.
+	Bundle string
 }
 
 type Result struct {
@@ -42,10 +65,27 @@ type Service struct {
 	repository domain.Repository
 	configs    domain.ConfigStore
 	runtime    domain.ComposeRuntime
+	outputs    domain.OutputStore
+	health     domain.HealthChecker
+	host       domain.HostInspector
+	registry   domain.ImageRegistry
+	bundles    domain.BundleStore
+	archiver   domain.ImageArchiver
 }
 
-func NewService(repository domain.Repository, configs domain.ConfigStore, runtime domain.ComposeRuntime) *Service {
-	return &Service{repository: repository, configs: configs, runtime: runtime}
+func NewService(
+	repository domain.Repository,
+	configs domain.ConfigStore,
+	runtime domain.ComposeRuntime,
+	outputs domain.OutputStore,
+	health domain.HealthChecker,
+	host domain.HostInspector,
+	registry domain.ImageRegistry,
+	bundles domain.BundleStore,
+	archiver domain.ImageArchiver,
+) *Service {
+	return &Service{repository: repository, configs: configs, runtime: runtime, outputs: outputs, health: health, host: host,
+		registry: registry, bundles: bundles, archiver: archiver}
 }
 
 func (s *Service) List(ctx context.Context) ([]domain.Application, error) {
@@ -56,6 +96,29 @@ func (s *Service) Get(ctx context.Context, id uint) (domain.Application, error)
 	return s.repository.Get(ctx, id)
 }
 
+func (s *Service) GetByDeployID(ctx context.Context, deployID uint64) (domain.Application, error) {
+	return s.repository.GetByDeployID(ctx, deployID)
+}
+
+// HostResources returns the sockets and docker objects in use on the host,
+// without those of the deployment exclude, for conflict checks.
+func (s *Service) HostResources(exclude uint64) (domain.HostResources, error) {
+	resources, err := s.host.Resources(exclude)
+	if err != nil {
+		zap.L().Warn("failed to inspect host resources", zap.Uint64("exclude", exclude), zap.Error(err))
+		return domain.HostResources{}, fmt.Errorf("%w: %v", ErrHostInspect, err)
+	}
+	return resources, nil
+}
+
+// SetReconcile turns reconciling of a deployment on or off.
+func (s *Service) SetReconcile(ctx context.Context, deployID uint64, reconcile bool) error {
+	if _, err := s.repository.GetByDeployID(ctx, deployID); err != nil {
+		return err
+	}
+	return s.repository.UpdateReconcile(ctx, deployID, reconcile)
+}
+
 func (s *Service) Update(ctx context.Context, request Request) error {
 	return s.repository.Update(ctx, &domain.Application{
 		ID: request.ID, Name: request.Name, Description: request.Description,
@@ -64,12 +127,18 @@ func (s *Service) Update(ctx context.Context, request Request) error {
 }
 
 func (s *Service) Add(ctx context.Context, request Request) Result {
+	if request.Type == "k8s_manifest" || request.Type == "helm_chart" {
+		return Result{Err: fmt.Errorf("%w: %s", ErrUnsupportedType, request.Type)}
+	}
 	if !s.runtime.DockerInstalled() {
 		return Result{Err: ErrDockerNotInstalled}
 	}
 	if !s.runtime.ComposeAvailable() {
 		return Result{Err: ErrComposeNotFound}
 	}
+	if err := s.checkBundle(request.Bundle); err != nil {
+		return Result{Err: err}
+	}
 	existing, err := s.repository.GetByDeployID(ctx, request.DeployID)
 	if err == nil {
 		return s.redeploy(ctx, existing, request)
@@ -83,10 +152,12 @@ func (s *Service) Add(ctx context.Context, request Request) Result {
 	if err := s.repository.Add(ctx, &app); err != nil {
 		return Result{Err: err}
 	}
+	s.setReconcile(ctx, app.DeployID, request.Reconcile)
+	s.setDesired(ctx, app.DeployID, domain.DesiredRunning)
 	if err := s.configs.Save(ctx, "server_id", fmt.Sprint(request.ServerID)); err != nil {
 		return Result{Err: err}
 	}
-	if err := s.deploy(ctx, &app); err != nil {
+	if err := s.deploy(ctx, &app, request); err != nil {
 		return Result{Err: fmt.Errorf("%w: %v", ErrComposeCreate, err)}
 	}
 	return Result{Data: "Application added successfully, starting in background"}
@@ -104,9 +175,18 @@ func (s *Service) Start(ctx context.Context, deployID uint64) Result {
 	if err := s.repository.Update(ctx, &app); err != nil {
 		return Result{Err: err}
 	}
-	path := s.runtime.Path(deployID)
+	s.setDesired(ctx, deployID, domain.DesiredRunning)
 	go func() {
-		if err := s.runtime.Start(path, "docker-compose.yml"); err != nil {
+		ctx := context.Background()
+		unlock, err := s.runtime.Lock(ctx, deployID)
+		if err != nil {
+			s.updateStatusToFailed(ctx, deployID)
+			return
+		}
+		defer unlock()
+		if err := s.compose(deployID, "start", func(output domain.Output) error {
+			return s.runtime.Start(ctx, deployID, output)
+		}); err != nil {
 			s.updateStatusToFailed(context.Background(), deployID)
 		}
 	}()
@@ -121,8 +201,17 @@ func (s *Service) Stop(ctx context.Context, deployID uint64) Result {
 	if app.Status != domain.StatusRunning || !s.runtime.ComposeFileExists(deployID) {
 		return Result{Err: ErrComposeStop}
 	}
-	path := s.runtime.Path(deployID)
-	if err := s.runtime.Stop(path, "docker-compose.yml"); err != nil {
+	// Set first so that reconciling does not start it again meanwhile.
+	s.setDesired(ctx, deployID, domain.DesiredStopped)
+	unlock, err := s.runtime.Lock(ctx, deployID)
+	if err != nil {
+		return Result{Err: ErrComposeStop}
+	}
+	err = s.compose(deployID, "stop", func(output domain.Output) error {
+		return s.runtime.Stop(ctx, deployID, output)
+	})
+	unlock()
+	if err != nil {
 		return Result{Err: ErrComposeStop}
 	}
 	app.Status = domain.StatusStopped
@@ -168,11 +257,27 @@ func (s *Service) DeleteByDeployID(ctx context.Context, deployID uint64) Result
 	return Result{Data: "success"}
 }
 
+// redeploy holds the lock of the deployment from stopping it until the new
+// files are up, so that an earlier action never runs on half-written files.
+// The lock passes to startAsync once the files are written.
 func (s *Service) redeploy(ctx context.Context, app domain.Application, request Request) Result {
+	unlock, err := s.runtime.Lock(ctx, request.DeployID)
+	if err != nil {
+		return Result{Err: fmt.Errorf("%w: %v", ErrComposeCreate, err)}
+	}
+	started := false
+	defer func() {
+		if !started {
+			unlock()
+		}
+	}()
+	previous := app
 	if app.Status == domain.StatusRunning {
-		_ = s.runtime.Stop(s.runtime.Path(request.DeployID), "docker-compose.yml")
+		_ = s.compose(request.DeployID, "stop", func(output domain.Output) error {
+			return s.runtime.Stop(ctx, request.DeployID, output)
+		})
 	}
-	err := s.repository.Transaction(ctx, func(repository domain.Repository) error {
+	err = s.repository.Transaction(ctx, func(repository domain.Repository) error {
 		app.Name, app.Description, app.Type = request.Name, request.Description, request.Type
 		app.Content, app.Version, app.Env = request.Content, request.Version, request.Env
 		app.Status = domain.StatusStarting
@@ -184,36 +289,151 @@ func (s *Service) redeploy(ctx context.Context, app domain.Application, request
 	if err != nil {
 		return Result{Err: err}
 	}
-	path, file, err := s.runtime.Prepare(app.DeployID, app.Content, app.Env)
+	s.setReconcile(ctx, app.DeployID, request.Reconcile)
+	s.setDesired(ctx, app.DeployID, domain.DesiredRunning)
+	restore, err := s.snapshot(previous, request.HealthCheck)
 	if err != nil {
 		return Result{Err: fmt.Errorf("%w: %v", ErrComposeCreate, err)}
 	}
-	s.startAsync(app.Name, path, file, app.DeployID)
+	if _, _, err := s.runtime.Prepare(app.DeployID, app.Content, withSecrets(app.Env, request.Secrets)); err != nil {
+		return Result{Err: fmt.Errorf("%w: %v", ErrComposeCreate, err)}
+	}
+	s.recordPushed(ctx, app.DeployID)
+	started = true
+	s.startAsync(ctx, app.DeployID, request, restore, unlock)
 	return Result{Data: "Application redeployed successfully, starting in background"}
 }
 
-func (s *Service) deploy(ctx context.Context, app *domain.Application) error {
-	path, file, err := s.runtime.Prepare(app.DeployID, app.Content, app.Env)
+func (s *Service) deploy(ctx context.Context, app *domain.Application, request Request) error {
+	unlock, err := s.runtime.Lock(ctx, app.DeployID)
 	if err != nil {
 		return err
 	}
+	if _, _, err := s.runtime.Prepare(app.DeployID, app.Content, withSecrets(app.Env, request.Secrets)); err != nil {
+		unlock()
+		return err
+	}
+	s.recordPushed(ctx, app.DeployID)
 	app.Status = domain.StatusStarting
 	if err := s.repository.Update(ctx, app); err != nil {
+		unlock()
 		return fmt.Errorf("failed to update application status: %w", err)
 	}
-	s.startAsync(app.Name, path, file, app.DeployID)
+	s.startAsync(ctx, app.DeployID, request, nil, unlock)
 	return nil
 }
 
-func (s *Service) startAsync(_ string, path, file string, deployID uint64) {
+// withSecrets adds secret values to env for the env file. They come last so
+// that a secret wins over a plain env entry of the same name.
+func withSecrets(env []map[string]string, secrets map[string]string) []map[string]string {
+	if len(secrets) == 0 {
+		return env
+	}
+	return append(slices.Clone(env), secrets)
+}
+
+// startAsync brings the project up in the background, after loading the
+// image bundle and pulling the images of a private registry of the request.
+// With a health check the checks run as part of the same action, and a
+// failure of any step is handed to rollback with the deployment to restore.
+// It calls unlock, the lock of the deployment its caller took, once the
+// project is up or restored.
+func (s *Service) startAsync(ctx context.Context, deployID uint64, request Request, previous *domain.Application, unlock func()) {
+	check := request.HealthCheck
+	health := ""
+	if check != nil {
+		health = domain.HealthStarting
+	}
+	s.setHealth(ctx, deployID, health, "")
 	go func() {
-		if err := s.runtime.Up(path, file); err != nil {
+		defer unlock()
+		ctx := context.Background()
+		err := s.compose(deployID, "up", func(output domain.Output) error {
+			if err := s.loadBundle(ctx, request.Bundle, output); err != nil {
+				return err
+			}
+			if err := s.pullImages(ctx, deployID, request.Registry, output); err != nil {
+				return err
+			}
+			if err := s.runtime.Up(ctx, deployID, output); err != nil || check == nil {
+				return err
+			}
+			return s.waitHealthy(ctx, deployID, *check, output)
+		})
+		switch {
+		case err == nil && check != nil:
+			s.setHealth(ctx, deployID, domain.HealthHealthy, "")
+		case err == nil:
+		case check != nil:
+			s.rollback(ctx, deployID, previous, err)
+		default:
 			zap.L().Error("Failed to start docker-compose", zap.Uint64("deploy_id", deployID), zap.Error(err))
-			s.updateStatusToFailed(context.Background(), deployID)
+			s.updateStatusToFailed(ctx, deployID)
 		}
 	}()
 }
 
+// compose runs one compose action and records its output under deployID.
+func (s *Service) compose(deployID uint64, action string, run func(domain.Output) error) error {
+	recorder := s.outputs.Begin(deployID, action)
+	err := run(recorder)
+	recorder.Finish(err)
+	return err
+}
+
+// Output returns the output of the current or last compose action.
+func (s *Service) Output(deployID uint64) (domain.Run, error) {
+	return s.outputs.Last(deployID)
+}
+
+// WatchOutput is Output plus a feed of new lines while the action runs.
+func (s *Service) WatchOutput(deployID uint64) (domain.Run, <-chan domain.OutputLine, func(), error) {
+	return s.outputs.Watch(deployID)
+}
+
+// Logs checks the options and the deployment and returns a function that
+// writes its container logs to an output. Checks happen up front so callers
+// can still send an error response before they start streaming.
+func (s *Service) Logs(ctx context.Context, deployID uint64, options domain.LogOptions) (func(domain.Output) error, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.repository.GetByDeployID(ctx, deployID); err != nil {
+		return nil, err
+	}
+	if !s.runtime.ComposeFileExists(deployID) {
+		return nil, ErrComposeFileMissing
+	}
+	return func(output domain.Output) error {
+		err := s.runtime.Logs(ctx, deployID, options, output)
+		if ctx.Err() != nil {
+			// The client went away; the killed command is not an error.
+			return nil
+		}
+		return err
+	}, nil
+}
+
+func (s *Service) setDesired(ctx context.Context, deployID uint64, desired string) {
+	if err := s.repository.UpdateDesired(ctx, deployID, desired); err != nil {
+		zap.L().Error("failed to record desired deployment state",
+			zap.Uint64("deploy_id", deployID),
+			zap.String("desired", desired),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *Service) setReconcile(ctx context.Context, deployID uint64, reconcile bool) {
+	if err := s.repository.UpdateReconcile(ctx, deployID, reconcile); err != nil {
+		zap.L().Error("failed to record reconcile mode",
+			zap.Uint64("deploy_id", deployID),
+			zap.Bool("reconcile", reconcile),
+			zap.Error(err),
+		)
+	}
+}
+
 func (s *Service) updateStatusToFailed(ctx context.Context, deployID uint64) {
 	apps, err := s.repository.List(ctx)
 	if err != nil {
@@ -0,0 +1,63 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"squirrel-dev/internal/squ-agent/module/application/infra"
+)
+
+func waitUps(t *testing.T, runtime *runtimeStub, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.mu.Lock()
+		ups := strings.Join(runtime.ups, ",")
+		runtime.mu.Unlock()
+		if ups == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("ups = %v, want %s", runtime.ups, want)
+}
+
+func TestRedeployWaitsForRunningAction(t *testing.T) {
+	repository := &repositoryStub{}
+	runtime := &runtimeStub{}
+	service := NewService(repository, configStub{}, runtime, infra.NewOutputStore(t.TempDir()), checkerStub{runtime: runtime}, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	if result := service.Add(ctx, Request{Name: "web", DeployID: 9, Content: "one"}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	waitUps(t, runtime, "one")
+
+	// Stands in for an action, such as a reconcile, still running compose.
+	unlock, err := runtime.Lock(ctx, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if result := service.Add(expired, Request{Name: "web", DeployID: 9, Content: "two"}); !errors.Is(result.Err, ErrComposeCreate) {
+		t.Fatalf("redeploy during a running action: %v", result.Err)
+	}
+	done := make(chan Result, 1)
+	go func() { done <- service.Add(ctx, Request{Name: "web", DeployID: 9, Content: "two"}) }()
+	time.Sleep(20 * time.Millisecond)
+	runtime.mu.Lock()
+	content := runtime.content
+	runtime.mu.Unlock()
+	if content != "one" {
+		t.Fatalf("redeploy wrote %q while another action held the deployment", content)
+	}
+	unlock()
+	if result := <-done; result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	waitUps(t, runtime, "one,two")
+}
@@ -9,6 +9,9 @@ const (
 	StatusUndeploy = "undeploy"
 	StatusPaused   = "paused"
 	StatusFailed   = "Failed"
+	// StatusDegraded means some containers run while others crash, restart
+	// or report unhealthy.
+	StatusDegraded = "degraded"
 )
 
 type Application struct {
@@ -22,6 +25,20 @@ type Application struct {
 	Version     string
 	DeployID    uint64
 	Env         []map[string]string
+	// Health is the result of the health check of the last deploy: empty
+	// without a health check, otherwise HealthStarting, HealthHealthy or
+	// HealthUnhealthy. HealthMessage says why a check failed and whether the
+	// previous deployment was restored.
+	Health        string
+	HealthMessage string
+	// Desired is DesiredRunning or DesiredStopped, or empty for deployments
+	// from before the agent tracked it. With Reconcile set the agent brings
+	// the deployment back to it when it drifts.
+	Desired   string
+	Reconcile bool
+	// Pushed are the hashes of the files the agent last wrote for the
+	// apiserver. Files on disk that differ were changed by hand.
+	Pushed FileHashes
 }
 
 type Repository interface {
@@ -31,6 +48,15 @@ type Repository interface {
 	Delete(context.Context, uint) error
 	Add(context.Context, *Application) error
 	Update(context.Context, *Application) error
+	// UpdateHealth sets the health of a deployment. Update leaves it alone.
+	UpdateHealth(ctx context.Context, deployID uint64, health, message string) error
+	// UpdateDesired and UpdateReconcile set the desired state and the
+	// reconcile mode of a deployment. Update leaves both alone.
+	UpdateDesired(ctx context.Context, deployID uint64, desired string) error
+	UpdateReconcile(ctx context.Context, deployID uint64, reconcile bool) error
+	// UpdatePushed records the hashes of the files written for a deployment.
+	// Update leaves them alone.
+	UpdatePushed(ctx context.Context, deployID uint64, hashes FileHashes) error
 	Transaction(context.Context, func(Repository) error) error
 }
 
@@ -43,8 +69,26 @@ type ComposeRuntime interface {
 	ComposeAvailable() bool
 	Prepare(uint64, string, []map[string]string) (string, string, error)
 	ComposeFileExists(uint64) bool
-	Up(string, string) error
-	Start(string, string) error
-	Stop(string, string) error
+	// Lock serializes the actions of a deployment. An action holds it from
+	// writing or restoring the files of the deployment until the compose
+	// commands that use them have finished; it returns the unlock function.
+	Lock(context.Context, uint64) (func(), error)
+	// Up, Start and Stop run compose for the project of a deployment until
+	// it finishes or ctx is done. Their errors end with what compose wrote
+	// to stderr. They do not take Lock.
+	Up(context.Context, uint64, Output) error
+	Start(context.Context, uint64, Output) error
+	Stop(context.Context, uint64, Output) error
+	// Logs writes container logs to output until they end or ctx is done.
+	Logs(context.Context, uint64, LogOptions, Output) error
+	// Images lists the images the services of a deployment run.
+	Images(context.Context, uint64) ([]string, error)
 	Path(uint64) string
+	// Snapshot keeps the compose file and the env file of a deployment so
+	// that Restore can bring them back after a failed deploy. It reports
+	// whether there was anything to keep.
+	Snapshot(uint64) (bool, error)
+	Restore(uint64) error
+	// Files reads the compose file and the env file of a deployment.
+	Files(uint64) (Files, error)
 }
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+)
+
+var (
+	ErrBundleNotFound = errors.New("image bundle not found")
+	// ErrBundleOffset is returned for a chunk that does not continue the
+	// bundle where it ends; the sender asks for the size and resumes there.
+	ErrBundleOffset   = errors.New("chunk does not continue the image bundle")
+	ErrBundleChecksum = errors.New("image bundle checksum mismatch")
+)
+
+// Bundle is an image archive as docker save writes it, named by the sha256
+// checksum of its content. Bundles arrive in chunks; Size counts the bytes
+// received so far and Complete is set once the whole bundle matched its
+// checksum.
+type Bundle struct {
+	Checksum string
+	Size     int64
+	Complete bool
+}
+
+// ValidChecksum reports whether value is a hex sha256 checksum, which is
+// also what keeps bundle names safe to use as file names.
+func ValidChecksum(value string) bool {
+	decoded, err := hex.DecodeString(value)
+	return err == nil && len(decoded) == 32 && hex.EncodeToString(decoded) == value
+}
+
+// BundleStore keeps image bundles on the agent's disk.
+type BundleStore interface {
+	// Get returns the state of a bundle; one that never arrived has size 0.
+	Get(checksum string) (Bundle, error)
+	// Append adds a chunk at offset, which must be the size received.
+	Append(checksum string, offset int64, chunk []byte) (Bundle, error)
+	// Complete checks the received bundle against its checksum. A bundle
+	// that does not match is dropped so that it can be sent again.
+	Complete(checksum string) (Bundle, error)
+	// Save stores the archive that write puts at the path it is given.
+	Save(write func(path string) error) (Bundle, error)
+	// Path returns the file of a complete bundle.
+	Path(checksum string) (string, error)
+}
+
+// ImageArchiver moves images between the docker engine and archives.
+type ImageArchiver interface {
+	Save(ctx context.Context, images []string, path string) error
+	Load(ctx context.Context, path string, output Output) error
+}
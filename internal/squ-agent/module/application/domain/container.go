@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Container states as reported by docker.
+const (
+	ContainerCreated    = "created"
+	ContainerRunning    = "running"
+	ContainerPaused     = "paused"
+	ContainerRestarting = "restarting"
+	ContainerExited     = "exited"
+	ContainerDead       = "dead"
+)
+
+// Health check states as reported by docker. Health is empty for containers
+// without a health check.
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+type Port struct {
+	HostIP        string `json:"host_ip,omitempty"`
+	HostPort      int    `json:"host_port"`
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol"`
+}
+
+// Container is one container of a compose project. ImageDigest is the
+// repository digest of the image when known, otherwise its image ID.
+type Container struct {
+	Service      string     `json:"service"`
+	Name         string     `json:"name"`
+	ID           string     `json:"id"`
+	State        string     `json:"state"`
+	Health       string     `json:"health,omitempty"`
+	RestartCount int        `json:"restart_count"`
+	ExitCode     int        `json:"exit_code"`
+	Image        string     `json:"image"`
+	ImageDigest  string     `json:"image_digest,omitempty"`
+	Ports        []Port     `json:"ports,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// ContainerInspector lists the containers of a deployment's compose project.
+type ContainerInspector interface {
+	Containers(deployID uint64) ([]Container, error)
+}
+
+// ContainerWatcher follows container changes of compose projects.
+type ContainerWatcher interface {
+	// Watch calls ready once it receives events and then changed with the
+	// deploy ID of every project one of whose containers was created,
+	// started, stopped, paused, removed or changed health. It returns when
+	// ctx ends or the event stream fails.
+	Watch(ctx context.Context, ready func(), changed func(deployID uint64)) error
+}
+
+// Summarize reduces a project's containers to one application status. A
+// container that exited with code 0 next to running ones is a finished
+// one-shot service and does not count against the project.
+func Summarize(containers []Container) string {
+	if len(containers) == 0 {
+		return StatusUndeploy
+	}
+	var running, starting, paused, failing, crashed int
+	for _, container := range containers {
+		switch container.State {
+		case ContainerRunning:
+			switch container.Health {
+			case HealthUnhealthy:
+				failing++
+			case HealthStarting:
+				starting++
+			default:
+				running++
+			}
+		case ContainerCreated:
+			starting++
+		case ContainerPaused:
+			paused++
+		case ContainerRestarting, ContainerDead:
+			failing++
+		case ContainerExited:
+			if container.ExitCode != 0 {
+				crashed++
+			}
+		}
+	}
+	active := running + starting
+	switch {
+	case failing > 0 || active > 0 && (crashed > 0 || paused > 0):
+		return StatusDegraded
+	case starting > 0:
+		return StatusStarting
+	case running > 0:
+		return StatusRunning
+	case paused > 0:
+		return StatusPaused
+	default:
+		return StatusStopped
+	}
+}
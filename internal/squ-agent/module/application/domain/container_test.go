@@ -0,0 +1,30 @@
+package domain
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	running := Container{State: ContainerRunning}
+	tests := []struct {
+		name       string
+		containers []Container
+		want       string
+	}{
+		{"none", nil, StatusUndeploy},
+		{"running", []Container{running, running}, StatusRunning},
+		{"one-shot finished", []Container{running, {State: ContainerExited}}, StatusRunning},
+		{"crash loop", []Container{running, running, {State: ContainerRestarting, RestartCount: 7}}, StatusDegraded},
+		{"crashed", []Container{running, {State: ContainerExited, ExitCode: 1}}, StatusDegraded},
+		{"unhealthy", []Container{running, {State: ContainerRunning, Health: HealthUnhealthy}}, StatusDegraded},
+		{"health starting", []Container{running, {State: ContainerRunning, Health: HealthStarting}}, StatusStarting},
+		{"creating", []Container{running, {State: ContainerCreated}}, StatusStarting},
+		{"paused", []Container{{State: ContainerPaused}}, StatusPaused},
+		{"stopped", []Container{{State: ContainerExited, ExitCode: 137}, {State: ContainerExited}}, StatusStopped},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Summarize(test.containers); got != test.want {
+				t.Fatalf("Summarize() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Files are the compose file and the env file of a deployment as they are
+// on disk. Env is empty when the deployment has no env file.
+type Files struct {
+	Compose string
+	Env     string
+}
+
+// FileHashes are SHA-256 digests of Files in hex. A missing file has an
+// empty hash.
+type FileHashes struct {
+	Compose string
+	Env     string
+}
+
+func (f Files) Hashes() FileHashes {
+	return FileHashes{Compose: hashFile(f.Compose), Env: hashFile(f.Env)}
+}
+
+// EnvValues parses the env file into its values by key. Blank lines and
+// comments are skipped and a later line wins over an earlier one, as in
+// compose.
+func (f Files) EnvValues() map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(f.Env, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		values[strings.TrimSpace(key)] = value
+	}
+	return values
+}
+
+func hashFile(content string) string {
+	if content == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Health check types. A compose check passes when every container runs and
+// the ones with a docker health check report healthy.
+const (
+	CheckCompose = "compose"
+	CheckHTTP    = "http"
+	CheckTCP     = "tcp"
+)
+
+// DefaultHealthTimeout bounds the checks of a health check without a timeout.
+const DefaultHealthTimeout = 60 * time.Second
+
+// HealthCheck is verified after a deploy brings the project up. Timeout is
+// the number of seconds all checks get to pass together.
+type HealthCheck struct {
+	Timeout int     `json:"timeout"`
+	Checks  []Check `json:"checks"`
+}
+
+// Check is one health check. URL and Status belong to HTTP checks, where a
+// zero Status accepts any 2xx response, and Address to TCP checks.
+type Check struct {
+	Type    string `json:"type"`
+	URL     string `json:"url,omitempty"`
+	Status  int    `json:"status,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+func (h HealthCheck) Duration() time.Duration {
+	if h.Timeout <= 0 {
+		return DefaultHealthTimeout
+	}
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// HealthChecker runs one check once and returns why it failed.
+type HealthChecker interface {
+	Check(ctx context.Context, deployID uint64, check Check) error
+}
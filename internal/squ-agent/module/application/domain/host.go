@@ -0,0 +1,26 @@
+package domain
+
+// HostResources are what a host already uses that a new compose project can
+// collide with: listening sockets and the names of docker containers,
+// volumes and networks.
+type HostResources struct {
+	Listeners  []Listener `json:"listeners"`
+	Containers []string   `json:"containers"`
+	Volumes    []string   `json:"volumes"`
+	Networks   []string   `json:"networks"`
+}
+
+// Listener is a listening TCP socket or a bound UDP socket. IP is 0.0.0.0 or
+// :: for sockets bound to every address.
+type Listener struct {
+	Protocol string `json:"protocol"`
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+}
+
+// HostInspector reads the resources of the host. Resources of the compose
+// project of exclude are left out, so that a deployment does not collide
+// with itself.
+type HostInspector interface {
+	Resources(exclude uint64) (HostResources, error)
+}
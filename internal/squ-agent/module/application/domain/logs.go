@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidLogOptions = errors.New("invalid log options")
+
+// serviceName matches compose service names; it also keeps user input from
+// being read as a flag by docker compose.
+var serviceName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// LogOptions selects container logs like the flags of docker compose logs.
+// A negative Tail returns every line. Since and Until take an RFC 3339 time,
+// a Unix timestamp or a duration relative to now such as "10m".
+type LogOptions struct {
+	Services   []string
+	Tail       int
+	Since      string
+	Until      string
+	Timestamps bool
+	Follow     bool
+}
+
+func (o LogOptions) Validate() error {
+	for _, service := range o.Services {
+		if !serviceName.MatchString(service) {
+			return ErrInvalidLogOptions
+		}
+	}
+	if !validLogTime(o.Since) || !validLogTime(o.Until) {
+		return ErrInvalidLogOptions
+	}
+	return nil
+}
+
+func validLogTime(value string) bool {
+	if value == "" {
+		return true
+	}
+	if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return true
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return duration >= 0
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	return err == nil && seconds >= 0
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+var ErrOutputNotFound = errors.New("no compose output recorded")
+
+// OutputLine is one line written by a compose command.
+type OutputLine struct {
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream"`
+	Text   string    `json:"text"`
+}
+
+// Run is the output of one compose action of a deployment. Only the newest
+// lines are kept when the output is too long; Truncated is set then.
+type Run struct {
+	DeployID   uint64       `json:"deploy_id"`
+	Action     string       `json:"action"`
+	Status     string       `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	Truncated  bool         `json:"truncated,omitempty"`
+	Lines      []OutputLine `json:"lines"`
+}
+
+// Output receives the stdout and stderr of one compose command.
+type Output interface {
+	Stdout() io.Writer
+	Stderr() io.Writer
+}
+
+// OutputRecorder records one run. Finish must be called once the command
+// exited.
+type OutputRecorder interface {
+	Output
+	Finish(error)
+}
+
+type OutputStore interface {
+	Begin(deployID uint64, action string) OutputRecorder
+	// Last returns the current or most recent run of a deployment, or
+	// ErrOutputNotFound.
+	Last(deployID uint64) (Run, error)
+	// Watch returns the current or most recent run. While that run is still
+	// going, lines receives its new output and is closed when it ends;
+	// otherwise lines is nil. cancel releases the subscription.
+	Watch(deployID uint64) (run Run, lines <-chan OutputLine, cancel func(), err error)
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Desired states of a deployment. Deploys and starts want it running, stops
+// want it stopped.
+const (
+	DesiredRunning = "running"
+	DesiredStopped = "stopped"
+)
+
+// Reconcile actions. ActionGiveUp records that the agent stopped correcting
+// a deployment that keeps drifting.
+const (
+	ActionStart  = "start"
+	ActionStop   = "stop"
+	ActionGiveUp = "give_up"
+)
+
+// DriftEvent records one corrective action of the reconcile loop: what was
+// observed, what was wanted and how the action went. Error is empty when the
+// action succeeded. Attempt counts the actions since the deployment was last
+// seen in its desired state.
+type DriftEvent struct {
+	ID        uint
+	DeployID  uint64
+	Observed  string
+	Desired   string
+	Action    string
+	Attempt   int
+	Error     string
+	CreatedAt time.Time
+}
+
+type DriftRepository interface {
+	Add(context.Context, *DriftEvent) error
+	// Unreported returns the events not yet accepted by the apiserver,
+	// oldest first.
+	Unreported(context.Context) ([]DriftEvent, error)
+	MarkReported(context.Context, uint) error
+}
+
+// ReconcileAction returns the action that brings a deployment observed in
+// status back to desired, or "" when there is nothing to do. Degraded
+// deployments that should run are left to their restart policies, paused
+// ones to whoever paused them, and starting ones are not stopped.
+func ReconcileAction(desired, observed string) string {
+	switch desired {
+	case DesiredRunning:
+		switch observed {
+		case StatusStopped, StatusUndeploy:
+			return ActionStart
+		}
+	case DesiredStopped:
+		switch observed {
+		case StatusRunning, StatusDegraded:
+			return ActionStop
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"strings"
+)
+
+// dockerHub is the registry of images whose reference names no registry.
+const dockerHub = "docker.io"
+
+// RegistryAuth holds the credentials of a private registry that the images
+// of a deployment are pulled from. They come with each deploy and are never
+// stored. Server is the registry host as image references name it, with an
+// optional port.
+type RegistryAuth struct {
+	Server   string
+	Username string
+	Password string
+}
+
+// Host returns the registry host of Server without scheme or path.
+func (r RegistryAuth) Host() string {
+	host := strings.TrimPrefix(strings.TrimPrefix(r.Server, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case "", "index.docker.io", "registry-1.docker.io":
+		return dockerHub
+	}
+	return host
+}
+
+// Serves reports whether image is pulled from the registry.
+func (r RegistryAuth) Serves(image string) bool {
+	return imageRegistry(image) == r.Host()
+}
+
+// imageRegistry returns the registry host of an image reference. Like
+// docker, it only reads the first path component as a host when it looks
+// like one.
+func imageRegistry(image string) string {
+	first, rest, found := strings.Cut(image, "/")
+	if !found || rest == "" {
+		return dockerHub
+	}
+	if first != "localhost" && !strings.ContainsAny(first, ".:") && strings.ToLower(first) == first {
+		return dockerHub
+	}
+	return first
+}
+
+// ImageRegistry talks to registries through the docker engine.
+type ImageRegistry interface {
+	// Login checks the credentials with the registry.
+	Login(context.Context, RegistryAuth) error
+	// Pull pulls an image with the credentials and writes its progress to
+	// output.
+	Pull(context.Context, string, RegistryAuth, Output) error
+}
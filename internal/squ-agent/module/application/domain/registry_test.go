@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestRegistryServesImages(t *testing.T) {
+	cases := []struct {
+		server string
+		image  string
+		want   bool
+	}{
+		{"registry.example.com", "registry.example.com/shop/web:1", true},
+		{"https://registry.example.com/", "registry.example.com/web", true},
+		{"registry.example.com:5000", "registry.example.com:5000/web@sha256:abc", true},
+		{"registry.example.com:5000", "registry.example.com/web", false},
+		{"localhost", "localhost/web", true},
+		{"registry.example.com", "nginx:1.27", false},
+		{"registry.example.com", "shop/web", false},
+		{"https://index.docker.io/v1/", "shop/web", true},
+		{"docker.io", "nginx", true},
+		{"docker.io", "Shop/web", false},
+	}
+	for _, c := range cases {
+		auth := RegistryAuth{Server: c.server}
+		if got := auth.Serves(c.image); got != c.want {
+			t.Errorf("RegistryAuth{Server: %q}.Serves(%q) = %v, want %v", c.server, c.image, got, c.want)
+		}
+	}
+}
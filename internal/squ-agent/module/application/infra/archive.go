@@ -0,0 +1,64 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/moby/moby/api/types/jsonstream"
+	"github.com/moby/moby/client"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/pkg/docker"
+)
+
+// DockerArchiver saves images to bundles and loads bundles with the docker
+// engine, like docker save and docker load.
+type DockerArchiver struct {
+	docker *docker.Docker
+}
+
+func NewDockerArchiver() (*DockerArchiver, error) {
+	cli, err := client.New(client.FromEnv)
+	if err != nil {
+		return nil, err
+	}
+	return &DockerArchiver{docker: &docker.Docker{Client: cli}}, nil
+}
+
+func (d *DockerArchiver) Save(ctx context.Context, images []string, path string) error {
+	return d.docker.SaveImagesToTar(ctx, images, path)
+}
+
+// Load writes the images the engine loaded, one per line.
+func (d *DockerArchiver) Load(ctx context.Context, path string, output domain.Output) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	response, err := d.docker.Client.ImageLoad(ctx, file, client.ImageLoadWithQuiet(true))
+	if err != nil {
+		return err
+	}
+	defer response.Close()
+	decoder := json.NewDecoder(response)
+	for {
+		var message jsonstream.Message
+		if err := decoder.Decode(&message); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if message.Error != nil {
+			return message.Error
+		}
+		if line := strings.TrimSpace(message.Stream); line != "" {
+			fmt.Fprintln(output.Stdout(), line)
+		}
+	}
+}
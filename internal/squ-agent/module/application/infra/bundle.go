@@ -0,0 +1,170 @@
+package infra
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+const (
+	bundleExt  = ".tar"
+	partialExt = ".part"
+)
+
+// BundleStore keeps complete bundles as <checksum>.tar and the ones still
+// arriving as <checksum>.tar.part, so that a broken transfer resumes from
+// the size of the partial file.
+type BundleStore struct {
+	basePath string
+	mu       sync.Mutex
+}
+
+func NewBundleStore(basePath string) *BundleStore {
+	if basePath == "" {
+		basePath = "bundles"
+	}
+	return &BundleStore{basePath: basePath}
+}
+
+func (s *BundleStore) Get(checksum string) (domain.Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state(checksum)
+}
+
+func (s *BundleStore) Append(checksum string, offset int64, chunk []byte) (domain.Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bundle, err := s.state(checksum)
+	if err != nil || bundle.Complete {
+		return bundle, err
+	}
+	if offset != bundle.Size {
+		return bundle, fmt.Errorf("%w: offset %d, received %d bytes", domain.ErrBundleOffset, offset, bundle.Size)
+	}
+	if err := os.MkdirAll(s.basePath, 0o755); err != nil {
+		return bundle, err
+	}
+	file, err := os.OpenFile(s.file(checksum)+partialExt, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return bundle, err
+	}
+	written, err := file.Write(chunk)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	bundle.Size += int64(written)
+	return bundle, err
+}
+
+func (s *BundleStore) Complete(checksum string) (domain.Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bundle, err := s.state(checksum)
+	if err != nil || bundle.Complete {
+		return bundle, err
+	}
+	partial := s.file(checksum) + partialExt
+	actual, _, err := fileChecksum(partial)
+	if errors.Is(err, fs.ErrNotExist) {
+		return bundle, domain.ErrBundleNotFound
+	}
+	if err != nil {
+		return bundle, err
+	}
+	if actual != checksum {
+		_ = os.Remove(partial)
+		return domain.Bundle{Checksum: checksum}, fmt.Errorf("%w: received %s", domain.ErrBundleChecksum, actual)
+	}
+	if err := os.Rename(partial, s.file(checksum)); err != nil {
+		return bundle, err
+	}
+	bundle.Complete = true
+	return bundle, nil
+}
+
+func (s *BundleStore) Save(write func(path string) error) (domain.Bundle, error) {
+	if err := os.MkdirAll(s.basePath, 0o755); err != nil {
+		return domain.Bundle{}, err
+	}
+	temp, err := os.CreateTemp(s.basePath, "save-*"+partialExt)
+	if err != nil {
+		return domain.Bundle{}, err
+	}
+	path := temp.Name()
+	_ = temp.Close()
+	defer os.Remove(path)
+	if err := write(path); err != nil {
+		return domain.Bundle{}, err
+	}
+	checksum, size, err := fileChecksum(path)
+	if err != nil {
+		return domain.Bundle{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Rename(path, s.file(checksum)); err != nil {
+		return domain.Bundle{}, err
+	}
+	_ = os.Remove(s.file(checksum) + partialExt)
+	return domain.Bundle{Checksum: checksum, Size: size, Complete: true}, nil
+}
+
+func (s *BundleStore) Path(checksum string) (string, error) {
+	bundle, err := s.Get(checksum)
+	if err != nil {
+		return "", err
+	}
+	if !bundle.Complete {
+		return "", domain.ErrBundleNotFound
+	}
+	return s.file(checksum), nil
+}
+
+func (s *BundleStore) state(checksum string) (domain.Bundle, error) {
+	if !domain.ValidChecksum(checksum) {
+		return domain.Bundle{}, domain.ErrBundleNotFound
+	}
+	bundle := domain.Bundle{Checksum: checksum}
+	if info, err := os.Stat(s.file(checksum)); err == nil {
+		bundle.Size, bundle.Complete = info.Size(), true
+		return bundle, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return bundle, err
+	}
+	info, err := os.Stat(s.file(checksum) + partialExt)
+	if errors.Is(err, fs.ErrNotExist) {
+		return bundle, nil
+	}
+	if err != nil {
+		return bundle, err
+	}
+	bundle.Size = info.Size()
+	return bundle, nil
+}
+
+func (s *BundleStore) file(checksum string) string {
+	return filepath.Join(s.basePath, checksum+bundleExt)
+}
+
+func fileChecksum(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
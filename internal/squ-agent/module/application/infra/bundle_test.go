@@ -0,0 +1,61 @@
+package infra
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"testing"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+func TestBundleArrivesInChunks(t *testing.T) {
+	store := NewBundleStore(t.TempDir())
+	content := []byte("manifest.json and layers")
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	if _, err := store.Append(checksum, 0, content[:8]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append(checksum, 4, content[4:]); !errors.Is(err, domain.ErrBundleOffset) {
+		t.Fatalf("Append() at a wrong offset error = %v", err)
+	}
+	bundle, err := store.Get(checksum)
+	if err != nil || bundle.Size != 8 || bundle.Complete {
+		t.Fatalf("bundle = %+v, err = %v", bundle, err)
+	}
+	if _, err := store.Append(checksum, 8, content[8:]); err != nil {
+		t.Fatal(err)
+	}
+	if bundle, err = store.Complete(checksum); err != nil || !bundle.Complete || bundle.Size != int64(len(content)) {
+		t.Fatalf("bundle = %+v, err = %v", bundle, err)
+	}
+	path, err := store.Path(checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(content) {
+		t.Fatalf("content = %q", data)
+	}
+}
+
+func TestCorruptBundleIsDropped(t *testing.T) {
+	store := NewBundleStore(t.TempDir())
+	sum := sha256.Sum256([]byte("expected"))
+	checksum := hex.EncodeToString(sum[:])
+
+	if _, err := store.Append(checksum, 0, []byte("received")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Complete(checksum); !errors.Is(err, domain.ErrBundleChecksum) {
+		t.Fatalf("Complete() error = %v, want %v", err, domain.ErrBundleChecksum)
+	}
+	if bundle, err := store.Get(checksum); err != nil || bundle.Size != 0 {
+		t.Fatalf("bundle = %+v, err = %v", bundle, err)
+	}
+	if _, err := store.Get("../../etc/passwd"); !errors.Is(err, domain.ErrBundleNotFound) {
+		t.Fatalf("Get() of a path error = %v", err)
+	}
+}
@@ -1,23 +1,43 @@
 package infra
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"squirrel-dev/internal/squ-agent/module/application/domain"
 	"squirrel-dev/pkg/execute"
 )
 
+// composeTimeout bounds one compose command. Pulling images makes up the
+// slowest of them.
+const composeTimeout = 15 * time.Minute
+
+const composeFileName = "docker-compose.yml"
+
 type ComposeRuntime struct {
 	basePath string
+	timeout  time.Duration
+
+	mu    sync.Mutex
+	locks map[uint64]chan struct{}
 }
 
 func NewComposeRuntime(basePath string) *ComposeRuntime {
 	if basePath == "" {
 		basePath = "."
 	}
-	return &ComposeRuntime{basePath: basePath}
+	return &ComposeRuntime{basePath: basePath, timeout: composeTimeout, locks: map[uint64]chan struct{}{}}
 }
 
 func (r *ComposeRuntime) DockerInstalled() bool {
@@ -30,12 +50,15 @@ func (r *ComposeRuntime) ComposeAvailable() bool {
 	return err == nil
 }
 
+// Prepare writes the compose file and the .env file of a deployment. The env
+// can hold secret values, so the .env file is only readable by the agent
+// user and a stale one is removed when there is no env.
 func (r *ComposeRuntime) Prepare(deployID uint64, content string, env []map[string]string) (string, string, error) {
 	path := r.Path(deployID)
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return "", "", fmt.Errorf("failed to create compose directory: %w", err)
 	}
-	composeFile := filepath.Join(path, "docker-compose.yml")
+	composeFile := filepath.Join(path, composeFileName)
 	if _, err := os.Stat(composeFile); err == nil {
 		if err := os.Remove(composeFile); err != nil {
 			return "", "", fmt.Errorf("failed to delete existing docker-compose file: %w", err)
@@ -44,6 +67,10 @@ func (r *ComposeRuntime) Prepare(deployID uint64, content string, env []map[stri
 	if err := os.WriteFile(composeFile, []byte(content), 0644); err != nil {
 		return "", "", fmt.Errorf("failed to create docker-compose file: %w", err)
 	}
+	envFile := filepath.Join(path, ".env")
+	if err := os.Remove(envFile); err != nil && !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("failed to delete existing .env file: %w", err)
+	}
 	if len(env) > 0 {
 		var lines []string
 		for _, item := range env {
@@ -55,15 +82,15 @@ func (r *ComposeRuntime) Prepare(deployID uint64, content string, env []map[stri
 		if envContent != "" {
 			envContent += "\n"
 		}
-		if err := os.WriteFile(filepath.Join(path, ".env"), []byte(envContent), 0644); err != nil {
+		if err := os.WriteFile(envFile, []byte(envContent), 0600); err != nil {
 			return "", "", fmt.Errorf("failed to create .env file: %w", err)
 		}
 	}
-	return path, "docker-compose.yml", nil
+	return path, composeFileName, nil
 }
 
 func (r *ComposeRuntime) ComposeFileExists(deployID uint64) bool {
-	_, err := os.Stat(filepath.Join(r.Path(deployID), "docker-compose.yml"))
+	_, err := os.Stat(filepath.Join(r.Path(deployID), composeFileName))
 	return !os.IsNotExist(err)
 }
 
@@ -71,9 +98,204 @@ func (r *ComposeRuntime) Path(deployID uint64) string {
 	return filepath.Join(r.basePath, fmt.Sprintf("%d", deployID))
 }
 
-func (r *ComposeRuntime) Up(path, file string) error    { return runCompose(path, file, "up", "-d") }
-func (r *ComposeRuntime) Start(path, file string) error { return runCompose(path, file, "start") }
-func (r *ComposeRuntime) Stop(path, file string) error  { return runCompose(path, file, "stop") }
+// Snapshot copies the compose file and the .env file into the .previous
+// directory of the deployment, replacing an older snapshot.
+func (r *ComposeRuntime) Snapshot(deployID uint64) (bool, error) {
+	path := r.Path(deployID)
+	previous := filepath.Join(path, ".previous")
+	if err := os.RemoveAll(previous); err != nil {
+		return false, fmt.Errorf("failed to delete previous snapshot: %w", err)
+	}
+	if !r.ComposeFileExists(deployID) {
+		return false, nil
+	}
+	if err := os.MkdirAll(previous, 0700); err != nil {
+		return false, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	for name, mode := range snapshotFiles {
+		if err := copyFile(filepath.Join(path, name), filepath.Join(previous, name), mode); err != nil {
+			return false, fmt.Errorf("failed to snapshot %s: %w", name, err)
+		}
+	}
+	return true, nil
+}
+
+// Restore puts the files of the last snapshot back in place of the current
+// ones.
+func (r *ComposeRuntime) Restore(deployID uint64) error {
+	path := r.Path(deployID)
+	previous := filepath.Join(path, ".previous")
+	if _, err := os.Stat(filepath.Join(previous, composeFileName)); err != nil {
+		return fmt.Errorf("no snapshot to restore: %w", err)
+	}
+	for name, mode := range snapshotFiles {
+		if err := os.Remove(filepath.Join(path, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %w", name, err)
+		}
+		if err := copyFile(filepath.Join(previous, name), filepath.Join(path, name), mode); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Files reads the files of a deployment. A missing env file is read as
+// empty, a missing compose file is an error.
+func (r *ComposeRuntime) Files(deployID uint64) (domain.Files, error) {
+	path := r.Path(deployID)
+	compose, err := os.ReadFile(filepath.Join(path, composeFileName))
+	if err != nil {
+		return domain.Files{}, err
+	}
+	env, err := os.ReadFile(filepath.Join(path, ".env"))
+	if err != nil && !os.IsNotExist(err) {
+		return domain.Files{}, err
+	}
+	return domain.Files{Compose: string(compose), Env: string(env)}, nil
+}
+
+// snapshotFiles are the files of a deployment a snapshot keeps, with their
+// modes.
+var snapshotFiles = map[string]os.FileMode{composeFileName: 0644, ".env": 0600}
+
+// copyFile copies src to dst. A missing src is not an error because a
+// deployment without env has no .env file.
+func copyFile(src, dst string, mode os.FileMode) error {
+	data, err := os.ReadFile(src)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, mode)
+}
+
+// Up, Start and Stop run one compose command for the project of a
+// deployment. Callers hold Lock of the deployment around them.
+func (r *ComposeRuntime) Up(ctx context.Context, deployID uint64, output domain.Output) error {
+	return r.run(ctx, deployID, output, "up", "-d")
+}
+
+func (r *ComposeRuntime) Start(ctx context.Context, deployID uint64, output domain.Output) error {
+	return r.run(ctx, deployID, output, "start")
+}
+
+func (r *ComposeRuntime) Stop(ctx context.Context, deployID uint64, output domain.Output) error {
+	return r.run(ctx, deployID, output, "stop")
+}
+
+// Logs runs docker compose logs for a deployment. It neither waits for
+// other commands nor times out because a followed log can run for hours.
+func (r *ComposeRuntime) Logs(ctx context.Context, deployID uint64, options domain.LogOptions, output domain.Output) error {
+	actions := []string{"logs", "--no-color"}
+	if options.Tail >= 0 {
+		actions = append(actions, "--tail", strconv.Itoa(options.Tail))
+	}
+	if options.Since != "" {
+		actions = append(actions, "--since", options.Since)
+	}
+	if options.Until != "" {
+		actions = append(actions, "--until", options.Until)
+	}
+	if options.Timestamps {
+		actions = append(actions, "--timestamps")
+	}
+	if options.Follow {
+		actions = append(actions, "--follow")
+	}
+	actions = append(actions, options.Services...)
+	cmd, err := r.command(ctx, deployID, actions)
+	if err != nil {
+		return err
+	}
+	cmd.Stdout, cmd.Stderr = output.Stdout(), output.Stderr()
+	return cmd.Run()
+}
+
+// Images lists the images of the services of a deployment, with variables
+// of its env file resolved.
+func (r *ComposeRuntime) Images(ctx context.Context, deployID uint64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	cmd, err := r.command(ctx, deployID, []string{"config", "--images"})
+	if err != nil {
+		return nil, err
+	}
+	var stdout bytes.Buffer
+	tail := &stderrTail{}
+	cmd.Stdout, cmd.Stderr = &stdout, tail
+	if err := cmd.Run(); err != nil {
+		if tail.String() != "" {
+			return nil, fmt.Errorf("%w: %s", err, tail)
+		}
+		return nil, err
+	}
+	var images []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !slices.Contains(images, line) {
+			images = append(images, line)
+		}
+	}
+	return images, nil
+}
+
+func (r *ComposeRuntime) run(ctx context.Context, deployID uint64, output domain.Output, actions ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	cmd, err := r.command(ctx, deployID, actions)
+	if err != nil {
+		return err
+	}
+	tail := &stderrTail{}
+	cmd.Stdout, cmd.Stderr = output.Stdout(), io.MultiWriter(output.Stderr(), tail)
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("docker compose %s did not finish within %s", actions[0], r.timeout)
+	}
+	if err != nil && tail.String() != "" {
+		return fmt.Errorf("%w: %s", err, tail)
+	}
+	return err
+}
+
+// command builds a compose command for the project of a deployment. The
+// project is named after the deploy ID and every path is passed explicitly,
+// so commands of different deployments can run side by side.
+func (r *ComposeRuntime) command(ctx context.Context, deployID uint64, actions []string) (*exec.Cmd, error) {
+	command, prefix, err := composeCommand()
+	if err != nil {
+		return nil, err
+	}
+	path, err := filepath.Abs(r.Path(deployID))
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, command, composeArgs(prefix, deployID, path, actions)...)
+	cmd.Dir = path
+	// Do not wait for children that keep the output open after compose
+	// was killed.
+	cmd.WaitDelay = 10 * time.Second
+	return cmd, nil
+}
+
+// Lock waits until no other action of the deployment holds its lock or ctx
+// is done, and returns the function that lets the next one take it.
+func (r *ComposeRuntime) Lock(ctx context.Context, deployID uint64) (func(), error) {
+	r.mu.Lock()
+	lock, ok := r.locks[deployID]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		r.locks[deployID] = lock
+	}
+	r.mu.Unlock()
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
 
 func composeCommand() (string, string, error) {
 	if _, err := execute.Command("docker-compose", "--version"); err == nil {
@@ -85,25 +307,47 @@ func composeCommand() (string, string, error) {
 	return "", "", fmt.Errorf("docker-compose command not available")
 }
 
-func runCompose(workDir, composeFile string, actions ...string) error {
-	command, prefix, err := composeCommand()
-	if err != nil {
-		return err
-	}
+func composeArgs(prefix string, deployID uint64, path string, actions []string) []string {
 	var args []string
-	if prefix == "" {
-		args = append([]string{"-f", composeFile}, actions...)
-	} else {
-		args = append([]string{prefix, "-f", composeFile}, actions...)
+	if prefix != "" {
+		args = append(args, prefix)
 	}
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+	args = append(args,
+		"-p", strconv.FormatUint(deployID, 10),
+		"--project-directory", path,
+		"-f", filepath.Join(path, composeFileName),
+	)
+	return append(args, actions...)
+}
+
+// stderrTail keeps the last lines a command wrote to stderr for its error.
+type stderrTail struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (s *stderrTail) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Keep the end of long outputs only.
+	if s.buffer.Len() > 64<<10 {
+		rest := bytes.Clone(s.buffer.Bytes()[s.buffer.Len()-4<<10:])
+		s.buffer.Reset()
+		s.buffer.Write(rest)
 	}
-	defer os.Chdir(currentDir)
-	if err := os.Chdir(workDir); err != nil {
-		return fmt.Errorf("failed to change to work directory: %w", err)
+	return s.buffer.Write(p)
+}
+
+func (s *stderrTail) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	text := strings.TrimSpace(s.buffer.String())
+	if text == "" {
+		return ""
 	}
-	_, _, err = execute.CommandError(command, args...)
-	return err
+	lines := strings.Split(text, "\n")
+	if len(lines) > 3 {
+		lines = lines[len(lines)-3:]
+	}
+	return strings.Join(lines, "; ")
 }
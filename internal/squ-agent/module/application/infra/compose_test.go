@@ -0,0 +1,156 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrepareWritesPrivateEnvFile(t *testing.T) {
+	runtime := NewComposeRuntime(t.TempDir())
+	path, _, err := runtime.Prepare(7, "services: {}\n", []map[string]string{{"DB_PASSWORD": "hunter2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envFile := filepath.Join(path, ".env")
+	info, err := os.Stat(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf(".env mode = %v, want 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(envFile); string(data) != "DB_PASSWORD=hunter2\n" {
+		t.Fatalf(".env = %q", data)
+	}
+
+	if _, _, err := runtime.Prepare(7, "services: {}\n", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(envFile); !os.IsNotExist(err) {
+		t.Fatalf("stale .env was kept: %v", err)
+	}
+}
+
+func TestSnapshotRestoresPreviousFiles(t *testing.T) {
+	runtime := NewComposeRuntime(t.TempDir())
+	if kept, err := runtime.Snapshot(7); err != nil || kept {
+		t.Fatalf("Snapshot() of a new deployment = %v, %v", kept, err)
+	}
+	path, _, err := runtime.Prepare(7, "services: {old: {}}\n", []map[string]string{{"A": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept, err := runtime.Snapshot(7); err != nil || !kept {
+		t.Fatalf("Snapshot() = %v, %v", kept, err)
+	}
+	if _, _, err := runtime.Prepare(7, "services: {new: {}}\n", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := runtime.Restore(7); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(path, "docker-compose.yml")); string(data) != "services: {old: {}}\n" {
+		t.Fatalf("docker-compose.yml = %q", data)
+	}
+	info, err := os.Stat(filepath.Join(path, ".env"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf(".env was not restored privately: %v, %v", info, err)
+	}
+}
+
+func TestFilesReadsDeploymentFiles(t *testing.T) {
+	runtime := NewComposeRuntime(t.TempDir())
+	if _, err := runtime.Files(7); !os.IsNotExist(err) {
+		t.Fatalf("error = %v, want not exist", err)
+	}
+	if _, _, err := runtime.Prepare(7, "services: {}\n", nil); err != nil {
+		t.Fatal(err)
+	}
+	files, err := runtime.Files(7)
+	if err != nil || files.Compose != "services: {}\n" || files.Env != "" {
+		t.Fatalf("files = %+v, err = %v", files, err)
+	}
+	if hashes := files.Hashes(); hashes.Compose == "" || hashes.Env != "" {
+		t.Fatalf("hashes = %+v", hashes)
+	}
+}
+
+// fakeCompose puts a docker-compose on PATH that records its arguments in
+// the deployment directory, fails to stop and hangs on start.
+func fakeCompose(t *testing.T) {
+	bin := t.TempDir()
+	script := `#!/bin/sh
+[ "$1" = "--version" ] && exit 0
+echo "$@" > args
+case "$*" in
+*" stop") echo "Error response from daemon: container is paused" >&2; exit 1 ;;
+*" start") exec sleep 5 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestComposeRunsInDeploymentProject(t *testing.T) {
+	fakeCompose(t)
+	runtime := NewComposeRuntime(t.TempDir())
+	path, _, err := runtime.Prepare(7, "services: {}\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := os.Getwd()
+	output := NewOutputStore(t.TempDir()).Begin(7, "up")
+	if err := runtime.Up(context.Background(), 7, output); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := os.Getwd(); current != dir {
+		t.Fatalf("working directory changed to %s", current)
+	}
+	args, _ := os.ReadFile(filepath.Join(path, "args"))
+	want := "-p 7 --project-directory " + path + " -f " + filepath.Join(path, "docker-compose.yml") + " up -d\n"
+	if string(args) != want {
+		t.Fatalf("args = %q\nwant   %q", args, want)
+	}
+
+	err = runtime.Stop(context.Background(), 7, output)
+	if err == nil || err.Error() != "exit status 1: Error response from daemon: container is paused" {
+		t.Fatalf("error = %v", err)
+	}
+
+	runtime.timeout = 50 * time.Millisecond
+	err = runtime.Start(context.Background(), 7, output)
+	if err == nil || !strings.Contains(err.Error(), "did not finish within 50ms") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestComposeCommandsOfADeploymentWaitForEachOther(t *testing.T) {
+	runtime := NewComposeRuntime(t.TempDir())
+	unlock, err := runtime.Lock(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := runtime.Lock(ctx, 7); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second lock of the deployment: %v", err)
+	}
+	other, err := runtime.Lock(context.Background(), 8)
+	if err != nil {
+		t.Fatalf("lock of another deployment: %v", err)
+	}
+	other()
+	unlock()
+	again, err := runtime.Lock(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again()
+}
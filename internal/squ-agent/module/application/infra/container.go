@@ -0,0 +1,172 @@
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/pkg/execute"
+)
+
+// Compose labels set on every container of a project. The project name is
+// the deployment directory's name, which is the deploy ID.
+const (
+	projectLabel = "com.docker.compose.project"
+	serviceLabel = "com.docker.compose.service"
+)
+
+// DockerInspector reads container state with the docker CLI.
+type DockerInspector struct{}
+
+func NewDockerInspector() DockerInspector { return DockerInspector{} }
+
+func (DockerInspector) Containers(deployID uint64) ([]domain.Container, error) {
+	output, stderr, err := execute.CommandError("docker", "ps", "--all", "--quiet", "--no-trunc",
+		"--filter", fmt.Sprintf("label=%s=%d", projectLabel, deployID))
+	if err != nil {
+		return nil, fmt.Errorf("docker ps failed: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	ids := strings.Fields(output)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	output, stderr, err = execute.CommandError("docker", append([]string{"inspect"}, ids...)...)
+	if err != nil {
+		return nil, fmt.Errorf("docker inspect failed: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	var containers []inspectedContainer
+	if err := json.Unmarshal([]byte(output), &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse docker inspect output: %w", err)
+	}
+	// Repository digests are best effort: the image may have been removed or
+	// built locally.
+	var images []inspectedImage
+	imageIDs := imageIDs(containers)
+	if output, _, err := execute.CommandError("docker", append([]string{"image", "inspect"}, imageIDs...)...); err == nil {
+		_ = json.Unmarshal([]byte(output), &images)
+	}
+	return toContainers(containers, images), nil
+}
+
+type inspectedContainer struct {
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	Image        string `json:"Image"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		Status     string `json:"Status"`
+		ExitCode   int    `json:"ExitCode"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+		Health     *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+type inspectedImage struct {
+	ID          string   `json:"Id"`
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+func imageIDs(containers []inspectedContainer) []string {
+	var ids []string
+	for _, container := range containers {
+		if !slices.Contains(ids, container.Image) {
+			ids = append(ids, container.Image)
+		}
+	}
+	return ids
+}
+
+func toContainers(containers []inspectedContainer, images []inspectedImage) []domain.Container {
+	digests := map[string]string{}
+	for _, image := range images {
+		if len(image.RepoDigests) > 0 {
+			digests[image.ID] = image.RepoDigests[0]
+		}
+	}
+	result := make([]domain.Container, 0, len(containers))
+	for _, container := range containers {
+		value := domain.Container{
+			Service:      container.Config.Labels[serviceLabel],
+			Name:         strings.TrimPrefix(container.Name, "/"),
+			ID:           container.ID,
+			State:        container.State.Status,
+			RestartCount: container.RestartCount,
+			ExitCode:     container.State.ExitCode,
+			Image:        container.Config.Image,
+			ImageDigest:  container.Image,
+			Ports:        toPorts(container),
+			StartedAt:    dockerTime(container.State.StartedAt),
+			FinishedAt:   dockerTime(container.State.FinishedAt),
+		}
+		if digest, ok := digests[container.Image]; ok {
+			value.ImageDigest = digest
+		}
+		if container.State.Health != nil {
+			value.Health = container.State.Health.Status
+		}
+		result = append(result, value)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Service != result[j].Service {
+			return result[i].Service < result[j].Service
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func toPorts(container inspectedContainer) []domain.Port {
+	var ports []domain.Port
+	for key, bindings := range container.NetworkSettings.Ports {
+		number, protocol, _ := strings.Cut(key, "/")
+		containerPort, err := strconv.Atoi(number)
+		if err != nil {
+			continue
+		}
+		for _, binding := range bindings {
+			hostPort, err := strconv.Atoi(binding.HostPort)
+			if err != nil {
+				continue
+			}
+			ports = append(ports, domain.Port{
+				HostIP: binding.HostIP, HostPort: hostPort,
+				ContainerPort: containerPort, Protocol: protocol,
+			})
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].HostPort != ports[j].HostPort {
+			return ports[i].HostPort < ports[j].HostPort
+		}
+		return ports[i].HostIP < ports[j].HostIP
+	})
+	return ports
+}
+
+// dockerTime parses a docker timestamp; docker uses the zero time for events
+// that have not happened.
+func dockerTime(value string) *time.Time {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || parsed.IsZero() || parsed.Year() <= 1 {
+		return nil
+	}
+	return &parsed
+}
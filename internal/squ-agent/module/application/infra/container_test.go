@@ -0,0 +1,62 @@
+package infra
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const inspectOutput = `[
+  {
+    "Id": "b2",
+    "Name": "/42-worker-1",
+    "Image": "sha256:bbb",
+    "RestartCount": 5,
+    "State": {"Status": "restarting", "ExitCode": 1, "StartedAt": "2026-01-02T03:04:05.5Z", "FinishedAt": "2026-01-02T03:04:07Z"},
+    "Config": {"Image": "worker:latest", "Labels": {"com.docker.compose.service": "worker"}},
+    "NetworkSettings": {"Ports": {}}
+  },
+  {
+    "Id": "a1",
+    "Name": "/42-web-1",
+    "Image": "sha256:aaa",
+    "RestartCount": 0,
+    "State": {"Status": "running", "ExitCode": 0, "StartedAt": "2026-01-02T03:04:05Z", "FinishedAt": "0001-01-01T00:00:00Z", "Health": {"Status": "healthy"}},
+    "Config": {"Image": "nginx:1.27", "Labels": {"com.docker.compose.service": "web"}},
+    "NetworkSettings": {"Ports": {
+      "80/tcp": [{"HostIp": "0.0.0.0", "HostPort": "8080"}, {"HostIp": "::", "HostPort": "8080"}],
+      "443/tcp": null
+    }}
+  }
+]`
+
+func TestToContainers(t *testing.T) {
+	var containers []inspectedContainer
+	if err := json.Unmarshal([]byte(inspectOutput), &containers); err != nil {
+		t.Fatal(err)
+	}
+	if ids := imageIDs(containers); len(ids) != 2 {
+		t.Fatalf("image IDs = %#v", ids)
+	}
+	images := []inspectedImage{{ID: "sha256:aaa", RepoDigests: []string{"nginx@sha256:1234"}}}
+	result := toContainers(containers, images)
+	if len(result) != 2 {
+		t.Fatalf("containers = %#v", result)
+	}
+	web, worker := result[0], result[1]
+	if web.Service != "web" || web.Name != "42-web-1" || web.State != "running" || web.Health != "healthy" {
+		t.Fatalf("web = %#v", web)
+	}
+	if web.ImageDigest != "nginx@sha256:1234" || web.Image != "nginx:1.27" {
+		t.Fatalf("web image = %q digest = %q", web.Image, web.ImageDigest)
+	}
+	if len(web.Ports) != 2 || web.Ports[0].HostPort != 8080 || web.Ports[0].ContainerPort != 80 || web.Ports[0].Protocol != "tcp" {
+		t.Fatalf("web ports = %#v", web.Ports)
+	}
+	if web.StartedAt == nil || !web.StartedAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) || web.FinishedAt != nil {
+		t.Fatalf("web times = %v %v", web.StartedAt, web.FinishedAt)
+	}
+	if worker.State != "restarting" || worker.RestartCount != 5 || worker.ExitCode != 1 || worker.ImageDigest != "sha256:bbb" {
+		t.Fatalf("worker = %#v", worker)
+	}
+}
@@ -0,0 +1,59 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+type driftEventModel struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	DeployID  uint64 `gorm:"index"`
+	Observed  string
+	Desired   string
+	Action    string
+	Attempt   int
+	Error     string
+	Reported  bool `gorm:"index"`
+}
+
+func (driftEventModel) TableName() string { return "drift_events" }
+
+type DriftRepository struct{ db *gorm.DB }
+
+func NewDriftRepository(db *gorm.DB) *DriftRepository { return &DriftRepository{db: db} }
+
+func (r *DriftRepository) Add(ctx context.Context, event *domain.DriftEvent) error {
+	model := driftEventModel{
+		DeployID: event.DeployID, Observed: event.Observed, Desired: event.Desired,
+		Action: event.Action, Attempt: event.Attempt, Error: event.Error,
+	}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	event.ID, event.CreatedAt = model.ID, model.CreatedAt
+	return nil
+}
+
+func (r *DriftRepository) Unreported(ctx context.Context) ([]domain.DriftEvent, error) {
+	var models []driftEventModel
+	if err := r.db.WithContext(ctx).Where("reported = ?", false).Order("id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.DriftEvent, 0, len(models))
+	for _, model := range models {
+		result = append(result, domain.DriftEvent{
+			ID: model.ID, DeployID: model.DeployID, Observed: model.Observed, Desired: model.Desired,
+			Action: model.Action, Attempt: model.Attempt, Error: model.Error, CreatedAt: model.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (r *DriftRepository) MarkReported(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&driftEventModel{}).Where("id = ?", id).Update("reported", true).Error
+}
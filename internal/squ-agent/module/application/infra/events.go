@@ -0,0 +1,82 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+)
+
+// containerActions are the container events that can change the status of
+// a deployment. Health events carry the status after a colon.
+var containerActions = map[events.Action]bool{
+	events.ActionCreate:  true,
+	events.ActionStart:   true,
+	events.ActionRestart: true,
+	events.ActionStop:    true,
+	events.ActionDie:     true,
+	events.ActionOOM:     true,
+	events.ActionPause:   true,
+	events.ActionUnPause: true,
+	events.ActionDestroy: true,
+}
+
+// DockerEvents follows the event stream of the docker engine.
+type DockerEvents struct {
+	client *client.Client
+}
+
+func NewDockerEvents() (*DockerEvents, error) {
+	cli, err := client.New(client.FromEnv)
+	if err != nil {
+		return nil, err
+	}
+	return &DockerEvents{client: cli}, nil
+}
+
+func (d *DockerEvents) Watch(ctx context.Context, ready func(), changed func(uint64)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	filters := client.Filters{}.Add("type", string(events.ContainerEventType)).Add("label", projectLabel)
+	result := d.client.Events(ctx, client.EventsListOptions{Filters: filters})
+	// The stream is open once Events returns, unless it failed right away.
+	select {
+	case err := <-result.Err:
+		return err
+	default:
+	}
+	ready()
+	for {
+		select {
+		case message := <-result.Messages:
+			if deployID, ok := eventDeployID(message); ok {
+				changed(deployID)
+			}
+		case err := <-result.Err:
+			if err == nil {
+				err = errors.New("docker event stream closed")
+			}
+			return err
+		}
+	}
+}
+
+// eventDeployID returns the deploy ID of the compose project of a container
+// event that can change a deployment's status. Projects not named after a
+// deploy ID were not started by the agent.
+func eventDeployID(message events.Message) (uint64, bool) {
+	if message.Type != events.ContainerEventType {
+		return 0, false
+	}
+	if !containerActions[message.Action] && !strings.HasPrefix(string(message.Action), string(events.ActionHealthStatus)) {
+		return 0, false
+	}
+	deployID, err := strconv.ParseUint(message.Actor.Attributes[projectLabel], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return deployID, true
+}
@@ -0,0 +1,36 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/events"
+)
+
+func TestEventDeployID(t *testing.T) {
+	message := func(action events.Action, project string) events.Message {
+		return events.Message{Type: events.ContainerEventType, Action: action,
+			Actor: events.Actor{Attributes: map[string]string{projectLabel: project}}}
+	}
+	cases := []struct {
+		name    string
+		message events.Message
+		want    uint64
+		ok      bool
+	}{
+		{"start", message(events.ActionStart, "42"), 42, true},
+		{"health", message(events.ActionHealthStatus+": unhealthy", "42"), 42, true},
+		{"die", message(events.ActionDie, "7"), 7, true},
+		{"exec", message(events.ActionExecStart+": sh", "42"), 0, false},
+		{"foreign project", message(events.ActionStart, "monitoring"), 0, false},
+		{"network", events.Message{Type: events.NetworkEventType, Action: events.ActionConnect,
+			Actor: events.Actor{Attributes: map[string]string{projectLabel: "42"}}}, 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			deployID, ok := eventDeployID(c.message)
+			if deployID != c.want || ok != c.ok {
+				t.Fatalf("eventDeployID = %d, %v, want %d, %v", deployID, ok, c.want, c.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+// checkTimeout bounds one attempt of an HTTP or TCP check.
+const checkTimeout = 5 * time.Second
+
+type HealthChecker struct {
+	containers domain.ContainerInspector
+	client     *http.Client
+}
+
+func NewHealthChecker(containers domain.ContainerInspector) *HealthChecker {
+	return &HealthChecker{
+		containers: containers,
+		// Redirects are followed; the check judges the final response.
+		client: &http.Client{Timeout: checkTimeout},
+	}
+}
+
+func (c *HealthChecker) Check(ctx context.Context, deployID uint64, check domain.Check) error {
+	switch check.Type {
+	case domain.CheckCompose:
+		return c.compose(deployID)
+	case domain.CheckHTTP:
+		return c.http(ctx, check)
+	case domain.CheckTCP:
+		return tcp(ctx, check.Address)
+	default:
+		return fmt.Errorf("unknown health check type %q", check.Type)
+	}
+}
+
+func (c *HealthChecker) compose(deployID uint64) error {
+	containers, err := c.containers.Containers(deployID)
+	if err != nil {
+		return fmt.Errorf("compose: %w", err)
+	}
+	if status := domain.Summarize(containers); status != domain.StatusRunning {
+		for _, container := range containers {
+			if container.State != domain.ContainerRunning && container.State != domain.ContainerExited ||
+				container.Health != "" && container.Health != domain.HealthHealthy {
+				return fmt.Errorf("compose: project is %s, %s is %s", status, container.Name, containerState(container))
+			}
+		}
+		return fmt.Errorf("compose: project is %s", status)
+	}
+	return nil
+}
+
+func containerState(container domain.Container) string {
+	if container.Health != "" {
+		return container.State + " (" + container.Health + ")"
+	}
+	return container.State
+}
+
+func (c *HealthChecker) http(ctx context.Context, check domain.Check) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return fmt.Errorf("http %s: %w", check.URL, err)
+	}
+	response, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("http %s: %w", check.URL, err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	if check.Status != 0 && response.StatusCode != check.Status ||
+		check.Status == 0 && (response.StatusCode < 200 || response.StatusCode > 299) {
+		return fmt.Errorf("http %s: status %d", check.URL, response.StatusCode)
+	}
+	return nil
+}
+
+func tcp(ctx context.Context, address string) error {
+	dialer := net.Dialer{Timeout: checkTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("tcp %s: %w", address, err)
+	}
+	return conn.Close()
+}
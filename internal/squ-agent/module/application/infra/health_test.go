@@ -0,0 +1,58 @@
+package infra
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+type inspectorStub []domain.Container
+
+func (s inspectorStub) Containers(uint64) ([]domain.Container, error) { return s, nil }
+
+func TestHealthChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := listener.Addr().String()
+	listener.Close()
+
+	healthy := NewHealthChecker(inspectorStub{
+		{Name: "web", State: domain.ContainerRunning, Health: domain.HealthHealthy},
+		{Name: "migrate", State: domain.ContainerExited},
+	})
+	unhealthy := NewHealthChecker(inspectorStub{
+		{Name: "web", State: domain.ContainerRunning, Health: domain.HealthUnhealthy},
+	})
+	ctx := context.Background()
+	for _, tc := range []struct {
+		checker *HealthChecker
+		check   domain.Check
+		want    string
+	}{
+		{healthy, domain.Check{Type: domain.CheckCompose}, ""},
+		{unhealthy, domain.Check{Type: domain.CheckCompose}, "web is running (unhealthy)"},
+		{healthy, domain.Check{Type: domain.CheckHTTP, URL: server.URL + "/ok"}, ""},
+		{healthy, domain.Check{Type: domain.CheckHTTP, URL: server.URL + "/broken"}, "status 503"},
+		{healthy, domain.Check{Type: domain.CheckHTTP, URL: server.URL + "/broken", Status: 503}, ""},
+		{healthy, domain.Check{Type: domain.CheckTCP, Address: strings.TrimPrefix(server.URL, "http://")}, ""},
+		{healthy, domain.Check{Type: domain.CheckTCP, Address: closed}, "tcp " + closed},
+	} {
+		err := tc.checker.Check(ctx, 1, tc.check)
+		if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("Check(%+v) = %v, want %q", tc.check, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package infra
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+	"squirrel-dev/pkg/execute"
+)
+
+// Socket states in /proc/net: 0A is a listening TCP socket and 07 an
+// unconnected UDP socket.
+const (
+	tcpListen   = "0A"
+	udpUnbound  = "07"
+	procNetPath = "/proc/net"
+)
+
+// HostInspector reads listening sockets from /proc/net and docker objects
+// with the docker CLI.
+type HostInspector struct {
+	procNet    string
+	containers domain.ContainerInspector
+}
+
+func NewHostInspector(containers domain.ContainerInspector) *HostInspector {
+	return &HostInspector{procNet: procNetPath, containers: containers}
+}
+
+func (h *HostInspector) Resources(exclude uint64) (domain.HostResources, error) {
+	listeners, err := h.listeners()
+	if err != nil {
+		return domain.HostResources{}, err
+	}
+	project := ""
+	if exclude != 0 {
+		project = strconv.FormatUint(exclude, 10)
+		// Published ports are held by docker on behalf of the project.
+		containers, err := h.containers.Containers(exclude)
+		if err != nil {
+			return domain.HostResources{}, err
+		}
+		listeners = withoutPorts(listeners, containers)
+	}
+	result := domain.HostResources{Listeners: listeners}
+	for _, list := range []struct {
+		target *[]string
+		args   []string
+	}{
+		{&result.Containers, []string{"ps", "--all", "--format", `{{.Names}}\t{{.Label "` + projectLabel + `"}}`}},
+		{&result.Volumes, []string{"volume", "ls", "--format", `{{.Name}}\t{{.Label "` + projectLabel + `"}}`}},
+		{&result.Networks, []string{"network", "ls", "--format", `{{.Name}}\t{{.Label "` + projectLabel + `"}}`}},
+	} {
+		output, stderr, err := execute.CommandError("docker", list.args...)
+		if err != nil {
+			return domain.HostResources{}, fmt.Errorf("docker %s failed: %w: %s", list.args[0], err, strings.TrimSpace(stderr))
+		}
+		*list.target = dockerNames(output, project)
+	}
+	return result, nil
+}
+
+// dockerNames reads "name<TAB>project" lines and drops the objects of
+// project.
+func dockerNames(output, project string) []string {
+	names := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		name, owner, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if name == "" || project != "" && owner == project {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (h *HostInspector) listeners() ([]domain.Listener, error) {
+	seen := map[domain.Listener]bool{}
+	result := []domain.Listener{}
+	for _, file := range []struct{ name, protocol, state string }{
+		{"tcp", "tcp", tcpListen}, {"tcp6", "tcp", tcpListen},
+		{"udp", "udp", udpUnbound}, {"udp6", "udp", udpUnbound},
+	} {
+		listeners, err := readSockets(filepath.Join(h.procNet, file.name), file.protocol, file.state)
+		if err != nil {
+			return nil, err
+		}
+		for _, listener := range listeners {
+			if !seen[listener] {
+				seen[listener] = true
+				result = append(result, listener)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Port != result[j].Port {
+			return result[i].Port < result[j].Port
+		}
+		if result[i].Protocol != result[j].Protocol {
+			return result[i].Protocol < result[j].Protocol
+		}
+		return result[i].IP < result[j].IP
+	})
+	return result, nil
+}
+
+// readSockets parses a /proc/net socket table. A missing table means the
+// kernel lacks the protocol, for example IPv6.
+func readSockets(path, protocol, state string) ([]domain.Listener, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sockets: %w", err)
+	}
+	defer file.Close()
+	var result []domain.Listener
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != state {
+			continue
+		}
+		ip, port, err := parseSocketAddress(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		result = append(result, domain.Listener{Protocol: protocol, IP: ip, Port: port})
+	}
+	return result, scanner.Err()
+}
+
+// parseSocketAddress parses "0100007F:1F90". The address is stored as 32-bit
+// words in host byte order, which is little-endian on supported platforms.
+func parseSocketAddress(value string) (string, int, error) {
+	rawIP, rawPort, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid address %q", value)
+	}
+	port, err := strconv.ParseUint(rawPort, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %q", rawPort)
+	}
+	data, err := hex.DecodeString(rawIP)
+	if err != nil || len(data) != net.IPv4len && len(data) != net.IPv6len {
+		return "", 0, fmt.Errorf("invalid ip %q", rawIP)
+	}
+	for i := 0; i < len(data); i += 4 {
+		data[i], data[i+1], data[i+2], data[i+3] = data[i+3], data[i+2], data[i+1], data[i]
+	}
+	return net.IP(data).String(), int(port), nil
+}
+
+func withoutPorts(listeners []domain.Listener, containers []domain.Container) []domain.Listener {
+	published := map[string]bool{}
+	for _, container := range containers {
+		for _, port := range container.Ports {
+			published[fmt.Sprintf("%s/%d", port.Protocol, port.HostPort)] = true
+		}
+	}
+	result := listeners[:0:0]
+	for _, listener := range listeners {
+		if !published[fmt.Sprintf("%s/%d", listener.Protocol, listener.Port)] {
+			result = append(result, listener)
+		}
+	}
+	return result
+}
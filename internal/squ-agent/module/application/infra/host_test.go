@@ -0,0 +1,60 @@
+package infra
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+func TestHostListeners(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const header = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	write("tcp", header+
+		"   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1 1 0 100 0 0 10 0\n"+
+		"   1: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2 1 0 100 0 0 10 0\n"+
+		"   2: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000  1000        0 3 1 0 100 0 0 10 0\n")
+	write("tcp6", header+
+		"   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4 1 0 100 0 0 10 0\n"+
+		"   1: 00000000000000000000000001000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 5 1 0 100 0 0 10 0\n")
+	write("udp", header+
+		"   0: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 6 2 0\n")
+
+	inspector := &HostInspector{procNet: dir}
+	listeners, err := inspector.listeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.Listener{
+		{Protocol: "tcp", IP: "0.0.0.0", Port: 22},
+		{Protocol: "tcp", IP: "::", Port: 22},
+		{Protocol: "udp", IP: "127.0.0.53", Port: 53},
+		{Protocol: "tcp", IP: "::1", Port: 80},
+		{Protocol: "tcp", IP: "127.0.0.1", Port: 8080},
+	}
+	if !reflect.DeepEqual(listeners, want) {
+		t.Fatalf("listeners = %+v\nwant        %+v", listeners, want)
+	}
+
+	containers := []domain.Container{{Ports: []domain.Port{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}}}}
+	if got := withoutPorts(listeners, containers); len(got) != 4 || got[3].Port != 80 {
+		t.Fatalf("withoutPorts() = %+v", got)
+	}
+}
+
+func TestDockerNames(t *testing.T) {
+	output := "web\t42\nlegacy-db\t\n42_default\t42\nproxy\t7\n"
+	if got := dockerNames(output, "42"); !reflect.DeepEqual(got, []string{"legacy-db", "proxy"}) {
+		t.Fatalf("dockerNames() = %v", got)
+	}
+	if got := dockerNames("", ""); got == nil || len(got) != 0 {
+		t.Fatalf("dockerNames() = %#v, want an empty list", got)
+	}
+}
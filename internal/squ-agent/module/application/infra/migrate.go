@@ -16,4 +16,46 @@ func RegisterMigrations(registry *migration.MigrationRegistry) {
 			return db.Migrator().DropTable("servers")
 		},
 	)
+	registry.Register(
+		"1.0.1",
+		"应用健康检查结果",
+		func(db *gorm.DB) error { return db.AutoMigrate(&applicationModel{}) },
+		func(db *gorm.DB) error {
+			for _, column := range []string{"health_message", "health"} {
+				if err := db.Migrator().DropColumn(&applicationModel{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+	registry.Register(
+		"1.0.2",
+		"应用期望状态与漂移事件",
+		func(db *gorm.DB) error { return db.AutoMigrate(&applicationModel{}, &driftEventModel{}) },
+		func(db *gorm.DB) error {
+			if err := db.Migrator().DropTable(&driftEventModel{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"reconcile", "desired"} {
+				if err := db.Migrator().DropColumn(&applicationModel{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+	registry.Register(
+		"1.0.3",
+		"应用文件摘要",
+		func(db *gorm.DB) error { return db.AutoMigrate(&applicationModel{}) },
+		func(db *gorm.DB) error {
+			for _, column := range []string{"pushed_env_hash", "pushed_compose_hash"} {
+				if err := db.Migrator().DropColumn(&applicationModel{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
 }
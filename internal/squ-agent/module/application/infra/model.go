@@ -20,6 +20,17 @@ type applicationModel struct {
 	Version     string
 	DeployID    uint64
 	Env         []map[string]string `gorm:"type:json;serializer:json"`
+	// Health and HealthMessage are written by UpdateHealth only, so that the
+	// status job does not overwrite a health check result it read before.
+	Health        string
+	HealthMessage string
+	// Desired and Reconcile are written by UpdateDesired and UpdateReconcile
+	// only, for the same reason.
+	Desired   string
+	Reconcile bool
+	// PushedComposeHash and PushedEnvHash are written by UpdatePushed only.
+	PushedComposeHash string
+	PushedEnvHash     string
 }
 
 func (applicationModel) TableName() string { return "applications" }
@@ -0,0 +1,261 @@
+package infra
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+const (
+	maxOutputLines   = 10000
+	maxLineBytes     = 4096
+	subscriberBuffer = 256
+	// outputFileName lives next to docker-compose.yml; compose ignores it.
+	outputFileName = ".squirrel-output.json"
+)
+
+// OutputStore keeps the current or last compose run of every deployment in
+// memory and writes each finished run to the deployment's directory so it
+// survives an agent restart.
+type OutputStore struct {
+	basePath string
+
+	mu   sync.Mutex
+	runs map[uint64]*outputRecorder
+}
+
+func NewOutputStore(basePath string) *OutputStore {
+	if basePath == "" {
+		basePath = "."
+	}
+	return &OutputStore{basePath: basePath, runs: map[uint64]*outputRecorder{}}
+}
+
+func (s *OutputStore) Begin(deployID uint64, action string) domain.OutputRecorder {
+	recorder := &outputRecorder{
+		store:       s,
+		run:         domain.Run{DeployID: deployID, Action: action, Status: domain.RunRunning, StartedAt: time.Now()},
+		subscribers: map[int]chan domain.OutputLine{},
+	}
+	recorder.stdout = &lineWriter{recorder: recorder, stream: domain.OutputStdout}
+	recorder.stderr = &lineWriter{recorder: recorder, stream: domain.OutputStderr}
+	s.mu.Lock()
+	s.runs[deployID] = recorder
+	s.mu.Unlock()
+	return recorder
+}
+
+func (s *OutputStore) Last(deployID uint64) (domain.Run, error) {
+	s.mu.Lock()
+	recorder := s.runs[deployID]
+	s.mu.Unlock()
+	if recorder != nil {
+		return recorder.snapshot(), nil
+	}
+	return s.load(deployID)
+}
+
+func (s *OutputStore) Watch(deployID uint64) (domain.Run, <-chan domain.OutputLine, func(), error) {
+	s.mu.Lock()
+	recorder := s.runs[deployID]
+	s.mu.Unlock()
+	if recorder == nil {
+		run, err := s.load(deployID)
+		return run, nil, func() {}, err
+	}
+	run, lines, cancel := recorder.subscribe()
+	return run, lines, cancel, nil
+}
+
+func (s *OutputStore) file(deployID uint64) string {
+	return filepath.Join(s.basePath, fmt.Sprintf("%d", deployID), outputFileName)
+}
+
+func (s *OutputStore) load(deployID uint64) (domain.Run, error) {
+	data, err := os.ReadFile(s.file(deployID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return domain.Run{}, domain.ErrOutputNotFound
+	}
+	if err != nil {
+		return domain.Run{}, err
+	}
+	var run domain.Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return domain.Run{}, fmt.Errorf("failed to parse compose output: %w", err)
+	}
+	return run, nil
+}
+
+func (s *OutputStore) save(run domain.Run) error {
+	path := s.file(run.DeployID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+type outputRecorder struct {
+	store          *OutputStore
+	stdout, stderr *lineWriter
+
+	mu          sync.Mutex
+	run         domain.Run
+	done        bool
+	subscribers map[int]chan domain.OutputLine
+	next        int
+}
+
+func (r *outputRecorder) Stdout() io.Writer { return r.stdout }
+func (r *outputRecorder) Stderr() io.Writer { return r.stderr }
+
+func (r *outputRecorder) Finish(err error) {
+	r.stdout.flush()
+	r.stderr.flush()
+	r.mu.Lock()
+	if r.done {
+		r.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	r.run.Status, r.run.FinishedAt = domain.RunSucceeded, &now
+	if err != nil {
+		r.run.Status, r.run.Error = domain.RunFailed, err.Error()
+	}
+	r.trim(maxOutputLines)
+	r.done = true
+	for id, subscriber := range r.subscribers {
+		close(subscriber)
+		delete(r.subscribers, id)
+	}
+	run := r.copyRun()
+	r.mu.Unlock()
+	if err := r.store.save(run); err != nil {
+		zap.L().Warn("failed to save compose output",
+			zap.Uint64("deploy_id", run.DeployID),
+			zap.String("action", run.Action),
+			zap.Error(err),
+		)
+	}
+}
+
+func (r *outputRecorder) add(stream, text string) {
+	line := domain.OutputLine{Time: time.Now(), Stream: stream, Text: text}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return
+	}
+	r.run.Lines = append(r.run.Lines, line)
+	// Trim in steps so that long outputs do not copy the slice on every line.
+	if len(r.run.Lines) > maxOutputLines+maxOutputLines/10 {
+		r.trim(maxOutputLines)
+	}
+	for id, subscriber := range r.subscribers {
+		select {
+		case subscriber <- line:
+		default:
+			// A subscriber that cannot keep up is dropped rather than
+			// slowing down the command; it can watch again for a snapshot.
+			close(subscriber)
+			delete(r.subscribers, id)
+		}
+	}
+}
+
+func (r *outputRecorder) trim(limit int) {
+	if len(r.run.Lines) > limit {
+		r.run.Lines = slices.Clone(r.run.Lines[len(r.run.Lines)-limit:])
+		r.run.Truncated = true
+	}
+}
+
+func (r *outputRecorder) subscribe() (domain.Run, <-chan domain.OutputLine, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run := r.copyRun()
+	if r.done {
+		return run, nil, func() {}
+	}
+	id := r.next
+	r.next++
+	lines := make(chan domain.OutputLine, subscriberBuffer)
+	r.subscribers[id] = lines
+	cancel := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if subscriber, ok := r.subscribers[id]; ok {
+			close(subscriber)
+			delete(r.subscribers, id)
+		}
+	}
+	return run, lines, cancel
+}
+
+func (r *outputRecorder) snapshot() domain.Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.copyRun()
+}
+
+func (r *outputRecorder) copyRun() domain.Run {
+	run := r.run
+	run.Lines = slices.Clone(r.run.Lines)
+	return run
+}
+
+// lineWriter splits a command's output stream into lines.
+type lineWriter struct {
+	recorder *outputRecorder
+	stream   string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.recorder.add(w.stream, string(bytes.TrimSuffix(w.buf[:i], []byte("\r"))))
+		w.buf = w.buf[i+1:]
+	}
+	for len(w.buf) >= maxLineBytes {
+		w.recorder.add(w.stream, string(w.buf[:maxLineBytes]))
+		w.buf = w.buf[maxLineBytes:]
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.recorder.add(w.stream, string(bytes.TrimSuffix(w.buf, []byte("\r"))))
+		w.buf = nil
+	}
+}
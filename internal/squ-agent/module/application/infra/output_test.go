@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+func TestOutputStoreRecordsAndPersistsRuns(t *testing.T) {
+	dir := t.TempDir()
+	store := NewOutputStore(dir)
+	recorder := store.Begin(7, "up")
+	_, _ = recorder.Stdout().Write([]byte("Creating net"))
+	_, _ = recorder.Stdout().Write([]byte("work\r\nCreating web\n"))
+
+	run, lines, cancel, err := store.Watch(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if run.Status != domain.RunRunning || len(run.Lines) != 2 || run.Lines[0].Text != "Creating network" {
+		t.Fatalf("run = %#v", run)
+	}
+	_, _ = recorder.Stderr().Write([]byte("bind: address already in use"))
+	recorder.Finish(errors.New("exit status 1"))
+	var received []domain.OutputLine
+	for line := range lines {
+		received = append(received, line)
+	}
+	if len(received) != 1 || received[0].Stream != domain.OutputStderr || received[0].Text != "bind: address already in use" {
+		t.Fatalf("received = %#v", received)
+	}
+
+	// A new store reads the run back from the deployment directory.
+	run, err = NewOutputStore(dir).Last(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != domain.RunFailed || run.Error != "exit status 1" || len(run.Lines) != 3 || run.FinishedAt == nil {
+		t.Fatalf("run = %#v", run)
+	}
+	if _, err := store.Last(8); !errors.Is(err, domain.ErrOutputNotFound) {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestOutputStoreKeepsNewestLines(t *testing.T) {
+	store := NewOutputStore(t.TempDir())
+	recorder := store.Begin(1, "up")
+	for range maxOutputLines + maxOutputLines/10 + 5 {
+		_, _ = recorder.Stdout().Write([]byte("line\n"))
+	}
+	_, _ = recorder.Stdout().Write([]byte("last\n" + strings.Repeat("x", maxLineBytes+1)))
+	recorder.Finish(nil)
+	run, err := store.Last(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run.Truncated || len(run.Lines) != maxOutputLines || run.Lines[len(run.Lines)-1].Text != "x" ||
+		run.Lines[len(run.Lines)-3].Text != "last" || run.Status != domain.RunSucceeded {
+		t.Fatalf("lines = %d truncated = %v tail = %#v", len(run.Lines), run.Truncated, run.Lines[len(run.Lines)-3:])
+	}
+}
@@ -0,0 +1,75 @@
+package infra
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/moby/moby/api/types/registry"
+	"github.com/moby/moby/client"
+
+	"squirrel-dev/internal/squ-agent/module/application/domain"
+)
+
+// DockerRegistry logs in to registries and pulls images through the docker
+// engine. Credentials go with each request and are not kept by docker.
+type DockerRegistry struct {
+	client *client.Client
+}
+
+func NewDockerRegistry() (*DockerRegistry, error) {
+	cli, err := client.New(client.FromEnv)
+	if err != nil {
+		return nil, err
+	}
+	return &DockerRegistry{client: cli}, nil
+}
+
+func (d *DockerRegistry) Login(ctx context.Context, auth domain.RegistryAuth) error {
+	_, err := d.client.RegistryLogin(ctx, client.RegistryLoginOptions{
+		Username: auth.Username, Password: auth.Password, ServerAddress: auth.Host(),
+	})
+	return err
+}
+
+// Pull writes one line per status change of the pull; the progress of
+// single layers is left out.
+func (d *DockerRegistry) Pull(ctx context.Context, image string, auth domain.RegistryAuth, output domain.Output) error {
+	encoded, err := encodeAuth(auth)
+	if err != nil {
+		return err
+	}
+	response, err := d.client.ImagePull(ctx, image, client.ImagePullOptions{RegistryAuth: encoded})
+	if err != nil {
+		return err
+	}
+	for message, err := range response.JSONMessages(ctx) {
+		if err != nil {
+			return err
+		}
+		if message.Error != nil {
+			return message.Error
+		}
+		if message.Progress != nil || message.Status == "" {
+			continue
+		}
+		if message.ID != "" {
+			fmt.Fprintf(output.Stdout(), "%s: %s\n", message.ID, message.Status)
+		} else {
+			fmt.Fprintln(output.Stdout(), message.Status)
+		}
+	}
+	return nil
+}
+
+// encodeAuth encodes credentials for the X-Registry-Auth header.
+func encodeAuth(auth domain.RegistryAuth) (string, error) {
+	data, err := json.Marshal(registry.AuthConfig{
+		Username: auth.Username, Password: auth.Password, ServerAddress: auth.Host(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
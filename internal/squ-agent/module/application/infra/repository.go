@@ -57,6 +57,26 @@ func (r *Repository) Update(ctx context.Context, value *domain.Application) erro
 	return r.db.WithContext(ctx).Updates(toModel(*value)).Error
 }
 
+func (r *Repository) UpdateHealth(ctx context.Context, deployID uint64, health, message string) error {
+	return r.db.WithContext(ctx).Model(&applicationModel{}).Where("deploy_id = ?", deployID).
+		Updates(map[string]any{"health": health, "health_message": message}).Error
+}
+
+func (r *Repository) UpdateDesired(ctx context.Context, deployID uint64, desired string) error {
+	return r.db.WithContext(ctx).Model(&applicationModel{}).Where("deploy_id = ?", deployID).
+		Update("desired", desired).Error
+}
+
+func (r *Repository) UpdateReconcile(ctx context.Context, deployID uint64, reconcile bool) error {
+	return r.db.WithContext(ctx).Model(&applicationModel{}).Where("deploy_id = ?", deployID).
+		Update("reconcile", reconcile).Error
+}
+
+func (r *Repository) UpdatePushed(ctx context.Context, deployID uint64, hashes domain.FileHashes) error {
+	return r.db.WithContext(ctx).Model(&applicationModel{}).Where("deploy_id = ?", deployID).
+		Updates(map[string]any{"pushed_compose_hash": hashes.Compose, "pushed_env_hash": hashes.Env}).Error
+}
+
 func (r *Repository) Transaction(ctx context.Context, fn func(domain.Repository) error) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		return fn(NewRepository(tx))
@@ -76,5 +96,8 @@ func toDomain(value applicationModel) domain.Application {
 		ID: value.ID, Name: value.Name, Description: value.Description, Type: value.Type,
 		OldStatus: value.OldStatus, Status: value.Status, Content: value.Content,
 		Version: value.Version, DeployID: value.DeployID, Env: value.Env,
+		Health: value.Health, HealthMessage: value.HealthMessage,
+		Desired: value.Desired, Reconcile: value.Reconcile,
+		Pushed: domain.FileHashes{Compose: value.PushedComposeHash, Env: value.PushedEnvHash},
 	}
 }
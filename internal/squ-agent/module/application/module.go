@@ -2,6 +2,7 @@ package application
 
 import (
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"squirrel-dev/internal/pkg/migration"
@@ -9,6 +10,7 @@ import (
 	"squirrel-dev/internal/squ-agent/module/application/api"
 	"squirrel-dev/internal/squ-agent/module/application/api/res"
 	appService "squirrel-dev/internal/squ-agent/module/application/application"
+	appDomain "squirrel-dev/internal/squ-agent/module/application/domain"
 	appInfra "squirrel-dev/internal/squ-agent/module/application/infra"
 	configInfra "squirrel-dev/internal/squ-agent/module/config/infra"
 )
@@ -21,10 +23,29 @@ type Dependencies struct {
 
 func RegisterHTTP(group *gin.RouterGroup, dependencies Dependencies) {
 	res.RegisterCode()
+	inspector := appInfra.NewDockerInspector()
+	var registry appDomain.ImageRegistry
+	if value, err := appInfra.NewDockerRegistry(); err != nil {
+		zap.L().Warn("failed to create docker client, private registries are unavailable", zap.Error(err))
+	} else {
+		registry = value
+	}
+	var archiver appDomain.ImageArchiver
+	if value, err := appInfra.NewDockerArchiver(); err != nil {
+		zap.L().Warn("failed to create docker client, image bundles are unavailable", zap.Error(err))
+	} else {
+		archiver = value
+	}
 	service := appService.NewService(
 		appInfra.NewRepository(dependencies.AppDB),
 		appInfra.NewConfigStore(configInfra.NewRepository(dependencies.AgentDB)),
 		appInfra.NewComposeRuntime(dependencies.Config.Common.ComposePath),
+		appInfra.NewOutputStore(dependencies.Config.Common.ComposePath),
+		appInfra.NewHealthChecker(inspector),
+		appInfra.NewHostInspector(inspector),
+		registry,
+		appInfra.NewBundleStore(dependencies.Config.Common.BundlesPath),
+		archiver,
 	)
 	api.RegisterRoutes(group, api.NewHandler(service))
 }
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-agent/module/docker/api/res"
+	"squirrel-dev/internal/squ-agent/module/docker/application"
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+)
+
+// refParam returns the catch-all parameter name without its leading slash.
+func refParam(c *gin.Context, name string) string {
+	return strings.TrimPrefix(c.Param(name), "/")
+}
+
+func writeResult(c *gin.Context, data any, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(data))
+}
+
+// writeError carries the message of docker as data, except for missing
+// resources.
+func writeError(c *gin.Context, err error) {
+	if errors.Is(err, application.ErrInvalidPrune) {
+		c.JSON(http.StatusBadRequest, response.Error(response.ErrCodeParameter))
+		return
+	}
+	code := res.ErrDockerFailed
+	switch {
+	case errors.Is(err, domain.ErrUnavailable):
+		code = res.ErrDockerUnavailable
+	case errors.Is(err, domain.ErrNotFound):
+		code = res.ErrResourceNotFound
+	case errors.Is(err, domain.ErrInUse):
+		code = res.ErrResourceInUse
+	}
+	result := response.Error(code)
+	if code != res.ErrResourceNotFound {
+		result.Data = err.Error()
+	}
+	c.JSON(http.StatusOK, result)
+}
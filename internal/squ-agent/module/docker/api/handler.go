@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-agent/module/docker/api/req"
+	"squirrel-dev/internal/squ-agent/module/docker/application"
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+)
+
+type Handler struct {
+	service *application.Service
+}
+
+func NewHandler(service *application.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Containers(c *gin.Context) {
+	values, err := h.service.Containers(c.Request.Context())
+	writeResult(c, mapAll(values, fromContainer), err)
+}
+
+func (h *Handler) Images(c *gin.Context) {
+	values, err := h.service.Images(c.Request.Context())
+	writeResult(c, mapAll(values, fromImage), err)
+}
+
+func (h *Handler) Volumes(c *gin.Context) {
+	values, err := h.service.Volumes(c.Request.Context())
+	writeResult(c, mapAll(values, fromVolume), err)
+}
+
+func (h *Handler) Networks(c *gin.Context) {
+	values, err := h.service.Networks(c.Request.Context())
+	writeResult(c, mapAll(values, fromNetwork), err)
+}
+
+// Inspect serves the document docker inspect shows for a resource of kind.
+func (h *Handler) Inspect(kind domain.Kind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, err := h.service.Inspect(c.Request.Context(), kind, refParam(c, "id"))
+		writeResult(c, value, err)
+	}
+}
+
+// Remove removes a resource of kind; the force query removes containers
+// that run, images that containers use and volumes in use.
+func (h *Handler) Remove(kind domain.Kind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		force := c.Query("force") == "true"
+		writeResult(c, "success", h.service.Remove(c.Request.Context(), kind, refParam(c, "id"), force))
+	}
+}
+
+func (h *Handler) Prune(c *gin.Context) {
+	var value req.Prune
+	if err := c.ShouldBindJSON(&value); err != nil {
+		c.JSON(http.StatusBadRequest, response.Error(response.ErrCodeParameter))
+		return
+	}
+	report, err := h.service.Prune(c.Request.Context(), application.PruneRequest{
+		Containers: value.Containers, Images: value.Images, Volumes: value.Volumes,
+		AllVolumes: value.AllVolumes, DryRun: value.DryRun,
+	})
+	writeResult(c, fromPruneReport(report), err)
+}
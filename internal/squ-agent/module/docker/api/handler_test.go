@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-agent/module/docker/api/res"
+	"squirrel-dev/internal/squ-agent/module/docker/application"
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+)
+
+type engineStub struct{ domain.Engine }
+
+func (engineStub) Volumes(context.Context) ([]domain.Volume, error) {
+	return []domain.Volume{{Name: "shop_data", Driver: "local", Project: "shop", Size: 4096, Containers: 1}}, nil
+}
+func (engineStub) Inspect(_ context.Context, _ domain.Kind, id string) (json.RawMessage, error) {
+	return nil, fmt.Errorf("%w: No such volume: %s", domain.ErrNotFound, id)
+}
+func (engineStub) Remove(_ context.Context, _ domain.Kind, id string, force bool) error {
+	if force {
+		return nil
+	}
+	return fmt.Errorf("%w: remove %s: volume is in use", domain.ErrInUse, id)
+}
+
+func TestDockerResourceResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	res.RegisterCode()
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/api/v1"), NewHandler(application.NewService(engineStub{})))
+
+	for _, test := range []struct{ method, path, body, expected string }{
+		{http.MethodGet, "/api/v1/docker/volumes", "",
+			`{"code":0,"message":"success","data":[{"name":"shop_data","driver":"local","mountpoint":"","project":"shop","anonymous":false,"created":"","size":4096,"containers":1}]}`},
+		{http.MethodGet, "/api/v1/docker/volumes/gone", "", `{"code":60002,"message":"docker resource not found"}`},
+		{http.MethodDelete, "/api/v1/docker/volumes/shop_data", "",
+			`{"code":60003,"message":"docker resource is in use","data":"docker resource is in use: remove shop_data: volume is in use"}`},
+		{http.MethodDelete, "/api/v1/docker/volumes/shop_data?force=true", "", `{"code":0,"message":"success","data":"success"}`},
+		{http.MethodDelete, "/api/v1/docker/images/ghcr.io/org/app:1.0", "",
+			`{"code":60003,"message":"docker resource is in use","data":"docker resource is in use: remove ghcr.io/org/app:1.0: volume is in use"}`},
+		{http.MethodDelete, "/api/v1/docker/images/ghcr.io%2Forg%2Fapp:1.0?force=true", "", `{"code":0,"message":"success","data":"success"}`},
+		{http.MethodPost, "/api/v1/docker/prune", `{"dry_run":true}`, `{"code":41001,"message":"parameter error"}`},
+	} {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+		if recorder.Body.String() != test.expected {
+			t.Errorf("%s %s body = %s\nwant = %s", test.method, test.path, recorder.Body.String(), test.expected)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"squirrel-dev/internal/squ-agent/module/docker/api/res"
+	"squirrel-dev/internal/squ-agent/module/docker/application"
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+)
+
+func fromContainer(value domain.Container) res.Container {
+	volumes := value.Volumes
+	if volumes == nil {
+		volumes = []string{}
+	}
+	return res.Container{ID: value.ID, Name: value.Name, Image: value.Image, ImageID: value.ImageID,
+		State: value.State, Status: value.Status, Project: value.Project, Created: value.Created,
+		SizeRw: value.SizeRw, SizeRootFs: value.SizeRootFs, Volumes: volumes}
+}
+
+func fromImage(value domain.Image) res.Image {
+	tags := value.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return res.Image{ID: value.ID, Tags: tags, Dangling: value.Dangling(), Created: value.Created,
+		Size: value.Size, UniqueSize: value.UniqueSize, Containers: value.Containers}
+}
+
+func fromVolume(value domain.Volume) res.Volume {
+	return res.Volume{Name: value.Name, Driver: value.Driver, Mountpoint: value.Mountpoint, Project: value.Project,
+		Anonymous: value.Anonymous, Created: value.Created, Size: value.Size, Containers: value.Containers}
+}
+
+func fromNetwork(value domain.Network) res.Network {
+	return res.Network{ID: value.ID, Name: value.Name, Driver: value.Driver, Scope: value.Scope,
+		Project: value.Project, Internal: value.Internal, Created: value.Created}
+}
+
+func fromPruneReport(value application.PruneReport) res.PruneReport {
+	return res.PruneReport{DryRun: value.DryRun, Containers: fromPruneResult(value.Containers),
+		Images: fromPruneResult(value.Images), Volumes: fromPruneResult(value.Volumes), SpaceReclaimed: value.SpaceReclaimed}
+}
+
+func fromPruneResult(value *domain.PruneResult) *res.PruneResult {
+	if value == nil {
+		return nil
+	}
+	deleted := value.Deleted
+	if deleted == nil {
+		deleted = []string{}
+	}
+	return &res.PruneResult{Deleted: deleted, SpaceReclaimed: value.SpaceReclaimed}
+}
+
+// mapAll maps a listing, keeping an empty one a JSON array.
+func mapAll[T, R any](values []T, convert func(T) R) []R {
+	result := make([]R, 0, len(values))
+	for _, value := range values {
+		result = append(result, convert(value))
+	}
+	return result
+}
@@ -0,0 +1,11 @@
+package req
+
+// Prune selects what to prune. Volumes are anonymous ones unless
+// all_volumes is set; dry_run only reports what would be removed.
+type Prune struct {
+	Containers bool `json:"containers"`
+	Images     bool `json:"images"`
+	Volumes    bool `json:"volumes"`
+	AllVolumes bool `json:"all_volumes"`
+	DryRun     bool `json:"dry_run"`
+}
@@ -0,0 +1,66 @@
+package res
+
+import "time"
+
+// Container sizes are in bytes; size_rw is the writable layer and
+// size_root_fs the whole filesystem.
+type Container struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Image      string    `json:"image"`
+	ImageID    string    `json:"image_id"`
+	State      string    `json:"state"`
+	Status     string    `json:"status"`
+	Project    string    `json:"project,omitempty"`
+	Created    time.Time `json:"created"`
+	SizeRw     int64     `json:"size_rw"`
+	SizeRootFs int64     `json:"size_root_fs"`
+	Volumes    []string  `json:"volumes"`
+}
+
+// Image sizes are in bytes; unique_size is what removing the image frees.
+type Image struct {
+	ID         string    `json:"id"`
+	Tags       []string  `json:"tags"`
+	Dangling   bool      `json:"dangling"`
+	Created    time.Time `json:"created"`
+	Size       int64     `json:"size"`
+	UniqueSize int64     `json:"unique_size"`
+	Containers int64     `json:"containers"`
+}
+
+// Volume size is in bytes, or -1 when the driver does not report it.
+type Volume struct {
+	Name       string `json:"name"`
+	Driver     string `json:"driver"`
+	Mountpoint string `json:"mountpoint"`
+	Project    string `json:"project,omitempty"`
+	Anonymous  bool   `json:"anonymous"`
+	Created    string `json:"created"`
+	Size       int64  `json:"size"`
+	Containers int64  `json:"containers"`
+}
+
+type Network struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Driver   string    `json:"driver"`
+	Scope    string    `json:"scope"`
+	Project  string    `json:"project,omitempty"`
+	Internal bool      `json:"internal"`
+	Created  time.Time `json:"created"`
+}
+
+type PruneResult struct {
+	Deleted        []string `json:"deleted"`
+	SpaceReclaimed uint64   `json:"space_reclaimed"`
+}
+
+// PruneReport has a result for each kind that was pruned.
+type PruneReport struct {
+	DryRun         bool         `json:"dry_run"`
+	Containers     *PruneResult `json:"containers,omitempty"`
+	Images         *PruneResult `json:"images,omitempty"`
+	Volumes        *PruneResult `json:"volumes,omitempty"`
+	SpaceReclaimed uint64       `json:"space_reclaimed"`
+}
@@ -0,0 +1,17 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrDockerUnavailable = 60001
+	ErrResourceNotFound  = 60002
+	ErrResourceInUse     = 60003
+	ErrDockerFailed      = 60004
+)
+
+func RegisterCode() {
+	response.Register(ErrDockerUnavailable, "docker is not available on this agent")
+	response.Register(ErrResourceNotFound, "docker resource not found")
+	response.Register(ErrResourceInUse, "docker resource is in use")
+	response.Register(ErrDockerFailed, "docker request failed")
+}
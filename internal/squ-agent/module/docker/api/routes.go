@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+)
+
+// The ID of a resource is a catch-all, since image references such as
+// ghcr.io/org/app:1.0 contain slashes.
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/docker/containers", handler.Containers)
+	group.GET("/docker/containers/*id", handler.Inspect(domain.KindContainer))
+	group.DELETE("/docker/containers/*id", handler.Remove(domain.KindContainer))
+	group.GET("/docker/images", handler.Images)
+	group.GET("/docker/images/*id", handler.Inspect(domain.KindImage))
+	group.DELETE("/docker/images/*id", handler.Remove(domain.KindImage))
+	group.GET("/docker/volumes", handler.Volumes)
+	group.GET("/docker/volumes/*id", handler.Inspect(domain.KindVolume))
+	group.DELETE("/docker/volumes/*id", handler.Remove(domain.KindVolume))
+	group.GET("/docker/networks", handler.Networks)
+	group.GET("/docker/networks/*id", handler.Inspect(domain.KindNetwork))
+	group.DELETE("/docker/networks/*id", handler.Remove(domain.KindNetwork))
+	group.POST("/docker/prune", handler.Prune)
+}
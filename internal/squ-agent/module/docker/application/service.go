@@ -0,0 +1,204 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+)
+
+var ErrInvalidPrune = errors.New("prune names no resources")
+
+// PruneRequest selects what to prune: stopped containers, dangling images
+// and unused volumes. Only anonymous volumes are pruned unless AllVolumes
+// is set, as with docker volume prune. A dry run removes nothing and
+// reports what a prune would remove.
+type PruneRequest struct {
+	Containers bool
+	Images     bool
+	Volumes    bool
+	AllVolumes bool
+	DryRun     bool
+}
+
+// PruneReport holds a result for each kind that was pruned. On a dry run
+// the reclaimed space is an estimate from the sizes docker reports.
+type PruneReport struct {
+	DryRun         bool
+	Containers     *domain.PruneResult
+	Images         *domain.PruneResult
+	Volumes        *domain.PruneResult
+	SpaceReclaimed uint64
+}
+
+type Service struct {
+	engine domain.Engine
+}
+
+// NewService takes a nil engine when the agent has no docker client; every
+// call then fails with domain.ErrUnavailable.
+func NewService(engine domain.Engine) *Service {
+	return &Service{engine: engine}
+}
+
+func (s *Service) Containers(ctx context.Context) ([]domain.Container, error) {
+	if s.engine == nil {
+		return nil, domain.ErrUnavailable
+	}
+	return s.engine.Containers(ctx)
+}
+
+func (s *Service) Images(ctx context.Context) ([]domain.Image, error) {
+	if s.engine == nil {
+		return nil, domain.ErrUnavailable
+	}
+	return s.engine.Images(ctx)
+}
+
+func (s *Service) Volumes(ctx context.Context) ([]domain.Volume, error) {
+	if s.engine == nil {
+		return nil, domain.ErrUnavailable
+	}
+	return s.engine.Volumes(ctx)
+}
+
+func (s *Service) Networks(ctx context.Context) ([]domain.Network, error) {
+	if s.engine == nil {
+		return nil, domain.ErrUnavailable
+	}
+	return s.engine.Networks(ctx)
+}
+
+func (s *Service) Inspect(ctx context.Context, kind domain.Kind, id string) (json.RawMessage, error) {
+	if s.engine == nil {
+		return nil, domain.ErrUnavailable
+	}
+	return s.engine.Inspect(ctx, kind, id)
+}
+
+func (s *Service) Remove(ctx context.Context, kind domain.Kind, id string, force bool) error {
+	if s.engine == nil {
+		return domain.ErrUnavailable
+	}
+	if err := s.engine.Remove(ctx, kind, id, force); err != nil {
+		zap.L().Warn("failed to remove docker resource",
+			zap.String("kind", string(kind)), zap.String("id", id), zap.Bool("force", force), zap.Error(err))
+		return err
+	}
+	zap.L().Info("docker resource removed", zap.String("kind", string(kind)), zap.String("id", id), zap.Bool("force", force))
+	return nil
+}
+
+// Prune removes containers first, so that the images and volumes only they
+// used are pruned in the same run.
+func (s *Service) Prune(ctx context.Context, request PruneRequest) (PruneReport, error) {
+	if !request.Containers && !request.Images && !request.Volumes {
+		return PruneReport{}, ErrInvalidPrune
+	}
+	if s.engine == nil {
+		return PruneReport{}, domain.ErrUnavailable
+	}
+	if request.DryRun {
+		return s.plan(ctx, request)
+	}
+	report := PruneReport{}
+	if request.Containers {
+		result, err := s.engine.PruneContainers(ctx)
+		if err != nil {
+			return PruneReport{}, err
+		}
+		report.Containers = &result
+	}
+	if request.Images {
+		result, err := s.engine.PruneImages(ctx)
+		if err != nil {
+			return PruneReport{}, err
+		}
+		report.Images = &result
+	}
+	if request.Volumes {
+		result, err := s.engine.PruneVolumes(ctx, request.AllVolumes)
+		if err != nil {
+			return PruneReport{}, err
+		}
+		report.Volumes = &result
+	}
+	report.total()
+	zap.L().Info("docker resources pruned",
+		zap.Bool("containers", request.Containers),
+		zap.Bool("images", request.Images),
+		zap.Bool("volumes", request.Volumes),
+		zap.Bool("all_volumes", request.AllVolumes),
+		zap.Uint64("space_reclaimed", report.SpaceReclaimed),
+	)
+	return report, nil
+}
+
+// plan works out what Prune would remove from the resources docker lists,
+// following the rules docker prunes by.
+func (s *Service) plan(ctx context.Context, request PruneRequest) (PruneReport, error) {
+	containers, err := s.engine.Containers(ctx)
+	if err != nil {
+		return PruneReport{}, err
+	}
+	report := PruneReport{DryRun: true}
+	if request.Containers {
+		result := domain.PruneResult{Deleted: []string{}}
+		containers = slices.DeleteFunc(containers, func(container domain.Container) bool {
+			if !container.Stopped() {
+				return false
+			}
+			result.Deleted = append(result.Deleted, container.ID)
+			result.SpaceReclaimed += uint64(max(container.SizeRw, 0))
+			return true
+		})
+		report.Containers = &result
+	}
+	// containers now holds the containers left after the prune.
+	if request.Images {
+		images, err := s.engine.Images(ctx)
+		if err != nil {
+			return PruneReport{}, err
+		}
+		result := domain.PruneResult{Deleted: []string{}}
+		for _, image := range images {
+			used := slices.ContainsFunc(containers, func(container domain.Container) bool { return container.ImageID == image.ID })
+			if image.Dangling() && !used {
+				result.Deleted = append(result.Deleted, image.ID)
+				result.SpaceReclaimed += uint64(max(image.UniqueSize, 0))
+			}
+		}
+		report.Images = &result
+	}
+	if request.Volumes {
+		volumes, err := s.engine.Volumes(ctx)
+		if err != nil {
+			return PruneReport{}, err
+		}
+		result := domain.PruneResult{Deleted: []string{}}
+		for _, volume := range volumes {
+			used := slices.ContainsFunc(containers, func(container domain.Container) bool {
+				return slices.Contains(container.Volumes, volume.Name)
+			})
+			if !used && (volume.Anonymous || request.AllVolumes) {
+				result.Deleted = append(result.Deleted, volume.Name)
+				result.SpaceReclaimed += uint64(max(volume.Size, 0))
+			}
+		}
+		report.Volumes = &result
+	}
+	report.total()
+	return report, nil
+}
+
+func (r *PruneReport) total() {
+	for _, result := range []*domain.PruneResult{r.Containers, r.Images, r.Volumes} {
+		if result != nil {
+			r.SpaceReclaimed += result.SpaceReclaimed
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+)
+
+// engineStub lists fixed resources and records the prunes it is asked for.
+type engineStub struct {
+	containers []domain.Container
+	images     []domain.Image
+	volumes    []domain.Volume
+	pruned     []string
+}
+
+func (e *engineStub) Containers(context.Context) ([]domain.Container, error) {
+	return slices.Clone(e.containers), nil
+}
+func (e *engineStub) Images(context.Context) ([]domain.Image, error)     { return e.images, nil }
+func (e *engineStub) Volumes(context.Context) ([]domain.Volume, error)   { return e.volumes, nil }
+func (e *engineStub) Networks(context.Context) ([]domain.Network, error) { return nil, nil }
+func (e *engineStub) Inspect(context.Context, domain.Kind, string) (json.RawMessage, error) {
+	return nil, domain.ErrNotFound
+}
+func (e *engineStub) Remove(context.Context, domain.Kind, string, bool) error { return nil }
+func (e *engineStub) PruneContainers(context.Context) (domain.PruneResult, error) {
+	e.pruned = append(e.pruned, "containers")
+	return domain.PruneResult{Deleted: []string{"c2"}, SpaceReclaimed: 100}, nil
+}
+func (e *engineStub) PruneImages(context.Context) (domain.PruneResult, error) {
+	e.pruned = append(e.pruned, "images")
+	return domain.PruneResult{Deleted: []string{"sha256:old"}, SpaceReclaimed: 2000}, nil
+}
+func (e *engineStub) PruneVolumes(_ context.Context, all bool) (domain.PruneResult, error) {
+	e.pruned = append(e.pruned, "volumes")
+	return domain.PruneResult{SpaceReclaimed: 0}, nil
+}
+
+func newEngine() *engineStub {
+	return &engineStub{
+		containers: []domain.Container{
+			{ID: "c1", State: "running", ImageID: "sha256:web", Volumes: []string{"shop_data"}},
+			{ID: "c2", State: "exited", ImageID: "sha256:old", SizeRw: 100, Volumes: []string{"3f1c"}},
+			{ID: "c3", State: "created", ImageID: "sha256:cache", SizeRw: 5},
+		},
+		images: []domain.Image{
+			{ID: "sha256:web", Tags: []string{"shop/web:1.2"}, UniqueSize: 1000},
+			{ID: "sha256:old", Tags: []string{"<none>:<none>"}, UniqueSize: 2000},
+			{ID: "sha256:cache"}, // dangling, used by c3
+			{ID: "sha256:orphan", UniqueSize: 300},
+		},
+		volumes: []domain.Volume{
+			{Name: "shop_data", Size: 4000},
+			{Name: "3f1c", Anonymous: true, Size: 50},
+			{Name: "logs", Size: -1},
+		},
+	}
+}
+
+func TestPruneDryRunFollowsDockerRules(t *testing.T) {
+	engine := newEngine()
+	service := NewService(engine)
+
+	report, err := service.Prune(context.Background(), PruneRequest{Images: true, Volumes: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without pruning containers, stopped ones still hold their images and
+	// volumes.
+	if !slices.Equal(report.Images.Deleted, []string{"sha256:orphan"}) || len(report.Volumes.Deleted) != 0 || report.SpaceReclaimed != 300 {
+		t.Fatalf("report = %+v, images = %+v, volumes = %+v", report, report.Images, report.Volumes)
+	}
+
+	report, err = service.Prune(context.Background(), PruneRequest{Containers: true, Images: true, Volumes: true, AllVolumes: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(report.Containers.Deleted, []string{"c2", "c3"}) || report.Containers.SpaceReclaimed != 105 {
+		t.Fatalf("containers = %+v", report.Containers)
+	}
+	if !slices.Equal(report.Images.Deleted, []string{"sha256:old", "sha256:cache", "sha256:orphan"}) {
+		t.Fatalf("images = %+v", report.Images)
+	}
+	if !slices.Equal(report.Volumes.Deleted, []string{"3f1c", "logs"}) || report.Volumes.SpaceReclaimed != 50 {
+		t.Fatalf("volumes = %+v", report.Volumes)
+	}
+	if !report.DryRun || report.SpaceReclaimed != 105+2300+50 || len(engine.pruned) != 0 {
+		t.Fatalf("report = %+v, pruned = %v", report, engine.pruned)
+	}
+}
+
+func TestPruneRemovesContainersFirst(t *testing.T) {
+	engine := newEngine()
+	service := NewService(engine)
+
+	report, err := service.Prune(context.Background(), PruneRequest{Volumes: true, Images: true, Containers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(engine.pruned, []string{"containers", "images", "volumes"}) {
+		t.Fatalf("pruned = %v", engine.pruned)
+	}
+	if report.DryRun || report.SpaceReclaimed != 2100 || report.Volumes == nil {
+		t.Fatalf("report = %+v", report)
+	}
+	if _, err := service.Prune(context.Background(), PruneRequest{DryRun: true}); !errors.Is(err, ErrInvalidPrune) {
+		t.Fatalf("Prune() without kinds error = %v", err)
+	}
+	if _, err := NewService(nil).Containers(context.Background()); !errors.Is(err, domain.ErrUnavailable) {
+		t.Fatalf("Containers() without docker error = %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Kind names a type of docker resource.
+type Kind string
+
+const (
+	KindContainer Kind = "container"
+	KindImage     Kind = "image"
+	KindVolume    Kind = "volume"
+	KindNetwork   Kind = "network"
+)
+
+var (
+	ErrUnavailable = errors.New("docker is not available")
+	ErrNotFound    = errors.New("docker resource not found")
+	// ErrInUse is returned when docker refuses to remove a resource that a
+	// container uses; force removes it anyway where docker allows.
+	ErrInUse = errors.New("docker resource is in use")
+)
+
+// Container is a container with the size of its writable layer and of its
+// whole filesystem. Volumes names the volumes it mounts.
+type Container struct {
+	ID         string
+	Name       string
+	Image      string
+	ImageID    string
+	State      string
+	Status     string
+	Project    string
+	Created    time.Time
+	SizeRw     int64
+	SizeRootFs int64
+	Volumes    []string
+}
+
+// Stopped reports whether the container is one docker container prune
+// removes.
+func (c Container) Stopped() bool {
+	switch c.State {
+	case "created", "exited", "dead":
+		return true
+	}
+	return false
+}
+
+// Image is an image. UniqueSize is the part of Size not shared with other
+// images, which is what removing it frees.
+type Image struct {
+	ID         string
+	Tags       []string
+	Created    time.Time
+	Size       int64
+	UniqueSize int64
+	Containers int64
+}
+
+// Dangling reports whether the image has no tag, like the images docker
+// image prune removes.
+func (i Image) Dangling() bool {
+	for _, tag := range i.Tags {
+		if tag != "<none>:<none>" {
+			return false
+		}
+	}
+	return true
+}
+
+// Volume is a volume. Size is -1 when the driver does not report it.
+type Volume struct {
+	Name       string
+	Driver     string
+	Mountpoint string
+	Project    string
+	Anonymous  bool
+	Created    string
+	Size       int64
+	Containers int64
+}
+
+type Network struct {
+	ID       string
+	Name     string
+	Driver   string
+	Scope    string
+	Project  string
+	Internal bool
+	Created  time.Time
+}
+
+// PruneResult lists what a prune of one kind removed, or would remove on a
+// dry run, and the space that frees.
+type PruneResult struct {
+	Deleted        []string
+	SpaceReclaimed uint64
+}
+
+// Engine is the docker engine of the agent's host.
+type Engine interface {
+	Containers(ctx context.Context) ([]Container, error)
+	Images(ctx context.Context) ([]Image, error)
+	Volumes(ctx context.Context) ([]Volume, error)
+	Networks(ctx context.Context) ([]Network, error)
+	// Inspect returns the document docker inspect shows for a resource.
+	Inspect(ctx context.Context, kind Kind, id string) (json.RawMessage, error)
+	Remove(ctx context.Context, kind Kind, id string, force bool) error
+	PruneContainers(ctx context.Context) (PruneResult, error)
+	PruneImages(ctx context.Context) (PruneResult, error)
+	// PruneVolumes removes unused anonymous volumes, and named ones too
+	// with all.
+	PruneVolumes(ctx context.Context, all bool) (PruneResult, error)
+}
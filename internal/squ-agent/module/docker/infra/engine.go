@@ -0,0 +1,209 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/client"
+
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+)
+
+const (
+	// projectLabel names the compose project of containers, volumes and
+	// networks created by compose.
+	projectLabel = "com.docker.compose.project"
+	// anonymousLabel marks volumes docker created without a name.
+	anonymousLabel = "com.docker.volume.anonymous"
+)
+
+// DockerEngine talks to the docker engine of the host with the moby client.
+type DockerEngine struct {
+	client *client.Client
+}
+
+func NewDockerEngine() (*DockerEngine, error) {
+	cli, err := client.New(client.FromEnv)
+	if err != nil {
+		return nil, err
+	}
+	return &DockerEngine{client: cli}, nil
+}
+
+func (e *DockerEngine) Containers(ctx context.Context) ([]domain.Container, error) {
+	result, err := e.client.ContainerList(ctx, client.ContainerListOptions{All: true, Size: true})
+	if err != nil {
+		return nil, engineError(err)
+	}
+	containers := make([]domain.Container, 0, len(result.Items))
+	for _, item := range result.Items {
+		value := domain.Container{
+			ID: item.ID, Image: item.Image, ImageID: item.ImageID, State: string(item.State), Status: item.Status,
+			Project: item.Labels[projectLabel], Created: time.Unix(item.Created, 0),
+			SizeRw: item.SizeRw, SizeRootFs: item.SizeRootFs,
+		}
+		if len(item.Names) > 0 {
+			value.Name = strings.TrimPrefix(item.Names[0], "/")
+		}
+		for _, point := range item.Mounts {
+			if point.Type == mount.TypeVolume && point.Name != "" {
+				value.Volumes = append(value.Volumes, point.Name)
+			}
+		}
+		containers = append(containers, value)
+	}
+	return containers, nil
+}
+
+func (e *DockerEngine) Images(ctx context.Context) ([]domain.Image, error) {
+	result, err := e.client.ImageList(ctx, client.ImageListOptions{SharedSize: true})
+	if err != nil {
+		return nil, engineError(err)
+	}
+	images := make([]domain.Image, 0, len(result.Items))
+	for _, item := range result.Items {
+		value := domain.Image{ID: item.ID, Tags: item.RepoTags, Created: time.Unix(item.Created, 0),
+			Size: item.Size, UniqueSize: item.Size, Containers: item.Containers}
+		// SharedSize is -1 when docker did not compute it.
+		if item.SharedSize > 0 {
+			value.UniqueSize = item.Size - item.SharedSize
+		}
+		images = append(images, value)
+	}
+	return images, nil
+}
+
+// Volumes reads volumes from docker system df, the only listing that
+// carries their sizes and the number of containers using them.
+func (e *DockerEngine) Volumes(ctx context.Context) ([]domain.Volume, error) {
+	result, err := e.client.DiskUsage(ctx, client.DiskUsageOptions{Volumes: true, Verbose: true})
+	if err != nil {
+		return nil, engineError(err)
+	}
+	volumes := make([]domain.Volume, 0, len(result.Volumes.Items))
+	for _, item := range result.Volumes.Items {
+		_, anonymous := item.Labels[anonymousLabel]
+		value := domain.Volume{Name: item.Name, Driver: item.Driver, Mountpoint: item.Mountpoint,
+			Project: item.Labels[projectLabel], Anonymous: anonymous, Created: item.CreatedAt, Size: -1}
+		if item.UsageData != nil {
+			value.Size, value.Containers = item.UsageData.Size, item.UsageData.RefCount
+		}
+		volumes = append(volumes, value)
+	}
+	return volumes, nil
+}
+
+func (e *DockerEngine) Networks(ctx context.Context) ([]domain.Network, error) {
+	result, err := e.client.NetworkList(ctx, client.NetworkListOptions{})
+	if err != nil {
+		return nil, engineError(err)
+	}
+	networks := make([]domain.Network, 0, len(result.Items))
+	for _, item := range result.Items {
+		networks = append(networks, domain.Network{ID: item.ID, Name: item.Name, Driver: item.Driver,
+			Scope: item.Scope, Project: item.Labels[projectLabel], Internal: item.Internal, Created: item.Created})
+	}
+	return networks, nil
+}
+
+func (e *DockerEngine) Inspect(ctx context.Context, kind domain.Kind, id string) (json.RawMessage, error) {
+	var (
+		raw json.RawMessage
+		err error
+	)
+	switch kind {
+	case domain.KindContainer:
+		var result client.ContainerInspectResult
+		result, err = e.client.ContainerInspect(ctx, id, client.ContainerInspectOptions{Size: true})
+		raw = result.Raw
+	case domain.KindImage:
+		var buffer bytes.Buffer
+		_, err = e.client.ImageInspect(ctx, id, client.ImageInspectWithRawResponse(&buffer))
+		raw = buffer.Bytes()
+	case domain.KindVolume:
+		var result client.VolumeInspectResult
+		result, err = e.client.VolumeInspect(ctx, id, client.VolumeInspectOptions{})
+		raw = result.Raw
+	case domain.KindNetwork:
+		var result client.NetworkInspectResult
+		result, err = e.client.NetworkInspect(ctx, id, client.NetworkInspectOptions{})
+		raw = result.Raw
+	default:
+		return nil, fmt.Errorf("unknown docker resource kind %q", kind)
+	}
+	if err != nil {
+		return nil, engineError(err)
+	}
+	return raw, nil
+}
+
+func (e *DockerEngine) Remove(ctx context.Context, kind domain.Kind, id string, force bool) error {
+	var err error
+	switch kind {
+	case domain.KindContainer:
+		_, err = e.client.ContainerRemove(ctx, id, client.ContainerRemoveOptions{Force: force})
+	case domain.KindImage:
+		_, err = e.client.ImageRemove(ctx, id, client.ImageRemoveOptions{Force: force, PruneChildren: true})
+	case domain.KindVolume:
+		_, err = e.client.VolumeRemove(ctx, id, client.VolumeRemoveOptions{Force: force})
+	case domain.KindNetwork:
+		_, err = e.client.NetworkRemove(ctx, id, client.NetworkRemoveOptions{})
+	default:
+		return fmt.Errorf("unknown docker resource kind %q", kind)
+	}
+	return engineError(err)
+}
+
+func (e *DockerEngine) PruneContainers(ctx context.Context) (domain.PruneResult, error) {
+	result, err := e.client.ContainerPrune(ctx, client.ContainerPruneOptions{})
+	if err != nil {
+		return domain.PruneResult{}, engineError(err)
+	}
+	return domain.PruneResult{Deleted: result.Report.ContainersDeleted, SpaceReclaimed: result.Report.SpaceReclaimed}, nil
+}
+
+// PruneImages removes dangling images only, like docker image prune without
+// --all.
+func (e *DockerEngine) PruneImages(ctx context.Context) (domain.PruneResult, error) {
+	result, err := e.client.ImagePrune(ctx, client.ImagePruneOptions{Filters: client.Filters{}.Add("dangling", "true")})
+	if err != nil {
+		return domain.PruneResult{}, engineError(err)
+	}
+	value := domain.PruneResult{SpaceReclaimed: result.Report.SpaceReclaimed}
+	for _, item := range result.Report.ImagesDeleted {
+		if item.Deleted != "" {
+			value.Deleted = append(value.Deleted, item.Deleted)
+		}
+	}
+	return value, nil
+}
+
+func (e *DockerEngine) PruneVolumes(ctx context.Context, all bool) (domain.PruneResult, error) {
+	result, err := e.client.VolumePrune(ctx, client.VolumePruneOptions{All: all})
+	if err != nil {
+		return domain.PruneResult{}, engineError(err)
+	}
+	return domain.PruneResult{Deleted: result.Report.VolumesDeleted, SpaceReclaimed: result.Report.SpaceReclaimed}, nil
+}
+
+// engineError keeps the message of docker and marks the errors callers act
+// on.
+func engineError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case client.IsErrConnectionFailed(err):
+		return fmt.Errorf("%w: %v", domain.ErrUnavailable, err)
+	case cerrdefs.IsNotFound(err):
+		return fmt.Errorf("%w: %v", domain.ErrNotFound, err)
+	case cerrdefs.IsConflict(err):
+		return fmt.Errorf("%w: %v", domain.ErrInUse, err)
+	}
+	return err
+}
@@ -0,0 +1,29 @@
+package docker
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-agent/module/docker/api"
+	"squirrel-dev/internal/squ-agent/module/docker/api/res"
+	"squirrel-dev/internal/squ-agent/module/docker/application"
+	"squirrel-dev/internal/squ-agent/module/docker/domain"
+	"squirrel-dev/internal/squ-agent/module/docker/infra"
+)
+
+type Dependencies struct {
+	Engine domain.Engine
+}
+
+func RegisterHTTP(group *gin.RouterGroup, dependencies Dependencies) {
+	res.RegisterCode()
+	engine := dependencies.Engine
+	if engine == nil {
+		if value, err := infra.NewDockerEngine(); err != nil {
+			zap.L().Warn("failed to create docker client, docker resources are unavailable", zap.Error(err))
+		} else {
+			engine = value
+		}
+	}
+	api.RegisterRoutes(group, api.NewHandler(application.NewService(engine)))
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-agent/module/tunnel/api/res"
+)
+
+const (
+	dialTimeout = 10 * time.Second
+	bufferSize  = 32 * 1024
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  bufferSize,
+	WriteBufferSize: bufferSize,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// Handler relays a WebSocket to a TCP port on this host so that the apiserver
+// can reach services that only listen on loopback or a private interface.
+type Handler struct {
+	// localAddress reports whether an IP belongs to this host. It is a field
+	// so that tests do not depend on the interfaces of the machine.
+	localAddress func(net.IP) bool
+}
+
+func NewHandler() *Handler {
+	return &Handler{localAddress: isLocalAddress}
+}
+
+// Relay dials host:port (host defaults to 127.0.0.1) and copies bytes between
+// the TCP connection and binary WebSocket messages until either side closes.
+// Only addresses of this host are accepted; the agent is not a general proxy.
+func (h *Handler) Relay(c *gin.Context) {
+	host := c.DefaultQuery("host", "127.0.0.1")
+	port, err := strconv.Atoi(c.Query("port"))
+	ip := net.ParseIP(host)
+	if host == "localhost" {
+		ip = net.IPv4(127, 0, 0, 1)
+	}
+	if err != nil || port < 1 || port > 65535 || ip == nil || !h.localAddress(ip) {
+		zap.L().Warn("rejected tunnel target", zap.String("host", host), zap.String("port", c.Query("port")))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidTarget))
+		return
+	}
+	address := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+	target, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		zap.L().Warn("failed to dial tunnel target", zap.String("address", address), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrDialFailed))
+		return
+	}
+	defer target.Close()
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		zap.L().Warn("failed to upgrade tunnel connection", zap.String("address", address), zap.Error(err))
+		return
+	}
+	defer conn.Close()
+	zap.L().Info("tunnel opened", zap.String("address", address), zap.String("remote", c.Request.RemoteAddr))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buffer := make([]byte, bufferSize)
+		for {
+			n, err := target.Read(buffer)
+			if n > 0 {
+				if err := conn.WriteMessage(websocket.BinaryMessage, buffer[:n]); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		}
+	}()
+	for {
+		messageType, reader, err := conn.NextReader()
+		if err != nil {
+			break
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+		if _, err := io.Copy(target, reader); err != nil {
+			break
+		}
+	}
+	// Closing the target ends the copy loop above.
+	target.Close()
+	<-done
+	zap.L().Info("tunnel closed", zap.String("address", address))
+}
+
+func isLocalAddress(ip net.IP) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+	addresses, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, address := range addresses {
+		if network, ok := address.(*net.IPNet); ok && network.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-agent/module/tunnel/api/res"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	res.RegisterCode()
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/api/v1"), NewHandler())
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRelayCopiesBothWays(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buffer := make([]byte, 64)
+		n, _ := conn.Read(buffer)
+		_, _ = conn.Write([]byte(strings.ToUpper(string(buffer[:n]))))
+	}()
+	server := newTestServer(t)
+	port := listener.Addr().(*net.TCPAddr).Port
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/tunnel?port=" + strconv.Itoa(port)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial tunnel: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(message) != "PING" {
+		t.Fatalf("message = %q, want PING", message)
+	}
+}
+
+func TestRelayRejectsRemoteTargets(t *testing.T) {
+	server := newTestServer(t)
+	for _, query := range []string{"host=192.0.2.1&port=80", "port=0", "port=abc", "host=example.com&port=80"} {
+		resp, err := http.Get(server.URL + "/api/v1/tunnel?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body response.Response
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body.Code != res.ErrInvalidTarget {
+			t.Fatalf("%s: code = %d, want %d", query, body.Code, res.ErrInvalidTarget)
+		}
+	}
+}
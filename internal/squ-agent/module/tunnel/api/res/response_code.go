@@ -0,0 +1,13 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrInvalidTarget = 40001
+	ErrDialFailed    = 40002
+)
+
+func RegisterCode() {
+	response.Register(ErrInvalidTarget, "tunnel target must be a local address")
+	response.Register(ErrDialFailed, "failed to connect to tunnel target")
+}
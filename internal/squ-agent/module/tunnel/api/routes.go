@@ -0,0 +1,7 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/tunnel", handler.Relay)
+}
@@ -0,0 +1,13 @@
+package tunnel
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/squ-agent/module/tunnel/api"
+	"squirrel-dev/internal/squ-agent/module/tunnel/api/res"
+)
+
+func RegisterHTTP(group *gin.RouterGroup) {
+	res.RegisterCode()
+	api.RegisterRoutes(group, api.NewHandler())
+}
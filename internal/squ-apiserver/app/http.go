@@ -9,11 +9,19 @@ import (
 	applicationModule "squirrel-dev/internal/squ-apiserver/module/application"
 	appstoreModule "squirrel-dev/internal/squ-apiserver/module/appstore"
 	authModule "squirrel-dev/internal/squ-apiserver/module/auth"
+	bundleModule "squirrel-dev/internal/squ-apiserver/module/bundle"
+	clusterModule "squirrel-dev/internal/squ-apiserver/module/cluster"
+	commandModule "squirrel-dev/internal/squ-apiserver/module/command"
 	configModule "squirrel-dev/internal/squ-apiserver/module/config"
 	deploymentModule "squirrel-dev/internal/squ-apiserver/module/deployment"
+	dockerModule "squirrel-dev/internal/squ-apiserver/module/docker"
 	monitorModule "squirrel-dev/internal/squ-apiserver/module/monitor"
+	registryModule "squirrel-dev/internal/squ-apiserver/module/registry"
 	scriptModule "squirrel-dev/internal/squ-apiserver/module/script"
+	secretModule "squirrel-dev/internal/squ-apiserver/module/secret"
 	serverModule "squirrel-dev/internal/squ-apiserver/module/server"
+	sftpModule "squirrel-dev/internal/squ-apiserver/module/sftp"
+	tunnelModule "squirrel-dev/internal/squ-apiserver/module/tunnel"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,6 +39,8 @@ func (a *App) registerHTTPRoutes() {
 		// 与旧版一致：终端 WebSocket 不经过 HTTP JWT 中间件，而是在
 		// WebSocket 建立后通过首条 auth 消息校验 token。
 		serverModule.RegisterTerminalHTTP(v1, a.Config, a.DB.GetDB())
+		// 反向代理同样在处理器内自行校验 token，浏览器可通过 Cookie 访问。
+		tunnelModule.RegisterProxyHTTP(v1, a.Config, a.DB.GetDB())
 
 		v1Auth := a.Gin.Group("/api/v1")
 		v1Auth.Use(jwt.JWTAuth(a.Config.Auth.Jwt.SigningKey))
@@ -39,9 +49,17 @@ func (a *App) registerHTTPRoutes() {
 		configModule.RegisterHTTP(v1Auth, a.DB.GetDB())
 		appstoreModule.RegisterHTTP(v1Auth, a.DB.GetDB())
 		applicationModule.RegisterHTTP(v1Auth, a.DB.GetDB())
+		clusterModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
+		secretModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
+		registryModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
+		bundleModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
+		dockerModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
 		deploymentModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
 		scriptModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
 		monitorModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
+		sftpModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
+		commandModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
+		tunnelModule.RegisterHTTP(v1Auth, a.Config, a.DB.GetDB())
 	}
 	v1.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, response.Success("health"))
@@ -34,6 +34,115 @@ func TestLegacyHealthRoute(t *testing.T) {
 	}
 }
 
+// addedRoutes lists the routes introduced after the legacy contract was
+// captured. They are counted explicitly so that an unexpected route still
+// fails the inventory check.
+var addedRoutes = []string{
+	"GET /api/v1/sftp/:id/list",
+	"GET /api/v1/sftp/:id/stat",
+	"GET /api/v1/sftp/:id/download",
+	"POST /api/v1/sftp/:id/upload",
+	"POST /api/v1/sftp/:id/rename",
+	"POST /api/v1/sftp/:id/delete",
+	"POST /api/v1/sftp/:id/mkdir",
+	"POST /api/v1/sftp/:id/chmod",
+	"GET /api/v1/sftp/:id/transfers",
+	"GET /api/v1/sftp/:id/access",
+	"POST /api/v1/sftp/:id/access",
+	"DELETE /api/v1/sftp/:id/access/:username",
+	"POST /api/v1/command/execute",
+	"POST /api/v1/command/execute/stream",
+	"GET /api/v1/ws/servers",
+	"POST /api/v1/server/:id/labels",
+	"POST /api/v1/server/labels",
+	"GET /api/v1/server-group",
+	"GET /api/v1/server-group/:id",
+	"POST /api/v1/server-group",
+	"POST /api/v1/server-group/:id",
+	"DELETE /api/v1/server-group/:id",
+	"POST /api/v1/server-group/:id/members",
+	"GET /api/v1/monitor/stats",
+	"POST /api/v1/server/import",
+	"GET /api/v1/server/export",
+	"GET /api/v1/ssh-key",
+	"GET /api/v1/ssh-key/:id",
+	"POST /api/v1/ssh-key/generate",
+	"POST /api/v1/ssh-key/import",
+	"DELETE /api/v1/ssh-key/:id",
+	"POST /api/v1/ssh-key/:id/install",
+	"GET /api/v1/tunnel",
+	"POST /api/v1/tunnel",
+	"DELETE /api/v1/tunnel/:id",
+	"POST /api/v1/tunnel/proxy-token",
+	"GET /api/v1/proxy/server/:id/:port/*path",
+	"POST /api/v1/proxy/server/:id/:port/*path",
+	"PUT /api/v1/proxy/server/:id/:port/*path",
+	"PATCH /api/v1/proxy/server/:id/:port/*path",
+	"HEAD /api/v1/proxy/server/:id/:port/*path",
+	"OPTIONS /api/v1/proxy/server/:id/:port/*path",
+	"DELETE /api/v1/proxy/server/:id/:port/*path",
+	"CONNECT /api/v1/proxy/server/:id/:port/*path",
+	"TRACE /api/v1/proxy/server/:id/:port/*path",
+	"GET /api/v1/deployment/:id/revisions",
+	"GET /api/v1/deployment/:id/revisions/:revision",
+	"POST /api/v1/deployment/:id/rollback",
+	"GET /api/v1/rollout",
+	"GET /api/v1/rollout/:id",
+	"POST /api/v1/rollout",
+	"POST /api/v1/rollout/:id/stop",
+	"POST /api/v1/rollout/:id/rollback",
+	"GET /api/v1/deployment/:id/output",
+	"GET /api/v1/deployment/:id/output/download",
+	"GET /api/v1/deployment/:id/logs",
+	"GET /api/v1/deployment/:id/containers",
+	"POST /api/v1/deployment/:id/preview",
+	"POST /api/v1/deployment/:id/reconcile",
+	"GET /api/v1/deployment/:id/drift",
+	"POST /api/v1/deployment/report/drift",
+	"GET /api/v1/deployment/:id/files",
+	"POST /api/v1/deployment/:id/files/adopt",
+	"POST /api/v1/deployment/:id/files/push",
+	"POST /api/v1/deployment/report/files",
+	"GET /api/v1/cluster",
+	"POST /api/v1/cluster",
+	"GET /api/v1/cluster/:id",
+	"POST /api/v1/cluster/:id",
+	"DELETE /api/v1/cluster/:id",
+	"POST /api/v1/cluster/:id/check",
+	"GET /api/v1/secret",
+	"POST /api/v1/secret",
+	"GET /api/v1/secret/:id",
+	"POST /api/v1/secret/:id",
+	"DELETE /api/v1/secret/:id",
+	"GET /api/v1/registry",
+	"POST /api/v1/registry",
+	"GET /api/v1/registry/:id",
+	"POST /api/v1/registry/:id",
+	"DELETE /api/v1/registry/:id",
+	"POST /api/v1/registry/:id/login",
+	"GET /api/v1/bundle",
+	"POST /api/v1/bundle",
+	"GET /api/v1/bundle/images",
+	"POST /api/v1/bundle/upload",
+	"GET /api/v1/bundle/:id",
+	"DELETE /api/v1/bundle/:id",
+	"GET /api/v1/bundle/:id/download",
+	"POST /api/v1/bundle/:id/push",
+	"GET /api/v1/docker/:id/containers",
+	"GET /api/v1/docker/:id/containers/*ref",
+	"DELETE /api/v1/docker/:id/containers/*ref",
+	"GET /api/v1/docker/:id/images",
+	"GET /api/v1/docker/:id/images/*ref",
+	"DELETE /api/v1/docker/:id/images/*ref",
+	"GET /api/v1/docker/:id/volumes",
+	"GET /api/v1/docker/:id/volumes/*ref",
+	"DELETE /api/v1/docker/:id/volumes/*ref",
+	"GET /api/v1/docker/:id/networks",
+	"GET /api/v1/docker/:id/networks/*ref",
+	"DELETE /api/v1/docker/:id/networks/*ref",
+	"POST /api/v1/docker/:id/prune",
+}
+
 func TestAPIServerLegacyRouteInventory(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	db := database.New("sqlite", ":memory:")
@@ -65,8 +174,14 @@ func TestAPIServerLegacyRouteInventory(t *testing.T) {
 			t.Errorf("legacy route missing: %s", key)
 		}
 	}
-	if len(actual) != len(service.Routes)+1 {
-		t.Fatalf("route count=%d, want %d legacy routes plus /health alias", len(actual), len(service.Routes)+1)
+	for _, key := range addedRoutes {
+		if _, ok := actual[key]; !ok {
+			t.Errorf("added route missing: %s", key)
+		}
+	}
+	if want := len(service.Routes) + 1 + len(addedRoutes); len(actual) != want {
+		t.Fatalf("route count=%d, want %d legacy routes plus /health alias and %d added routes",
+			len(actual), len(service.Routes)+1, len(addedRoutes))
 	}
 }
 
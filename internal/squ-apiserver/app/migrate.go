@@ -5,10 +5,15 @@ import (
 	applicationModule "squirrel-dev/internal/squ-apiserver/module/application"
 	appstoreModule "squirrel-dev/internal/squ-apiserver/module/appstore"
 	authModule "squirrel-dev/internal/squ-apiserver/module/auth"
+	bundleModule "squirrel-dev/internal/squ-apiserver/module/bundle"
+	clusterModule "squirrel-dev/internal/squ-apiserver/module/cluster"
 	configModule "squirrel-dev/internal/squ-apiserver/module/config"
 	deploymentModule "squirrel-dev/internal/squ-apiserver/module/deployment"
+	registryModule "squirrel-dev/internal/squ-apiserver/module/registry"
 	scriptModule "squirrel-dev/internal/squ-apiserver/module/script"
+	secretModule "squirrel-dev/internal/squ-apiserver/module/secret"
 	serverModule "squirrel-dev/internal/squ-apiserver/module/server"
+	sftpModule "squirrel-dev/internal/squ-apiserver/module/sftp"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -48,9 +53,163 @@ func (a *App) buildMigrationRegistry() *migration.MigrationRegistry {
 		scriptModule.MigrateResults,
 		scriptModule.RollbackResults,
 	)
+	registry.Register(
+		"1.0.2",
+		"sftp access and transfer records",
+		sftpModule.Migrate,
+		sftpModule.Rollback,
+	)
+	registry.Register(
+		"1.0.3",
+		"server labels and groups",
+		serverModule.MigrateGroups,
+		serverModule.RollbackGroups,
+	)
+	registry.Register(
+		"1.0.4",
+		"ssh key vault",
+		serverModule.MigrateKeys,
+		serverModule.RollbackKeys,
+	)
+	registry.Register(
+		"1.0.5",
+		"deployment revisions",
+		deploymentModule.MigrateRevisions,
+		deploymentModule.RollbackRevisions,
+	)
+	registry.Register(
+		"1.0.6",
+		"deployment rollouts",
+		deploymentModule.MigrateRollouts,
+		deploymentModule.RollbackRollouts,
+	)
+	registry.Register(
+		"1.0.7",
+		"deployment container state",
+		deploymentModule.MigrateContainers,
+		deploymentModule.RollbackContainers,
+	)
+	registry.Register(
+		"1.0.8",
+		"kubernetes clusters",
+		migrateClusters,
+		rollbackClusters,
+	)
+	registry.Register(
+		"1.0.9",
+		"helm chart releases",
+		deploymentModule.MigrateReleases,
+		deploymentModule.RollbackReleases,
+	)
+	registry.Register(
+		"1.0.10",
+		"deployment secrets",
+		migrateSecrets,
+		rollbackSecrets,
+	)
+	registry.Register(
+		"1.0.11",
+		"application health checks",
+		migrateHealthChecks,
+		rollbackHealthChecks,
+	)
+	registry.Register(
+		"1.0.12",
+		"deployment reconcile",
+		deploymentModule.MigrateReconcile,
+		deploymentModule.RollbackReconcile,
+	)
+	registry.Register(
+		"1.0.13",
+		"deployment file drift",
+		deploymentModule.MigrateFiles,
+		deploymentModule.RollbackFiles,
+	)
+	registry.Register(
+		"1.0.14",
+		"private registries",
+		migrateRegistries,
+		rollbackRegistries,
+	)
+	registry.Register(
+		"1.0.15",
+		"image bundles",
+		migrateBundles,
+		rollbackBundles,
+	)
 	return registry
 }
 
+func migrateClusters(db *gorm.DB) error {
+	if err := clusterModule.Migrate(db); err != nil {
+		return err
+	}
+	return deploymentModule.MigrateClusterTargets(db)
+}
+
+func rollbackClusters(db *gorm.DB) error {
+	if err := deploymentModule.RollbackClusterTargets(db); err != nil {
+		return err
+	}
+	return clusterModule.Rollback(db)
+}
+
+func migrateSecrets(db *gorm.DB) error {
+	if err := secretModule.Migrate(db); err != nil {
+		return err
+	}
+	return deploymentModule.MigrateSecretRefs(db)
+}
+
+func rollbackSecrets(db *gorm.DB) error {
+	if err := deploymentModule.RollbackSecretRefs(db); err != nil {
+		return err
+	}
+	return secretModule.Rollback(db)
+}
+
+func migrateRegistries(db *gorm.DB) error {
+	if err := registryModule.Migrate(db); err != nil {
+		return err
+	}
+	return applicationModule.MigrateRegistries(db)
+}
+
+func rollbackRegistries(db *gorm.DB) error {
+	if err := applicationModule.RollbackRegistries(db); err != nil {
+		return err
+	}
+	return registryModule.Rollback(db)
+}
+
+func migrateBundles(db *gorm.DB) error {
+	if err := bundleModule.Migrate(db); err != nil {
+		return err
+	}
+	return applicationModule.MigrateBundles(db)
+}
+
+func rollbackBundles(db *gorm.DB) error {
+	if err := applicationModule.RollbackBundles(db); err != nil {
+		return err
+	}
+	return bundleModule.Rollback(db)
+}
+
+func migrateHealthChecks(db *gorm.DB) error {
+	if err := applicationModule.MigrateHealthChecks(db); err != nil {
+		return err
+	}
+	return deploymentModule.MigrateHealth(db)
+}
+
+func rollbackHealthChecks(db *gorm.DB) error {
+	if err := deploymentModule.RollbackHealth(db); err != nil {
+		return err
+	}
+	return applicationModule.RollbackHealthChecks(db)
+}
+
 func migrate(db *gorm.DB) error {
 	if err := authModule.Migrate(db); err != nil {
 		return err
@@ -0,0 +1,7 @@
+package config
+
+// Bundles 离线部署镜像包配置
+type Bundles struct {
+	// Path 是镜像包的存储目录，为空时使用 ./bundles
+	Path string `mapstructure:"path"`
+}
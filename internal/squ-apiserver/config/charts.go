@@ -0,0 +1,8 @@
+package config
+
+// Charts Helm chart 配置
+type Charts struct {
+	// Path 是本地 chart 的存储目录，为空时使用 ./charts。helm_chart 应用的
+	// path 只能指向该目录下的 chart，或使用 oci:// 引用
+	Path string `mapstructure:"path"`
+}
@@ -29,12 +29,16 @@ func New(loadPath string) *Config {
 
 // Config 配置文件
 type Config struct {
-	DB     DB
-	Log    Log
-	Server Server
-	Auth   Auth
-	Agent  Agent
-	MTLS   MTLS
+	DB      DB
+	Log     Log
+	Server  Server
+	Auth    Auth
+	Agent   Agent
+	MTLS    MTLS
+	Tunnel  Tunnel
+	Secrets Secrets
+	Bundles Bundles
+	Charts  Charts
 }
 
 // 获取文件绝对路径
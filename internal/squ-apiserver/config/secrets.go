@@ -0,0 +1,9 @@
+package config
+
+// Secrets 部署密钥加密配置
+type Secrets struct {
+	// Key 是 base64 编码的 32 字节 AES-256 密钥。未配置时无法保存和读取
+	// 部署密钥、镜像仓库密码、SSH 密钥库私钥与集群 kubeconfig。修改后已保存的
+	// 内容将无法解密。
+	Key string `mapstructure:"key"`
+}
@@ -0,0 +1,10 @@
+package config
+
+// Tunnel 端口转发配置
+type Tunnel struct {
+	// BindAddress 是转发端口监听的地址，为空时只监听 127.0.0.1。
+	// 转发端口不做认证，非回环地址需同时开启 AllowPublicBind
+	BindAddress string `mapstructure:"bindAddress"`
+	// AllowPublicBind 允许转发端口监听非回环地址
+	AllowPublicBind bool `mapstructure:"allowPublicBind"`
+}
@@ -2,6 +2,8 @@ package config
 
 type Auth struct {
 	Jwt Jwt
+	// Admins 是管理员用户名列表，可管理所有服务器的 SFTP 访问列表
+	Admins []string `mapstructure:"admins"`
 }
 
 type Jwt struct {
@@ -12,6 +12,7 @@ import (
 	"squirrel-dev/internal/squ-apiserver/module/application/api/req"
 	"squirrel-dev/internal/squ-apiserver/module/application/api/res"
 	appService "squirrel-dev/internal/squ-apiserver/module/application/application"
+	"squirrel-dev/internal/squ-apiserver/module/application/domain"
 	"squirrel-dev/pkg/utils"
 )
 
@@ -49,6 +50,12 @@ func writeError(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, appService.ErrInvalidYAML):
 		code = res.ErrInvalidApplicationConfig
+	case errors.Is(err, domain.ErrInvalidHealthCheck):
+		code = res.ErrInvalidHealthCheck
+	case errors.Is(err, appService.ErrInvalidRegistry):
+		code = res.ErrInvalidRegistry
+	case errors.Is(err, appService.ErrInvalidBundle):
+		code = res.ErrInvalidBundle
 	case err == gorm.ErrRecordNotFound:
 		code = res.ErrApplicationNotFound
 	case err == gorm.ErrDuplicatedKey:
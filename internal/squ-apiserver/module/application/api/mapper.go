@@ -14,9 +14,25 @@ func toDomain(value req.Application) domain.Application {
 		Type:        value.Type,
 		Content:     value.Content,
 		Version:     value.Version,
+		HealthCheck: toHealthCheck(value.HealthCheck),
+		RegistryID:  value.RegistryID,
+		BundleID:    value.BundleID,
 	}
 }
 
+func toHealthCheck(value *req.HealthCheck) *domain.HealthCheck {
+	if value == nil {
+		return nil
+	}
+	result := &domain.HealthCheck{Timeout: value.Timeout}
+	for _, check := range value.Checks {
+		result.Checks = append(result.Checks, domain.Check{
+			Type: check.Type, URL: check.URL, Status: check.Status, Address: check.Address,
+		})
+	}
+	return result
+}
+
 func fromDomain(value domain.Application) res.Application {
 	return res.Application{
 		ID:          value.ID,
@@ -25,5 +41,21 @@ func fromDomain(value domain.Application) res.Application {
 		Type:        value.Type,
 		Content:     value.Content,
 		Version:     value.Version,
+		HealthCheck: fromHealthCheck(value.HealthCheck),
+		RegistryID:  value.RegistryID,
+		BundleID:    value.BundleID,
+	}
+}
+
+func fromHealthCheck(value *domain.HealthCheck) *res.HealthCheck {
+	if value == nil {
+		return nil
+	}
+	result := &res.HealthCheck{Timeout: value.Timeout, Checks: []res.Check{}}
+	for _, check := range value.Checks {
+		result.Checks = append(result.Checks, res.Check{
+			Type: check.Type, URL: check.URL, Status: check.Status, Address: check.Address,
+		})
 	}
+	return result
 }
@@ -7,4 +7,25 @@ type Application struct {
 	Type        string `json:"type"`
 	Content     string `json:"content"`
 	Version     string `json:"version"`
+	// HealthCheck is verified by the agent after each deploy.
+	HealthCheck *HealthCheck `json:"health_check"`
+	// RegistryID is the private registry the images are pulled from.
+	RegistryID uint `json:"registry_id"`
+	// BundleID is the image bundle loaded on servers before each deploy.
+	BundleID uint `json:"bundle_id"`
+}
+
+// HealthCheck gives all checks Timeout seconds to pass.
+type HealthCheck struct {
+	Timeout int     `json:"timeout"`
+	Checks  []Check `json:"checks"`
+}
+
+// Check is a compose, http or tcp check. URL and Status belong to http
+// checks, Address to tcp checks.
+type Check struct {
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Status  int    `json:"status"`
+	Address string `json:"address"`
 }
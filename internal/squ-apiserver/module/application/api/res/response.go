@@ -1,10 +1,25 @@
 package res
 
 type Application struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Type        string `json:"type"`
-	Content     string `json:"content"`
-	Version     string `json:"version"`
+	ID          uint         `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Type        string       `json:"type"`
+	Content     string       `json:"content"`
+	Version     string       `json:"version"`
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+	RegistryID  uint         `json:"registry_id,omitempty"`
+	BundleID    uint         `json:"bundle_id,omitempty"`
+}
+
+type HealthCheck struct {
+	Timeout int     `json:"timeout"`
+	Checks  []Check `json:"checks"`
+}
+
+type Check struct {
+	Type    string `json:"type"`
+	URL     string `json:"url,omitempty"`
+	Status  int    `json:"status,omitempty"`
+	Address string `json:"address,omitempty"`
 }
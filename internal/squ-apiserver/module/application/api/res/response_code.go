@@ -10,6 +10,9 @@ const (
 	ErrInvalidApplicationConfig = 71005
 	ErrApplicationUpdateFailed  = 71006
 	ErrApplicationDeleteFailed  = 71007
+	ErrInvalidHealthCheck       = 71008
+	ErrInvalidRegistry          = 71009
+	ErrInvalidBundle            = 71010
 )
 
 func RegisterCode() {
@@ -20,4 +23,7 @@ func RegisterCode() {
 	response.Register(ErrInvalidApplicationConfig, "invalid application configuration")
 	response.Register(ErrApplicationUpdateFailed, "application update failed")
 	response.Register(ErrApplicationDeleteFailed, "application delete failed")
+	response.Register(ErrInvalidHealthCheck, "invalid application health check")
+	response.Register(ErrInvalidRegistry, "invalid application registry")
+	response.Register(ErrInvalidBundle, "invalid application bundle")
 }
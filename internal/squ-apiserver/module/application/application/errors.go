@@ -2,4 +2,8 @@ package application
 
 import "errors"
 
-var ErrInvalidYAML = errors.New("invalid YAML")
+var (
+	ErrInvalidYAML     = errors.New("invalid YAML")
+	ErrInvalidRegistry = errors.New("only compose applications pull images from a registry")
+	ErrInvalidBundle   = errors.New("only compose applications load images from a bundle")
+)
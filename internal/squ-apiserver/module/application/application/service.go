@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"fmt"
 
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -44,6 +45,18 @@ func (s *Service) Add(ctx context.Context, value domain.Application) error {
 		zap.L().Warn("invalid application YAML", zap.String("application_name", value.Name), zap.Error(err))
 		return ErrInvalidYAML
 	}
+	if err := validateHealthCheck(value); err != nil {
+		zap.L().Warn("invalid application health check", zap.String("application_name", value.Name), zap.Error(err))
+		return err
+	}
+	if err := validateRegistry(value); err != nil {
+		zap.L().Warn("invalid application registry", zap.String("application_name", value.Name), zap.Error(err))
+		return err
+	}
+	if err := validateBundle(value); err != nil {
+		zap.L().Warn("invalid application bundle", zap.String("application_name", value.Name), zap.Error(err))
+		return err
+	}
 	if err := s.repository.Add(ctx, &value); err != nil {
 		zap.L().Error("failed to add application", zap.String("application_name", value.Name), zap.Error(err))
 		return err
@@ -60,6 +73,30 @@ func (s *Service) Update(ctx context.Context, value domain.Application) error {
 		)
 		return ErrInvalidYAML
 	}
+	if err := validateHealthCheck(value); err != nil {
+		zap.L().Warn("invalid application health check",
+			zap.Uint("application_id", value.ID),
+			zap.String("application_name", value.Name),
+			zap.Error(err),
+		)
+		return err
+	}
+	if err := validateRegistry(value); err != nil {
+		zap.L().Warn("invalid application registry",
+			zap.Uint("application_id", value.ID),
+			zap.String("application_name", value.Name),
+			zap.Error(err),
+		)
+		return err
+	}
+	if err := validateBundle(value); err != nil {
+		zap.L().Warn("invalid application bundle",
+			zap.Uint("application_id", value.ID),
+			zap.String("application_name", value.Name),
+			zap.Error(err),
+		)
+		return err
+	}
 	if err := s.repository.Update(ctx, &value); err != nil {
 		zap.L().Error("failed to update application",
 			zap.Uint("application_id", value.ID),
@@ -71,6 +108,36 @@ func (s *Service) Update(ctx context.Context, value domain.Application) error {
 	return nil
 }
 
+// validateHealthCheck accepts health checks on compose applications only;
+// Kubernetes deployments have probes for this.
+func validateHealthCheck(value domain.Application) error {
+	if value.HealthCheck == nil {
+		return nil
+	}
+	if value.Type != domain.TypeCompose {
+		return fmt.Errorf("%w: %s applications do not support health checks", domain.ErrInvalidHealthCheck, value.Type)
+	}
+	return value.HealthCheck.Validate()
+}
+
+// validateRegistry accepts private registries on compose applications only;
+// agents pull their images before compose up.
+func validateRegistry(value domain.Application) error {
+	if value.RegistryID != 0 && value.Type != domain.TypeCompose {
+		return ErrInvalidRegistry
+	}
+	return nil
+}
+
+// validateBundle accepts image bundles on compose applications only; agents
+// load them before compose up.
+func validateBundle(value domain.Application) error {
+	if value.BundleID != 0 && value.Type != domain.TypeCompose {
+		return ErrInvalidBundle
+	}
+	return nil
+}
+
 func validateYAML(content string) error {
 	if content == "" {
 		return nil
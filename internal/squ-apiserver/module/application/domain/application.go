@@ -2,6 +2,10 @@ package domain
 
 import "context"
 
+// TypeCompose is the type of applications deployed by agents with docker
+// compose.
+const TypeCompose = "compose"
+
 type Application struct {
 	ID          uint
 	Name        string
@@ -9,6 +13,14 @@ type Application struct {
 	Type        string
 	Content     string
 	Version     string
+	// HealthCheck is verified after each deploy of a compose application.
+	HealthCheck *HealthCheck
+	// RegistryID names the private registry that the images of a compose
+	// application are pulled from; zero when they are public.
+	RegistryID uint
+	// BundleID names the image bundle that is loaded on servers without
+	// registry access before each deploy of a compose application.
+	BundleID uint
 }
 
 type Repository interface {
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// Health check types. A compose check passes when every container runs and
+// the ones with a docker health check report healthy.
+const (
+	CheckCompose = "compose"
+	CheckHTTP    = "http"
+	CheckTCP     = "tcp"
+)
+
+// MaxHealthTimeout bounds how long an agent waits for the checks of a
+// deploy, in seconds.
+const MaxHealthTimeout = 600
+
+var ErrInvalidHealthCheck = errors.New("invalid health check")
+
+// HealthCheck is what an agent verifies after it deployed the application.
+// All checks must pass within Timeout seconds, or 60 when it is zero;
+// otherwise the agent restores the previous deployment.
+type HealthCheck struct {
+	Timeout int     `json:"timeout"`
+	Checks  []Check `json:"checks"`
+}
+
+// Check is one health check. HTTP checks GET URL and expect Status, or any
+// 2xx status when it is zero. TCP checks connect to Address. Both run on the
+// agent's host.
+type Check struct {
+	Type    string `json:"type"`
+	URL     string `json:"url,omitempty"`
+	Status  int    `json:"status,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+func (h HealthCheck) Validate() error {
+	if h.Timeout < 0 || h.Timeout > MaxHealthTimeout {
+		return fmt.Errorf("%w: timeout must be between 0 and %d seconds", ErrInvalidHealthCheck, MaxHealthTimeout)
+	}
+	if len(h.Checks) == 0 {
+		return fmt.Errorf("%w: no checks", ErrInvalidHealthCheck)
+	}
+	for i, check := range h.Checks {
+		if err := check.validate(); err != nil {
+			return fmt.Errorf("%w: check %d: %v", ErrInvalidHealthCheck, i+1, err)
+		}
+	}
+	return nil
+}
+
+func (c Check) validate() error {
+	switch c.Type {
+	case CheckCompose:
+		return nil
+	case CheckHTTP:
+		value, err := url.Parse(c.URL)
+		if err != nil || value.Scheme != "http" && value.Scheme != "https" || value.Host == "" {
+			return fmt.Errorf("invalid URL %q", c.URL)
+		}
+		if c.Status != 0 && (c.Status < 100 || c.Status > 599) {
+			return fmt.Errorf("invalid status %d", c.Status)
+		}
+		return nil
+	case CheckTCP:
+		if _, port, err := net.SplitHostPort(c.Address); err != nil || port == "" {
+			return fmt.Errorf("invalid address %q", c.Address)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown type %q", c.Type)
+	}
+}
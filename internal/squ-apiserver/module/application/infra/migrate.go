@@ -5,3 +5,21 @@ import "gorm.io/gorm"
 func Migrate(db *gorm.DB) error { return db.AutoMigrate(&model{}) }
 
 func Rollback(db *gorm.DB) error { return db.Migrator().DropTable("servers") }
+
+// MigrateHealthChecks adds the health checks of applications.
+func MigrateHealthChecks(db *gorm.DB) error { return db.AutoMigrate(&model{}) }
+func RollbackHealthChecks(db *gorm.DB) error {
+	return db.Migrator().DropColumn(&model{}, "health_check")
+}
+
+// MigrateRegistries adds the private registry of applications.
+func MigrateRegistries(db *gorm.DB) error { return db.AutoMigrate(&model{}) }
+func RollbackRegistries(db *gorm.DB) error {
+	return db.Migrator().DropColumn(&model{}, "registry_id")
+}
+
+// MigrateBundles adds the image bundle of applications.
+func MigrateBundles(db *gorm.DB) error { return db.AutoMigrate(&model{}) }
+func RollbackBundles(db *gorm.DB) error {
+	return db.Migrator().DropColumn(&model{}, "bundle_id")
+}
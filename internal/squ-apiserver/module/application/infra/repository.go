@@ -19,6 +19,9 @@ type model struct {
 	Type        string
 	Content     string
 	Version     string
+	HealthCheck *domain.HealthCheck `gorm:"type:json;serializer:json"`
+	RegistryID  uint                `gorm:"index"`
+	BundleID    uint                `gorm:"index"`
 }
 
 func (model) TableName() string { return "applications" }
@@ -61,13 +64,36 @@ func (r *Repository) Add(ctx context.Context, value *domain.Application) error {
 }
 
 func (r *Repository) Update(ctx context.Context, value *domain.Application) error {
-	return r.db.WithContext(ctx).Updates(toModel(*value)).Error
+	record := toModel(*value)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Updates(record).Error; err != nil {
+			return err
+		}
+		// Updates skips zero fields; an update without a registry, a bundle
+		// or a health check removes it.
+		if value.RegistryID == 0 {
+			if err := tx.Model(&record).Update("registry_id", 0).Error; err != nil {
+				return err
+			}
+		}
+		if value.BundleID == 0 {
+			if err := tx.Model(&record).Update("bundle_id", 0).Error; err != nil {
+				return err
+			}
+		}
+		if value.HealthCheck != nil {
+			return nil
+		}
+		return tx.Model(&record).Update("health_check", nil).Error
+	})
 }
 
 func toModel(v domain.Application) model {
-	return model{ID: v.ID, Name: v.Name, Description: v.Description, Type: v.Type, Content: v.Content, Version: v.Version}
+	return model{ID: v.ID, Name: v.Name, Description: v.Description, Type: v.Type, Content: v.Content, Version: v.Version,
+		HealthCheck: v.HealthCheck, RegistryID: v.RegistryID, BundleID: v.BundleID}
 }
 
 func toDomain(v model) domain.Application {
-	return domain.Application{ID: v.ID, Name: v.Name, Description: v.Description, Type: v.Type, Content: v.Content, Version: v.Version}
+	return domain.Application{ID: v.ID, Name: v.Name, Description: v.Description, Type: v.Type, Content: v.Content, Version: v.Version,
+		HealthCheck: v.HealthCheck, RegistryID: v.RegistryID, BundleID: v.BundleID}
 }
@@ -18,3 +18,12 @@ func RegisterHTTP(group *gin.RouterGroup, db *gorm.DB) {
 func Migrate(db *gorm.DB) error { return infra.Migrate(db) }
 
 func Rollback(db *gorm.DB) error { return infra.Rollback(db) }
+
+func MigrateHealthChecks(db *gorm.DB) error  { return infra.MigrateHealthChecks(db) }
+func RollbackHealthChecks(db *gorm.DB) error { return infra.RollbackHealthChecks(db) }
+
+func MigrateRegistries(db *gorm.DB) error  { return infra.MigrateRegistries(db) }
+func RollbackRegistries(db *gorm.DB) error { return infra.RollbackRegistries(db) }
+
+func MigrateBundles(db *gorm.DB) error  { return infra.MigrateBundles(db) }
+func RollbackBundles(db *gorm.DB) error { return infra.RollbackBundles(db) }
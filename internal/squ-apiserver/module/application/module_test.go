@@ -33,6 +33,79 @@ func TestLegacyApplicationYAMLContract(t *testing.T) {
 	assertApplication(t, engine, http.MethodGet, "/api/v1/application/bad", "", `{"code":71005,"message":"invalid application configuration"}`)
 }
 
+func TestApplicationHealthCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateHealthChecks(db); err != nil {
+		t.Fatal(err)
+	}
+	engine := gin.New()
+	RegisterHTTP(engine.Group("/api/v1"), db)
+
+	const invalid = `{"code":71008,"message":"invalid application health check"}`
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"compose","health_check":{"checks":[{"type":"http","url":"ftp://web"}]}}`, invalid)
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"compose","health_check":{"checks":[{"type":"tcp","address":"web"}]}}`, invalid)
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"compose","health_check":{"timeout":601,"checks":[{"type":"compose"}]}}`, invalid)
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"k8s_manifest","health_check":{"checks":[{"type":"compose"}]}}`, invalid)
+
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"compose","health_check":{"timeout":30,"checks":[{"type":"compose"},{"type":"http","url":"http://127.0.0.1:8080/health","status":204},{"type":"tcp","address":"127.0.0.1:5432"}]}}`, `{"code":0,"message":"success","data":"success"}`)
+	assertApplication(t, engine, http.MethodGet, "/api/v1/application/1", "", `{"code":0,"message":"success","data":{"id":1,"name":"web","description":"","type":"compose","content":"","version":"","health_check":{"timeout":30,"checks":[{"type":"compose"},{"type":"http","url":"http://127.0.0.1:8080/health","status":204},{"type":"tcp","address":"127.0.0.1:5432"}]}}}`)
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application/1", `{"name":"web","type":"compose"}`, `{"code":0,"message":"success","data":"success"}`)
+	assertApplication(t, engine, http.MethodGet, "/api/v1/application/1", "", `{"code":0,"message":"success","data":{"id":1,"name":"web","description":"","type":"compose","content":"","version":""}}`)
+}
+
+func TestApplicationRegistry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateRegistries(db); err != nil {
+		t.Fatal(err)
+	}
+	engine := gin.New()
+	RegisterHTTP(engine.Group("/api/v1"), db)
+
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"k8s_manifest","registry_id":1}`, `{"code":71009,"message":"invalid application registry"}`)
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"compose","registry_id":1}`, `{"code":0,"message":"success","data":"success"}`)
+	assertApplication(t, engine, http.MethodGet, "/api/v1/application/1", "", `{"code":0,"message":"success","data":{"id":1,"name":"web","description":"","type":"compose","content":"","version":"","registry_id":1}}`)
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application/1", `{"name":"web","type":"compose"}`, `{"code":0,"message":"success","data":"success"}`)
+	assertApplication(t, engine, http.MethodGet, "/api/v1/application/1", "", `{"code":0,"message":"success","data":{"id":1,"name":"web","description":"","type":"compose","content":"","version":""}}`)
+}
+
+func TestApplicationBundle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, migrate := range []func(*gorm.DB) error{Migrate, MigrateRegistries, MigrateBundles} {
+		if err := migrate(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine := gin.New()
+	RegisterHTTP(engine.Group("/api/v1"), db)
+
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"k8s_manifest","bundle_id":2}`, `{"code":71010,"message":"invalid application bundle"}`)
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application", `{"name":"web","type":"compose","registry_id":1,"bundle_id":2}`, `{"code":0,"message":"success","data":"success"}`)
+	assertApplication(t, engine, http.MethodGet, "/api/v1/application/1", "", `{"code":0,"message":"success","data":{"id":1,"name":"web","description":"","type":"compose","content":"","version":"","registry_id":1,"bundle_id":2}}`)
+	assertApplication(t, engine, http.MethodPost, "/api/v1/application/1", `{"name":"web","type":"compose","registry_id":1}`, `{"code":0,"message":"success","data":"success"}`)
+	assertApplication(t, engine, http.MethodGet, "/api/v1/application/1", "", `{"code":0,"message":"success","data":{"id":1,"name":"web","description":"","type":"compose","content":"","version":"","registry_id":1}}`)
+}
+
 func assertApplication(t *testing.T, engine http.Handler, method, path, body, expected string) {
 	t.Helper()
 	request := httptest.NewRequest(method, path, strings.NewReader(body))
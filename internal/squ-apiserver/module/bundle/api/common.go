@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/application"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+	"squirrel-dev/pkg/utils"
+)
+
+func bindRequest[T any](c *gin.Context) (T, bool) {
+	var request T
+	if err := c.ShouldBindJSON(&request); err != nil {
+		zap.L().Warn("failed to bind bundle request", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return request, false
+	}
+	return request, true
+}
+
+func bundleID(c *gin.Context) (uint, bool) {
+	rawID := c.Param("id")
+	id, err := utils.StringToUint(rawID)
+	if err != nil {
+		zap.L().Warn("failed to parse bundle ID", zap.String("raw_bundle_id", rawID), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+// optionalID parses an ID that may be left out.
+func optionalID(c *gin.Context, raw string) (uint, bool) {
+	if raw == "" {
+		return 0, true
+	}
+	id, err := utils.StringToUint(raw)
+	if err != nil {
+		zap.L().Warn("failed to parse application ID", zap.String("raw_application_id", raw), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+func writeResult(c *gin.Context, data any, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(data))
+}
+
+// writeError carries the reason of a rejected bundle or a failed transfer
+// as data; the user has to act on it.
+func writeError(c *gin.Context, err error) {
+	code := res.ErrBundleUpdateFailed
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = res.ErrBundleNotFound
+	case errors.Is(err, domain.ErrInvalidBundle):
+		code = res.ErrInvalidBundle
+	case errors.Is(err, domain.ErrBundleInUse):
+		code = res.ErrBundleInUse
+	case errors.Is(err, domain.ErrBundleNotReady):
+		code = res.ErrBundleNotReady
+	case errors.Is(err, application.ErrBundleTransfer):
+		code = res.ErrBundleTransfer
+	}
+	result := response.Error(code)
+	if code == res.ErrInvalidBundle || code == res.ErrBundleTransfer {
+		result.Data = err.Error()
+	}
+	c.JSON(http.StatusOK, result)
+}
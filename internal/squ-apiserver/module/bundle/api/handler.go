@@ -0,0 +1,136 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/application"
+)
+
+type Handler struct {
+	service *application.Service
+}
+
+func NewHandler(service *application.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	values, err := h.service.List(c.Request.Context())
+	result := make([]res.Bundle, 0, len(values))
+	for _, value := range values {
+		result = append(result, toResponse(value))
+	}
+	writeResult(c, result, err)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := bundleID(c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Get(c.Request.Context(), id)
+	writeResult(c, toResponse(value), err)
+}
+
+// Images lists the images a bundle for an application holds by default.
+func (h *Handler) Images(c *gin.Context) {
+	id, ok := optionalID(c, c.Query("application_id"))
+	if !ok {
+		return
+	}
+	if id == 0 {
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return
+	}
+	images, err := h.service.Images(c.Request.Context(), id)
+	writeResult(c, images, err)
+}
+
+// Build starts building a bundle on an agent; the bundle is returned while
+// it is still building.
+func (h *Handler) Build(c *gin.Context) {
+	request, ok := bindRequest[req.Build](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Build(c.Request.Context(), toBuildRequest(request))
+	writeResult(c, toResponse(value), err)
+}
+
+// Upload stores an archive written by docker save. It is either the file
+// field of a multipart form, with name and application_id fields, or the
+// raw request body, with name and application_id queries.
+func (h *Handler) Upload(c *gin.Context) {
+	var (
+		body                 io.Reader = c.Request.Body
+		name, rawApplication           = c.Query("name"), c.Query("application_id")
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			zap.L().Warn("failed to read uploaded bundle", zap.Error(err))
+			c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		defer file.Close()
+		body, name, rawApplication = file, c.PostForm("name"), c.PostForm("application_id")
+	}
+	applicationID, ok := optionalID(c, rawApplication)
+	if !ok {
+		return
+	}
+	value, err := h.service.Upload(c.Request.Context(), name, applicationID, body)
+	writeResult(c, toResponse(value), err)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, ok := bundleID(c)
+	if !ok {
+		return
+	}
+	writeResult(c, "success", h.service.Delete(c.Request.Context(), id))
+}
+
+// Download serves the archive of a ready bundle; ranges let a download
+// resume.
+func (h *Handler) Download(c *gin.Context) {
+	id, ok := bundleID(c)
+	if !ok {
+		return
+	}
+	bundle, file, err := h.service.Open(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	defer file.Close()
+	c.Header("Content-Disposition", `attachment; filename="`+bundle.Checksum+`.tar"`)
+	http.ServeContent(c.Writer, c.Request, bundle.Checksum+".tar", bundle.UpdatedAt, file)
+}
+
+// Push sends a bundle to the agent of a server ahead of a deployment.
+func (h *Handler) Push(c *gin.Context) {
+	id, ok := bundleID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Push](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Push(c.Request.Context(), id, request.ServerID)
+	writeResult(c, toResponse(value), err)
+}
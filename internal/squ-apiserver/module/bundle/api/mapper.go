@@ -0,0 +1,23 @@
+package api
+
+import (
+	"squirrel-dev/internal/squ-apiserver/module/bundle/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/application"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+)
+
+func toBuildRequest(value req.Build) application.BuildRequest {
+	return application.BuildRequest{Name: value.Name, ApplicationID: value.ApplicationID,
+		ServerID: value.ServerID, Images: value.Images}
+}
+
+func toResponse(value domain.Bundle) res.Bundle {
+	images := value.Images
+	if images == nil {
+		images = []string{}
+	}
+	return res.Bundle{ID: value.ID, Name: value.Name, ApplicationID: value.ApplicationID, ServerID: value.ServerID,
+		Source: value.Source, Status: value.Status, Message: value.Message, Images: images, Size: value.Size,
+		Checksum: value.Checksum, CreatedAt: value.CreatedAt, UpdatedAt: value.UpdatedAt}
+}
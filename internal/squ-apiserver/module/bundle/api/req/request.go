@@ -0,0 +1,15 @@
+package req
+
+// Build builds a bundle on the agent of a server. Images default to the
+// images of the compose file of the application; one of them is required.
+type Build struct {
+	Name          string   `json:"name"`
+	ApplicationID uint     `json:"application_id"`
+	ServerID      uint     `json:"server_id" binding:"required"`
+	Images        []string `json:"images"`
+}
+
+// Push names the server whose agent receives the bundle.
+type Push struct {
+	ServerID uint `json:"server_id" binding:"required"`
+}
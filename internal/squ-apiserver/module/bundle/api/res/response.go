@@ -0,0 +1,19 @@
+package res
+
+import "time"
+
+// Bundle is an image bundle. Message explains a failed build.
+type Bundle struct {
+	ID            uint      `json:"id"`
+	Name          string    `json:"name"`
+	ApplicationID uint      `json:"application_id,omitempty"`
+	ServerID      uint      `json:"server_id,omitempty"`
+	Source        string    `json:"source"`
+	Status        string    `json:"status"`
+	Message       string    `json:"message,omitempty"`
+	Images        []string  `json:"images"`
+	Size          int64     `json:"size"`
+	Checksum      string    `json:"checksum"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,23 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrBundleNotFound     = 77001
+	ErrInvalidParameter   = 77002
+	ErrInvalidBundle      = 77003
+	ErrBundleInUse        = 77004
+	ErrBundleNotReady     = 77005
+	ErrBundleTransfer     = 77006
+	ErrBundleUpdateFailed = 77007
+)
+
+func RegisterCode() {
+	response.Register(ErrBundleNotFound, "image bundle not found")
+	response.Register(ErrInvalidParameter, "invalid parameter")
+	response.Register(ErrInvalidBundle, "invalid image bundle")
+	response.Register(ErrBundleInUse, "image bundle is used by applications")
+	response.Register(ErrBundleNotReady, "image bundle is not ready")
+	response.Register(ErrBundleTransfer, "failed to send image bundle to agent")
+	response.Register(ErrBundleUpdateFailed, "image bundle update failed")
+}
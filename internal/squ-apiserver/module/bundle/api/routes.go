@@ -0,0 +1,14 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/bundle", handler.List)
+	group.POST("/bundle", handler.Build)
+	group.GET("/bundle/images", handler.Images)
+	group.POST("/bundle/upload", handler.Upload)
+	group.GET("/bundle/:id", handler.Get)
+	group.DELETE("/bundle/:id", handler.Delete)
+	group.GET("/bundle/:id/download", handler.Download)
+	group.POST("/bundle/:id/push", handler.Push)
+}
@@ -0,0 +1,5 @@
+package application
+
+import "errors"
+
+var ErrBundleTransfer = errors.New("failed to send image bundle to agent")
@@ -0,0 +1,336 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+)
+
+var (
+	// buildTimeout bounds pulling, saving and downloading the images of a
+	// bundle built on an agent.
+	buildTimeout = time.Hour
+	// pushChunk is the size of the chunks a bundle is sent to agents in.
+	pushChunk int64 = 8 << 20
+)
+
+// BuildRequest builds a bundle on a connected agent. Images default to the
+// images of the compose file of the application.
+type BuildRequest struct {
+	Name          string
+	ApplicationID uint
+	ServerID      uint
+	Images        []string
+}
+
+type Service struct {
+	repository   domain.Repository
+	store        domain.Store
+	agent        domain.Agent
+	applications domain.ApplicationReader
+	registries   domain.RegistryReader
+}
+
+func NewService(repository domain.Repository, store domain.Store, agent domain.Agent,
+	applications domain.ApplicationReader, registries domain.RegistryReader) *Service {
+	return &Service{repository: repository, store: store, agent: agent, applications: applications, registries: registries}
+}
+
+func (s *Service) List(ctx context.Context) ([]domain.Bundle, error) {
+	bundles, err := s.repository.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to list bundles", zap.Error(err))
+		return nil, err
+	}
+	return bundles, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uint) (domain.Bundle, error) {
+	bundle, err := s.repository.Get(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get bundle", zap.Uint("bundle_id", id), zap.Error(err))
+		return domain.Bundle{}, err
+	}
+	return bundle, nil
+}
+
+// Images returns the images a bundle for an application holds by default.
+func (s *Service) Images(ctx context.Context, applicationID uint) ([]string, error) {
+	application, err := s.application(ctx, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.ComposeImages(application.Content)
+}
+
+// Build records a bundle and builds it in the background: the agent pulls
+// and saves the images, and the apiserver downloads the archive. The
+// bundle is ready once its checksum matches the agent's.
+func (s *Service) Build(ctx context.Context, request BuildRequest) (domain.Bundle, error) {
+	if request.ServerID == 0 {
+		return domain.Bundle{}, fmt.Errorf("%w: server_id is required", domain.ErrInvalidBundle)
+	}
+	bundle := domain.Bundle{
+		Name:          strings.TrimSpace(request.Name),
+		ApplicationID: request.ApplicationID,
+		ServerID:      request.ServerID,
+		Source:        domain.SourceAgent,
+		Status:        domain.StatusBuilding,
+		Images:        request.Images,
+	}
+	var registry *domain.Registry
+	if request.ApplicationID != 0 {
+		application, err := s.application(ctx, request.ApplicationID)
+		if err != nil {
+			return domain.Bundle{}, err
+		}
+		if len(bundle.Images) == 0 {
+			if bundle.Images, err = domain.ComposeImages(application.Content); err != nil {
+				return domain.Bundle{}, err
+			}
+		}
+		if bundle.Name == "" {
+			bundle.Name = application.Name
+		}
+		if application.RegistryID != 0 {
+			value, err := s.registries.Get(ctx, application.RegistryID)
+			if err != nil {
+				zap.L().Error("failed to get registry of application",
+					zap.Uint("application_id", application.ID), zap.Uint("registry_id", application.RegistryID), zap.Error(err))
+				return domain.Bundle{}, err
+			}
+			registry = &value
+		}
+	}
+	if len(bundle.Images) == 0 {
+		return domain.Bundle{}, fmt.Errorf("%w: images or application_id is required", domain.ErrInvalidBundle)
+	}
+	if bundle.Name == "" {
+		return domain.Bundle{}, fmt.Errorf("%w: name is required", domain.ErrInvalidBundle)
+	}
+	if err := s.repository.Add(ctx, &bundle); err != nil {
+		zap.L().Error("failed to add bundle", zap.String("bundle_name", bundle.Name), zap.Error(err))
+		return domain.Bundle{}, err
+	}
+	zap.L().Info("building bundle",
+		zap.Uint("bundle_id", bundle.ID),
+		zap.Uint("server_id", bundle.ServerID),
+		zap.Strings("images", bundle.Images),
+	)
+	go s.build(bundle, registry)
+	return bundle, nil
+}
+
+func (s *Service) build(bundle domain.Bundle, registry *domain.Registry) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+	defer cancel()
+	checksum, size, err := s.fetch(ctx, bundle, registry)
+	if err != nil {
+		zap.L().Error("failed to build bundle", zap.Uint("bundle_id", bundle.ID), zap.Error(err))
+		bundle.Status, bundle.Message = domain.StatusFailed, err.Error()
+	} else {
+		bundle.Status, bundle.Message, bundle.Checksum, bundle.Size = domain.StatusReady, "", checksum, size
+		zap.L().Info("bundle built", zap.Uint("bundle_id", bundle.ID), zap.String("checksum", checksum), zap.Int64("size", size))
+	}
+	if err := s.repository.Update(ctx, &bundle); err != nil {
+		zap.L().Error("failed to update bundle", zap.Uint("bundle_id", bundle.ID), zap.Error(err))
+	}
+	// A bundle deleted while it was building leaves no archive behind.
+	if _, err := s.repository.Get(ctx, bundle.ID); errors.Is(err, gorm.ErrRecordNotFound) {
+		_ = s.store.Remove(bundle.ID)
+	}
+}
+
+func (s *Service) fetch(ctx context.Context, bundle domain.Bundle, registry *domain.Registry) (string, int64, error) {
+	saved, err := s.agent.Save(ctx, bundle.ServerID, bundle.Images, registry)
+	if err != nil {
+		return "", 0, err
+	}
+	archive, err := s.agent.Download(ctx, bundle.ServerID, saved.Checksum)
+	if err != nil {
+		return "", 0, err
+	}
+	defer archive.Close()
+	checksum, size, err := s.store.Write(bundle.ID, archive)
+	if err != nil {
+		return "", 0, err
+	}
+	if checksum != saved.Checksum {
+		_ = s.store.Remove(bundle.ID)
+		return "", 0, fmt.Errorf("downloaded archive has checksum %s, the agent saved %s", checksum, saved.Checksum)
+	}
+	return checksum, size, nil
+}
+
+// Upload stores an archive written by docker save elsewhere. Its images are
+// read from the archive's manifest.
+func (s *Service) Upload(ctx context.Context, name string, applicationID uint, r io.Reader) (domain.Bundle, error) {
+	bundle := domain.Bundle{
+		Name:          strings.TrimSpace(name),
+		ApplicationID: applicationID,
+		Source:        domain.SourceUpload,
+		Status:        domain.StatusBuilding,
+	}
+	if applicationID != 0 {
+		application, err := s.application(ctx, applicationID)
+		if err != nil {
+			return domain.Bundle{}, err
+		}
+		if bundle.Name == "" {
+			bundle.Name = application.Name
+		}
+	}
+	if bundle.Name == "" {
+		return domain.Bundle{}, fmt.Errorf("%w: name is required", domain.ErrInvalidBundle)
+	}
+	if err := s.repository.Add(ctx, &bundle); err != nil {
+		zap.L().Error("failed to add bundle", zap.String("bundle_name", bundle.Name), zap.Error(err))
+		return domain.Bundle{}, err
+	}
+	if err := s.keep(ctx, &bundle, r); err != nil {
+		_ = s.store.Remove(bundle.ID)
+		if deleteErr := s.repository.Delete(ctx, bundle.ID); deleteErr != nil {
+			zap.L().Error("failed to delete bundle", zap.Uint("bundle_id", bundle.ID), zap.Error(deleteErr))
+		}
+		return domain.Bundle{}, err
+	}
+	zap.L().Info("bundle uploaded", zap.Uint("bundle_id", bundle.ID), zap.String("checksum", bundle.Checksum), zap.Int64("size", bundle.Size))
+	return bundle, nil
+}
+
+// keep writes an uploaded archive and marks the bundle ready if it is one
+// docker can load.
+func (s *Service) keep(ctx context.Context, bundle *domain.Bundle, r io.Reader) error {
+	checksum, size, err := s.store.Write(bundle.ID, r)
+	if err != nil {
+		zap.L().Error("failed to store bundle", zap.Uint("bundle_id", bundle.ID), zap.Error(err))
+		return err
+	}
+	file, err := s.store.Open(bundle.ID)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if bundle.Images, err = domain.ArchiveImages(file); err != nil {
+		return err
+	}
+	bundle.Status, bundle.Checksum, bundle.Size = domain.StatusReady, checksum, size
+	if err := s.repository.Update(ctx, bundle); err != nil {
+		zap.L().Error("failed to update bundle", zap.Uint("bundle_id", bundle.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Open returns a ready bundle with its archive.
+func (s *Service) Open(ctx context.Context, id uint) (domain.Bundle, domain.File, error) {
+	bundle, err := s.Get(ctx, id)
+	if err != nil {
+		return domain.Bundle{}, nil, err
+	}
+	if bundle.Status != domain.StatusReady {
+		return domain.Bundle{}, nil, domain.ErrBundleNotReady
+	}
+	file, err := s.store.Open(id)
+	if err != nil {
+		zap.L().Error("failed to open bundle", zap.Uint("bundle_id", id), zap.Error(err))
+		return domain.Bundle{}, nil, err
+	}
+	return bundle, file, nil
+}
+
+// Delete removes a bundle no application deploys with.
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	count, err := s.repository.CountApplications(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to count applications of bundle", zap.Uint("bundle_id", id), zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		return domain.ErrBundleInUse
+	}
+	if err := s.repository.Delete(ctx, id); err != nil {
+		zap.L().Error("failed to delete bundle", zap.Uint("bundle_id", id), zap.Error(err))
+		return err
+	}
+	if err := s.store.Remove(id); err != nil {
+		zap.L().Warn("failed to remove bundle archive", zap.Uint("bundle_id", id), zap.Error(err))
+	}
+	zap.L().Info("bundle deleted", zap.Uint("bundle_id", id))
+	return nil
+}
+
+// Push sends a ready bundle to the agent of a server in checksummed chunks.
+// A transfer continues from what the agent already received, and ends with
+// the agent checking the checksum of the whole archive; an agent that
+// holds the bundle already is left alone.
+func (s *Service) Push(ctx context.Context, id, serverID uint) (domain.Bundle, error) {
+	bundle, file, err := s.Open(ctx, id)
+	if err != nil {
+		return domain.Bundle{}, err
+	}
+	defer file.Close()
+	state, err := s.agent.State(ctx, serverID, bundle.Checksum)
+	if err != nil {
+		return domain.Bundle{}, s.transferError(bundle, serverID, err)
+	}
+	if state.Complete {
+		return bundle, nil
+	}
+	resumed, chunk := state.Size, make([]byte, pushChunk)
+	for offset := state.Size; offset < bundle.Size; {
+		n, err := file.ReadAt(chunk, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return domain.Bundle{}, err
+		}
+		if n == 0 {
+			return domain.Bundle{}, fmt.Errorf("bundle %d: archive ends at %d bytes", id, offset)
+		}
+		if state, err = s.agent.Append(ctx, serverID, bundle.Checksum, offset, chunk[:n]); err != nil {
+			return domain.Bundle{}, s.transferError(bundle, serverID, err)
+		}
+		if state.Size <= offset {
+			return domain.Bundle{}, s.transferError(bundle, serverID, fmt.Errorf("agent kept %d bytes after offset %d", state.Size, offset))
+		}
+		offset = state.Size
+	}
+	// An agent holding more bytes than the archive fails the checksum and
+	// drops them, so the next push starts over.
+	if _, err := s.agent.Complete(ctx, serverID, bundle.Checksum); err != nil {
+		return domain.Bundle{}, s.transferError(bundle, serverID, err)
+	}
+	zap.L().Info("bundle sent to agent",
+		zap.Uint("bundle_id", id),
+		zap.Uint("server_id", serverID),
+		zap.Int64("resumed_at", resumed),
+	)
+	return bundle, nil
+}
+
+func (s *Service) transferError(bundle domain.Bundle, serverID uint, err error) error {
+	zap.L().Error("failed to send bundle to agent", zap.Uint("bundle_id", bundle.ID), zap.Uint("server_id", serverID), zap.Error(err))
+	return fmt.Errorf("%w: %v", ErrBundleTransfer, err)
+}
+
+func (s *Service) application(ctx context.Context, id uint) (domain.Application, error) {
+	application, err := s.applications.Get(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get application", zap.Uint("application_id", id), zap.Error(err))
+		return domain.Application{}, err
+	}
+	if !application.Compose() {
+		return domain.Application{}, fmt.Errorf("%w: application %s is not a compose application", domain.ErrInvalidBundle, application.Name)
+	}
+	return application, nil
+}
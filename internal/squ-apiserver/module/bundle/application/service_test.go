@@ -0,0 +1,287 @@
+package application
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/infra"
+)
+
+type repositoryStub struct {
+	mu           sync.Mutex
+	bundles      []domain.Bundle
+	applications int64
+}
+
+func (r *repositoryStub) List(context.Context) ([]domain.Bundle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.Bundle(nil), r.bundles...), nil
+}
+func (r *repositoryStub) Get(_ context.Context, id uint) (domain.Bundle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range r.bundles {
+		if value.ID == id {
+			return value, nil
+		}
+	}
+	return domain.Bundle{}, gorm.ErrRecordNotFound
+}
+func (r *repositoryStub) Add(_ context.Context, value *domain.Bundle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	value.ID = uint(len(r.bundles) + 1)
+	r.bundles = append(r.bundles, *value)
+	return nil
+}
+func (r *repositoryStub) Update(_ context.Context, value *domain.Bundle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.bundles {
+		if r.bundles[i].ID == value.ID {
+			r.bundles[i] = *value
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) Delete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bundles = slices.DeleteFunc(r.bundles, func(value domain.Bundle) bool { return value.ID == id })
+	return nil
+}
+func (r *repositoryStub) CountApplications(context.Context, uint) (int64, error) {
+	return r.applications, nil
+}
+
+// agentStub holds one archive, saved from images or received in chunks.
+type agentStub struct {
+	mu       sync.Mutex
+	archive  []byte
+	received []byte
+	offsets  []int64
+	images   []string
+	registry *domain.Registry
+}
+
+func (a *agentStub) Save(_ context.Context, _ uint, images []string, registry *domain.Registry) (domain.AgentBundle, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.images, a.registry = images, registry
+	return domain.AgentBundle{Checksum: checksum(a.archive), Size: int64(len(a.archive)), Complete: true}, nil
+}
+func (a *agentStub) Download(context.Context, uint, string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(a.archive)), nil
+}
+func (a *agentStub) State(_ context.Context, _ uint, value string) (domain.AgentBundle, error) {
+	return domain.AgentBundle{Checksum: value, Size: int64(len(a.received))}, nil
+}
+func (a *agentStub) Append(_ context.Context, _ uint, value string, offset int64, chunk []byte) (domain.AgentBundle, error) {
+	if offset != int64(len(a.received)) {
+		return domain.AgentBundle{}, errors.New("offset mismatch")
+	}
+	a.offsets = append(a.offsets, offset)
+	a.received = append(a.received, chunk...)
+	return domain.AgentBundle{Checksum: value, Size: int64(len(a.received))}, nil
+}
+func (a *agentStub) Complete(_ context.Context, _ uint, value string) (domain.AgentBundle, error) {
+	if checksum(a.received) != value {
+		a.received = nil
+		return domain.AgentBundle{}, errors.New("image bundle checksum mismatch")
+	}
+	return domain.AgentBundle{Checksum: value, Size: int64(len(a.received)), Complete: true}, nil
+}
+
+type applicationStub map[uint]domain.Application
+
+func (a applicationStub) Get(_ context.Context, id uint) (domain.Application, error) {
+	value, ok := a[id]
+	if !ok {
+		return domain.Application{}, gorm.ErrRecordNotFound
+	}
+	return value, nil
+}
+
+type registryStub map[uint]domain.Registry
+
+func (r registryStub) Get(_ context.Context, id uint) (domain.Registry, error) {
+	value, ok := r[id]
+	if !ok {
+		return domain.Registry{}, gorm.ErrRecordNotFound
+	}
+	return value, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// saveArchive writes a docker save archive holding images.
+func saveArchive(t *testing.T, images ...string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	archive := tar.NewWriter(&buffer)
+	manifest := `[{"Config":"config.json","RepoTags":["` + strings.Join(images, `","`) + `"],"Layers":[]}]`
+	if err := archive.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0o644, Size: int64(len(manifest))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archive.Write([]byte(manifest)); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func newService(t *testing.T, repository *repositoryStub, agent *agentStub) *Service {
+	t.Helper()
+	conf := &config.Config{Bundles: config.Bundles{Path: t.TempDir()}}
+	apps := applicationStub{
+		1: {ID: 1, Name: "shop", Type: "compose", RegistryID: 4,
+			Content: "services:\n  web:\n    image: harbor.example.com/shop/web:1.2\n  cache:\n    image: redis:7\n  worker:\n    build: .\n"},
+		2: {ID: 2, Name: "manifests", Type: "k8s_manifest"},
+	}
+	registries := registryStub{4: {Server: "harbor.example.com", Username: "ci", Password: "secret"}}
+	return NewService(repository, infra.NewStore(conf), agent, apps, registries)
+}
+
+func waitStatus(t *testing.T, repository *repositoryStub, id uint) domain.Bundle {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		bundle, _ := repository.Get(context.Background(), id)
+		if bundle.Status != domain.StatusBuilding {
+			return bundle
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("bundle %d is still building", id)
+	return domain.Bundle{}
+}
+
+func TestBuildSavesComposeImagesOnAgent(t *testing.T) {
+	repository := &repositoryStub{}
+	agent := &agentStub{archive: saveArchive(t, "harbor.example.com/shop/web:1.2", "redis:7")}
+	service := newService(t, repository, agent)
+	ctx := context.Background()
+
+	if _, err := service.Build(ctx, BuildRequest{ApplicationID: 2, ServerID: 3}); !errors.Is(err, domain.ErrInvalidBundle) {
+		t.Fatalf("Build() for a manifest application error = %v", err)
+	}
+	bundle, err := service.Build(ctx, BuildRequest{ApplicationID: 1, ServerID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Name != "shop" || bundle.Status != domain.StatusBuilding {
+		t.Fatalf("bundle = %+v", bundle)
+	}
+	bundle = waitStatus(t, repository, bundle.ID)
+	if bundle.Status != domain.StatusReady || bundle.Checksum != checksum(agent.archive) || bundle.Size != int64(len(agent.archive)) {
+		t.Fatalf("bundle = %+v", bundle)
+	}
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	if strings.Join(agent.images, ",") != "harbor.example.com/shop/web:1.2,redis:7" {
+		t.Fatalf("images = %v", agent.images)
+	}
+	if agent.registry == nil || agent.registry.Username != "ci" {
+		t.Fatalf("registry = %+v", agent.registry)
+	}
+	_, file, err := service.Open(ctx, bundle.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if stored, _ := io.ReadAll(file); !bytes.Equal(stored, agent.archive) {
+		t.Fatal("stored archive differs from the agent's")
+	}
+}
+
+func TestPushContinuesFromAgentState(t *testing.T) {
+	chunk := pushChunk
+	pushChunk = 300
+	defer func() { pushChunk = chunk }()
+
+	repository := &repositoryStub{}
+	archive := saveArchive(t, "redis:7")
+	service := newService(t, repository, &agentStub{})
+	ctx := context.Background()
+	bundle, err := service.Upload(ctx, "", 1, bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Name != "shop" || !slices.Equal(bundle.Images, []string{"redis:7"}) {
+		t.Fatalf("bundle = %+v", bundle)
+	}
+
+	agent := &agentStub{received: append([]byte(nil), archive[:1000]...)}
+	service.agent = agent
+	if _, err := service.Push(ctx, bundle.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(agent.received, archive) {
+		t.Fatalf("agent received %d of %d bytes", len(agent.received), len(archive))
+	}
+	if agent.offsets[0] != 1000 || agent.offsets[1] != 1300 {
+		t.Fatalf("offsets = %v", agent.offsets)
+	}
+
+	agent.received = append(agent.received[:len(archive)-1], 'x')
+	if _, err := service.Push(ctx, bundle.ID, 3); !errors.Is(err, ErrBundleTransfer) {
+		t.Fatalf("Push() onto a corrupt transfer error = %v", err)
+	}
+	if _, err := service.Push(ctx, bundle.ID, 3); err != nil || !bytes.Equal(agent.received, archive) {
+		t.Fatalf("Push() after the agent dropped the transfer error = %v", err)
+	}
+}
+
+func TestUploadRejectsArchiveWithoutImages(t *testing.T) {
+	repository := &repositoryStub{}
+	service := newService(t, repository, &agentStub{})
+	ctx := context.Background()
+
+	if _, err := service.Upload(ctx, "images", 0, strings.NewReader("not a tar archive")); !errors.Is(err, domain.ErrInvalidBundle) {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if bundles, _ := repository.List(ctx); len(bundles) != 0 {
+		t.Fatalf("bundles = %+v, want the rejected upload removed", bundles)
+	}
+}
+
+func TestDeleteRefusesBundleInUse(t *testing.T) {
+	repository := &repositoryStub{applications: 1}
+	service := newService(t, repository, &agentStub{})
+	ctx := context.Background()
+	bundle, err := service.Upload(ctx, "images", 0, bytes.NewReader(saveArchive(t, "redis:7")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.Delete(ctx, bundle.ID); !errors.Is(err, domain.ErrBundleInUse) {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	repository.applications = 0
+	if err := service.Delete(ctx, bundle.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.Open(ctx, bundle.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Open() after Delete() error = %v", err)
+	}
+}
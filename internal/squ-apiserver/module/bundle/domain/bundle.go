@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// Bundle states. A bundle built on an agent is building until the apiserver
+// has downloaded it.
+const (
+	StatusBuilding = "building"
+	StatusReady    = "ready"
+	StatusFailed   = "failed"
+)
+
+// Bundle sources.
+const (
+	SourceAgent  = "agent"
+	SourceUpload = "upload"
+)
+
+var (
+	ErrBundleInUse    = errors.New("bundle is used by applications")
+	ErrBundleNotReady = errors.New("bundle is not ready")
+	ErrInvalidBundle  = errors.New("invalid image bundle")
+)
+
+// Bundle is an image archive as docker save writes it, for servers without
+// registry access. It is stored on the apiserver and sent to agents, which
+// load it before compose up. Checksum is the sha256 of the archive and also
+// names it on agents.
+type Bundle struct {
+	ID        uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	// ApplicationID is the application whose images the bundle holds; zero
+	// for an upload that names none.
+	ApplicationID uint
+	// ServerID is the agent a bundle was built on.
+	ServerID uint
+	Source   string
+	Status   string
+	Message  string
+	Images   []string
+	Size     int64
+	Checksum string
+}
+
+type Repository interface {
+	List(context.Context) ([]Bundle, error)
+	Get(context.Context, uint) (Bundle, error)
+	Add(context.Context, *Bundle) error
+	Update(context.Context, *Bundle) error
+	Delete(context.Context, uint) error
+	// CountApplications counts the applications deployed with a bundle.
+	CountApplications(context.Context, uint) (int64, error)
+}
+
+// Store keeps the archives of bundles on the apiserver's disk.
+type Store interface {
+	// Write stores r as the archive of a bundle and returns its checksum and
+	// size.
+	Write(id uint, r io.Reader) (checksum string, size int64, err error)
+	Open(id uint) (File, error)
+	Remove(id uint) error
+}
+
+type File interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+}
+
+// AgentBundle is the state of a bundle on an agent. Size counts the bytes
+// the agent received; a transfer continues from there.
+type AgentBundle struct {
+	Checksum string
+	Size     int64
+	Complete bool
+}
+
+// Agent builds bundles on agents and moves them between the apiserver and
+// agents.
+type Agent interface {
+	// Save pulls images on the agent, logging in to registry for its own
+	// images, and saves them to a bundle there.
+	Save(ctx context.Context, serverID uint, images []string, registry *Registry) (AgentBundle, error)
+	Download(ctx context.Context, serverID uint, checksum string) (io.ReadCloser, error)
+	State(ctx context.Context, serverID uint, checksum string) (AgentBundle, error)
+	Append(ctx context.Context, serverID uint, checksum string, offset int64, chunk []byte) (AgentBundle, error)
+	Complete(ctx context.Context, serverID uint, checksum string) (AgentBundle, error)
+}
+
+// Application is the part of an application a bundle is built from.
+type Application struct {
+	ID         uint
+	Name       string
+	Type       string
+	Content    string
+	RegistryID uint
+}
+
+// Compose reports whether the application is deployed with docker compose,
+// the only kind that runs bundled images.
+func (a Application) Compose() bool { return a.Type == "compose" }
+
+type ApplicationReader interface {
+	Get(ctx context.Context, id uint) (Application, error)
+}
+
+// Registry holds the credentials of the private registry of an application.
+type Registry struct {
+	Server   string
+	Username string
+	Password string
+}
+
+type RegistryReader interface {
+	Get(ctx context.Context, id uint) (Registry, error)
+}
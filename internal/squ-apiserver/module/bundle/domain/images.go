@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/loader"
+	"github.com/compose-spec/compose-go/types"
+)
+
+// manifestName is the index of the images in a docker save archive.
+const manifestName = "manifest.json"
+
+// ComposeImages returns the images the services of a compose file run,
+// sorted and without duplicates. Services that are only built have no image
+// to bundle and are left out. Images are not interpolated, since a bundle
+// is built before any deployment provides the variables; an image that uses
+// them is an error, and the images have to be given explicitly instead.
+func ComposeImages(content string) ([]string, error) {
+	project, err := loader.LoadWithContext(context.Background(),
+		types.ConfigDetails{
+			WorkingDir:  "/",
+			ConfigFiles: []types.ConfigFile{{Filename: "docker-compose.yml", Content: []byte(content)}},
+		},
+		func(options *loader.Options) {
+			options.SetProjectName("squirrel", true)
+			options.SkipInterpolation = true
+			options.SkipNormalization = true
+			options.SkipConsistencyCheck = true
+			options.SkipResolveEnvironment = true
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse compose content: %v", ErrInvalidBundle, err)
+	}
+	var images []string
+	for _, service := range project.Services {
+		if service.Image == "" {
+			continue
+		}
+		if strings.Contains(service.Image, "$") {
+			return nil, fmt.Errorf("%w: image %q of service %s uses variables", ErrInvalidBundle, service.Image, service.Name)
+		}
+		images = append(images, service.Image)
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("%w: the compose file names no images", ErrInvalidBundle)
+	}
+	slices.Sort(images)
+	return slices.Compact(images), nil
+}
+
+// ArchiveImages returns the tagged images of a docker save archive, read
+// from its manifest.
+func ArchiveImages(r io.Reader) ([]string, error) {
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %s is missing", ErrInvalidBundle, manifestName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		if strings.TrimPrefix(header.Name, "./") != manifestName {
+			continue
+		}
+		var manifest []struct {
+			RepoTags []string `json:"RepoTags"`
+		}
+		if err := json.NewDecoder(archive).Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, manifestName, err)
+		}
+		var images []string
+		for _, entry := range manifest {
+			images = append(images, entry.RepoTags...)
+		}
+		if len(images) == 0 {
+			return nil, fmt.Errorf("%w: the archive holds no tagged images", ErrInvalidBundle)
+		}
+		slices.Sort(images)
+		return slices.Compact(images), nil
+	}
+}
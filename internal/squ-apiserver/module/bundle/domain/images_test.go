@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestComposeImages(t *testing.T) {
+	images, err := ComposeImages("services:\n  web:\n    image: nginx:1.27\n  api:\n    image: nginx:1.27\n  db:\n    image: postgres:16\n  worker:\n    build: .\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nginx:1.27", "postgres:16"}; !slices.Equal(images, want) {
+		t.Fatalf("images = %v, want %v", images, want)
+	}
+	for _, content := range []string{
+		"services:\n  web:\n    image: nginx:${TAG}\n",
+		"services:\n  worker:\n    build: .\n",
+		"services: [",
+	} {
+		if _, err := ComposeImages(content); !errors.Is(err, ErrInvalidBundle) {
+			t.Errorf("ComposeImages(%q) error = %v, want ErrInvalidBundle", content, err)
+		}
+	}
+}
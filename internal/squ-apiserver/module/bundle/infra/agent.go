@@ -0,0 +1,166 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	"squirrel-dev/pkg/utils"
+)
+
+const (
+	bundlePath = "/application/bundle"
+	// chunkChecksumHeader carries the sha256 of the chunk in a request body.
+	chunkChecksumHeader = "X-Chunk-Checksum"
+	// maxAgentResponse bounds the size of a JSON agent response.
+	maxAgentResponse = 1 << 20
+)
+
+// AgentClient moves bundles between the apiserver and agents. Saving images
+// and moving archives take as long as the images are large, so requests are
+// bounded by the caller's context only.
+type AgentClient struct {
+	config  *config.Config
+	servers serverDomain.Repository
+	http    *http.Client
+}
+
+func NewAgentClient(conf *config.Config, servers serverDomain.Repository) *AgentClient {
+	return &AgentClient{config: conf, servers: servers, http: &http.Client{}}
+}
+
+func (c *AgentClient) Save(ctx context.Context, serverID uint, images []string, registry *domain.Registry) (domain.AgentBundle, error) {
+	body := map[string]any{"images": images}
+	if registry != nil {
+		body["registry"] = map[string]string{
+			"server":   registry.Server,
+			"username": registry.Username,
+			"password": registry.Password,
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return domain.AgentBundle{}, err
+	}
+	return c.do(ctx, serverID, http.MethodPost, bundlePath+"/save", "application/json", data, nil)
+}
+
+func (c *AgentClient) Download(ctx context.Context, serverID uint, checksum string) (io.ReadCloser, error) {
+	address, err := c.url(ctx, serverID, bundlePath+"/"+url.PathEscape(checksum)+"/download")
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.http.Do(request)
+	if err != nil {
+		zap.L().Error("agent request failed", zap.String("url", address), zap.Uint("server_id", serverID), zap.Error(err))
+		return nil, fmt.Errorf("agent request failed: %w", err)
+	}
+	if reply.StatusCode == http.StatusOK && reply.Header.Get("Content-Disposition") != "" {
+		return reply.Body, nil
+	}
+	// Anything else is an error response instead of the archive.
+	defer reply.Body.Close()
+	_, err = decode(reply)
+	if err == nil {
+		err = fmt.Errorf("unexpected agent response: status %d", reply.StatusCode)
+	}
+	return nil, err
+}
+
+func (c *AgentClient) State(ctx context.Context, serverID uint, checksum string) (domain.AgentBundle, error) {
+	return c.do(ctx, serverID, http.MethodGet, bundlePath+"/"+url.PathEscape(checksum), "", nil, nil)
+}
+
+func (c *AgentClient) Append(ctx context.Context, serverID uint, checksum string, offset int64, chunk []byte) (domain.AgentBundle, error) {
+	sum := sha256.Sum256(chunk)
+	path := bundlePath + "/" + url.PathEscape(checksum) + "/chunk?offset=" + strconv.FormatInt(offset, 10)
+	return c.do(ctx, serverID, http.MethodPost, path, "application/octet-stream", chunk,
+		http.Header{chunkChecksumHeader: {hex.EncodeToString(sum[:])}})
+}
+
+func (c *AgentClient) Complete(ctx context.Context, serverID uint, checksum string) (domain.AgentBundle, error) {
+	return c.do(ctx, serverID, http.MethodPost, bundlePath+"/"+url.PathEscape(checksum)+"/complete", "", nil, nil)
+}
+
+func (c *AgentClient) do(ctx context.Context, serverID uint, method, path, contentType string, body []byte, header http.Header) (domain.AgentBundle, error) {
+	address, err := c.url(ctx, serverID, path)
+	if err != nil {
+		return domain.AgentBundle{}, err
+	}
+	request, err := http.NewRequestWithContext(ctx, method, address, bytes.NewReader(body))
+	if err != nil {
+		return domain.AgentBundle{}, err
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	reply, err := c.http.Do(request)
+	if err != nil {
+		zap.L().Error("agent request failed", zap.String("url", address), zap.Uint("server_id", serverID), zap.Error(err))
+		return domain.AgentBundle{}, fmt.Errorf("agent request failed: %w", err)
+	}
+	defer reply.Body.Close()
+	data, err := decode(reply)
+	if err != nil {
+		return domain.AgentBundle{}, err
+	}
+	var value struct {
+		Checksum string `json:"checksum"`
+		Size     int64  `json:"size"`
+		Complete bool   `json:"complete"`
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return domain.AgentBundle{}, fmt.Errorf("parse agent response failed: %w", err)
+	}
+	return domain.AgentBundle{Checksum: value.Checksum, Size: value.Size, Complete: value.Complete}, nil
+}
+
+// decode returns the data of a successful agent response. A failed one
+// becomes an error with the reason the agent put into data, if any.
+func decode(reply *http.Response) (json.RawMessage, error) {
+	var value struct {
+		response.Response
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(reply.Body, maxAgentResponse)).Decode(&value); err != nil {
+		return nil, fmt.Errorf("parse agent response failed: status %d: %w", reply.StatusCode, err)
+	}
+	if value.Code != 0 {
+		var reason string
+		if json.Unmarshal(value.Data, &reason) == nil && reason != "" {
+			return nil, errors.New(reason)
+		}
+		return nil, fmt.Errorf("agent error: code=%d, message=%s", value.Code, value.Message)
+	}
+	return value.Data, nil
+}
+
+func (c *AgentClient) url(ctx context.Context, serverID uint, path string) (string, error) {
+	server, err := c.servers.Get(ctx, serverID)
+	if err != nil {
+		return "", err
+	}
+	return utils.GenAgentUrl(c.config.Agent.Http.Scheme, server.IPAddress, server.AgentPort,
+		c.config.Agent.Http.BaseUrl, path), nil
+}
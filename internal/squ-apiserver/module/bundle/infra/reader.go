@@ -0,0 +1,38 @@
+package infra
+
+import (
+	"context"
+
+	applicationDomain "squirrel-dev/internal/squ-apiserver/module/application/domain"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+	registryDomain "squirrel-dev/internal/squ-apiserver/module/registry/domain"
+)
+
+type ApplicationReader struct{ repository applicationDomain.Repository }
+
+func NewApplicationReader(repository applicationDomain.Repository) *ApplicationReader {
+	return &ApplicationReader{repository: repository}
+}
+
+func (r *ApplicationReader) Get(ctx context.Context, id uint) (domain.Application, error) {
+	value, err := r.repository.Get(ctx, id)
+	if err != nil {
+		return domain.Application{}, err
+	}
+	return domain.Application{ID: value.ID, Name: value.Name, Type: value.Type, Content: value.Content,
+		RegistryID: value.RegistryID}, nil
+}
+
+type RegistryReader struct{ repository registryDomain.Repository }
+
+func NewRegistryReader(repository registryDomain.Repository) *RegistryReader {
+	return &RegistryReader{repository: repository}
+}
+
+func (r *RegistryReader) Get(ctx context.Context, id uint) (domain.Registry, error) {
+	value, err := r.repository.Get(ctx, id)
+	if err != nil {
+		return domain.Registry{}, err
+	}
+	return domain.Registry{Server: value.Server, Username: value.Username, Password: value.Password}, nil
+}
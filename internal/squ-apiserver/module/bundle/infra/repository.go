@@ -0,0 +1,91 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+)
+
+type bundleModel struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	Name          string         `gorm:"type:varchar(100);not null"`
+	ApplicationID uint           `gorm:"index"`
+	ServerID      uint
+	Source        string   `gorm:"type:varchar(20);not null"`
+	Status        string   `gorm:"type:varchar(20);not null"`
+	Message       string   `gorm:"type:text"`
+	Images        []string `gorm:"type:json;serializer:json"`
+	Size          int64
+	Checksum      string `gorm:"type:varchar(64)"`
+}
+
+func (bundleModel) TableName() string { return "image_bundles" }
+
+type Repository struct{ db *gorm.DB }
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+func (r *Repository) List(ctx context.Context) ([]domain.Bundle, error) {
+	var models []bundleModel
+	if err := r.db.WithContext(ctx).Order("id DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.Bundle, 0, len(models))
+	for _, model := range models {
+		result = append(result, toDomain(model))
+	}
+	return result, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id uint) (domain.Bundle, error) {
+	var model bundleModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		return domain.Bundle{}, err
+	}
+	return toDomain(model), nil
+}
+
+func (r *Repository) Add(ctx context.Context, value *domain.Bundle) error {
+	model := toModel(*value)
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	value.ID, value.CreatedAt, value.UpdatedAt = model.ID, model.CreatedAt, model.UpdatedAt
+	return nil
+}
+
+// Update writes every field, so that a finished build clears its message.
+func (r *Repository) Update(ctx context.Context, value *domain.Bundle) error {
+	model := toModel(*value)
+	return r.db.WithContext(ctx).Model(&bundleModel{ID: value.ID}).
+		Select("name", "status", "message", "images", "size", "checksum").Updates(&model).Error
+}
+
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&bundleModel{}, id).Error
+}
+
+func (r *Repository) CountApplications(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("applications").
+		Where("bundle_id = ? AND deleted_at IS NULL", id).Count(&count).Error
+	return count, err
+}
+
+func toModel(v domain.Bundle) bundleModel {
+	return bundleModel{ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+		ApplicationID: v.ApplicationID, ServerID: v.ServerID, Source: v.Source, Status: v.Status,
+		Message: v.Message, Images: v.Images, Size: v.Size, Checksum: v.Checksum}
+}
+
+func toDomain(v bundleModel) domain.Bundle {
+	return domain.Bundle{ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+		ApplicationID: v.ApplicationID, ServerID: v.ServerID, Source: v.Source, Status: v.Status,
+		Message: v.Message, Images: v.Images, Size: v.Size, Checksum: v.Checksum}
+}
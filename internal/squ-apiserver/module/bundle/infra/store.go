@@ -0,0 +1,69 @@
+package infra
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+)
+
+// defaultBundlePath is used when the configuration names no directory.
+const defaultBundlePath = "./bundles"
+
+// Store keeps the archive of each bundle as <id>.tar. An archive is written
+// to <id>.tar.part first, so that a failed write never looks complete.
+type Store struct{ basePath string }
+
+func NewStore(conf *config.Config) *Store {
+	basePath := conf.Bundles.Path
+	if basePath == "" {
+		basePath = defaultBundlePath
+	}
+	return &Store{basePath: basePath}
+}
+
+func (s *Store) Write(id uint, r io.Reader) (string, int64, error) {
+	if err := os.MkdirAll(s.basePath, 0o755); err != nil {
+		return "", 0, err
+	}
+	partial := s.file(id) + ".part"
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(partial)
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(partial, s.file(id)); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func (s *Store) Open(id uint) (domain.File, error) {
+	return os.Open(s.file(id))
+}
+
+func (s *Store) Remove(id uint) error {
+	if err := os.Remove(s.file(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *Store) file(id uint) string {
+	return filepath.Join(s.basePath, strconv.FormatUint(uint64(id), 10)+".tar")
+}
@@ -0,0 +1,35 @@
+package bundle
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	applicationInfra "squirrel-dev/internal/squ-apiserver/module/application/infra"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/api"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/application"
+	"squirrel-dev/internal/squ-apiserver/module/bundle/infra"
+	registryInfra "squirrel-dev/internal/squ-apiserver/module/registry/infra"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+)
+
+// NewService builds the bundle service; deployments use it to send the
+// bundle of an application to agents.
+func NewService(conf *config.Config, db *gorm.DB) *application.Service {
+	return application.NewService(
+		infra.NewRepository(db),
+		infra.NewStore(conf),
+		infra.NewAgentClient(conf, serverInfra.NewRepository(db, secretInfra.NewCipher(conf))),
+		infra.NewApplicationReader(applicationInfra.NewRepository(db)),
+		infra.NewRegistryReader(registryInfra.NewRepository(db, secretInfra.NewCipher(conf))),
+	)
+}
+
+func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
+	res.RegisterCode()
+	api.RegisterRoutes(group, api.NewHandler(NewService(conf, db)))
+}
+func Migrate(db *gorm.DB) error  { return infra.Migrate(db) }
+func Rollback(db *gorm.DB) error { return infra.Rollback(db) }
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/cluster/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/cluster/application"
+	"squirrel-dev/internal/squ-apiserver/module/cluster/domain"
+	"squirrel-dev/pkg/utils"
+)
+
+func bindRequest[T any](c *gin.Context) (T, bool) {
+	var request T
+	if err := c.ShouldBindJSON(&request); err != nil {
+		zap.L().Warn("failed to bind cluster request", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return request, false
+	}
+	return request, true
+}
+
+func clusterID(c *gin.Context) (uint, bool) {
+	rawID := c.Param("id")
+	id, err := utils.StringToUint(rawID)
+	if err != nil {
+		zap.L().Warn("failed to parse cluster ID", zap.String("raw_cluster_id", rawID), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+func writeResult(c *gin.Context, data any, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(data))
+}
+
+func writeError(c *gin.Context, err error) {
+	code := res.ErrClusterUpdateFailed
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = res.ErrClusterNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		code = res.ErrClusterAlreadyExists
+	case errors.Is(err, application.ErrInvalidCluster):
+		code = res.ErrInvalidCluster
+	case errors.Is(err, application.ErrInvalidKubeconfig):
+		code = res.ErrInvalidKubeconfig
+	case errors.Is(err, domain.ErrClusterInUse):
+		code = res.ErrClusterInUse
+	case errors.Is(err, application.ErrClusterUnreachable):
+		code = res.ErrClusterUnreachable
+	}
+	c.JSON(http.StatusOK, response.Error(code))
+}
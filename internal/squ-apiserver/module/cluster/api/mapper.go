@@ -0,0 +1,18 @@
+package api
+
+import (
+	"squirrel-dev/internal/squ-apiserver/module/cluster/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/cluster/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/cluster/application"
+	"squirrel-dev/internal/squ-apiserver/module/cluster/domain"
+)
+
+func toChange(value req.Cluster) application.Change {
+	return application.Change{Name: value.Name, Description: value.Description,
+		Namespace: value.Namespace, Kubeconfig: value.Kubeconfig}
+}
+
+func toResponse(value domain.Cluster) res.Cluster {
+	return res.Cluster{ID: value.ID, Name: value.Name, Description: value.Description, Server: value.Server,
+		Namespace: value.Namespace, CreatedAt: value.CreatedAt, UpdatedAt: value.UpdatedAt}
+}
@@ -0,0 +1,10 @@
+package req
+
+// Cluster registers or updates a cluster. Kubeconfig is the content of a
+// kubeconfig file; it may be left empty on update to keep the stored one.
+type Cluster struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Namespace   string `json:"namespace"`
+	Kubeconfig  string `json:"kubeconfig"`
+}
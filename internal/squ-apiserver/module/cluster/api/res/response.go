@@ -0,0 +1,19 @@
+package res
+
+import "time"
+
+// Cluster never carries the kubeconfig; it holds credentials.
+type Cluster struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Server      string    `json:"server"`
+	Namespace   string    `json:"namespace"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ClusterCheck struct {
+	Server  string `json:"server"`
+	Version string `json:"version"`
+}
@@ -0,0 +1,25 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrClusterNotFound      = 74001
+	ErrClusterAlreadyExists = 74002
+	ErrInvalidParameter     = 74003
+	ErrInvalidCluster       = 74004
+	ErrInvalidKubeconfig    = 74005
+	ErrClusterInUse         = 74006
+	ErrClusterUnreachable   = 74007
+	ErrClusterUpdateFailed  = 74008
+)
+
+func RegisterCode() {
+	response.Register(ErrClusterNotFound, "cluster not found")
+	response.Register(ErrClusterAlreadyExists, "cluster already exists")
+	response.Register(ErrInvalidParameter, "invalid parameter")
+	response.Register(ErrInvalidCluster, "invalid cluster")
+	response.Register(ErrInvalidKubeconfig, "invalid kubeconfig")
+	response.Register(ErrClusterInUse, "cluster is used by deployments")
+	response.Register(ErrClusterUnreachable, "cluster is unreachable")
+	response.Register(ErrClusterUpdateFailed, "cluster update failed")
+}
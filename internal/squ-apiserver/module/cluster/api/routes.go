@@ -0,0 +1,12 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/cluster", handler.List)
+	group.POST("/cluster", handler.Add)
+	group.GET("/cluster/:id", handler.Get)
+	group.POST("/cluster/:id", handler.Update)
+	group.DELETE("/cluster/:id", handler.Delete)
+	group.POST("/cluster/:id/check", handler.Check)
+}
@@ -0,0 +1,9 @@
+package application
+
+import "errors"
+
+var (
+	ErrInvalidCluster     = errors.New("invalid cluster")
+	ErrInvalidKubeconfig  = errors.New("invalid kubeconfig")
+	ErrClusterUnreachable = errors.New("cluster is unreachable")
+)
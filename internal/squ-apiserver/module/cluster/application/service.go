@@ -0,0 +1,148 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"squirrel-dev/internal/squ-apiserver/module/cluster/domain"
+)
+
+// Change is a cluster as submitted by a user. An empty Kubeconfig on update
+// keeps the stored one, so clients never need to read it back.
+type Change struct {
+	Name        string
+	Description string
+	Namespace   string
+	Kubeconfig  string
+}
+
+type Service struct {
+	repository domain.Repository
+	connector  domain.Connector
+}
+
+func NewService(repository domain.Repository, connector domain.Connector) *Service {
+	return &Service{repository: repository, connector: connector}
+}
+
+func (s *Service) List(ctx context.Context) ([]domain.Cluster, error) {
+	clusters, err := s.repository.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to list clusters", zap.Error(err))
+		return nil, err
+	}
+	return clusters, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uint) (domain.Cluster, error) {
+	cluster, err := s.repository.Get(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get cluster", zap.Uint("cluster_id", id), zap.Error(err))
+		return domain.Cluster{}, err
+	}
+	return cluster, nil
+}
+
+// Add registers a cluster. The kubeconfig must parse, but the cluster does
+// not have to be reachable yet; Check reports whether it is.
+func (s *Service) Add(ctx context.Context, change Change) (domain.Cluster, error) {
+	if strings.TrimSpace(change.Kubeconfig) == "" {
+		return domain.Cluster{}, ErrInvalidKubeconfig
+	}
+	cluster := domain.Cluster{}
+	if err := s.apply(&cluster, change); err != nil {
+		return domain.Cluster{}, err
+	}
+	if err := s.repository.Add(ctx, &cluster); err != nil {
+		zap.L().Error("failed to add cluster", zap.String("cluster_name", cluster.Name), zap.Error(err))
+		return domain.Cluster{}, err
+	}
+	zap.L().Info("cluster registered",
+		zap.Uint("cluster_id", cluster.ID),
+		zap.String("cluster_name", cluster.Name),
+		zap.String("server", cluster.Server),
+	)
+	return cluster, nil
+}
+
+func (s *Service) Update(ctx context.Context, id uint, change Change) (domain.Cluster, error) {
+	cluster, err := s.Get(ctx, id)
+	if err != nil {
+		return domain.Cluster{}, err
+	}
+	if err := s.apply(&cluster, change); err != nil {
+		return domain.Cluster{}, err
+	}
+	if err := s.repository.Update(ctx, &cluster); err != nil {
+		zap.L().Error("failed to update cluster", zap.Uint("cluster_id", id), zap.Error(err))
+		return domain.Cluster{}, err
+	}
+	return cluster, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	count, err := s.repository.CountDeployments(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to count cluster deployments", zap.Uint("cluster_id", id), zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		zap.L().Warn("refusing to delete cluster with deployments",
+			zap.Uint("cluster_id", id),
+			zap.Int64("deployments", count),
+		)
+		return domain.ErrClusterInUse
+	}
+	if err := s.repository.Delete(ctx, id); err != nil {
+		zap.L().Error("failed to delete cluster", zap.Uint("cluster_id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Check connects to a registered cluster and returns its version.
+func (s *Service) Check(ctx context.Context, id uint) (domain.Info, error) {
+	cluster, err := s.Get(ctx, id)
+	if err != nil {
+		return domain.Info{}, err
+	}
+	info, err := s.connector.Check(ctx, cluster.Kubeconfig)
+	if err != nil {
+		zap.L().Warn("cluster check failed",
+			zap.Uint("cluster_id", id),
+			zap.String("server", cluster.Server),
+			zap.Error(err),
+		)
+		return domain.Info{}, fmt.Errorf("%w: %v", ErrClusterUnreachable, err)
+	}
+	return info, nil
+}
+
+func (s *Service) apply(cluster *domain.Cluster, change Change) error {
+	name := strings.TrimSpace(change.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCluster)
+	}
+	if change.Namespace != "" {
+		if problems := validation.IsDNS1123Label(change.Namespace); len(problems) > 0 {
+			return fmt.Errorf("%w: namespace %q: %s", ErrInvalidCluster, change.Namespace, strings.Join(problems, "; "))
+		}
+	}
+	if change.Kubeconfig != "" {
+		server, err := s.connector.Server(change.Kubeconfig)
+		if err != nil {
+			zap.L().Warn("rejected cluster kubeconfig", zap.String("cluster_name", name), zap.Error(err))
+			return fmt.Errorf("%w: %v", ErrInvalidKubeconfig, err)
+		}
+		cluster.Kubeconfig, cluster.Server = change.Kubeconfig, server
+	}
+	cluster.Name, cluster.Description, cluster.Namespace = name, change.Description, change.Namespace
+	return nil
+}
@@ -0,0 +1,124 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/cluster/domain"
+)
+
+type repositoryStub struct {
+	clusters    []domain.Cluster
+	deployments int64
+	deleted     []uint
+}
+
+func (r *repositoryStub) List(context.Context) ([]domain.Cluster, error) { return r.clusters, nil }
+func (r *repositoryStub) Get(_ context.Context, id uint) (domain.Cluster, error) {
+	for _, value := range r.clusters {
+		if value.ID == id {
+			return value, nil
+		}
+	}
+	return domain.Cluster{}, gorm.ErrRecordNotFound
+}
+func (r *repositoryStub) Add(_ context.Context, value *domain.Cluster) error {
+	value.ID = uint(len(r.clusters) + 1)
+	r.clusters = append(r.clusters, *value)
+	return nil
+}
+func (r *repositoryStub) Update(_ context.Context, value *domain.Cluster) error {
+	for i := range r.clusters {
+		if r.clusters[i].ID == value.ID {
+			r.clusters[i] = *value
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) Delete(_ context.Context, id uint) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+func (r *repositoryStub) CountDeployments(context.Context, uint) (int64, error) {
+	return r.deployments, nil
+}
+
+type connectorStub struct{ err error }
+
+func (c connectorStub) Server(kubeconfig string) (string, error) {
+	if kubeconfig == "broken" {
+		return "", errors.New("no current context")
+	}
+	return "https://" + kubeconfig, nil
+}
+func (c connectorStub) Check(context.Context, string) (domain.Info, error) {
+	return domain.Info{Server: "https://k8s", Version: "v1.36.0"}, c.err
+}
+
+func TestAddValidatesCluster(t *testing.T) {
+	service := NewService(&repositoryStub{}, connectorStub{})
+	ctx := context.Background()
+	cases := []struct {
+		change Change
+		want   error
+	}{
+		{Change{Name: "prod"}, ErrInvalidKubeconfig},
+		{Change{Name: "prod", Kubeconfig: "broken"}, ErrInvalidKubeconfig},
+		{Change{Name: " ", Kubeconfig: "k8s"}, ErrInvalidCluster},
+		{Change{Name: "prod", Namespace: "Not_Valid", Kubeconfig: "k8s"}, ErrInvalidCluster},
+	}
+	for _, tc := range cases {
+		if _, err := service.Add(ctx, tc.change); !errors.Is(err, tc.want) {
+			t.Errorf("Add(%+v) error = %v, want %v", tc.change, err, tc.want)
+		}
+	}
+
+	cluster, err := service.Add(ctx, Change{Name: "prod", Namespace: "shop", Kubeconfig: "k8s"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if cluster.Server != "https://k8s" || cluster.Namespace != "shop" {
+		t.Fatalf("cluster = %+v", cluster)
+	}
+}
+
+func TestUpdateKeepsKubeconfig(t *testing.T) {
+	repository := &repositoryStub{clusters: []domain.Cluster{{ID: 1, Name: "prod", Kubeconfig: "k8s", Server: "https://k8s"}}}
+	service := NewService(repository, connectorStub{})
+
+	cluster, err := service.Update(context.Background(), 1, Change{Name: "production", Description: "main"})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if cluster.Kubeconfig != "k8s" || cluster.Server != "https://k8s" || cluster.Name != "production" {
+		t.Fatalf("cluster = %+v", cluster)
+	}
+}
+
+func TestDeleteRefusesClusterInUse(t *testing.T) {
+	repository := &repositoryStub{clusters: []domain.Cluster{{ID: 1, Name: "prod"}}, deployments: 2}
+	service := NewService(repository, connectorStub{})
+
+	if err := service.Delete(context.Background(), 1); !errors.Is(err, domain.ErrClusterInUse) {
+		t.Fatalf("Delete() error = %v, want %v", err, domain.ErrClusterInUse)
+	}
+	repository.deployments = 0
+	if err := service.Delete(context.Background(), 1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(repository.deleted) != 1 {
+		t.Fatalf("deleted = %v", repository.deleted)
+	}
+}
+
+func TestCheckReportsUnreachableCluster(t *testing.T) {
+	repository := &repositoryStub{clusters: []domain.Cluster{{ID: 1, Name: "prod", Kubeconfig: "k8s"}}}
+	service := NewService(repository, connectorStub{err: errors.New("connection refused")})
+
+	if _, err := service.Check(context.Background(), 1); !errors.Is(err, ErrClusterUnreachable) {
+		t.Fatalf("Check() error = %v, want %v", err, ErrClusterUnreachable)
+	}
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrClusterInUse is returned when a cluster that deployments still target is
+// deleted.
+var ErrClusterInUse = errors.New("cluster is used by deployments")
+
+// Cluster is a Kubernetes cluster that manifests can be deployed to. Server
+// is the API server address read from Kubeconfig, and Namespace is used for
+// namespaced objects when neither the manifest nor the deployment sets one.
+type Cluster struct {
+	ID          uint
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Description string
+	Server      string
+	Namespace   string
+	Kubeconfig  string
+}
+
+// Info is what a reachable cluster reports about itself.
+type Info struct {
+	Server  string
+	Version string
+}
+
+// Cipher encrypts kubeconfigs at rest.
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+type Repository interface {
+	// List returns clusters without their kubeconfigs.
+	List(context.Context) ([]Cluster, error)
+	Get(context.Context, uint) (Cluster, error)
+	Add(context.Context, *Cluster) error
+	Update(context.Context, *Cluster) error
+	Delete(context.Context, uint) error
+	// CountDeployments counts the deployments that target a cluster.
+	CountDeployments(context.Context, uint) (int64, error)
+}
+
+// Connector validates kubeconfigs and talks to the clusters they describe.
+type Connector interface {
+	// Server parses kubeconfig and returns the API server of its current
+	// context.
+	Server(kubeconfig string) (string, error)
+	Check(ctx context.Context, kubeconfig string) (Info, error)
+}
@@ -0,0 +1,42 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"squirrel-dev/internal/squ-apiserver/module/cluster/domain"
+	"squirrel-dev/pkg/k8s"
+)
+
+// checkTimeout bounds a connectivity check; discovery calls take no context.
+const checkTimeout = 10 * time.Second
+
+// Connector reaches clusters with client-go.
+type Connector struct{}
+
+func NewConnector() Connector { return Connector{} }
+
+func (Connector) Server(kubeconfig string) (string, error) {
+	config, err := k8s.RESTConfig([]byte(kubeconfig))
+	if err != nil {
+		return "", err
+	}
+	return config.Host, nil
+}
+
+func (Connector) Check(_ context.Context, kubeconfig string) (domain.Info, error) {
+	config, err := k8s.RESTConfig([]byte(kubeconfig))
+	if err != nil {
+		return domain.Info{}, err
+	}
+	config.Timeout = checkTimeout
+	client, err := k8s.NewForConfig(config)
+	if err != nil {
+		return domain.Info{}, err
+	}
+	version, err := client.GetKubernetesVersion()
+	if err != nil {
+		return domain.Info{}, err
+	}
+	return domain.Info{Server: config.Host, Version: version}, nil
+}
@@ -0,0 +1,6 @@
+package infra
+
+import "gorm.io/gorm"
+
+func Migrate(db *gorm.DB) error  { return db.AutoMigrate(&clusterModel{}) }
+func Rollback(db *gorm.DB) error { return db.Migrator().DropTable("clusters") }
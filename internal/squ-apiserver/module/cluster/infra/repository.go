@@ -0,0 +1,119 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/cluster/domain"
+)
+
+type clusterModel struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Name        string         `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string
+	Server      string
+	Namespace   string `gorm:"type:varchar(63)"`
+	Kubeconfig  string `gorm:"type:text;not null"`
+}
+
+func (clusterModel) TableName() string { return "clusters" }
+
+// Repository stores clusters with their kubeconfigs encrypted by cipher.
+// Only Get decrypts them, for the callers that build a client.
+type Repository struct {
+	db     *gorm.DB
+	cipher domain.Cipher
+}
+
+func NewRepository(db *gorm.DB, cipher domain.Cipher) *Repository {
+	return &Repository{db: db, cipher: cipher}
+}
+
+func (r *Repository) List(ctx context.Context) ([]domain.Cluster, error) {
+	var models []clusterModel
+	if err := r.db.WithContext(ctx).Order("id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.Cluster, 0, len(models))
+	for _, model := range models {
+		result = append(result, toDomain(model))
+	}
+	return result, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id uint) (domain.Cluster, error) {
+	var model clusterModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		return domain.Cluster{}, err
+	}
+	kubeconfig, err := r.cipher.Decrypt(model.Kubeconfig)
+	if err != nil {
+		return domain.Cluster{}, fmt.Errorf("cluster %q: %w", model.Name, err)
+	}
+	cluster := toDomain(model)
+	cluster.Kubeconfig = kubeconfig
+	return cluster, nil
+}
+
+func (r *Repository) Add(ctx context.Context, value *domain.Cluster) error {
+	model, err := r.toModel(*value)
+	if err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	value.ID, value.CreatedAt, value.UpdatedAt = model.ID, model.CreatedAt, model.UpdatedAt
+	return nil
+}
+
+// Update writes every field so that description and namespace can be
+// cleared.
+func (r *Repository) Update(ctx context.Context, value *domain.Cluster) error {
+	model, err := r.toModel(*value)
+	if err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Model(&clusterModel{ID: value.ID}).
+		Select("name", "description", "server", "namespace", "kubeconfig").Updates(&model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&clusterModel{}, id).Error
+}
+
+// CountDeployments reads the deployments table directly; the deployment
+// module owns it but cluster deletion must not leave deployments orphaned.
+func (r *Repository) CountDeployments(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("deployments").
+		Where("cluster_id = ? AND deleted_at IS NULL", id).Count(&count).Error
+	return count, err
+}
+
+func (r *Repository) toModel(v domain.Cluster) (clusterModel, error) {
+	kubeconfig, err := r.cipher.Encrypt(v.Kubeconfig)
+	if err != nil {
+		return clusterModel{}, fmt.Errorf("encrypt kubeconfig of cluster %q: %w", v.Name, err)
+	}
+	return clusterModel{ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+		Description: v.Description, Server: v.Server, Namespace: v.Namespace, Kubeconfig: kubeconfig}, nil
+}
+
+func toDomain(v clusterModel) domain.Cluster {
+	return domain.Cluster{ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+		Description: v.Description, Server: v.Server, Namespace: v.Namespace}
+}
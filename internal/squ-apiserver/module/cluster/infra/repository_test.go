@@ -0,0 +1,62 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/cluster/domain"
+)
+
+// cipherStub prefixes kubeconfigs and refuses values without the prefix.
+type cipherStub struct{}
+
+func (cipherStub) Encrypt(plaintext string) (string, error) { return "enc:" + plaintext, nil }
+func (cipherStub) Decrypt(ciphertext string) (string, error) {
+	value, ok := strings.CutPrefix(ciphertext, "enc:")
+	if !ok {
+		return "", errors.New("malformed ciphertext")
+	}
+	return value, nil
+}
+
+func TestKubeconfigIsEncryptedAndOnlyDecryptedByGet(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repository := NewRepository(db, cipherStub{})
+	prod := domain.Cluster{Name: "prod", Server: "https://prod:6443", Kubeconfig: "apiVersion: v1"}
+	if err := repository.Add(ctx, &prod); err != nil {
+		t.Fatal(err)
+	}
+
+	var model clusterModel
+	if err := db.First(&model, prod.ID).Error; err != nil || model.Kubeconfig != "enc:apiVersion: v1" {
+		t.Fatalf("stored kubeconfig = %q, %v", model.Kubeconfig, err)
+	}
+	values, err := repository.List(ctx)
+	if err != nil || len(values) != 1 || values[0].Kubeconfig != "" {
+		t.Fatalf("List() = %+v, %v", values, err)
+	}
+	value, err := repository.Get(ctx, prod.ID)
+	if err != nil || value.Kubeconfig != "apiVersion: v1" {
+		t.Fatalf("Get() = %+v, %v", value, err)
+	}
+
+	value.Kubeconfig = "apiVersion: v2"
+	if err := repository.Update(ctx, &value); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(&model, prod.ID).Error; err != nil || model.Kubeconfig != "enc:apiVersion: v2" {
+		t.Fatalf("updated kubeconfig = %q, %v", model.Kubeconfig, err)
+	}
+}
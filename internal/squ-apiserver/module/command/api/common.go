@@ -0,0 +1,38 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/command/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/command/application"
+)
+
+func bindRequest[T any](c *gin.Context) (T, bool) {
+	var request T
+	if err := c.ShouldBindJSON(&request); err != nil {
+		zap.L().Warn("failed to bind command request", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return request, false
+	}
+	return request, true
+}
+
+func writeError(c *gin.Context, err error) {
+	code := res.ErrExecuteFailed
+	switch {
+	case errors.Is(err, application.ErrEmptyCommand):
+		code = res.ErrEmptyCommand
+	case errors.Is(err, application.ErrNoTargets):
+		code = res.ErrNoTargets
+	case errors.Is(err, application.ErrTooManyTargets):
+		code = res.ErrTooManyTargets
+	case errors.Is(err, application.ErrInvalidTarget):
+		code = res.ErrInvalidTarget
+	}
+	c.JSON(http.StatusOK, response.Error(code))
+}
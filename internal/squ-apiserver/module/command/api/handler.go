@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/command/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/command/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/command/application"
+	"squirrel-dev/internal/squ-apiserver/module/command/domain"
+)
+
+type Handler struct {
+	service *application.Service
+}
+
+func NewHandler(service *application.Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Execute waits for every server and returns all results at once.
+func (h *Handler) Execute(c *gin.Context) {
+	request, ok := bindRequest[req.Execute](c)
+	if !ok {
+		return
+	}
+	values, err := h.service.Execute(c.Request.Context(), toServiceRequest(c, request), nil)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	result := make([]res.Result, 0, len(values))
+	for _, value := range values {
+		result = append(result, toResultResponse(value))
+	}
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// Stream sends a server-sent "result" event as each server finishes and a
+// final "done" event carrying the number of servers.
+func (h *Handler) Stream(c *gin.Context) {
+	request, ok := bindRequest[req.Execute](c)
+	if !ok {
+		return
+	}
+	started := false
+	values, err := h.service.Execute(c.Request.Context(), toServiceRequest(c, request), func(value domain.Result) {
+		if !started {
+			c.Header("Cache-Control", "no-cache")
+			c.Header("X-Accel-Buffering", "no")
+			started = true
+		}
+		c.SSEvent("result", toResultResponse(value))
+		c.Writer.Flush()
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.SSEvent("done", gin.H{"total": len(values)})
+	c.Writer.Flush()
+}
+
+func toServiceRequest(c *gin.Context, request req.Execute) application.Request {
+	return application.Request{
+		ServerIDs:   request.ServerIDs,
+		Group:       request.Group,
+		Selector:    request.Selector,
+		Command:     request.Command,
+		Concurrency: request.Concurrency,
+		Timeout:     time.Duration(request.Timeout) * time.Second,
+		Username:    c.GetString("username"),
+	}
+}
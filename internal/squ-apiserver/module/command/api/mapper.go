@@ -0,0 +1,20 @@
+package api
+
+import (
+	"squirrel-dev/internal/squ-apiserver/module/command/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/command/domain"
+)
+
+func toResultResponse(value domain.Result) res.Result {
+	return res.Result{
+		ServerID:  value.ServerID,
+		Hostname:  value.Hostname,
+		IPAddress: value.IPAddress,
+		Stdout:    value.Stdout,
+		Stderr:    value.Stderr,
+		ExitCode:  value.ExitCode,
+		Truncated: value.Truncated,
+		Duration:  value.Duration.Milliseconds(),
+		Error:     value.Error,
+	}
+}
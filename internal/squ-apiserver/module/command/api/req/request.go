@@ -0,0 +1,13 @@
+package req
+
+// Execute runs one shell command on many servers, chosen by ID, group or
+// label selector. Timeout is per server in seconds; zero values fall back to
+// the service defaults.
+type Execute struct {
+	ServerIDs   []uint `json:"server_ids"`
+	Group       string `json:"group"`
+	Selector    string `json:"selector"`
+	Command     string `json:"command" binding:"required"`
+	Concurrency int    `json:"concurrency"`
+	Timeout     int    `json:"timeout"`
+}
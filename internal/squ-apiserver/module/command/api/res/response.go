@@ -0,0 +1,13 @@
+package res
+
+type Result struct {
+	ServerID  uint   `json:"server_id"`
+	Hostname  string `json:"hostname"`
+	IPAddress string `json:"ip_address"`
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	ExitCode  int    `json:"exit_code"`
+	Truncated bool   `json:"truncated"`
+	Duration  int64  `json:"duration_ms"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,23 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrInvalidParameter = 62001
+	ErrEmptyCommand     = 62002
+	ErrNoTargets        = 62003
+	ErrTooManyTargets   = 62004
+	ErrInvalidTarget    = 62005
+
+	ErrExecuteFailed = 62021
+)
+
+func RegisterCode() {
+	response.Register(ErrInvalidParameter, "invalid parameter")
+	response.Register(ErrEmptyCommand, "command is empty")
+	response.Register(ErrNoTargets, "no target servers")
+	response.Register(ErrTooManyTargets, "too many target servers")
+	response.Register(ErrInvalidTarget, "invalid command target")
+
+	response.Register(ErrExecuteFailed, "command execution failed")
+}
@@ -0,0 +1,8 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.POST("/command/execute", handler.Execute)
+	group.POST("/command/execute/stream", handler.Stream)
+}
@@ -0,0 +1,11 @@
+package application
+
+import "errors"
+
+var (
+	ErrEmptyCommand   = errors.New("command is empty")
+	ErrNoTargets      = errors.New("no target servers")
+	ErrTooManyTargets = errors.New("too many target servers")
+	ErrServerNotFound = errors.New("server not found")
+	ErrInvalidTarget  = errors.New("invalid command target")
+)
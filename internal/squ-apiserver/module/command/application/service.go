@@ -0,0 +1,167 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/command/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+const (
+	defaultConcurrency = 10
+	maxConcurrency     = 50
+	maxTargets         = 500
+	defaultTimeout     = 30 * time.Second
+	maxTimeout         = 10 * time.Minute
+)
+
+// Request runs Command on every server in ServerIDs, or on the servers
+// matched by Group and Selector when either is set. Concurrency limits how
+// many SSH sessions are open at once and Timeout bounds each server.
+type Request struct {
+	ServerIDs   []uint
+	Group       string
+	Selector    string
+	Command     string
+	Concurrency int
+	Timeout     time.Duration
+	Username    string
+}
+
+type Service struct {
+	runner  domain.Runner
+	servers domain.ServerSelector
+}
+
+func NewService(runner domain.Runner, servers domain.ServerSelector) *Service {
+	return &Service{runner: runner, servers: servers}
+}
+
+// Execute fans the command out and calls emit as soon as each server
+// finishes, so callers can stream results. The returned slice follows the
+// order of the requested server IDs. Failures on a single server are reported
+// in its result and never abort the others.
+func (s *Service) Execute(ctx context.Context, request Request, emit func(domain.Result)) ([]domain.Result, error) {
+	if strings.TrimSpace(request.Command) == "" {
+		return nil, ErrEmptyCommand
+	}
+	ids, err := s.targets(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrNoTargets
+	}
+	if len(ids) > maxTargets {
+		return nil, ErrTooManyTargets
+	}
+	concurrency := clamp(request.Concurrency, defaultConcurrency, maxConcurrency)
+	timeout := request.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if timeout > maxTimeout {
+		timeout = maxTimeout
+	}
+	zap.L().Info("executing ad-hoc command",
+		zap.String("username", request.Username),
+		zap.Int("targets", len(ids)),
+		zap.Int("concurrency", concurrency),
+		zap.Duration("timeout", timeout),
+		zap.String("command", request.Command),
+	)
+
+	results := make([]domain.Result, len(ids))
+	semaphore := make(chan struct{}, concurrency)
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for index, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+				results[index] = s.run(ctx, id, request.Command, timeout)
+			case <-ctx.Done():
+				results[index] = domain.Result{ServerID: id, Output: domain.Output{ExitCode: -1}, Error: ctx.Err().Error()}
+			}
+			if emit != nil {
+				mu.Lock()
+				emit(results[index])
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func (s *Service) run(ctx context.Context, id uint, command string, timeout time.Duration) domain.Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	output, err := s.runner.Run(ctx, id, command)
+	result := domain.Result{ServerID: id, Output: output, Duration: time.Since(start)}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrServerNotFound
+		}
+		result.Error = err.Error()
+		zap.L().Warn("ad-hoc command failed",
+			zap.Uint("server_id", id),
+			zap.Duration("cost", result.Duration),
+			zap.Error(err),
+		)
+	}
+	return result
+}
+
+func (s *Service) targets(ctx context.Context, request Request) ([]uint, error) {
+	ids := uniqueIDs(request.ServerIDs)
+	if request.Group == "" && request.Selector == "" {
+		return ids, nil
+	}
+	ids, err := s.servers.Select(ctx, serverDomain.Target{ServerIDs: ids, Group: request.Group, Selector: request.Selector})
+	if err != nil {
+		zap.L().Warn("failed to resolve command target",
+			zap.String("group", request.Group),
+			zap.String("selector", request.Selector),
+			zap.Error(err),
+		)
+		return nil, ErrInvalidTarget
+	}
+	return ids, nil
+}
+
+func uniqueIDs(values []uint) []uint {
+	seen := make(map[uint]struct{}, len(values))
+	result := make([]uint, 0, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok || value == 0 {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	return result
+}
+
+func clamp(value, fallback, limit int) int {
+	if value <= 0 {
+		return fallback
+	}
+	if value > limit {
+		return limit
+	}
+	return value
+}
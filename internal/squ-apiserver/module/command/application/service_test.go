@@ -0,0 +1,118 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/command/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+type runnerStub struct {
+	mu      sync.Mutex
+	active  int32
+	peak    int32
+	delay   time.Duration
+	outputs map[uint]domain.Output
+	errors  map[uint]error
+}
+
+func (r *runnerStub) Run(ctx context.Context, id uint, _ string) (domain.Output, error) {
+	active := atomic.AddInt32(&r.active, 1)
+	defer atomic.AddInt32(&r.active, -1)
+	r.mu.Lock()
+	if active > r.peak {
+		r.peak = active
+	}
+	r.mu.Unlock()
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return domain.Output{ExitCode: -1}, ctx.Err()
+	}
+	return r.outputs[id], r.errors[id]
+}
+
+type selectorStub struct{}
+
+func (selectorStub) Select(_ context.Context, target serverDomain.Target) ([]uint, error) {
+	if target.Selector == "env=prod" {
+		return []uint{5, 6}, nil
+	}
+	return nil, errors.New("invalid label selector")
+}
+
+func TestExecuteLimitsConcurrencyAndKeepsOrder(t *testing.T) {
+	runner := &runnerStub{
+		delay:   10 * time.Millisecond,
+		outputs: map[uint]domain.Output{1: {Stdout: "one"}, 2: {ExitCode: 3}, 3: {Stdout: "three"}},
+		errors:  map[uint]error{4: gorm.ErrRecordNotFound},
+	}
+	service := NewService(runner, selectorStub{})
+	var emitted []uint
+	results, err := service.Execute(context.Background(), Request{
+		ServerIDs: []uint{1, 2, 3, 4, 2}, Command: "uptime", Concurrency: 2,
+	}, func(result domain.Result) {
+		emitted = append(emitted, result.ServerID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || len(emitted) != 4 {
+		t.Fatalf("results = %d, emitted = %d", len(results), len(emitted))
+	}
+	for index, id := range []uint{1, 2, 3, 4} {
+		if results[index].ServerID != id {
+			t.Fatalf("results[%d].ServerID = %d", index, results[index].ServerID)
+		}
+	}
+	if results[1].ExitCode != 3 || results[1].Error != "" {
+		t.Fatalf("non-zero exit must not be an error: %#v", results[1])
+	}
+	if results[3].Error != ErrServerNotFound.Error() {
+		t.Fatalf("missing server error = %q", results[3].Error)
+	}
+	if runner.peak > 2 {
+		t.Fatalf("peak concurrency = %d", runner.peak)
+	}
+}
+
+func TestExecuteAppliesPerServerTimeout(t *testing.T) {
+	service := NewService(&runnerStub{delay: time.Second}, selectorStub{})
+	results, err := service.Execute(context.Background(), Request{
+		ServerIDs: []uint{1}, Command: "sleep 60", Timeout: 10 * time.Millisecond,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("error = %q", results[0].Error)
+	}
+}
+
+func TestExecuteValidatesRequest(t *testing.T) {
+	service := NewService(&runnerStub{}, selectorStub{})
+	if _, err := service.Execute(context.Background(), Request{ServerIDs: []uint{1}, Command: " "}, nil); !errors.Is(err, ErrEmptyCommand) {
+		t.Fatalf("error = %v", err)
+	}
+	if _, err := service.Execute(context.Background(), Request{Command: "id"}, nil); !errors.Is(err, ErrNoTargets) {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestExecuteResolvesSelector(t *testing.T) {
+	service := NewService(&runnerStub{}, selectorStub{})
+	results, err := service.Execute(context.Background(), Request{Selector: "env=prod", Command: "id"}, nil)
+	if err != nil || len(results) != 2 || results[0].ServerID != 5 {
+		t.Fatalf("results = %#v, %v", results, err)
+	}
+	if _, err := service.Execute(context.Background(), Request{Selector: "=", Command: "id"}, nil); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("error = %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+// Output is what one server produced for an ad-hoc command. A non-zero exit
+// code is a normal outcome and is not reported as an error by the runner.
+type Output struct {
+	Hostname  string
+	IPAddress string
+	Stdout    string
+	Stderr    string
+	ExitCode  int
+	Truncated bool
+}
+
+type Result struct {
+	ServerID uint
+	Output
+	Duration time.Duration
+	Error    string
+}
+
+type Runner interface {
+	Run(context.Context, uint, string) (Output, error)
+}
+
+type ServerSelector interface {
+	Select(context.Context, serverDomain.Target) ([]uint, error)
+}
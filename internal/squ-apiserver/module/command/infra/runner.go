@@ -0,0 +1,127 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"squirrel-dev/internal/squ-apiserver/module/command/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+)
+
+// maxOutputBytes caps stdout and stderr per server so that a chatty command
+// on a large fleet cannot exhaust apiserver memory.
+const maxOutputBytes = 1 << 20
+
+// killGrace bounds how long Run waits for the session to finish copying
+// output after the command was killed on timeout.
+const killGrace = 2 * time.Second
+
+type Runner struct {
+	servers serverDomain.Repository
+}
+
+func NewRunner(servers serverDomain.Repository) *Runner {
+	return &Runner{servers: servers}
+}
+
+// Select resolves target to server IDs; it satisfies domain.ServerSelector.
+func (r *Runner) Select(ctx context.Context, target serverDomain.Target) ([]uint, error) {
+	servers, err := serverInfra.Select(ctx, r.servers, target)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(servers))
+	for _, server := range servers {
+		ids = append(ids, server.ID)
+	}
+	return ids, nil
+}
+
+func (r *Runner) Run(ctx context.Context, serverID uint, command string) (domain.Output, error) {
+	server, err := r.servers.Get(ctx, serverID)
+	if err != nil {
+		return domain.Output{}, err
+	}
+	output := domain.Output{Hostname: server.Hostname, IPAddress: server.IPAddress, ExitCode: -1}
+	client, err := serverInfra.NewSSHClientContext(ctx, server)
+	if err != nil {
+		return output, fmt.Errorf("ssh connect failed: %w", err)
+	}
+	defer client.Close()
+	session, err := client.Client.NewSession()
+	if err != nil {
+		return output, fmt.Errorf("ssh session failed: %w", err)
+	}
+	defer session.Close()
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxOutputBytes}
+	session.Stdout, session.Stderr = stdout, stderr
+	if err := session.Start(command); err != nil {
+		return output, fmt.Errorf("start command failed: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+	select {
+	case <-ctx.Done():
+		_ = session.Signal(gossh.SIGKILL)
+		client.Close()
+		err = ctx.Err()
+		select {
+		case <-done:
+		case <-time.After(killGrace):
+		}
+	case err = <-done:
+	}
+	output.Stdout, output.Stderr = stdout.String(), stderr.String()
+	output.Truncated = stdout.Truncated() || stderr.Truncated()
+	var exitErr *gossh.ExitError
+	switch {
+	case err == nil:
+		output.ExitCode = 0
+	case errors.As(err, &exitErr):
+		output.ExitCode = exitErr.ExitStatus()
+		err = nil
+	}
+	return output, err
+}
+
+// limitedBuffer is safe for concurrent use: the ssh session keeps copying
+// into it until it is torn down, which may outlast Run on a timeout.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buffer    bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(value []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if remaining := b.limit - b.buffer.Len(); remaining < len(value) {
+		b.truncated = true
+		if remaining > 0 {
+			b.buffer.Write(value[:remaining])
+		}
+		return len(value), nil
+	}
+	return b.buffer.Write(value)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.String()
+}
+
+func (b *limitedBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.truncated
+}
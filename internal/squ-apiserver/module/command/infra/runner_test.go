@@ -0,0 +1,29 @@
+package infra
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLimitedBufferAllowsReadsWhileWriting(t *testing.T) {
+	buffer := &limitedBuffer{limit: 64}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 16 {
+				_, _ = buffer.Write([]byte("output"))
+				_ = buffer.String()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := buffer.String(); len(got) != 64 || strings.Trim(got, "output") != "" {
+		t.Fatalf("expected 64 bytes of output, got %q", got)
+	}
+	if !buffer.Truncated() {
+		t.Fatal("expected the buffer to be marked truncated")
+	}
+}
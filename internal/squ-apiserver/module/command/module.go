@@ -0,0 +1,21 @@
+package command
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/command/api"
+	"squirrel-dev/internal/squ-apiserver/module/command/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/command/application"
+	"squirrel-dev/internal/squ-apiserver/module/command/infra"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+)
+
+func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
+	res.RegisterCode()
+	runner := infra.NewRunner(serverInfra.NewRepository(db, secretInfra.NewCipher(conf)))
+	service := application.NewService(runner, runner)
+	api.RegisterRoutes(group, api.NewHandler(service))
+}
@@ -42,6 +42,17 @@ func pathID(c *gin.Context, field string) (uint, bool) {
 	return id, true
 }
 
+func revisionNumber(c *gin.Context) (uint, bool) {
+	rawNumber := c.Param("revision")
+	number, err := utils.StringToUint(rawNumber)
+	if err != nil {
+		zap.L().Warn("failed to parse deployment revision", zap.String("raw_revision", rawNumber), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidDeploymentConfig))
+		return 0, false
+	}
+	return number, true
+}
+
 func deploymentServerID(c *gin.Context) (uint, bool) {
 	value := c.Query("server_id")
 	if value == "" {
@@ -93,6 +104,67 @@ func writeError(c *gin.Context, err error) {
 		code = res.ErrAgentStopFailed
 	case errors.Is(err, application.ErrAgentStart):
 		code = res.ErrAgentStartFailed
+	case errors.Is(err, application.ErrAgentReconcile):
+		code = res.ErrAgentReconcileFailed
+	case errors.Is(err, application.ErrHostFilesMissing):
+		code = res.ErrHostFilesMissing
+	case errors.Is(err, application.ErrInvalidTarget):
+		code = res.ErrInvalidTarget
+	case errors.Is(err, application.ErrNoTargetServers):
+		code = res.ErrNoTargetServers
+	case errors.Is(err, application.ErrRevisionNotFound):
+		code = res.ErrRevisionNotFound
+	case errors.Is(err, application.ErrInvalidRollout):
+		code = res.ErrInvalidRollout
+	case errors.Is(err, application.ErrRolloutNotFound):
+		code = res.ErrRolloutNotFound
+	case errors.Is(err, application.ErrRolloutInProgress):
+		code = res.ErrRolloutInProgress
+	case errors.Is(err, application.ErrRolloutState):
+		code = res.ErrRolloutState
+	case errors.Is(err, application.ErrOutputNotFound):
+		code = res.ErrOutputNotFound
+	case errors.Is(err, application.ErrInvalidLogOptions):
+		code = res.ErrInvalidLogOptions
+	case errors.Is(err, application.ErrAgentOutput), errors.Is(err, application.ErrAgentState),
+		errors.Is(err, application.ErrAgentFiles):
+		code = res.ErrAgentRequestFailed
+	case errors.Is(err, application.ErrClusterRequired):
+		code = res.ErrClusterRequired
+	case errors.Is(err, application.ErrClusterMissing):
+		code = res.ErrClusterNotFound
+	case errors.Is(err, application.ErrUnsupportedAppType):
+		code = res.ErrUnsupportedAppType
+	case errors.Is(err, application.ErrClusterApply):
+		code = res.ErrClusterApplyFailed
+	case errors.Is(err, application.ErrClusterDelete):
+		code = res.ErrClusterDeleteFailed
+	case errors.Is(err, application.ErrUnsupported):
+		code = res.ErrUnsupportedOperation
+	case errors.Is(err, application.ErrSecretMissing):
+		code = res.ErrSecretNotFound
+	case errors.Is(err, application.ErrSecretDenied):
+		code = res.ErrSecretAccessDenied
+	case errors.Is(err, application.ErrSecretResolve):
+		code = res.ErrSecretResolveFailed
+	case errors.Is(err, application.ErrRegistryMissing):
+		code = res.ErrRegistryNotFound
+	case errors.Is(err, application.ErrRegistryResolve):
+		code = res.ErrRegistryResolveFailed
+	case errors.Is(err, application.ErrBundleMissing):
+		code = res.ErrBundleNotFound
+	case errors.Is(err, application.ErrBundleTransfer):
+		code = res.ErrBundleTransferFailed
+	}
+	result := response.Error(code)
+	var conflicts *application.ConflictError
+	if errors.As(err, &conflicts) {
+		result.Data = toConflicts(conflicts.Conflicts)
+	}
+	// The reason a bundle did not reach the agent is the agent's or the
+	// network's; the user has to act on it.
+	if code == res.ErrBundleTransferFailed {
+		result.Data = err.Error()
 	}
-	c.JSON(http.StatusOK, response.Error(code))
+	c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/application"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// DriftHandler serves the reconcile mode of deployments and the drift
+// events agents report for them.
+type DriftHandler struct {
+	service *application.DriftService
+}
+
+func NewDriftHandler(service *application.DriftService) *DriftHandler {
+	return &DriftHandler{
+		service: service,
+	}
+}
+
+func (h *DriftHandler) Reconcile(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Reconcile](c)
+	if !ok {
+		return
+	}
+	err := h.service.SetReconcile(c.Request.Context(), id, request.Enabled)
+	writeResult(c, "success", err)
+}
+
+func (h *DriftHandler) Events(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	events, err := h.service.DriftEvents(c.Request.Context(), id)
+	writeResult(c, toDriftEvents(events), err)
+}
+
+func (h *DriftHandler) Report(c *gin.Context) {
+	request, ok := bindRequest[req.ReportDrift](c)
+	if !ok {
+		return
+	}
+	err := h.service.ReportDrift(c.Request.Context(), domain.DriftEvent{
+		DeployID: request.DeployID, Observed: request.Observed, Desired: request.Desired,
+		Action: request.Action, Attempt: request.Attempt, Error: request.Error, CreatedAt: request.CreatedAt,
+	})
+	writeResult(c, "success", err)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/application"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// FilesHandler shows how the files on agents differ from the recorded
+// deployments and resolves the differences.
+type FilesHandler struct {
+	service *application.FilesService
+}
+
+func NewFilesHandler(service *application.FilesService) *FilesHandler {
+	return &FilesHandler{
+		service: service,
+	}
+}
+
+func (h *FilesHandler) Files(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	report, err := h.service.Files(c.Request.Context(), id)
+	writeResult(c, toFiles(report), err)
+}
+
+func (h *FilesHandler) Adopt(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	report, err := h.service.Adopt(c.Request.Context(), id, c.GetString("username"))
+	writeResult(c, toFiles(report), err)
+}
+
+func (h *FilesHandler) Push(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	data, err := h.service.Push(c.Request.Context(), id, c.GetString("username"))
+	writeResult(c, data, err)
+}
+
+func (h *FilesHandler) Report(c *gin.Context) {
+	request, ok := bindRequest[req.ReportFiles](c)
+	if !ok {
+		return
+	}
+	err := h.service.ReportFiles(c.Request.Context(), request.DeployID, domain.FileState{
+		ComposeHash: request.ComposeHash, EnvHash: request.EnvHash,
+		PushedComposeHash: request.PushedComposeHash, PushedEnvHash: request.PushedEnvHash,
+	})
+	writeResult(c, "success", err)
+}
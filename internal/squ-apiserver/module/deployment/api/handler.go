@@ -1,11 +1,15 @@
 package api
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	"squirrel-dev/internal/squ-apiserver/module/deployment/api/req"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/application"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type Handler struct {
@@ -40,7 +44,9 @@ func (h *Handler) Update(c *gin.Context) {
 	if !ok {
 		return
 	}
-	data, err := h.service.Update(c.Request.Context(), id, request.Content)
+	data, err := h.service.Update(c.Request.Context(), id, application.Change{
+		Content: request.Content, Env: request.Env, Secrets: request.Secrets, Author: c.GetString("username"),
+	})
 	writeResult(c, data, err)
 }
 
@@ -53,8 +59,23 @@ func (h *Handler) Deploy(c *gin.Context) {
 	if !ok {
 		return
 	}
-	data, err := h.service.Deploy(c.Request.Context(), id, request.ServerID)
-	writeResult(c, data, err)
+	if request.ClusterID != 0 {
+		data, err := h.service.DeployCluster(c.Request.Context(), id, request.ClusterID, request.Namespace, c.GetString("username"))
+		writeResult(c, data, err)
+		return
+	}
+	target := serverDomain.Target{ServerIDs: request.ServerIDs, Group: request.Group, Selector: request.Selector}
+	if target.Empty() {
+		data, err := h.service.Deploy(c.Request.Context(), id, request.ServerID, c.GetString("username"))
+		writeResult(c, data, err)
+		return
+	}
+	values, err := h.service.DeployTarget(c.Request.Context(), id, target, c.GetString("username"))
+	result := make([]res.TargetResult, 0, len(values))
+	for _, value := range values {
+		result = append(result, res.TargetResult{ServerID: value.ServerID, Message: value.Message, Error: value.Error})
+	}
+	writeResult(c, result, err)
 }
 
 func (h *Handler) ListServers(c *gin.Context) {
@@ -102,15 +123,69 @@ func (h *Handler) ReDeploy(c *gin.Context) {
 	if !ok {
 		return
 	}
-	data, err := h.service.ReDeploy(c.Request.Context(), id)
+	data, err := h.service.ReDeploy(c.Request.Context(), id, c.GetString("username"))
 	writeResult(c, data, err)
 }
 
+func (h *Handler) Containers(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	values, err := h.service.Containers(c.Request.Context(), id)
+	now := time.Now()
+	result := make([]res.Container, 0, len(values))
+	for _, value := range values {
+		result = append(result, toContainerResponse(value, now))
+	}
+	writeResult(c, result, err)
+}
+
+func (h *Handler) Revisions(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	values, err := h.service.Revisions(c.Request.Context(), id)
+	result := make([]res.Revision, 0, len(values))
+	for _, value := range values {
+		result = append(result, toRevisionResponse(value, false))
+	}
+	writeResult(c, result, err)
+}
+
+func (h *Handler) Revision(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	number, ok := revisionNumber(c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Revision(c.Request.Context(), id, number)
+	writeResult(c, toRevisionResponse(value, true), err)
+}
+
+func (h *Handler) Rollback(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.RollbackDeployment](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Rollback(c.Request.Context(), id, request.Revision, c.GetString("username"))
+	writeResult(c, toRevisionResponse(value, true), err)
+}
+
 func (h *Handler) ReportStatus(c *gin.Context) {
 	request, ok := bindRequest[req.ReportApplicationStatus](c)
 	if !ok {
 		return
 	}
-	data, err := h.service.ReportStatus(c.Request.Context(), request.DeployID, request.Status)
+	health := domain.Health{Status: request.Health, Message: request.HealthMessage}
+	data, err := h.service.ReportStatus(c.Request.Context(), request.DeployID, request.Status, health, toContainers(request.Containers))
 	writeResult(c, data, err)
 }
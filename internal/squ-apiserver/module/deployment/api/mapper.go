@@ -1,13 +1,16 @@
 package api
 
 import (
+	"time"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/api/req"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/application"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
 )
 
 func toDeploymentResponse(value application.DeploymentView) res.Deployment {
-	return res.Deployment{
+	result := res.Deployment{
 		ID:       value.Deployment.ID,
 		DeployID: value.Deployment.DeployID,
 		Application: res.ApplicationInfo{
@@ -21,7 +24,97 @@ func toDeploymentResponse(value application.DeploymentView) res.Deployment {
 		Status:     value.Deployment.Status,
 		DeployedAt: value.Deployment.CreatedAt.Format("2006-01-02 15:04:05"),
 		Content:    value.Deployment.Content,
+		Secrets:    value.Deployment.Secrets,
+		Reconcile:  value.Deployment.Reconcile,
+	}
+	if health := value.Deployment.Health; health.Status != "" {
+		result.Health = &res.Health{Status: health.Status, Message: health.Message}
+	}
+	if drift := value.Deployment.FileDrift(); drift.Drifted() {
+		result.FileDrift = &res.FileDrift{Compose: drift.Compose, Env: drift.Env}
+	}
+	if value.Deployment.ClusterID != 0 {
+		result.Cluster = &res.ClusterInfo{
+			ID:        value.Cluster.ID,
+			Name:      value.Cluster.Name,
+			Server:    value.Cluster.Server,
+			Namespace: value.Deployment.Namespace,
+			Release:   value.Deployment.Release,
+		}
+		for _, object := range value.Deployment.Objects {
+			apiVersion := object.Version
+			if object.Group != "" {
+				apiVersion = object.Group + "/" + object.Version
+			}
+			result.Objects = append(result.Objects, res.Object{
+				APIVersion: apiVersion, Kind: object.Kind, Namespace: object.Namespace, Name: object.Name,
+			})
+		}
+	}
+	return result
+}
+
+func toFiles(value domain.FileReport) res.Files {
+	return res.Files{
+		Drifted:           value.Drift.Drifted(),
+		Drift:             res.FileDrift{Compose: value.Drift.Compose, Env: value.Drift.Env},
+		ComposeHash:       value.State.ComposeHash,
+		EnvHash:           value.State.EnvHash,
+		PushedComposeHash: value.State.PushedComposeHash,
+		PushedEnvHash:     value.State.PushedEnvHash,
+		CheckedAt:         value.State.CheckedAt.Format("2006-01-02 15:04:05"),
+		Diff:              value.Diff,
+	}
+}
+
+func toRevisionResponse(value domain.Revision, detail bool) res.Revision {
+	result := res.Revision{
+		Number:     value.Number,
+		Reason:     value.Reason,
+		Author:     value.Author,
+		RollbackOf: value.RollbackOf,
+		CreatedAt:  value.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if detail {
+		result.Content, result.Env, result.Secrets, result.Diff = value.Content, value.Env, value.Secrets, value.Diff
 	}
+	return result
+}
+
+func toRolloutResponse(value domain.Rollout) res.Rollout {
+	result := res.Rollout{
+		ID:                   value.ID,
+		ApplicationID:        value.ApplicationID,
+		ServerIDs:            value.Target.ServerIDs,
+		Group:                value.Target.Group,
+		Selector:             value.Target.Selector,
+		BatchSize:            value.BatchSize,
+		Canary:               value.Canary,
+		PauseSeconds:         int64(value.Pause / time.Second),
+		HealthTimeoutSeconds: int64(value.HealthTimeout / time.Second),
+		AutoRollback:         value.AutoRollback,
+		Status:               value.Status,
+		CurrentBatch:         value.CurrentBatch,
+		Batches:              value.Batches(),
+		Author:               value.Author,
+		Error:                value.Error,
+		CreatedAt:            value.CreatedAt.Format("2006-01-02 15:04:05"),
+		Steps:                make([]res.RolloutStep, 0, len(value.Steps)),
+	}
+	if value.FinishedAt != nil {
+		result.FinishedAt = value.FinishedAt.Format("2006-01-02 15:04:05")
+	}
+	for _, step := range value.Steps {
+		result.Steps = append(result.Steps, res.RolloutStep{
+			ServerID:         step.ServerID,
+			Batch:            step.Batch,
+			Status:           step.Status,
+			DeploymentID:     step.DeploymentID,
+			PreviousRevision: step.PreviousRevision,
+			Error:            step.Error,
+		})
+	}
+	return result
 }
 
 func toServerResponse(value domain.Server) res.ServerInfo {
@@ -31,3 +124,98 @@ func toServerResponse(value domain.Server) res.ServerInfo {
 		AgentPort: value.AgentPort,
 	}
 }
+
+func toContainers(values []req.Container) []domain.Container {
+	if values == nil {
+		return nil
+	}
+	result := make([]domain.Container, 0, len(values))
+	for _, value := range values {
+		container := domain.Container{
+			Service:      value.Service,
+			Name:         value.Name,
+			ContainerID:  value.ID,
+			State:        value.State,
+			Health:       value.Health,
+			RestartCount: value.RestartCount,
+			ExitCode:     value.ExitCode,
+			Image:        value.Image,
+			ImageDigest:  value.ImageDigest,
+			StartedAt:    value.StartedAt,
+			FinishedAt:   value.FinishedAt,
+		}
+		for _, port := range value.Ports {
+			container.Ports = append(container.Ports, domain.Port(port))
+		}
+		result = append(result, container)
+	}
+	return result
+}
+
+func toContainerResponse(value domain.Container, now time.Time) res.Container {
+	result := res.Container{
+		Service:       value.Service,
+		Name:          value.Name,
+		ContainerID:   value.ContainerID,
+		State:         value.State,
+		Health:        value.Health,
+		RestartCount:  value.RestartCount,
+		ExitCode:      value.ExitCode,
+		Image:         value.Image,
+		ImageDigest:   value.ImageDigest,
+		Ports:         make([]res.Port, 0, len(value.Ports)),
+		UptimeSeconds: int64(value.Uptime(now) / time.Second),
+		UpdatedAt:     value.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	for _, port := range value.Ports {
+		result.Ports = append(result.Ports, res.Port(port))
+	}
+	if value.StartedAt != nil {
+		result.StartedAt = value.StartedAt.Format(time.RFC3339)
+	}
+	if value.FinishedAt != nil {
+		result.FinishedAt = value.FinishedAt.Format(time.RFC3339)
+	}
+	return result
+}
+
+func toPreviewResponse(value domain.Preview) res.Preview {
+	result := res.Preview{
+		Services:  make([]res.ServiceChange, 0, len(value.Services)),
+		Env:       res.EnvChange(value.Env),
+		Secrets:   res.ListChange(value.Secrets),
+		Conflicts: toConflicts(value.Conflicts),
+		Recreate:  value.Recreate,
+	}
+	for _, service := range value.Services {
+		change := res.ServiceChange{
+			Name: service.Name, Change: service.Change, Recreate: service.Recreate,
+			Ports: res.ListChange(service.Ports), Volumes: res.ListChange(service.Volumes),
+			Environment: res.EnvChange(service.Environment), Other: service.Other,
+		}
+		if service.Image != nil {
+			change.Image = &res.ImageChange{From: service.Image.From, To: service.Image.To}
+		}
+		result.Services = append(result.Services, change)
+	}
+	return result
+}
+
+func toConflicts(values []domain.Conflict) []res.Conflict {
+	result := make([]res.Conflict, 0, len(values))
+	for _, conflict := range values {
+		result = append(result, res.Conflict(conflict))
+	}
+	return result
+}
+
+func toDriftEvents(values []domain.DriftEvent) []res.DriftEvent {
+	result := make([]res.DriftEvent, 0, len(values))
+	for _, value := range values {
+		result = append(result, res.DriftEvent{
+			ID: value.ID, Observed: value.Observed, Desired: value.Desired, Action: value.Action,
+			Attempt: value.Attempt, Error: value.Error, CreatedAt: value.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return result
+}
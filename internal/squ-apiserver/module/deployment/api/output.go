@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/application"
+)
+
+// OutputHandler serves the docker compose output and container logs of
+// deployments. The agent's server-sent events are passed through unchanged.
+type OutputHandler struct {
+	service *application.OutputService
+}
+
+func NewOutputHandler(service *application.OutputService) *OutputHandler {
+	return &OutputHandler{
+		service: service,
+	}
+}
+
+// Stream follows the current or last compose run: a "run" event, one "line"
+// event per output line and a final "end" event.
+func (h *OutputHandler) Stream(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	stream, err := h.service.Stream(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	proxyEvents(c, id, stream)
+}
+
+// Logs streams a deployment's container logs: one "line" event per log line
+// and a final "end" event whose error is set when docker compose failed.
+func (h *OutputHandler) Logs(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	var query req.DeploymentLogs
+	if err := c.ShouldBindQuery(&query); err != nil {
+		zap.L().Warn("failed to bind deployment log query", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidLogOptions))
+		return
+	}
+	stream, err := h.service.Logs(c.Request.Context(), id, application.LogQuery{
+		Services:   query.Service,
+		Tail:       query.Tail,
+		Since:      query.Since,
+		Until:      query.Until,
+		Timestamps: query.Timestamps,
+		Follow:     query.Follow,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	proxyEvents(c, id, stream)
+}
+
+// proxyEvents copies an agent's event stream to the client as it arrives.
+func proxyEvents(c *gin.Context, id uint, stream io.ReadCloser) {
+	defer stream.Close()
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			if _, werr := c.Writer.Write(buf[:n]); werr != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+		if err != nil {
+			if c.Request.Context().Err() == nil {
+				zap.L().Debug("agent event stream ended", zap.Uint("deployment_id", id), zap.Error(err))
+			}
+			return
+		}
+	}
+}
+
+func (h *OutputHandler) Download(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	run, err := h.service.Last(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="deployment-%d-%s.log"`, id, run.Action))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(application.FormatOutput(run)))
+}
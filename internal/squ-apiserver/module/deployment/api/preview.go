@@ -0,0 +1,36 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/application"
+)
+
+// PreviewHandler shows the impact of a deploy before it runs.
+type PreviewHandler struct {
+	service *application.PreviewService
+}
+
+func NewPreviewHandler(service *application.PreviewService) *PreviewHandler {
+	return &PreviewHandler{
+		service: service,
+	}
+}
+
+// Preview takes the body of an update. Omitted fields preview the stored
+// deployment as it would be redeployed now.
+func (h *PreviewHandler) Preview(c *gin.Context) {
+	id, ok := deploymentID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.UpdateDeployment](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Preview(c.Request.Context(), id, application.Change{
+		Content: request.Content, Env: request.Env, Secrets: request.Secrets,
+	})
+	writeResult(c, toPreviewResponse(value), err)
+}
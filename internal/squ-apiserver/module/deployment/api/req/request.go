@@ -1,19 +1,119 @@
 package req
 
+import "time"
+
 // DeployApplication is the request to deploy an application to a server.
+// ServerIDs, Group and Selector deploy to several servers at once and take
+// precedence over ServerID when any of them is set. ClusterID deploys a
+// Kubernetes application to a cluster instead; Namespace overrides the
+// cluster's default namespace.
 type DeployApplication struct {
-	ServerID uint `json:"server_id"`
+	ServerID  uint   `json:"server_id"`
+	ServerIDs []uint `json:"server_ids"`
+	Group     string `json:"group"`
+	Selector  string `json:"selector"`
+	ClusterID uint   `json:"cluster_id"`
+	Namespace string `json:"namespace"`
 }
 
-// UpdateDeployment is the request to update deployment content.
+// UpdateDeployment is the request to update deployment content. Omitted
+// fields keep their current value. Secrets are names of secrets injected into
+// the env under their own name; an empty list removes them all.
 type UpdateDeployment struct {
-	Content string `json:"content"`
+	Content string              `json:"content"`
+	Env     []map[string]string `json:"env"`
+	Secrets []string            `json:"secrets"`
+}
+
+// RollbackDeployment is the request to roll a deployment back to a revision.
+type RollbackDeployment struct {
+	Revision uint `json:"revision"`
+}
+
+// StartRollout is the request to roll an application out to several servers.
+// Servers are picked like DeployApplication; at least one of ServerIDs, Group
+// or Selector must be set.
+type StartRollout struct {
+	ApplicationID        uint   `json:"application_id"`
+	ServerIDs            []uint `json:"server_ids"`
+	Group                string `json:"group"`
+	Selector             string `json:"selector"`
+	BatchSize            int    `json:"batch_size"`
+	Canary               bool   `json:"canary"`
+	PauseSeconds         int    `json:"pause_seconds"`
+	HealthTimeoutSeconds int    `json:"health_timeout_seconds"`
+	AutoRollback         bool   `json:"auto_rollback"`
+}
+
+// DeploymentLogs is the query of a container log request. Service may repeat;
+// Tail is a line count or "all".
+type DeploymentLogs struct {
+	Service    []string `form:"service"`
+	Tail       string   `form:"tail"`
+	Since      string   `form:"since"`
+	Until      string   `form:"until"`
+	Timestamps bool     `form:"timestamps"`
+	Follow     bool     `form:"follow"`
 }
 
 // ReportApplicationStatus is the request used by an agent to report deployment status.
+// Containers is absent or null when the agent did not inspect them.
 type ReportApplicationStatus struct {
-	ApplicationID uint   `json:"application_id"`
-	ServerID      uint   `json:"server_id"`
-	Status        string `json:"status"`
-	DeployID      uint64 `json:"deploy_id"`
+	ApplicationID uint        `json:"application_id"`
+	ServerID      uint        `json:"server_id"`
+	Status        string      `json:"status"`
+	DeployID      uint64      `json:"deploy_id"`
+	Containers    []Container `json:"containers"`
+	Health        string      `json:"health"`
+	HealthMessage string      `json:"health_message"`
+}
+
+// ReportDrift is the request used by an agent to report a corrective action
+// of its reconcile loop.
+type ReportDrift struct {
+	DeployID  uint64    `json:"deploy_id"`
+	Observed  string    `json:"observed"`
+	Desired   string    `json:"desired"`
+	Action    string    `json:"action"`
+	Attempt   int       `json:"attempt"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportFiles is the request used by an agent to report the hashes of a
+// deployment's files on disk and as it last wrote them.
+type ReportFiles struct {
+	DeployID          uint64 `json:"deploy_id"`
+	ComposeHash       string `json:"compose_hash"`
+	EnvHash           string `json:"env_hash"`
+	PushedComposeHash string `json:"pushed_compose_hash"`
+	PushedEnvHash     string `json:"pushed_env_hash"`
+}
+
+// Reconcile turns restoring the desired state of a deployment on or off.
+type Reconcile struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Container is one container as inspected by the agent.
+type Container struct {
+	Service      string     `json:"service"`
+	Name         string     `json:"name"`
+	ID           string     `json:"id"`
+	State        string     `json:"state"`
+	Health       string     `json:"health"`
+	RestartCount int        `json:"restart_count"`
+	ExitCode     int        `json:"exit_code"`
+	Image        string     `json:"image"`
+	ImageDigest  string     `json:"image_digest"`
+	Ports        []Port     `json:"ports"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
+
+type Port struct {
+	HostIP        string `json:"host_ip"`
+	HostPort      int    `json:"host_port"`
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol"`
 }
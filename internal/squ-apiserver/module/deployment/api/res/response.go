@@ -16,13 +16,209 @@ type ServerInfo struct {
 	AgentPort int    `json:"agent_port"`
 }
 
-// Deployment describes an application deployment.
+// TargetResult is the outcome of a deployment on one selected server.
+type TargetResult struct {
+	ServerID uint   `json:"server_id"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Revision describes one entry of a deployment's history. Content, Env,
+// Secrets and Diff are only filled in when a single revision is requested.
+// Secrets are names; values are never returned.
+type Revision struct {
+	Number     uint                `json:"revision"`
+	Reason     string              `json:"reason"`
+	Author     string              `json:"author"`
+	RollbackOf uint                `json:"rollback_of,omitempty"`
+	CreatedAt  string              `json:"created_at"`
+	Content    string              `json:"content,omitempty"`
+	Env        []map[string]string `json:"env,omitempty"`
+	Secrets    []string            `json:"secrets,omitempty"`
+	Diff       string              `json:"diff,omitempty"`
+}
+
+// RolloutStep is the progress of a rollout on one server.
+type RolloutStep struct {
+	ServerID         uint   `json:"server_id"`
+	Batch            int    `json:"batch"`
+	Status           string `json:"status"`
+	DeploymentID     uint   `json:"deployment_id,omitempty"`
+	PreviousRevision uint   `json:"previous_revision,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// Rollout describes a multi-server rollout and its progress.
+type Rollout struct {
+	ID                   uint          `json:"id"`
+	ApplicationID        uint          `json:"application_id"`
+	ServerIDs            []uint        `json:"server_ids,omitempty"`
+	Group                string        `json:"group,omitempty"`
+	Selector             string        `json:"selector,omitempty"`
+	BatchSize            int           `json:"batch_size"`
+	Canary               bool          `json:"canary"`
+	PauseSeconds         int64         `json:"pause_seconds"`
+	HealthTimeoutSeconds int64         `json:"health_timeout_seconds"`
+	AutoRollback         bool          `json:"auto_rollback"`
+	Status               string        `json:"status"`
+	CurrentBatch         int           `json:"current_batch"`
+	Batches              int           `json:"batches"`
+	Author               string        `json:"author"`
+	Error                string        `json:"error,omitempty"`
+	CreatedAt            string        `json:"created_at"`
+	FinishedAt           string        `json:"finished_at,omitempty"`
+	Steps                []RolloutStep `json:"steps"`
+}
+
+// ClusterInfo describes the cluster of a Kubernetes deployment. Release is
+// set for helm chart deployments.
+type ClusterInfo struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Server    string `json:"server"`
+	Namespace string `json:"namespace"`
+	Release   string `json:"release,omitempty"`
+}
+
+// Object is a Kubernetes object applied by a deployment.
+type Object struct {
+	APIVersion string `json:"api_version"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// Deployment describes an application deployment. Cluster deployments have
+// Cluster and Objects instead of a server.
 type Deployment struct {
 	ID          uint            `json:"id"`
 	DeployID    uint64          `json:"deploy_id"`
 	Application ApplicationInfo `json:"application"`
 	Server      ServerInfo      `json:"server"`
+	Cluster     *ClusterInfo    `json:"cluster,omitempty"`
+	Objects     []Object        `json:"objects,omitempty"`
 	Status      string          `json:"status"`
 	DeployedAt  string          `json:"deployed_at"`
 	Content     string          `json:"content"`
+	Secrets     []string        `json:"secrets,omitempty"`
+	Health      *Health         `json:"health,omitempty"`
+	Reconcile   bool            `json:"reconcile"`
+	// FileDrift is set when the files on the agent differ from the record.
+	FileDrift *FileDrift `json:"file_drift,omitempty"`
+}
+
+// FileDrift says how the files on the agent differ from the record. Compose
+// is missing, edited or outdated; env is edited.
+type FileDrift struct {
+	Compose string `json:"compose,omitempty"`
+	Env     string `json:"env,omitempty"`
+}
+
+// Files compares the files on the agent with the recorded deployment. Diff
+// is a unified diff from the record to the host; env values appear as
+// digests.
+type Files struct {
+	Drifted           bool      `json:"drifted"`
+	Drift             FileDrift `json:"drift"`
+	ComposeHash       string    `json:"compose_hash"`
+	EnvHash           string    `json:"env_hash"`
+	PushedComposeHash string    `json:"pushed_compose_hash"`
+	PushedEnvHash     string    `json:"pushed_env_hash"`
+	CheckedAt         string    `json:"checked_at"`
+	Diff              string    `json:"diff,omitempty"`
+}
+
+// Health is the result of the health check after the last deploy.
+type Health struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Container is the last reported state of a deployment's container. Uptime is
+// in seconds and only set while the container runs.
+type Container struct {
+	Service       string `json:"service"`
+	Name          string `json:"name"`
+	ContainerID   string `json:"container_id"`
+	State         string `json:"state"`
+	Health        string `json:"health,omitempty"`
+	RestartCount  int    `json:"restart_count"`
+	ExitCode      int    `json:"exit_code"`
+	Image         string `json:"image"`
+	ImageDigest   string `json:"image_digest,omitempty"`
+	Ports         []Port `json:"ports"`
+	StartedAt     string `json:"started_at,omitempty"`
+	FinishedAt    string `json:"finished_at,omitempty"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type Port struct {
+	HostIP        string `json:"host_ip,omitempty"`
+	HostPort      int    `json:"host_port"`
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol"`
+}
+
+// ListChange lists what a preview adds to and removes from a list.
+type ListChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// EnvChange lists env keys by what a preview does to them. Values are never
+// returned.
+type EnvChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+type ImageChange struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// ServiceChange is the impact of a preview on one compose service. Change is
+// added, removed, modified or unchanged.
+type ServiceChange struct {
+	Name        string       `json:"name"`
+	Change      string       `json:"change"`
+	Recreate    bool         `json:"recreate"`
+	Image       *ImageChange `json:"image,omitempty"`
+	Ports       ListChange   `json:"ports"`
+	Volumes     ListChange   `json:"volumes"`
+	Environment EnvChange    `json:"environment"`
+	Other       []string     `json:"other,omitempty"`
+}
+
+// Conflict is a resource the deployment would claim that another deployment
+// or the host already holds. deployment_id is 0 for the host.
+type Conflict struct {
+	DeploymentID uint   `json:"deployment_id"`
+	Application  string `json:"application"`
+	Kind         string `json:"kind"`
+	Resource     string `json:"resource"`
+	Reason       string `json:"reason"`
+}
+
+// Preview is what deploying the previewed content would change on the agent.
+type Preview struct {
+	Services  []ServiceChange `json:"services"`
+	Env       EnvChange       `json:"env"`
+	Secrets   ListChange      `json:"secrets"`
+	Conflicts []Conflict      `json:"conflicts"`
+	Recreate  bool            `json:"recreate"`
+}
+
+// DriftEvent is a corrective action an agent took for a reconciled
+// deployment. Error is empty when the action succeeded.
+type DriftEvent struct {
+	ID        uint   `json:"id"`
+	Observed  string `json:"observed"`
+	Desired   string `json:"desired"`
+	Action    string `json:"action"`
+	Attempt   int    `json:"attempt"`
+	Error     string `json:"error,omitempty"`
+	CreatedAt string `json:"created_at"`
 }
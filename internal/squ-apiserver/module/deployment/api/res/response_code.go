@@ -13,6 +13,15 @@ const (
 	ErrComposePortConflict          = 72008
 	ErrComposeVolumeConflict        = 72009
 	ErrComposeNetworkConflict       = 72010
+	ErrInvalidTarget                = 72011
+	ErrNoTargetServers              = 72012
+	ErrRevisionNotFound             = 72013
+	ErrInvalidRollout               = 72014
+	ErrRolloutNotFound              = 72015
+	ErrRolloutInProgress            = 72016
+	ErrRolloutState                 = 72017
+	ErrOutputNotFound               = 72018
+	ErrInvalidLogOptions            = 72019
 
 	ErrAgentRequestFailed       = 72021
 	ErrAgentResponseParseFailed = 72022
@@ -21,6 +30,24 @@ const (
 	ErrAgentStopFailed          = 72025
 	ErrAgentStartFailed         = 72026
 	ErrAgentOperationFailed     = 72027
+	ErrAgentReconcileFailed     = 72028
+	ErrHostFilesMissing         = 72029
+
+	ErrClusterRequired      = 72041
+	ErrClusterNotFound      = 72042
+	ErrUnsupportedAppType   = 72043
+	ErrClusterApplyFailed   = 72044
+	ErrClusterDeleteFailed  = 72045
+	ErrUnsupportedOperation = 72046
+
+	ErrSecretNotFound      = 72061
+	ErrSecretAccessDenied  = 72062
+	ErrSecretResolveFailed = 72063
+
+	ErrRegistryNotFound      = 72081
+	ErrRegistryResolveFailed = 72082
+	ErrBundleNotFound        = 72083
+	ErrBundleTransferFailed  = 72084
 )
 
 func RegisterCode() {
@@ -34,6 +61,15 @@ func RegisterCode() {
 	response.Register(ErrComposePortConflict, "docker-compose port conflict detected")
 	response.Register(ErrComposeVolumeConflict, "docker-compose volume conflict detected")
 	response.Register(ErrComposeNetworkConflict, "docker-compose network conflict detected")
+	response.Register(ErrInvalidTarget, "invalid deployment target")
+	response.Register(ErrNoTargetServers, "no servers match the deployment target")
+	response.Register(ErrRevisionNotFound, "deployment revision not found")
+	response.Register(ErrInvalidRollout, "invalid rollout options")
+	response.Register(ErrRolloutNotFound, "rollout not found")
+	response.Register(ErrRolloutInProgress, "another rollout of this application is in progress")
+	response.Register(ErrRolloutState, "rollout is not in a state that allows this operation")
+	response.Register(ErrOutputNotFound, "no compose output recorded for this deployment")
+	response.Register(ErrInvalidLogOptions, "invalid log options")
 
 	response.Register(ErrAgentRequestFailed, "failed to send request to agent")
 	response.Register(ErrAgentResponseParseFailed, "failed to parse agent response")
@@ -42,4 +78,20 @@ func RegisterCode() {
 	response.Register(ErrAgentStopFailed, "agent stop application failed")
 	response.Register(ErrAgentStartFailed, "agent start application failed")
 	response.Register(ErrAgentOperationFailed, "agent operation failed")
+	response.Register(ErrAgentReconcileFailed, "agent failed to change the reconcile mode")
+	response.Register(ErrHostFilesMissing, "deployment files are missing on the agent host")
+
+	response.Register(ErrClusterRequired, "kubernetes applications must be deployed to a cluster")
+	response.Register(ErrClusterNotFound, "cluster not found")
+	response.Register(ErrUnsupportedAppType, "application type cannot be deployed to a cluster")
+	response.Register(ErrClusterApplyFailed, "failed to apply manifest to cluster")
+	response.Register(ErrClusterDeleteFailed, "failed to delete objects from cluster")
+	response.Register(ErrUnsupportedOperation, "operation is not supported for cluster deployments")
+	response.Register(ErrSecretNotFound, "deployment references an unknown secret")
+	response.Register(ErrSecretAccessDenied, "not allowed to use a secret of the deployment")
+	response.Register(ErrSecretResolveFailed, "failed to read deployment secrets")
+	response.Register(ErrRegistryNotFound, "application references an unknown registry")
+	response.Register(ErrRegistryResolveFailed, "failed to read the registry of the application")
+	response.Register(ErrBundleNotFound, "application references an unknown image bundle")
+	response.Register(ErrBundleTransferFailed, "failed to send the image bundle of the application")
 }
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/application"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	"squirrel-dev/pkg/utils"
+)
+
+// RolloutHandler serves rollouts. Starting and rolling back return at once;
+// clients poll the rollout for progress.
+type RolloutHandler struct {
+	service *application.RolloutService
+}
+
+func NewRolloutHandler(service *application.RolloutService) *RolloutHandler {
+	return &RolloutHandler{
+		service: service,
+	}
+}
+
+func (h *RolloutHandler) List(c *gin.Context) {
+	var applicationID uint
+	if value := c.Query("application_id"); value != "" {
+		id, err := utils.StringToUint(value)
+		if err != nil {
+			zap.L().Warn("failed to parse rollout application ID", zap.String("raw_application_id", value), zap.Error(err))
+			c.JSON(http.StatusOK, response.Error(res.ErrInvalidRollout))
+			return
+		}
+		applicationID = id
+	}
+	values, err := h.service.List(c.Request.Context(), applicationID)
+	result := make([]res.Rollout, 0, len(values))
+	for _, value := range values {
+		result = append(result, toRolloutResponse(value))
+	}
+	writeResult(c, result, err)
+}
+
+func (h *RolloutHandler) Get(c *gin.Context) {
+	id, ok := pathID(c, "raw_rollout_id")
+	if !ok {
+		return
+	}
+	value, err := h.service.Get(c.Request.Context(), id)
+	writeResult(c, toRolloutResponse(value), err)
+}
+
+func (h *RolloutHandler) Start(c *gin.Context) {
+	request, ok := bindRequest[req.StartRollout](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Start(c.Request.Context(), application.RolloutRequest{
+		ApplicationID: request.ApplicationID,
+		Target:        serverDomain.Target{ServerIDs: request.ServerIDs, Group: request.Group, Selector: request.Selector},
+		BatchSize:     request.BatchSize,
+		Canary:        request.Canary,
+		Pause:         time.Duration(request.PauseSeconds) * time.Second,
+		HealthTimeout: time.Duration(request.HealthTimeoutSeconds) * time.Second,
+		AutoRollback:  request.AutoRollback,
+		Author:        c.GetString("username"),
+	})
+	writeResult(c, toRolloutResponse(value), err)
+}
+
+func (h *RolloutHandler) Stop(c *gin.Context) {
+	id, ok := pathID(c, "raw_rollout_id")
+	if !ok {
+		return
+	}
+	value, err := h.service.Stop(c.Request.Context(), id)
+	writeResult(c, toRolloutResponse(value), err)
+}
+
+func (h *RolloutHandler) Rollback(c *gin.Context) {
+	id, ok := pathID(c, "raw_rollout_id")
+	if !ok {
+		return
+	}
+	value, err := h.service.Rollback(c.Request.Context(), id, c.GetString("username"))
+	writeResult(c, toRolloutResponse(value), err)
+}
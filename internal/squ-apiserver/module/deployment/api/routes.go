@@ -7,12 +7,53 @@ func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
 	group.POST("/deployment/:id", handler.Update)
 	group.POST("/deployment/deploy/:id", handler.Deploy)
 	group.GET("/deployment/:id/servers", handler.ListServers)
+	group.GET("/deployment/:id/containers", handler.Containers)
 	group.DELETE("/deployment/deploy/:id", handler.Undeploy)
 	group.POST("/deployment/stop/:id", handler.Stop)
 	group.POST("/deployment/start/:id", handler.Start)
 	group.POST("/deployment/redeploy/:id", handler.ReDeploy)
+	group.GET("/deployment/:id/revisions", handler.Revisions)
+	group.GET("/deployment/:id/revisions/:revision", handler.Revision)
+	group.POST("/deployment/:id/rollback", handler.Rollback)
+}
+
+func RegisterRolloutRoutes(group *gin.RouterGroup, handler *RolloutHandler) {
+	group.GET("/rollout", handler.List)
+	group.GET("/rollout/:id", handler.Get)
+	group.POST("/rollout", handler.Start)
+	group.POST("/rollout/:id/stop", handler.Stop)
+	group.POST("/rollout/:id/rollback", handler.Rollback)
+}
+
+func RegisterOutputRoutes(group *gin.RouterGroup, handler *OutputHandler) {
+	group.GET("/deployment/:id/output", handler.Stream)
+	group.GET("/deployment/:id/output/download", handler.Download)
+	group.GET("/deployment/:id/logs", handler.Logs)
+}
+
+func RegisterPreviewRoutes(group *gin.RouterGroup, handler *PreviewHandler) {
+	group.POST("/deployment/:id/preview", handler.Preview)
+}
+
+func RegisterDriftRoutes(group *gin.RouterGroup, handler *DriftHandler) {
+	group.POST("/deployment/:id/reconcile", handler.Reconcile)
+	group.GET("/deployment/:id/drift", handler.Events)
+}
+
+func RegisterFilesRoutes(group *gin.RouterGroup, handler *FilesHandler) {
+	group.GET("/deployment/:id/files", handler.Files)
+	group.POST("/deployment/:id/files/adopt", handler.Adopt)
+	group.POST("/deployment/:id/files/push", handler.Push)
 }
 
 func RegisterAgentRoutes(group *gin.RouterGroup, handler *Handler) {
 	group.POST("/deployment/report", handler.ReportStatus)
 }
+
+func RegisterAgentDriftRoutes(group *gin.RouterGroup, handler *DriftHandler) {
+	group.POST("/deployment/report/drift", handler.Report)
+}
+
+func RegisterAgentFilesRoutes(group *gin.RouterGroup, handler *FilesHandler) {
+	group.POST("/deployment/report/files", handler.Report)
+}
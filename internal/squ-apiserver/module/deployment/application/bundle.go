@@ -0,0 +1,36 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// transferBundle sends the image bundle of app to the agent of the
+// deployment and returns its checksum, which the agent loads before compose
+// up. Applications without a bundle pull their images as before.
+func (s *Service) transferBundle(ctx context.Context, app domain.Application, deployment domain.Deployment) (string, error) {
+	if app.BundleID == 0 {
+		return "", nil
+	}
+	checksum, err := s.bundles.Push(ctx, app.BundleID, deployment.ServerID)
+	if err == nil {
+		return checksum, nil
+	}
+	zap.L().Warn("failed to send application bundle",
+		zap.Uint("deployment_id", deployment.ID),
+		zap.Uint64("deploy_id", deployment.DeployID),
+		zap.Uint("application_id", app.ID),
+		zap.Uint("bundle_id", app.BundleID),
+		zap.Uint("server_id", deployment.ServerID),
+		zap.Error(err),
+	)
+	if errors.Is(err, domain.ErrBundleNotFound) {
+		return "", fmt.Errorf("%w: %d", ErrBundleMissing, app.BundleID)
+	}
+	return "", fmt.Errorf("%w: %v", ErrBundleTransfer, err)
+}
@@ -0,0 +1,50 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// bundleStub maps bundles to checksums; an empty checksum fails the transfer.
+type bundleStub map[uint]string
+
+func (b bundleStub) Push(_ context.Context, bundleID, serverID uint) (string, error) {
+	checksum, ok := b[bundleID]
+	if !ok {
+		return "", domain.ErrBundleNotFound
+	}
+	if checksum == "" {
+		return "", errors.New("agent request failed: connection refused")
+	}
+	return checksum, nil
+}
+
+func TestBundleIsSentBeforeDeploy(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 1, ServerID: 2, ApplicationID: 1, DeployID: 99, Content: "services: {}"},
+	}}
+	agent := &agentStub{}
+	apps := applicationStub{1: {ID: 1, Name: "demo", Type: "compose", BundleID: 3}}
+	bundles := bundleStub{3: "8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4"}
+	service := NewService(repository, &revisionStub{}, &containerStub{}, apps, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundles, agent, idStub{})
+
+	if _, err := service.ReDeploy(ctx, 1, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if request := agent.requests[0].(AgentApplication); request.Bundle != bundles[3] {
+		t.Fatalf("bundle = %q, want %q", request.Bundle, bundles[3])
+	}
+
+	bundles[3] = ""
+	if _, err := service.ReDeploy(ctx, 1, "alice"); !errors.Is(err, ErrBundleTransfer) || len(agent.requests) != 1 {
+		t.Fatalf("ReDeploy() with a failed transfer error = %v, requests = %d", err, len(agent.requests))
+	}
+	delete(bundles, 3)
+	if _, err := service.ReDeploy(ctx, 1, "alice"); !errors.Is(err, ErrBundleMissing) || len(agent.requests) != 1 {
+		t.Fatalf("ReDeploy() without the bundle error = %v, requests = %d", err, len(agent.requests))
+	}
+}
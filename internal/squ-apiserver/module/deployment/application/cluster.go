@@ -0,0 +1,352 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// ClusterStatusInterval is how often the status of cluster deployments is
+// refreshed from workload readiness.
+const ClusterStatusInterval = 15 * time.Second
+
+// DeployCluster applies a k8s_manifest application, or installs a helm_chart
+// application, to a registered cluster. An empty namespace falls back to the
+// cluster's default namespace. A manifest that was only partly applied is
+// still recorded, as failed, so that the applied objects can be fixed by a
+// redeploy or removed by an undeploy.
+func (s *Service) DeployCluster(ctx context.Context, applicationID, clusterID uint, namespace, author string) (string, error) {
+	app, err := s.applications.Get(ctx, applicationID)
+	if err != nil {
+		zap.L().Error("failed to get application for cluster deployment",
+			zap.Uint("application_id", applicationID),
+			zap.Uint("cluster_id", clusterID),
+			zap.Error(err),
+		)
+		return "", ErrApplicationMissing
+	}
+	if app.Type != domain.AppTypeK8sManifest && app.Type != domain.AppTypeHelmChart {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAppType, app.Type)
+	}
+	cluster, err := s.clusters.Cluster(ctx, clusterID)
+	if err != nil {
+		zap.L().Error("failed to get cluster for deployment",
+			zap.Uint("application_id", applicationID),
+			zap.Uint("cluster_id", clusterID),
+			zap.Error(err),
+		)
+		return "", ErrClusterMissing
+	}
+	if namespace == "" {
+		namespace = cluster.Namespace
+	}
+	if namespace != "" {
+		if problems := validation.IsDNS1123Label(namespace); len(problems) > 0 {
+			return "", fmt.Errorf("%w: namespace %q: %s", ErrInvalidConfig, namespace, strings.Join(problems, "; "))
+		}
+	}
+	deployments, err := s.repository.List(ctx, 0)
+	if err != nil {
+		zap.L().Error("failed to list deployments for cluster deployment",
+			zap.Uint("application_id", applicationID),
+			zap.Uint("cluster_id", clusterID),
+			zap.Error(err),
+		)
+		return "", repositoryError(err)
+	}
+	for _, deployment := range deployments {
+		if deployment.ClusterID == clusterID && deployment.ApplicationID == applicationID &&
+			deployment.Namespace == namespace {
+			return "", ErrAlreadyDeployed
+		}
+	}
+	deployID, err := s.ids.Generate()
+	if err != nil {
+		zap.L().Error("failed to generate deployment ID",
+			zap.Uint("application_id", applicationID),
+			zap.Uint("cluster_id", clusterID),
+			zap.Error(err),
+		)
+		return "", ErrIDGeneration
+	}
+	deployment := domain.Deployment{
+		ClusterID: clusterID, Namespace: namespace, ApplicationID: applicationID, Content: app.Content,
+		DeployID: deployID, Status: domain.StatusStarting,
+	}
+	if app.Type == domain.AppTypeHelmChart {
+		deployment.Release = domain.ReleaseName(app.Name, deployID)
+	}
+	applyErr := s.applyCluster(ctx, &deployment, cluster)
+	if applyErr != nil && len(deployment.Objects) == 0 {
+		return "", applyErr
+	}
+	if applyErr != nil {
+		deployment.Status = domain.StatusFailed
+	}
+	if err := s.repository.Add(ctx, &deployment); err != nil {
+		zap.L().Error("failed to create cluster deployment record",
+			zap.Uint64("deploy_id", deployID),
+			zap.Uint("application_id", applicationID),
+			zap.Uint("cluster_id", clusterID),
+			zap.Int("objects", len(deployment.Objects)),
+			zap.Error(err),
+		)
+		return "", repositoryError(err)
+	}
+	_, _ = s.record(ctx, deployment, domain.RevisionDeploy, author, 0)
+	if applyErr != nil {
+		return "", applyErr
+	}
+	return "deploy success", nil
+}
+
+// applyCluster applies the content of deployment and prunes the objects of the
+// previous apply that the manifest no longer contains. On failure the objects
+// applied so far are kept together with the previous ones, so nothing the
+// deployment created is forgotten.
+func (s *Service) applyCluster(ctx context.Context, deployment *domain.Deployment, cluster domain.Cluster) error {
+	if deployment.Release != "" {
+		return s.installChart(ctx, deployment, cluster)
+	}
+	previous := deployment.Objects
+	objects, err := s.clusters.Apply(ctx, cluster, deployment.Content, deployment.Namespace)
+	if err != nil {
+		zap.L().Error("failed to apply manifest to cluster",
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Uint("cluster_id", cluster.ID),
+			zap.String("namespace", deployment.Namespace),
+			zap.Int("applied", len(objects)),
+			zap.Error(err),
+		)
+		deployment.Objects = append(objects, domain.Prune(previous, objects)...)
+		return fmt.Errorf("%w: %v", ErrClusterApply, err)
+	}
+	deployment.Objects = objects
+	if pruned := domain.Prune(previous, objects); len(pruned) > 0 {
+		if err := s.clusters.Delete(ctx, cluster, pruned); err != nil {
+			// The new manifest is live; leftovers are reported but do not
+			// fail the deploy.
+			zap.L().Warn("failed to prune objects removed from manifest",
+				zap.Uint64("deploy_id", deployment.DeployID),
+				zap.Uint("cluster_id", cluster.ID),
+				zap.Int("objects", len(pruned)),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+// redeployCluster re-applies content to the cluster of deployment and stores
+// the result. The status goes back to starting until the workloads are ready.
+func (s *Service) redeployCluster(ctx context.Context, deployment *domain.Deployment) error {
+	cluster, err := s.clusters.Cluster(ctx, deployment.ClusterID)
+	if err != nil {
+		zap.L().Error("failed to get cluster of deployment",
+			zap.Uint("deployment_id", deployment.ID),
+			zap.Uint("cluster_id", deployment.ClusterID),
+			zap.Error(err),
+		)
+		return ErrClusterMissing
+	}
+	applyErr := s.applyCluster(ctx, deployment, cluster)
+	status := domain.StatusStarting
+	if applyErr != nil {
+		status = domain.StatusFailed
+	}
+	if err := s.repository.Update(ctx, deployment); err != nil {
+		zap.L().Error("failed to store applied cluster objects",
+			zap.Uint("deployment_id", deployment.ID),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Error(err),
+		)
+		return repositoryError(err)
+	}
+	if err := s.repository.UpdateStatus(ctx, deployment.DeployID, status); err != nil {
+		zap.L().Warn("failed to reset cluster deployment status",
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Error(err),
+		)
+	}
+	return applyErr
+}
+
+// installChart installs or upgrades the release of deployment. Helm prunes
+// the objects a new chart version drops itself. A failed upgrade is rolled
+// back to the previous release; a failed first install is uninstalled, so
+// that no half-installed release is left without a deployment record.
+func (s *Service) installChart(ctx context.Context, deployment *domain.Deployment, cluster domain.Cluster) error {
+	chart, err := domain.ParseChart(deployment.Content)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	release := chartRelease(*deployment, chart)
+	objects, err := s.clusters.InstallChart(ctx, cluster, release)
+	if err == nil {
+		deployment.Objects = objects
+		return nil
+	}
+	// A chart that was refused never reached helm, so there is nothing to
+	// undo.
+	if errors.Is(err, domain.ErrInvalidChart) {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	zap.L().Error("failed to install helm chart",
+		zap.Uint64("deploy_id", deployment.DeployID),
+		zap.Uint("cluster_id", cluster.ID),
+		zap.String("release", release.Name),
+		zap.String("namespace", deployment.Namespace),
+		zap.Error(err),
+	)
+	undo, action := s.clusters.RollbackChart, "roll back"
+	if deployment.ID == 0 {
+		undo, action = s.clusters.UninstallChart, "uninstall"
+	}
+	if undoErr := undo(ctx, cluster, release); undoErr != nil {
+		zap.L().Error("failed to "+action+" helm release after a failed install",
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.String("release", release.Name),
+			zap.Error(undoErr),
+		)
+	}
+	return fmt.Errorf("%w: %v", ErrClusterApply, err)
+}
+
+func chartRelease(deployment domain.Deployment, chart domain.Chart) domain.Release {
+	return domain.Release{Name: deployment.Release, Namespace: deployment.Namespace, Chart: chart, Set: deployment.Env}
+}
+
+func (s *Service) undeployCluster(ctx context.Context, deployment domain.Deployment) (string, error) {
+	cluster, err := s.clusters.Cluster(ctx, deployment.ClusterID)
+	if err != nil {
+		zap.L().Error("failed to get cluster for undeploy",
+			zap.Uint("deployment_id", deployment.ID),
+			zap.Uint("cluster_id", deployment.ClusterID),
+			zap.Error(err),
+		)
+		return "", ErrClusterMissing
+	}
+	remove := func() error { return s.clusters.Delete(ctx, cluster, deployment.Objects) }
+	if deployment.Release != "" {
+		remove = func() error {
+			return s.clusters.UninstallChart(ctx, cluster, domain.Release{Name: deployment.Release, Namespace: deployment.Namespace})
+		}
+	}
+	if err := remove(); err != nil {
+		zap.L().Error("failed to delete deployment objects from cluster",
+			zap.Uint("deployment_id", deployment.ID),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Uint("cluster_id", deployment.ClusterID),
+			zap.Error(err),
+		)
+		return "", ErrClusterDelete
+	}
+	if err := s.repository.Delete(ctx, deployment.ID); err != nil {
+		zap.L().Error("failed to delete deployment record",
+			zap.Uint("deployment_id", deployment.ID),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Error(err),
+		)
+		return "", repositoryError(err)
+	}
+	return "undeploy success", nil
+}
+
+// clusterDeployment loads a deployment and reports whether it targets a
+// cluster.
+func (s *Service) clusterDeployment(ctx context.Context, id uint) (domain.Deployment, bool, error) {
+	deployment, err := s.repository.Get(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get deployment", zap.Uint("deployment_id", id), zap.Error(err))
+		return domain.Deployment{}, false, repositoryError(err)
+	}
+	return deployment, deployment.ClusterID != 0, nil
+}
+
+// SyncClusterStatus sets the status of every cluster deployment from the
+// readiness of its workloads. Deployments whose cluster cannot be reached
+// keep their status.
+func (s *Service) SyncClusterStatus(ctx context.Context) {
+	deployments, err := s.repository.List(ctx, 0)
+	if err != nil {
+		zap.L().Error("failed to list deployments for cluster status", zap.Error(err))
+		return
+	}
+	clusters := map[uint]domain.Cluster{}
+	for _, deployment := range deployments {
+		if deployment.ClusterID == 0 {
+			continue
+		}
+		cluster, ok := clusters[deployment.ClusterID]
+		if !ok {
+			cluster, err = s.clusters.Cluster(ctx, deployment.ClusterID)
+			if err != nil {
+				zap.L().Warn("failed to get cluster for status",
+					zap.Uint("cluster_id", deployment.ClusterID),
+					zap.Error(err),
+				)
+				continue
+			}
+			clusters[deployment.ClusterID] = cluster
+		}
+		readiness, err := s.clusters.Readiness(ctx, cluster, deployment.Objects)
+		if err != nil {
+			zap.L().Warn("failed to check cluster deployment readiness",
+				zap.Uint64("deploy_id", deployment.DeployID),
+				zap.Uint("cluster_id", deployment.ClusterID),
+				zap.Error(err),
+			)
+			continue
+		}
+		status := readiness.Status()
+		if deployment.Release != "" {
+			release, err := s.clusters.ChartStatus(ctx, cluster, domain.Release{Name: deployment.Release, Namespace: deployment.Namespace})
+			if err != nil {
+				zap.L().Warn("failed to get helm release status",
+					zap.Uint64("deploy_id", deployment.DeployID),
+					zap.String("release", deployment.Release),
+					zap.Error(err),
+				)
+				continue
+			}
+			status = domain.ReleaseStatus(release, readiness)
+		}
+		if strings.EqualFold(status, deployment.Status) {
+			continue
+		}
+		if err := s.repository.UpdateStatus(ctx, deployment.DeployID, status); err != nil {
+			zap.L().Error("failed to update cluster deployment status",
+				zap.Uint64("deploy_id", deployment.DeployID),
+				zap.String("status", status),
+				zap.Error(err),
+			)
+			continue
+		}
+		zap.L().Info("cluster deployment status updated",
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.String("status", status),
+			zap.String("reason", readiness.Message),
+		)
+	}
+}
+
+// WatchClusterStatus runs SyncClusterStatus every interval until ctx is done.
+func (s *Service) WatchClusterStatus(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.SyncClusterStatus(ctx)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// clusterStub applies a manifest by turning each "kind/name" line into an
+// object, and fails on a line "fail".
+type clusterStub struct {
+	mu         sync.Mutex
+	clusters   map[uint]domain.Cluster
+	applied    []string
+	deleted    [][]domain.Object
+	readiness  domain.Readiness
+	releases   []domain.Release
+	helm       []string
+	installErr error
+	status     string
+}
+
+func (c *clusterStub) Cluster(_ context.Context, id uint) (domain.Cluster, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.clusters[id]
+	if !ok {
+		return domain.Cluster{}, gorm.ErrRecordNotFound
+	}
+	return value, nil
+}
+func (c *clusterStub) Apply(_ context.Context, _ domain.Cluster, manifest, namespace string) ([]domain.Object, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.applied = append(c.applied, manifest)
+	var objects []domain.Object
+	for _, line := range strings.Fields(manifest) {
+		if line == "fail" {
+			return objects, errors.New("admission webhook denied the request")
+		}
+		kind, name, _ := strings.Cut(line, "/")
+		objects = append(objects, domain.Object{Version: "v1", Kind: kind, Namespace: namespace, Name: name})
+	}
+	return objects, nil
+}
+func (c *clusterStub) Readiness(context.Context, domain.Cluster, []domain.Object) (domain.Readiness, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.readiness, nil
+}
+func (c *clusterStub) Delete(_ context.Context, _ domain.Cluster, objects []domain.Object) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleted = append(c.deleted, slices.Clone(objects))
+	return nil
+}
+
+func (c *clusterStub) InstallChart(_ context.Context, _ domain.Cluster, release domain.Release) ([]domain.Object, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.releases = append(c.releases, release)
+	c.helm = append(c.helm, "install "+release.Name)
+	if c.installErr != nil {
+		return nil, c.installErr
+	}
+	return []domain.Object{{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: release.Namespace, Name: release.Name}}, nil
+}
+func (c *clusterStub) RollbackChart(_ context.Context, _ domain.Cluster, release domain.Release) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.helm = append(c.helm, "rollback "+release.Name)
+	return nil
+}
+func (c *clusterStub) UninstallChart(_ context.Context, _ domain.Cluster, release domain.Release) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.helm = append(c.helm, "uninstall "+release.Name)
+	return nil
+}
+func (c *clusterStub) ChartStatus(context.Context, domain.Cluster, domain.Release) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status, nil
+}
+
+func newClusterService(repository *repositoryStub, clusters *clusterStub, agent *agentStub) *Service {
+	apps := applicationStub{
+		1: {ID: 1, Name: "shop", Type: domain.AppTypeK8sManifest, Content: "Deployment/web Service/web"},
+		2: {ID: 2, Name: "demo", Type: domain.AppTypeCompose, Content: "services: {}"},
+	}
+	return NewService(repository, &revisionStub{}, &containerStub{}, apps, serverStub{2: {ID: 2}}, clusters, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{value: 42})
+}
+
+func TestDeployClusterAppliesManifest(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{}
+	clusters := &clusterStub{clusters: map[uint]domain.Cluster{3: {ID: 3, Namespace: "shop"}}}
+	agent := &agentStub{}
+	service := newClusterService(repository, clusters, agent)
+
+	if _, err := service.Deploy(ctx, 1, 2, "admin"); !errors.Is(err, ErrClusterRequired) {
+		t.Fatalf("Deploy() of a manifest to a server error = %v", err)
+	}
+	if _, err := service.DeployCluster(ctx, 2, 3, "", "admin"); !errors.Is(err, ErrUnsupportedAppType) {
+		t.Fatalf("DeployCluster() of a compose app error = %v", err)
+	}
+	if _, err := service.DeployCluster(ctx, 1, 9, "", "admin"); !errors.Is(err, ErrClusterMissing) {
+		t.Fatalf("DeployCluster() to a missing cluster error = %v", err)
+	}
+	if _, err := service.DeployCluster(ctx, 1, 3, "Bad_NS", "admin"); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("DeployCluster() to an invalid namespace error = %v", err)
+	}
+
+	if _, err := service.DeployCluster(ctx, 1, 3, "", "admin"); err != nil {
+		t.Fatalf("DeployCluster() error = %v", err)
+	}
+	deployment := repository.deployments[0]
+	if deployment.ClusterID != 3 || deployment.Namespace != "shop" || deployment.DeployID != 42 ||
+		deployment.Status != domain.StatusStarting || len(deployment.Objects) != 2 {
+		t.Fatalf("deployment = %+v", deployment)
+	}
+	if len(agent.paths) != 0 {
+		t.Fatalf("agent was called: %v", agent.paths)
+	}
+	if _, err := service.DeployCluster(ctx, 1, 3, "shop", "admin"); !errors.Is(err, ErrAlreadyDeployed) {
+		t.Fatalf("second DeployCluster() error = %v", err)
+	}
+}
+
+func TestClusterRedeployPrunesRemovedObjects(t *testing.T) {
+	ctx := context.Background()
+	web := domain.Object{Version: "v1", Kind: "Deployment", Namespace: "shop", Name: "web"}
+	cache := domain.Object{Version: "v1", Kind: "Deployment", Namespace: "shop", Name: "cache"}
+	repository := &repositoryStub{deployments: []domain.Deployment{{
+		ID: 1, ClusterID: 3, Namespace: "shop", ApplicationID: 1, DeployID: 42, Status: domain.StatusRunning,
+		Content: "Deployment/web", Objects: []domain.Object{web, cache},
+	}}}
+	clusters := &clusterStub{clusters: map[uint]domain.Cluster{3: {ID: 3}}}
+	service := newClusterService(repository, clusters, &agentStub{})
+
+	if _, err := service.ReDeploy(ctx, 1, "admin"); err != nil {
+		t.Fatalf("ReDeploy() error = %v", err)
+	}
+	if len(clusters.deleted) != 1 || !slices.Equal(clusters.deleted[0], []domain.Object{cache}) {
+		t.Fatalf("deleted = %+v", clusters.deleted)
+	}
+	deployment := repository.deployments[0]
+	if !slices.Equal(deployment.Objects, []domain.Object{web}) || deployment.Status != domain.StatusStarting {
+		t.Fatalf("deployment = %+v", deployment)
+	}
+
+	if _, err := service.Stop(ctx, 1); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err := service.Undeploy(ctx, 1); err != nil {
+		t.Fatalf("Undeploy() error = %v", err)
+	}
+	if len(clusters.deleted) != 2 || !slices.Equal(clusters.deleted[1], []domain.Object{web}) || !repository.deleted {
+		t.Fatalf("deleted = %+v, record deleted = %v", clusters.deleted, repository.deleted)
+	}
+}
+
+func TestClusterApplyFailureKeepsObjects(t *testing.T) {
+	ctx := context.Background()
+	web := domain.Object{Version: "v1", Kind: "Deployment", Namespace: "shop", Name: "web"}
+	repository := &repositoryStub{deployments: []domain.Deployment{{
+		ID: 1, ClusterID: 3, Namespace: "shop", ApplicationID: 1, DeployID: 42,
+		Content: "Deployment/web", Objects: []domain.Object{web},
+	}}}
+	revisions := &revisionStub{revisions: []domain.Revision{
+		{DeploymentID: 1, Number: 1, Content: "Deployment/web"},
+		{DeploymentID: 1, Number: 2, Content: "Service/web fail"},
+	}}
+	clusters := &clusterStub{clusters: map[uint]domain.Cluster{3: {ID: 3}}}
+	apps := applicationStub{1: {ID: 1, Type: domain.AppTypeK8sManifest}}
+	service := NewService(repository, revisions, &containerStub{}, apps, serverStub{}, clusters, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{})
+
+	if _, err := service.Rollback(ctx, 1, 2, "admin"); !errors.Is(err, ErrClusterApply) {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	deployment := repository.deployments[0]
+	want := []domain.Object{{Version: "v1", Kind: "Service", Namespace: "shop", Name: "web"}, web}
+	if !slices.Equal(deployment.Objects, want) || deployment.Status != domain.StatusFailed {
+		t.Fatalf("deployment = %+v", deployment)
+	}
+	if len(clusters.deleted) != 0 || len(revisions.revisions) != 2 {
+		t.Fatalf("deleted = %+v revisions = %+v", clusters.deleted, revisions.revisions)
+	}
+
+	revision, err := service.Rollback(ctx, 1, 1, "admin")
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if revision.Number != 3 || revision.RollbackOf != 1 {
+		t.Fatalf("revision = %+v", revision)
+	}
+	if len(clusters.deleted) != 1 || clusters.deleted[0][0].Kind != "Service" {
+		t.Fatalf("deleted = %+v", clusters.deleted)
+	}
+}
+
+func TestSyncClusterStatus(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 1, ClusterID: 3, DeployID: 42, Status: domain.StatusStarting},
+		{ID: 2, ServerID: 2, DeployID: 43, Status: domain.StatusStarting},
+	}}
+	clusters := &clusterStub{clusters: map[uint]domain.Cluster{3: {ID: 3}}, readiness: domain.Readiness{Ready: true}}
+	service := newClusterService(repository, clusters, &agentStub{})
+
+	service.SyncClusterStatus(ctx)
+	if repository.deployments[0].Status != domain.StatusRunning || repository.deployments[1].Status != domain.StatusStarting {
+		t.Fatalf("deployments = %+v", repository.deployments)
+	}
+	clusters.readiness = domain.Readiness{Failed: true, Message: "progress deadline exceeded"}
+	service.SyncClusterStatus(ctx)
+	if repository.deployments[0].Status != domain.StatusFailed {
+		t.Fatalf("deployments = %+v", repository.deployments)
+	}
+}
+
+func TestDeployChartLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{}
+	clusters := &clusterStub{clusters: map[uint]domain.Cluster{3: {ID: 3, Namespace: "web"}}}
+	apps := applicationStub{1: {ID: 1, Name: "Web", Type: domain.AppTypeHelmChart, Content: "path: /srv/charts/web\nvalues:\n  replicaCount: 2\n"}}
+	service := NewService(repository, &revisionStub{}, &containerStub{}, apps, serverStub{}, clusters, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{value: 42})
+
+	if _, err := service.DeployCluster(ctx, 1, 3, "", "admin"); err != nil {
+		t.Fatalf("DeployCluster() error = %v", err)
+	}
+	deployment := repository.deployments[0]
+	if deployment.Release != "web-16" || len(deployment.Objects) != 1 || deployment.Status != domain.StatusStarting {
+		t.Fatalf("deployment = %+v", deployment)
+	}
+	release := clusters.releases[0]
+	if release.Namespace != "web" || release.Chart.Path != "/srv/charts/web" || release.Chart.Values["replicaCount"] != 2 {
+		t.Fatalf("release = %+v", release)
+	}
+
+	if _, err := service.Update(ctx, 1, Change{Env: []map[string]string{{"image.tag": "1.27"}}}); err != nil {
+		t.Fatal(err)
+	}
+	clusters.installErr = errors.New("UPGRADE FAILED: timed out")
+	if _, err := service.ReDeploy(ctx, 1, "admin"); !errors.Is(err, ErrClusterApply) {
+		t.Fatalf("ReDeploy() error = %v", err)
+	}
+	if last := clusters.releases[len(clusters.releases)-1]; last.Set[0]["image.tag"] != "1.27" {
+		t.Fatalf("release = %+v", last)
+	}
+	if len(repository.deployments[0].Objects) != 1 {
+		t.Fatalf("objects were dropped: %+v", repository.deployments[0])
+	}
+
+	clusters.installErr = nil
+	clusters.status = domain.ReleaseDeployed
+	clusters.readiness = domain.Readiness{Ready: true}
+	service.SyncClusterStatus(ctx)
+	if repository.deployments[0].Status != domain.StatusRunning {
+		t.Fatalf("status = %q", repository.deployments[0].Status)
+	}
+	clusters.status = domain.ReleasePendingUpgrade
+	service.SyncClusterStatus(ctx)
+	if repository.deployments[0].Status != domain.StatusStarting {
+		t.Fatalf("status = %q", repository.deployments[0].Status)
+	}
+
+	if _, err := service.Undeploy(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"install web-16", "install web-16", "rollback web-16", "uninstall web-16"}
+	if !slices.Equal(clusters.helm, want) || len(clusters.deleted) != 0 {
+		t.Fatalf("helm = %v, deleted = %v", clusters.helm, clusters.deleted)
+	}
+}
+
+func TestDeployChartFailureUninstalls(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{}
+	clusters := &clusterStub{clusters: map[uint]domain.Cluster{3: {ID: 3}}, installErr: errors.New("chart not found")}
+	apps := applicationStub{
+		1: {ID: 1, Name: "web", Type: domain.AppTypeHelmChart, Content: "path: /srv/charts/web"},
+		2: {ID: 2, Name: "bad", Type: domain.AppTypeHelmChart, Content: "values: {}"},
+	}
+	service := NewService(repository, &revisionStub{}, &containerStub{}, apps, serverStub{}, clusters, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{value: 42})
+
+	if _, err := service.DeployCluster(ctx, 1, 3, "", "admin"); !errors.Is(err, ErrClusterApply) {
+		t.Fatalf("DeployCluster() error = %v", err)
+	}
+	if len(repository.deployments) != 0 || !slices.Equal(clusters.helm, []string{"install web-16", "uninstall web-16"}) {
+		t.Fatalf("deployments = %+v, helm = %v", repository.deployments, clusters.helm)
+	}
+	if _, err := service.DeployCluster(ctx, 2, 3, "", "admin"); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("DeployCluster() of an invalid chart error = %v", err)
+	}
+}
@@ -1,30 +1,41 @@
 package application
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"slices"
 
-	"gopkg.in/yaml.v3"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
 )
 
-type composeInfo struct {
-	containers map[string]struct{}
-	ports      map[string]struct{}
-	volumes    map[string]struct{}
-	networks   map[string]struct{}
+// ConflictError reports every compose conflict that blocks a deploy. It
+// matches the sentinel of its first conflict's kind.
+type ConflictError struct {
+	Conflicts []domain.Conflict
 }
 
-type composeService struct {
-	ContainerName string   `yaml:"container_name"`
-	Ports         []string `yaml:"ports"`
-	Volumes       []string `yaml:"volumes"`
+func (e *ConflictError) Error() string {
+	message := e.Conflicts[0].Reason
+	if len(e.Conflicts) > 1 {
+		message = fmt.Sprintf("%s (and %d more conflicts)", message, len(e.Conflicts)-1)
+	}
+	return message
 }
 
-type composeConfig struct {
-	Services map[string]composeService `yaml:"services"`
-	Volumes  map[string]any            `yaml:"volumes"`
-	Networks map[string]any            `yaml:"networks"`
+func (e *ConflictError) Unwrap() error {
+	switch e.Conflicts[0].Kind {
+	case domain.ConflictContainer:
+		return ErrContainerConflict
+	case domain.ConflictPort:
+		return ErrPortConflict
+	case domain.ConflictVolume:
+		return ErrVolumeConflict
+	default:
+		return ErrNetworkConflict
+	}
 }
 
 func checkComposeContent(requestContent, deployedContent string) error {
@@ -34,85 +45,100 @@ func checkComposeContent(requestContent, deployedContent string) error {
 	if deployedContent == "" {
 		return nil
 	}
-	request, err := parseCompose(requestContent)
+	request, err := domain.ParseComposeResources(requestContent, nil)
 	if err != nil {
 		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
 	}
-	deployed, err := parseCompose(deployedContent)
+	deployed, err := domain.ParseComposeResources(deployedContent, nil)
 	if err != nil {
 		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
 	}
-	if intersects(request.containers, deployed.containers) {
-		return ErrContainerConflict
-	}
-	if intersects(request.ports, deployed.ports) {
-		return ErrPortConflict
-	}
-	if intersects(request.volumes, deployed.volumes) {
-		return ErrVolumeConflict
-	}
-	if intersects(request.networks, deployed.networks) {
-		return ErrNetworkConflict
+	if conflicts := request.Conflicts(deployed); len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
 	}
 	return nil
 }
 
-func parseCompose(content string) (*composeInfo, error) {
-	var config composeConfig
-	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
-		return nil, fmt.Errorf("failed to parse compose content: %w", err)
-	}
-	info := &composeInfo{
-		containers: map[string]struct{}{}, ports: map[string]struct{}{},
-		volumes: map[string]struct{}{}, networks: map[string]struct{}{},
-	}
-	for _, service := range config.Services {
-		if service.ContainerName != "" {
-			info.containers[service.ContainerName] = struct{}{}
+// composeConflicts checks proposed against the other compose deployments on
+// its server and against the agent's host. Deployments that cannot be read
+// or parsed are skipped, as is the host when the agent cannot report it.
+func (s *Service) composeConflicts(ctx context.Context, proposed domain.Deployment, server domain.Server) ([]domain.Conflict, error) {
+	if proposed.Content == "" {
+		return nil, nil
+	}
+	resources, err := domain.ParseComposeResources(proposed.Content, envMap(proposed.Env))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	others, err := s.repository.List(ctx, proposed.ServerID)
+	if err != nil {
+		zap.L().Error("failed to list existing deployments for conflict check",
+			zap.Uint("application_id", proposed.ApplicationID),
+			zap.Uint("server_id", proposed.ServerID),
+			zap.Error(err),
+		)
+		return nil, repositoryError(err)
+	}
+	var result []domain.Conflict
+	for _, other := range others {
+		if proposed.ID != 0 && other.ID == proposed.ID || other.Content == "" {
+			continue
+		}
+		app, err := s.applications.Get(ctx, other.ApplicationID)
+		if err != nil {
+			zap.L().Warn("failed to get existing application for conflict check",
+				zap.Uint("application_id", other.ApplicationID),
+				zap.Uint("server_id", proposed.ServerID),
+				zap.Uint64("deploy_id", other.DeployID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if app.Type != domain.AppTypeCompose {
+			continue
 		}
-		for _, mapping := range service.Ports {
-			parts := strings.Split(mapping, ":")
-			if len(parts) > 0 {
-				port := parts[0]
-				if index := strings.Index(port, "/"); index != -1 {
-					port = port[:index]
-				}
-				if port != "" {
-					info.ports[port] = struct{}{}
-				}
-			}
+		existing, err := domain.ParseComposeResources(other.Content, envMap(other.Env))
+		if err != nil {
+			continue
 		}
-		for _, volume := range service.Volumes {
-			parts := strings.Split(volume, ":")
-			if len(parts) > 0 {
-				name := parts[0]
-				if name != "" && !strings.HasPrefix(name, "/") && !strings.HasPrefix(name, "./") && !strings.HasPrefix(name, "~") {
-					if index := strings.Index(name, ":"); index != -1 {
-						name = name[:index]
-					}
-					if name != "" {
-						info.volumes[name] = struct{}{}
-					}
-				}
-			}
+		for _, conflict := range resources.Conflicts(existing) {
+			conflict.DeploymentID, conflict.Application = other.ID, app.Name
+			result = append(result, conflict)
 		}
 	}
-	for name := range config.Volumes {
-		info.volumes[name] = struct{}{}
+
+	path := "application/resources"
+	if proposed.DeployID != 0 {
+		path = fmt.Sprintf("%s?exclude=%d", path, proposed.DeployID)
+	}
+	var host domain.HostResources
+	if err := s.agent.Get(ctx, server, path, &host); err != nil {
+		zap.L().Warn("failed to read host resources from agent, skipping host conflict check",
+			zap.Uint("server_id", proposed.ServerID),
+			zap.Error(err),
+		)
+		return result, nil
 	}
-	for name := range config.Networks {
-		info.networks[name] = struct{}{}
+	for _, conflict := range resources.HostConflicts(host) {
+		// Other deployments show up on the host as well.
+		if !slices.ContainsFunc(result, func(known domain.Conflict) bool {
+			return known.Kind == conflict.Kind && known.Resource == conflict.Resource
+		}) {
+			result = append(result, conflict)
+		}
 	}
-	return info, nil
+	return result, nil
 }
 
-func intersects(left, right map[string]struct{}) bool {
-	for value := range left {
-		if _, ok := right[value]; ok {
-			return true
+// envMap flattens deployment env the way it ends up in the env file.
+func envMap(env []map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, item := range env {
+		for key, value := range item {
+			result[key] = value
 		}
 	}
-	return false
+	return result
 }
 
 func repositoryError(err error) error {
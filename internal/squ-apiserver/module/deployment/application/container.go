@@ -0,0 +1,24 @@
+package application
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// Containers returns the containers of a deployment as last reported by its
+// agent.
+func (s *Service) Containers(ctx context.Context, id uint) ([]domain.Container, error) {
+	if _, err := s.repository.Get(ctx, id); err != nil {
+		zap.L().Error("failed to get deployment for containers", zap.Uint("deployment_id", id), zap.Error(err))
+		return nil, repositoryError(err)
+	}
+	values, err := s.containers.ListContainers(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to list deployment containers", zap.Uint("deployment_id", id), zap.Error(err))
+		return nil, repositoryError(err)
+	}
+	return values, nil
+}
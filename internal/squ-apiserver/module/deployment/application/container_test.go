@@ -0,0 +1,60 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+func TestReportStatusStoresContainers(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99, Status: "running"}}}
+	containers := &containerStub{}
+	service := NewService(repository, &revisionStub{}, containers, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{})
+	ctx := context.Background()
+
+	reported := []domain.Container{
+		{Service: "web", State: "running"},
+		{Service: "worker", State: "restarting", RestartCount: 9},
+	}
+	if _, err := service.ReportStatus(ctx, 99, domain.StatusDegraded, domain.Health{}, reported); err != nil {
+		t.Fatal(err)
+	}
+	values, err := service.Containers(ctx, 1)
+	if err != nil || len(values) != 2 || values[1].RestartCount != 9 {
+		t.Fatalf("containers = %#v err = %v", values, err)
+	}
+	if repository.deployments[0].Status != domain.StatusDegraded {
+		t.Fatalf("status = %q", repository.deployments[0].Status)
+	}
+
+	// An agent that could not inspect docker keeps the last known containers.
+	if _, err := service.ReportStatus(ctx, 99, "Failed", domain.Health{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if values, _ := service.Containers(ctx, 1); len(values) != 2 {
+		t.Fatalf("containers were dropped: %#v", values)
+	}
+	if _, err := service.ReportStatus(ctx, 99, "undeploy", domain.Health{}, []domain.Container{}); err != nil {
+		t.Fatal(err)
+	}
+	if values, _ := service.Containers(ctx, 1); len(values) != 0 {
+		t.Fatalf("containers = %#v", values)
+	}
+	if _, err := service.Containers(ctx, 5); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestReportStatusStoresHealth(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99, Status: "starting"}}}
+	service := NewService(repository, &revisionStub{}, &containerStub{}, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{})
+	health := domain.Health{Status: domain.HealthUnhealthy, Message: "tcp 127.0.0.1:5432: connection refused; restored the previous deployment"}
+	if _, err := service.ReportStatus(context.Background(), 99, "running", health, nil); err != nil {
+		t.Fatal(err)
+	}
+	if repository.deployments[0].Health != health {
+		t.Fatalf("health = %+v", repository.deployments[0].Health)
+	}
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// maxDriftEvents bounds the drift events listed for a deployment.
+const maxDriftEvents = 100
+
+// DriftService manages the reconcile mode of compose deployments and keeps
+// the drift events their agents report.
+type DriftService struct {
+	deployments *Service
+	events      domain.DriftRepository
+}
+
+func NewDriftService(deployments *Service, events domain.DriftRepository) *DriftService {
+	return &DriftService{deployments: deployments, events: events}
+}
+
+// SetReconcile turns reconciling on or off on the agent first, so that the
+// stored mode never claims more than the agent does.
+func (s *DriftService) SetReconcile(ctx context.Context, id uint, reconcile bool) error {
+	deployment, server, err := s.deployments.deploymentServer(ctx, id)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("application/reconcile/%d", deployment.DeployID)
+	if err := s.deployments.agent.Post(ctx, server, path, map[string]bool{"enabled": reconcile}); err != nil {
+		zap.L().Error("failed to change reconcile mode on agent",
+			zap.Uint("deployment_id", id),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Bool("reconcile", reconcile),
+			zap.Error(err),
+		)
+		return ErrAgentReconcile
+	}
+	if err := s.events.SetReconcile(ctx, id, reconcile); err != nil {
+		zap.L().Error("failed to store reconcile mode", zap.Uint("deployment_id", id), zap.Error(err))
+		return repositoryError(err)
+	}
+	return nil
+}
+
+// ReportDrift stores a drift event an agent reported for one of its deploy
+// IDs.
+func (s *DriftService) ReportDrift(ctx context.Context, event domain.DriftEvent) error {
+	deployment, err := s.deployments.repository.GetByDeployID(ctx, event.DeployID)
+	if err != nil {
+		zap.L().Warn("drift reported for unknown deployment", zap.Uint64("deploy_id", event.DeployID), zap.Error(err))
+		return repositoryError(err)
+	}
+	event.DeploymentID = deployment.ID
+	if event.Error != "" {
+		zap.L().Warn("agent reported deployment drift",
+			zap.Uint("deployment_id", deployment.ID),
+			zap.Uint64("deploy_id", event.DeployID),
+			zap.String("observed", event.Observed),
+			zap.String("desired", event.Desired),
+			zap.String("action", event.Action),
+			zap.Int("attempt", event.Attempt),
+			zap.String("error", event.Error),
+		)
+	}
+	if err := s.events.AddDriftEvent(ctx, &event); err != nil {
+		zap.L().Error("failed to store drift event", zap.Uint("deployment_id", deployment.ID), zap.Error(err))
+		return repositoryError(err)
+	}
+	return nil
+}
+
+// DriftEvents returns the newest drift events of a deployment.
+func (s *DriftService) DriftEvents(ctx context.Context, id uint) ([]domain.DriftEvent, error) {
+	if _, err := s.deployments.repository.Get(ctx, id); err != nil {
+		return nil, repositoryError(err)
+	}
+	events, err := s.events.ListDriftEvents(ctx, id, maxDriftEvents)
+	if err != nil {
+		zap.L().Error("failed to list drift events", zap.Uint("deployment_id", id), zap.Error(err))
+		return nil, repositoryError(err)
+	}
+	return events, nil
+}
@@ -0,0 +1,64 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type driftStub struct {
+	reconcile map[uint]bool
+	events    []domain.DriftEvent
+}
+
+func (d *driftStub) SetReconcile(_ context.Context, id uint, reconcile bool) error {
+	d.reconcile[id] = reconcile
+	return nil
+}
+func (d *driftStub) AddDriftEvent(_ context.Context, event *domain.DriftEvent) error {
+	d.events = append(d.events, *event)
+	return nil
+}
+func (d *driftStub) ListDriftEvents(_ context.Context, id uint, _ int) ([]domain.DriftEvent, error) {
+	var result []domain.DriftEvent
+	for _, event := range d.events {
+		if event.DeploymentID == id {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func TestDriftService(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99}}}
+	agent := &agentStub{}
+	deployments := NewService(repository, &revisionStub{}, &containerStub{}, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{})
+	events := &driftStub{reconcile: map[uint]bool{}}
+	service := NewDriftService(deployments, events)
+
+	if err := service.SetReconcile(context.Background(), 1, true); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(agent.paths, []string{"application/reconcile/99"}) || !reflect.DeepEqual(agent.requests[0], map[string]bool{"enabled": true}) || !events.reconcile[1] {
+		t.Fatalf("paths = %v, requests = %v, reconcile = %v", agent.paths, agent.requests, events.reconcile)
+	}
+	// The mode is only stored once the agent accepted it.
+	agent.err = errors.New("connection refused")
+	if err := service.SetReconcile(context.Background(), 1, false); !errors.Is(err, ErrAgentReconcile) || !events.reconcile[1] {
+		t.Fatalf("error = %v, reconcile = %v", err, events.reconcile)
+	}
+
+	if err := service.ReportDrift(context.Background(), domain.DriftEvent{DeployID: 99, Action: domain.DriftStart, Attempt: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ReportDrift(context.Background(), domain.DriftEvent{DeployID: 42}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("error = %v, want %v", err, ErrNotFound)
+	}
+	list, err := service.DriftEvents(context.Background(), 1)
+	if err != nil || len(list) != 1 || list[0].DeploymentID != 1 || list[0].Action != domain.DriftStart {
+		t.Fatalf("events = %+v, err = %v", list, err)
+	}
+}
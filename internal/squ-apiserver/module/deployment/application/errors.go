@@ -17,4 +17,35 @@ var (
 	ErrAgentDelete        = errors.New("agent delete failed")
 	ErrAgentStop          = errors.New("agent stop failed")
 	ErrAgentStart         = errors.New("agent start failed")
+	ErrAgentReconcile     = errors.New("agent failed to change the reconcile mode")
+	ErrInvalidTarget      = errors.New("invalid deployment target")
+	ErrNoTargetServers    = errors.New("no servers match the deployment target")
+	ErrRevisionNotFound   = errors.New("deployment revision not found")
+	ErrInvalidRollout     = errors.New("invalid rollout options")
+	ErrRolloutNotFound    = errors.New("rollout not found")
+	ErrRolloutInProgress  = errors.New("another rollout of this application is in progress")
+	ErrRolloutState       = errors.New("rollout is not in a state that allows this operation")
+	ErrOutputNotFound     = errors.New("no compose output recorded for deployment")
+	ErrAgentOutput        = errors.New("failed to read compose output from agent")
+	ErrAgentState         = errors.New("failed to read deployed state from agent")
+	ErrAgentFiles         = errors.New("failed to read deployment files from agent")
+	ErrHostFilesMissing   = errors.New("deployment files are missing on the agent host")
+	ErrInvalidLogOptions  = errors.New("invalid log options")
+	ErrClusterRequired    = errors.New("kubernetes applications must be deployed to a cluster")
+	ErrClusterMissing     = errors.New("cluster not found")
+	ErrUnsupportedAppType = errors.New("application type cannot be deployed to a cluster")
+	ErrClusterApply       = errors.New("failed to apply manifest to cluster")
+	ErrClusterDelete      = errors.New("failed to delete objects from cluster")
+	ErrUnsupported        = errors.New("operation is not supported for cluster deployments")
+	ErrSecretMissing      = errors.New("deployment references an unknown secret")
+	ErrSecretDenied       = errors.New("not allowed to use a secret of the deployment")
+	ErrSecretResolve      = errors.New("failed to read deployment secrets")
+	ErrRegistryMissing    = errors.New("application references an unknown registry")
+	ErrRegistryResolve    = errors.New("failed to read the registry of the application")
+	ErrBundleMissing      = errors.New("application references an unknown image bundle")
+	ErrBundleTransfer     = errors.New("failed to send the image bundle of the application")
+	// ErrDeploymentUnhealthy and ErrHealthTimeout describe failed rollout
+	// steps and are stored on the step rather than returned to callers.
+	ErrDeploymentUnhealthy = errors.New("deployment reported unhealthy")
+	ErrHealthTimeout       = errors.New("timed out waiting for the deployment to report running")
 )
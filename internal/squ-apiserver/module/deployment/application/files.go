@@ -0,0 +1,157 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// hostFiles is the agent's view of the files of a deployment.
+type hostFiles struct {
+	DeployID          uint64            `json:"deploy_id"`
+	Content           string            `json:"content"`
+	EnvDigests        map[string]string `json:"env_digests"`
+	ComposeHash       string            `json:"compose_hash"`
+	EnvHash           string            `json:"env_hash"`
+	PushedComposeHash string            `json:"pushed_compose_hash"`
+	PushedEnvHash     string            `json:"pushed_env_hash"`
+}
+
+// FilesService compares the files agents have for compose deployments with
+// the recorded deployments and resolves differences either way.
+type FilesService struct {
+	deployments *Service
+	agent       domain.AgentReader
+	now         func() time.Time
+}
+
+func NewFilesService(deployments *Service, agent domain.AgentReader) *FilesService {
+	return &FilesService{
+		deployments: deployments,
+		agent:       agent,
+		now:         time.Now,
+	}
+}
+
+// ReportFiles stores the file state an agent reported for one of its deploy
+// IDs.
+func (s *FilesService) ReportFiles(ctx context.Context, deployID uint64, state domain.FileState) error {
+	deployment, err := s.deployments.repository.GetByDeployID(ctx, deployID)
+	if err != nil {
+		zap.L().Warn("files reported for unknown deployment", zap.Uint64("deploy_id", deployID), zap.Error(err))
+		return repositoryError(err)
+	}
+	return s.store(ctx, deployment, state)
+}
+
+// Files compares the files on the agent with the record and returns the
+// difference. The state read from the agent replaces the last report.
+func (s *FilesService) Files(ctx context.Context, id uint) (domain.FileReport, error) {
+	deployment, host, err := s.hostFiles(ctx, id)
+	if err != nil {
+		return domain.FileReport{}, err
+	}
+	if err := s.store(ctx, deployment, host.State); err != nil {
+		return domain.FileReport{}, err
+	}
+	return fileReport(deployment, host), nil
+}
+
+// Adopt makes the compose file on the host the recorded content of the
+// deployment, under a new revision by author. Env edits on the host cannot
+// be adopted because their values never leave it; Push replaces them.
+func (s *FilesService) Adopt(ctx context.Context, id uint, author string) (domain.FileReport, error) {
+	deployment, host, err := s.hostFiles(ctx, id)
+	if err != nil {
+		return domain.FileReport{}, err
+	}
+	if host.State.ComposeHash == "" {
+		return domain.FileReport{}, ErrHostFilesMissing
+	}
+	if host.Content != deployment.Content {
+		if _, err := s.deployments.Update(ctx, id, Change{Content: host.Content, Author: author}); err != nil {
+			return domain.FileReport{}, err
+		}
+		deployment.Content = host.Content
+	}
+	if err := s.store(ctx, deployment, host.State); err != nil {
+		return domain.FileReport{}, err
+	}
+	return fileReport(deployment, host), nil
+}
+
+// Push writes the recorded version to the agent again and brings the
+// deployment up with it.
+func (s *FilesService) Push(ctx context.Context, id uint, author string) (string, error) {
+	return s.deployments.ReDeploy(ctx, id, author)
+}
+
+// hostFiles reads the files of a compose deployment from its agent. A
+// missing compose file is returned as empty files rather than an error, as
+// it is a difference like any other.
+func (s *FilesService) hostFiles(ctx context.Context, id uint) (domain.Deployment, domain.HostFiles, error) {
+	deployment, server, err := s.deployments.deploymentServer(ctx, id)
+	if err != nil {
+		return domain.Deployment{}, domain.HostFiles{}, err
+	}
+	var files hostFiles
+	err = s.agent.Get(ctx, server, fmt.Sprintf("application/files/%d", deployment.DeployID), &files)
+	var agentErr *domain.AgentError
+	switch {
+	case errors.As(err, &agentErr) && agentErr.Code == agentComposeFileMissing:
+		files = hostFiles{PushedComposeHash: deployment.Files.PushedComposeHash, PushedEnvHash: deployment.Files.PushedEnvHash}
+	case errors.As(err, &agentErr) && agentErr.Code == agentRecordNotFound:
+		return domain.Deployment{}, domain.HostFiles{}, ErrApplicationMissing
+	case err != nil:
+		zap.L().Error("failed to read deployment files from agent",
+			zap.Uint("deployment_id", id),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Error(err),
+		)
+		return domain.Deployment{}, domain.HostFiles{}, ErrAgentFiles
+	}
+	return deployment, domain.HostFiles{
+		Content: files.Content, EnvDigests: files.EnvDigests,
+		State: domain.FileState{
+			ComposeHash: files.ComposeHash, EnvHash: files.EnvHash,
+			PushedComposeHash: files.PushedComposeHash, PushedEnvHash: files.PushedEnvHash,
+			CheckedAt: s.now(),
+		},
+	}, nil
+}
+
+func fileReport(deployment domain.Deployment, host domain.HostFiles) domain.FileReport {
+	deployment.Files = host.State
+	report := domain.FileReport{State: host.State, Drift: deployment.FileDrift()}
+	if report.Drift.Drifted() {
+		report.Diff = domain.FilesDiff(deployment, host)
+	}
+	return report
+}
+
+// store saves state and logs when the deployment starts to drift.
+func (s *FilesService) store(ctx context.Context, deployment domain.Deployment, state domain.FileState) error {
+	if state.CheckedAt.IsZero() {
+		state.CheckedAt = s.now()
+	}
+	before := deployment.FileDrift()
+	deployment.Files = state
+	if after := deployment.FileDrift(); after.Drifted() && after != before {
+		zap.L().Warn("deployment files differ from the record",
+			zap.Uint("deployment_id", deployment.ID),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.String("compose", after.Compose),
+			zap.String("env", after.Env),
+		)
+	}
+	if err := s.deployments.repository.UpdateFiles(ctx, deployment.DeployID, state); err != nil {
+		zap.L().Error("failed to store deployment files state", zap.Uint64("deploy_id", deployment.DeployID), zap.Error(err))
+		return repositoryError(err)
+	}
+	return nil
+}
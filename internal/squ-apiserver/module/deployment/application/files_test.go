@@ -0,0 +1,79 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type filesStub struct {
+	files hostFiles
+	err   error
+}
+
+func (f *filesStub) Get(_ context.Context, _ domain.Server, _ string, result any) error {
+	if f.err != nil {
+		return f.err
+	}
+	*result.(*hostFiles) = f.files
+	return nil
+}
+
+func (f *filesStub) Stream(context.Context, domain.Server, string) (io.ReadCloser, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestFilesDriftAndAdopt(t *testing.T) {
+	recorded, edited := "services:\n  web:\n    image: nginx:1.25\n", "services:\n  web:\n    image: nginx:1.27\n"
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99, Content: recorded}}}
+	revisions := &revisionStub{}
+	deployments := NewService(repository, revisions, &containerStub{}, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{})
+	agent := &filesStub{files: hostFiles{Content: edited, ComposeHash: domain.ContentHash(edited), PushedComposeHash: domain.ContentHash(recorded)}}
+	service := NewFilesService(deployments, agent)
+	ctx := context.Background()
+
+	// The agent reports a hand edit.
+	if err := service.ReportFiles(ctx, 99, domain.FileState{ComposeHash: domain.ContentHash(edited), PushedComposeHash: domain.ContentHash(recorded)}); err != nil {
+		t.Fatal(err)
+	}
+	if drift := repository.deployments[0].FileDrift(); drift.Compose != domain.FilesEdited {
+		t.Fatalf("drift = %+v", drift)
+	}
+	if err := service.ReportFiles(ctx, 42, domain.FileState{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("error = %v, want %v", err, ErrNotFound)
+	}
+
+	report, err := service.Files(ctx, 1)
+	if err != nil || report.Drift.Compose != domain.FilesEdited || report.Diff == "" {
+		t.Fatalf("report = %+v, err = %v", report, err)
+	}
+
+	// Adopting makes the host version the record under a new revision.
+	report, err = service.Adopt(ctx, 1, "alice")
+	if err != nil || report.Drift.Drifted() || report.Diff != "" {
+		t.Fatalf("report = %+v, err = %v", report, err)
+	}
+	if repository.deployments[0].Content != edited || repository.deployments[0].FileDrift().Drifted() {
+		t.Fatalf("deployment = %+v", repository.deployments[0])
+	}
+	if latest, _ := revisions.latest(1); latest.Content != edited || latest.Author != "alice" {
+		t.Fatalf("latest revision = %+v", latest)
+	}
+
+	// Without a compose file there is nothing to adopt.
+	agent.err = &domain.AgentError{Code: agentComposeFileMissing}
+	report, err = service.Files(ctx, 1)
+	if err != nil || report.Drift.Compose != domain.FilesMissing {
+		t.Fatalf("report = %+v, err = %v", report, err)
+	}
+	if _, err := service.Adopt(ctx, 1, "alice"); !errors.Is(err, ErrHostFilesMissing) {
+		t.Fatalf("error = %v, want %v", err, ErrHostFilesMissing)
+	}
+	agent.err = errors.New("connection refused")
+	if _, err := service.Files(ctx, 1); !errors.Is(err, ErrAgentFiles) {
+		t.Fatalf("error = %v, want %v", err, ErrAgentFiles)
+	}
+}
@@ -0,0 +1,155 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// Agent error codes that map to errors of their own.
+const (
+	agentOutputNotFound     = 10006
+	agentInvalidLogOptions  = 10007
+	agentComposeFileMissing = 10008
+	agentRecordNotFound     = 50001
+)
+
+// LogQuery selects container logs like docker compose logs. Tail is a line
+// count or "all"; the agent validates every field.
+type LogQuery struct {
+	Services   []string
+	Tail       string
+	Since      string
+	Until      string
+	Timestamps bool
+	Follow     bool
+}
+
+func (q LogQuery) encode() string {
+	values := url.Values{}
+	for _, service := range q.Services {
+		values.Add("service", service)
+	}
+	for key, value := range map[string]string{"tail": q.Tail, "since": q.Since, "until": q.Until} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if q.Timestamps {
+		values.Set("timestamps", "true")
+	}
+	if q.Follow {
+		values.Set("follow", "true")
+	}
+	return values.Encode()
+}
+
+// OutputService reads the docker compose output that agents record for each
+// deployment and the logs of its containers.
+type OutputService struct {
+	deployments *Service
+	agent       domain.AgentReader
+}
+
+func NewOutputService(deployments *Service, agent domain.AgentReader) *OutputService {
+	return &OutputService{
+		deployments: deployments,
+		agent:       agent,
+	}
+}
+
+// Last returns the current or last compose run of a deployment.
+func (s *OutputService) Last(ctx context.Context, id uint) (domain.ComposeRun, error) {
+	deployment, server, err := s.deployments.deploymentServer(ctx, id)
+	if err != nil {
+		return domain.ComposeRun{}, err
+	}
+	var run domain.ComposeRun
+	err = s.agent.Get(ctx, server, fmt.Sprintf("application/output/%d", deployment.DeployID), &run)
+	if err != nil {
+		return domain.ComposeRun{}, outputError(deployment, err)
+	}
+	return run, nil
+}
+
+// Stream opens the agent's server-sent event stream of the current or last
+// compose run. The stream ends when the run finishes or ctx is cancelled.
+func (s *OutputService) Stream(ctx context.Context, id uint) (io.ReadCloser, error) {
+	deployment, server, err := s.deployments.deploymentServer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := s.agent.Stream(ctx, server, fmt.Sprintf("application/output/%d/stream", deployment.DeployID))
+	if err != nil {
+		return nil, outputError(deployment, err)
+	}
+	return stream, nil
+}
+
+// Logs opens the agent's server-sent event stream of the deployment's
+// container logs. With Follow set it stays open until ctx is cancelled.
+func (s *OutputService) Logs(ctx context.Context, id uint, query LogQuery) (io.ReadCloser, error) {
+	deployment, server, err := s.deployments.deploymentServer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("application/logs/%d", deployment.DeployID)
+	if encoded := query.encode(); encoded != "" {
+		path += "?" + encoded
+	}
+	stream, err := s.agent.Stream(ctx, server, path)
+	if err != nil {
+		return nil, outputError(deployment, err)
+	}
+	return stream, nil
+}
+
+func outputError(deployment domain.Deployment, err error) error {
+	var agentErr *domain.AgentError
+	if errors.As(err, &agentErr) {
+		switch agentErr.Code {
+		case agentOutputNotFound:
+			return ErrOutputNotFound
+		case agentInvalidLogOptions:
+			return ErrInvalidLogOptions
+		case agentComposeFileMissing, agentRecordNotFound:
+			return ErrApplicationMissing
+		}
+	}
+	zap.L().Error("failed to read from agent",
+		zap.Uint("deployment_id", deployment.ID),
+		zap.Uint64("deploy_id", deployment.DeployID),
+		zap.Error(err),
+	)
+	return ErrAgentOutput
+}
+
+// FormatOutput renders a compose run as a plain text log: a header of
+// "# key: value" lines followed by one "time stream text" line per output line.
+func FormatOutput(run domain.ComposeRun) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# deploy_id: %d\n", run.DeployID)
+	fmt.Fprintf(&b, "# action: %s\n", run.Action)
+	fmt.Fprintf(&b, "# status: %s\n", run.Status)
+	fmt.Fprintf(&b, "# started_at: %s\n", run.StartedAt)
+	if run.FinishedAt != "" {
+		fmt.Fprintf(&b, "# finished_at: %s\n", run.FinishedAt)
+	}
+	if run.Error != "" {
+		fmt.Fprintf(&b, "# error: %s\n", run.Error)
+	}
+	if run.Truncated {
+		b.WriteString("# truncated: earlier lines were dropped\n")
+	}
+	for _, line := range run.Lines {
+		fmt.Fprintf(&b, "%s %s %s\n", line.Time, line.Stream, line.Text)
+	}
+	return b.String()
+}
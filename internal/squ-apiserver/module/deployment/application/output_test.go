@@ -0,0 +1,117 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type readerStub struct {
+	paths []string
+	run   domain.ComposeRun
+	err   error
+}
+
+func (r *readerStub) Get(_ context.Context, _ domain.Server, path string, result any) error {
+	r.paths = append(r.paths, path)
+	if r.err != nil {
+		return r.err
+	}
+	*result.(*domain.ComposeRun) = r.run
+	return nil
+}
+
+func (r *readerStub) Stream(_ context.Context, _ domain.Server, path string) (io.ReadCloser, error) {
+	r.paths = append(r.paths, path)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return io.NopCloser(strings.NewReader("event: end\ndata: {}\n\n")), nil
+}
+
+func TestOutputLastAndFormat(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99}}}
+	deployments := NewService(repository, &revisionStub{}, &containerStub{}, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{})
+	reader := &readerStub{run: domain.ComposeRun{
+		DeployID:  99,
+		Action:    "up",
+		Status:    "failed",
+		Error:     "exit status 1",
+		StartedAt: "2026-01-02T03:04:05Z",
+		Lines: []domain.ComposeLine{
+			{Time: "2026-01-02T03:04:06Z", Stream: "stderr", Text: "Error: pull access denied for missing"},
+		},
+	}}
+	service := NewOutputService(deployments, reader)
+
+	run, err := service.Last(context.Background(), 1)
+	if err != nil || run.Action != "up" || reader.paths[0] != "application/output/99" {
+		t.Fatalf("run=%#v err=%v paths=%#v", run, err, reader.paths)
+	}
+	text := FormatOutput(run)
+	for _, want := range []string{"# status: failed\n", "# error: exit status 1\n", "2026-01-02T03:04:06Z stderr Error: pull access denied for missing\n"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("formatted output misses %q:\n%s", want, text)
+		}
+	}
+
+	stream, err := service.Stream(context.Background(), 1)
+	if err != nil || reader.paths[1] != "application/output/99/stream" {
+		t.Fatalf("stream err=%v paths=%#v", err, reader.paths)
+	}
+	stream.Close()
+}
+
+func TestOutputErrors(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99}}}
+	deployments := NewService(repository, &revisionStub{}, &containerStub{}, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{})
+
+	service := NewOutputService(deployments, &readerStub{err: &domain.AgentError{Code: agentOutputNotFound}})
+	if _, err := service.Last(context.Background(), 1); !errors.Is(err, ErrOutputNotFound) {
+		t.Fatalf("error = %v, want %v", err, ErrOutputNotFound)
+	}
+	service = NewOutputService(deployments, &readerStub{err: errors.New("connection refused")})
+	if _, err := service.Stream(context.Background(), 1); !errors.Is(err, ErrAgentOutput) {
+		t.Fatalf("error = %v, want %v", err, ErrAgentOutput)
+	}
+	if _, err := service.Last(context.Background(), 5); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestLogsQueryAndErrors(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99}}}
+	deployments := NewService(repository, &revisionStub{}, &containerStub{}, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{})
+	reader := &readerStub{}
+	service := NewOutputService(deployments, reader)
+
+	stream, err := service.Logs(context.Background(), 1, LogQuery{
+		Services: []string{"web", "db"}, Tail: "100", Since: "10m", Timestamps: true, Follow: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+	want := "application/logs/99?follow=true&service=web&service=db&since=10m&tail=100&timestamps=true"
+	if reader.paths[0] != want {
+		t.Fatalf("path = %q, want %q", reader.paths[0], want)
+	}
+	if _, err := service.Logs(context.Background(), 1, LogQuery{}); err != nil || reader.paths[1] != "application/logs/99" {
+		t.Fatalf("err=%v paths=%#v", err, reader.paths)
+	}
+
+	for code, want := range map[int]error{
+		agentInvalidLogOptions:  ErrInvalidLogOptions,
+		agentComposeFileMissing: ErrApplicationMissing,
+		agentRecordNotFound:     ErrApplicationMissing,
+	} {
+		service := NewOutputService(deployments, &readerStub{err: &domain.AgentError{Code: code}})
+		if _, err := service.Logs(context.Background(), 1, LogQuery{}); !errors.Is(err, want) {
+			t.Fatalf("code %d: error = %v, want %v", code, err, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// deployedState is the agent's report of what it last deployed.
+type deployedState struct {
+	DeployID   uint64            `json:"deploy_id"`
+	Status     string            `json:"status"`
+	Content    string            `json:"content"`
+	EnvDigests map[string]string `json:"env_digests"`
+}
+
+// PreviewService shows what a deploy would change before it runs.
+type PreviewService struct {
+	deployments *Service
+	agent       domain.AgentReader
+}
+
+func NewPreviewService(deployments *Service, agent domain.AgentReader) *PreviewService {
+	return &PreviewService{
+		deployments: deployments,
+		agent:       agent,
+	}
+}
+
+// Preview compares change, applied to the stored deployment, with what the
+// agent currently runs. Empty fields of change use the stored values, so an
+// empty change previews the pending result of earlier updates. Nothing is
+// written and the agent is not asked to deploy.
+func (s *PreviewService) Preview(ctx context.Context, id uint, change Change) (domain.Preview, error) {
+	deployment, server, err := s.deployments.deploymentServer(ctx, id)
+	if err != nil {
+		return domain.Preview{}, err
+	}
+	proposed := deployment
+	if change.Content != "" {
+		proposed.Content = change.Content
+	}
+	if change.Env != nil {
+		proposed.Env = change.Env
+	}
+	if change.Secrets != nil {
+		proposed.Secrets = change.Secrets
+	}
+
+	var deployed deployedState
+	err = s.agent.Get(ctx, server, fmt.Sprintf("application/deployed/%d", deployment.DeployID), &deployed)
+	var agentErr *domain.AgentError
+	if errors.As(err, &agentErr) && agentErr.Code == agentRecordNotFound {
+		// The agent lost the deployment; the next deploy creates everything.
+		err = nil
+	}
+	if err != nil {
+		zap.L().Error("failed to read deployed state from agent",
+			zap.Uint("deployment_id", id),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Error(err),
+		)
+		return domain.Preview{}, ErrAgentState
+	}
+	secrets, err := s.deployedSecrets(ctx, deployment)
+	if err != nil {
+		return domain.Preview{}, err
+	}
+
+	preview, err := domain.PreviewCompose(
+		domain.ComposeState{Content: deployed.Content, EnvDigests: deployed.EnvDigests, Secrets: secrets},
+		domain.ComposeState{Content: proposed.Content, EnvDigests: domain.EnvDigests(proposed.Env), Secrets: proposed.Secrets},
+	)
+	if err != nil {
+		return domain.Preview{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	preview.Conflicts, err = s.deployments.composeConflicts(ctx, proposed, server)
+	if err != nil {
+		return domain.Preview{}, err
+	}
+	return preview, nil
+}
+
+// deployedSecrets returns the secret names of the last revision that was
+// pushed to the agent, which does not know them.
+func (s *PreviewService) deployedSecrets(ctx context.Context, deployment domain.Deployment) ([]string, error) {
+	revisions, err := s.deployments.revisions.ListRevisions(ctx, deployment.ID)
+	if err != nil {
+		zap.L().Error("failed to list revisions for preview", zap.Uint("deployment_id", deployment.ID), zap.Error(err))
+		return nil, repositoryError(err)
+	}
+	for _, revision := range revisions {
+		switch revision.Reason {
+		case domain.RevisionCreate, domain.RevisionDeploy, domain.RevisionRollback:
+			return revision.Secrets, nil
+		}
+	}
+	return deployment.Secrets, nil
+}
@@ -0,0 +1,85 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type stateStub struct {
+	state deployedState
+	err   error
+}
+
+func (s stateStub) Get(_ context.Context, _ domain.Server, _ string, result any) error {
+	if s.err != nil {
+		return s.err
+	}
+	*result.(*deployedState) = s.state
+	return nil
+}
+
+func (s stateStub) Stream(context.Context, domain.Server, string) (io.ReadCloser, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestPreview(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 1, ApplicationID: 1, ServerID: 2, DeployID: 99, Content: "services:\n  web:\n    image: nginx:1.25\n"},
+		{ID: 2, ApplicationID: 2, ServerID: 2, DeployID: 98, Content: "services:\n  proxy:\n    image: caddy\n    ports: [\"443:443\"]\n"},
+		{ID: 3, ClusterID: 1},
+	}}
+	revisions := &revisionStub{revisions: []domain.Revision{{DeploymentID: 1, Number: 1, Reason: domain.RevisionDeploy, Secrets: []string{"API_KEY"}}}}
+	applications := applicationStub{
+		1: {ID: 1, Name: "web", Type: domain.AppTypeCompose},
+		2: {ID: 2, Name: "proxy", Type: domain.AppTypeCompose},
+	}
+	agent := &agentStub{}
+	deployments := NewService(repository, revisions, &containerStub{}, applications, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{})
+	service := NewPreviewService(deployments, stateStub{state: deployedState{DeployID: 99, Content: "services:\n  web:\n    image: nginx:1.25\n"}})
+
+	preview, err := service.Preview(context.Background(), 1, Change{
+		Content: "services:\n  web:\n    image: nginx:1.27\n    ports: [\"443:443\"]\n",
+		Secrets: []string{"API_KEY"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Services) != 1 || preview.Services[0].Change != domain.ChangeModified || !preview.Recreate {
+		t.Fatalf("services = %+v", preview.Services)
+	}
+	if len(preview.Secrets.Added) != 0 || len(preview.Secrets.Removed) != 0 {
+		t.Fatalf("secrets = %+v", preview.Secrets)
+	}
+	if len(preview.Conflicts) != 1 || preview.Conflicts[0].DeploymentID != 2 || preview.Conflicts[0].Application != "proxy" {
+		t.Fatalf("conflicts = %+v", preview.Conflicts)
+	}
+	// The deployment's own containers do not conflict with it.
+	if len(agent.reads) != 1 || agent.reads[0] != "application/resources?exclude=99" {
+		t.Fatalf("reads = %v", agent.reads)
+	}
+	if _, err := service.Preview(context.Background(), 1, Change{Content: "services: ["}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("error = %v, want %v", err, ErrInvalidConfig)
+	}
+	if _, err := service.Preview(context.Background(), 3, Change{}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("error = %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestPreviewAgentState(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99, Content: "services:\n  web:\n    image: nginx\n"}}}
+	deployments := NewService(repository, &revisionStub{}, &containerStub{}, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, idStub{})
+
+	service := NewPreviewService(deployments, stateStub{err: &domain.AgentError{Code: agentRecordNotFound}})
+	preview, err := service.Preview(context.Background(), 1, Change{})
+	if err != nil || len(preview.Services) != 1 || preview.Services[0].Change != domain.ChangeAdded {
+		t.Fatalf("preview = %+v, err = %v", preview, err)
+	}
+	service = NewPreviewService(deployments, stateStub{err: errors.New("connection refused")})
+	if _, err := service.Preview(context.Background(), 1, Change{}); !errors.Is(err, ErrAgentState) {
+		t.Fatalf("error = %v, want %v", err, ErrAgentState)
+	}
+}
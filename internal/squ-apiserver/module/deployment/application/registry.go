@@ -0,0 +1,35 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// resolveRegistry reads the credentials of the private registry of app for
+// the agent, which logs in and pulls the images before compose up.
+func (s *Service) resolveRegistry(ctx context.Context, app domain.Application, deployment domain.Deployment) (*AgentRegistry, error) {
+	if app.RegistryID == 0 {
+		return nil, nil
+	}
+	registry, err := s.registries.Get(ctx, app.RegistryID)
+	if err == nil {
+		return &AgentRegistry{Server: registry.Server, Username: registry.Username, Password: registry.Password}, nil
+	}
+	zap.L().Warn("failed to resolve application registry",
+		zap.Uint("deployment_id", deployment.ID),
+		zap.Uint64("deploy_id", deployment.DeployID),
+		zap.Uint("application_id", app.ID),
+		zap.Uint("registry_id", app.RegistryID),
+		zap.Error(err),
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %d", ErrRegistryMissing, app.RegistryID)
+	}
+	return nil, ErrRegistryResolve
+}
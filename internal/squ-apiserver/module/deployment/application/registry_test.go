@@ -0,0 +1,45 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type registryStub map[uint]domain.Registry
+
+func (r registryStub) Get(_ context.Context, id uint) (domain.Registry, error) {
+	registry, ok := r[id]
+	if !ok {
+		return domain.Registry{}, gorm.ErrRecordNotFound
+	}
+	return registry, nil
+}
+
+func TestRegistryCredentialsAreSentOnDeploy(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 1, ServerID: 2, ApplicationID: 1, DeployID: 99, Content: "services: {}"},
+	}}
+	agent := &agentStub{}
+	apps := applicationStub{1: {ID: 1, Name: "demo", Type: "compose", RegistryID: 5}}
+	registries := registryStub{5: {Server: "harbor.example.com", Username: "ci", Password: "secret"}}
+	service := NewService(repository, &revisionStub{}, &containerStub{}, apps, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registries, bundleStub{}, agent, idStub{})
+
+	if _, err := service.ReDeploy(ctx, 1, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	request := agent.requests[0].(AgentApplication)
+	if request.Registry == nil || *request.Registry != (AgentRegistry{Server: "harbor.example.com", Username: "ci", Password: "secret"}) {
+		t.Fatalf("registry = %+v", request.Registry)
+	}
+
+	delete(registries, 5)
+	if _, err := service.ReDeploy(ctx, 1, "alice"); !errors.Is(err, ErrRegistryMissing) || len(agent.requests) != 1 {
+		t.Fatalf("ReDeploy() without the registry error = %v, requests = %d", err, len(agent.requests))
+	}
+}
@@ -0,0 +1,164 @@
+package application
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// Change is an edit of a deployment. Empty Content and nil Env or Secrets
+// keep the current values.
+type Change struct {
+	Content string
+	Env     []map[string]string
+	Secrets []string
+	Author  string
+}
+
+func (s *Service) Revisions(ctx context.Context, id uint) ([]domain.Revision, error) {
+	if _, err := s.repository.Get(ctx, id); err != nil {
+		zap.L().Error("failed to get deployment for revisions", zap.Uint("deployment_id", id), zap.Error(err))
+		return nil, repositoryError(err)
+	}
+	values, err := s.revisions.ListRevisions(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to list deployment revisions", zap.Uint("deployment_id", id), zap.Error(err))
+		return nil, repositoryError(err)
+	}
+	return values, nil
+}
+
+func (s *Service) Revision(ctx context.Context, id, number uint) (domain.Revision, error) {
+	value, err := s.revisions.GetRevision(ctx, id, number)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Revision{}, ErrRevisionNotFound
+	}
+	if err != nil {
+		zap.L().Error("failed to get deployment revision",
+			zap.Uint("deployment_id", id),
+			zap.Uint("revision", number),
+			zap.Error(err),
+		)
+		return domain.Revision{}, repositoryError(err)
+	}
+	return value, nil
+}
+
+// Rollback re-posts the content of an earlier revision to the agent and, once
+// the agent accepted it, makes it the current state under a new revision.
+func (s *Service) Rollback(ctx context.Context, id, number uint, author string) (domain.Revision, error) {
+	if deployment, ok, err := s.clusterDeployment(ctx, id); err != nil {
+		return domain.Revision{}, err
+	} else if ok {
+		return s.rollbackCluster(ctx, deployment, number, author)
+	}
+	deployment, server, err := s.deploymentServer(ctx, id)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+	target, err := s.Revision(ctx, id, number)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+	app, err := s.applications.Get(ctx, deployment.ApplicationID)
+	if err != nil {
+		zap.L().Error("failed to get application for rollback",
+			zap.Uint("deployment_id", id),
+			zap.Uint("application_id", deployment.ApplicationID),
+			zap.Error(err),
+		)
+		return domain.Revision{}, ErrApplicationMissing
+	}
+	deployment.Content, deployment.Env, deployment.Secrets = target.Content, target.Env, target.Secrets
+	request, err := s.agentApplication(ctx, app, deployment, author)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+	if err := s.agent.Post(ctx, server, "application", request); err != nil {
+		zap.L().Error("failed to roll back application on agent",
+			zap.Uint("deployment_id", id),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Uint("revision", number),
+			zap.Error(err),
+		)
+		return domain.Revision{}, ErrAgentDeploy
+	}
+	if err := s.repository.Update(ctx, &deployment); err != nil {
+		zap.L().Error("failed to store rolled back deployment",
+			zap.Uint("deployment_id", id),
+			zap.Uint64("deploy_id", deployment.DeployID),
+			zap.Uint("revision", number),
+			zap.Error(err),
+		)
+		return domain.Revision{}, repositoryError(err)
+	}
+	return s.recordRollback(ctx, deployment, number, author)
+}
+
+// rollbackCluster re-applies the content of an earlier revision to the
+// cluster. Objects of the current manifest that the revision lacks are
+// pruned.
+func (s *Service) rollbackCluster(ctx context.Context, deployment domain.Deployment, number uint, author string) (domain.Revision, error) {
+	target, err := s.Revision(ctx, deployment.ID, number)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+	deployment.Content, deployment.Env, deployment.Secrets = target.Content, target.Env, target.Secrets
+	if err := s.redeployCluster(ctx, &deployment); err != nil {
+		return domain.Revision{}, err
+	}
+	return s.recordRollback(ctx, deployment, number, author)
+}
+
+func (s *Service) recordRollback(ctx context.Context, deployment domain.Deployment, number uint, author string) (domain.Revision, error) {
+	id := deployment.ID
+	revision, err := s.record(ctx, deployment, domain.RevisionRollback, author, number)
+	if err != nil {
+		return domain.Revision{}, repositoryError(err)
+	}
+	zap.L().Info("deployment rolled back",
+		zap.Uint("deployment_id", id),
+		zap.Uint64("deploy_id", deployment.DeployID),
+		zap.Uint("revision", number),
+		zap.Uint("new_revision", revision.Number),
+		zap.String("author", author),
+	)
+	return revision, nil
+}
+
+// ensureHistory snapshots deployments created before revisions existed so
+// that their first edit has something to diff and roll back to.
+func (s *Service) ensureHistory(ctx context.Context, deployment domain.Deployment) error {
+	_, err := s.revisions.LatestRevision(ctx, deployment.ID)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	_, err = s.record(ctx, deployment, domain.RevisionCreate, "", 0)
+	return err
+}
+
+func (s *Service) record(ctx context.Context, deployment domain.Deployment, reason, author string, rollbackOf uint) (domain.Revision, error) {
+	previous, err := s.revisions.LatestRevision(ctx, deployment.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		zap.L().Error("failed to get latest deployment revision", zap.Uint("deployment_id", deployment.ID), zap.Error(err))
+		return domain.Revision{}, err
+	}
+	revision := domain.Revision{
+		DeploymentID: deployment.ID, Number: previous.Number + 1, Reason: reason, Author: author,
+		Content: deployment.Content, Env: deployment.Env, Secrets: deployment.Secrets, RollbackOf: rollbackOf,
+	}
+	revision.Diff = domain.Diff(previous, revision)
+	if err := s.revisions.AddRevision(ctx, &revision); err != nil {
+		zap.L().Error("failed to add deployment revision",
+			zap.Uint("deployment_id", deployment.ID),
+			zap.String("reason", reason),
+			zap.Error(err),
+		)
+		return domain.Revision{}, err
+	}
+	return revision, nil
+}
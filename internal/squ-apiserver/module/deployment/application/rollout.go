@@ -0,0 +1,501 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+const (
+	defaultHealthTimeout = 5 * time.Minute
+	maxHealthTimeout     = time.Hour
+	maxRolloutPause      = time.Hour
+	healthPollInterval   = 2 * time.Second
+)
+
+// RolloutRequest starts a rollout. A zero BatchSize deploys one server at a
+// time and a zero HealthTimeout uses defaultHealthTimeout.
+type RolloutRequest struct {
+	ApplicationID uint
+	Target        serverDomain.Target
+	BatchSize     int
+	Canary        bool
+	Pause         time.Duration
+	HealthTimeout time.Duration
+	AutoRollback  bool
+	Author        string
+}
+
+// RolloutService runs rollouts in the background. Only one rollout per
+// application runs at a time; progress is persisted after every step so it can
+// be queried while the rollout runs.
+type RolloutService struct {
+	deployments *Service
+	rollouts    domain.RolloutRepository
+	poll        time.Duration
+
+	mu      sync.Mutex
+	running map[uint]*rolloutRun
+}
+
+type rolloutRun struct {
+	applicationID uint
+	rollback      bool
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+func NewRolloutService(deployments *Service, rollouts domain.RolloutRepository) *RolloutService {
+	return &RolloutService{
+		deployments: deployments,
+		rollouts:    rollouts,
+		poll:        healthPollInterval,
+		running:     map[uint]*rolloutRun{},
+	}
+}
+
+func (s *RolloutService) Start(ctx context.Context, request RolloutRequest) (domain.Rollout, error) {
+	if request.BatchSize == 0 {
+		request.BatchSize = 1
+	}
+	if request.HealthTimeout == 0 {
+		request.HealthTimeout = defaultHealthTimeout
+	}
+	if request.BatchSize < 0 || request.Pause < 0 || request.Pause > maxRolloutPause ||
+		request.HealthTimeout < 0 || request.HealthTimeout > maxHealthTimeout {
+		return domain.Rollout{}, ErrInvalidRollout
+	}
+	app, err := s.deployments.applications.Get(ctx, request.ApplicationID)
+	if err != nil {
+		zap.L().Error("failed to get application for rollout",
+			zap.Uint("application_id", request.ApplicationID),
+			zap.Error(err),
+		)
+		return domain.Rollout{}, ErrApplicationMissing
+	}
+	servers, err := s.deployments.servers.Select(ctx, request.Target)
+	if err != nil {
+		zap.L().Warn("failed to resolve rollout target",
+			zap.Uint("application_id", request.ApplicationID),
+			zap.Uints("server_ids", request.Target.ServerIDs),
+			zap.String("group", request.Target.Group),
+			zap.String("selector", request.Target.Selector),
+			zap.Error(err),
+		)
+		return domain.Rollout{}, ErrInvalidTarget
+	}
+	if len(servers) == 0 {
+		return domain.Rollout{}, ErrNoTargetServers
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.running {
+		if run.applicationID == request.ApplicationID {
+			return domain.Rollout{}, ErrRolloutInProgress
+		}
+	}
+	rollout := domain.Rollout{
+		ApplicationID: request.ApplicationID, Target: request.Target, BatchSize: request.BatchSize,
+		Canary: request.Canary, Pause: request.Pause, HealthTimeout: request.HealthTimeout,
+		AutoRollback: request.AutoRollback, Status: domain.RolloutRunning, Author: request.Author,
+		Steps: domain.PlanSteps(servers, request.BatchSize, request.Canary),
+	}
+	if err := s.rollouts.AddRollout(ctx, &rollout); err != nil {
+		zap.L().Error("failed to create rollout", zap.Uint("application_id", request.ApplicationID), zap.Error(err))
+		return domain.Rollout{}, repositoryError(err)
+	}
+	progress := s.track(rollout, false)
+	go s.execute(progress, app)
+	zap.L().Info("rollout started",
+		zap.Uint("rollout_id", rollout.ID),
+		zap.Uint("application_id", rollout.ApplicationID),
+		zap.Int("servers", len(rollout.Steps)),
+		zap.Int("batches", rollout.Batches()),
+		zap.String("author", rollout.Author),
+	)
+	return progress.snapshot(), nil
+}
+
+func (s *RolloutService) List(ctx context.Context, applicationID uint) ([]domain.Rollout, error) {
+	values, err := s.rollouts.ListRollouts(ctx, applicationID)
+	if err != nil {
+		zap.L().Error("failed to list rollouts", zap.Uint("application_id", applicationID), zap.Error(err))
+		return nil, repositoryError(err)
+	}
+	for i := range values {
+		values[i] = s.reconcile(ctx, values[i])
+	}
+	return values, nil
+}
+
+func (s *RolloutService) Get(ctx context.Context, id uint) (domain.Rollout, error) {
+	value, err := s.rollouts.GetRollout(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Rollout{}, ErrRolloutNotFound
+	}
+	if err != nil {
+		zap.L().Error("failed to get rollout", zap.Uint("rollout_id", id), zap.Error(err))
+		return domain.Rollout{}, repositoryError(err)
+	}
+	return s.reconcile(ctx, value), nil
+}
+
+// Stop cancels a running rollout and waits for in-flight steps to settle.
+// Servers that were already deployed keep the new version.
+func (s *RolloutService) Stop(ctx context.Context, id uint) (domain.Rollout, error) {
+	s.mu.Lock()
+	run, ok := s.running[id]
+	s.mu.Unlock()
+	if !ok || run.rollback {
+		if _, err := s.Get(ctx, id); err != nil {
+			return domain.Rollout{}, err
+		}
+		return domain.Rollout{}, ErrRolloutState
+	}
+	run.cancel()
+	select {
+	case <-run.done:
+	case <-ctx.Done():
+		return domain.Rollout{}, ctx.Err()
+	}
+	return s.Get(ctx, id)
+}
+
+// Rollback restores every server the rollout touched: upgraded deployments go
+// back to their previous revision and deployments the rollout created are
+// removed. It runs in the background like the rollout itself.
+func (s *RolloutService) Rollback(ctx context.Context, id uint, author string) (domain.Rollout, error) {
+	rollout, err := s.Get(ctx, id)
+	if err != nil {
+		return domain.Rollout{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.running[id]; ok || rollout.Status == domain.RolloutRolledBack {
+		return domain.Rollout{}, ErrRolloutState
+	}
+	for _, run := range s.running {
+		if run.applicationID == rollout.ApplicationID {
+			return domain.Rollout{}, ErrRolloutInProgress
+		}
+	}
+	progress := s.track(rollout, true)
+	progress.update(func(r *domain.Rollout) { r.Status, r.FinishedAt = domain.RolloutRollingBack, nil })
+	go func() {
+		defer s.finish(progress)
+		s.revert(context.Background(), progress, author)
+	}()
+	return progress.snapshot(), nil
+}
+
+// track registers a rollout as running. The caller holds s.mu.
+func (s *RolloutService) track(rollout domain.Rollout, rollback bool) *rolloutProgress {
+	ctx, cancel := context.WithCancel(context.Background())
+	rollout.Steps = slices.Clone(rollout.Steps)
+	s.running[rollout.ID] = &rolloutRun{
+		applicationID: rollout.ApplicationID, rollback: rollback, cancel: cancel, done: make(chan struct{}),
+	}
+	return &rolloutProgress{ctx: ctx, rollout: rollout, rollouts: s.rollouts}
+}
+
+func (s *RolloutService) finish(progress *rolloutProgress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := progress.snapshot().ID
+	if run, ok := s.running[id]; ok {
+		run.cancel()
+		close(run.done)
+		delete(s.running, id)
+	}
+}
+
+// reconcile marks rollouts that were active when the apiserver last exited.
+func (s *RolloutService) reconcile(ctx context.Context, rollout domain.Rollout) domain.Rollout {
+	if !rollout.Active() {
+		return rollout
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.running[rollout.ID]; ok {
+		return rollout
+	}
+	// The rollout may have finished between the read and taking the lock.
+	if latest, err := s.rollouts.GetRollout(ctx, rollout.ID); err == nil && !latest.Active() {
+		return latest
+	}
+	now := time.Now()
+	rollout.Status, rollout.FinishedAt = domain.RolloutInterrupted, &now
+	rollout.Error = "apiserver stopped while the rollout was running"
+	if err := s.rollouts.UpdateRollout(ctx, &rollout); err != nil {
+		zap.L().Warn("failed to mark rollout interrupted", zap.Uint("rollout_id", rollout.ID), zap.Error(err))
+	}
+	return rollout
+}
+
+func (s *RolloutService) execute(progress *rolloutProgress, app domain.Application) {
+	defer s.finish(progress)
+	ctx := progress.ctx
+	rollout := progress.snapshot()
+	for batch := 0; batch < rollout.Batches(); batch++ {
+		if batch > 0 && rollout.Pause > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(rollout.Pause):
+			}
+		}
+		if ctx.Err() != nil {
+			progress.end(domain.RolloutStopped, "stopped by user")
+			return
+		}
+		progress.update(func(r *domain.Rollout) { r.CurrentBatch = batch })
+		var wg sync.WaitGroup
+		for i, step := range rollout.Steps {
+			if step.Batch == batch {
+				wg.Go(func() { s.runStep(ctx, progress, i, app, rollout) })
+			}
+		}
+		wg.Wait()
+
+		current := progress.snapshot()
+		for _, step := range current.Steps {
+			if step.Status == domain.StepFailed {
+				message := fmt.Sprintf("server %d: %s", step.ServerID, step.Error)
+				zap.L().Warn("rollout stopped after failed step",
+					zap.Uint("rollout_id", rollout.ID),
+					zap.Uint("server_id", step.ServerID),
+					zap.Int("batch", batch),
+					zap.String("error", step.Error),
+				)
+				progress.end(domain.RolloutFailed, message)
+				if rollout.AutoRollback {
+					progress.update(func(r *domain.Rollout) { r.Status, r.FinishedAt = domain.RolloutRollingBack, nil })
+					s.revert(context.Background(), progress, rollout.Author)
+				}
+				return
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		progress.end(domain.RolloutStopped, "stopped by user")
+		return
+	}
+	progress.end(domain.RolloutSucceeded, "")
+	zap.L().Info("rollout succeeded", zap.Uint("rollout_id", rollout.ID), zap.Uint("application_id", rollout.ApplicationID))
+}
+
+func (s *RolloutService) runStep(ctx context.Context, progress *rolloutProgress, index int, app domain.Application, rollout domain.Rollout) {
+	serverID := rollout.Steps[index].ServerID
+	progress.update(func(r *domain.Rollout) { r.Steps[index].Status = domain.StepDeploying })
+	deployment, previous, err := s.deploy(ctx, app, serverID, rollout.Author)
+	progress.update(func(r *domain.Rollout) {
+		r.Steps[index].DeploymentID, r.Steps[index].PreviousRevision = deployment.ID, previous
+		r.Steps[index].Status = domain.StepVerifying
+		if err != nil {
+			r.Steps[index].Status, r.Steps[index].Error = domain.StepFailed, err.Error()
+		}
+	})
+	if err != nil {
+		return
+	}
+	err = s.waitHealthy(ctx, deployment.DeployID, app.HealthCheck != nil, rollout.HealthTimeout)
+	progress.update(func(r *domain.Rollout) {
+		switch {
+		case err == nil:
+			r.Steps[index].Status = domain.StepSucceeded
+		case ctx.Err() != nil:
+			r.Steps[index].Status = domain.StepStopped
+		default:
+			r.Steps[index].Status, r.Steps[index].Error = domain.StepFailed, err.Error()
+		}
+	})
+}
+
+// deploy creates the deployment on a server or upgrades the existing one to
+// the application's current content. The returned revision is the one to
+// restore on rollback and is set even when the upgrade fails half way.
+func (s *RolloutService) deploy(ctx context.Context, app domain.Application, serverID uint, author string) (domain.Deployment, uint, error) {
+	existing, found, err := s.find(ctx, app.ID, serverID)
+	if err != nil {
+		return domain.Deployment{}, 0, err
+	}
+	if !found {
+		if _, err := s.deployments.Deploy(ctx, app.ID, serverID, author); err != nil {
+			return domain.Deployment{}, 0, err
+		}
+		created, found, err := s.find(ctx, app.ID, serverID)
+		if err == nil && !found {
+			err = ErrNotFound
+		}
+		return created, 0, err
+	}
+	if err := s.deployments.ensureHistory(ctx, existing); err != nil {
+		return existing, 0, repositoryError(err)
+	}
+	latest, err := s.deployments.revisions.LatestRevision(ctx, existing.ID)
+	if err != nil {
+		return existing, 0, repositoryError(err)
+	}
+	if _, err := s.deployments.Update(ctx, existing.ID, Change{Content: app.Content, Author: author}); err != nil {
+		return existing, latest.Number, err
+	}
+	if err := s.deployments.repository.UpdateStatus(ctx, existing.DeployID, domain.StatusStarting); err != nil {
+		return existing, latest.Number, repositoryError(err)
+	}
+	// The health of the last deploy must not pass this one.
+	health := domain.Health{}
+	if app.HealthCheck != nil {
+		health.Status = domain.HealthStarting
+	}
+	if err := s.deployments.repository.UpdateHealth(ctx, existing.DeployID, health); err != nil {
+		return existing, latest.Number, repositoryError(err)
+	}
+	if _, err := s.deployments.ReDeploy(ctx, existing.ID, author); err != nil {
+		return existing, latest.Number, err
+	}
+	return existing, latest.Number, nil
+}
+
+func (s *RolloutService) find(ctx context.Context, applicationID, serverID uint) (domain.Deployment, bool, error) {
+	deployments, err := s.deployments.repository.List(ctx, serverID)
+	if err != nil {
+		zap.L().Error("failed to list deployments for rollout",
+			zap.Uint("application_id", applicationID),
+			zap.Uint("server_id", serverID),
+			zap.Error(err),
+		)
+		return domain.Deployment{}, false, repositoryError(err)
+	}
+	var result domain.Deployment
+	for _, deployment := range deployments {
+		if deployment.ApplicationID == applicationID && deployment.ID > result.ID {
+			result = deployment
+		}
+	}
+	return result, result.ID != 0, nil
+}
+
+// waitHealthy waits until the agent reports the deployment running and, for
+// an application with a health check, healthy. A failed status or an
+// unhealthy check ends the wait early; the agent has then restored the
+// previous deployment if it could. Anything else is retried until timeout.
+func (s *RolloutService) waitHealthy(ctx context.Context, deployID uint64, checked bool, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(s.poll)
+	defer ticker.Stop()
+	status, health := "", ""
+	for {
+		deployment, err := s.deployments.repository.GetByDeployID(ctx, deployID)
+		if err == nil {
+			status, health = deployment.Status, deployment.Health.Status
+			switch {
+			case health == domain.HealthUnhealthy:
+				return fmt.Errorf("%w: %s", ErrDeploymentUnhealthy, deployment.Health.Message)
+			case strings.EqualFold(status, domain.StatusFailed):
+				return fmt.Errorf("%w: agent reported %q", ErrDeploymentUnhealthy, status)
+			case strings.EqualFold(status, domain.StatusRunning) && (!checked || health == domain.HealthHealthy):
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			if checked {
+				return fmt.Errorf("%w: last reported status %q, health %q", ErrHealthTimeout, status, health)
+			}
+			return fmt.Errorf("%w: last reported status %q", ErrHealthTimeout, status)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *RolloutService) revert(ctx context.Context, progress *rolloutProgress, author string) {
+	rollout := progress.snapshot()
+	failed := false
+	for i := len(rollout.Steps) - 1; i >= 0; i-- {
+		step := rollout.Steps[i]
+		if step.DeploymentID == 0 || step.Status == domain.StepRolledBack {
+			continue
+		}
+		var err error
+		if step.PreviousRevision > 0 {
+			_, err = s.deployments.Rollback(ctx, step.DeploymentID, step.PreviousRevision, author)
+		} else {
+			_, err = s.deployments.Undeploy(ctx, step.DeploymentID)
+		}
+		progress.update(func(r *domain.Rollout) {
+			r.Steps[i].Status, r.Steps[i].Error = domain.StepRolledBack, ""
+			if err != nil {
+				r.Steps[i].Status, r.Steps[i].Error = domain.StepRollbackFailed, err.Error()
+			}
+		})
+		if err != nil {
+			failed = true
+			zap.L().Warn("failed to roll back rollout step",
+				zap.Uint("rollout_id", rollout.ID),
+				zap.Uint("server_id", step.ServerID),
+				zap.Uint("deployment_id", step.DeploymentID),
+				zap.Error(err),
+			)
+		}
+	}
+	if failed {
+		progress.end(domain.RolloutRollbackFailed, "one or more servers could not be rolled back")
+		return
+	}
+	progress.end(domain.RolloutRolledBack, rollout.Error)
+	zap.L().Info("rollout rolled back", zap.Uint("rollout_id", rollout.ID), zap.String("author", author))
+}
+
+// rolloutProgress is the in-memory state of a running rollout. Every change is
+// written through to the repository.
+type rolloutProgress struct {
+	ctx      context.Context
+	mu       sync.Mutex
+	rollout  domain.Rollout
+	rollouts domain.RolloutRepository
+}
+
+func (p *rolloutProgress) update(change func(*domain.Rollout)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	change(&p.rollout)
+	value := p.rollout
+	value.Steps = slices.Clone(p.rollout.Steps)
+	if err := p.rollouts.UpdateRollout(context.Background(), &value); err != nil {
+		zap.L().Warn("failed to save rollout progress", zap.Uint("rollout_id", p.rollout.ID), zap.Error(err))
+	}
+}
+
+// end finishes the rollout with status; pending steps are skipped.
+func (p *rolloutProgress) end(status, message string) {
+	p.update(func(r *domain.Rollout) {
+		now := time.Now()
+		r.Status, r.Error, r.FinishedAt = status, message, &now
+		for i := range r.Steps {
+			if r.Steps[i].Status == domain.StepPending {
+				r.Steps[i].Status = domain.StepSkipped
+			}
+		}
+	})
+}
+
+func (p *rolloutProgress) snapshot() domain.Rollout {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	value := p.rollout
+	value.Steps = slices.Clone(p.rollout.Steps)
+	return value
+}
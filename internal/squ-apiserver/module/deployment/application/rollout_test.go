@@ -0,0 +1,299 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+type rolloutStore struct {
+	mu       sync.Mutex
+	rollouts []domain.Rollout
+}
+
+func (r *rolloutStore) ListRollouts(context.Context, uint) ([]domain.Rollout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.Rollout(nil), r.rollouts...), nil
+}
+func (r *rolloutStore) GetRollout(_ context.Context, id uint) (domain.Rollout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range r.rollouts {
+		if value.ID == id {
+			return value, nil
+		}
+	}
+	return domain.Rollout{}, gorm.ErrRecordNotFound
+}
+func (r *rolloutStore) AddRollout(_ context.Context, value *domain.Rollout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	value.ID = uint(len(r.rollouts) + 1)
+	r.rollouts = append(r.rollouts, *value)
+	return nil
+}
+func (r *rolloutStore) UpdateRollout(_ context.Context, value *domain.Rollout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rollouts[value.ID-1] = *value
+	return nil
+}
+
+type sequenceStub struct{ value atomic.Uint64 }
+
+func (s *sequenceStub) Generate() (uint64, error) { return s.value.Add(1) + 100, nil }
+
+// reportStatus plays the agent: every deployment that is starting is reported
+// running, or failed on the servers listed in failing.
+func reportStatus(t *testing.T, repository *repositoryStub, failing map[uint]bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		for ctx.Err() == nil {
+			repository.mu.Lock()
+			for i := range repository.deployments {
+				if repository.deployments[i].Status == domain.StatusStarting {
+					repository.deployments[i].Status = domain.StatusRunning
+					if failing[repository.deployments[i].ServerID] {
+						repository.deployments[i].Status = "Failed"
+					}
+				}
+			}
+			repository.mu.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+}
+
+func waitRollout(t *testing.T, service *RolloutService, id uint) domain.Rollout {
+	t.Helper()
+	service.mu.Lock()
+	run, ok := service.running[id]
+	service.mu.Unlock()
+	if ok {
+		select {
+		case <-run.done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("rollout did not finish")
+		}
+	}
+	value, err := service.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func newRolloutFixture(t *testing.T, failing map[uint]bool) (*RolloutService, *repositoryStub, *revisionStub, *agentStub) {
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 1, ServerID: 3, ApplicationID: 1, DeployID: 7, Status: domain.StatusRunning, Content: "services: {}\n# v1"},
+	}}
+	revisions := &revisionStub{}
+	agent := &agentStub{}
+	apps := applicationStub{1: {ID: 1, Name: "demo", Type: "compose", Content: "services: {}\n# v2"}}
+	servers := serverStub{2: {ID: 2}, 3: {ID: 3}, 4: {ID: 4}, 5: {ID: 5}}
+	service := NewService(repository, revisions, &containerStub{}, apps, servers, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, &sequenceStub{})
+	rollouts := NewRolloutService(service, &rolloutStore{})
+	rollouts.poll = time.Millisecond
+	reportStatus(t, repository, failing)
+	return rollouts, repository, revisions, agent
+}
+
+func TestRolloutCanaryAndBatches(t *testing.T) {
+	service, repository, _, agent := newRolloutFixture(t, nil)
+	rollout, err := service.Start(context.Background(), RolloutRequest{
+		ApplicationID: 1, Target: serverDomain.Target{ServerIDs: []uint{2, 3, 4, 5}}, BatchSize: 2, Canary: true,
+		HealthTimeout: time.Second, Author: "alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Start(context.Background(), RolloutRequest{
+		ApplicationID: 1, Target: serverDomain.Target{ServerIDs: []uint{2}},
+	}); err != ErrRolloutInProgress {
+		t.Fatalf("concurrent rollout error = %v", err)
+	}
+
+	rollout = waitRollout(t, service, rollout.ID)
+	if rollout.Status != domain.RolloutSucceeded || rollout.FinishedAt == nil {
+		t.Fatalf("rollout = %#v", rollout)
+	}
+	batches := []int{0, 1, 1, 2}
+	for i, step := range rollout.Steps {
+		if step.Batch != batches[i] || step.Status != domain.StepSucceeded || step.DeploymentID == 0 {
+			t.Fatalf("step %d = %#v", i, step)
+		}
+	}
+	if upgraded := rollout.Steps[1]; upgraded.DeploymentID != 1 || upgraded.PreviousRevision != 1 {
+		t.Fatalf("upgraded step = %#v", upgraded)
+	}
+	existing, _ := repository.Get(context.Background(), 1)
+	if existing.Content != "services: {}\n# v2" {
+		t.Fatalf("existing deployment content = %q", existing.Content)
+	}
+	if len(agent.paths) != 4 {
+		t.Fatalf("agent paths = %#v", agent.paths)
+	}
+}
+
+func TestRolloutStopsOnFailureAndRollsBack(t *testing.T) {
+	service, repository, _, agent := newRolloutFixture(t, map[uint]bool{3: true})
+	rollout, err := service.Start(context.Background(), RolloutRequest{
+		ApplicationID: 1, Target: serverDomain.Target{ServerIDs: []uint{2, 3, 4}}, HealthTimeout: time.Second,
+		AutoRollback: true, Author: "alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rollout = waitRollout(t, service, rollout.ID)
+	if rollout.Status != domain.RolloutRolledBack || !strings.Contains(rollout.Error, "server 3") {
+		t.Fatalf("rollout = %#v", rollout)
+	}
+	statuses := []string{domain.StepRolledBack, domain.StepRolledBack, domain.StepSkipped}
+	for i, step := range rollout.Steps {
+		if step.Status != statuses[i] {
+			t.Fatalf("step %d = %#v", i, step)
+		}
+	}
+	existing, _ := repository.Get(context.Background(), 1)
+	if existing.Content != "services: {}\n# v1" {
+		t.Fatalf("existing deployment content = %q", existing.Content)
+	}
+	// Deploy server 2, redeploy server 3, then revert in reverse order: roll
+	// server 3 back to its previous content and remove the new deployment.
+	expected := []string{"application", "application", "application", "application/delete/101"}
+	if !slices.Equal(agent.paths, expected) {
+		t.Fatalf("agent paths = %#v", agent.paths)
+	}
+	if _, err := service.Rollback(context.Background(), rollout.ID, "bob"); err != ErrRolloutState {
+		t.Fatalf("second rollback error = %v", err)
+	}
+}
+
+func TestRolloutWaitsForHealthCheck(t *testing.T) {
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 1, ServerID: 3, ApplicationID: 1, DeployID: 7, Status: domain.StatusRunning, Content: "services: {}\n# v1",
+			Health: domain.Health{Status: domain.HealthHealthy}},
+	}}
+	check := &domain.HealthCheck{Timeout: 5, Checks: []domain.Check{{Type: "compose"}}}
+	apps := applicationStub{1: {ID: 1, Name: "demo", Type: "compose", Content: "services: {}\n# v2", HealthCheck: check}}
+	servers := serverStub{2: {ID: 2}, 3: {ID: 3}}
+	deployments := NewService(repository, &revisionStub{}, &containerStub{}, apps, servers, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, &agentStub{}, &sequenceStub{})
+	service := NewRolloutService(deployments, &rolloutStore{})
+	service.poll = time.Millisecond
+
+	// Plays the agent: deployments run at once, but the health check of
+	// server 2 needs a while and the one of server 3 fails, after which the
+	// agent restores the previous compose and it runs again.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		started := map[uint64]time.Time{}
+		for ctx.Err() == nil {
+			repository.mu.Lock()
+			for i := range repository.deployments {
+				deployment := &repository.deployments[i]
+				if deployment.Status == domain.StatusStarting {
+					deployment.Status = domain.StatusRunning
+					deployment.Health = domain.Health{Status: domain.HealthStarting}
+					started[deployment.DeployID] = time.Now()
+				}
+				if deployment.Health.Status != domain.HealthStarting || time.Since(started[deployment.DeployID]) < 20*time.Millisecond {
+					continue
+				}
+				deployment.Health = domain.Health{Status: domain.HealthHealthy}
+				if deployment.ServerID == 3 {
+					deployment.Health = domain.Health{Status: domain.HealthUnhealthy, Message: "status 503; restored the previous deployment"}
+				}
+			}
+			repository.mu.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	rollout, err := service.Start(context.Background(), RolloutRequest{
+		ApplicationID: 1, Target: serverDomain.Target{ServerIDs: []uint{2, 3}}, HealthTimeout: time.Second, Author: "alice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rollout = waitRollout(t, service, rollout.ID)
+	if rollout.Status != domain.RolloutFailed {
+		t.Fatalf("rollout = %#v", rollout)
+	}
+	if step := rollout.Steps[0]; step.Status != domain.StepSucceeded {
+		t.Fatalf("step of the healthy server = %#v", step)
+	}
+	if step := rollout.Steps[1]; step.Status != domain.StepFailed || !strings.Contains(step.Error, "status 503") {
+		t.Fatalf("step of the unhealthy server = %#v", step)
+	}
+}
+
+func TestRolloutManualStopAndRollback(t *testing.T) {
+	service, _, _, _ := newRolloutFixture(t, nil)
+	rollout, err := service.Start(context.Background(), RolloutRequest{
+		ApplicationID: 1, Target: serverDomain.Target{ServerIDs: []uint{2, 4}}, Pause: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		value, _ := service.Get(context.Background(), rollout.ID)
+		if value.Steps[0].Status == domain.StepSucceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("first batch did not finish: %#v", value)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	rollout, err = service.Stop(context.Background(), rollout.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rollout.Status != domain.RolloutStopped || rollout.Steps[1].Status != domain.StepSkipped {
+		t.Fatalf("rollout = %#v", rollout)
+	}
+	if _, err := service.Rollback(context.Background(), rollout.ID, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	rollout = waitRollout(t, service, rollout.ID)
+	if rollout.Status != domain.RolloutRolledBack || rollout.Steps[0].Status != domain.StepRolledBack {
+		t.Fatalf("rollout = %#v", rollout)
+	}
+}
+
+func TestRolloutValidation(t *testing.T) {
+	service, _, _, _ := newRolloutFixture(t, nil)
+	tests := []struct {
+		name    string
+		request RolloutRequest
+		err     error
+	}{
+		{"batch", RolloutRequest{ApplicationID: 1, Target: serverDomain.Target{ServerIDs: []uint{2}}, BatchSize: -1}, ErrInvalidRollout},
+		{"pause", RolloutRequest{ApplicationID: 1, Target: serverDomain.Target{ServerIDs: []uint{2}}, Pause: 2 * time.Hour}, ErrInvalidRollout},
+		{"application", RolloutRequest{ApplicationID: 9, Target: serverDomain.Target{ServerIDs: []uint{2}}}, ErrApplicationMissing},
+		{"target", RolloutRequest{ApplicationID: 1, Target: serverDomain.Target{ServerIDs: []uint{9}}}, ErrNoTargetServers},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := service.Start(context.Background(), test.request); err != test.err {
+				t.Fatalf("error = %v, want %v", err, test.err)
+			}
+		})
+	}
+	if _, err := service.Get(context.Background(), 42); err != ErrRolloutNotFound {
+		t.Fatalf("error = %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// agentApplication builds the agent request for deployment on behalf of
+// author. Secret values and registry credentials are resolved here, when the
+// request is sent, so that they never reach the deployments table.
+func (s *Service) agentApplication(ctx context.Context, app domain.Application, deployment domain.Deployment, author string) (AgentApplication, error) {
+	secrets, err := s.resolveSecrets(ctx, deployment, author)
+	if err != nil {
+		return AgentApplication{}, err
+	}
+	registry, err := s.resolveRegistry(ctx, app, deployment)
+	if err != nil {
+		return AgentApplication{}, err
+	}
+	bundle, err := s.transferBundle(ctx, app, deployment)
+	if err != nil {
+		return AgentApplication{}, err
+	}
+	return AgentApplication{
+		Name: app.Name, Description: app.Description, Type: app.Type, Content: deployment.Content,
+		Version: app.Version, ServerID: deployment.ServerID, DeployID: deployment.DeployID,
+		Env: deployment.Env, Secrets: secrets, HealthCheck: app.HealthCheck, Reconcile: deployment.Reconcile,
+		Registry: registry, Bundle: bundle,
+	}, nil
+}
+
+func (s *Service) resolveSecrets(ctx context.Context, deployment domain.Deployment, author string) (map[string]string, error) {
+	if len(deployment.Secrets) == 0 {
+		return nil, nil
+	}
+	values, err := s.secrets.Resolve(ctx, deployment.Secrets, author)
+	if err == nil {
+		return values, nil
+	}
+	zap.L().Warn("failed to resolve deployment secrets",
+		zap.Uint("deployment_id", deployment.ID),
+		zap.Uint64("deploy_id", deployment.DeployID),
+		zap.Strings("secrets", deployment.Secrets),
+		zap.String("author", author),
+		zap.Error(err),
+	)
+	switch {
+	case errors.Is(err, domain.ErrSecretNotFound):
+		return nil, fmt.Errorf("%w: %v", ErrSecretMissing, err)
+	case errors.Is(err, domain.ErrSecretDenied):
+		return nil, fmt.Errorf("%w: %v", ErrSecretDenied, err)
+	default:
+		return nil, ErrSecretResolve
+	}
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type secretValue struct {
+	value string
+	users []string
+}
+
+type secretStub map[string]secretValue
+
+func (s secretStub) Resolve(_ context.Context, names []string, username string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, name := range names {
+		secret, ok := s[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", domain.ErrSecretNotFound, name)
+		}
+		if !slices.Contains(secret.users, username) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrSecretDenied, name)
+		}
+		values[name] = secret.value
+	}
+	return values, nil
+}
+
+func TestSecretsAreResolvedOnDeploy(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 1, ServerID: 2, ApplicationID: 1, DeployID: 99, Content: "services: {}", Env: []map[string]string{{"MODE": "prod"}}},
+	}}
+	revisions := &revisionStub{}
+	agent := &agentStub{}
+	secrets := secretStub{
+		"DB_PASSWORD": {value: "hunter2", users: []string{"alice", "bob"}},
+		"API_KEY":     {value: "k-123", users: []string{"alice"}},
+	}
+	apps := applicationStub{1: {ID: 1, Name: "demo", Type: "compose"}}
+	service := NewService(repository, revisions, &containerStub{}, apps, serverStub{2: {ID: 2}}, &clusterStub{}, secrets, registryStub{}, bundleStub{}, agent, idStub{})
+
+	if _, err := service.Update(ctx, 1, Change{Secrets: []string{"MISSING"}, Author: "alice"}); !errors.Is(err, ErrSecretMissing) {
+		t.Fatalf("Update() with an unknown secret error = %v", err)
+	}
+	if _, err := service.Update(ctx, 1, Change{Secrets: []string{"API_KEY"}, Author: "bob"}); !errors.Is(err, ErrSecretDenied) {
+		t.Fatalf("Update() with a denied secret error = %v", err)
+	}
+	if _, err := service.Update(ctx, 1, Change{Secrets: []string{"DB_PASSWORD", "API_KEY"}, Author: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := repository.deployments[0].Secrets; !slices.Equal(got, []string{"DB_PASSWORD", "API_KEY"}) {
+		t.Fatalf("secrets = %v", got)
+	}
+	for _, revision := range revisions.revisions {
+		if value := fmt.Sprint(revision); strings.Contains(value, "hunter2") || strings.Contains(value, "k-123") {
+			t.Fatalf("revision stores a secret value: %+v", revision)
+		}
+	}
+
+	if _, err := service.ReDeploy(ctx, 1, "bob"); !errors.Is(err, ErrSecretDenied) || len(agent.requests) != 0 {
+		t.Fatalf("ReDeploy() by bob error = %v, requests = %d", err, len(agent.requests))
+	}
+	if _, err := service.ReDeploy(ctx, 1, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	request := agent.requests[0].(AgentApplication)
+	if request.Secrets["DB_PASSWORD"] != "hunter2" || request.Secrets["API_KEY"] != "k-123" || request.Env[0]["MODE"] != "prod" {
+		t.Fatalf("request = %+v", request)
+	}
+
+	if _, err := service.Rollback(ctx, 1, 1, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if request := agent.requests[1].(AgentApplication); request.Secrets != nil {
+		t.Fatalf("rollback request secrets = %v", request.Secrets)
+	}
+	if len(repository.deployments[0].Secrets) != 0 {
+		t.Fatalf("secrets after rollback = %v", repository.deployments[0].Secrets)
+	}
+}
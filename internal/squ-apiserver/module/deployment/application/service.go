@@ -7,44 +7,97 @@ import (
 	"go.uber.org/zap"
 
 	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
+// AgentApplication is what the agent deploys. Secrets holds the resolved
+// secret values by name; the agent adds them to the env file right before
+// bringing the project up and does not store them. Registry is the private
+// registry the agent pulls the images from, which it does not store either.
+// Bundle is the checksum of the image bundle the agent loads before up; it
+// has been sent to the agent already.
 type AgentApplication struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Type        string `json:"type"`
-	Content     string `json:"content"`
-	Version     string `json:"version"`
-	ServerID    uint   `json:"server_id"`
-	DeployID    uint64 `json:"deploy_id"`
+	ID          uint                `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Type        string              `json:"type"`
+	Content     string              `json:"content"`
+	Version     string              `json:"version"`
+	ServerID    uint                `json:"server_id"`
+	DeployID    uint64              `json:"deploy_id"`
+	Env         []map[string]string `json:"env"`
+	Secrets     map[string]string   `json:"secrets,omitempty"`
+	HealthCheck *domain.HealthCheck `json:"health_check,omitempty"`
+	Reconcile   bool                `json:"reconcile,omitempty"`
+	Registry    *AgentRegistry      `json:"registry,omitempty"`
+	Bundle      string              `json:"bundle,omitempty"`
 }
 
+type AgentRegistry struct {
+	Server   string `json:"server"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// TargetResult is the outcome of deploying to one server of a target.
+type TargetResult struct {
+	ServerID uint
+	Message  string
+	Error    string
+}
+
+// DeploymentView is a deployment with its application and either its server
+// or its cluster.
 type DeploymentView struct {
 	Deployment  domain.Deployment
 	Application domain.Application
 	Server      domain.Server
+	Cluster     domain.Cluster
 }
 
 type Service struct {
 	repository   domain.Repository
+	revisions    domain.RevisionRepository
+	containers   domain.ContainerRepository
 	applications domain.ApplicationReader
 	servers      domain.ServerReader
+	clusters     domain.ClusterRuntime
+	secrets      domain.SecretResolver
+	registries   domain.RegistryReader
+	bundles      domain.BundleTransfer
 	agent        domain.AgentClient
 	ids          domain.IDGenerator
 }
 
 func NewService(
 	repository domain.Repository,
+	revisions domain.RevisionRepository,
+	containers domain.ContainerRepository,
 	applications domain.ApplicationReader,
 	servers domain.ServerReader,
+	clusters domain.ClusterRuntime,
+	secrets domain.SecretResolver,
+	registries domain.RegistryReader,
+	bundles domain.BundleTransfer,
 	agent domain.AgentClient,
 	ids domain.IDGenerator,
 ) *Service {
-	return &Service{repository: repository, applications: applications, servers: servers, agent: agent, ids: ids}
+	return &Service{
+		repository:   repository,
+		revisions:    revisions,
+		containers:   containers,
+		applications: applications,
+		servers:      servers,
+		clusters:     clusters,
+		secrets:      secrets,
+		registries:   registries,
+		bundles:      bundles,
+		agent:        agent,
+		ids:          ids,
+	}
 }
 
-func (s *Service) Deploy(ctx context.Context, applicationID, serverID uint) (string, error) {
+func (s *Service) Deploy(ctx context.Context, applicationID, serverID uint, author string) (string, error) {
 	app, err := s.applications.Get(ctx, applicationID)
 	if err != nil {
 		zap.L().Error("failed to get application for deployment",
@@ -54,6 +107,9 @@ func (s *Service) Deploy(ctx context.Context, applicationID, serverID uint) (str
 		)
 		return "", ErrApplicationMissing
 	}
+	if app.Type == domain.AppTypeK8sManifest || app.Type == domain.AppTypeHelmChart {
+		return "", ErrClusterRequired
+	}
 	server, err := s.servers.Get(ctx, serverID)
 	if err != nil {
 		zap.L().Error("failed to get server for deployment",
@@ -63,40 +119,25 @@ func (s *Service) Deploy(ctx context.Context, applicationID, serverID uint) (str
 		)
 		return "", ErrApplicationMissing
 	}
-	deployments, err := s.repository.List(ctx, serverID)
-	if err != nil {
-		zap.L().Error("failed to list existing deployments for conflict check",
+	var conflicts []domain.Conflict
+	if app.Type == domain.AppTypeCompose && app.Content != "" {
+		conflicts, err = s.composeConflicts(ctx, domain.Deployment{
+			ServerID: serverID, ApplicationID: applicationID, Content: app.Content,
+		}, server)
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(conflicts) > 0 {
+		err := &ConflictError{Conflicts: conflicts}
+		zap.L().Warn("deployment compose check failed",
 			zap.Uint("application_id", applicationID),
+			zap.String("application_name", app.Name),
 			zap.Uint("server_id", serverID),
+			zap.Int("conflicts", len(conflicts)),
 			zap.Error(err),
 		)
-		return "", repositoryError(err)
-	}
-	for _, deployment := range deployments {
-		existing, err := s.applications.Get(ctx, deployment.ApplicationID)
-		if err != nil {
-			zap.L().Warn("failed to get existing application for conflict check",
-				zap.Uint("application_id", deployment.ApplicationID),
-				zap.Uint("server_id", serverID),
-				zap.Uint64("deploy_id", deployment.DeployID),
-				zap.Error(err),
-			)
-			continue
-		}
-		if existing.Type == "compose" && app.Type == "compose" {
-			if err := checkComposeContent(app.Content, deployment.Content); err != nil {
-				zap.L().Warn("deployment compose check failed",
-					zap.Uint("application_id", applicationID),
-					zap.String("application_name", app.Name),
-					zap.Uint("existing_application_id", deployment.ApplicationID),
-					zap.String("existing_application_name", existing.Name),
-					zap.Uint("server_id", serverID),
-					zap.Uint64("existing_deploy_id", deployment.DeployID),
-					zap.Error(err),
-				)
-				return "", err
-			}
-		}
+		return "", err
 	}
 	deployID, err := s.ids.Generate()
 	if err != nil {
@@ -107,9 +148,13 @@ func (s *Service) Deploy(ctx context.Context, applicationID, serverID uint) (str
 		)
 		return "", ErrIDGeneration
 	}
-	request := AgentApplication{
-		Name: app.Name, Description: app.Description, Type: app.Type, Content: app.Content,
-		Version: app.Version, ServerID: serverID, DeployID: deployID,
+	deployment := domain.Deployment{
+		ServerID: serverID, ApplicationID: applicationID, Content: app.Content, DeployID: deployID,
+		Status: domain.StatusStarting,
+	}
+	request, err := s.agentApplication(ctx, app, deployment, author)
+	if err != nil {
+		return "", err
 	}
 	if err := s.agent.Post(ctx, server, "application", request); err != nil {
 		zap.L().Error("failed to deploy application on agent",
@@ -120,9 +165,6 @@ func (s *Service) Deploy(ctx context.Context, applicationID, serverID uint) (str
 		)
 		return "", ErrAgentDeploy
 	}
-	deployment := domain.Deployment{
-		ServerID: serverID, ApplicationID: applicationID, Content: app.Content, DeployID: deployID,
-	}
 	if err := s.repository.Add(ctx, &deployment); err != nil {
 		zap.L().Error("failed to create deployment record",
 			zap.Uint64("deploy_id", deployID),
@@ -148,10 +190,43 @@ func (s *Service) Deploy(ctx context.Context, applicationID, serverID uint) (str
 		}
 		return "", repositoryError(err)
 	}
+	// The deployment is live at this point; a missing revision is logged
+	// rather than reported as a failed deploy.
+	_, _ = s.record(ctx, deployment, domain.RevisionDeploy, author, 0)
 	return "deploy success", nil
 }
 
-func (s *Service) ReDeploy(ctx context.Context, id uint) (string, error) {
+// DeployTarget deploys an application to every server matched by target.
+// Servers are deployed one after another so that compose conflict checks see
+// consistent state; a failure on one server does not stop the others.
+func (s *Service) DeployTarget(ctx context.Context, applicationID uint, target serverDomain.Target, author string) ([]TargetResult, error) {
+	servers, err := s.servers.Select(ctx, target)
+	if err != nil {
+		zap.L().Warn("failed to resolve deployment target",
+			zap.Uint("application_id", applicationID),
+			zap.Uints("server_ids", target.ServerIDs),
+			zap.String("group", target.Group),
+			zap.String("selector", target.Selector),
+			zap.Error(err),
+		)
+		return nil, ErrInvalidTarget
+	}
+	if len(servers) == 0 {
+		return nil, ErrNoTargetServers
+	}
+	result := make([]TargetResult, 0, len(servers))
+	for _, server := range servers {
+		message, err := s.Deploy(ctx, applicationID, server.ID, author)
+		value := TargetResult{ServerID: server.ID, Message: message}
+		if err != nil {
+			value.Error = err.Error()
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+func (s *Service) ReDeploy(ctx context.Context, id uint, author string) (string, error) {
 	deployment, err := s.repository.Get(ctx, id)
 	if err != nil {
 		zap.L().Error("failed to get deployment for redeploy", zap.Uint("deployment_id", id), zap.Error(err))
@@ -167,6 +242,13 @@ func (s *Service) ReDeploy(ctx context.Context, id uint) (string, error) {
 		)
 		return "", repositoryError(err)
 	}
+	if deployment.ClusterID != 0 {
+		if err := s.redeployCluster(ctx, &deployment); err != nil {
+			return "", err
+		}
+		_, _ = s.record(ctx, deployment, domain.RevisionDeploy, author, 0)
+		return "success", nil
+	}
 	server, err := s.servers.Get(ctx, deployment.ServerID)
 	if err != nil {
 		zap.L().Error("failed to get server for redeploy",
@@ -177,9 +259,9 @@ func (s *Service) ReDeploy(ctx context.Context, id uint) (string, error) {
 		)
 		return "", ErrApplicationMissing
 	}
-	request := AgentApplication{
-		Name: app.Name, Description: app.Description, Type: app.Type, Content: deployment.Content,
-		Version: app.Version, ServerID: deployment.ServerID, DeployID: deployment.DeployID,
+	request, err := s.agentApplication(ctx, app, deployment, author)
+	if err != nil {
+		return "", err
 	}
 	if err := s.agent.Post(ctx, server, "application", request); err != nil {
 		zap.L().Error("failed to redeploy application on agent",
@@ -191,6 +273,7 @@ func (s *Service) ReDeploy(ctx context.Context, id uint) (string, error) {
 		)
 		return "", ErrAgentDeploy
 	}
+	_, _ = s.record(ctx, deployment, domain.RevisionDeploy, author, 0)
 	return "success", nil
 }
 
@@ -212,6 +295,20 @@ func (s *Service) List(ctx context.Context, serverID uint) ([]DeploymentView, er
 			)
 			continue
 		}
+		if deployment.ClusterID != 0 {
+			cluster, err := s.clusters.Cluster(ctx, deployment.ClusterID)
+			if err != nil {
+				zap.L().Warn("failed to get cluster information for deployment",
+					zap.Uint("application_id", deployment.ApplicationID),
+					zap.Uint("cluster_id", deployment.ClusterID),
+					zap.Uint64("deploy_id", deployment.DeployID),
+					zap.Error(err),
+				)
+				continue
+			}
+			result = append(result, DeploymentView{Deployment: deployment, Application: app, Cluster: cluster})
+			continue
+		}
 		server, err := s.servers.Get(ctx, deployment.ServerID)
 		if err != nil {
 			zap.L().Warn("failed to get server information for deployment",
@@ -262,13 +359,39 @@ func (s *Service) ListServers(ctx context.Context, applicationID uint) ([]domain
 	return result, nil
 }
 
-func (s *Service) Update(ctx context.Context, id uint, content string) (string, error) {
+// Update stores new content, env or secret references and records them as a
+// revision. The change is not pushed to the agent until the next deploy. The
+// author must be allowed to use every secret referenced.
+func (s *Service) Update(ctx context.Context, id uint, change Change) (string, error) {
 	deployment, err := s.repository.Get(ctx, id)
 	if err != nil {
 		zap.L().Error("failed to get deployment for update", zap.Uint("deployment_id", id), zap.Error(err))
 		return "", repositoryError(err)
 	}
-	deployment.Content = content
+	updated := deployment
+	if change.Content != "" {
+		updated.Content = change.Content
+	}
+	if change.Env != nil {
+		updated.Env = change.Env
+	}
+	if change.Secrets != nil {
+		if deployment.ClusterID != 0 && len(change.Secrets) > 0 {
+			return "", ErrUnsupported
+		}
+		updated.Secrets = change.Secrets
+		if _, err := s.resolveSecrets(ctx, updated, change.Author); err != nil {
+			return "", err
+		}
+	}
+	current := domain.Revision{Content: deployment.Content, Env: deployment.Env, Secrets: deployment.Secrets}
+	if current.SameState(updated) {
+		return "success", nil
+	}
+	if err := s.ensureHistory(ctx, deployment); err != nil {
+		return "", repositoryError(err)
+	}
+	deployment = updated
 	if err := s.repository.Update(ctx, &deployment); err != nil {
 		zap.L().Error("failed to update deployment",
 			zap.Uint("deployment_id", id),
@@ -277,10 +400,18 @@ func (s *Service) Update(ctx context.Context, id uint, content string) (string,
 		)
 		return "", repositoryError(err)
 	}
+	if _, err := s.record(ctx, deployment, domain.RevisionUpdate, change.Author, 0); err != nil {
+		return "", repositoryError(err)
+	}
 	return "success", nil
 }
 
 func (s *Service) Undeploy(ctx context.Context, id uint) (string, error) {
+	if deployment, ok, err := s.clusterDeployment(ctx, id); err != nil {
+		return "", err
+	} else if ok {
+		return s.undeployCluster(ctx, deployment)
+	}
 	deployment, server, err := s.deploymentServer(ctx, id)
 	if err != nil {
 		return "", err
@@ -342,8 +473,12 @@ func (s *Service) Start(ctx context.Context, id uint) (string, error) {
 	return "start success", nil
 }
 
-func (s *Service) ReportStatus(ctx context.Context, deployID uint64, status string) (string, error) {
-	if _, err := s.repository.GetByDeployID(ctx, deployID); err != nil {
+// ReportStatus records the status and health an agent observed for a
+// deployment. A nil containers keeps the stored containers; agents send nil
+// when they could not query docker, and older agents never send containers.
+func (s *Service) ReportStatus(ctx context.Context, deployID uint64, status string, health domain.Health, containers []domain.Container) (string, error) {
+	deployment, err := s.repository.GetByDeployID(ctx, deployID)
+	if err != nil {
 		zap.L().Error("failed to get deployment for status update",
 			zap.Uint64("deploy_id", deployID),
 			zap.String("status", status),
@@ -359,6 +494,30 @@ func (s *Service) ReportStatus(ctx context.Context, deployID uint64, status stri
 		)
 		return "", repositoryError(err)
 	}
+	if err := s.repository.UpdateHealth(ctx, deployID, health); err != nil {
+		zap.L().Error("failed to update deployment health",
+			zap.Uint64("deploy_id", deployID),
+			zap.String("health", health.Status),
+			zap.Error(err),
+		)
+		return "", repositoryError(err)
+	}
+	if health.Status == domain.HealthUnhealthy && deployment.Health != health {
+		zap.L().Warn("deployment is unhealthy",
+			zap.Uint64("deploy_id", deployID),
+			zap.String("reason", health.Message),
+		)
+	}
+	if containers != nil {
+		if err := s.containers.ReplaceContainers(ctx, deployment.ID, containers); err != nil {
+			zap.L().Error("failed to store deployment containers",
+				zap.Uint64("deploy_id", deployID),
+				zap.Int("containers", len(containers)),
+				zap.Error(err),
+			)
+			return "", repositoryError(err)
+		}
+	}
 	zap.L().Info("application status updated", zap.Uint64("deploy_id", deployID), zap.String("status", status))
 	return "success", nil
 }
@@ -369,6 +528,9 @@ func (s *Service) deploymentServer(ctx context.Context, id uint) (domain.Deploym
 		zap.L().Error("failed to get deployment", zap.Uint("deployment_id", id), zap.Error(err))
 		return domain.Deployment{}, domain.Server{}, repositoryError(err)
 	}
+	if deployment.ClusterID != 0 {
+		return domain.Deployment{}, domain.Server{}, ErrUnsupported
+	}
 	server, err := s.servers.Get(ctx, deployment.ServerID)
 	if err != nil {
 		zap.L().Error("failed to get server for deployment operation",
@@ -3,22 +3,30 @@ package application
 import (
 	"context"
 	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
 	"gorm.io/gorm"
 
 	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type repositoryStub struct {
+	mu          sync.Mutex
 	deployments []domain.Deployment
 	addErr      error
 	deleted     bool
 }
 
 func (r *repositoryStub) List(_ context.Context, serverID uint) ([]domain.Deployment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if serverID == 0 {
-		return r.deployments, nil
+		return slices.Clone(r.deployments), nil
 	}
 	var result []domain.Deployment
 	for _, value := range r.deployments {
@@ -29,6 +37,8 @@ func (r *repositoryStub) List(_ context.Context, serverID uint) ([]domain.Deploy
 	return result, nil
 }
 func (r *repositoryStub) Get(_ context.Context, id uint) (domain.Deployment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	for _, value := range r.deployments {
 		if value.ID == id {
 			return value, nil
@@ -37,6 +47,8 @@ func (r *repositoryStub) Get(_ context.Context, id uint) (domain.Deployment, err
 	return domain.Deployment{}, gorm.ErrRecordNotFound
 }
 func (r *repositoryStub) GetByDeployID(_ context.Context, id uint64) (domain.Deployment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	for _, value := range r.deployments {
 		if value.DeployID == id {
 			return value, nil
@@ -44,8 +56,15 @@ func (r *repositoryStub) GetByDeployID(_ context.Context, id uint64) (domain.Dep
 	}
 	return domain.Deployment{}, gorm.ErrRecordNotFound
 }
-func (r *repositoryStub) Delete(context.Context, uint) error { r.deleted = true; return nil }
+func (r *repositoryStub) Delete(context.Context, uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = true
+	return nil
+}
 func (r *repositoryStub) Add(_ context.Context, value *domain.Deployment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.addErr != nil {
 		return r.addErr
 	}
@@ -53,8 +72,99 @@ func (r *repositoryStub) Add(_ context.Context, value *domain.Deployment) error
 	r.deployments = append(r.deployments, *value)
 	return nil
 }
-func (r *repositoryStub) Update(context.Context, *domain.Deployment) error { return nil }
-func (r *repositoryStub) UpdateStatus(context.Context, uint64, string) error {
+func (r *repositoryStub) Update(_ context.Context, value *domain.Deployment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.deployments {
+		if r.deployments[i].ID == value.ID {
+			r.deployments[i].Content, r.deployments[i].Env = value.Content, value.Env
+			r.deployments[i].Secrets, r.deployments[i].Objects = value.Secrets, value.Objects
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) UpdateHealth(_ context.Context, id uint64, health domain.Health) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.deployments {
+		if r.deployments[i].DeployID == id {
+			r.deployments[i].Health = health
+		}
+	}
+	return nil
+}
+
+func (r *repositoryStub) UpdateFiles(_ context.Context, id uint64, state domain.FileState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.deployments {
+		if r.deployments[i].DeployID == id {
+			r.deployments[i].Files = state
+		}
+	}
+	return nil
+}
+
+func (r *repositoryStub) UpdateStatus(_ context.Context, id uint64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.deployments {
+		if r.deployments[i].DeployID == id {
+			r.deployments[i].Status = status
+		}
+	}
+	return nil
+}
+
+type revisionStub struct {
+	mu        sync.Mutex
+	revisions []domain.Revision
+}
+
+func (r *revisionStub) ListRevisions(_ context.Context, id uint) ([]domain.Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []domain.Revision
+	for _, value := range r.revisions {
+		if value.DeploymentID == id {
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+func (r *revisionStub) GetRevision(_ context.Context, id, number uint) (domain.Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range r.revisions {
+		if value.DeploymentID == id && value.Number == number {
+			return value, nil
+		}
+	}
+	return domain.Revision{}, gorm.ErrRecordNotFound
+}
+func (r *revisionStub) LatestRevision(_ context.Context, id uint) (domain.Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latest(id)
+}
+func (r *revisionStub) latest(id uint) (domain.Revision, error) {
+	var latest domain.Revision
+	for _, value := range r.revisions {
+		if value.DeploymentID == id && value.Number > latest.Number {
+			latest = value
+		}
+	}
+	if latest.Number == 0 {
+		return domain.Revision{}, gorm.ErrRecordNotFound
+	}
+	return latest, nil
+}
+func (r *revisionStub) AddRevision(_ context.Context, value *domain.Revision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	latest, _ := r.latest(value.DeploymentID)
+	value.Number = latest.Number + 1
+	r.revisions = append(r.revisions, *value)
 	return nil
 }
 
@@ -78,16 +188,66 @@ func (s serverStub) Get(_ context.Context, id uint) (domain.Server, error) {
 	return value, nil
 }
 
+func (s serverStub) Select(_ context.Context, target serverDomain.Target) ([]domain.Server, error) {
+	var result []domain.Server
+	for _, id := range target.ServerIDs {
+		if value, ok := s[id]; ok {
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+
 type agentStub struct {
-	paths []string
-	err   error
+	mu       sync.Mutex
+	paths    []string
+	requests []any
+	err      error
+	// reads are the paths of Get, which reports host.
+	reads   []string
+	host    domain.HostResources
+	readErr error
+}
+
+func (a *agentStub) Get(_ context.Context, _ domain.Server, path string, result any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reads = append(a.reads, path)
+	if a.readErr != nil {
+		return a.readErr
+	}
+	*result.(*domain.HostResources) = a.host
+	return nil
 }
 
-func (a *agentStub) Post(_ context.Context, _ domain.Server, path string, _ any) error {
+func (a *agentStub) Post(_ context.Context, _ domain.Server, path string, request any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.paths = append(a.paths, path)
+	a.requests = append(a.requests, request)
 	return a.err
 }
 
+type containerStub struct {
+	mu         sync.Mutex
+	containers map[uint][]domain.Container
+}
+
+func (c *containerStub) ListContainers(_ context.Context, id uint) ([]domain.Container, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.containers[id]), nil
+}
+func (c *containerStub) ReplaceContainers(_ context.Context, id uint, containers []domain.Container) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.containers == nil {
+		c.containers = map[uint][]domain.Container{}
+	}
+	c.containers[id] = slices.Clone(containers)
+	return nil
+}
+
 type idStub struct{ value uint64 }
 
 func (i idStub) Generate() (uint64, error) { return i.value, nil }
@@ -114,14 +274,57 @@ func TestComposeConflictErrors(t *testing.T) {
 	}
 }
 
+func TestDeployReportsHostConflicts(t *testing.T) {
+	apps := applicationStub{
+		1: {ID: 1, Name: "web", Type: "compose", Content: "services:\n  web:\n    image: nginx\n    ports: ['127.0.0.1:80:80', '8443:443']\n"},
+		2: {ID: 2, Name: "proxy", Type: "compose"},
+	}
+	servers := serverStub{2: {ID: 2}}
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 5, ApplicationID: 2, ServerID: 2, DeployID: 50, Content: "services:\n  proxy:\n    image: caddy\n    ports: ['8443:443']\n"},
+	}}
+	agent := &agentStub{host: domain.HostResources{Listeners: []domain.Listener{
+		{Protocol: "tcp", IP: "0.0.0.0", Port: 80},
+		{Protocol: "tcp", IP: "0.0.0.0", Port: 8443},
+	}}}
+	service := NewService(repository, &revisionStub{}, &containerStub{}, apps, servers, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{value: 99})
+
+	_, err := service.Deploy(context.Background(), 1, 2, "admin")
+	var conflicts *ConflictError
+	if !errors.As(err, &conflicts) || !errors.Is(err, ErrPortConflict) {
+		t.Fatalf("error = %v, want a port conflict report", err)
+	}
+	want := []domain.Conflict{
+		{DeploymentID: 5, Application: "proxy", Kind: domain.ConflictPort, Resource: "8443/tcp",
+			Reason: "service web publishes 8443/tcp, which overlaps 8443/tcp of service proxy"},
+		{Kind: domain.ConflictPort, Resource: "127.0.0.1:80/tcp",
+			Reason: "service web publishes 127.0.0.1:80/tcp, but 0.0.0.0:80/tcp is already listening on the host"},
+	}
+	if !reflect.DeepEqual(conflicts.Conflicts, want) {
+		t.Fatalf("conflicts = %+v\nwant        %+v", conflicts.Conflicts, want)
+	}
+	if len(agent.paths) != 0 || !reflect.DeepEqual(agent.reads, []string{"application/resources"}) {
+		t.Fatalf("paths = %v, reads = %v", agent.paths, agent.reads)
+	}
+
+	// Agents that cannot report their host are only checked against other
+	// deployments.
+	repository.deployments = nil
+	agent = &agentStub{readErr: errors.New("404 page not found")}
+	service = NewService(repository, &revisionStub{}, &containerStub{}, apps, servers, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{value: 99})
+	if _, err := service.Deploy(context.Background(), 1, 2, "admin"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestDeployAndRollbackAgentPaths(t *testing.T) {
 	apps := applicationStub{1: {ID: 1, Name: "demo", Type: "compose", Content: "services: {}"}}
 	servers := serverStub{2: {ID: 2, IPAddress: "192.0.2.1", AgentPort: 10750}}
 
 	repository := &repositoryStub{}
 	agent := &agentStub{}
-	service := NewService(repository, apps, servers, agent, idStub{value: 99})
-	data, err := service.Deploy(context.Background(), 1, 2)
+	service := NewService(repository, &revisionStub{}, &containerStub{}, apps, servers, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{value: 99})
+	data, err := service.Deploy(context.Background(), 1, 2, "admin")
 	if err != nil || data != "deploy success" {
 		t.Fatalf("data=%q err=%v", data, err)
 	}
@@ -131,8 +334,8 @@ func TestDeployAndRollbackAgentPaths(t *testing.T) {
 
 	repository = &repositoryStub{addErr: errors.New("insert failed")}
 	agent = &agentStub{}
-	service = NewService(repository, apps, servers, agent, idStub{value: 100})
-	_, err = service.Deploy(context.Background(), 1, 2)
+	service = NewService(repository, &revisionStub{}, &containerStub{}, apps, servers, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{value: 100})
+	_, err = service.Deploy(context.Background(), 1, 2, "admin")
 	if err == nil {
 		t.Fatal("expected deployment record error")
 	}
@@ -144,7 +347,7 @@ func TestDeployAndRollbackAgentPaths(t *testing.T) {
 func TestStartStopUndeployPaths(t *testing.T) {
 	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, DeployID: 99}}}
 	agent := &agentStub{}
-	service := NewService(repository, applicationStub{}, serverStub{2: {ID: 2}}, agent, idStub{})
+	service := NewService(repository, &revisionStub{}, &containerStub{}, applicationStub{}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{})
 	if _, err := service.Stop(context.Background(), 1); err != nil {
 		t.Fatal(err)
 	}
@@ -164,3 +367,81 @@ func TestStartStopUndeployPaths(t *testing.T) {
 		t.Fatal("undeploy did not soft-delete deployment")
 	}
 }
+
+func TestDeployTargetReportsEachServer(t *testing.T) {
+	apps := applicationStub{1: {ID: 1, Name: "demo", Type: "compose", Content: "services: {}"}}
+	servers := serverStub{2: {ID: 2}, 3: {ID: 3}}
+	agent := &agentStub{}
+	service := NewService(&repositoryStub{}, &revisionStub{}, &containerStub{}, apps, servers, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{value: 7})
+	results, err := service.DeployTarget(context.Background(), 1, serverDomain.Target{ServerIDs: []uint{2, 3}}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].ServerID != 2 || results[1].Error != "" || len(agent.paths) != 2 {
+		t.Fatalf("results = %#v, paths = %#v", results, agent.paths)
+	}
+	if _, err := service.DeployTarget(context.Background(), 1, serverDomain.Target{ServerIDs: []uint{9}}, "admin"); !errors.Is(err, ErrNoTargetServers) {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestUpdateAndRollbackRecordRevisions(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{deployments: []domain.Deployment{
+		{ID: 1, ServerID: 2, ApplicationID: 1, DeployID: 99, Content: "services:\n  web:\n    image: nginx:1.25"},
+	}}
+	revisions := &revisionStub{}
+	agent := &agentStub{}
+	apps := applicationStub{1: {ID: 1, Name: "demo", Type: "compose"}}
+	service := NewService(repository, revisions, &containerStub{}, apps, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{})
+
+	change := Change{Content: "services:\n  web:\n    image: nginx:1.27", Author: "alice"}
+	if _, err := service.Update(ctx, 1, change); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Update(ctx, 1, change); err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions.revisions) != 2 {
+		t.Fatalf("revisions = %#v", revisions.revisions)
+	}
+	baseline, update := revisions.revisions[0], revisions.revisions[1]
+	if baseline.Reason != domain.RevisionCreate || update.Reason != domain.RevisionUpdate || update.Author != "alice" {
+		t.Fatalf("revisions = %#v", revisions.revisions)
+	}
+	if !strings.Contains(update.Diff, "-    image: nginx:1.25") || !strings.Contains(update.Diff, "+    image: nginx:1.27") {
+		t.Fatalf("diff = %q", update.Diff)
+	}
+
+	revision, err := service.Rollback(ctx, 1, 1, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision.Number != 3 || revision.Reason != domain.RevisionRollback || revision.RollbackOf != 1 {
+		t.Fatalf("revision = %#v", revision)
+	}
+	request, ok := agent.requests[0].(AgentApplication)
+	if !ok || agent.paths[0] != "application" || request.Content != baseline.Content || request.DeployID != 99 {
+		t.Fatalf("agent paths=%#v requests=%#v", agent.paths, agent.requests)
+	}
+	if repository.deployments[0].Content != baseline.Content {
+		t.Fatalf("content = %q", repository.deployments[0].Content)
+	}
+	if _, err := service.Rollback(ctx, 1, 9, "bob"); !errors.Is(err, ErrRevisionNotFound) {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestRollbackKeepsStateWhenAgentFails(t *testing.T) {
+	ctx := context.Background()
+	repository := &repositoryStub{deployments: []domain.Deployment{{ID: 1, ServerID: 2, ApplicationID: 1, Content: "new"}}}
+	revisions := &revisionStub{revisions: []domain.Revision{{DeploymentID: 1, Number: 1, Content: "old"}}}
+	agent := &agentStub{err: errors.New("unreachable")}
+	service := NewService(repository, revisions, &containerStub{}, applicationStub{1: {ID: 1}}, serverStub{2: {ID: 2}}, &clusterStub{}, secretStub{}, registryStub{}, bundleStub{}, agent, idStub{})
+	if _, err := service.Rollback(ctx, 1, 1, "bob"); !errors.Is(err, ErrAgentDeploy) {
+		t.Fatalf("error = %v", err)
+	}
+	if repository.deployments[0].Content != "new" || len(revisions.revisions) != 1 {
+		t.Fatalf("deployment = %#v revisions = %#v", repository.deployments, revisions.revisions)
+	}
+}
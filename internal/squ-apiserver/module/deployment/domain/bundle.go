@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrBundleNotFound = errors.New("image bundle not found")
+
+// BundleTransfer sends the image bundle of an application to the agent of a
+// server, which loads it before compose up.
+type BundleTransfer interface {
+	// Push returns the checksum the agent knows the bundle by. A bundle the
+	// agent holds already is not sent again.
+	Push(ctx context.Context, bundleID, serverID uint) (checksum string, err error)
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Helm release statuses, as reported by helm status.
+const (
+	ReleaseDeployed        = "deployed"
+	ReleaseFailed          = "failed"
+	ReleaseUninstalled     = "uninstalled"
+	ReleasePendingInstall  = "pending-install"
+	ReleasePendingUpgrade  = "pending-upgrade"
+	ReleasePendingRollback = "pending-rollback"
+)
+
+var ErrInvalidChart = errors.New("invalid helm chart")
+
+// Chart is the content of a helm_chart application and its deployments:
+//
+//	path: web               # a chart directory inside charts.path
+//	archive: H4sIAAAA...    # or a packaged chart (.tgz), base64 encoded
+//	values:
+//	  replicaCount: 2
+//
+// Path may also be an OCI reference such as oci://ghcr.io/org/charts/web.
+// Exactly one of Path and Archive is set.
+type Chart struct {
+	Path    string
+	Archive []byte
+	Values  map[string]any
+}
+
+// Release is a chart installed into a cluster. Set holds --set-string
+// overrides taken from the deployment env, applied on top of the chart
+// values.
+type Release struct {
+	Name      string
+	Namespace string
+	Chart     Chart
+	Set       []map[string]string
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ParseChart reads the content of a helm_chart application.
+func ParseChart(content string) (Chart, error) {
+	var value struct {
+		Path    string         `yaml:"path"`
+		Archive string         `yaml:"archive"`
+		Values  map[string]any `yaml:"values"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &value); err != nil {
+		return Chart{}, fmt.Errorf("%w: %v", ErrInvalidChart, err)
+	}
+	chart := Chart{Path: strings.TrimSpace(value.Path), Values: value.Values}
+	if archive := strings.Join(strings.Fields(value.Archive), ""); archive != "" {
+		data, err := base64.StdEncoding.DecodeString(archive)
+		if err != nil {
+			return Chart{}, fmt.Errorf("%w: archive is not base64: %v", ErrInvalidChart, err)
+		}
+		if !bytes.HasPrefix(data, gzipMagic) {
+			return Chart{}, fmt.Errorf("%w: archive is not a gzipped chart", ErrInvalidChart)
+		}
+		chart.Archive = data
+	}
+	if (chart.Path == "") == (chart.Archive == nil) {
+		return Chart{}, fmt.Errorf("%w: set exactly one of path and archive", ErrInvalidChart)
+	}
+	if strings.HasPrefix(chart.Path, "-") {
+		return Chart{}, fmt.Errorf("%w: path must not start with \"-\"", ErrInvalidChart)
+	}
+	return chart, nil
+}
+
+var releaseNameInvalid = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ReleaseName derives a release name from an application name and deploy
+// ID. Helm limits release names to 53 lowercase DNS characters.
+func ReleaseName(application string, deployID uint64) string {
+	suffix := strconv.FormatUint(deployID, 36)
+	name := releaseNameInvalid.ReplaceAllString(strings.ToLower(application), "-")
+	name = strings.Trim(name, "-")
+	if limit := 52 - len(suffix); len(name) > limit {
+		name = strings.TrimRight(name[:limit], "-")
+	}
+	if name == "" {
+		return "app-" + suffix
+	}
+	return name + "-" + suffix
+}
+
+// ReleaseStatus maps a helm release status and the readiness of the
+// release's workloads to a deployment status. A deployed release is only
+// running once its workloads are ready.
+func ReleaseStatus(status string, readiness Readiness) string {
+	switch status {
+	case ReleaseDeployed:
+		return readiness.Status()
+	case ReleaseFailed, ReleaseUninstalled:
+		return StatusFailed
+	}
+	return StatusStarting
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseChart(t *testing.T) {
+	archive := base64.StdEncoding.EncodeToString([]byte{0x1f, 0x8b, 0x08, 0x00})
+	chart, err := ParseChart("archive: |\n  " + archive + "\nvalues:\n  replicaCount: 2\n")
+	if err != nil {
+		t.Fatalf("ParseChart() error = %v", err)
+	}
+	if len(chart.Archive) != 4 || chart.Path != "" || chart.Values["replicaCount"] != 2 {
+		t.Fatalf("chart = %+v", chart)
+	}
+	chart, err = ParseChart("path: /srv/charts/web\n")
+	if err != nil || chart.Path != "/srv/charts/web" {
+		t.Fatalf("chart = %+v, error = %v", chart, err)
+	}
+
+	for _, content := range []string{
+		"",
+		"values: {}",
+		"path: /srv/charts/web\narchive: " + archive,
+		"archive: not-base64!",
+		"archive: " + base64.StdEncoding.EncodeToString([]byte("plain text")),
+		"path: [",
+		"path: --post-renderer=/bin/sh",
+	} {
+		if _, err := ParseChart(content); !errors.Is(err, ErrInvalidChart) {
+			t.Errorf("ParseChart(%q) error = %v, want %v", content, err, ErrInvalidChart)
+		}
+	}
+}
+
+func TestReleaseName(t *testing.T) {
+	if got := ReleaseName("My_Web App", 35); got != "my-web-app-z" {
+		t.Fatalf("ReleaseName() = %q", got)
+	}
+	if got := ReleaseName("___", 36); got != "app-10" {
+		t.Fatalf("ReleaseName() = %q", got)
+	}
+	long := ReleaseName(strings.Repeat("a", 80), 1<<63)
+	if len(long) > 53 || !strings.HasSuffix(long, "-"+"1y2p0ij32e8e8") {
+		t.Fatalf("ReleaseName() = %q (%d)", long, len(long))
+	}
+}
+
+func TestReleaseStatus(t *testing.T) {
+	cases := []struct {
+		status    string
+		readiness Readiness
+		want      string
+	}{
+		{ReleaseDeployed, Readiness{Ready: true}, StatusRunning},
+		{ReleaseDeployed, Readiness{}, StatusStarting},
+		{ReleaseDeployed, Readiness{Failed: true}, StatusFailed},
+		{ReleaseFailed, Readiness{Ready: true}, StatusFailed},
+		{ReleaseUninstalled, Readiness{}, StatusFailed},
+		{ReleasePendingUpgrade, Readiness{Ready: true}, StatusStarting},
+	}
+	for _, tc := range cases {
+		if got := ReleaseStatus(tc.status, tc.readiness); got != tc.want {
+			t.Errorf("ReleaseStatus(%q, %+v) = %q, want %q", tc.status, tc.readiness, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Application types. Compose applications run on servers, the others on
+// clusters.
+const (
+	AppTypeCompose     = "compose"
+	AppTypeK8sManifest = "k8s_manifest"
+	AppTypeHelmChart   = "helm_chart"
+)
+
+// Cluster is a registered Kubernetes cluster. UpdatedAt changes whenever the
+// kubeconfig may have, so clients built from it can be cached by it.
+type Cluster struct {
+	ID         uint
+	Name       string
+	Server     string
+	Namespace  string
+	Kubeconfig string
+	UpdatedAt  time.Time
+}
+
+// Object is a Kubernetes object applied for a deployment.
+type Object struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Resource  string `json:"resource"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (o Object) identity() Object {
+	return Object{Group: o.Group, Kind: o.Kind, Namespace: o.Namespace, Name: o.Name}
+}
+
+// Readiness is the state of the workloads of a cluster deployment.
+type Readiness struct {
+	Ready   bool
+	Failed  bool
+	Message string
+}
+
+// Status maps readiness to a deployment status.
+func (r Readiness) Status() string {
+	switch {
+	case r.Ready:
+		return StatusRunning
+	case r.Failed:
+		return StatusFailed
+	}
+	return StatusStarting
+}
+
+// ClusterRuntime deploys manifests to registered clusters.
+type ClusterRuntime interface {
+	Cluster(context.Context, uint) (Cluster, error)
+	// Apply server-side applies manifest and returns the applied objects.
+	// Namespaced objects without a namespace go to namespace.
+	Apply(ctx context.Context, cluster Cluster, manifest, namespace string) ([]Object, error)
+	Readiness(context.Context, Cluster, []Object) (Readiness, error)
+	Delete(context.Context, Cluster, []Object) error
+
+	// InstallChart installs or upgrades release and returns the objects of
+	// its manifest.
+	InstallChart(context.Context, Cluster, Release) ([]Object, error)
+	// RollbackChart rolls release back to its previous revision.
+	RollbackChart(context.Context, Cluster, Release) error
+	UninstallChart(context.Context, Cluster, Release) error
+	// ChartStatus returns the helm status of release.
+	ChartStatus(context.Context, Cluster, Release) (string, error)
+}
+
+// Prune returns the objects of previous that are not in current, in their
+// original order. Objects are compared without their version, so moving a
+// manifest to a newer API version does not delete the object.
+func Prune(previous, current []Object) []Object {
+	keep := make(map[Object]bool, len(current))
+	for _, object := range current {
+		keep[object.identity()] = true
+	}
+	var result []Object
+	for _, object := range previous {
+		if !keep[object.identity()] {
+			result = append(result, object)
+		}
+	}
+	return result
+}
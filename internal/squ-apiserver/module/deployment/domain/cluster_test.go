@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPruneIgnoresVersion(t *testing.T) {
+	previous := []Object{
+		{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "shop", Name: "web"},
+		{Group: "autoscaling", Version: "v1", Resource: "horizontalpodautoscalers", Kind: "HorizontalPodAutoscaler", Namespace: "shop", Name: "web"},
+		{Version: "v1", Resource: "services", Kind: "Service", Namespace: "shop", Name: "web"},
+	}
+	current := []Object{
+		{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "shop", Name: "web"},
+		{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers", Kind: "HorizontalPodAutoscaler", Namespace: "shop", Name: "web"},
+	}
+
+	got := Prune(previous, current)
+	if want := previous[2:]; !slices.Equal(got, want) {
+		t.Fatalf("Prune() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadinessStatus(t *testing.T) {
+	cases := map[string]Readiness{
+		StatusRunning:  {Ready: true},
+		StatusFailed:   {Failed: true},
+		StatusStarting: {Message: "1 of 2 replicas available"},
+	}
+	for want, readiness := range cases {
+		if got := readiness.Status(); got != want {
+			t.Errorf("%+v.Status() = %q, want %q", readiness, got, want)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/loader"
+	"github.com/compose-spec/compose-go/types"
+)
+
+// Conflict kinds.
+const (
+	ConflictContainer = "container"
+	ConflictPort      = "port"
+	ConflictVolume    = "volume"
+	ConflictNetwork   = "network"
+)
+
+// Conflict is a compose conflict with another deployment on the same server,
+// as the deploy check would report it. DeploymentID is zero when the
+// conflict is with something already on the server's host, such as a
+// process listening on a port. Resource names what both claim.
+type Conflict struct {
+	DeploymentID uint
+	Application  string
+	Kind         string
+	Resource     string
+	Reason       string
+}
+
+// PortBinding is a range of host ports a compose service publishes. An empty
+// IP binds every address.
+type PortBinding struct {
+	Service  string
+	IP       string
+	Protocol string
+	Start    int
+	End      int
+}
+
+func (p PortBinding) String() string {
+	port := strconv.Itoa(p.Start)
+	if p.End != p.Start {
+		port += "-" + strconv.Itoa(p.End)
+	}
+	if p.IP != "" {
+		port = net.JoinHostPort(p.IP, port)
+	}
+	return port + "/" + p.Protocol
+}
+
+func (p PortBinding) overlaps(other PortBinding) bool {
+	return p.Protocol == other.Protocol && p.Start <= other.End && other.Start <= p.End &&
+		addressesOverlap(p.IP, other.IP)
+}
+
+// ComposeResources are the host resources a compose project claims.
+// Volumes and Networks are the names the project uses, which are what
+// other deployments are compared by. NamedVolumes and NamedNetworks are
+// set with an explicit name and are not scoped to the project on the host;
+// the external ones must already exist there.
+type ComposeResources struct {
+	Containers       []string
+	Ports            []PortBinding
+	Volumes          []string
+	Networks         []string
+	NamedVolumes     []string
+	NamedNetworks    []string
+	ExternalVolumes  []string
+	ExternalNetworks []string
+}
+
+// HostResources are what an agent reports about its host: listening sockets
+// and existing docker containers, volumes and networks.
+type HostResources struct {
+	Listeners  []Listener `json:"listeners"`
+	Containers []string   `json:"containers"`
+	Volumes    []string   `json:"volumes"`
+	Networks   []string   `json:"networks"`
+}
+
+type Listener struct {
+	Protocol string `json:"protocol"`
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+}
+
+// ParseComposeResources loads content with compose-go, interpolating env.
+func ParseComposeResources(content string, env map[string]string) (ComposeResources, error) {
+	project, err := loader.LoadWithContext(context.Background(),
+		types.ConfigDetails{
+			WorkingDir:  "/",
+			ConfigFiles: []types.ConfigFile{{Filename: "docker-compose.yml", Content: []byte(content)}},
+			Environment: env,
+		},
+		func(options *loader.Options) {
+			options.SetProjectName("squirrel", true)
+			options.SkipNormalization = true
+			options.SkipConsistencyCheck = true
+			options.SkipResolveEnvironment = true
+		},
+	)
+	if err != nil {
+		return ComposeResources{}, fmt.Errorf("failed to parse compose content: %w", err)
+	}
+	var result ComposeResources
+	volumes := map[string]bool{}
+	for _, service := range project.Services {
+		if service.ContainerName != "" {
+			result.Containers = append(result.Containers, service.ContainerName)
+		}
+		for _, port := range service.Ports {
+			binding, ok, err := portBinding(service.Name, port)
+			if err != nil {
+				return ComposeResources{}, err
+			}
+			if ok {
+				result.Ports = append(result.Ports, binding)
+			}
+		}
+		for _, volume := range service.Volumes {
+			if volume.Type == types.VolumeTypeVolume && volume.Source != "" {
+				volumes[volume.Source] = true
+			}
+		}
+	}
+	for key, volume := range project.Volumes {
+		volumes[key] = true
+		switch {
+		case volume.External.External:
+			result.ExternalVolumes = append(result.ExternalVolumes, externalName(key, volume.Name, volume.External.Name))
+		case volume.Name != "":
+			result.NamedVolumes = append(result.NamedVolumes, volume.Name)
+		}
+	}
+	for key, network := range project.Networks {
+		result.Networks = append(result.Networks, key)
+		switch {
+		case network.External.External:
+			result.ExternalNetworks = append(result.ExternalNetworks, externalName(key, network.Name, network.External.Name))
+		case network.Name != "":
+			result.NamedNetworks = append(result.NamedNetworks, network.Name)
+		}
+	}
+	for name := range volumes {
+		result.Volumes = append(result.Volumes, name)
+	}
+	result.sort()
+	return result, nil
+}
+
+func (r *ComposeResources) sort() {
+	for _, values := range [][]string{r.Containers, r.Volumes, r.Networks, r.NamedVolumes,
+		r.NamedNetworks, r.ExternalVolumes, r.ExternalNetworks} {
+		sort.Strings(values)
+	}
+	sort.SliceStable(r.Ports, func(i, j int) bool {
+		if r.Ports[i].Start != r.Ports[j].Start {
+			return r.Ports[i].Start < r.Ports[j].Start
+		}
+		return r.Ports[i].Protocol < r.Ports[j].Protocol
+	})
+}
+
+// portBinding reads the published side of a port; ok is false when docker
+// picks an ephemeral host port.
+func portBinding(service string, port types.ServicePortConfig) (PortBinding, bool, error) {
+	if port.Published == "" {
+		return PortBinding{}, false, nil
+	}
+	binding := PortBinding{Service: service, IP: port.HostIP, Protocol: strings.ToLower(port.Protocol)}
+	if binding.Protocol == "" {
+		binding.Protocol = "tcp"
+	}
+	start, end, isRange := strings.Cut(port.Published, "-")
+	var err error
+	if binding.Start, err = strconv.Atoi(start); err != nil {
+		return PortBinding{}, false, fmt.Errorf("service %s: invalid published port %q", service, port.Published)
+	}
+	binding.End = binding.Start
+	if isRange {
+		if binding.End, err = strconv.Atoi(end); err != nil || binding.End < binding.Start {
+			return PortBinding{}, false, fmt.Errorf("service %s: invalid published port %q", service, port.Published)
+		}
+	}
+	return binding, true, nil
+}
+
+func externalName(key, name, external string) string {
+	switch {
+	case name != "":
+		return name
+	case external != "":
+		return external
+	default:
+		return key
+	}
+}
+
+// Conflicts compares the resources with another deployment's. Conflicts
+// only carry the kind, the resource and the reason; the caller names the
+// deployment.
+func (r ComposeResources) Conflicts(other ComposeResources) []Conflict {
+	var result []Conflict
+	for _, name := range intersection(r.Containers, other.Containers) {
+		result = append(result, Conflict{Kind: ConflictContainer, Resource: name,
+			Reason: fmt.Sprintf("container name %s is already used", name)})
+	}
+	for _, port := range r.Ports {
+		for _, taken := range other.Ports {
+			if port.overlaps(taken) {
+				result = append(result, Conflict{Kind: ConflictPort, Resource: port.String(),
+					Reason: fmt.Sprintf("service %s publishes %s, which overlaps %s of service %s", port.Service, port, taken, taken.Service)})
+				break
+			}
+		}
+	}
+	for _, name := range intersection(r.Volumes, other.Volumes) {
+		result = append(result, Conflict{Kind: ConflictVolume, Resource: name,
+			Reason: fmt.Sprintf("volume %s is already used", name)})
+	}
+	for _, name := range intersection(r.Networks, other.Networks) {
+		result = append(result, Conflict{Kind: ConflictNetwork, Resource: name,
+			Reason: fmt.Sprintf("network %s is already used", name)})
+	}
+	return result
+}
+
+// HostConflicts compares the resources with what is on the host: sockets
+// listening on a published port, containers with a requested container name,
+// explicitly named volumes and networks that already exist and external
+// ones that do not.
+func (r ComposeResources) HostConflicts(host HostResources) []Conflict {
+	var result []Conflict
+	for _, name := range intersection(r.Containers, host.Containers) {
+		result = append(result, Conflict{Kind: ConflictContainer, Resource: name,
+			Reason: fmt.Sprintf("a container named %s exists on the host", name)})
+	}
+	for _, port := range r.Ports {
+		for _, listener := range host.Listeners {
+			taken := PortBinding{IP: listener.IP, Protocol: listener.Protocol, Start: listener.Port, End: listener.Port}
+			if port.overlaps(taken) {
+				result = append(result, Conflict{Kind: ConflictPort, Resource: port.String(),
+					Reason: fmt.Sprintf("service %s publishes %s, but %s is already listening on the host", port.Service, port, taken)})
+				break
+			}
+		}
+	}
+	for _, name := range intersection(r.NamedVolumes, host.Volumes) {
+		result = append(result, Conflict{Kind: ConflictVolume, Resource: name,
+			Reason: fmt.Sprintf("volume %s exists on the host; mark it external to use it", name)})
+	}
+	for _, name := range difference(r.ExternalVolumes, host.Volumes) {
+		result = append(result, Conflict{Kind: ConflictVolume, Resource: name,
+			Reason: fmt.Sprintf("external volume %s does not exist on the host", name)})
+	}
+	for _, name := range intersection(r.NamedNetworks, host.Networks) {
+		result = append(result, Conflict{Kind: ConflictNetwork, Resource: name,
+			Reason: fmt.Sprintf("network %s exists on the host; mark it external to use it", name)})
+	}
+	for _, name := range difference(r.ExternalNetworks, host.Networks) {
+		result = append(result, Conflict{Kind: ConflictNetwork, Resource: name,
+			Reason: fmt.Sprintf("external network %s does not exist on the host", name)})
+	}
+	return result
+}
+
+// addressesOverlap reports whether binding both addresses could collide.
+// Empty and unspecified addresses bind every address of the host.
+func addressesOverlap(left, right string) bool {
+	if unspecified(left) || unspecified(right) {
+		return true
+	}
+	leftIP, rightIP := net.ParseIP(left), net.ParseIP(right)
+	if leftIP == nil || rightIP == nil {
+		return left == right
+	}
+	return leftIP.Equal(rightIP)
+}
+
+func unspecified(address string) bool {
+	if address == "" {
+		return true
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsUnspecified()
+}
+
+func intersection(left, right []string) []string {
+	var result []string
+	for _, value := range left {
+		if slices.Contains(right, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func difference(left, right []string) []string {
+	var result []string
+	for _, value := range left {
+		if !slices.Contains(right, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseComposeResources(t *testing.T) {
+	resources, err := ParseComposeResources(`services:
+  web:
+    image: nginx
+    container_name: web
+    ports:
+      - "127.0.0.1:8080:80"
+      - "9000-9001:9000-9001/udp"
+      - "3000"
+      - target: 443
+        published: "${HTTPS_PORT}"
+        host_ip: "::1"
+    volumes: ["data:/data", "./conf:/etc/nginx", "/srv:/srv"]
+volumes:
+  data: {}
+  shared: {external: true, name: team-shared}
+  fixed: {name: fixed-name}
+networks:
+  edge: {external: true}
+  private: {}
+`, map[string]string{"HTTPS_PORT": "8443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ComposeResources{
+		Containers: []string{"web"},
+		Ports: []PortBinding{
+			{Service: "web", IP: "127.0.0.1", Protocol: "tcp", Start: 8080, End: 8080},
+			{Service: "web", IP: "::1", Protocol: "tcp", Start: 8443, End: 8443},
+			{Service: "web", Protocol: "udp", Start: 9000, End: 9000},
+			{Service: "web", Protocol: "udp", Start: 9001, End: 9001},
+		},
+		Volumes:          []string{"data", "fixed", "shared"},
+		Networks:         []string{"edge", "private"},
+		NamedVolumes:     []string{"fixed-name"},
+		ExternalVolumes:  []string{"team-shared"},
+		ExternalNetworks: []string{"edge"},
+	}
+	if !reflect.DeepEqual(resources, want) {
+		t.Fatalf("resources = %+v\nwant        %+v", resources, want)
+	}
+}
+
+func TestComposeConflicts(t *testing.T) {
+	parse := func(content string) ComposeResources {
+		t.Helper()
+		resources, err := ParseComposeResources(content, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resources
+	}
+	tests := []struct {
+		name     string
+		request  string
+		existing string
+		want     []string
+	}{
+		{"different addresses", "services:\n  a:\n    ports: ['127.0.0.1:80:80']", "services:\n  b:\n    ports: ['127.0.0.2:80:80']", nil},
+		{"wildcard address", "services:\n  a:\n    ports: ['127.0.0.1:80:80']", "services:\n  b:\n    ports: ['80:8080']", []string{"127.0.0.1:80/tcp"}},
+		{"other protocol", "services:\n  a:\n    ports: ['53:53/udp']", "services:\n  b:\n    ports: ['53:53']", nil},
+		{"range", "services:\n  a:\n    ports: [{target: 80, published: '8000-8010'}]", "services:\n  b:\n    ports: ['8005:80']", []string{"8000-8010/tcp"}},
+		{"long syntax", "services:\n  a:\n    ports: [{target: 80, published: 8080}]", "services:\n  b:\n    ports: ['8080:81']", []string{"8080/tcp"}},
+		{"container and volume", "services:\n  a:\n    container_name: db\n    volumes: ['data:/a']", "services:\n  b:\n    container_name: db\n    volumes: [{type: volume, source: data, target: /b}]", []string{"db", "data"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var resources []string
+			for _, conflict := range parse(test.request).Conflicts(parse(test.existing)) {
+				resources = append(resources, conflict.Resource)
+			}
+			if !reflect.DeepEqual(resources, test.want) {
+				t.Fatalf("conflicts = %v, want %v", resources, test.want)
+			}
+		})
+	}
+}
+
+func TestHostConflicts(t *testing.T) {
+	resources, err := ParseComposeResources(`services:
+  web:
+    image: nginx
+    container_name: proxy
+    ports: ["80:80", "127.0.0.1:8080:8080", "[::1]:9090:9090"]
+volumes:
+  cache: {name: cache}
+  data: {external: true}
+networks:
+  edge: {external: true}
+  backend: {name: backend}
+`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := HostResources{
+		Listeners: []Listener{
+			{Protocol: "tcp", IP: "0.0.0.0", Port: 80},
+			{Protocol: "tcp", IP: "10.0.0.5", Port: 8080},
+			{Protocol: "tcp", IP: "::", Port: 9090},
+			{Protocol: "udp", IP: "0.0.0.0", Port: 80},
+		},
+		Containers: []string{"proxy"},
+		Volumes:    []string{"cache"},
+		Networks:   []string{"bridge", "backend"},
+	}
+	var got []string
+	for _, conflict := range resources.HostConflicts(host) {
+		got = append(got, conflict.Kind+" "+conflict.Resource)
+	}
+	want := []string{"container proxy", "port 80/tcp", "port [::1]:9090/tcp", "volume cache", "volume data", "network backend", "network edge"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("conflicts = %v\nwant        %v", got, want)
+	}
+}
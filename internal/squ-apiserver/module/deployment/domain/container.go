@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// StatusDegraded is reported by agents when some containers of a deployment
+// run while others crash, restart or report unhealthy.
+const StatusDegraded = "degraded"
+
+type Port struct {
+	HostIP        string `json:"host_ip,omitempty"`
+	HostPort      int    `json:"host_port"`
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol"`
+}
+
+// Container is the last reported state of one container of a deployment.
+type Container struct {
+	DeploymentID uint
+	Service      string
+	Name         string
+	ContainerID  string
+	State        string
+	Health       string
+	RestartCount int
+	ExitCode     int
+	Image        string
+	ImageDigest  string
+	Ports        []Port
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+	UpdatedAt    time.Time
+}
+
+// Uptime is how long a running container has been up at now; zero for any
+// other state.
+func (c Container) Uptime(now time.Time) time.Duration {
+	if c.State != "running" || c.StartedAt == nil || now.Before(*c.StartedAt) {
+		return 0
+	}
+	return now.Sub(*c.StartedAt)
+}
+
+type ContainerRepository interface {
+	ListContainers(context.Context, uint) ([]Container, error)
+	// ReplaceContainers stores containers as the complete set of the
+	// deployment's containers.
+	ReplaceContainers(ctx context.Context, deploymentID uint, containers []Container) error
+}
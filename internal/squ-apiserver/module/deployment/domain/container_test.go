@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContainerUptime(t *testing.T) {
+	started := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	now := started.Add(90 * time.Minute)
+	if got := (Container{State: "running", StartedAt: &started}).Uptime(now); got != 90*time.Minute {
+		t.Fatalf("uptime = %v", got)
+	}
+	if got := (Container{State: "exited", StartedAt: &started}).Uptime(now); got != 0 {
+		t.Fatalf("uptime of exited container = %v", got)
+	}
+	if got := (Container{State: "running"}).Uptime(now); got != 0 {
+		t.Fatalf("uptime without start time = %v", got)
+	}
+}
@@ -3,17 +3,37 @@ package domain
 import (
 	"context"
 	"time"
+
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
+// Deployment is an application deployed either to a server, through its
+// agent, or to a Kubernetes cluster. Cluster deployments have a ClusterID
+// instead of a ServerID and remember the objects they applied in Objects.
+// Helm chart deployments also have the name of their Release. Secrets are
+// the names of secrets injected into the env of compose deployments.
+// Health is the health check result of compose deployments.
 type Deployment struct {
 	ID            uint
 	CreatedAt     time.Time
 	ServerID      uint
+	ClusterID     uint
+	Namespace     string
+	Release       string
 	ApplicationID uint
 	Status        string
 	DeployID      uint64
 	Content       string
 	Env           []map[string]string
+	Secrets       []string
+	Objects       []Object
+	Health        Health
+	// Reconcile lets the agent restore the deployment's desired state when
+	// it stops or starts behind the apiserver's back.
+	Reconcile bool
+	// Files is the state of the compose deployment's files its agent last
+	// reported.
+	Files FileState
 }
 
 type Application struct {
@@ -23,6 +43,9 @@ type Application struct {
 	Type        string
 	Content     string
 	Version     string
+	HealthCheck *HealthCheck
+	RegistryID  uint
+	BundleID    uint
 }
 
 type Server struct {
@@ -39,6 +62,9 @@ type Repository interface {
 	Add(context.Context, *Deployment) error
 	Update(context.Context, *Deployment) error
 	UpdateStatus(context.Context, uint64, string) error
+	UpdateHealth(context.Context, uint64, Health) error
+	// UpdateFiles stores the file state an agent reported for a deploy ID.
+	UpdateFiles(context.Context, uint64, FileState) error
 }
 
 type ApplicationReader interface {
@@ -47,10 +73,12 @@ type ApplicationReader interface {
 
 type ServerReader interface {
 	Get(context.Context, uint) (Server, error)
+	Select(context.Context, serverDomain.Target) ([]Server, error)
 }
 
 type AgentClient interface {
 	Post(context.Context, Server, string, any) error
+	Get(ctx context.Context, server Server, path string, result any) error
 }
 
 type IDGenerator interface {
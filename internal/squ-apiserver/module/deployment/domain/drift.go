@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Drift actions agents report. DriftGiveUp means the agent stopped
+// correcting a deployment that kept drifting.
+const (
+	DriftStart  = "start"
+	DriftStop   = "stop"
+	DriftGiveUp = "give_up"
+)
+
+// DriftEvent is one corrective action an agent took because a reconciled
+// deployment was observed in another state than its desired one. Error is
+// empty when the action succeeded.
+type DriftEvent struct {
+	ID           uint
+	DeploymentID uint
+	DeployID     uint64
+	Observed     string
+	Desired      string
+	Action       string
+	Attempt      int
+	Error        string
+	CreatedAt    time.Time
+}
+
+type DriftRepository interface {
+	SetReconcile(ctx context.Context, id uint, reconcile bool) error
+	AddDriftEvent(context.Context, *DriftEvent) error
+	// ListDriftEvents returns the newest events of a deployment first.
+	ListDriftEvents(ctx context.Context, deploymentID uint, limit int) ([]DriftEvent, error)
+}
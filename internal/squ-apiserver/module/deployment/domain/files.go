@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sort"
+	"time"
+)
+
+// How the compose file on an agent differs from the recorded content.
+const (
+	// FilesMissing means the agent has no compose file for the deployment.
+	FilesMissing = "missing"
+	// FilesEdited means the file was changed on the host after the agent
+	// wrote it.
+	FilesEdited = "edited"
+	// FilesOutdated means the agent still has what it was last sent, but the
+	// record changed since, for example by an update that was never
+	// deployed.
+	FilesOutdated = "outdated"
+)
+
+// FileState is what an agent last reported about the files of a compose
+// deployment: the SHA-256 of its compose file and env file on disk and as the
+// agent last wrote them. A missing file has an empty hash. CheckedAt is zero
+// until the first report.
+type FileState struct {
+	ComposeHash       string
+	EnvHash           string
+	PushedComposeHash string
+	PushedEnvHash     string
+	CheckedAt         time.Time
+}
+
+// FileDrift says how the files on the agent differ from the deployment
+// record. Compose is empty, FilesMissing, FilesEdited or FilesOutdated. Env
+// is empty or FilesEdited; env values stay on the host, so only edits there
+// are detected.
+type FileDrift struct {
+	Compose string
+	Env     string
+}
+
+func (d FileDrift) Drifted() bool { return d.Compose != "" || d.Env != "" }
+
+// HostFiles are the compose file of a deployment as its agent has it and the
+// SHA-256 of each value of the env file.
+type HostFiles struct {
+	Content    string
+	EnvDigests map[string]string
+	State      FileState
+}
+
+// FileReport is the file drift of a deployment with a diff from the recorded
+// version to the host version.
+type FileReport struct {
+	State FileState
+	Drift FileDrift
+	Diff  string
+}
+
+// ContentHash returns the hash an agent reports for a file with content.
+func ContentHash(content string) string {
+	if content == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// FileDrift compares the files last reported by the agent with the record.
+// Env edits are only detected once the agent recorded what it wrote.
+func (d Deployment) FileDrift() FileDrift {
+	files := d.Files
+	if d.ClusterID != 0 || files.CheckedAt.IsZero() {
+		return FileDrift{}
+	}
+	var drift FileDrift
+	switch {
+	case files.ComposeHash == "":
+		drift.Compose = FilesMissing
+	case files.ComposeHash == ContentHash(d.Content):
+	case files.ComposeHash != files.PushedComposeHash:
+		drift.Compose = FilesEdited
+	default:
+		drift.Compose = FilesOutdated
+	}
+	if files.PushedComposeHash != "" && files.EnvHash != files.PushedEnvHash {
+		drift.Env = FilesEdited
+	}
+	return drift
+}
+
+// FilesDiff renders a unified diff from the recorded content and env of
+// deployment to host. Env values are compared by their digests, and secret
+// values, which the apiserver only resolves for deploys, are not compared.
+func FilesDiff(deployment Deployment, host HostFiles) string {
+	recorded := map[string]string{}
+	for _, values := range deployment.Env {
+		for key, value := range values {
+			sum := sha256.Sum256([]byte(value))
+			recorded[key] = digestLine(key, hex.EncodeToString(sum[:]))
+		}
+	}
+	for _, name := range deployment.Secrets {
+		recorded[name] = name + "=<secret>"
+	}
+	current := map[string]string{}
+	for key, digest := range host.EnvDigests {
+		current[key] = digestLine(key, digest)
+		if slices.Contains(deployment.Secrets, key) {
+			current[key] = key + "=<secret>"
+		}
+	}
+	return unifiedDiff("recorded content", "host content", splitLines(deployment.Content), splitLines(host.Content)) +
+		unifiedDiff("recorded env", "host env", sortedValues(recorded), sortedValues(current))
+}
+
+func digestLine(key, digest string) string {
+	return key + "=sha256:" + digest[:min(len(digest), 12)]
+}
+
+func sortedValues(values map[string]string) []string {
+	lines := make([]string, 0, len(values))
+	for _, line := range values {
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	return lines
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFileDrift(t *testing.T) {
+	content := "services: {}\n"
+	recorded, other := ContentHash(content), ContentHash("services:\n  web: {}\n")
+	tests := []struct {
+		name  string
+		files FileState
+		want  FileDrift
+	}{
+		{"not reported", FileState{}, FileDrift{}},
+		{"in sync", FileState{ComposeHash: recorded, PushedComposeHash: recorded, EnvHash: "e", PushedEnvHash: "e"}, FileDrift{}},
+		{"adopted", FileState{ComposeHash: recorded, PushedComposeHash: other}, FileDrift{}},
+		{"missing", FileState{PushedComposeHash: recorded}, FileDrift{Compose: FilesMissing}},
+		{"edited", FileState{ComposeHash: other, PushedComposeHash: recorded}, FileDrift{Compose: FilesEdited}},
+		{"outdated", FileState{ComposeHash: other, PushedComposeHash: other}, FileDrift{Compose: FilesOutdated}},
+		{"env edited", FileState{ComposeHash: recorded, PushedComposeHash: recorded, EnvHash: "f", PushedEnvHash: "e"}, FileDrift{Env: FilesEdited}},
+		{"env not recorded", FileState{ComposeHash: recorded, EnvHash: "f"}, FileDrift{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deployment := Deployment{Content: content, Files: test.files}
+			if test.name != "not reported" {
+				deployment.Files.CheckedAt = time.Now()
+			}
+			if got := deployment.FileDrift(); got != test.want {
+				t.Fatalf("drift = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFilesDiff(t *testing.T) {
+	deployment := Deployment{
+		Content: "services:\n  web:\n    image: nginx:1.25\n",
+		Env:     []map[string]string{{"MODE": "prod"}, {"EMPTY": ""}},
+		Secrets: []string{"TOKEN"},
+	}
+	host := HostFiles{
+		Content: "services:\n  web:\n    image: nginx:1.27\n",
+		EnvDigests: map[string]string{
+			"MODE":  "0000000000000000000000000000000000000000000000000000000000000000",
+			"EMPTY": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			"TOKEN": "1111111111111111111111111111111111111111111111111111111111111111",
+		},
+	}
+	want := `--- recorded content
++++ host content
+@@ -1,3 +1,3 @@
+ services:
+   web:
+-    image: nginx:1.25
++    image: nginx:1.27
+--- recorded env
++++ host env
+@@ -1,3 +1,3 @@
+ EMPTY=sha256:e3b0c44298fc
+-MODE=sha256:6754af9632a2
++MODE=sha256:000000000000
+ TOKEN=<secret>
+`
+	if got := FilesDiff(deployment, host); got != want {
+		t.Fatalf("diff =\n%s\nwant\n%s", got, want)
+	}
+}
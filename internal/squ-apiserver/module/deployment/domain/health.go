@@ -0,0 +1,31 @@
+package domain
+
+// HealthCheck is what the agent verifies after it deployed a compose
+// application; see the application module for its rules.
+type HealthCheck struct {
+	Timeout int     `json:"timeout"`
+	Checks  []Check `json:"checks"`
+}
+
+type Check struct {
+	Type    string `json:"type"`
+	URL     string `json:"url,omitempty"`
+	Status  int    `json:"status,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// Health states of a deployment.
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// Health is the result of the health check of a compose deployment's last
+// deploy as reported by its agent. Status is empty without a health check,
+// otherwise starting, healthy or unhealthy. Message says why the check failed
+// and whether the agent restored the previous deployment.
+type Health struct {
+	Status  string
+	Message string
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// AgentError is an error response returned by an agent.
+type AgentError struct {
+	Code    int
+	Message string
+}
+
+func (e *AgentError) Error() string {
+	return fmt.Sprintf("agent error: code=%d, message=%s", e.Code, e.Message)
+}
+
+// ComposeLine is one line of compose output recorded by the agent.
+type ComposeLine struct {
+	Time   string `json:"time"`
+	Stream string `json:"stream"`
+	Text   string `json:"text"`
+}
+
+// ComposeRun is the output of the last compose action the agent ran for a
+// deployment.
+type ComposeRun struct {
+	DeployID   uint64        `json:"deploy_id"`
+	Action     string        `json:"action"`
+	Status     string        `json:"status"`
+	Error      string        `json:"error,omitempty"`
+	StartedAt  string        `json:"started_at"`
+	FinishedAt string        `json:"finished_at,omitempty"`
+	Truncated  bool          `json:"truncated,omitempty"`
+	Lines      []ComposeLine `json:"lines,omitempty"`
+}
+
+// AgentReader reads data from an agent. Errors reported by the agent are
+// returned as *AgentError.
+type AgentReader interface {
+	Get(ctx context.Context, server Server, path string, result any) error
+	// Stream opens a server-sent event stream; the caller closes it.
+	Stream(ctx context.Context, server Server, path string) (io.ReadCloser, error)
+}
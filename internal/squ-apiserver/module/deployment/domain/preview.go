@@ -0,0 +1,300 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Service changes of a preview.
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangeModified  = "modified"
+	ChangeUnchanged = "unchanged"
+)
+
+// interpolation matches the variables a compose file reads from the env
+// file; "$$" is an escaped dollar sign.
+var interpolation = regexp.MustCompile(`\$\$|\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ComposeState is a compose project as deployed or as proposed. EnvDigests
+// maps env keys to the SHA-256 of their values, which is how agents report
+// env. Secrets are the names of the secrets written to the env file.
+type ComposeState struct {
+	Content    string
+	EnvDigests map[string]string
+	Secrets    []string
+}
+
+// ListChange lists the entries added to and removed from a list.
+type ListChange struct {
+	Added   []string
+	Removed []string
+}
+
+// EnvChange lists env keys by what happened to them. Values are never part of
+// a preview.
+type EnvChange struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// ServiceChange is the impact of a proposal on one compose service. Image is
+// set when the image changes and Other names the remaining keys of the
+// service definition that change. Recreate reports whether docker compose up
+// would recreate the service's containers.
+type ServiceChange struct {
+	Name        string
+	Change      string
+	Recreate    bool
+	Image       *ImageChange
+	Ports       ListChange
+	Volumes     ListChange
+	Environment EnvChange
+	Other       []string
+}
+
+type ImageChange struct {
+	From string
+	To   string
+}
+
+// Preview is the impact of deploying a proposal over the deployed state.
+type Preview struct {
+	Services  []ServiceChange
+	Env       EnvChange
+	Secrets   ListChange
+	Conflicts []Conflict
+	Recreate  bool
+}
+
+// EnvDigests digests env the way agents report it.
+func EnvDigests(env []map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, item := range env {
+		for key, value := range item {
+			sum := sha256.Sum256([]byte(value))
+			result[key] = hex.EncodeToString(sum[:])
+		}
+	}
+	return result
+}
+
+// PreviewCompose compares a proposed compose project with the deployed one.
+// A service is recreated when its definition changes or when it reads an env
+// file variable that changes. Changed secret values cannot be seen, so
+// services reading a secret that stays referenced are not reported.
+func PreviewCompose(current, proposed ComposeState) (Preview, error) {
+	from, err := composeServices(current.Content)
+	if err != nil {
+		return Preview{}, fmt.Errorf("deployed content: %w", err)
+	}
+	to, err := composeServices(proposed.Content)
+	if err != nil {
+		return Preview{}, fmt.Errorf("proposed content: %w", err)
+	}
+	preview := Preview{
+		Env:     mapChange(current.EnvDigests, proposed.EnvDigests),
+		Secrets: listChange(current.Secrets, proposed.Secrets),
+	}
+	changedKeys := map[string]bool{}
+	for _, keys := range [][]string{preview.Env.Added, preview.Env.Removed, preview.Env.Changed,
+		preview.Secrets.Added, preview.Secrets.Removed} {
+		for _, key := range keys {
+			changedKeys[key] = true
+		}
+	}
+	names := make([]string, 0, len(from)+len(to))
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		before, existed := from[name]
+		after, exists := to[name]
+		change := ServiceChange{Name: name, Change: ChangeModified}
+		switch {
+		case !existed:
+			change.Change, change.Recreate = ChangeAdded, true
+			change.Image = &ImageChange{To: stringify(after["image"])}
+		case !exists:
+			change.Change, change.Recreate = ChangeRemoved, true
+			change.Image = &ImageChange{From: stringify(before["image"])}
+		default:
+			compareService(&change, before, after)
+			if change.Change == ChangeUnchanged && readsAny(after, changedKeys) {
+				change.Change, change.Recreate = ChangeModified, true
+			}
+		}
+		if change.Change != ChangeAdded {
+			change.Ports = listChange(stringList(before["ports"]), stringList(after["ports"]))
+			change.Volumes = listChange(stringList(before["volumes"]), stringList(after["volumes"]))
+			change.Environment = mapChange(environment(before["environment"]), environment(after["environment"]))
+		} else {
+			change.Ports.Added = stringList(after["ports"])
+			change.Volumes.Added = stringList(after["volumes"])
+			change.Environment.Added = sortedKeys(environment(after["environment"]))
+		}
+		preview.Recreate = preview.Recreate || change.Recreate
+		preview.Services = append(preview.Services, change)
+	}
+	return preview, nil
+}
+
+func compareService(change *ServiceChange, before, after map[string]any) {
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	for key := range keys {
+		if reflect.DeepEqual(before[key], after[key]) {
+			continue
+		}
+		switch key {
+		case "image":
+			change.Image = &ImageChange{From: stringify(before[key]), To: stringify(after[key])}
+		case "ports", "volumes", "environment":
+		default:
+			change.Other = append(change.Other, key)
+		}
+		change.Recreate = true
+	}
+	sort.Strings(change.Other)
+	if !change.Recreate {
+		change.Change = ChangeUnchanged
+	}
+}
+
+// readsAny reports whether a service definition interpolates one of keys.
+func readsAny(service map[string]any, keys map[string]bool) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	data, err := yaml.Marshal(service)
+	if err != nil {
+		return false
+	}
+	for _, match := range interpolation.FindAllStringSubmatch(string(data), -1) {
+		if keys[match[1]] {
+			return true
+		}
+	}
+	return false
+}
+
+func composeServices(content string) (map[string]map[string]any, error) {
+	var config struct {
+		Services map[string]map[string]any `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse compose content: %w", err)
+	}
+	return config.Services, nil
+}
+
+// environment reads the list and the map form of a service's environment.
+func environment(value any) map[string]string {
+	result := map[string]string{}
+	switch value := value.(type) {
+	case []any:
+		for _, item := range value {
+			key, env, _ := strings.Cut(stringify(item), "=")
+			result[key] = env
+		}
+	case map[string]any:
+		for key, env := range value {
+			result[key] = stringify(env)
+		}
+	}
+	return result
+}
+
+// stringList renders the short and the long syntax of ports and volumes.
+func stringList(value any) []string {
+	items, _ := value.([]any)
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, stringify(item))
+	}
+	return result
+}
+
+func stringify(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case map[string]any, []any:
+		data, _ := json.Marshal(value)
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func listChange(before, after []string) ListChange {
+	var result ListChange
+	for _, value := range after {
+		if !slices.Contains(before, value) && !slices.Contains(result.Added, value) {
+			result.Added = append(result.Added, value)
+		}
+	}
+	for _, value := range before {
+		if !slices.Contains(after, value) && !slices.Contains(result.Removed, value) {
+			result.Removed = append(result.Removed, value)
+		}
+	}
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	return result
+}
+
+func mapChange(before, after map[string]string) EnvChange {
+	var result EnvChange
+	for key, value := range after {
+		previous, ok := before[key]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, key)
+		case previous != value:
+			result.Changed = append(result.Changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			result.Removed = append(result.Removed, key)
+		}
+	}
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Changed)
+	return result
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPreviewCompose(t *testing.T) {
+	current := ComposeState{
+		Content: `services:
+  web:
+    image: nginx:1.25
+    ports: ["8080:80"]
+    environment:
+      MODE: prod
+  worker:
+    image: app:1
+    command: ["work", "--db", "${DB_URL}"]
+  cache:
+    image: redis:7
+`,
+		EnvDigests: EnvDigests([]map[string]string{{"DB_URL": "postgres://a", "TZ": "UTC"}}),
+		Secrets:    []string{"API_KEY"},
+	}
+	proposed := ComposeState{
+		Content: `services:
+  web:
+    image: nginx:1.27
+    ports: ["8080:80", "8443:443"]
+    environment: ["MODE=prod", "DEBUG=1"]
+  worker:
+    image: app:1
+    command: ["work", "--db", "${DB_URL}"]
+  cache:
+    image: redis:7
+  db:
+    image: postgres:16
+    volumes: ["data:/var/lib/postgresql/data"]
+`,
+		EnvDigests: EnvDigests([]map[string]string{{"DB_URL": "postgres://b", "TZ": "UTC"}}),
+		Secrets:    []string{"API_KEY", "DB_PASSWORD"},
+	}
+	preview, err := PreviewCompose(current, proposed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ServiceChange{
+		{Name: "cache", Change: ChangeUnchanged},
+		{Name: "db", Change: ChangeAdded, Recreate: true, Image: &ImageChange{To: "postgres:16"},
+			Ports: ListChange{Added: []string{}}, Volumes: ListChange{Added: []string{"data:/var/lib/postgresql/data"}},
+			Environment: EnvChange{Added: []string{}}},
+		{Name: "web", Change: ChangeModified, Recreate: true, Image: &ImageChange{From: "nginx:1.25", To: "nginx:1.27"},
+			Ports: ListChange{Added: []string{"8443:443"}}, Environment: EnvChange{Added: []string{"DEBUG"}}},
+		{Name: "worker", Change: ChangeModified, Recreate: true},
+	}
+	if !reflect.DeepEqual(preview.Services, want) {
+		t.Fatalf("services = %+v\nwant       %+v", preview.Services, want)
+	}
+	if !reflect.DeepEqual(preview.Env, EnvChange{Changed: []string{"DB_URL"}}) || !preview.Recreate {
+		t.Fatalf("env = %+v, recreate = %v", preview.Env, preview.Recreate)
+	}
+	if !reflect.DeepEqual(preview.Secrets, ListChange{Added: []string{"DB_PASSWORD"}}) {
+		t.Fatalf("secrets = %+v", preview.Secrets)
+	}
+
+	same, err := PreviewCompose(current, current)
+	if err != nil || same.Recreate {
+		t.Fatalf("preview of the deployed state = %+v, %v", same, err)
+	}
+	if _, err := PreviewCompose(current, ComposeState{Content: "services: ["}); err == nil {
+		t.Fatal("PreviewCompose() accepted invalid content")
+	}
+}
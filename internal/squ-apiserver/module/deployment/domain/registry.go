@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+// Registry holds the credentials of the private registry an application
+// pulls its images from.
+type Registry struct {
+	Server   string
+	Username string
+	Password string
+}
+
+// RegistryReader reads a registry with its password in plain text.
+type RegistryReader interface {
+	Get(ctx context.Context, id uint) (Registry, error)
+}
@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionDeploy   = "deploy"
+	RevisionRollback = "rollback"
+)
+
+// diffContext is the number of unchanged lines kept around each change.
+const diffContext = 3
+
+// maxDiffCells bounds the line matrix used by Diff. Larger inputs are shown
+// as a full replacement instead of a minimal diff.
+const maxDiffCells = 4 << 20
+
+// Revision is an immutable snapshot of a deployment's content, env and secret
+// references. Number
+// starts at 1 for each deployment and increases by one for every snapshot.
+type Revision struct {
+	ID           uint
+	DeploymentID uint
+	Number       uint
+	Reason       string
+	Author       string
+	Content      string
+	Env          []map[string]string
+	Secrets      []string
+	Diff         string
+	RollbackOf   uint
+	CreatedAt    time.Time
+}
+
+type RevisionRepository interface {
+	ListRevisions(context.Context, uint) ([]Revision, error)
+	GetRevision(ctx context.Context, deploymentID, number uint) (Revision, error)
+	// LatestRevision returns gorm.ErrRecordNotFound when the deployment has
+	// no history yet.
+	LatestRevision(context.Context, uint) (Revision, error)
+	// AddRevision assigns the next revision number and the creation time.
+	AddRevision(context.Context, *Revision) error
+}
+
+// SameState reports whether a deployment already has the content, env and
+// secrets of r.
+func (r Revision) SameState(deployment Deployment) bool {
+	return r.Content == deployment.Content &&
+		strings.Join(envLines(r.Env), "\n") == strings.Join(envLines(deployment.Env), "\n") &&
+		strings.Join(secretLines(r.Secrets), "\n") == strings.Join(secretLines(deployment.Secrets), "\n")
+}
+
+// Diff renders a unified diff from previous to current covering the content
+// and, when they changed, the env and secret names. An empty string means
+// nothing changed.
+func Diff(previous, current Revision) string {
+	from, to := "/dev/null", fmt.Sprintf("revision %d", current.Number)
+	if previous.Number > 0 {
+		from = fmt.Sprintf("revision %d", previous.Number)
+	}
+	var b strings.Builder
+	b.WriteString(unifiedDiff(from+" content", to+" content", splitLines(previous.Content), splitLines(current.Content)))
+	b.WriteString(unifiedDiff(from+" env", to+" env", envLines(previous.Env), envLines(current.Env)))
+	b.WriteString(unifiedDiff(from+" secrets", to+" secrets", secretLines(previous.Secrets), secretLines(current.Secrets)))
+	return b.String()
+}
+
+func splitLines(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(value, "\n"), "\n")
+}
+
+// envLines flattens env into sorted KEY=VALUE lines so that map ordering does
+// not show up as a change.
+func envLines(env []map[string]string) []string {
+	var lines []string
+	for _, values := range env {
+		for key, value := range values {
+			lines = append(lines, key+"="+value)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+func unifiedDiff(fromName, toName string, a, b []string) string {
+	lines := diffLines(a, b)
+	changed := false
+	for _, line := range lines {
+		if line.op != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(lines); {
+		// Find the next change and open a hunk diffContext lines before it.
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		begin := max(first-diffContext, start)
+		end := first
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].op == ' ' {
+				run++
+			}
+			if run == len(lines) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(lines))
+				break
+			}
+			end = run
+		}
+		aStart, bStart := 1, 1
+		for _, line := range lines[:begin] {
+			if line.op != '+' {
+				aStart++
+			}
+			if line.op != '-' {
+				bStart++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, line := range lines[begin:end] {
+			if line.op != '+' {
+				aCount++
+			}
+			if line.op != '-' {
+				bCount++
+			}
+		}
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, line := range lines[begin:end] {
+			out.WriteByte(line.op)
+			out.WriteString(line.text)
+			out.WriteByte('\n')
+		}
+		start = end
+	}
+	return out.String()
+}
+
+// diffLines aligns a and b on their longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	if len(a)*len(b) > maxDiffCells {
+		result := make([]diffLine, 0, len(a)+len(b))
+		for _, line := range a {
+			result = append(result, diffLine{'-', line})
+		}
+		for _, line := range b {
+			result = append(result, diffLine{'+', line})
+		}
+		return result
+	}
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	result := make([]diffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, diffLine{' ', a[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, diffLine{'-', a[i]})
+			i++
+		default:
+			result = append(result, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, diffLine{'+', b[j]})
+	}
+	return result
+}
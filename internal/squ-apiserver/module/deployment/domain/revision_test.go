@@ -0,0 +1,31 @@
+package domain
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	previous := Revision{Number: 1, Content: "a\nb\nc\nd\ne\nf\ng\nh\ni\n", Env: []map[string]string{{"A": "1"}}}
+	current := Revision{Number: 2, Content: "a\nb\nc\nd\nE\nf\ng\nh\ni\n", Env: []map[string]string{{"A": "1"}}}
+	want := "--- revision 1 content\n+++ revision 2 content\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n"
+	if got := Diff(previous, current); got != want {
+		t.Fatalf("diff = %q, want %q", got, want)
+	}
+
+	current = Revision{Number: 2, Content: previous.Content, Env: []map[string]string{{"A": "2"}}}
+	want = "--- revision 1 env\n+++ revision 2 env\n@@ -1,1 +1,1 @@\n-A=1\n+A=2\n"
+	if got := Diff(previous, current); got != want {
+		t.Fatalf("diff = %q, want %q", got, want)
+	}
+	current = Revision{Number: 2, Content: previous.Content, Env: previous.Env, Secrets: []string{"DB_PASSWORD", "API_KEY"}}
+	want = "--- revision 1 secrets\n+++ revision 2 secrets\n@@ -0,0 +1,2 @@\n+API_KEY\n+DB_PASSWORD\n"
+	if got := Diff(previous, current); got != want {
+		t.Fatalf("diff = %q, want %q", got, want)
+	}
+	if got := Diff(previous, previous); got != "" {
+		t.Fatalf("diff = %q, want empty", got)
+	}
+
+	want = "--- /dev/null content\n+++ revision 1 content\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Diff(Revision{}, Revision{Number: 1, Content: "x\ny"}); got != want {
+		t.Fatalf("diff = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+// Deployment statuses as reported by the agent. The agent reports "Failed"
+// with a capital letter, so compare them with strings.EqualFold.
+const (
+	StatusStarting = "starting"
+	StatusRunning  = "running"
+	StatusFailed   = "failed"
+)
+
+const (
+	RolloutRunning        = "running"
+	RolloutSucceeded      = "succeeded"
+	RolloutFailed         = "failed"
+	RolloutStopped        = "stopped"
+	RolloutRollingBack    = "rolling_back"
+	RolloutRolledBack     = "rolled_back"
+	RolloutRollbackFailed = "rollback_failed"
+	// RolloutInterrupted marks a rollout whose apiserver exited while it was
+	// still running.
+	RolloutInterrupted = "interrupted"
+)
+
+const (
+	StepPending        = "pending"
+	StepDeploying      = "deploying"
+	StepVerifying      = "verifying"
+	StepSucceeded      = "succeeded"
+	StepFailed         = "failed"
+	StepSkipped        = "skipped"
+	StepStopped        = "stopped"
+	StepRolledBack     = "rolled_back"
+	StepRollbackFailed = "rollback_failed"
+)
+
+// Rollout deploys one application to a set of servers in batches. With Canary
+// set, the first batch holds a single server.
+type Rollout struct {
+	ID            uint
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ApplicationID uint
+	Target        serverDomain.Target
+	BatchSize     int
+	Canary        bool
+	Pause         time.Duration
+	HealthTimeout time.Duration
+	AutoRollback  bool
+	Status        string
+	CurrentBatch  int
+	Author        string
+	Error         string
+	FinishedAt    *time.Time
+	Steps         []RolloutStep
+}
+
+// RolloutStep is the progress of a rollout on one server. PreviousRevision is
+// the deployment revision before the rollout touched it; zero means the
+// rollout created the deployment.
+type RolloutStep struct {
+	ServerID         uint   `json:"server_id"`
+	Batch            int    `json:"batch"`
+	Status           string `json:"status"`
+	DeploymentID     uint   `json:"deployment_id,omitempty"`
+	PreviousRevision uint   `json:"previous_revision,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// Active reports whether a rollout still has work in progress.
+func (r Rollout) Active() bool {
+	return r.Status == RolloutRunning || r.Status == RolloutRollingBack
+}
+
+// Batches returns the number of batches in the rollout.
+func (r Rollout) Batches() int {
+	batches := 0
+	for _, step := range r.Steps {
+		batches = max(batches, step.Batch+1)
+	}
+	return batches
+}
+
+// PlanSteps splits servers into batches of batchSize, preceded by a single
+// canary server when canary is set.
+func PlanSteps(servers []Server, batchSize int, canary bool) []RolloutStep {
+	steps := make([]RolloutStep, 0, len(servers))
+	batch, size := 0, 0
+	for _, server := range servers {
+		limit := batchSize
+		if canary && batch == 0 {
+			limit = 1
+		}
+		if size == limit {
+			batch, size = batch+1, 0
+		}
+		steps = append(steps, RolloutStep{ServerID: server.ID, Batch: batch, Status: StepPending})
+		size++
+	}
+	return steps
+}
+
+type RolloutRepository interface {
+	ListRollouts(ctx context.Context, applicationID uint) ([]Rollout, error)
+	GetRollout(context.Context, uint) (Rollout, error)
+	AddRollout(context.Context, *Rollout) error
+	UpdateRollout(context.Context, *Rollout) error
+}
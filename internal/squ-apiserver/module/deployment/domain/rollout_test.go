@@ -0,0 +1,31 @@
+package domain
+
+import "testing"
+
+func TestPlanSteps(t *testing.T) {
+	servers := []Server{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}, {ID: 6}}
+	tests := []struct {
+		name      string
+		batchSize int
+		canary    bool
+		batches   []int
+	}{
+		{"batches", 2, false, []int{0, 0, 1, 1, 2, 2}},
+		{"canary", 2, true, []int{0, 1, 1, 2, 2, 3}},
+		{"canary one by one", 1, true, []int{0, 1, 2, 3, 4, 5}},
+		{"single batch", 10, false, []int{0, 0, 0, 0, 0, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			steps := PlanSteps(servers, test.batchSize, test.canary)
+			for i, step := range steps {
+				if step.ServerID != servers[i].ID || step.Batch != test.batches[i] || step.Status != StepPending {
+					t.Fatalf("steps = %#v", steps)
+				}
+			}
+			if got := (Rollout{Steps: steps}).Batches(); got != test.batches[len(test.batches)-1]+1 {
+				t.Fatalf("batches = %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+var (
+	ErrSecretNotFound = errors.New("secret not found")
+	ErrSecretDenied   = errors.New("secret access denied")
+)
+
+// SecretResolver reads the secrets a deployment references. Resolve returns
+// the values keyed by name, ErrSecretNotFound for unknown names and
+// ErrSecretDenied when username may not use one of them.
+type SecretResolver interface {
+	Resolve(ctx context.Context, names []string, username string) (map[string]string, error)
+}
+
+// secretLines returns the sorted secret names so that their order does not
+// show up as a change.
+func secretLines(names []string) []string {
+	lines := slices.Clone(names)
+	slices.Sort(lines)
+	return lines
+}
@@ -0,0 +1,33 @@
+package infra
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	bundleDomain "squirrel-dev/internal/squ-apiserver/module/bundle/domain"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// bundlePusher is the part of the bundle service that sends bundles.
+type bundlePusher interface {
+	Push(ctx context.Context, id, serverID uint) (bundleDomain.Bundle, error)
+}
+
+type BundleTransfer struct{ bundles bundlePusher }
+
+func NewBundleTransfer(bundles bundlePusher) *BundleTransfer {
+	return &BundleTransfer{bundles: bundles}
+}
+
+func (t *BundleTransfer) Push(ctx context.Context, bundleID, serverID uint) (string, error) {
+	bundle, err := t.bundles.Push(ctx, bundleID, serverID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", domain.ErrBundleNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return bundle.Checksum, nil
+}
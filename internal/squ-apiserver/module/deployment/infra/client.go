@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -15,13 +18,23 @@ import (
 	"squirrel-dev/pkg/utils"
 )
 
+// maxAgentResponse bounds the size of a non-streaming agent response.
+const maxAgentResponse = 64 << 20
+
 type AgentClient struct {
 	config *config.Config
 	http   *httpclient.Client
+	// stream has no overall timeout; streams end when the caller's context
+	// is cancelled or the agent closes them.
+	stream *http.Client
 }
 
 func NewAgentClient(conf *config.Config) *AgentClient {
-	return &AgentClient{config: conf, http: httpclient.NewClient(30 * time.Second)}
+	return &AgentClient{
+		config: conf,
+		http:   httpclient.NewClient(30 * time.Second),
+		stream: &http.Client{Transport: &http.Transport{ResponseHeaderTimeout: 30 * time.Second}},
+	}
 }
 
 func (c *AgentClient) Post(_ context.Context, server domain.Server, path string, request any) error {
@@ -60,6 +73,64 @@ func (c *AgentClient) Post(_ context.Context, server domain.Server, path string,
 	return nil
 }
 
+func (c *AgentClient) Get(ctx context.Context, server domain.Server, path string, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	url := c.url(server, path)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	reply, err := c.stream.Do(request)
+	if err != nil {
+		zap.L().Error("agent request failed", zap.String("url", url), zap.Uint("server_id", server.ID), zap.Error(err))
+		return fmt.Errorf("agent request failed: %w", err)
+	}
+	defer reply.Body.Close()
+	var value struct {
+		response.Response
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(reply.Body, maxAgentResponse)).Decode(&value); err != nil {
+		return fmt.Errorf("parse agent response failed: %w", err)
+	}
+	if value.Code != 0 {
+		return &domain.AgentError{Code: value.Code, Message: value.Message}
+	}
+	if result == nil || len(value.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(value.Data, result)
+}
+
+func (c *AgentClient) Stream(ctx context.Context, server domain.Server, path string) (io.ReadCloser, error) {
+	url := c.url(server, path)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	reply, err := c.stream.Do(request)
+	if err != nil {
+		zap.L().Error("agent stream failed", zap.String("url", url), zap.Uint("server_id", server.ID), zap.Error(err))
+		return nil, fmt.Errorf("agent request failed: %w", err)
+	}
+	if strings.HasPrefix(reply.Header.Get("Content-Type"), "text/event-stream") {
+		return reply.Body, nil
+	}
+	// Anything else is an error response sent before streaming started.
+	defer reply.Body.Close()
+	var value response.Response
+	if err := json.NewDecoder(io.LimitReader(reply.Body, maxAgentResponse)).Decode(&value); err != nil || value.Code == 0 {
+		return nil, fmt.Errorf("unexpected agent response: status %d", reply.StatusCode)
+	}
+	return nil, &domain.AgentError{Code: value.Code, Message: value.Message}
+}
+
+func (c *AgentClient) url(server domain.Server, path string) string {
+	return utils.GenAgentUrl(c.config.Agent.Http.Scheme, server.IPAddress, server.AgentPort, c.config.Agent.Http.BaseUrl, path)
+}
+
 type IDGenerator struct{}
 
 func (IDGenerator) Generate() (uint64, error) { return utils.IDGenerate() }
@@ -0,0 +1,112 @@
+package infra
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	clusterDomain "squirrel-dev/internal/squ-apiserver/module/cluster/domain"
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	"squirrel-dev/pkg/k8s"
+)
+
+// ClusterRuntime deploys to registered clusters with pkg/k8s and the helm
+// CLI. Clients are cached per cluster and rebuilt when the cluster was
+// updated.
+type ClusterRuntime struct {
+	repository clusterDomain.Repository
+	chartsDir  string
+	connect    func(kubeconfig []byte) (*k8s.Client, error)
+	run        func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+	mu      sync.Mutex
+	clients map[uint]cachedClient
+}
+
+type cachedClient struct {
+	updatedAt time.Time
+	client    *k8s.Client
+}
+
+// NewClusterRuntime installs local charts from chartsDir only, which
+// defaults to ./charts.
+func NewClusterRuntime(repository clusterDomain.Repository, chartsDir string) *ClusterRuntime {
+	if chartsDir == "" {
+		chartsDir = defaultChartsDir
+	}
+	return &ClusterRuntime{
+		repository: repository,
+		chartsDir:  chartsDir,
+		connect:    k8s.NewFromKubeconfig,
+		run:        runCommand,
+		clients:    map[uint]cachedClient{},
+	}
+}
+
+func (r *ClusterRuntime) Cluster(ctx context.Context, id uint) (domain.Cluster, error) {
+	value, err := r.repository.Get(ctx, id)
+	if err != nil {
+		return domain.Cluster{}, err
+	}
+	return domain.Cluster{ID: value.ID, Name: value.Name, Server: value.Server, Namespace: value.Namespace,
+		Kubeconfig: value.Kubeconfig, UpdatedAt: value.UpdatedAt}, nil
+}
+
+func (r *ClusterRuntime) Apply(ctx context.Context, cluster domain.Cluster, manifest, namespace string) ([]domain.Object, error) {
+	client, err := r.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := client.Apply(ctx, manifest, namespace)
+	return fromK8sObjects(objects), err
+}
+
+func (r *ClusterRuntime) Readiness(ctx context.Context, cluster domain.Cluster, objects []domain.Object) (domain.Readiness, error) {
+	client, err := r.client(cluster)
+	if err != nil {
+		return domain.Readiness{}, err
+	}
+	readiness, err := client.CheckObjectsReadiness(ctx, toK8sObjects(objects))
+	if err != nil {
+		return domain.Readiness{}, err
+	}
+	return domain.Readiness{Ready: readiness.Ready, Failed: readiness.Failed, Message: readiness.Message}, nil
+}
+
+func (r *ClusterRuntime) Delete(ctx context.Context, cluster domain.Cluster, objects []domain.Object) error {
+	client, err := r.client(cluster)
+	if err != nil {
+		return err
+	}
+	return client.Delete(ctx, toK8sObjects(objects))
+}
+
+func (r *ClusterRuntime) client(cluster domain.Cluster) (*k8s.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.clients[cluster.ID]; ok && cached.updatedAt.Equal(cluster.UpdatedAt) {
+		return cached.client, nil
+	}
+	client, err := r.connect([]byte(cluster.Kubeconfig))
+	if err != nil {
+		return nil, err
+	}
+	r.clients[cluster.ID] = cachedClient{updatedAt: cluster.UpdatedAt, client: client}
+	return client, nil
+}
+
+func toK8sObjects(values []domain.Object) []k8s.Object {
+	result := make([]k8s.Object, 0, len(values))
+	for _, value := range values {
+		result = append(result, k8s.Object(value))
+	}
+	return result
+}
+
+func fromK8sObjects(values []k8s.Object) []domain.Object {
+	result := make([]domain.Object, 0, len(values))
+	for _, value := range values {
+		result = append(result, domain.Object(value))
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package infra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	"squirrel-dev/pkg/k8s"
+)
+
+func TestClusterTargetPersistence(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateClusterTargets(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repository := NewRepository(db)
+	object := domain.Object{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "shop", Name: "web"}
+	value := domain.Deployment{ClusterID: 4, Namespace: "shop", ApplicationID: 3, DeployID: 7, Objects: []domain.Object{object}}
+	if err := repository.Add(ctx, &value); err != nil {
+		t.Fatal(err)
+	}
+	value.Objects = nil
+	if err := repository.Update(ctx, &value); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repository.Get(ctx, value.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ClusterID != 4 || stored.Namespace != "shop" || len(stored.Objects) != 0 {
+		t.Fatalf("stored = %#v", stored)
+	}
+	if err := RollbackClusterTargets(db); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn(&deploymentModel{}, "cluster_id") {
+		t.Fatal("cluster_id column still exists after rollback")
+	}
+}
+
+func TestClusterRuntimeCachesClients(t *testing.T) {
+	connects := 0
+	clusters := &ClusterRuntime{
+		connect: func([]byte) (*k8s.Client, error) {
+			connects++
+			return &k8s.Client{
+				ClientSet:     fake.NewClientset(),
+				DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+			}, nil
+		},
+		clients: map[uint]cachedClient{},
+	}
+	cluster := domain.Cluster{ID: 1, UpdatedAt: time.Unix(100, 0)}
+	ctx := context.Background()
+
+	if _, err := clusters.Readiness(ctx, cluster, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusters.Delete(ctx, cluster, nil); err != nil {
+		t.Fatal(err)
+	}
+	if connects != 1 {
+		t.Fatalf("connects = %d, want 1", connects)
+	}
+	cluster.UpdatedAt = time.Unix(200, 0)
+	if _, err := clusters.Readiness(ctx, cluster, nil); err != nil {
+		t.Fatal(err)
+	}
+	if connects != 2 {
+		t.Fatalf("connects after cluster update = %d, want 2", connects)
+	}
+}
@@ -0,0 +1,96 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type containerModel struct {
+	ID           uint `gorm:"primarykey"`
+	UpdatedAt    time.Time
+	DeploymentID uint   `gorm:"index"`
+	Service      string `gorm:"size:255"`
+	Name         string `gorm:"size:255"`
+	ContainerID  string `gorm:"size:128"`
+	State        string `gorm:"size:32"`
+	Health       string `gorm:"size:32"`
+	RestartCount int
+	ExitCode     int
+	Image        string        `gorm:"size:1024"`
+	ImageDigest  string        `gorm:"size:1024"`
+	Ports        []domain.Port `gorm:"type:json;serializer:json"`
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+}
+
+func (containerModel) TableName() string { return "deployment_containers" }
+
+func (r *Repository) ListContainers(ctx context.Context, deploymentID uint) ([]domain.Container, error) {
+	var models []containerModel
+	if err := r.db.WithContext(ctx).Where("deployment_id = ?", deploymentID).Order("service, name").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.Container, 0, len(models))
+	for _, model := range models {
+		result = append(result, toContainer(model))
+	}
+	return result, nil
+}
+
+func (r *Repository) ReplaceContainers(ctx context.Context, deploymentID uint, containers []domain.Container) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("deployment_id = ?", deploymentID).Delete(&containerModel{}).Error; err != nil {
+			return err
+		}
+		if len(containers) == 0 {
+			return nil
+		}
+		models := make([]containerModel, 0, len(containers))
+		for _, container := range containers {
+			container.DeploymentID = deploymentID
+			models = append(models, toContainerModel(container))
+		}
+		return tx.Create(&models).Error
+	})
+}
+
+func toContainer(model containerModel) domain.Container {
+	return domain.Container{
+		DeploymentID: model.DeploymentID,
+		Service:      model.Service,
+		Name:         model.Name,
+		ContainerID:  model.ContainerID,
+		State:        model.State,
+		Health:       model.Health,
+		RestartCount: model.RestartCount,
+		ExitCode:     model.ExitCode,
+		Image:        model.Image,
+		ImageDigest:  model.ImageDigest,
+		Ports:        model.Ports,
+		StartedAt:    model.StartedAt,
+		FinishedAt:   model.FinishedAt,
+		UpdatedAt:    model.UpdatedAt,
+	}
+}
+
+func toContainerModel(value domain.Container) containerModel {
+	return containerModel{
+		DeploymentID: value.DeploymentID,
+		Service:      value.Service,
+		Name:         value.Name,
+		ContainerID:  value.ContainerID,
+		State:        value.State,
+		Health:       value.Health,
+		RestartCount: value.RestartCount,
+		ExitCode:     value.ExitCode,
+		Image:        value.Image,
+		ImageDigest:  value.ImageDigest,
+		Ports:        value.Ports,
+		StartedAt:    value.StartedAt,
+		FinishedAt:   value.FinishedAt,
+	}
+}
@@ -0,0 +1,57 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type driftEventModel struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	DeploymentID uint   `gorm:"index"`
+	DeployID     uint64 `gorm:"index"`
+	Observed     string `gorm:"size:32"`
+	Desired      string `gorm:"size:32"`
+	Action       string `gorm:"size:32"`
+	Attempt      int
+	Error        string `gorm:"type:text"`
+}
+
+func (driftEventModel) TableName() string { return "deployment_drift_events" }
+
+func (r *Repository) SetReconcile(ctx context.Context, id uint, reconcile bool) error {
+	return r.db.WithContext(ctx).Model(&deploymentModel{ID: id}).Update("reconcile", reconcile).Error
+}
+
+// AddDriftEvent keeps the time the agent recorded the event when it has one.
+func (r *Repository) AddDriftEvent(ctx context.Context, event *domain.DriftEvent) error {
+	model := driftEventModel{
+		CreatedAt: event.CreatedAt, DeploymentID: event.DeploymentID, DeployID: event.DeployID,
+		Observed: event.Observed, Desired: event.Desired, Action: event.Action,
+		Attempt: event.Attempt, Error: event.Error,
+	}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	event.ID, event.CreatedAt = model.ID, model.CreatedAt
+	return nil
+}
+
+func (r *Repository) ListDriftEvents(ctx context.Context, deploymentID uint, limit int) ([]domain.DriftEvent, error) {
+	var models []driftEventModel
+	if err := r.db.WithContext(ctx).Where("deployment_id = ?", deploymentID).
+		Order("created_at DESC, id DESC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.DriftEvent, 0, len(models))
+	for _, model := range models {
+		result = append(result, domain.DriftEvent{
+			ID: model.ID, DeploymentID: model.DeploymentID, DeployID: model.DeployID,
+			Observed: model.Observed, Desired: model.Desired, Action: model.Action,
+			Attempt: model.Attempt, Error: model.Error, CreatedAt: model.CreatedAt,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+// helmBinary is the helm CLI used for chart deployments. It is looked up in
+// PATH on the apiserver host.
+const helmBinary = "helm"
+
+const (
+	defaultChartsDir = "./charts"
+	ociPrefix        = "oci://"
+)
+
+// InstallChart runs helm upgrade --install. A packaged chart and the chart
+// values are written to a temporary directory that is removed afterwards.
+func (r *ClusterRuntime) InstallChart(ctx context.Context, cluster domain.Cluster, release domain.Release) ([]domain.Object, error) {
+	dir, err := os.MkdirTemp("", "squirrel-chart-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	var chart string
+	if release.Chart.Archive != nil {
+		chart = filepath.Join(dir, "chart.tgz")
+		if err := os.WriteFile(chart, release.Chart.Archive, 0600); err != nil {
+			return nil, err
+		}
+	} else if chart, err = r.chartPath(release.Chart.Path); err != nil {
+		return nil, err
+	}
+	args := []string{"upgrade", "--install", "--create-namespace"}
+	if len(release.Chart.Values) > 0 {
+		values, err := yaml.Marshal(release.Chart.Values)
+		if err != nil {
+			return nil, fmt.Errorf("encode chart values: %w", err)
+		}
+		file := filepath.Join(dir, "values.yaml")
+		if err := os.WriteFile(file, values, 0600); err != nil {
+			return nil, err
+		}
+		args = append(args, "--values", file)
+	}
+	args = append(args, setArgs(release.Set)...)
+	if _, err := r.helm(ctx, cluster, release, args, release.Name, chart); err != nil {
+		return nil, err
+	}
+	manifest, err := r.helm(ctx, cluster, release, []string{"get", "manifest"}, release.Name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(manifest)) == "" {
+		return nil, nil
+	}
+	client, err := r.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := client.Objects(string(manifest), namespace(release))
+	if err != nil {
+		return nil, fmt.Errorf("read release manifest: %w", err)
+	}
+	return fromK8sObjects(objects), nil
+}
+
+func (r *ClusterRuntime) RollbackChart(ctx context.Context, cluster domain.Cluster, release domain.Release) error {
+	_, err := r.helm(ctx, cluster, release, []string{"rollback"}, release.Name)
+	return err
+}
+
+func (r *ClusterRuntime) UninstallChart(ctx context.Context, cluster domain.Cluster, release domain.Release) error {
+	_, err := r.helm(ctx, cluster, release, []string{"uninstall", "--ignore-not-found"}, release.Name)
+	return err
+}
+
+func (r *ClusterRuntime) ChartStatus(ctx context.Context, cluster domain.Cluster, release domain.Release) (string, error) {
+	output, err := r.helm(ctx, cluster, release, []string{"status", "--output", "json"}, release.Name)
+	if err != nil {
+		return "", err
+	}
+	var status struct {
+		Info struct {
+			Status string `json:"status"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(output, &status); err != nil {
+		return "", fmt.Errorf("parse helm status: %w", err)
+	}
+	return status.Info.Status, nil
+}
+
+// chartPath resolves the chart argument of helm. OCI references are passed
+// on; a local chart must lie inside the charts directory, so that an
+// application cannot point helm at arbitrary files of the apiserver host.
+func (r *ClusterRuntime) chartPath(path string) (string, error) {
+	if strings.HasPrefix(path, ociPrefix) {
+		return path, nil
+	}
+	root, err := filepath.EvalSymlinks(r.chartsDir)
+	if err != nil {
+		return "", fmt.Errorf("charts directory: %w", err)
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidChart, err)
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is outside the charts directory %s", domain.ErrInvalidChart, path, root)
+	}
+	return resolved, nil
+}
+
+// helm runs the helm CLI against cluster in the namespace of release. The
+// kubeconfig is passed through a file only the apiserver user can read.
+// positional follows "--" so that it is never read as a flag.
+func (r *ClusterRuntime) helm(
+	ctx context.Context,
+	cluster domain.Cluster,
+	release domain.Release,
+	args []string,
+	positional ...string,
+) ([]byte, error) {
+	kubeconfig, err := os.CreateTemp("", "squirrel-kubeconfig-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(kubeconfig.Name())
+	_, err = kubeconfig.WriteString(cluster.Kubeconfig)
+	if closeErr := kubeconfig.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "--namespace", namespace(release), "--kubeconfig", kubeconfig.Name(), "--")
+	args = append(args, positional...)
+	return r.run(ctx, helmBinary, args...)
+}
+
+// runCommand returns the stdout of a command, and its stderr as the error
+// when it fails.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s %s: %w: %s", name, args[0], err, message)
+		}
+		return nil, fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func namespace(release domain.Release) string {
+	if release.Namespace == "" {
+		return "default"
+	}
+	return release.Namespace
+}
+
+// setArgs turns env entries into --set-string flags, sorted so that the
+// command line is stable. Commas are escaped because helm splits on them.
+func setArgs(env []map[string]string) []string {
+	var pairs []string
+	for _, item := range env {
+		for key, value := range item {
+			pairs = append(pairs, key+"="+strings.ReplaceAll(value, ",", `\,`))
+		}
+	}
+	sort.Strings(pairs)
+	args := make([]string, 0, 2*len(pairs))
+	for _, pair := range pairs {
+		args = append(args, "--set-string", pair)
+	}
+	return args
+}
@@ -0,0 +1,185 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	"squirrel-dev/pkg/k8s"
+)
+
+type helmCall struct {
+	args       []string
+	values     string
+	kubeconfig string
+	mode       os.FileMode
+}
+
+func fakeHelm(t *testing.T, outputs map[string]string) (*ClusterRuntime, *[]helmCall) {
+	t.Helper()
+	clientSet := fake.NewClientset()
+	clientSet.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}},
+	}}
+	charts := t.TempDir()
+	if err := os.Mkdir(filepath.Join(charts, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var calls []helmCall
+	clusters := &ClusterRuntime{
+		chartsDir: charts,
+		connect: func([]byte) (*k8s.Client, error) {
+			return &k8s.Client{ClientSet: clientSet, DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())}, nil
+		},
+		run: func(_ context.Context, name string, args ...string) ([]byte, error) {
+			if name != helmBinary {
+				t.Fatalf("ran %q", name)
+			}
+			call := helmCall{args: args}
+			if i := slices.Index(args, "--values"); i >= 0 {
+				data, _ := os.ReadFile(args[i+1])
+				call.values = string(data)
+			}
+			path := args[slices.Index(args, "--kubeconfig")+1]
+			data, _ := os.ReadFile(path)
+			info, _ := os.Stat(path)
+			call.kubeconfig, call.mode = string(data), info.Mode().Perm()
+			calls = append(calls, call)
+			output, ok := outputs[args[0]]
+			if !ok {
+				return nil, errors.New("helm " + args[0] + ": exit status 1: Error: release failed")
+			}
+			return []byte(output), nil
+		},
+		clients: map[uint]cachedClient{},
+	}
+	return clusters, &calls
+}
+
+func TestInstallChartRunsHelmUpgrade(t *testing.T) {
+	clusters, calls := fakeHelm(t, map[string]string{
+		"upgrade": "",
+		"get":     "---\n# Source: web/templates/deployment.yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n",
+	})
+	release := domain.Release{
+		Name: "web-1", Namespace: "shop",
+		Chart: domain.Chart{Path: "web", Values: map[string]any{"replicaCount": 2}},
+		Set:   []map[string]string{{"image.tag": "1.27"}, {"hosts": "a,b"}},
+	}
+
+	objects, err := clusters.InstallChart(context.Background(), domain.Cluster{ID: 1, Kubeconfig: "apiVersion: v1"}, release)
+	if err != nil {
+		t.Fatalf("InstallChart() error = %v", err)
+	}
+	want := []domain.Object{{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "shop", Name: "web"}}
+	if !slices.Equal(objects, want) {
+		t.Fatalf("objects = %+v, want %+v", objects, want)
+	}
+	if len(*calls) != 2 {
+		t.Fatalf("calls = %+v", *calls)
+	}
+	upgrade := (*calls)[0]
+	command := strings.Join(upgrade.args, " ")
+	for _, part := range []string{
+		"upgrade --install --create-namespace",
+		`--set-string hosts=a\,b --set-string image.tag=1.27`,
+		"--namespace shop --kubeconfig",
+		" -- web-1 " + filepath.Join(clusters.chartsDir, "web"),
+	} {
+		if !strings.Contains(command, part) {
+			t.Errorf("command %q does not contain %q", command, part)
+		}
+	}
+	if upgrade.values != "replicaCount: 2\n" {
+		t.Errorf("values = %q", upgrade.values)
+	}
+	if upgrade.kubeconfig != "apiVersion: v1" || upgrade.mode != 0600 {
+		t.Errorf("kubeconfig = %q mode %v", upgrade.kubeconfig, upgrade.mode)
+	}
+	if got := (*calls)[1].args; got[0] != "get" || got[1] != "manifest" || got[len(got)-1] != "web-1" {
+		t.Errorf("second call = %q", got)
+	}
+}
+
+func TestInstallChartWritesArchive(t *testing.T) {
+	clusters, calls := fakeHelm(t, map[string]string{"upgrade": "", "get": ""})
+	release := domain.Release{Name: "web-1", Chart: domain.Chart{Archive: []byte{0x1f, 0x8b}}}
+	var archive string
+	run := clusters.run
+	clusters.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if args[0] == "upgrade" {
+			data, _ := os.ReadFile(args[len(args)-1])
+			archive = string(data)
+		}
+		return run(ctx, name, args...)
+	}
+
+	objects, err := clusters.InstallChart(context.Background(), domain.Cluster{ID: 1}, release)
+	if err != nil || objects != nil {
+		t.Fatalf("InstallChart() = %+v, %v", objects, err)
+	}
+	if args := (*calls)[0].args; archive != "\x1f\x8b" || !strings.HasSuffix(args[len(args)-1], "chart.tgz") {
+		t.Fatalf("archive = %q, args = %v", archive, (*calls)[0].args)
+	}
+	if !strings.Contains(strings.Join((*calls)[0].args, " "), "--namespace default") {
+		t.Fatalf("args = %v", (*calls)[0].args)
+	}
+}
+
+func TestChartStatusAndErrors(t *testing.T) {
+	clusters, calls := fakeHelm(t, map[string]string{
+		"status":    `{"name":"web-1","info":{"status":"pending-upgrade"}}`,
+		"uninstall": "",
+	})
+	ctx := context.Background()
+	release := domain.Release{Name: "web-1", Namespace: "shop"}
+
+	status, err := clusters.ChartStatus(ctx, domain.Cluster{}, release)
+	if err != nil || status != domain.ReleasePendingUpgrade {
+		t.Fatalf("ChartStatus() = %q, %v", status, err)
+	}
+	if err := clusters.UninstallChart(ctx, domain.Cluster{}, release); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusters.RollbackChart(ctx, domain.Cluster{}, release); err == nil || !strings.Contains(err.Error(), "release failed") {
+		t.Fatalf("RollbackChart() error = %v", err)
+	}
+	if got := (*calls)[1].args; !slices.Equal(got[:2], []string{"uninstall", "--ignore-not-found"}) ||
+		!slices.Equal(got[len(got)-2:], []string{"--", "web-1"}) {
+		t.Fatalf("uninstall args = %v", got)
+	}
+}
+
+func TestInstallChartRejectsPathsOutsideChartsDir(t *testing.T) {
+	clusters, calls := fakeHelm(t, map[string]string{"upgrade": "", "get": ""})
+	ctx := context.Background()
+	for _, path := range []string{"/etc", "../web", "web/../../etc", "missing"} {
+		release := domain.Release{Name: "web-1", Chart: domain.Chart{Path: path}}
+		if _, err := clusters.InstallChart(ctx, domain.Cluster{ID: 1}, release); !errors.Is(err, domain.ErrInvalidChart) {
+			t.Errorf("InstallChart(%q) error = %v, want ErrInvalidChart", path, err)
+		}
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("helm ran for a rejected chart: %+v", *calls)
+	}
+
+	release := domain.Release{Name: "web-1", Chart: domain.Chart{Path: "oci://registry.example.com/charts/web"}}
+	if _, err := clusters.InstallChart(ctx, domain.Cluster{ID: 1}, release); err != nil {
+		t.Fatalf("InstallChart(oci) error = %v", err)
+	}
+	if args := (*calls)[0].args; args[len(args)-1] != release.Chart.Path {
+		t.Fatalf("args = %v", args)
+	}
+}
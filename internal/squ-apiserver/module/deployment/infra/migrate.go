@@ -4,3 +4,80 @@ import "gorm.io/gorm"
 
 func Migrate(db *gorm.DB) error  { return db.AutoMigrate(&deploymentModel{}) }
 func Rollback(db *gorm.DB) error { return db.Migrator().DropTable("deployments") }
+
+func MigrateRevisions(db *gorm.DB) error  { return db.AutoMigrate(&revisionModel{}) }
+func RollbackRevisions(db *gorm.DB) error { return db.Migrator().DropTable("deployment_revisions") }
+
+func MigrateRollouts(db *gorm.DB) error  { return db.AutoMigrate(&rolloutModel{}) }
+func RollbackRollouts(db *gorm.DB) error { return db.Migrator().DropTable("deployment_rollouts") }
+
+func MigrateContainers(db *gorm.DB) error  { return db.AutoMigrate(&containerModel{}) }
+func RollbackContainers(db *gorm.DB) error { return db.Migrator().DropTable("deployment_containers") }
+
+// MigrateClusterTargets adds the columns of deployments to Kubernetes
+// clusters.
+func MigrateClusterTargets(db *gorm.DB) error { return db.AutoMigrate(&deploymentModel{}) }
+func RollbackClusterTargets(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&deploymentModel{}, "ClusterID") {
+		if err := db.Migrator().DropIndex(&deploymentModel{}, "ClusterID"); err != nil {
+			return err
+		}
+	}
+	for _, column := range []string{"objects", "namespace", "cluster_id"} {
+		if err := db.Migrator().DropColumn(&deploymentModel{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func MigrateReleases(db *gorm.DB) error { return db.AutoMigrate(&deploymentModel{}) }
+func RollbackReleases(db *gorm.DB) error {
+	return db.Migrator().DropColumn(&deploymentModel{}, "release")
+}
+
+// MigrateSecretRefs adds the secret names referenced by deployments and their
+// revisions.
+func MigrateSecretRefs(db *gorm.DB) error {
+	return db.AutoMigrate(&deploymentModel{}, &revisionModel{})
+}
+func RollbackSecretRefs(db *gorm.DB) error {
+	if err := db.Migrator().DropColumn(&revisionModel{}, "secrets"); err != nil {
+		return err
+	}
+	return db.Migrator().DropColumn(&deploymentModel{}, "secrets")
+}
+
+// MigrateHealth adds the health check result reported for deployments.
+func MigrateHealth(db *gorm.DB) error { return db.AutoMigrate(&deploymentModel{}) }
+func RollbackHealth(db *gorm.DB) error {
+	for _, column := range []string{"health_message", "health"} {
+		if err := db.Migrator().DropColumn(&deploymentModel{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateReconcile adds the reconcile mode of deployments and the drift
+// events agents report for them.
+func MigrateReconcile(db *gorm.DB) error {
+	return db.AutoMigrate(&deploymentModel{}, &driftEventModel{})
+}
+func RollbackReconcile(db *gorm.DB) error {
+	if err := db.Migrator().DropTable(&driftEventModel{}); err != nil {
+		return err
+	}
+	return db.Migrator().DropColumn(&deploymentModel{}, "reconcile")
+}
+
+// MigrateFiles adds the file state agents report for deployments.
+func MigrateFiles(db *gorm.DB) error { return db.AutoMigrate(&deploymentModel{}) }
+func RollbackFiles(db *gorm.DB) error {
+	for _, column := range []string{"files_checked_at", "pushed_env_hash", "pushed_compose_hash", "env_hash", "compose_hash"} {
+		if err := db.Migrator().DropColumn(&deploymentModel{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
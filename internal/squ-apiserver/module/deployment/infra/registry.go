@@ -0,0 +1,22 @@
+package infra
+
+import (
+	"context"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	registryDomain "squirrel-dev/internal/squ-apiserver/module/registry/domain"
+)
+
+type RegistryReader struct{ repository registryDomain.Repository }
+
+func NewRegistryReader(repository registryDomain.Repository) *RegistryReader {
+	return &RegistryReader{repository: repository}
+}
+
+func (r *RegistryReader) Get(ctx context.Context, id uint) (domain.Registry, error) {
+	value, err := r.repository.Get(ctx, id)
+	if err != nil {
+		return domain.Registry{}, err
+	}
+	return domain.Registry{Server: value.Server, Username: value.Username, Password: value.Password}, nil
+}
@@ -9,6 +9,7 @@ import (
 	applicationDomain "squirrel-dev/internal/squ-apiserver/module/application/domain"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
 	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
 )
 
 type deploymentModel struct {
@@ -17,11 +18,25 @@ type deploymentModel struct {
 	UpdatedAt     time.Time
 	DeletedAt     gorm.DeletedAt `gorm:"index"`
 	ServerID      uint
+	ClusterID     uint   `gorm:"index"`
+	Namespace     string `gorm:"type:varchar(63)"`
+	Release       string `gorm:"type:varchar(53)"`
 	ApplicationID uint
 	Status        string
 	DeployID      uint64
 	Content       string
 	Env           []map[string]string `gorm:"type:json;serializer:json"`
+	Secrets       []string            `gorm:"type:json;serializer:json"`
+	Objects       []domain.Object     `gorm:"type:json;serializer:json"`
+	Health        string
+	HealthMessage string
+	Reconcile     bool
+	// The file state reported by the agent.
+	ComposeHash       string
+	EnvHash           string
+	PushedComposeHash string
+	PushedEnvHash     string
+	FilesCheckedAt    *time.Time
 }
 
 func (deploymentModel) TableName() string { return "deployments" }
@@ -75,22 +90,46 @@ func (r *Repository) Add(ctx context.Context, value *domain.Deployment) error {
 	return nil
 }
 
+// Update writes content, env, secrets and applied objects even when they are
+// empty so that a rollback can restore a revision that had none.
 func (r *Repository) Update(ctx context.Context, value *domain.Deployment) error {
-	return r.db.WithContext(ctx).Updates(toModel(*value)).Error
+	return r.db.WithContext(ctx).Model(&deploymentModel{ID: value.ID}).Select("content", "env", "secrets", "objects").
+		Updates(toModel(*value)).Error
 }
 
 func (r *Repository) UpdateStatus(ctx context.Context, deployID uint64, status string) error {
 	return r.db.WithContext(ctx).Model(&deploymentModel{}).Where("deploy_id = ?", deployID).Update("status", status).Error
 }
 
+func (r *Repository) UpdateHealth(ctx context.Context, deployID uint64, health domain.Health) error {
+	return r.db.WithContext(ctx).Model(&deploymentModel{}).Where("deploy_id = ?", deployID).
+		Updates(map[string]any{"health": health.Status, "health_message": health.Message}).Error
+}
+
+func (r *Repository) UpdateFiles(ctx context.Context, deployID uint64, state domain.FileState) error {
+	return r.db.WithContext(ctx).Model(&deploymentModel{}).Where("deploy_id = ?", deployID).
+		Updates(map[string]any{"compose_hash": state.ComposeHash, "env_hash": state.EnvHash,
+			"pushed_compose_hash": state.PushedComposeHash, "pushed_env_hash": state.PushedEnvHash,
+			"files_checked_at": state.CheckedAt}).Error
+}
+
 func toModel(v domain.Deployment) deploymentModel {
-	return deploymentModel{ID: v.ID, CreatedAt: v.CreatedAt, ServerID: v.ServerID,
-		ApplicationID: v.ApplicationID, Status: v.Status, DeployID: v.DeployID, Content: v.Content, Env: v.Env}
+	return deploymentModel{ID: v.ID, CreatedAt: v.CreatedAt, ServerID: v.ServerID, ClusterID: v.ClusterID,
+		Namespace: v.Namespace, Release: v.Release, ApplicationID: v.ApplicationID, Status: v.Status, DeployID: v.DeployID,
+		Content: v.Content, Env: v.Env, Secrets: v.Secrets, Objects: v.Objects}
 }
 
 func toDomain(v deploymentModel) domain.Deployment {
-	return domain.Deployment{ID: v.ID, CreatedAt: v.CreatedAt, ServerID: v.ServerID,
-		ApplicationID: v.ApplicationID, Status: v.Status, DeployID: v.DeployID, Content: v.Content, Env: v.Env}
+	result := domain.Deployment{ID: v.ID, CreatedAt: v.CreatedAt, ServerID: v.ServerID, ClusterID: v.ClusterID,
+		Namespace: v.Namespace, Release: v.Release, ApplicationID: v.ApplicationID, Status: v.Status, DeployID: v.DeployID,
+		Content: v.Content, Env: v.Env, Secrets: v.Secrets, Objects: v.Objects,
+		Health: domain.Health{Status: v.Health, Message: v.HealthMessage}, Reconcile: v.Reconcile,
+		Files: domain.FileState{ComposeHash: v.ComposeHash, EnvHash: v.EnvHash,
+			PushedComposeHash: v.PushedComposeHash, PushedEnvHash: v.PushedEnvHash}}
+	if v.FilesCheckedAt != nil {
+		result.Files.CheckedAt = *v.FilesCheckedAt
+	}
+	return result
 }
 
 type ApplicationReader struct{ repository applicationDomain.Repository }
@@ -104,8 +143,18 @@ func (r *ApplicationReader) Get(ctx context.Context, id uint) (domain.Applicatio
 	if err != nil {
 		return domain.Application{}, err
 	}
-	return domain.Application{ID: value.ID, Name: value.Name, Description: value.Description,
-		Type: value.Type, Content: value.Content, Version: value.Version}, nil
+	result := domain.Application{ID: value.ID, Name: value.Name, Description: value.Description,
+		Type: value.Type, Content: value.Content, Version: value.Version, RegistryID: value.RegistryID,
+		BundleID: value.BundleID}
+	if check := value.HealthCheck; check != nil {
+		result.HealthCheck = &domain.HealthCheck{Timeout: check.Timeout}
+		for _, item := range check.Checks {
+			result.HealthCheck.Checks = append(result.HealthCheck.Checks, domain.Check{
+				Type: item.Type, URL: item.URL, Status: item.Status, Address: item.Address,
+			})
+		}
+	}
+	return result, nil
 }
 
 type ServerReader struct{ repository serverDomain.Repository }
@@ -121,3 +170,15 @@ func (r *ServerReader) Get(ctx context.Context, id uint) (domain.Server, error)
 	}
 	return domain.Server{ID: value.ID, IPAddress: value.IPAddress, AgentPort: value.AgentPort}, nil
 }
+
+func (r *ServerReader) Select(ctx context.Context, target serverDomain.Target) ([]domain.Server, error) {
+	values, err := serverInfra.Select(ctx, r.repository, target)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.Server, 0, len(values))
+	for _, value := range values {
+		result = append(result, domain.Server{ID: value.ID, IPAddress: value.IPAddress, AgentPort: value.AgentPort})
+	}
+	return result, nil
+}
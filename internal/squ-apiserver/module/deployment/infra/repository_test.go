@@ -3,11 +3,13 @@ package infra
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 
 	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 func TestLegacyDeploymentPersistence(t *testing.T) {
@@ -31,3 +33,132 @@ func TestLegacyDeploymentPersistence(t *testing.T) {
 		t.Fatalf("stored = %#v", stored)
 	}
 }
+
+func TestRevisionNumbering(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateRevisions(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repository := NewRepository(db)
+	for _, deploymentID := range []uint{1, 1, 2, 1} {
+		value := domain.Revision{DeploymentID: deploymentID, Content: "c", Env: []map[string]string{{"A": "B"}}}
+		if err := repository.AddRevision(ctx, &value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	values, err := repository.ListRevisions(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 || values[0].Number != 3 || values[2].Number != 1 {
+		t.Fatalf("revisions = %#v", values)
+	}
+	latest, err := repository.LatestRevision(ctx, 2)
+	if err != nil || latest.Number != 1 || latest.Env[0]["A"] != "B" {
+		t.Fatalf("latest = %#v, err = %v", latest, err)
+	}
+	if _, err := repository.GetRevision(ctx, 2, 2); err != gorm.ErrRecordNotFound {
+		t.Fatalf("error = %v", err)
+	}
+
+	deployment := domain.Deployment{ServerID: 1, Content: "old", Env: []map[string]string{{"A": "B"}}}
+	if err := repository.Add(ctx, &deployment); err != nil {
+		t.Fatal(err)
+	}
+	deployment.Content, deployment.Env = "new", nil
+	if err := repository.Update(ctx, &deployment); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repository.Get(ctx, deployment.ID)
+	if err != nil || stored.Content != "new" || len(stored.Env) != 0 {
+		t.Fatalf("stored = %#v, err = %v", stored, err)
+	}
+}
+
+func TestRolloutPersistence(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateRollouts(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repository := NewRepository(db)
+	value := domain.Rollout{
+		ApplicationID: 3, Target: serverDomain.Target{ServerIDs: []uint{1, 2}}, BatchSize: 1, Canary: true,
+		Pause: 30 * time.Second, HealthTimeout: time.Minute, Status: domain.RolloutRunning,
+		Steps: domain.PlanSteps([]domain.Server{{ID: 1}, {ID: 2}}, 1, true),
+	}
+	if err := repository.AddRollout(ctx, &value); err != nil {
+		t.Fatal(err)
+	}
+	value.Steps[0].Status, value.Steps[0].DeploymentID = domain.StepSucceeded, 9
+	value.Status = domain.RolloutSucceeded
+	if err := repository.UpdateRollout(ctx, &value); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repository.GetRollout(ctx, value.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != domain.RolloutSucceeded || stored.Pause != 30*time.Second || stored.Target.ServerIDs[1] != 2 ||
+		stored.Steps[0].DeploymentID != 9 || stored.Steps[1].Batch != 1 {
+		t.Fatalf("stored = %#v", stored)
+	}
+	values, err := repository.ListRollouts(ctx, 4)
+	if err != nil || len(values) != 0 {
+		t.Fatalf("values = %#v, err = %v", values, err)
+	}
+}
+
+func TestContainerReplacement(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateContainers(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repository := NewRepository(db)
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := []domain.Container{
+		{Service: "worker", Name: "42-worker-1", State: "restarting", RestartCount: 4, ExitCode: 1},
+		{Service: "web", Name: "42-web-1", State: "running", StartedAt: &started,
+			Ports: []domain.Port{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}}},
+	}
+	if err := repository.ReplaceContainers(ctx, 7, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.ReplaceContainers(ctx, 8, first[:1]); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repository.ListContainers(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || stored[0].Service != "web" || stored[0].Ports[0].HostPort != 8080 || !stored[0].StartedAt.Equal(started) {
+		t.Fatalf("stored = %#v", stored)
+	}
+	if stored[1].RestartCount != 4 || stored[1].DeploymentID != 7 {
+		t.Fatalf("worker = %#v", stored[1])
+	}
+
+	if err := repository.ReplaceContainers(ctx, 7, []domain.Container{}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := repository.ListContainers(ctx, 7); err != nil || len(stored) != 0 {
+		t.Fatalf("stored = %#v err = %v", stored, err)
+	}
+	if stored, err := repository.ListContainers(ctx, 8); err != nil || len(stored) != 1 {
+		t.Fatalf("other deployment = %#v err = %v", stored, err)
+	}
+}
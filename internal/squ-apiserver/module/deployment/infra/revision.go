@@ -0,0 +1,83 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+)
+
+type revisionModel struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	DeploymentID uint   `gorm:"uniqueIndex:idx_deployment_revision,priority:1"`
+	Number       uint   `gorm:"uniqueIndex:idx_deployment_revision,priority:2"`
+	Reason       string `gorm:"size:32"`
+	Author       string `gorm:"size:255"`
+	Content      string
+	Env          []map[string]string `gorm:"type:json;serializer:json"`
+	Secrets      []string            `gorm:"type:json;serializer:json"`
+	Diff         string
+	RollbackOf   uint
+}
+
+func (revisionModel) TableName() string { return "deployment_revisions" }
+
+func (r *Repository) ListRevisions(ctx context.Context, deploymentID uint) ([]domain.Revision, error) {
+	var models []revisionModel
+	if err := r.db.WithContext(ctx).Where("deployment_id = ?", deploymentID).Order("number desc").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.Revision, 0, len(models))
+	for _, model := range models {
+		result = append(result, toRevision(model))
+	}
+	return result, nil
+}
+
+func (r *Repository) GetRevision(ctx context.Context, deploymentID, number uint) (domain.Revision, error) {
+	var model revisionModel
+	if err := r.db.WithContext(ctx).Where("deployment_id = ? AND number = ?", deploymentID, number).First(&model).Error; err != nil {
+		return domain.Revision{}, err
+	}
+	return toRevision(model), nil
+}
+
+func (r *Repository) LatestRevision(ctx context.Context, deploymentID uint) (domain.Revision, error) {
+	var model revisionModel
+	if err := r.db.WithContext(ctx).Where("deployment_id = ?", deploymentID).Order("number desc").First(&model).Error; err != nil {
+		return domain.Revision{}, err
+	}
+	return toRevision(model), nil
+}
+
+// AddRevision numbers the revision inside a transaction; the unique index on
+// (deployment_id, number) rejects a concurrent writer that picked the same one.
+func (r *Repository) AddRevision(ctx context.Context, value *domain.Revision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest uint
+		if err := tx.Model(&revisionModel{}).Where("deployment_id = ?", value.DeploymentID).
+			Select("COALESCE(MAX(number), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		model := toRevisionModel(*value)
+		model.Number = latest + 1
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		value.ID, value.Number, value.CreatedAt = model.ID, model.Number, model.CreatedAt
+		return nil
+	})
+}
+
+func toRevisionModel(v domain.Revision) revisionModel {
+	return revisionModel{ID: v.ID, CreatedAt: v.CreatedAt, DeploymentID: v.DeploymentID, Number: v.Number,
+		Reason: v.Reason, Author: v.Author, Content: v.Content, Env: v.Env, Secrets: v.Secrets, Diff: v.Diff, RollbackOf: v.RollbackOf}
+}
+
+func toRevision(v revisionModel) domain.Revision {
+	return domain.Revision{ID: v.ID, CreatedAt: v.CreatedAt, DeploymentID: v.DeploymentID, Number: v.Number,
+		Reason: v.Reason, Author: v.Author, Content: v.Content, Env: v.Env, Secrets: v.Secrets, Diff: v.Diff, RollbackOf: v.RollbackOf}
+}
@@ -0,0 +1,96 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+type rolloutModel struct {
+	ID                   uint `gorm:"primarykey"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	ApplicationID        uint   `gorm:"index"`
+	TargetServerIDs      []uint `gorm:"type:json;serializer:json"`
+	TargetGroup          string `gorm:"size:255"`
+	TargetSelector       string `gorm:"size:1024"`
+	BatchSize            int
+	Canary               bool
+	PauseSeconds         int64
+	HealthTimeoutSeconds int64
+	AutoRollback         bool
+	Status               string `gorm:"size:32;index"`
+	CurrentBatch         int
+	Author               string `gorm:"size:255"`
+	Error                string
+	FinishedAt           *time.Time
+	Steps                []domain.RolloutStep `gorm:"type:json;serializer:json"`
+}
+
+func (rolloutModel) TableName() string { return "deployment_rollouts" }
+
+func (r *Repository) ListRollouts(ctx context.Context, applicationID uint) ([]domain.Rollout, error) {
+	var models []rolloutModel
+	query := r.db.WithContext(ctx).Order("id desc")
+	if applicationID > 0 {
+		query = query.Where("application_id = ?", applicationID)
+	}
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.Rollout, 0, len(models))
+	for _, model := range models {
+		result = append(result, toRollout(model))
+	}
+	return result, nil
+}
+
+func (r *Repository) GetRollout(ctx context.Context, id uint) (domain.Rollout, error) {
+	var model rolloutModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		return domain.Rollout{}, err
+	}
+	return toRollout(model), nil
+}
+
+func (r *Repository) AddRollout(ctx context.Context, value *domain.Rollout) error {
+	model := toRolloutModel(*value)
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	value.ID, value.CreatedAt, value.UpdatedAt = model.ID, model.CreatedAt, model.UpdatedAt
+	return nil
+}
+
+func (r *Repository) UpdateRollout(ctx context.Context, value *domain.Rollout) error {
+	model := toRolloutModel(*value)
+	if err := r.db.WithContext(ctx).Save(&model).Error; err != nil {
+		return err
+	}
+	value.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func toRolloutModel(v domain.Rollout) rolloutModel {
+	return rolloutModel{
+		ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, ApplicationID: v.ApplicationID,
+		TargetServerIDs: v.Target.ServerIDs, TargetGroup: v.Target.Group, TargetSelector: v.Target.Selector,
+		BatchSize: v.BatchSize, Canary: v.Canary, PauseSeconds: int64(v.Pause / time.Second),
+		HealthTimeoutSeconds: int64(v.HealthTimeout / time.Second), AutoRollback: v.AutoRollback,
+		Status: v.Status, CurrentBatch: v.CurrentBatch, Author: v.Author, Error: v.Error,
+		FinishedAt: v.FinishedAt, Steps: v.Steps,
+	}
+}
+
+func toRollout(v rolloutModel) domain.Rollout {
+	return domain.Rollout{
+		ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, ApplicationID: v.ApplicationID,
+		Target:    serverDomain.Target{ServerIDs: v.TargetServerIDs, Group: v.TargetGroup, Selector: v.TargetSelector},
+		BatchSize: v.BatchSize, Canary: v.Canary, Pause: time.Duration(v.PauseSeconds) * time.Second,
+		HealthTimeout: time.Duration(v.HealthTimeoutSeconds) * time.Second, AutoRollback: v.AutoRollback,
+		Status: v.Status, CurrentBatch: v.CurrentBatch, Author: v.Author, Error: v.Error,
+		FinishedAt: v.FinishedAt, Steps: v.Steps,
+	}
+}
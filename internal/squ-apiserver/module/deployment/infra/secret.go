@@ -0,0 +1,36 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/deployment/domain"
+	secretDomain "squirrel-dev/internal/squ-apiserver/module/secret/domain"
+)
+
+type SecretResolver struct{ repository secretDomain.Repository }
+
+func NewSecretResolver(repository secretDomain.Repository) *SecretResolver {
+	return &SecretResolver{repository: repository}
+}
+
+func (r *SecretResolver) Resolve(ctx context.Context, names []string, username string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		secret, err := r.repository.GetByName(ctx, name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrSecretNotFound, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !secret.CanUse(username) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrSecretDenied, name)
+		}
+		values[name] = secret.Value
+	}
+	return values, nil
+}
@@ -1,35 +1,96 @@
 package deployment
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"squirrel-dev/internal/squ-apiserver/config"
 	applicationInfra "squirrel-dev/internal/squ-apiserver/module/application/infra"
+	"squirrel-dev/internal/squ-apiserver/module/bundle"
+	clusterInfra "squirrel-dev/internal/squ-apiserver/module/cluster/infra"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/api"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/application"
 	"squirrel-dev/internal/squ-apiserver/module/deployment/infra"
+	registryInfra "squirrel-dev/internal/squ-apiserver/module/registry/infra"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
 	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
 )
 
-func BuildHandler(conf *config.Config, db *gorm.DB) *api.Handler {
-	service := application.NewService(
-		infra.NewRepository(db),
+func buildService(conf *config.Config, db *gorm.DB) *application.Service {
+	repository := infra.NewRepository(db)
+	return application.NewService(
+		repository,
+		repository,
+		repository,
 		infra.NewApplicationReader(applicationInfra.NewRepository(db)),
-		infra.NewServerReader(serverInfra.NewRepository(db)),
+		infra.NewServerReader(serverInfra.NewRepository(db, secretInfra.NewCipher(conf))),
+		infra.NewClusterRuntime(clusterInfra.NewRepository(db, secretInfra.NewCipher(conf)), conf.Charts.Path),
+		infra.NewSecretResolver(secretInfra.NewRepository(db, secretInfra.NewCipher(conf))),
+		infra.NewRegistryReader(registryInfra.NewRepository(db, secretInfra.NewCipher(conf))),
+		infra.NewBundleTransfer(bundle.NewService(conf, db)),
 		infra.NewAgentClient(conf),
 		infra.IDGenerator{},
 	)
-	return api.NewHandler(service)
 }
+
+func BuildHandler(conf *config.Config, db *gorm.DB) *api.Handler {
+	return api.NewHandler(buildService(conf, db))
+}
+
 func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
 	res.RegisterCode()
-	api.RegisterRoutes(group, BuildHandler(conf, db))
+	service := buildService(conf, db)
+	api.RegisterRoutes(group, api.NewHandler(service))
+	rollouts := application.NewRolloutService(service, infra.NewRepository(db))
+	api.RegisterRolloutRoutes(group, api.NewRolloutHandler(rollouts))
+	outputs := application.NewOutputService(service, infra.NewAgentClient(conf))
+	api.RegisterOutputRoutes(group, api.NewOutputHandler(outputs))
+	previews := application.NewPreviewService(service, infra.NewAgentClient(conf))
+	api.RegisterPreviewRoutes(group, api.NewPreviewHandler(previews))
+	drift := application.NewDriftService(service, infra.NewRepository(db))
+	api.RegisterDriftRoutes(group, api.NewDriftHandler(drift))
+	files := application.NewFilesService(service, infra.NewAgentClient(conf))
+	api.RegisterFilesRoutes(group, api.NewFilesHandler(files))
+	go service.WatchClusterStatus(context.Background(), application.ClusterStatusInterval)
 }
 func RegisterAgentHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
 	res.RegisterCode()
-	api.RegisterAgentRoutes(group, BuildHandler(conf, db))
+	service := buildService(conf, db)
+	api.RegisterAgentRoutes(group, api.NewHandler(service))
+	drift := application.NewDriftService(service, infra.NewRepository(db))
+	api.RegisterAgentDriftRoutes(group, api.NewDriftHandler(drift))
+	files := application.NewFilesService(service, infra.NewAgentClient(conf))
+	api.RegisterAgentFilesRoutes(group, api.NewFilesHandler(files))
 }
 func Migrate(db *gorm.DB) error  { return infra.Migrate(db) }
 func Rollback(db *gorm.DB) error { return infra.Rollback(db) }
+
+func MigrateRevisions(db *gorm.DB) error  { return infra.MigrateRevisions(db) }
+func RollbackRevisions(db *gorm.DB) error { return infra.RollbackRevisions(db) }
+
+func MigrateRollouts(db *gorm.DB) error  { return infra.MigrateRollouts(db) }
+func RollbackRollouts(db *gorm.DB) error { return infra.RollbackRollouts(db) }
+
+func MigrateContainers(db *gorm.DB) error  { return infra.MigrateContainers(db) }
+func RollbackContainers(db *gorm.DB) error { return infra.RollbackContainers(db) }
+
+func MigrateClusterTargets(db *gorm.DB) error  { return infra.MigrateClusterTargets(db) }
+func RollbackClusterTargets(db *gorm.DB) error { return infra.RollbackClusterTargets(db) }
+
+func MigrateReleases(db *gorm.DB) error  { return infra.MigrateReleases(db) }
+func RollbackReleases(db *gorm.DB) error { return infra.RollbackReleases(db) }
+
+func MigrateSecretRefs(db *gorm.DB) error  { return infra.MigrateSecretRefs(db) }
+func RollbackSecretRefs(db *gorm.DB) error { return infra.RollbackSecretRefs(db) }
+
+func MigrateHealth(db *gorm.DB) error  { return infra.MigrateHealth(db) }
+func RollbackHealth(db *gorm.DB) error { return infra.RollbackHealth(db) }
+
+func MigrateReconcile(db *gorm.DB) error  { return infra.MigrateReconcile(db) }
+func RollbackReconcile(db *gorm.DB) error { return infra.RollbackReconcile(db) }
+
+func MigrateFiles(db *gorm.DB) error  { return infra.MigrateFiles(db) }
+func RollbackFiles(db *gorm.DB) error { return infra.RollbackFiles(db) }
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/docker/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/docker/application"
+	"squirrel-dev/pkg/utils"
+)
+
+func serverID(c *gin.Context) (uint, bool) {
+	rawID := c.Param("id")
+	id, err := utils.StringToUint(rawID)
+	if err != nil {
+		zap.L().Warn("failed to parse server ID", zap.String("raw_server_id", rawID), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+// refParam returns the catch-all parameter name without its leading slash.
+func refParam(c *gin.Context, name string) string {
+	return strings.TrimPrefix(c.Param(name), "/")
+}
+
+func writeResult(c *gin.Context, data any, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(data))
+}
+
+// writeError carries the message of docker or of the failed request as
+// data, so that the user sees why a resource could not be removed.
+func writeError(c *gin.Context, err error) {
+	code := res.ErrAgentRequest
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = res.ErrServerNotFound
+	case errors.Is(err, application.ErrInvalidKind), errors.Is(err, application.ErrInvalidPrune):
+		code = res.ErrInvalidParameter
+	case errors.Is(err, application.ErrResourceNotFound):
+		code = res.ErrResourceNotFound
+	case errors.Is(err, application.ErrResourceInUse):
+		code = res.ErrResourceInUse
+	case errors.Is(err, application.ErrDockerUnavailable):
+		code = res.ErrDockerUnavailable
+	case errors.Is(err, application.ErrDockerFailed):
+		code = res.ErrDockerFailed
+	}
+	result := response.Error(code)
+	if code != res.ErrServerNotFound && code != res.ErrResourceNotFound {
+		result.Data = err.Error()
+	}
+	c.JSON(http.StatusOK, result)
+}
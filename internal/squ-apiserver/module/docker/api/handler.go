@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/docker/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/docker/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/docker/application"
+	"squirrel-dev/internal/squ-apiserver/module/docker/domain"
+)
+
+type Handler struct {
+	service *application.Service
+}
+
+func NewHandler(service *application.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List, Inspect and Remove serve one kind of resource each; the kind is
+// fixed by the route.
+func (h *Handler) List(kind domain.Kind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := serverID(c)
+		if !ok {
+			return
+		}
+		data, err := h.service.List(c.Request.Context(), id, kind)
+		writeResult(c, data, err)
+	}
+}
+
+func (h *Handler) Inspect(kind domain.Kind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := serverID(c)
+		if !ok {
+			return
+		}
+		data, err := h.service.Inspect(c.Request.Context(), id, kind, refParam(c, "ref"))
+		writeResult(c, data, err)
+	}
+}
+
+func (h *Handler) Remove(kind domain.Kind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := serverID(c)
+		if !ok {
+			return
+		}
+		err := h.service.Remove(c.Request.Context(), id, kind, refParam(c, "ref"), c.Query("force") == "true")
+		writeResult(c, "success", err)
+	}
+}
+
+func (h *Handler) Prune(c *gin.Context) {
+	id, ok := serverID(c)
+	if !ok {
+		return
+	}
+	var request req.Prune
+	if err := c.ShouldBindJSON(&request); err != nil {
+		zap.L().Warn("failed to bind docker prune request", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return
+	}
+	data, err := h.service.Prune(c.Request.Context(), id, domain.Prune(request))
+	writeResult(c, data, err)
+}
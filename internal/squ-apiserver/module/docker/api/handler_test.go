@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/docker/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/docker/application"
+)
+
+// agentStub remembers the paths it was called with.
+type agentStub struct{ paths []string }
+
+func (a *agentStub) Get(_ context.Context, _ uint, path string) (json.RawMessage, error) {
+	a.paths = append(a.paths, path)
+	return json.RawMessage(`{}`), nil
+}
+func (a *agentStub) Delete(_ context.Context, _ uint, path string) (json.RawMessage, error) {
+	a.paths = append(a.paths, path)
+	return nil, nil
+}
+func (a *agentStub) Post(_ context.Context, _ uint, path string, _ any) (json.RawMessage, error) {
+	a.paths = append(a.paths, path)
+	return nil, nil
+}
+
+func TestImageReferencesWithSlashes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	res.RegisterCode()
+	agent := &agentStub{}
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/api/v1"), NewHandler(application.NewService(agent)))
+
+	for _, test := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/docker/1/images/ghcr.io/org/app:1.0"},
+		{http.MethodDelete, "/api/v1/docker/1/images/ghcr.io%2Forg%2Fapp:1.0?force=true"},
+		{http.MethodGet, "/api/v1/docker/1/containers/web"},
+	} {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+		var body response.Response
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body.Code != 0 {
+			t.Errorf("%s %s = %d %s", test.method, test.path, recorder.Code, recorder.Body.String())
+		}
+	}
+	want := []string{
+		"/docker/images/ghcr.io%2Forg%2Fapp:1.0",
+		"/docker/images/ghcr.io%2Forg%2Fapp:1.0?force=true",
+		"/docker/containers/web",
+	}
+	if !slices.Equal(agent.paths, want) {
+		t.Fatalf("paths = %v, want %v", agent.paths, want)
+	}
+}
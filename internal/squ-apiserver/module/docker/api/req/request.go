@@ -0,0 +1,11 @@
+package req
+
+// Prune selects what to prune. Volumes prunes anonymous volumes only,
+// unless AllVolumes is set as well.
+type Prune struct {
+	Containers bool `json:"containers"`
+	Images     bool `json:"images"`
+	Volumes    bool `json:"volumes"`
+	AllVolumes bool `json:"all_volumes"`
+	DryRun     bool `json:"dry_run"`
+}
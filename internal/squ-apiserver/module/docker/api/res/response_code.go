@@ -0,0 +1,23 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrServerNotFound    = 78001
+	ErrInvalidParameter  = 78002
+	ErrResourceNotFound  = 78003
+	ErrResourceInUse     = 78004
+	ErrDockerUnavailable = 78005
+	ErrDockerFailed      = 78006
+	ErrAgentRequest      = 78007
+)
+
+func RegisterCode() {
+	response.Register(ErrServerNotFound, "server not found")
+	response.Register(ErrInvalidParameter, "invalid parameter")
+	response.Register(ErrResourceNotFound, "docker resource not found")
+	response.Register(ErrResourceInUse, "docker resource is in use")
+	response.Register(ErrDockerUnavailable, "docker is not available on the server")
+	response.Register(ErrDockerFailed, "docker request failed")
+	response.Register(ErrAgentRequest, "agent request failed")
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/squ-apiserver/module/docker/domain"
+)
+
+// The reference of a resource is a catch-all, since image references such
+// as ghcr.io/org/app:1.0 contain slashes.
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	for _, kind := range []domain.Kind{domain.KindContainers, domain.KindImages, domain.KindVolumes, domain.KindNetworks} {
+		group.GET("/docker/:id/"+string(kind), handler.List(kind))
+		group.GET("/docker/:id/"+string(kind)+"/*ref", handler.Inspect(kind))
+		group.DELETE("/docker/:id/"+string(kind)+"/*ref", handler.Remove(kind))
+	}
+	group.POST("/docker/:id/prune", handler.Prune)
+}
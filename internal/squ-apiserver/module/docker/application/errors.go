@@ -0,0 +1,13 @@
+package application
+
+import "errors"
+
+var (
+	ErrInvalidKind       = errors.New("unknown docker resource kind")
+	ErrInvalidPrune      = errors.New("prune names no resources")
+	ErrDockerUnavailable = errors.New("docker is not available on the server")
+	ErrResourceNotFound  = errors.New("docker resource not found")
+	ErrResourceInUse     = errors.New("docker resource is in use")
+	ErrDockerFailed      = errors.New("docker request failed")
+	ErrAgentRequest      = errors.New("agent request failed")
+)
@@ -0,0 +1,124 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/docker/domain"
+)
+
+// Codes of the docker endpoints of agents.
+const (
+	agentDockerUnavailable = 60001
+	agentResourceNotFound  = 60002
+	agentResourceInUse     = 60003
+	agentDockerFailed      = 60004
+	agentInvalidParameter  = 41001
+)
+
+// Service passes docker requests on to the agent of a server. The data of
+// the agent is returned as it is, so that the apiserver need not follow
+// each field the agent lists.
+type Service struct {
+	agent domain.Agent
+}
+
+func NewService(agent domain.Agent) *Service {
+	return &Service{agent: agent}
+}
+
+func (s *Service) List(ctx context.Context, serverID uint, kind domain.Kind) (json.RawMessage, error) {
+	if !kind.Valid() {
+		return nil, ErrInvalidKind
+	}
+	data, err := s.agent.Get(ctx, serverID, "/docker/"+string(kind))
+	return data, s.agentError(serverID, kind, "", err)
+}
+
+func (s *Service) Inspect(ctx context.Context, serverID uint, kind domain.Kind, ref string) (json.RawMessage, error) {
+	if !kind.Valid() {
+		return nil, ErrInvalidKind
+	}
+	data, err := s.agent.Get(ctx, serverID, "/docker/"+string(kind)+"/"+url.PathEscape(ref))
+	return data, s.agentError(serverID, kind, ref, err)
+}
+
+func (s *Service) Remove(ctx context.Context, serverID uint, kind domain.Kind, ref string, force bool) error {
+	if !kind.Valid() {
+		return ErrInvalidKind
+	}
+	path := "/docker/" + string(kind) + "/" + url.PathEscape(ref)
+	if force {
+		path += "?force=true"
+	}
+	if _, err := s.agent.Delete(ctx, serverID, path); err != nil {
+		return s.agentError(serverID, kind, ref, err)
+	}
+	zap.L().Info("docker resource removed",
+		zap.Uint("server_id", serverID),
+		zap.String("kind", string(kind)),
+		zap.String("ref", ref),
+		zap.Bool("force", force),
+	)
+	return nil
+}
+
+// Prune has the agent prune, or report what it would prune on a dry run.
+func (s *Service) Prune(ctx context.Context, serverID uint, request domain.Prune) (json.RawMessage, error) {
+	if !request.Containers && !request.Images && !request.Volumes {
+		return nil, ErrInvalidPrune
+	}
+	data, err := s.agent.Post(ctx, serverID, "/docker/prune", request)
+	if err != nil {
+		return nil, s.agentError(serverID, "", "", err)
+	}
+	if !request.DryRun {
+		zap.L().Info("docker resources pruned",
+			zap.Uint("server_id", serverID),
+			zap.Bool("containers", request.Containers),
+			zap.Bool("images", request.Images),
+			zap.Bool("volumes", request.Volumes),
+			zap.Bool("all_volumes", request.AllVolumes),
+		)
+	}
+	return data, nil
+}
+
+// agentError turns the codes of the agent into errors of this module. The
+// message of docker stays in the error for the user.
+func (s *Service) agentError(serverID uint, kind domain.Kind, ref string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	var agentErr *domain.AgentError
+	if errors.As(err, &agentErr) {
+		switch agentErr.Code {
+		case agentResourceNotFound:
+			return ErrResourceNotFound
+		case agentInvalidParameter:
+			return ErrInvalidPrune
+		case agentDockerUnavailable:
+			return fmt.Errorf("%w: %s", ErrDockerUnavailable, agentErr.Reason)
+		case agentResourceInUse:
+			return fmt.Errorf("%w: %s", ErrResourceInUse, agentErr.Reason)
+		case agentDockerFailed:
+			return fmt.Errorf("%w: %s", ErrDockerFailed, agentErr.Reason)
+		}
+	}
+	zap.L().Error("docker request to agent failed",
+		zap.Uint("server_id", serverID),
+		zap.String("kind", string(kind)),
+		zap.String("ref", ref),
+		zap.Error(err),
+	)
+	return fmt.Errorf("%w: %v", ErrAgentRequest, err)
+}
@@ -0,0 +1,107 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/docker/domain"
+)
+
+// agentStub answers paths from replies and remembers the paths it was
+// called with.
+type agentStub struct {
+	replies map[string]error
+	paths   []string
+	body    any
+}
+
+func (a *agentStub) reply(serverID uint, path string) (json.RawMessage, error) {
+	if serverID != 1 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	a.paths = append(a.paths, path)
+	if err := a.replies[path]; err != nil {
+		return nil, err
+	}
+	return json.RawMessage(`[]`), nil
+}
+
+func (a *agentStub) Get(_ context.Context, serverID uint, path string) (json.RawMessage, error) {
+	return a.reply(serverID, path)
+}
+func (a *agentStub) Delete(_ context.Context, serverID uint, path string) (json.RawMessage, error) {
+	return a.reply(serverID, path)
+}
+func (a *agentStub) Post(_ context.Context, serverID uint, path string, body any) (json.RawMessage, error) {
+	a.body = body
+	return a.reply(serverID, path)
+}
+
+func TestServiceMapsAgentErrors(t *testing.T) {
+	agent := &agentStub{replies: map[string]error{
+		"/docker/images/sha256:gone": &domain.AgentError{Code: agentResourceNotFound},
+		"/docker/images/redis:7":     &domain.AgentError{Code: agentResourceInUse, Reason: "image is being used by running container web"},
+		"/docker/volumes":            &domain.AgentError{Code: agentDockerUnavailable, Reason: "cannot connect to the docker daemon"},
+		"/docker/networks/bridge":    &domain.AgentError{Code: agentDockerFailed, Reason: "bridge is a pre-defined network"},
+		"/docker/containers/web":     errors.New("connection refused"),
+	}}
+	service := NewService(agent)
+	ctx := context.Background()
+
+	if _, err := service.List(ctx, 1, domain.KindContainers); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.List(ctx, 1, "pods"); !errors.Is(err, ErrInvalidKind) {
+		t.Fatalf("List() of an unknown kind error = %v", err)
+	}
+	if _, err := service.List(ctx, 2, domain.KindContainers); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("List() on an unknown server error = %v", err)
+	}
+	if _, err := service.Inspect(ctx, 1, domain.KindImages, "sha256:gone"); !errors.Is(err, ErrResourceNotFound) {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	if err := service.Remove(ctx, 1, domain.KindImages, "redis:7", false); !errors.Is(err, ErrResourceInUse) || !strings.Contains(err.Error(), "running container web") {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := service.Remove(ctx, 1, domain.KindImages, "redis:7", true); err != nil {
+		t.Fatalf("Remove() with force error = %v", err)
+	}
+	if _, err := service.List(ctx, 1, domain.KindVolumes); !errors.Is(err, ErrDockerUnavailable) {
+		t.Fatalf("List() error = %v", err)
+	}
+	if err := service.Remove(ctx, 1, domain.KindNetworks, "bridge", false); !errors.Is(err, ErrDockerFailed) {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := service.Inspect(ctx, 1, domain.KindContainers, "web"); !errors.Is(err, ErrAgentRequest) {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	if !slices.Contains(agent.paths, "/docker/images/redis:7?force=true") {
+		t.Fatalf("paths = %v", agent.paths)
+	}
+}
+
+func TestServicePrune(t *testing.T) {
+	agent := &agentStub{}
+	service := NewService(agent)
+	ctx := context.Background()
+
+	if _, err := service.Prune(ctx, 1, domain.Prune{DryRun: true}); !errors.Is(err, ErrInvalidPrune) {
+		t.Fatalf("Prune() of nothing error = %v", err)
+	}
+	if len(agent.paths) != 0 {
+		t.Fatalf("agent was called with %v", agent.paths)
+	}
+	request := domain.Prune{Images: true, Volumes: true, DryRun: true}
+	if _, err := service.Prune(ctx, 1, request); err != nil {
+		t.Fatal(err)
+	}
+	if agent.paths[0] != "/docker/prune" || agent.body != request {
+		t.Fatalf("paths = %v, body = %+v", agent.paths, agent.body)
+	}
+}
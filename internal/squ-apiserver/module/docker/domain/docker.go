@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Kind names a type of docker resource as agents route it.
+type Kind string
+
+const (
+	KindContainers Kind = "containers"
+	KindImages     Kind = "images"
+	KindVolumes    Kind = "volumes"
+	KindNetworks   Kind = "networks"
+)
+
+func (k Kind) Valid() bool {
+	switch k {
+	case KindContainers, KindImages, KindVolumes, KindNetworks:
+		return true
+	}
+	return false
+}
+
+// Prune selects what the agent prunes; see the agent for the rules.
+type Prune struct {
+	Containers bool `json:"containers"`
+	Images     bool `json:"images"`
+	Volumes    bool `json:"volumes"`
+	AllVolumes bool `json:"all_volumes"`
+	DryRun     bool `json:"dry_run"`
+}
+
+// AgentError is an error response of an agent. Reason is the message of
+// docker, when the agent passed it on.
+type AgentError struct {
+	Code    int
+	Message string
+	Reason  string
+}
+
+func (e *AgentError) Error() string {
+	if e.Reason != "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("agent error: code=%d, message=%s", e.Code, e.Message)
+}
+
+// Agent calls the docker endpoints of the agent of a server and returns
+// the data of its responses as they are.
+type Agent interface {
+	Get(ctx context.Context, serverID uint, path string) (json.RawMessage, error)
+	Delete(ctx context.Context, serverID uint, path string) (json.RawMessage, error)
+	Post(ctx context.Context, serverID uint, path string, body any) (json.RawMessage, error)
+}
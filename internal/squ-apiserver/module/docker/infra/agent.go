@@ -0,0 +1,87 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/docker/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	"squirrel-dev/pkg/utils"
+)
+
+const (
+	// agentTimeout bounds a docker request; listing sizes and pruning make
+	// docker walk the filesystems of containers and images.
+	agentTimeout = 5 * time.Minute
+	// maxAgentResponse bounds the size of an agent response.
+	maxAgentResponse = 64 << 20
+)
+
+type AgentClient struct {
+	config  *config.Config
+	servers serverDomain.Repository
+	http    *http.Client
+}
+
+func NewAgentClient(conf *config.Config, servers serverDomain.Repository) *AgentClient {
+	return &AgentClient{config: conf, servers: servers, http: &http.Client{Timeout: agentTimeout}}
+}
+
+func (c *AgentClient) Get(ctx context.Context, serverID uint, path string) (json.RawMessage, error) {
+	return c.do(ctx, serverID, http.MethodGet, path, nil)
+}
+
+func (c *AgentClient) Delete(ctx context.Context, serverID uint, path string) (json.RawMessage, error) {
+	return c.do(ctx, serverID, http.MethodDelete, path, nil)
+}
+
+func (c *AgentClient) Post(ctx context.Context, serverID uint, path string, body any) (json.RawMessage, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, serverID, http.MethodPost, path, data)
+}
+
+func (c *AgentClient) do(ctx context.Context, serverID uint, method, path string, body []byte) (json.RawMessage, error) {
+	server, err := c.servers.Get(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	url := utils.GenAgentUrl(c.config.Agent.Http.Scheme, server.IPAddress, server.AgentPort, c.config.Agent.Http.BaseUrl, path)
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	reply, err := c.http.Do(request)
+	if err != nil {
+		zap.L().Error("agent request failed", zap.String("url", url), zap.Uint("server_id", serverID), zap.Error(err))
+		return nil, fmt.Errorf("agent request failed: %w", err)
+	}
+	defer reply.Body.Close()
+	var value struct {
+		response.Response
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(reply.Body, maxAgentResponse)).Decode(&value); err != nil {
+		return nil, fmt.Errorf("parse agent response failed: status %d: %w", reply.StatusCode, err)
+	}
+	if value.Code != 0 {
+		agentErr := &domain.AgentError{Code: value.Code, Message: value.Message}
+		_ = json.Unmarshal(value.Data, &agentErr.Reason)
+		return nil, agentErr
+	}
+	return value.Data, nil
+}
@@ -0,0 +1,20 @@
+package docker
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/docker/api"
+	"squirrel-dev/internal/squ-apiserver/module/docker/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/docker/application"
+	"squirrel-dev/internal/squ-apiserver/module/docker/infra"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+)
+
+func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
+	res.RegisterCode()
+	agent := infra.NewAgentClient(conf, serverInfra.NewRepository(db, secretInfra.NewCipher(conf)))
+	api.RegisterRoutes(group, api.NewHandler(application.NewService(agent)))
+}
@@ -43,6 +43,8 @@ func writeError(c *gin.Context, err error) {
 		code = res.ErrInvalidMonitorConfig
 	case errors.Is(err, application.ErrServerNotFound):
 		code = res.ErrServerNotFound
+	case errors.Is(err, application.ErrInvalidTarget):
+		code = res.ErrInvalidTarget
 	}
 	c.JSON(http.StatusOK, response.Error(code))
 }
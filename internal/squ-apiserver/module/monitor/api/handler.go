@@ -1,11 +1,16 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/monitor/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/monitor/application"
 	"squirrel-dev/internal/squ-apiserver/module/monitor/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type Handler struct {
@@ -22,6 +27,27 @@ func (h *Handler) Stats(c *gin.Context) {
 	h.writeForServer(c, h.service.Stats)
 }
 
+// TargetStats returns the snapshot of every server matched by the group and
+// selector query parameters.
+func (h *Handler) TargetStats(c *gin.Context) {
+	values, err := h.service.TargetStats(c.Request.Context(), serverDomain.Target{
+		Group: c.Query("group"), Selector: c.Query("selector"),
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	result := make([]res.TargetStats, 0, len(values))
+	for _, value := range values {
+		item := res.TargetStats{ServerID: value.ServerID, Data: value.Result.Data}
+		if value.Error != nil {
+			item.Error = value.Error.Error()
+		}
+		result = append(result, item)
+	}
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
 func (h *Handler) DiskIO(c *gin.Context) {
 	h.writeForServer(c, func(id uint) (domain.Result, error) {
 		device := c.Param("device")
@@ -1 +1,8 @@
 package res
+
+// TargetStats is the current monitor snapshot of one selected server.
+type TargetStats struct {
+	ServerID uint   `json:"server_id"`
+	Data     any    `json:"data,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
@@ -7,6 +7,7 @@ const (
 	ErrInvalidMonitorConfig = 81002
 	ErrMonitorDataNotFound  = 81003
 	ErrServerNotFound       = 81004
+	ErrInvalidTarget        = 81005
 )
 
 func RegisterCode() {
@@ -14,4 +15,5 @@ func RegisterCode() {
 	response.Register(ErrInvalidMonitorConfig, "invalid monitor configuration")
 	response.Register(ErrMonitorDataNotFound, "monitor data not found")
 	response.Register(ErrServerNotFound, "server not found")
+	response.Register(ErrInvalidTarget, "invalid monitor target")
 }
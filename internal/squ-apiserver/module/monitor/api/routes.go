@@ -3,6 +3,7 @@ package api
 import "github.com/gin-gonic/gin"
 
 func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/monitor/stats", handler.TargetStats)
 	group.GET("/monitor/stats/:serverId", handler.Stats)
 	group.GET("/monitor/stats/io/:serverId/:device", handler.DiskIO)
 	group.GET("/monitor/stats/io/:serverId/all", handler.AllDiskIO)
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrMonitorFailed  = errors.New("monitor request failed")
 	ErrServerNotFound = errors.New("server not found")
+	ErrInvalidTarget  = errors.New("invalid monitor target")
 )
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"squirrel-dev/internal/squ-apiserver/module/monitor/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type Service struct {
@@ -20,10 +22,57 @@ func NewService(servers domain.ServerReader, agent domain.AgentClient) *Service
 	return &Service{servers: servers, agent: agent}
 }
 
+// TargetStats is the snapshot of one server selected by a target.
+type TargetStats struct {
+	ServerID uint
+	Result   domain.Result
+	Error    error
+}
+
+// statsConcurrency bounds the agent requests of one target query.
+const statsConcurrency = 10
+
 func (s *Service) Stats(serverID uint) (domain.Result, error) {
 	return s.callAgent(serverID, "monitor/stats")
 }
 
+// TargetStats returns the current snapshot of every server matched by
+// target. Unreachable agents are reported per server.
+func (s *Service) TargetStats(ctx context.Context, target serverDomain.Target) ([]TargetStats, error) {
+	servers, err := s.servers.Select(ctx, target)
+	if err != nil {
+		zap.L().Warn("failed to resolve monitor target",
+			zap.String("group", target.Group),
+			zap.String("selector", target.Selector),
+			zap.Error(err),
+		)
+		return nil, ErrInvalidTarget
+	}
+	result := make([]TargetStats, len(servers))
+	semaphore := make(chan struct{}, statsConcurrency)
+	var wg sync.WaitGroup
+	for index, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			value, err := s.agent.Get(ctx, server, "monitor/stats")
+			if err != nil {
+				zap.L().Warn("failed to get monitor data from agent",
+					zap.Uint("server_id", server.ID),
+					zap.String("agent_path", "monitor/stats"),
+					zap.Error(err),
+				)
+				err = ErrMonitorFailed
+			}
+			result[index] = TargetStats{ServerID: server.ID, Result: value, Error: err}
+		}()
+	}
+	wg.Wait()
+	return result, nil
+}
+
 func (s *Service) DiskIO(serverID uint, device string) (domain.Result, error) {
 	return s.callAgent(serverID, fmt.Sprintf("monitor/stats/io/%s", device))
 }
@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 
 	"squirrel-dev/internal/squ-apiserver/module/monitor/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type serverStub struct{ err error }
@@ -16,6 +17,10 @@ func (s serverStub) Get(context.Context, uint) (domain.Server, error) {
 	return domain.Server{ID: 7, IPAddress: "127.0.0.1", AgentPort: 10750}, s.err
 }
 
+func (s serverStub) Select(context.Context, serverDomain.Target) ([]domain.Server, error) {
+	return []domain.Server{{ID: 7}, {ID: 8}}, s.err
+}
+
 type agentStub struct {
 	path   string
 	result domain.Result
@@ -52,3 +57,19 @@ func TestMonitorErrorMapping(t *testing.T) {
 		t.Fatalf("agent failure error=%v", err)
 	}
 }
+
+func TestTargetStatsReportsEachServer(t *testing.T) {
+	agent := &agentStub{result: domain.Result{Data: map[string]any{"cpu": 1}}}
+	service := NewService(serverStub{}, agent)
+	values, err := service.TargetStats(context.Background(), serverDomain.Target{Selector: "env=prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0].ServerID != 7 || values[1].ServerID != 8 || values[1].Error != nil {
+		t.Fatalf("values = %#v", values)
+	}
+	service = NewService(serverStub{err: errors.New("bad selector")}, agent)
+	if _, err := service.TargetStats(context.Background(), serverDomain.Target{Selector: "="}); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("error = %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"context"
+
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type Server struct {
@@ -17,6 +19,7 @@ type Result struct {
 
 type ServerReader interface {
 	Get(context.Context, uint) (Server, error)
+	Select(context.Context, serverDomain.Target) ([]Server, error)
 }
 
 type AgentClient interface {
@@ -9,13 +9,14 @@ import (
 	"squirrel-dev/internal/squ-apiserver/module/monitor/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/monitor/application"
 	"squirrel-dev/internal/squ-apiserver/module/monitor/infra"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
 	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
 )
 
 func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
 	res.RegisterCode()
 	service := application.NewService(
-		infra.NewServerReader(serverInfra.NewRepository(db)),
+		infra.NewServerReader(serverInfra.NewRepository(db, secretInfra.NewCipher(conf))),
 		infra.NewAgentClient(conf),
 	)
 	api.RegisterRoutes(group, api.NewHandler(service))
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/registry/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/registry/application"
+	"squirrel-dev/internal/squ-apiserver/module/registry/domain"
+	"squirrel-dev/pkg/utils"
+)
+
+func bindRequest[T any](c *gin.Context) (T, bool) {
+	var request T
+	if err := c.ShouldBindJSON(&request); err != nil {
+		zap.L().Warn("failed to bind registry request", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return request, false
+	}
+	return request, true
+}
+
+func registryID(c *gin.Context) (uint, bool) {
+	rawID := c.Param("id")
+	id, err := utils.StringToUint(rawID)
+	if err != nil {
+		zap.L().Warn("failed to parse registry ID", zap.String("raw_registry_id", rawID), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+func writeResult(c *gin.Context, data any, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(data))
+}
+
+// writeError carries the reason of a rejected registry or a failed login as
+// data; the user has to act on it.
+func writeError(c *gin.Context, err error) {
+	code := res.ErrRegistryUpdateFailed
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = res.ErrRegistryNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		code = res.ErrRegistryAlreadyExists
+	case errors.Is(err, application.ErrInvalidRegistry):
+		code = res.ErrInvalidRegistry
+	case errors.Is(err, domain.ErrRegistryInUse):
+		code = res.ErrRegistryInUse
+	case errors.Is(err, application.ErrRegistryLogin):
+		code = res.ErrRegistryLogin
+	}
+	result := response.Error(code)
+	if code == res.ErrInvalidRegistry || code == res.ErrRegistryLogin {
+		result.Data = err.Error()
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"squirrel-dev/internal/squ-apiserver/module/registry/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/registry/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/registry/application"
+	"squirrel-dev/internal/squ-apiserver/module/registry/domain"
+)
+
+func toChange(value req.Registry) application.Change {
+	return application.Change{Name: value.Name, Description: value.Description,
+		Server: value.Server, Username: value.Username, Password: value.Password}
+}
+
+func toResponse(value domain.Registry) res.Registry {
+	return res.Registry{ID: value.ID, Name: value.Name, Description: value.Description, Server: value.Server,
+		Username: value.Username, CreatedAt: value.CreatedAt, UpdatedAt: value.UpdatedAt}
+}
@@ -0,0 +1,16 @@
+package req
+
+// Registry adds or updates a private registry. Password may be left empty on
+// update to keep the stored one.
+type Registry struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Server      string `json:"server"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+}
+
+// Login names the server whose agent tries the credentials.
+type Login struct {
+	ServerID uint `json:"server_id" binding:"required"`
+}
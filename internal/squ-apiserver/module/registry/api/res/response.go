@@ -0,0 +1,14 @@
+package res
+
+import "time"
+
+// Registry never carries the password.
+type Registry struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Server      string    `json:"server"`
+	Username    string    `json:"username"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
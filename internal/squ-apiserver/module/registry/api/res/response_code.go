@@ -0,0 +1,23 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrRegistryNotFound      = 76001
+	ErrRegistryAlreadyExists = 76002
+	ErrInvalidParameter      = 76003
+	ErrInvalidRegistry       = 76004
+	ErrRegistryInUse         = 76005
+	ErrRegistryLogin         = 76006
+	ErrRegistryUpdateFailed  = 76007
+)
+
+func RegisterCode() {
+	response.Register(ErrRegistryNotFound, "registry not found")
+	response.Register(ErrRegistryAlreadyExists, "registry already exists")
+	response.Register(ErrInvalidParameter, "invalid parameter")
+	response.Register(ErrInvalidRegistry, "invalid registry")
+	response.Register(ErrRegistryInUse, "registry is used by applications")
+	response.Register(ErrRegistryLogin, "registry login failed")
+	response.Register(ErrRegistryUpdateFailed, "registry update failed")
+}
@@ -0,0 +1,12 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/registry", handler.List)
+	group.POST("/registry", handler.Add)
+	group.GET("/registry/:id", handler.Get)
+	group.POST("/registry/:id", handler.Update)
+	group.DELETE("/registry/:id", handler.Delete)
+	group.POST("/registry/:id/login", handler.Login)
+}
@@ -0,0 +1,8 @@
+package application
+
+import "errors"
+
+var (
+	ErrInvalidRegistry = errors.New("invalid registry")
+	ErrRegistryLogin   = errors.New("registry login failed")
+)
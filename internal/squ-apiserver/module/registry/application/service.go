@@ -0,0 +1,163 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/registry/domain"
+)
+
+// Change is a registry as submitted by a user. An empty Password on update
+// keeps the stored one, so clients never need to read it back.
+type Change struct {
+	Name        string
+	Description string
+	Server      string
+	Username    string
+	Password    string
+}
+
+type Service struct {
+	repository domain.Repository
+	agent      domain.Agent
+}
+
+func NewService(repository domain.Repository, agent domain.Agent) *Service {
+	return &Service{repository: repository, agent: agent}
+}
+
+func (s *Service) List(ctx context.Context) ([]domain.Registry, error) {
+	registries, err := s.repository.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to list registries", zap.Error(err))
+		return nil, err
+	}
+	return registries, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uint) (domain.Registry, error) {
+	registry, err := s.repository.Get(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get registry", zap.Uint("registry_id", id), zap.Error(err))
+		return domain.Registry{}, err
+	}
+	return registry, nil
+}
+
+// Add stores a registry. The credentials are not checked here; Login tries
+// them from a server, since only agents talk to registries.
+func (s *Service) Add(ctx context.Context, change Change) (domain.Registry, error) {
+	if change.Password == "" {
+		return domain.Registry{}, fmt.Errorf("%w: password is required", ErrInvalidRegistry)
+	}
+	registry := domain.Registry{}
+	if err := apply(&registry, change); err != nil {
+		return domain.Registry{}, err
+	}
+	if err := s.repository.Add(ctx, &registry); err != nil {
+		zap.L().Error("failed to add registry", zap.String("registry_name", registry.Name), zap.Error(err))
+		return domain.Registry{}, err
+	}
+	zap.L().Info("registry added",
+		zap.Uint("registry_id", registry.ID),
+		zap.String("registry_name", registry.Name),
+		zap.String("server", registry.Server),
+	)
+	return registry, nil
+}
+
+func (s *Service) Update(ctx context.Context, id uint, change Change) (domain.Registry, error) {
+	registry, err := s.Get(ctx, id)
+	if err != nil {
+		return domain.Registry{}, err
+	}
+	if err := apply(&registry, change); err != nil {
+		return domain.Registry{}, err
+	}
+	if err := s.repository.Update(ctx, &registry); err != nil {
+		zap.L().Error("failed to update registry", zap.Uint("registry_id", id), zap.Error(err))
+		return domain.Registry{}, err
+	}
+	return registry, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	count, err := s.repository.CountApplications(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to count registry applications", zap.Uint("registry_id", id), zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		zap.L().Warn("refusing to delete registry with applications",
+			zap.Uint("registry_id", id),
+			zap.Int64("applications", count),
+		)
+		return domain.ErrRegistryInUse
+	}
+	if err := s.repository.Delete(ctx, id); err != nil {
+		zap.L().Error("failed to delete registry", zap.Uint("registry_id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Login has the agent of a server log in to a registry, which checks both
+// the credentials and that the server can reach the registry.
+func (s *Service) Login(ctx context.Context, id, serverID uint) error {
+	registry, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.agent.Login(ctx, serverID, registry); err != nil {
+		zap.L().Warn("registry login failed",
+			zap.Uint("registry_id", id),
+			zap.Uint("server_id", serverID),
+			zap.String("server", registry.Server),
+			zap.Error(err),
+		)
+		return fmt.Errorf("%w: %v", ErrRegistryLogin, err)
+	}
+	return nil
+}
+
+func apply(registry *domain.Registry, change Change) error {
+	name := strings.TrimSpace(change.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRegistry)
+	}
+	server, err := normalizeServer(change.Server)
+	if err != nil {
+		return err
+	}
+	username := strings.TrimSpace(change.Username)
+	if username == "" {
+		return fmt.Errorf("%w: username is required", ErrInvalidRegistry)
+	}
+	if change.Password != "" {
+		registry.Password = change.Password
+	}
+	registry.Name, registry.Description, registry.Server, registry.Username = name, change.Description, server, username
+	return nil
+}
+
+// normalizeServer reduces a registry address to the host and port that
+// image references start with, so "https://registry.example.com/" and
+// "registry.example.com" name the same registry.
+func normalizeServer(value string) (string, error) {
+	server := strings.TrimSpace(value)
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	server = strings.TrimRight(server, "/")
+	if server == "" {
+		return "", fmt.Errorf("%w: server is required", ErrInvalidRegistry)
+	}
+	if strings.ContainsAny(server, "/ \t@") {
+		return "", fmt.Errorf("%w: server %q must be a host with an optional port", ErrInvalidRegistry, value)
+	}
+	return strings.ToLower(server), nil
+}
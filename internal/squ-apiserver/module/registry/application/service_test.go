@@ -0,0 +1,129 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/registry/domain"
+)
+
+type repositoryStub struct {
+	registries   []domain.Registry
+	applications int64
+	deleted      []uint
+}
+
+func (r *repositoryStub) List(context.Context) ([]domain.Registry, error) { return r.registries, nil }
+func (r *repositoryStub) Get(_ context.Context, id uint) (domain.Registry, error) {
+	for _, value := range r.registries {
+		if value.ID == id {
+			return value, nil
+		}
+	}
+	return domain.Registry{}, gorm.ErrRecordNotFound
+}
+func (r *repositoryStub) Add(_ context.Context, value *domain.Registry) error {
+	value.ID = uint(len(r.registries) + 1)
+	r.registries = append(r.registries, *value)
+	return nil
+}
+func (r *repositoryStub) Update(_ context.Context, value *domain.Registry) error {
+	for i := range r.registries {
+		if r.registries[i].ID == value.ID {
+			r.registries[i] = *value
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) Delete(_ context.Context, id uint) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+func (r *repositoryStub) CountApplications(context.Context, uint) (int64, error) {
+	return r.applications, nil
+}
+
+type agentStub struct {
+	err    error
+	logins []domain.Registry
+}
+
+func (a *agentStub) Login(_ context.Context, _ uint, registry domain.Registry) error {
+	a.logins = append(a.logins, registry)
+	return a.err
+}
+
+func TestAddValidatesRegistry(t *testing.T) {
+	service := NewService(&repositoryStub{}, &agentStub{})
+	ctx := context.Background()
+	cases := []Change{
+		{Name: "harbor", Server: "harbor.example.com", Username: "ci"},
+		{Name: " ", Server: "harbor.example.com", Username: "ci", Password: "secret"},
+		{Name: "harbor", Username: "ci", Password: "secret"},
+		{Name: "harbor", Server: "harbor.example.com/library", Username: "ci", Password: "secret"},
+		{Name: "harbor", Server: "harbor.example.com", Password: "secret"},
+	}
+	for _, change := range cases {
+		if _, err := service.Add(ctx, change); !errors.Is(err, ErrInvalidRegistry) {
+			t.Errorf("Add(%+v) error = %v, want %v", change, err, ErrInvalidRegistry)
+		}
+	}
+
+	registry, err := service.Add(ctx, Change{Name: "harbor", Server: "https://Harbor.example.com:8443/",
+		Username: "ci", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if registry.Server != "harbor.example.com:8443" {
+		t.Fatalf("server = %q", registry.Server)
+	}
+}
+
+func TestUpdateKeepsPassword(t *testing.T) {
+	repository := &repositoryStub{registries: []domain.Registry{{ID: 1, Name: "harbor",
+		Server: "harbor.example.com", Username: "ci", Password: "secret"}}}
+	service := NewService(repository, &agentStub{})
+
+	registry, err := service.Update(context.Background(), 1, Change{Name: "harbor", Server: "harbor.example.com", Username: "deploy"})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if registry.Password != "secret" || registry.Username != "deploy" {
+		t.Fatalf("registry = %+v", registry)
+	}
+}
+
+func TestDeleteRefusesRegistryInUse(t *testing.T) {
+	repository := &repositoryStub{registries: []domain.Registry{{ID: 1, Name: "harbor"}}, applications: 1}
+	service := NewService(repository, &agentStub{})
+
+	if err := service.Delete(context.Background(), 1); !errors.Is(err, domain.ErrRegistryInUse) {
+		t.Fatalf("Delete() error = %v, want %v", err, domain.ErrRegistryInUse)
+	}
+	repository.applications = 0
+	if err := service.Delete(context.Background(), 1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(repository.deleted) != 1 {
+		t.Fatalf("deleted = %v", repository.deleted)
+	}
+}
+
+func TestLoginReportsReasonOfRegistry(t *testing.T) {
+	repository := &repositoryStub{registries: []domain.Registry{{ID: 1, Name: "harbor",
+		Server: "harbor.example.com", Username: "ci", Password: "secret"}}}
+	agent := &agentStub{err: errors.New("unauthorized: incorrect username or password")}
+	service := NewService(repository, agent)
+
+	err := service.Login(context.Background(), 1, 3)
+	if !errors.Is(err, ErrRegistryLogin) || !strings.Contains(err.Error(), "incorrect username") {
+		t.Fatalf("Login() error = %v, want %v", err, ErrRegistryLogin)
+	}
+	if len(agent.logins) != 1 || agent.logins[0].Password != "secret" {
+		t.Fatalf("logins = %+v", agent.logins)
+	}
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRegistryInUse is returned when a registry that applications still pull
+// from is deleted.
+var ErrRegistryInUse = errors.New("registry is used by applications")
+
+// Registry is a private image registry. Server is the registry host as image
+// references name it, with an optional port. Password is only held in plain
+// text in memory; the repository encrypts it at rest.
+type Registry struct {
+	ID          uint
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Description string
+	Server      string
+	Username    string
+	Password    string
+}
+
+// Cipher encrypts registry passwords at rest.
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+type Repository interface {
+	// List returns registries without their passwords.
+	List(context.Context) ([]Registry, error)
+	Get(context.Context, uint) (Registry, error)
+	Add(context.Context, *Registry) error
+	Update(context.Context, *Registry) error
+	Delete(context.Context, uint) error
+	// CountApplications counts the applications that pull from a registry.
+	CountApplications(context.Context, uint) (int64, error)
+}
+
+// Agent has the agent of a server log in to a registry with its docker
+// engine. The error carries the registry's reason for a failed login.
+type Agent interface {
+	Login(ctx context.Context, serverID uint, registry Registry) error
+}
@@ -0,0 +1,62 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/registry/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	"squirrel-dev/pkg/httpclient"
+	"squirrel-dev/pkg/utils"
+)
+
+// loginPath is the agent endpoint that logs in to a registry.
+const loginPath = "/application/registry/login"
+
+// AgentClient asks the agent of a server to log in to a registry.
+type AgentClient struct {
+	config  *config.Config
+	servers serverDomain.Repository
+	http    *httpclient.Client
+}
+
+func NewAgentClient(conf *config.Config, servers serverDomain.Repository) *AgentClient {
+	return &AgentClient{config: conf, servers: servers, http: httpclient.NewClient(30 * time.Second)}
+}
+
+func (c *AgentClient) Login(ctx context.Context, serverID uint, registry domain.Registry) error {
+	server, err := c.servers.Get(ctx, serverID)
+	if err != nil {
+		return err
+	}
+	url := utils.GenAgentUrl(c.config.Agent.Http.Scheme, server.IPAddress, server.AgentPort,
+		c.config.Agent.Http.BaseUrl, loginPath)
+	body, err := c.http.Post(url, map[string]string{
+		"server":   registry.Server,
+		"username": registry.Username,
+		"password": registry.Password,
+	}, nil)
+	if err != nil {
+		zap.L().Error("agent request failed", zap.String("url", url), zap.Uint("server_id", serverID), zap.Error(err))
+		return fmt.Errorf("agent request failed: %w", err)
+	}
+	var result response.Response
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("parse agent response failed: %w", err)
+	}
+	if result.Code != 0 {
+		// The agent puts the registry's reason into data.
+		if reason, ok := result.Data.(string); ok && reason != "" {
+			return errors.New(reason)
+		}
+		return fmt.Errorf("agent error: code=%d, message=%s", result.Code, result.Message)
+	}
+	return nil
+}
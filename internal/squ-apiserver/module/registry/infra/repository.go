@@ -0,0 +1,122 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/registry/domain"
+)
+
+type registryModel struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Name        string         `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string
+	Server      string `gorm:"type:varchar(255);not null"`
+	Username    string `gorm:"type:varchar(255)"`
+	Password    string `gorm:"type:text;not null"`
+}
+
+func (registryModel) TableName() string { return "registries" }
+
+// Repository stores registries with their passwords encrypted by cipher.
+// Only Get decrypts them, so that a password that no longer decrypts does
+// not hide the other registries.
+type Repository struct {
+	db     *gorm.DB
+	cipher domain.Cipher
+}
+
+func NewRepository(db *gorm.DB, cipher domain.Cipher) *Repository {
+	return &Repository{db: db, cipher: cipher}
+}
+
+func (r *Repository) List(ctx context.Context) ([]domain.Registry, error) {
+	var models []registryModel
+	if err := r.db.WithContext(ctx).Order("id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.Registry, 0, len(models))
+	for _, model := range models {
+		result = append(result, toDomain(model))
+	}
+	return result, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id uint) (domain.Registry, error) {
+	var model registryModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		return domain.Registry{}, err
+	}
+	password, err := r.cipher.Decrypt(model.Password)
+	if err != nil {
+		return domain.Registry{}, fmt.Errorf("registry %q: %w", model.Name, err)
+	}
+	value := toDomain(model)
+	value.Password = password
+	return value, nil
+}
+
+func (r *Repository) Add(ctx context.Context, value *domain.Registry) error {
+	model, err := r.toModel(*value)
+	if err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	value.ID, value.CreatedAt, value.UpdatedAt = model.ID, model.CreatedAt, model.UpdatedAt
+	return nil
+}
+
+// Update writes every field so that the description can be cleared. The
+// password is encrypted again with a fresh nonce.
+func (r *Repository) Update(ctx context.Context, value *domain.Registry) error {
+	model, err := r.toModel(*value)
+	if err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Model(&registryModel{ID: value.ID}).
+		Select("name", "description", "server", "username", "password").Updates(&model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	value.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&registryModel{}, id).Error
+}
+
+// CountApplications reads the applications table directly; the application
+// module owns it but deleting a registry must not break later deploys.
+func (r *Repository) CountApplications(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("applications").
+		Where("registry_id = ? AND deleted_at IS NULL", id).Count(&count).Error
+	return count, err
+}
+
+func (r *Repository) toModel(v domain.Registry) (registryModel, error) {
+	password, err := r.cipher.Encrypt(v.Password)
+	if err != nil {
+		return registryModel{}, fmt.Errorf("encrypt registry %q: %w", v.Name, err)
+	}
+	return registryModel{ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+		Description: v.Description, Server: v.Server, Username: v.Username, Password: password}, nil
+}
+
+// toDomain maps a registry without its password.
+func toDomain(v registryModel) domain.Registry {
+	return domain.Registry{ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+		Description: v.Description, Server: v.Server, Username: v.Username}
+}
@@ -0,0 +1,61 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/registry/domain"
+)
+
+// cipherStub prefixes passwords and refuses values without the prefix.
+type cipherStub struct{}
+
+func (cipherStub) Encrypt(plaintext string) (string, error) { return "enc:" + plaintext, nil }
+func (cipherStub) Decrypt(ciphertext string) (string, error) {
+	value, ok := strings.CutPrefix(ciphertext, "enc:")
+	if !ok {
+		return "", errors.New("malformed secret ciphertext")
+	}
+	return value, nil
+}
+
+func TestListDoesNotDecryptPasswords(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repository := NewRepository(db, cipherStub{})
+	harbor := domain.Registry{Name: "harbor", Server: "harbor.example.com", Username: "ci", Password: "secret"}
+	broken := domain.Registry{Name: "broken", Server: "registry.example.com", Username: "ci", Password: "lost"}
+	for _, value := range []*domain.Registry{&harbor, &broken} {
+		if err := repository.Add(ctx, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(&registryModel{ID: broken.ID}).Update("password", "corrupt").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := repository.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0].Password != "" || values[1].Password != "" {
+		t.Fatalf("registries = %+v", values)
+	}
+	if value, err := repository.Get(ctx, harbor.ID); err != nil || value.Password != "secret" {
+		t.Fatalf("Get() = %+v, %v", value, err)
+	}
+	if _, err := repository.Get(ctx, broken.ID); err == nil {
+		t.Fatal("Get() of a corrupt password succeeded")
+	}
+}
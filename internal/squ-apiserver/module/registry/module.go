@@ -0,0 +1,23 @@
+package registry
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/registry/api"
+	"squirrel-dev/internal/squ-apiserver/module/registry/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/registry/application"
+	"squirrel-dev/internal/squ-apiserver/module/registry/infra"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+)
+
+func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
+	res.RegisterCode()
+	repository := infra.NewRepository(db, secretInfra.NewCipher(conf))
+	agent := infra.NewAgentClient(conf, serverInfra.NewRepository(db, secretInfra.NewCipher(conf)))
+	api.RegisterRoutes(group, api.NewHandler(application.NewService(repository, agent)))
+}
+func Migrate(db *gorm.DB) error  { return infra.Migrate(db) }
+func Rollback(db *gorm.DB) error { return infra.Rollback(db) }
@@ -53,6 +53,10 @@ func writeError(c *gin.Context, err error) {
 		code = res.ErrScriptExecutionFailed
 	case errors.Is(err, application.ErrServerNotFound):
 		code = res.ErrServerNotFound
+	case errors.Is(err, application.ErrInvalidTarget):
+		code = res.ErrInvalidTarget
+	case errors.Is(err, application.ErrNoTargets):
+		code = res.ErrNoTargetServers
 	}
 	c.JSON(http.StatusOK, response.Error(code))
 }
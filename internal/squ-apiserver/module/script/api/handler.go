@@ -6,6 +6,7 @@ import (
 	"squirrel-dev/internal/squ-apiserver/module/script/api/req"
 	"squirrel-dev/internal/squ-apiserver/module/script/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/script/application"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type Handler struct {
@@ -73,8 +74,18 @@ func (h *Handler) Execute(c *gin.Context) {
 	if !ok {
 		return
 	}
-	data, err := h.service.Execute(c.Request.Context(), toExecuteRequest(request))
-	writeResult(c, data, err)
+	target := serverDomain.Target{ServerIDs: request.ServerIDs, Group: request.Group, Selector: request.Selector}
+	if target.Empty() {
+		data, err := h.service.Execute(c.Request.Context(), toExecuteRequest(request))
+		writeResult(c, data, err)
+		return
+	}
+	values, err := h.service.ExecuteTarget(c.Request.Context(), request.ScriptID, target)
+	result := make([]res.TargetResult, 0, len(values))
+	for _, value := range values {
+		result = append(result, res.TargetResult{ServerID: value.ServerID, Message: value.Message, Error: value.Error})
+	}
+	writeResult(c, result, err)
 }
 
 func (h *Handler) ReceiveResult(c *gin.Context) {
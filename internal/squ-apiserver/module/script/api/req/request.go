@@ -6,9 +6,14 @@ type Script struct {
 	Content string `json:"content"`
 }
 
+// ServerIDs, Group and Selector run the script on several servers and take
+// precedence over ServerID when any of them is set.
 type ExecuteScript struct {
-	ScriptID uint `json:"script_id"`
-	ServerID uint `json:"server_id"`
+	ScriptID  uint   `json:"script_id"`
+	ServerID  uint   `json:"server_id"`
+	ServerIDs []uint `json:"server_ids"`
+	Group     string `json:"group"`
+	Selector  string `json:"selector"`
 }
 
 type ScriptResultReport struct {
@@ -6,6 +6,13 @@ type Script struct {
 	Content string `json:"content"`
 }
 
+// TargetResult is the outcome of submitting a script to one selected server.
+type TargetResult struct {
+	ServerID uint   `json:"server_id"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
 type ScriptResult struct {
 	ID           uint   `json:"id"`
 	TaskID       uint64 `json:"task_id"`
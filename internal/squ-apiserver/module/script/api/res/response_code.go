@@ -12,6 +12,8 @@ const (
 	ErrScriptExecutionFailed = 80021
 	ErrScriptTimeout         = 80022
 	ErrServerNotFound        = 80023
+	ErrInvalidTarget         = 80024
+	ErrNoTargetServers       = 80025
 )
 
 func RegisterCode() {
@@ -24,4 +26,6 @@ func RegisterCode() {
 	response.Register(ErrScriptExecutionFailed, "script execution failed")
 	response.Register(ErrScriptTimeout, "script execution timeout")
 	response.Register(ErrServerNotFound, "server not found")
+	response.Register(ErrInvalidTarget, "invalid script target")
+	response.Register(ErrNoTargetServers, "no servers match the script target")
 }
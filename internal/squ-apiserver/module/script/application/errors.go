@@ -12,6 +12,8 @@ var (
 	ErrInvalidContent = errors.New("invalid script content")
 	ErrExecuteFailed  = errors.New("script execution failed")
 	ErrServerNotFound = errors.New("server not found")
+	ErrInvalidTarget  = errors.New("invalid script target")
+	ErrNoTargets      = errors.New("no servers match the script target")
 )
 
 func repositoryError(err error) error {
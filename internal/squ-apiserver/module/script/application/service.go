@@ -7,6 +7,7 @@ import (
 	"go.uber.org/zap"
 
 	"squirrel-dev/internal/squ-apiserver/module/script/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type ScriptRequest struct {
@@ -20,6 +21,13 @@ type ExecuteRequest struct {
 	ServerID uint
 }
 
+// TargetResult is the outcome of submitting a script to one server.
+type TargetResult struct {
+	ServerID uint
+	Message  string
+	Error    string
+}
+
 type ResultReport struct {
 	TaskID       uint
 	ScriptID     uint
@@ -197,6 +205,35 @@ func (s *Service) Execute(ctx context.Context, request ExecuteRequest) (string,
 	return "script execution task submitted", nil
 }
 
+// ExecuteTarget submits a script to every server matched by target. Each
+// server gets its own task; a failure on one does not stop the others.
+func (s *Service) ExecuteTarget(ctx context.Context, scriptID uint, target serverDomain.Target) ([]TargetResult, error) {
+	servers, err := s.servers.Select(ctx, target)
+	if err != nil {
+		zap.L().Warn("failed to resolve script target",
+			zap.Uint("script_id", scriptID),
+			zap.Uints("server_ids", target.ServerIDs),
+			zap.String("group", target.Group),
+			zap.String("selector", target.Selector),
+			zap.Error(err),
+		)
+		return nil, ErrInvalidTarget
+	}
+	if len(servers) == 0 {
+		return nil, ErrNoTargets
+	}
+	result := make([]TargetResult, 0, len(servers))
+	for _, server := range servers {
+		message, err := s.Execute(ctx, ExecuteRequest{ScriptID: scriptID, ServerID: server.ID})
+		value := TargetResult{ServerID: server.ID, Message: message}
+		if err != nil {
+			value.Error = err.Error()
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
 func (s *Service) ReceiveResult(ctx context.Context, request ResultReport) (string, error) {
 	// The old service deliberately accepted reports for a missing script.
 	if _, err := s.repository.Get(ctx, request.ScriptID); err != nil {
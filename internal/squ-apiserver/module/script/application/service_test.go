@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 
 	"squirrel-dev/internal/squ-apiserver/module/script/domain"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type repositoryStub struct {
@@ -53,6 +54,12 @@ type serverStub struct {
 }
 
 func (s serverStub) Get(context.Context, uint) (domain.Server, error) { return s.server, s.err }
+func (s serverStub) Select(context.Context, serverDomain.Target) ([]domain.Server, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []domain.Server{s.server}, nil
+}
 
 type agentStub struct{ err error }
 
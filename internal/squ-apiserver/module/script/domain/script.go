@@ -3,6 +3,8 @@ package domain
 import (
 	"context"
 	"time"
+
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type Script struct {
@@ -43,6 +45,7 @@ type Repository interface {
 
 type ServerReader interface {
 	Get(context.Context, uint) (Server, error)
+	Select(context.Context, serverDomain.Target) ([]Server, error)
 }
 
 type AgentClient interface {
@@ -12,6 +12,7 @@ import (
 	"squirrel-dev/internal/squ-apiserver/config"
 	"squirrel-dev/internal/squ-apiserver/module/script/domain"
 	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
 	"squirrel-dev/pkg/httpclient"
 	"squirrel-dev/pkg/utils"
 )
@@ -32,6 +33,18 @@ func (r *ServerReader) Get(ctx context.Context, id uint) (domain.Server, error)
 	return domain.Server{ID: server.ID, IPAddress: server.IPAddress, AgentPort: server.AgentPort}, nil
 }
 
+func (r *ServerReader) Select(ctx context.Context, target serverDomain.Target) ([]domain.Server, error) {
+	servers, err := serverInfra.Select(ctx, r.repository, target)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.Server, 0, len(servers))
+	for _, server := range servers {
+		result = append(result, domain.Server{ID: server.ID, IPAddress: server.IPAddress, AgentPort: server.AgentPort})
+	}
+	return result, nil
+}
+
 type AgentClient struct {
 	config *config.Config
 	http   *httpclient.Client
@@ -9,13 +9,14 @@ import (
 	"squirrel-dev/internal/squ-apiserver/module/script/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/script/application"
 	"squirrel-dev/internal/squ-apiserver/module/script/infra"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
 	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
 )
 
 func BuildHandler(conf *config.Config, db *gorm.DB) *api.Handler {
 	service := application.NewService(
 		infra.NewRepository(db),
-		infra.NewServerReader(serverInfra.NewRepository(db)),
+		infra.NewServerReader(serverInfra.NewRepository(db, secretInfra.NewCipher(conf))),
 		infra.NewAgentClient(conf),
 		infra.IDGenerator{},
 	)
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/secret/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/secret/application"
+	"squirrel-dev/internal/squ-apiserver/module/secret/domain"
+	"squirrel-dev/pkg/utils"
+)
+
+func bindRequest[T any](c *gin.Context) (T, bool) {
+	var request T
+	if err := c.ShouldBindJSON(&request); err != nil {
+		zap.L().Warn("failed to bind secret request", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return request, false
+	}
+	return request, true
+}
+
+func secretID(c *gin.Context) (uint, bool) {
+	rawID := c.Param("id")
+	id, err := utils.StringToUint(rawID)
+	if err != nil {
+		zap.L().Warn("failed to parse secret ID", zap.String("raw_secret_id", rawID), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+func writeResult(c *gin.Context, data any, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(data))
+}
+
+func writeError(c *gin.Context, err error) {
+	code := res.ErrSecretUpdateFailed
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = res.ErrSecretNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		code = res.ErrSecretAlreadyExists
+	case errors.Is(err, application.ErrInvalidSecret):
+		code = res.ErrInvalidSecret
+	case errors.Is(err, domain.ErrAccessDenied):
+		code = res.ErrSecretAccessDenied
+	case errors.Is(err, domain.ErrSecretInUse):
+		code = res.ErrSecretInUse
+	}
+	c.JSON(http.StatusOK, response.Error(code))
+}
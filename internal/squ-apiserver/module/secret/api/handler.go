@@ -0,0 +1,65 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/squ-apiserver/module/secret/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/secret/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/secret/application"
+)
+
+type Handler struct {
+	service *application.Service
+}
+
+func NewHandler(service *application.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	values, err := h.service.List(c.Request.Context(), c.GetString("username"))
+	result := make([]res.Secret, 0, len(values))
+	for _, value := range values {
+		result = append(result, toResponse(value))
+	}
+	writeResult(c, result, err)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := secretID(c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Get(c.Request.Context(), id, c.GetString("username"))
+	writeResult(c, toResponse(value), err)
+}
+
+func (h *Handler) Add(c *gin.Context) {
+	request, ok := bindRequest[req.Secret](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Add(c.Request.Context(), toChange(request), c.GetString("username"))
+	writeResult(c, toResponse(value), err)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, ok := secretID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Secret](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Update(c.Request.Context(), id, toChange(request), c.GetString("username"))
+	writeResult(c, toResponse(value), err)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, ok := secretID(c)
+	if !ok {
+		return
+	}
+	writeResult(c, "success", h.service.Delete(c.Request.Context(), id, c.GetString("username")))
+}
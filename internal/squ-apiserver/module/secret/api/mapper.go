@@ -0,0 +1,21 @@
+package api
+
+import (
+	"squirrel-dev/internal/squ-apiserver/module/secret/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/secret/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/secret/application"
+	"squirrel-dev/internal/squ-apiserver/module/secret/domain"
+)
+
+func toChange(value req.Secret) application.Change {
+	return application.Change{Name: value.Name, Description: value.Description, Value: value.Value, Users: value.Users}
+}
+
+func toResponse(value domain.Secret) res.Secret {
+	users := value.Users
+	if users == nil {
+		users = []string{}
+	}
+	return res.Secret{ID: value.ID, Name: value.Name, Description: value.Description, Owner: value.Owner,
+		Users: users, CreatedAt: value.CreatedAt, UpdatedAt: value.UpdatedAt}
+}
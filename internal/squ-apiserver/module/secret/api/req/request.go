@@ -0,0 +1,11 @@
+package req
+
+// Secret creates or updates a secret. Value may be left empty on update to
+// keep the stored one. Users lists the usernames besides the owner that may
+// use the secret in deployments.
+type Secret struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Value       string   `json:"value"`
+	Users       []string `json:"users"`
+}
@@ -0,0 +1,23 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrSecretNotFound      = 75001
+	ErrSecretAlreadyExists = 75002
+	ErrInvalidParameter    = 75003
+	ErrInvalidSecret       = 75004
+	ErrSecretAccessDenied  = 75005
+	ErrSecretInUse         = 75006
+	ErrSecretUpdateFailed  = 75007
+)
+
+func RegisterCode() {
+	response.Register(ErrSecretNotFound, "secret not found")
+	response.Register(ErrSecretAlreadyExists, "secret already exists")
+	response.Register(ErrInvalidParameter, "invalid parameter")
+	response.Register(ErrInvalidSecret, "invalid secret")
+	response.Register(ErrSecretAccessDenied, "secret access denied")
+	response.Register(ErrSecretInUse, "secret is used by deployments")
+	response.Register(ErrSecretUpdateFailed, "secret update failed")
+}
@@ -0,0 +1,11 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/secret", handler.List)
+	group.POST("/secret", handler.Add)
+	group.GET("/secret/:id", handler.Get)
+	group.POST("/secret/:id", handler.Update)
+	group.DELETE("/secret/:id", handler.Delete)
+}
@@ -0,0 +1,5 @@
+package application
+
+import "errors"
+
+var ErrInvalidSecret = errors.New("invalid secret")
@@ -0,0 +1,155 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/secret/domain"
+)
+
+// secretName matches environment variable names, which is how deployments
+// see secrets.
+var secretName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Change is a secret as submitted by its owner. An empty Value on update
+// keeps the stored one, so clients never need to read it back. Users are the
+// other usernames allowed to use the secret.
+type Change struct {
+	Name        string
+	Description string
+	Value       string
+	Users       []string
+}
+
+type Service struct {
+	repository domain.Repository
+}
+
+func NewService(repository domain.Repository) *Service {
+	return &Service{repository: repository}
+}
+
+// List returns the secrets username may use.
+func (s *Service) List(ctx context.Context, username string) ([]domain.Secret, error) {
+	secrets, err := s.repository.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to list secrets", zap.Error(err))
+		return nil, err
+	}
+	result := make([]domain.Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		if secret.CanUse(username) {
+			result = append(result, secret)
+		}
+	}
+	return result, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uint, username string) (domain.Secret, error) {
+	secret, err := s.repository.Get(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get secret", zap.Uint("secret_id", id), zap.Error(err))
+		return domain.Secret{}, err
+	}
+	if !secret.CanUse(username) {
+		zap.L().Warn("denied secret access", zap.Uint("secret_id", id), zap.String("username", username))
+		return domain.Secret{}, domain.ErrAccessDenied
+	}
+	return secret, nil
+}
+
+// Add stores a secret owned by username.
+func (s *Service) Add(ctx context.Context, change Change, username string) (domain.Secret, error) {
+	if !secretName.MatchString(change.Name) {
+		return domain.Secret{}, fmt.Errorf("%w: name must be a valid environment variable name", ErrInvalidSecret)
+	}
+	if change.Value == "" {
+		return domain.Secret{}, fmt.Errorf("%w: value is required", ErrInvalidSecret)
+	}
+	secret := domain.Secret{Name: change.Name, Owner: username}
+	apply(&secret, change)
+	if err := s.repository.Add(ctx, &secret); err != nil {
+		zap.L().Error("failed to add secret", zap.String("secret_name", secret.Name), zap.Error(err))
+		return domain.Secret{}, err
+	}
+	zap.L().Info("secret created", zap.Uint("secret_id", secret.ID), zap.String("secret_name", secret.Name),
+		zap.String("owner", username))
+	return secret, nil
+}
+
+// Update changes a secret of username. The name cannot change because
+// deployments reference secrets by name.
+func (s *Service) Update(ctx context.Context, id uint, change Change, username string) (domain.Secret, error) {
+	secret, err := s.owned(ctx, id, username)
+	if err != nil {
+		return domain.Secret{}, err
+	}
+	if change.Name != "" && change.Name != secret.Name {
+		return domain.Secret{}, fmt.Errorf("%w: name cannot be changed", ErrInvalidSecret)
+	}
+	apply(&secret, change)
+	if err := s.repository.Update(ctx, &secret); err != nil {
+		zap.L().Error("failed to update secret", zap.Uint("secret_id", id), zap.Error(err))
+		return domain.Secret{}, err
+	}
+	zap.L().Info("secret updated", zap.Uint("secret_id", id), zap.String("secret_name", secret.Name),
+		zap.Bool("value_changed", change.Value != ""))
+	return secret, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id uint, username string) error {
+	secret, err := s.owned(ctx, id, username)
+	if err != nil {
+		return err
+	}
+	count, err := s.repository.CountDeployments(ctx, secret.Name)
+	if err != nil {
+		zap.L().Error("failed to count secret deployments", zap.Uint("secret_id", id), zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		zap.L().Warn("refusing to delete secret used by deployments",
+			zap.Uint("secret_id", id),
+			zap.Int64("deployments", count),
+		)
+		return domain.ErrSecretInUse
+	}
+	if err := s.repository.Delete(ctx, id); err != nil {
+		zap.L().Error("failed to delete secret", zap.Uint("secret_id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *Service) owned(ctx context.Context, id uint, username string) (domain.Secret, error) {
+	secret, err := s.repository.Get(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get secret", zap.Uint("secret_id", id), zap.Error(err))
+		return domain.Secret{}, err
+	}
+	if secret.Owner != username {
+		zap.L().Warn("denied secret change", zap.Uint("secret_id", id), zap.String("username", username))
+		return domain.Secret{}, domain.ErrAccessDenied
+	}
+	return secret, nil
+}
+
+func apply(secret *domain.Secret, change Change) {
+	secret.Description = change.Description
+	if change.Value != "" {
+		secret.Value = change.Value
+	}
+	var users []string
+	for _, user := range change.Users {
+		user = strings.TrimSpace(user)
+		if user != "" && user != secret.Owner && !slices.Contains(users, user) {
+			users = append(users, user)
+		}
+	}
+	secret.Users = users
+}
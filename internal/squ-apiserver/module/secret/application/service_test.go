@@ -0,0 +1,116 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/secret/domain"
+)
+
+type repositoryStub struct {
+	secrets     []domain.Secret
+	deployments int64
+	deleted     []uint
+}
+
+func (r *repositoryStub) List(context.Context) ([]domain.Secret, error) { return r.secrets, nil }
+func (r *repositoryStub) Get(_ context.Context, id uint) (domain.Secret, error) {
+	for _, value := range r.secrets {
+		if value.ID == id {
+			return value, nil
+		}
+	}
+	return domain.Secret{}, gorm.ErrRecordNotFound
+}
+func (r *repositoryStub) GetByName(_ context.Context, name string) (domain.Secret, error) {
+	for _, value := range r.secrets {
+		if value.Name == name {
+			return value, nil
+		}
+	}
+	return domain.Secret{}, gorm.ErrRecordNotFound
+}
+func (r *repositoryStub) Add(_ context.Context, value *domain.Secret) error {
+	value.ID = uint(len(r.secrets) + 1)
+	r.secrets = append(r.secrets, *value)
+	return nil
+}
+func (r *repositoryStub) Update(_ context.Context, value *domain.Secret) error {
+	for i := range r.secrets {
+		if r.secrets[i].ID == value.ID {
+			r.secrets[i] = *value
+		}
+	}
+	return nil
+}
+func (r *repositoryStub) Delete(_ context.Context, id uint) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+func (r *repositoryStub) CountDeployments(context.Context, string) (int64, error) {
+	return r.deployments, nil
+}
+
+func TestAddValidatesSecret(t *testing.T) {
+	service := NewService(&repositoryStub{})
+	ctx := context.Background()
+	for _, change := range []Change{
+		{Name: "db-password", Value: "x"},
+		{Name: "1PASSWORD", Value: "x"},
+		{Name: "DB_PASSWORD"},
+	} {
+		if _, err := service.Add(ctx, change, "alice"); !errors.Is(err, ErrInvalidSecret) {
+			t.Errorf("Add(%+v) error = %v, want %v", change, err, ErrInvalidSecret)
+		}
+	}
+
+	secret, err := service.Add(ctx, Change{Name: "DB_PASSWORD", Value: "hunter2", Users: []string{"bob", " bob ", "alice", ""}}, "alice")
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if secret.Owner != "alice" || len(secret.Users) != 1 || secret.Users[0] != "bob" {
+		t.Fatalf("secret = %+v", secret)
+	}
+}
+
+func TestSecretAccessControl(t *testing.T) {
+	repository := &repositoryStub{secrets: []domain.Secret{
+		{ID: 1, Name: "DB_PASSWORD", Value: "hunter2", Owner: "alice", Users: []string{"bob"}},
+		{ID: 2, Name: "API_KEY", Value: "k-123", Owner: "carol"},
+	}}
+	service := NewService(repository)
+	ctx := context.Background()
+
+	visible, err := service.List(ctx, "bob")
+	if err != nil || len(visible) != 1 || visible[0].ID != 1 {
+		t.Fatalf("List() = %+v, %v", visible, err)
+	}
+	if _, err := service.Get(ctx, 2, "bob"); !errors.Is(err, domain.ErrAccessDenied) {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := service.Update(ctx, 1, Change{Value: "changed"}, "bob"); !errors.Is(err, domain.ErrAccessDenied) {
+		t.Fatalf("Update() by a user error = %v", err)
+	}
+	if err := service.Delete(ctx, 1, "bob"); !errors.Is(err, domain.ErrAccessDenied) {
+		t.Fatalf("Delete() by a user error = %v", err)
+	}
+
+	secret, err := service.Update(ctx, 1, Change{Description: "primary"}, "alice")
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if secret.Value != "hunter2" || secret.Description != "primary" || len(secret.Users) != 0 {
+		t.Fatalf("secret = %+v", secret)
+	}
+	if _, err := service.Update(ctx, 1, Change{Name: "OTHER"}, "alice"); !errors.Is(err, ErrInvalidSecret) {
+		t.Fatalf("Update() rename error = %v", err)
+	}
+
+	repository.deployments = 1
+	if err := service.Delete(ctx, 1, "alice"); !errors.Is(err, domain.ErrSecretInUse) {
+		t.Fatalf("Delete() error = %v, want %v", err, domain.ErrSecretInUse)
+	}
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrSecretInUse is returned when a secret that deployments still
+	// reference is deleted.
+	ErrSecretInUse = errors.New("secret is used by deployments")
+	// ErrAccessDenied is returned when a user reads or changes a secret they
+	// may not use.
+	ErrAccessDenied = errors.New("secret access denied")
+)
+
+// Secret is a value injected into compose deployments as the environment
+// variable Name. Value is only held in plain text in memory; the repository
+// encrypts it at rest. Owner and Users may use the secret, only Owner may
+// change or delete it.
+type Secret struct {
+	ID          uint
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Description string
+	Value       string
+	Owner       string
+	Users       []string
+}
+
+// CanUse reports whether username may read the secret and reference it from
+// deployments.
+func (s Secret) CanUse(username string) bool {
+	return username != "" && (s.Owner == username || slices.Contains(s.Users, username))
+}
+
+type Repository interface {
+	// List leaves Value empty; Get and GetByName decrypt it.
+	List(context.Context) ([]Secret, error)
+	Get(context.Context, uint) (Secret, error)
+	// GetByName returns gorm.ErrRecordNotFound for unknown names.
+	GetByName(context.Context, string) (Secret, error)
+	Add(context.Context, *Secret) error
+	Update(context.Context, *Secret) error
+	Delete(context.Context, uint) error
+	// CountDeployments counts the deployments that reference a secret.
+	CountDeployments(context.Context, string) (int64, error)
+}
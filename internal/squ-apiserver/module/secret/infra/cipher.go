@@ -0,0 +1,74 @@
+package infra
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+)
+
+// cipherPrefix versions stored values so that the scheme can change later.
+const cipherPrefix = "v1:"
+
+var errCiphertext = errors.New("malformed secret ciphertext")
+
+// Cipher encrypts secret values with AES-256-GCM. A bad configured key is
+// reported by every call rather than at startup so that the rest of the
+// apiserver keeps working.
+type Cipher struct {
+	aead cipher.AEAD
+	err  error
+}
+
+// NewCipher uses conf.Secrets.Key. There is no fallback key: one derived
+// from other settings would be as public as their shipped defaults.
+func NewCipher(conf *config.Config) *Cipher {
+	if conf.Secrets.Key == "" {
+		return &Cipher{err: errors.New("secrets.key is not configured; set it to 32 random bytes encoded as base64")}
+	}
+	key, err := base64.StdEncoding.DecodeString(conf.Secrets.Key)
+	if err != nil || len(key) != 32 {
+		return &Cipher{err: fmt.Errorf("secrets.key must be 32 bytes encoded as base64")}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return &Cipher{err: err}
+	}
+	aead, err := cipher.NewGCM(block)
+	return &Cipher{aead: aead, err: err}
+}
+
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return cipherPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *Cipher) Decrypt(ciphertext string) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	if len(ciphertext) < len(cipherPrefix) || ciphertext[:len(cipherPrefix)] != cipherPrefix {
+		return "", errCiphertext
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext[len(cipherPrefix):])
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errCiphertext
+	}
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,39 @@
+package infra
+
+import (
+	"strings"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+)
+
+func TestCipherRoundTrip(t *testing.T) {
+	cipher := NewCipher(&config.Config{Secrets: config.Secrets{Key: "c3F1aXJyZWwtdGVzdC1zZWNyZXRzLWtleS0zMi1ieXQ="}})
+	first, err := cipher.Encrypt("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := cipher.Encrypt("hunter2")
+	if first == second || strings.Contains(first, "hunter2") {
+		t.Fatalf("ciphertexts = %q, %q", first, second)
+	}
+	if value, err := cipher.Decrypt(first); err != nil || value != "hunter2" {
+		t.Fatalf("Decrypt() = %q, %v", value, err)
+	}
+
+	other := NewCipher(&config.Config{Secrets: config.Secrets{Key: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="}})
+	if _, err := other.Decrypt(first); err == nil {
+		t.Fatal("Decrypt() with another key succeeded")
+	}
+	broken := NewCipher(&config.Config{Secrets: config.Secrets{Key: "short"}})
+	if _, err := broken.Encrypt("x"); err == nil {
+		t.Fatal("Encrypt() with an invalid key succeeded")
+	}
+	unset := NewCipher(&config.Config{Auth: config.Auth{Jwt: config.Jwt{SigningKey: "signing"}}})
+	if _, err := unset.Encrypt("x"); err == nil || !strings.Contains(err.Error(), "secrets.key") {
+		t.Fatalf("Encrypt() without secrets.key error = %v", err)
+	}
+	if _, err := unset.Decrypt(first); err == nil {
+		t.Fatal("Decrypt() without secrets.key succeeded")
+	}
+}
@@ -0,0 +1,134 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/secret/domain"
+)
+
+type secretModel struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Name        string         `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string
+	Value       string   `gorm:"type:text;not null"`
+	Owner       string   `gorm:"type:varchar(50);not null"`
+	Users       []string `gorm:"type:json;serializer:json"`
+}
+
+func (secretModel) TableName() string { return "secrets" }
+
+// Repository stores secrets with their values encrypted by Cipher. Only
+// Get and GetByName decrypt them, so that a value that no longer decrypts
+// does not hide the other secrets.
+type Repository struct {
+	db     *gorm.DB
+	cipher *Cipher
+}
+
+func NewRepository(db *gorm.DB, cipher *Cipher) *Repository {
+	return &Repository{db: db, cipher: cipher}
+}
+
+func (r *Repository) List(ctx context.Context) ([]domain.Secret, error) {
+	var models []secretModel
+	if err := r.db.WithContext(ctx).Order("id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	result := make([]domain.Secret, 0, len(models))
+	for _, model := range models {
+		result = append(result, toDomain(model))
+	}
+	return result, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id uint) (domain.Secret, error) {
+	var model secretModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		return domain.Secret{}, err
+	}
+	return r.decrypt(model)
+}
+
+func (r *Repository) GetByName(ctx context.Context, name string) (domain.Secret, error) {
+	var model secretModel
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&model).Error; err != nil {
+		return domain.Secret{}, err
+	}
+	return r.decrypt(model)
+}
+
+func (r *Repository) Add(ctx context.Context, value *domain.Secret) error {
+	model, err := r.toModel(*value)
+	if err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	value.ID, value.CreatedAt, value.UpdatedAt = model.ID, model.CreatedAt, model.UpdatedAt
+	return nil
+}
+
+// Update writes every field so that the description and users can be
+// cleared. The value is encrypted again with a fresh nonce.
+func (r *Repository) Update(ctx context.Context, value *domain.Secret) error {
+	model, err := r.toModel(*value)
+	if err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Model(&secretModel{ID: value.ID}).
+		Select("description", "value", "users").Updates(&model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	value.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&secretModel{}, id).Error
+}
+
+// CountDeployments reads the deployments table directly; the deployment
+// module owns it but deleting a secret must not break later deploys. The
+// secrets column is a JSON array of names, which are plain identifiers.
+func (r *Repository) CountDeployments(ctx context.Context, name string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("deployments").
+		Where("secrets LIKE ? AND deleted_at IS NULL", `%"`+name+`"%`).Count(&count).Error
+	return count, err
+}
+
+func (r *Repository) toModel(v domain.Secret) (secretModel, error) {
+	value, err := r.cipher.Encrypt(v.Value)
+	if err != nil {
+		return secretModel{}, fmt.Errorf("encrypt secret %q: %w", v.Name, err)
+	}
+	return secretModel{ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+		Description: v.Description, Value: value, Owner: v.Owner, Users: v.Users}, nil
+}
+
+func (r *Repository) decrypt(v secretModel) (domain.Secret, error) {
+	value, err := r.cipher.Decrypt(v.Value)
+	if err != nil {
+		return domain.Secret{}, fmt.Errorf("secret %q: %w", v.Name, err)
+	}
+	secret := toDomain(v)
+	secret.Value = value
+	return secret, nil
+}
+
+func toDomain(v secretModel) domain.Secret {
+	return domain.Secret{ID: v.ID, CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+		Description: v.Description, Owner: v.Owner, Users: v.Users}
+}
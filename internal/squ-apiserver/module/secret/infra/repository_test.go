@@ -0,0 +1,49 @@
+package infra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/secret/domain"
+)
+
+func TestListDoesNotDecryptValues(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	cipher := NewCipher(&config.Config{Secrets: config.Secrets{Key: "c3F1aXJyZWwtdGVzdC1zZWNyZXRzLWtleS0zMi1ieXQ="}})
+	repository := NewRepository(db, cipher)
+	token := domain.Secret{Name: "token", Value: "hunter2", Owner: "alice"}
+	broken := domain.Secret{Name: "broken", Value: "lost", Owner: "alice"}
+	for _, value := range []*domain.Secret{&token, &broken} {
+		if err := repository.Add(ctx, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(&secretModel{ID: broken.ID}).Update("value", "corrupt").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := repository.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0].Value != "" || values[1].Value != "" {
+		t.Fatalf("secrets = %+v", values)
+	}
+	if value, err := repository.GetByName(ctx, "token"); err != nil || value.Value != "hunter2" {
+		t.Fatalf("GetByName() = %+v, %v", value, err)
+	}
+	if _, err := repository.Get(ctx, broken.ID); err == nil {
+		t.Fatal("Get() of a corrupt value succeeded")
+	}
+}
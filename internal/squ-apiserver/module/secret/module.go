@@ -0,0 +1,20 @@
+package secret
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	"squirrel-dev/internal/squ-apiserver/module/secret/api"
+	"squirrel-dev/internal/squ-apiserver/module/secret/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/secret/application"
+	"squirrel-dev/internal/squ-apiserver/module/secret/infra"
+)
+
+func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
+	res.RegisterCode()
+	service := application.NewService(infra.NewRepository(db, infra.NewCipher(conf)))
+	api.RegisterRoutes(group, api.NewHandler(service))
+}
+func Migrate(db *gorm.DB) error  { return infra.Migrate(db) }
+func Rollback(db *gorm.DB) error { return infra.Rollback(db) }
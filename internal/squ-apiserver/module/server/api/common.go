@@ -10,6 +10,9 @@ import (
 
 	"squirrel-dev/internal/pkg/response"
 	"squirrel-dev/internal/squ-apiserver/module/server/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/server/application"
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+	"squirrel-dev/internal/squ-apiserver/module/server/inventory"
 	"squirrel-dev/pkg/utils"
 )
 
@@ -23,6 +26,11 @@ func bindRequest[T any](c *gin.Context) (T, bool) {
 	return request, true
 }
 
+// target reads the group and selector query parameters of list requests.
+func target(c *gin.Context) domain.Target {
+	return domain.Target{Group: c.Query("group"), Selector: c.Query("selector")}
+}
+
 func serverID(c *gin.Context) (uint, bool) {
 	rawID := c.Param("id")
 	id, err := utils.StringToUint(rawID)
@@ -57,6 +65,56 @@ func writeError(c *gin.Context, err error) {
 		code = res.ErrServerNotFound
 	case errors.Is(err, gorm.ErrDuplicatedKey):
 		code = res.ErrServerAlreadyExists
+	case errors.Is(err, domain.ErrInvalidSelector):
+		code = res.ErrInvalidSelector
+	case errors.Is(err, domain.ErrInvalidLabel):
+		code = res.ErrInvalidLabel
+	case errors.Is(err, inventory.ErrUnsupportedFormat):
+		code = res.ErrInvalidFormat
+	case errors.Is(err, application.ErrInvalidInventory):
+		code = res.ErrInvalidInventory
+	case errors.Is(err, application.ErrKeyNotFound):
+		code = res.ErrKeyNotFound
+	}
+	c.JSON(http.StatusOK, response.Error(code))
+}
+
+func writeGroupResult(c *gin.Context, data any, err error) {
+	if err == nil {
+		c.JSON(http.StatusOK, response.Success(data))
+		return
+	}
+	code := res.ErrServerUpdateFailed
+	switch {
+	case errors.Is(err, application.ErrGroupMemberNotFound):
+		code = res.ErrServerNotFound
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = res.ErrGroupNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		code = res.ErrGroupAlreadyExists
+	case errors.Is(err, domain.ErrInvalidGroup):
+		code = res.ErrInvalidGroup
+	}
+	c.JSON(http.StatusOK, response.Error(code))
+}
+
+func writeKeyResult(c *gin.Context, data any, err error) {
+	if err == nil {
+		c.JSON(http.StatusOK, response.Success(data))
+		return
+	}
+	code := res.ErrServerUpdateFailed
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		code = res.ErrKeyNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		code = res.ErrKeyAlreadyExists
+	case errors.Is(err, domain.ErrKeyInUse):
+		code = res.ErrKeyInUse
+	case errors.Is(err, domain.ErrInvalidKey), errors.Is(err, application.ErrInvalidKeyName):
+		code = res.ErrInvalidKey
+	case errors.Is(err, domain.ErrInvalidSelector):
+		code = res.ErrInvalidSelector
 	}
 	c.JSON(http.StatusOK, response.Error(code))
 }
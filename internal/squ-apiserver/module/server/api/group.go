@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/server/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/server/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/server/application"
+	"squirrel-dev/pkg/utils"
+)
+
+type GroupHandler struct {
+	service *application.GroupService
+}
+
+func NewGroupHandler(service *application.GroupService) *GroupHandler {
+	return &GroupHandler{
+		service: service,
+	}
+}
+
+func (h *GroupHandler) List(c *gin.Context) {
+	values, err := h.service.List(c.Request.Context())
+	result := make([]res.Group, 0, len(values))
+	for _, value := range values {
+		result = append(result, toGroupResponse(value))
+	}
+	writeGroupResult(c, result, err)
+}
+
+func (h *GroupHandler) Get(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Get(c.Request.Context(), id)
+	writeGroupResult(c, toGroupResponse(value), err)
+}
+
+func (h *GroupHandler) Add(c *gin.Context) {
+	request, ok := bindRequest[req.Group](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Add(c.Request.Context(), toGroupDomain(request))
+	writeGroupResult(c, toGroupResponse(value), err)
+}
+
+func (h *GroupHandler) Update(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Group](c)
+	if !ok {
+		return
+	}
+	group := toGroupDomain(request)
+	group.ID = id
+	err := h.service.Update(c.Request.Context(), group)
+	writeGroupResult(c, "success", err)
+}
+
+func (h *GroupHandler) Delete(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+	err := h.service.Delete(c.Request.Context(), id)
+	writeGroupResult(c, "success", err)
+}
+
+func (h *GroupHandler) SetMembers(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.GroupMembers](c)
+	if !ok {
+		return
+	}
+	err := h.service.SetMembers(c.Request.Context(), id, request.ServerIDs)
+	writeGroupResult(c, "success", err)
+}
+
+func groupID(c *gin.Context) (uint, bool) {
+	rawID := c.Param("id")
+	id, err := utils.StringToUint(rawID)
+	if err != nil {
+		zap.L().Warn("failed to parse server group ID", zap.String("raw_group_id", rawID), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
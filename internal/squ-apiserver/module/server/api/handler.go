@@ -6,6 +6,7 @@ import (
 	"squirrel-dev/internal/squ-apiserver/module/server/api/req"
 	"squirrel-dev/internal/squ-apiserver/module/server/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/server/application"
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
 type Handler struct {
@@ -21,7 +22,7 @@ func NewHandler(service *application.Service, signingKey string) *Handler {
 }
 
 func (h *Handler) List(c *gin.Context) {
-	values, err := h.service.List(c.Request.Context())
+	values, err := h.service.List(c.Request.Context(), target(c))
 	var result []res.Server
 	for _, value := range values {
 		result = append(result, toResponse(value))
@@ -87,3 +88,29 @@ func (h *Handler) CheckAgent(c *gin.Context) {
 	ready, message, info := h.service.CheckAgent(c.Request.Context(), request.IPAddress, request.Port)
 	writeResult(c, toAgentCheckResponse(ready, message, info), nil)
 }
+
+func (h *Handler) SetLabels(c *gin.Context) {
+	id, ok := serverID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Labels](c)
+	if !ok {
+		return
+	}
+	err := h.service.SetLabels(c.Request.Context(), id, request.Labels)
+	writeResult(c, "success", err)
+}
+
+func (h *Handler) ChangeLabels(c *gin.Context) {
+	request, ok := bindRequest[req.LabelChange](c)
+	if !ok {
+		return
+	}
+	ids, err := h.service.ChangeLabels(c.Request.Context(), application.LabelChange{
+		Target: domain.Target{ServerIDs: request.ServerIDs, Group: request.Group, Selector: request.Selector},
+		Set:    request.Set,
+		Remove: request.Remove,
+	})
+	writeResult(c, res.LabelChange{ServerIDs: ids}, err)
+}
@@ -43,6 +43,7 @@ func (r *repositoryStub) Update(context.Context, *domain.Server) error { return
 func (r *repositoryStub) GetByUUID(context.Context, string) (domain.Server, error) {
 	return domain.Server{}, gorm.ErrRecordNotFound
 }
+func (r *repositoryStub) SetLabels(context.Context, uint, map[string]string) error { return nil }
 
 type agentStub struct{}
 
@@ -63,7 +64,7 @@ func TestServerHTTPContract(t *testing.T) {
 		ID: 1, Hostname: "demo", IPAddress: "192.0.2.1", AgentPort: 10750,
 		SSHUsername: "root", SSHPassword: &password, SSHPort: 22, AuthType: "password",
 	}}}
-	service := application.NewService(repository, agentStub{}, sshStub{})
+	service := application.NewService(repository, nil, agentStub{}, sshStub{})
 	engine := gin.New()
 	RegisterRoutes(engine.Group("/api/v1"), NewHandler(service, "key"))
 
@@ -80,7 +81,7 @@ func TestTerminalMessageAuthenticationContract(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	response.Init()
 	res.RegisterCode()
-	service := application.NewService(&repositoryStub{}, agentStub{}, sshStub{})
+	service := application.NewService(&repositoryStub{}, nil, agentStub{}, sshStub{})
 	engine := gin.New()
 	group := engine.Group("/api/v1")
 	handler := NewHandler(service, "websocket-key")
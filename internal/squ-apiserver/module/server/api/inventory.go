@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/server/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/server/application"
+	"squirrel-dev/internal/squ-apiserver/module/server/inventory"
+)
+
+type InventoryHandler struct {
+	service *application.InventoryService
+}
+
+func NewInventoryHandler(service *application.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		service: service,
+	}
+}
+
+// Import accepts either a raw request body or a multipart "file" field in the
+// format named by the format query parameter. dry_run=true only previews.
+func (h *InventoryHandler) Import(c *gin.Context) {
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			zap.L().Warn("failed to read inventory form file", zap.Error(err))
+			c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			zap.L().Warn("failed to open inventory form file", zap.Error(err))
+			c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	result, err := h.service.Import(c.Request.Context(), c.Query("format"), body, c.Query("dry_run") == "true")
+	writeResult(c, toImportResponse(result), err)
+}
+
+// Export downloads the servers matching the group and selector query
+// parameters. Credentials are only included with include_secrets=true.
+func (h *InventoryHandler) Export(c *gin.Context) {
+	format := c.Query("format")
+	var buffer bytes.Buffer
+	err := h.service.Export(c.Request.Context(), format, target(c), c.Query("include_secrets") == "true", &buffer)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": inventory.FileName(format)}))
+	c.Data(http.StatusOK, inventory.ContentType(format), buffer.Bytes())
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/server/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/server/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/server/application"
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+	"squirrel-dev/pkg/utils"
+)
+
+// KeyHandler serves the SSH key vault. Private keys are accepted on import
+// but never returned.
+type KeyHandler struct {
+	service *application.KeyService
+}
+
+func NewKeyHandler(service *application.KeyService) *KeyHandler {
+	return &KeyHandler{
+		service: service,
+	}
+}
+
+func (h *KeyHandler) List(c *gin.Context) {
+	values, err := h.service.List(c.Request.Context())
+	result := make([]res.Key, 0, len(values))
+	for _, value := range values {
+		result = append(result, toKeyResponse(value))
+	}
+	writeKeyResult(c, result, err)
+}
+
+func (h *KeyHandler) Get(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Get(c.Request.Context(), id)
+	writeKeyResult(c, toKeyResponse(value), err)
+}
+
+func (h *KeyHandler) Generate(c *gin.Context) {
+	request, ok := bindRequest[req.GenerateKey](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Generate(c.Request.Context(), request.Name, request.Type, request.Bits, request.Comment)
+	writeKeyResult(c, toKeyResponse(value), err)
+}
+
+func (h *KeyHandler) Import(c *gin.Context) {
+	request, ok := bindRequest[req.ImportKey](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Import(c.Request.Context(), request.Name, request.PrivateKey, request.Passphrase, request.Comment)
+	writeKeyResult(c, toKeyResponse(value), err)
+}
+
+func (h *KeyHandler) Delete(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+	err := h.service.Delete(c.Request.Context(), id)
+	writeKeyResult(c, "success", err)
+}
+
+func (h *KeyHandler) Install(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.KeyInstall](c)
+	if !ok {
+		return
+	}
+	values, err := h.service.Install(c.Request.Context(), application.KeyInstall{
+		KeyID:      id,
+		Target:     domain.Target{ServerIDs: request.ServerIDs, Group: request.Group, Selector: request.Selector},
+		SwitchAuth: request.SwitchAuth,
+	})
+	result := make([]res.KeyInstallResult, 0, len(values))
+	for _, value := range values {
+		result = append(result, res.KeyInstallResult{
+			ServerID:  value.ServerID,
+			Hostname:  value.Hostname,
+			Installed: value.Installed,
+			Switched:  value.Switched,
+			Error:     value.Error,
+		})
+	}
+	writeKeyResult(c, result, err)
+}
+
+func keyID(c *gin.Context) (uint, bool) {
+	rawID := c.Param("id")
+	id, err := utils.StringToUint(rawID)
+	if err != nil {
+		zap.L().Warn("failed to parse ssh key ID", zap.String("raw_key_id", rawID), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
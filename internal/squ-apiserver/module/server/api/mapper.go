@@ -20,11 +20,17 @@ func toApplication(value req.Server) application.Request {
 		AuthType:      value.AuthType,
 		Status:        value.Status,
 		ServerAlias:   value.ServerAlias,
+		Labels:        value.Labels,
+		SSHKeyID:      value.SSHKeyID,
 	}
 }
 
 func toResponse(value application.ServerView) res.Server {
 	server := value.Server
+	// The private half of a vault key is never returned with the server.
+	if server.SSHKeyID != nil {
+		server.SSHPrivateKey = nil
+	}
 	return res.Server{
 		ID:            server.ID,
 		Hostname:      server.Hostname,
@@ -38,7 +44,27 @@ func toResponse(value application.ServerView) res.Server {
 		Status:        server.Status,
 		ServerAlias:   server.ServerAlias,
 		ServerInfo:    value.ServerInfo,
+		Labels:        server.Labels,
+		Groups:        server.Groups,
+		SSHKeyID:      server.SSHKeyID,
+	}
+}
+
+func toGroupResponse(value domain.Group) res.Group {
+	serverIDs := value.ServerIDs
+	if serverIDs == nil {
+		serverIDs = []uint{}
 	}
+	return res.Group{
+		ID:          value.ID,
+		Name:        value.Name,
+		Description: value.Description,
+		ServerIDs:   serverIDs,
+	}
+}
+
+func toGroupDomain(value req.Group) domain.Group {
+	return domain.Group{Name: value.Name, Description: value.Description, ServerIDs: value.ServerIDs}
 }
 
 func toSSHTestResponse(value domain.Server) res.SSHTestResult {
@@ -57,3 +83,44 @@ func toAgentCheckResponse(ready bool, message string, serverInfo map[string]any)
 		ServerInfo: serverInfo,
 	}
 }
+
+func toImportResponse(value application.ImportResult) res.ImportResult {
+	rows := make([]res.ImportRow, 0, len(value.Rows))
+	for _, row := range value.Rows {
+		rows = append(rows, res.ImportRow{
+			Row:       row.Row,
+			Hostname:  row.Hostname,
+			IPAddress: row.IPAddress,
+			Action:    row.Action,
+			ServerID:  row.ServerID,
+			Reason:    row.Reason,
+			Warnings:  row.Warnings,
+		})
+	}
+	return res.ImportResult{
+		DryRun:    value.DryRun,
+		Created:   value.Created,
+		Skipped:   value.Skipped,
+		Failed:    value.Failed,
+		NewGroups: value.NewGroups,
+		Rows:      rows,
+	}
+}
+
+func toKeyResponse(value domain.Key) res.Key {
+	serverIDs := value.ServerIDs
+	if serverIDs == nil {
+		serverIDs = []uint{}
+	}
+	return res.Key{
+		ID:          value.ID,
+		Name:        value.Name,
+		Type:        value.Type,
+		Bits:        value.Bits,
+		Fingerprint: value.Fingerprint,
+		PublicKey:   value.PublicKey,
+		Comment:     value.Comment,
+		CreatedAt:   value.CreatedAt,
+		ServerIDs:   serverIDs,
+	}
+}
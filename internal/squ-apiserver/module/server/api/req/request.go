@@ -12,9 +12,64 @@ type Server struct {
 	AuthType      string `json:"auth_type"`
 	Status        string `json:"status"`
 	ServerAlias   string `json:"server_alias,omitempty"`
+	// Labels replaces the server's labels when present and keeps them when omitted.
+	Labels map[string]string `json:"labels,omitempty"`
+	// SSHKeyID switches the server to a vault key instead of inline credentials.
+	SSHKeyID *uint `json:"ssh_key_id,omitempty"`
+}
+
+type Labels struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// LabelChange sets and removes labels on every server matched by the target.
+// At least one of ServerIDs, Group or Selector is required.
+type LabelChange struct {
+	ServerIDs []uint            `json:"server_ids"`
+	Group     string            `json:"group"`
+	Selector  string            `json:"selector"`
+	Set       map[string]string `json:"set"`
+	Remove    []string          `json:"remove"`
+}
+
+// Group creates or updates a server group. ServerIDs replaces the members
+// when present and keeps them when omitted.
+type Group struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	ServerIDs   []uint `json:"server_ids"`
+}
+
+type GroupMembers struct {
+	ServerIDs []uint `json:"server_ids"`
 }
 
 type CheckAgent struct {
 	IPAddress string `json:"ip_address" binding:"required"`
 	Port      int    `json:"port" binding:"required"`
 }
+
+// GenerateKey creates a key pair. Type is ed25519 (default) or rsa; Bits only
+// applies to rsa.
+type GenerateKey struct {
+	Name    string `json:"name" binding:"required"`
+	Type    string `json:"type"`
+	Bits    int    `json:"bits"`
+	Comment string `json:"comment"`
+}
+
+type ImportKey struct {
+	Name       string `json:"name" binding:"required"`
+	PrivateKey string `json:"private_key" binding:"required"`
+	Passphrase string `json:"passphrase"`
+	Comment    string `json:"comment"`
+}
+
+// KeyInstall installs a key on the servers matched by the target. SwitchAuth
+// moves them to key authentication after a successful test login.
+type KeyInstall struct {
+	ServerIDs  []uint `json:"server_ids"`
+	Group      string `json:"group"`
+	Selector   string `json:"selector"`
+	SwitchAuth bool   `json:"switch_auth"`
+}
@@ -1,18 +1,73 @@
 package res
 
+import "time"
+
 type Server struct {
-	ID            uint           `json:"id"`
-	Hostname      string         `json:"hostname"`
-	IPAddress     string         `json:"ip_address"`
-	Port          int            `json:"port"`
-	SSHUsername   string         `json:"ssh_username"`
-	SSHPassword   *string        `json:"ssh_password"`
-	SSHPrivateKey *string        `json:"ssh_private_key"`
-	SSHPort       int            `json:"ssh_port"`
-	AuthType      string         `json:"auth_type"`
-	Status        string         `json:"status"`
-	ServerAlias   *string        `json:"server_alias,omitempty"`
-	ServerInfo    map[string]any `json:"server_info"`
+	ID            uint              `json:"id"`
+	Hostname      string            `json:"hostname"`
+	IPAddress     string            `json:"ip_address"`
+	Port          int               `json:"port"`
+	SSHUsername   string            `json:"ssh_username"`
+	SSHPassword   *string           `json:"ssh_password"`
+	SSHPrivateKey *string           `json:"ssh_private_key"`
+	SSHPort       int               `json:"ssh_port"`
+	AuthType      string            `json:"auth_type"`
+	Status        string            `json:"status"`
+	ServerAlias   *string           `json:"server_alias,omitempty"`
+	ServerInfo    map[string]any    `json:"server_info"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Groups        []string          `json:"groups,omitempty"`
+	SSHKeyID      *uint             `json:"ssh_key_id,omitempty"`
+}
+
+type Group struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ServerIDs   []uint `json:"server_ids"`
+}
+
+type LabelChange struct {
+	ServerIDs []uint `json:"server_ids"`
+}
+
+type ImportRow struct {
+	Row       int      `json:"row"`
+	Hostname  string   `json:"hostname"`
+	IPAddress string   `json:"ip_address"`
+	Action    string   `json:"action"`
+	ServerID  uint     `json:"server_id,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+type ImportResult struct {
+	DryRun    bool        `json:"dry_run"`
+	Created   int         `json:"created"`
+	Skipped   int         `json:"skipped"`
+	Failed    int         `json:"failed"`
+	NewGroups []string    `json:"new_groups"`
+	Rows      []ImportRow `json:"rows"`
+}
+
+type Key struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Bits        int       `json:"bits"`
+	Fingerprint string    `json:"fingerprint"`
+	PublicKey   string    `json:"public_key"`
+	Comment     string    `json:"comment"`
+	CreatedAt   time.Time `json:"created_at"`
+	ServerIDs   []uint    `json:"server_ids"`
+}
+
+type KeyInstallResult struct {
+	ServerID  uint   `json:"server_id"`
+	Hostname  string `json:"hostname"`
+	Installed bool   `json:"installed"`
+	Switched  bool   `json:"switched"`
+	Error     string `json:"error,omitempty"`
 }
 
 type SSHTestResult struct {
@@ -9,11 +9,22 @@ const (
 	ErrServerAlreadyExists = 60004
 	ErrServerUpdateFailed  = 60005
 	ErrServerDeleteFailed  = 60006
+	ErrGroupNotFound       = 60007
+	ErrGroupAlreadyExists  = 60008
+	ErrKeyNotFound         = 60009
+	ErrKeyAlreadyExists    = 60010
 
 	ErrInvalidParameter = 60021
 	ErrInvalidAuthType  = 60022
 	ErrInvalidSSHConfig = 60023
 	ErrSSHTestFailed    = 60024
+	ErrInvalidSelector  = 60025
+	ErrInvalidLabel     = 60026
+	ErrInvalidGroup     = 60027
+	ErrInvalidFormat    = 60028
+	ErrInvalidInventory = 60029
+	ErrInvalidKey       = 60030
+	ErrKeyInUse         = 60031
 
 	ErrConnectFailed      = 60041
 	ErrAgentOffline       = 60042
@@ -27,11 +38,22 @@ func RegisterCode() {
 	response.Register(ErrServerAlreadyExists, "server already exists")
 	response.Register(ErrServerUpdateFailed, "server update failed")
 	response.Register(ErrServerDeleteFailed, "server delete failed")
+	response.Register(ErrGroupNotFound, "server group not found")
+	response.Register(ErrGroupAlreadyExists, "server group already exists")
+	response.Register(ErrKeyNotFound, "ssh key not found")
+	response.Register(ErrKeyAlreadyExists, "ssh key already exists")
 
 	response.Register(ErrInvalidParameter, "invalid parameter")
 	response.Register(ErrInvalidAuthType, "invalid auth type")
 	response.Register(ErrInvalidSSHConfig, "invalid SSH configuration")
 	response.Register(ErrSSHTestFailed, "SSH connection test failed")
+	response.Register(ErrInvalidSelector, "invalid label selector")
+	response.Register(ErrInvalidLabel, "invalid label")
+	response.Register(ErrInvalidGroup, "invalid group name")
+	response.Register(ErrInvalidFormat, "unsupported inventory format")
+	response.Register(ErrInvalidInventory, "invalid inventory file")
+	response.Register(ErrInvalidKey, "invalid ssh key")
+	response.Register(ErrKeyInUse, "ssh key is used by servers")
 
 	response.Register(ErrConnectFailed, "connect failed")
 	response.Register(ErrAgentOffline, "agent is offline")
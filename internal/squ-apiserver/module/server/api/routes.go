@@ -10,10 +10,36 @@ func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
 	group.POST("/server/:id", handler.Update)
 	group.POST("/server/check", handler.CheckAgent)
 	group.POST("/ssh/test/:id", handler.TestSSH)
+	group.POST("/server/:id/labels", handler.SetLabels)
+	group.POST("/server/labels", handler.ChangeLabels)
+}
+
+func RegisterGroupRoutes(group *gin.RouterGroup, handler *GroupHandler) {
+	group.GET("/server-group", handler.List)
+	group.GET("/server-group/:id", handler.Get)
+	group.POST("/server-group", handler.Add)
+	group.POST("/server-group/:id", handler.Update)
+	group.DELETE("/server-group/:id", handler.Delete)
+	group.POST("/server-group/:id/members", handler.SetMembers)
+}
+
+func RegisterInventoryRoutes(group *gin.RouterGroup, handler *InventoryHandler) {
+	group.POST("/server/import", handler.Import)
+	group.GET("/server/export", handler.Export)
+}
+
+func RegisterKeyRoutes(group *gin.RouterGroup, handler *KeyHandler) {
+	group.GET("/ssh-key", handler.List)
+	group.GET("/ssh-key/:id", handler.Get)
+	group.POST("/ssh-key/generate", handler.Generate)
+	group.POST("/ssh-key/import", handler.Import)
+	group.DELETE("/ssh-key/:id", handler.Delete)
+	group.POST("/ssh-key/:id/install", handler.Install)
 }
 
 // RegisterTerminalRoute registers the WebSocket endpoint separately because it
 // authenticates with the first WebSocket message rather than an HTTP header.
 func RegisterTerminalRoute(group *gin.RouterGroup, handler *Handler) {
 	group.GET("/ws/server/:id", handler.Terminal)
+	group.GET("/ws/servers", handler.GroupTerminal)
 }
@@ -0,0 +1,210 @@
+package terminal
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Member is one server terminal inside a group session.
+type Member struct {
+	ServerID uint
+	Handler  *SSH
+}
+
+// memberQueue bounds the frames waiting to be written to one member. A
+// member whose queue is full has stopped reading its input and is dropped so
+// that it cannot stall the rest of the group.
+const memberQueue = 64
+
+// GroupBridge connects one WebSocket to several terminals, cluster-SSH style.
+// stdin and resize frames are broadcast to every live member unless a stdin
+// frame names a server_id; stdout frames are tagged with the member's server.
+// When a member's shell ends an "exit" frame is sent and the others continue.
+func GroupBridge(conn *websocket.Conn, members []Member) {
+	group := &group{conn: conn, members: make(map[uint]*groupMember, len(members))}
+	for _, member := range members {
+		value := &groupMember{handler: member.Handler, queue: make(chan func(*SSH) error, memberQueue)}
+		group.members[member.ServerID] = value
+		go group.feed(member.ServerID, value)
+	}
+	defer func() {
+		group.closeAll()
+		if err := conn.Close(); err != nil {
+			zap.L().Error("failed to close group terminal websocket", zap.Error(err))
+		}
+	}()
+
+	var outputs sync.WaitGroup
+	for _, member := range members {
+		outputs.Add(1)
+		go func() {
+			defer outputs.Done()
+			group.pump(member)
+		}()
+	}
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		group.dispatch()
+	}()
+	finished := make(chan struct{})
+	go func() {
+		outputs.Wait()
+		close(finished)
+	}()
+	select {
+	case <-closed:
+	case <-finished:
+	}
+}
+
+type group struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	members map[uint]*groupMember
+}
+
+// groupMember owns the writes to one terminal. They run in feed, one member
+// each, so that a slow terminal only delays its own input.
+type groupMember struct {
+	handler *SSH
+	queue   chan func(*SSH) error
+}
+
+func (g *group) write(message Message) error {
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+	return g.conn.WriteJSON(message)
+}
+
+func (g *group) pump(member Member) {
+	buffer := make([]byte, bufferSize)
+	for {
+		n, err := member.Handler.Read(buffer)
+		if n > 0 {
+			if writeErr := g.write(Message{Type: "stdout", Data: string(buffer[:n]), ServerID: member.ServerID}); writeErr != nil {
+				zap.L().Error("failed to write group terminal output to websocket",
+					zap.Uint("server_id", member.ServerID),
+					zap.Error(writeErr),
+				)
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				zap.L().Error("failed to read from group terminal", zap.Uint("server_id", member.ServerID), zap.Error(err))
+			}
+			if g.remove(member.ServerID) {
+				_ = g.write(Message{Type: "exit", Data: "session closed", ServerID: member.ServerID})
+			}
+			return
+		}
+	}
+}
+
+// feed applies the queued frames of one member until it is removed.
+func (g *group) feed(serverID uint, member *groupMember) {
+	for apply := range member.queue {
+		if err := apply(member.handler); err != nil && g.live(serverID) {
+			zap.L().Error("failed to write to group terminal", zap.Uint("server_id", serverID), zap.Error(err))
+			_ = g.write(Message{Type: "error", Data: "failed to write to terminal", ServerID: serverID})
+		}
+	}
+}
+
+func (g *group) dispatch() {
+	for {
+		var message Message
+		if err := g.conn.ReadJSON(&message); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				zap.L().Error("failed to read from group terminal websocket", zap.Error(err))
+			}
+			return
+		}
+		switch message.Type {
+		case "stdin":
+			data := []byte(message.Data)
+			g.send(message.ServerID, func(handler *SSH) error {
+				_, err := handler.Write(data)
+				return err
+			})
+		case "resize":
+			cols, rows := message.Cols, message.Rows
+			g.send(0, func(handler *SSH) error {
+				if err := handler.Resize(cols, rows); err != nil {
+					zap.L().Error("failed to resize group terminal",
+						zap.Int("cols", cols),
+						zap.Int("rows", rows),
+						zap.Error(err),
+					)
+				}
+				return nil
+			})
+		default:
+			zap.L().Warn("unknown group terminal websocket message type", zap.String("type", message.Type))
+		}
+	}
+}
+
+// send queues apply for every live member, or only serverID when it is set,
+// and drops the members whose queue is full.
+func (g *group) send(serverID uint, apply func(*SSH) error) {
+	var stalled []uint
+	g.mu.Lock()
+	for id, member := range g.members {
+		if serverID != 0 && id != serverID {
+			continue
+		}
+		select {
+		case member.queue <- apply:
+		default:
+			stalled = append(stalled, id)
+		}
+	}
+	g.mu.Unlock()
+	for _, id := range stalled {
+		zap.L().Warn("dropping group terminal that stopped reading input", zap.Uint("server_id", id))
+		if g.remove(id) {
+			_ = g.write(Message{Type: "exit", Data: "terminal stopped reading input", ServerID: id})
+		}
+	}
+}
+
+func (g *group) live(serverID uint) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.members[serverID]
+	return ok
+}
+
+// remove closes the member's terminal and reports whether it was still live.
+func (g *group) remove(serverID uint) bool {
+	g.mu.Lock()
+	member := g.members[serverID]
+	delete(g.members, serverID)
+	if member != nil {
+		close(member.queue)
+	}
+	g.mu.Unlock()
+	if member == nil {
+		return false
+	}
+	_ = member.handler.Close()
+	return true
+}
+
+func (g *group) closeAll() {
+	g.mu.Lock()
+	ids := make([]uint, 0, len(g.members))
+	for id := range g.members {
+		ids = append(ids, id)
+	}
+	g.mu.Unlock()
+	for _, id := range ids {
+		g.remove(id)
+	}
+}
@@ -0,0 +1,148 @@
+package terminal
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// pipeTerminal stands in for an SSH shell. Input lines are drained in the
+// background because pipe writes block and broadcast order is not defined.
+type pipeTerminal struct {
+	handler *SSH
+	input   chan string
+	output  *io.PipeWriter
+}
+
+func newPipeTerminal() pipeTerminal {
+	inputReader, inputWriter := io.Pipe()
+	outputReader, outputWriter := io.Pipe()
+	terminal := pipeTerminal{
+		handler: &SSH{stdin: inputWriter, stdout: outputReader},
+		input:   make(chan string, 8),
+		output:  outputWriter,
+	}
+	go func() {
+		reader := bufio.NewReader(inputReader)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			terminal.input <- line
+		}
+	}()
+	return terminal
+}
+
+func readLine(t *testing.T, terminal pipeTerminal) string {
+	t.Helper()
+	select {
+	case line := <-terminal.input:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for terminal input")
+		return ""
+	}
+}
+
+func TestGroupBridgeBroadcastsInputAndTagsOutput(t *testing.T) {
+	first, second := newPipeTerminal(), newPipeTerminal()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		GroupBridge(conn, []Member{{ServerID: 1, Handler: first.handler}, {ServerID: 2, Handler: second.handler}})
+	}))
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(Message{Type: "stdin", Data: "uptime\n"}); err != nil {
+		t.Fatal(err)
+	}
+	for index, terminal := range []pipeTerminal{first, second} {
+		if line := readLine(t, terminal); line != "uptime\n" {
+			t.Fatalf("member %d input = %q", index+1, line)
+		}
+	}
+	if err := conn.WriteJSON(Message{Type: "stdin", Data: "only two\n", ServerID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if line := readLine(t, second); line != "only two\n" {
+		t.Fatalf("targeted input = %q", line)
+	}
+	select {
+	case line := <-first.input:
+		t.Fatalf("targeted input reached member 1: %q", line)
+	default:
+	}
+
+	go func() { _, _ = second.output.Write([]byte("up 3 days")) }()
+	var message Message
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != "stdout" || message.ServerID != 2 || message.Data != "up 3 days" {
+		t.Fatalf("message = %#v", message)
+	}
+
+	_ = first.output.Close()
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != "exit" || message.ServerID != 1 {
+		t.Fatalf("message = %#v", message)
+	}
+}
+
+func TestGroupBridgeDropsStalledMember(t *testing.T) {
+	healthy := newPipeTerminal()
+	// Nobody reads the stalled member's input, so every write to it blocks.
+	_, stalledInput := io.Pipe()
+	stalledOutput, _ := io.Pipe()
+	stalled := &SSH{stdin: stalledInput, stdout: stalledOutput}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		GroupBridge(conn, []Member{{ServerID: 1, Handler: healthy.handler}, {ServerID: 2, Handler: stalled}})
+	}))
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	for index := range memberQueue + 2 {
+		if err := conn.WriteJSON(Message{Type: "stdin", Data: "ls\n"}); err != nil {
+			t.Fatal(err)
+		}
+		if line := readLine(t, healthy); line != "ls\n" {
+			t.Fatalf("frame %d input = %q", index, line)
+		}
+	}
+	var message Message
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != "exit" || message.ServerID != 2 {
+		t.Fatalf("message = %#v", message)
+	}
+}
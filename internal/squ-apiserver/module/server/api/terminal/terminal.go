@@ -13,11 +13,15 @@ import (
 
 const bufferSize = 1024
 
+// Message is one WebSocket frame. ServerID is only set by group sessions: on
+// stdout, exit and error frames it names the originating server, and on stdin
+// it restricts the input to a single member instead of broadcasting it.
 type Message struct {
-	Type string `json:"type"`
-	Data string `json:"data"`
-	Cols int    `json:"cols,omitempty"`
-	Rows int    `json:"rows,omitempty"`
+	Type     string `json:"type"`
+	Data     string `json:"data"`
+	Cols     int    `json:"cols,omitempty"`
+	Rows     int    `json:"rows,omitempty"`
+	ServerID uint   `json:"server_id,omitempty"`
 }
 
 type SSH struct {
@@ -64,9 +68,9 @@ func NewSSH(client *ssh.Client, height, width int) (*SSH, error) {
 	return &SSH{session: session, stdin: stdin, stdout: stdout}, nil
 }
 
+// Write does not take mu, so that Close can interrupt a write that blocks
+// on a stalled session.
 func (s *SSH) Write(value []byte) (int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
 	if s.stdin == nil {
 		return 0, fmt.Errorf("终端未初始化")
 	}
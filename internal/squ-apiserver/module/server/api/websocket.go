@@ -1,7 +1,9 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -39,67 +41,177 @@ func (h *Handler) Terminal(c *gin.Context) {
 		_ = conn.Close()
 		return
 	}
+	claims, ok := h.authenticate(conn, zap.Uint("server_id", id))
+	if !ok {
+		return
+	}
+	zap.L().Info("terminal websocket authenticated",
+		zap.Uint("server_id", id),
+		zap.String("username", claims.Username),
+	)
+	server, err := h.service.GetStored(c.Request.Context(), id)
+	if err != nil {
+		_ = serverTerminal.WriteMessage(conn, "error", "server not found")
+		_ = conn.Close()
+		return
+	}
+	client, err := infra.NewSSHClient(server)
+	if err != nil {
+		zap.L().Error("failed to establish terminal ssh connection",
+			zap.Uint("server_id", id),
+			zap.String("ip_address", server.IPAddress),
+			zap.String("username", server.SSHUsername),
+			zap.Error(err),
+		)
+		_ = serverTerminal.WriteMessage(conn, "error", "failed to connect to server")
+		_ = conn.Close()
+		return
+	}
+	terminalHandler, err := serverTerminal.NewSSH(client.Client, 80, 24)
+	if err != nil {
+		zap.L().Error("failed to initialize terminal", zap.Uint("server_id", id), zap.Error(err))
+		_ = serverTerminal.WriteMessage(conn, "error", "failed to initialize terminal")
+		_ = conn.Close()
+		return
+	}
+	serverTerminal.Bridge(conn, terminalHandler)
+	_ = conn.WriteJSON(response.Success("success"))
+}
+
+// authenticate reads the first WebSocket message, which must carry a valid
+// JWT. The connection is closed when authentication fails.
+func (h *Handler) authenticate(conn *websocket.Conn, field zap.Field) (*jwt.CustomClaims, bool) {
 	var auth authMessage
 	if err := conn.ReadJSON(&auth); err != nil {
-		zap.L().Error("failed to read terminal auth message", zap.Uint("server_id", id), zap.Error(err))
+		zap.L().Error("failed to read terminal auth message", field, zap.Error(err))
 		_ = serverTerminal.WriteMessage(conn, "error", "failed to read auth message")
 		_ = conn.Close()
-		return
+		return nil, false
 	}
 	if auth.Type != "auth" {
 		zap.L().Warn("invalid terminal websocket message type",
-			zap.Uint("server_id", id),
+			field,
 			zap.String("type", auth.Type),
 		)
 		_ = serverTerminal.WriteMessage(conn, "error", "expected auth message")
 		_ = conn.Close()
-		return
+		return nil, false
 	}
 	claims, err := jwt.New(h.signingKey).ParseToken(auth.Token)
 	if err != nil {
-		zap.L().Warn("invalid terminal token", zap.Uint("server_id", id), zap.Error(err))
+		zap.L().Warn("invalid terminal token", field, zap.Error(err))
 		_ = serverTerminal.WriteMessage(conn, "auth_failed", "invalid token")
 		_ = conn.Close()
-		return
+		return nil, false
 	}
 	if err := serverTerminal.WriteMessage(conn, "auth_success", "authenticated"); err != nil {
-		zap.L().Error("failed to send terminal auth success", zap.Uint("server_id", id), zap.Error(err))
+		zap.L().Error("failed to send terminal auth success", field, zap.Error(err))
+		_ = conn.Close()
+		return nil, false
+	}
+	return claims, true
+}
+
+func parseServerID(value string) (uint, error) {
+	return utils.StringToUint(value)
+}
+
+// maxGroupTerminals bounds how many SSH shells a single group session opens.
+const maxGroupTerminals = 20
+
+// GroupTerminal opens one shell per server listed in the ids query parameter
+// (comma separated) and bridges them to a single WebSocket. Servers that
+// cannot be reached are reported with a tagged error frame and skipped.
+func (h *Handler) GroupTerminal(c *gin.Context) {
+	conn, err := (&websocket.Upgrader{
+		CheckOrigin: func(*http.Request) bool { return true },
+	}).Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		zap.L().Error("failed to upgrade group terminal websocket",
+			zap.String("raw_server_ids", c.Query("ids")),
+			zap.Error(err),
+		)
+		return
+	}
+	ids, err := parseServerIDs(c.Query("ids"))
+	if err != nil || len(ids) == 0 || len(ids) > maxGroupTerminals {
+		zap.L().Warn("failed to parse group terminal server IDs",
+			zap.String("raw_server_ids", c.Query("ids")),
+			zap.Error(err),
+		)
+		_ = conn.WriteJSON(response.Error(res.ErrInvalidParameter))
 		_ = conn.Close()
 		return
 	}
-	zap.L().Info("terminal websocket authenticated",
-		zap.Uint("server_id", id),
+	claims, ok := h.authenticate(conn, zap.Uints("server_ids", ids))
+	if !ok {
+		return
+	}
+	zap.L().Info("group terminal websocket authenticated",
+		zap.Uints("server_ids", ids),
 		zap.String("username", claims.Username),
 	)
-	server, err := h.service.GetStored(c.Request.Context(), id)
-	if err != nil {
-		_ = serverTerminal.WriteMessage(conn, "error", "server not found")
+	var members []serverTerminal.Member
+	for _, id := range ids {
+		member, closeClient, err := h.openTerminal(c, id)
+		if err != nil {
+			_ = conn.WriteJSON(serverTerminal.Message{Type: "error", Data: err.Error(), ServerID: id})
+			continue
+		}
+		defer closeClient()
+		members = append(members, member)
+	}
+	if len(members) == 0 {
+		_ = serverTerminal.WriteMessage(conn, "error", "failed to connect to any server")
 		_ = conn.Close()
 		return
 	}
+	serverTerminal.GroupBridge(conn, members)
+}
+
+// openTerminal starts a shell on one group member. The returned error text
+// is sent to the browser, so it never includes connection details.
+func (h *Handler) openTerminal(c *gin.Context, id uint) (serverTerminal.Member, func(), error) {
+	server, err := h.service.GetStored(c.Request.Context(), id)
+	if err != nil {
+		return serverTerminal.Member{}, nil, errors.New("server not found")
+	}
 	client, err := infra.NewSSHClient(server)
 	if err != nil {
-		zap.L().Error("failed to establish terminal ssh connection",
+		zap.L().Error("failed to establish group terminal ssh connection",
 			zap.Uint("server_id", id),
 			zap.String("ip_address", server.IPAddress),
 			zap.String("username", server.SSHUsername),
 			zap.Error(err),
 		)
-		_ = serverTerminal.WriteMessage(conn, "error", "failed to connect to server")
-		_ = conn.Close()
-		return
+		return serverTerminal.Member{}, nil, errors.New("failed to connect to server")
 	}
-	terminalHandler, err := serverTerminal.NewSSH(client.Client, 80, 24)
+	handler, err := serverTerminal.NewSSH(client.Client, 80, 24)
 	if err != nil {
-		zap.L().Error("failed to initialize terminal", zap.Uint("server_id", id), zap.Error(err))
-		_ = serverTerminal.WriteMessage(conn, "error", "failed to initialize terminal")
-		_ = conn.Close()
-		return
+		zap.L().Error("failed to initialize group terminal", zap.Uint("server_id", id), zap.Error(err))
+		client.Close()
+		return serverTerminal.Member{}, nil, errors.New("failed to initialize terminal")
 	}
-	serverTerminal.Bridge(conn, terminalHandler)
-	_ = conn.WriteJSON(response.Success("success"))
+	return serverTerminal.Member{ServerID: id, Handler: handler}, client.Close, nil
 }
 
-func parseServerID(value string) (uint, error) {
-	return utils.StringToUint(value)
+func parseServerIDs(value string) ([]uint, error) {
+	seen := make(map[uint]struct{})
+	var result []uint
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := parseServerID(raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result, nil
 }
@@ -0,0 +1,16 @@
+package application
+
+import "errors"
+
+// ErrGroupMemberNotFound keeps a missing member apart from a missing group,
+// which both surface as gorm.ErrRecordNotFound from the repositories.
+var ErrGroupMemberNotFound = errors.New("group member server not found")
+
+// ErrInvalidInventory is returned when an imported file cannot be parsed at
+// all; problems in single rows are reported on the rows instead.
+var ErrInvalidInventory = errors.New("invalid inventory file")
+
+// ErrKeyNotFound is returned when a server references a missing vault key.
+var ErrKeyNotFound = errors.New("ssh key not found")
+
+var ErrInvalidKeyName = errors.New("invalid ssh key name")
@@ -0,0 +1,116 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+type GroupService struct {
+	groups  domain.GroupRepository
+	servers domain.Repository
+}
+
+func NewGroupService(groups domain.GroupRepository, servers domain.Repository) *GroupService {
+	return &GroupService{groups: groups, servers: servers}
+}
+
+func (s *GroupService) List(ctx context.Context) ([]domain.Group, error) {
+	groups, err := s.groups.ListGroups(ctx)
+	if err != nil {
+		zap.L().Error("failed to list server groups", zap.Error(err))
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (s *GroupService) Get(ctx context.Context, id uint) (domain.Group, error) {
+	group, err := s.groups.GetGroup(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get server group", zap.Uint("group_id", id), zap.Error(err))
+		return domain.Group{}, err
+	}
+	return group, nil
+}
+
+func (s *GroupService) Add(ctx context.Context, group domain.Group) (domain.Group, error) {
+	if err := domain.ValidateGroupName(group.Name); err != nil {
+		return domain.Group{}, err
+	}
+	if err := s.groups.AddGroup(ctx, &group); err != nil {
+		zap.L().Error("failed to add server group", zap.String("group_name", group.Name), zap.Error(err))
+		return domain.Group{}, err
+	}
+	if group.ServerIDs != nil {
+		if err := s.SetMembers(ctx, group.ID, group.ServerIDs); err != nil {
+			return domain.Group{}, err
+		}
+	}
+	return group, nil
+}
+
+func (s *GroupService) Update(ctx context.Context, group domain.Group) error {
+	if err := domain.ValidateGroupName(group.Name); err != nil {
+		return err
+	}
+	if _, err := s.groups.GetGroup(ctx, group.ID); err != nil {
+		zap.L().Error("failed to get server group for update", zap.Uint("group_id", group.ID), zap.Error(err))
+		return err
+	}
+	if err := s.groups.UpdateGroup(ctx, &group); err != nil {
+		zap.L().Error("failed to update server group",
+			zap.Uint("group_id", group.ID),
+			zap.String("group_name", group.Name),
+			zap.Error(err),
+		)
+		return err
+	}
+	if group.ServerIDs != nil {
+		return s.SetMembers(ctx, group.ID, group.ServerIDs)
+	}
+	return nil
+}
+
+func (s *GroupService) Delete(ctx context.Context, id uint) error {
+	if err := s.groups.DeleteGroup(ctx, id); err != nil {
+		zap.L().Error("failed to delete server group", zap.Uint("group_id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// SetMembers replaces the members of a group. Every server must exist.
+func (s *GroupService) SetMembers(ctx context.Context, id uint, serverIDs []uint) error {
+	if _, err := s.groups.GetGroup(ctx, id); err != nil {
+		zap.L().Error("failed to get server group for members", zap.Uint("group_id", id), zap.Error(err))
+		return err
+	}
+	serverIDs = slices.Compact(slices.Sorted(slices.Values(serverIDs)))
+	for _, serverID := range serverIDs {
+		if _, err := s.servers.Get(ctx, serverID); err != nil {
+			zap.L().Warn("server group member not found",
+				zap.Uint("group_id", id),
+				zap.Uint("server_id", serverID),
+				zap.Error(err),
+			)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGroupMemberNotFound
+			}
+			return err
+		}
+	}
+	if err := s.groups.SetGroupMembers(ctx, id, serverIDs); err != nil {
+		zap.L().Error("failed to set server group members",
+			zap.Uint("group_id", id),
+			zap.Uints("server_ids", serverIDs),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+	"squirrel-dev/internal/squ-apiserver/module/server/inventory"
+)
+
+const (
+	ImportActionCreate = "create"
+	ImportActionSkip   = "skip"
+	ImportActionError  = "error"
+
+	maxInventorySize = 10 << 20
+)
+
+// ImportRow reports what happened, or in a dry run what would happen, to one
+// row of the imported file.
+type ImportRow struct {
+	Row       int
+	Hostname  string
+	IPAddress string
+	Action    string
+	ServerID  uint
+	Reason    string
+	Warnings  []string
+}
+
+type ImportResult struct {
+	DryRun    bool
+	Created   int
+	Skipped   int
+	Failed    int
+	NewGroups []string
+	Rows      []ImportRow
+}
+
+type InventoryService struct {
+	servers domain.Repository
+	groups  domain.GroupRepository
+}
+
+func NewInventoryService(servers domain.Repository, groups domain.GroupRepository) *InventoryService {
+	return &InventoryService{servers: servers, groups: groups}
+}
+
+// Import creates the servers listed in an inventory file. Rows whose hostname
+// or IP address is already known, or repeats an earlier row, are skipped; rows
+// that fail validation are reported and do not stop the others. With dryRun
+// nothing is written and the result previews the import.
+func (s *InventoryService) Import(ctx context.Context, format string, reader io.Reader, dryRun bool) (ImportResult, error) {
+	content, err := io.ReadAll(io.LimitReader(reader, maxInventorySize+1))
+	if err != nil {
+		return ImportResult{}, err
+	}
+	if len(content) > maxInventorySize {
+		return ImportResult{}, fmt.Errorf("%w: larger than %d bytes", ErrInvalidInventory, maxInventorySize)
+	}
+	records, err := inventory.Decode(format, bytes.NewReader(content))
+	if err != nil {
+		zap.L().Warn("failed to decode server inventory", zap.String("format", format), zap.Error(err))
+		if errors.Is(err, inventory.ErrUnsupportedFormat) {
+			return ImportResult{}, err
+		}
+		return ImportResult{}, fmt.Errorf("%w: %v", ErrInvalidInventory, err)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Row < records[j].Row })
+
+	existing, err := s.servers.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to list servers for import", zap.Error(err))
+		return ImportResult{}, err
+	}
+	byHostname := make(map[string]string, len(existing))
+	byIP := make(map[string]string, len(existing))
+	for _, server := range existing {
+		byHostname[server.Hostname] = fmt.Sprintf("server %d", server.ID)
+		byIP[server.IPAddress] = fmt.Sprintf("server %d", server.ID)
+	}
+	groups, err := s.groups.ListGroups(ctx)
+	if err != nil {
+		zap.L().Error("failed to list server groups for import", zap.Error(err))
+		return ImportResult{}, err
+	}
+	groupsByName := make(map[string]domain.Group, len(groups))
+	for _, group := range groups {
+		groupsByName[group.Name] = group
+	}
+
+	result := ImportResult{DryRun: dryRun}
+	members := make(map[string][]uint)
+	for _, record := range records {
+		row := ImportRow{Row: record.Row, Hostname: record.Entry.Hostname, IPAddress: record.Entry.IPAddress, Warnings: record.Warnings}
+		entry, err := normalizeEntry(record)
+		row.Hostname = entry.Hostname
+		switch {
+		case err != nil:
+			row.Action, row.Reason = ImportActionError, err.Error()
+		case byHostname[entry.Hostname] != "":
+			row.Action, row.Reason = ImportActionSkip, "hostname already used by "+byHostname[entry.Hostname]
+		case byIP[entry.IPAddress] != "":
+			row.Action, row.Reason = ImportActionSkip, "IP address already used by "+byIP[entry.IPAddress]
+		default:
+			row.Action = ImportActionCreate
+		}
+		if row.Action == ImportActionCreate && !dryRun {
+			id, err := s.create(ctx, entry)
+			if err != nil {
+				row.Action, row.Reason = ImportActionError, err.Error()
+			}
+			row.ServerID = id
+		}
+		if row.Action == ImportActionCreate {
+			where := fmt.Sprintf("row %d", record.Row)
+			byHostname[entry.Hostname], byIP[entry.IPAddress] = where, where
+			for _, group := range entry.Groups {
+				if _, ok := groupsByName[group]; !ok && !slices.Contains(result.NewGroups, group) {
+					result.NewGroups = append(result.NewGroups, group)
+				}
+				members[group] = append(members[group], row.ServerID)
+			}
+		}
+		switch row.Action {
+		case ImportActionCreate:
+			result.Created++
+		case ImportActionSkip:
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if !dryRun {
+		if err := s.addMembers(ctx, groupsByName, members); err != nil {
+			return result, err
+		}
+	}
+	zap.L().Info("imported server inventory",
+		zap.String("format", format),
+		zap.Bool("dry_run", dryRun),
+		zap.Int("created", result.Created),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed),
+	)
+	return result, nil
+}
+
+// Export writes the servers matching target in format. Passwords and private
+// keys are left out unless includeSecrets is set.
+func (s *InventoryService) Export(ctx context.Context, format string, target domain.Target, includeSecrets bool, writer io.Writer) error {
+	if !slices.Contains(inventory.Formats(), format) {
+		return fmt.Errorf("%w: %q", inventory.ErrUnsupportedFormat, format)
+	}
+	servers, err := s.servers.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to list servers for export", zap.Error(err))
+		return err
+	}
+	if servers, err = domain.SelectServers(servers, target); err != nil {
+		return err
+	}
+	entries := make([]inventory.Entry, 0, len(servers))
+	for _, server := range servers {
+		entry := inventory.Entry{
+			Hostname: server.Hostname, IPAddress: server.IPAddress, AgentPort: server.AgentPort,
+			SSHUsername: server.SSHUsername, SSHPort: server.SSHPort, AuthType: server.AuthType,
+			Labels: server.Labels, Groups: server.Groups,
+		}
+		if server.ServerAlias != nil {
+			entry.ServerAlias = *server.ServerAlias
+		}
+		if includeSecrets && server.SSHPassword != nil {
+			entry.SSHPassword = *server.SSHPassword
+		}
+		if includeSecrets && server.SSHPrivateKey != nil && server.SSHKeyID == nil {
+			entry.SSHPrivateKey = *server.SSHPrivateKey
+		}
+		entries = append(entries, entry)
+	}
+	zap.L().Info("exported server inventory",
+		zap.String("format", format),
+		zap.Int("servers", len(entries)),
+		zap.Bool("include_secrets", includeSecrets),
+	)
+	return inventory.Encode(format, writer, entries)
+}
+
+// normalizeEntry applies the same defaults and checks as adding a server by
+// hand.
+func normalizeEntry(record inventory.Record) (inventory.Entry, error) {
+	entry := record.Entry
+	if record.Err != nil {
+		return entry, record.Err
+	}
+	if entry.IPAddress == "" {
+		return entry, errors.New("ip_address is required")
+	}
+	if entry.Hostname == "" {
+		entry.Hostname = entry.IPAddress
+	}
+	switch entry.AuthType {
+	case "":
+		entry.AuthType = domain.AuthTypePassword
+		if entry.SSHPrivateKey != "" {
+			entry.AuthType = domain.AuthTypeKey
+		}
+	case domain.AuthTypePassword, domain.AuthTypeKey:
+	default:
+		return entry, fmt.Errorf("auth_type %q is not supported", entry.AuthType)
+	}
+	if err := domain.ValidateLabels(entry.Labels); err != nil {
+		return entry, err
+	}
+	for _, group := range entry.Groups {
+		if err := domain.ValidateGroupName(group); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+func (s *InventoryService) create(ctx context.Context, entry inventory.Entry) (uint, error) {
+	server := requestToServer(Request{
+		Hostname: entry.Hostname, IPAddress: entry.IPAddress, Port: entry.AgentPort,
+		SSHUsername: entry.SSHUsername, SSHPassword: entry.SSHPassword, SSHPrivateKey: entry.SSHPrivateKey,
+		SSHPort: entry.SSHPort, AuthType: entry.AuthType, ServerAlias: entry.ServerAlias,
+	})
+	server.UUID = uuid.New().String()
+	server.Labels = entry.Labels
+	if err := s.servers.Add(ctx, &server); err != nil {
+		zap.L().Error("failed to add imported server",
+			zap.String("hostname", server.Hostname),
+			zap.String("ip_address", server.IPAddress),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+	return server.ID, nil
+}
+
+// addMembers appends the imported servers to their groups, creating the
+// groups that do not exist yet.
+func (s *InventoryService) addMembers(ctx context.Context, groups map[string]domain.Group, members map[string][]uint) error {
+	for name, serverIDs := range members {
+		group, ok := groups[name]
+		if !ok {
+			group = domain.Group{Name: name}
+			if err := s.groups.AddGroup(ctx, &group); err != nil {
+				zap.L().Error("failed to add imported server group", zap.String("group_name", name), zap.Error(err))
+				return err
+			}
+		}
+		serverIDs = slices.Compact(slices.Sorted(slices.Values(append(group.ServerIDs, serverIDs...))))
+		if err := s.groups.SetGroupMembers(ctx, group.ID, serverIDs); err != nil {
+			zap.L().Error("failed to add imported servers to group",
+				zap.Uint("group_id", group.ID),
+				zap.Uints("server_ids", serverIDs),
+				zap.Error(err),
+			)
+			return err
+		}
+	}
+	return nil
+}
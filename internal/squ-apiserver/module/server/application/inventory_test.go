@@ -0,0 +1,137 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+type inventoryStore struct {
+	servers []domain.Server
+	groups  []domain.Group
+}
+
+func (s *inventoryStore) List(context.Context) ([]domain.Server, error) { return s.servers, nil }
+func (s *inventoryStore) Get(_ context.Context, id uint) (domain.Server, error) {
+	for _, server := range s.servers {
+		if server.ID == id {
+			return server, nil
+		}
+	}
+	return domain.Server{}, gorm.ErrRecordNotFound
+}
+func (s *inventoryStore) Delete(context.Context, uint) error { return nil }
+func (s *inventoryStore) Add(_ context.Context, server *domain.Server) error {
+	server.ID = uint(len(s.servers) + 1)
+	s.servers = append(s.servers, *server)
+	return nil
+}
+func (s *inventoryStore) Update(context.Context, *domain.Server) error { return nil }
+func (s *inventoryStore) GetByUUID(context.Context, string) (domain.Server, error) {
+	return domain.Server{}, nil
+}
+func (s *inventoryStore) SetLabels(_ context.Context, id uint, labels map[string]string) error {
+	s.servers[id-1].Labels = labels
+	return nil
+}
+
+func (s *inventoryStore) ListGroups(context.Context) ([]domain.Group, error) { return s.groups, nil }
+func (s *inventoryStore) GetGroup(context.Context, uint) (domain.Group, error) {
+	return domain.Group{}, nil
+}
+func (s *inventoryStore) AddGroup(_ context.Context, group *domain.Group) error {
+	group.ID = uint(len(s.groups) + 1)
+	s.groups = append(s.groups, *group)
+	return nil
+}
+func (s *inventoryStore) UpdateGroup(context.Context, *domain.Group) error { return nil }
+func (s *inventoryStore) DeleteGroup(context.Context, uint) error          { return nil }
+func (s *inventoryStore) SetGroupMembers(_ context.Context, id uint, serverIDs []uint) error {
+	s.groups[id-1].ServerIDs = serverIDs
+	return nil
+}
+
+const importCSV = "hostname,ip_address,labels,groups\n" +
+	"web-1,10.0.0.1,env=prod,web\n" +
+	"web-2,10.0.0.2,,web;db\n" +
+	"web-1,10.0.0.3,,\n" +
+	"old,10.0.0.4,,\n" +
+	",,,\n" +
+	"web-5,10.0.0.5,bad key=x,\n"
+
+func TestInventoryImportDryRun(t *testing.T) {
+	store := &inventoryStore{
+		servers: []domain.Server{{ID: 1, Hostname: "old", IPAddress: "10.0.0.9"}},
+		groups:  []domain.Group{{ID: 1, Name: "web", ServerIDs: []uint{1}}},
+	}
+	service := NewInventoryService(store, store)
+	result, err := service.Import(context.Background(), "csv", strings.NewReader(importCSV), true)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Created != 2 || result.Skipped != 2 || result.Failed != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	want := []string{ImportActionCreate, ImportActionCreate, ImportActionSkip, ImportActionSkip, ImportActionError, ImportActionError}
+	for i, row := range result.Rows {
+		if row.Action != want[i] || row.Row != i+2 {
+			t.Fatalf("row %d = %+v, want action %s", i, row, want[i])
+		}
+	}
+	if len(result.NewGroups) != 1 || result.NewGroups[0] != "db" {
+		t.Fatalf("new groups = %v", result.NewGroups)
+	}
+	if len(store.servers) != 1 || len(store.groups) != 1 {
+		t.Fatal("dry run wrote to the store")
+	}
+}
+
+func TestInventoryImport(t *testing.T) {
+	store := &inventoryStore{groups: []domain.Group{{ID: 1, Name: "web", ServerIDs: []uint{7}}}}
+	service := NewInventoryService(store, store)
+	result, err := service.Import(context.Background(), "csv", strings.NewReader(importCSV), false)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Created != 3 || len(store.servers) != 3 {
+		t.Fatalf("created = %d, stored = %d", result.Created, len(store.servers))
+	}
+	if store.servers[0].Labels["env"] != "prod" || store.servers[0].UUID == "" {
+		t.Fatalf("first server = %+v", store.servers[0])
+	}
+	if got := store.groups[0].ServerIDs; len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 7 {
+		t.Fatalf("web members = %v", got)
+	}
+	if len(store.groups) != 2 || store.groups[1].Name != "db" {
+		t.Fatalf("groups = %+v", store.groups)
+	}
+}
+
+func TestInventoryExportOmitsSecrets(t *testing.T) {
+	password := "secret"
+	store := &inventoryStore{servers: []domain.Server{{ID: 1, Hostname: "web-1", IPAddress: "10.0.0.1", AuthType: domain.AuthTypePassword, SSHPassword: &password}}}
+	service := NewInventoryService(store, store)
+
+	var buffer bytes.Buffer
+	if err := service.Export(context.Background(), "yaml", domain.Target{}, false, &buffer); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if strings.Contains(buffer.String(), password) {
+		t.Fatalf("export contains the password:\n%s", buffer.String())
+	}
+	buffer.Reset()
+	if err := service.Export(context.Background(), "yaml", domain.Target{}, true, &buffer); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !strings.Contains(buffer.String(), password) {
+		t.Fatalf("export with secrets lacks the password:\n%s", buffer.String())
+	}
+	if err := service.Export(context.Background(), "xml", domain.Target{}, false, &buffer); err == nil {
+		t.Fatal("Export() with an unknown format error = nil")
+	}
+}
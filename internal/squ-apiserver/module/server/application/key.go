@@ -0,0 +1,191 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+const (
+	keyInstallConcurrency = 10
+	keyInstallTimeout     = 30 * time.Second
+	maxKeyNameLength      = 100
+)
+
+// KeyInstall installs a vault key on every server matched by Target. With
+// SwitchAuth the servers are moved to the key once a login with it succeeds.
+type KeyInstall struct {
+	KeyID      uint
+	Target     domain.Target
+	SwitchAuth bool
+}
+
+type KeyInstallResult struct {
+	ServerID  uint
+	Hostname  string
+	Installed bool
+	Switched  bool
+	Error     string
+}
+
+type KeyService struct {
+	keys      domain.KeyRepository
+	servers   domain.Repository
+	installer domain.KeyInstaller
+}
+
+func NewKeyService(keys domain.KeyRepository, servers domain.Repository, installer domain.KeyInstaller) *KeyService {
+	return &KeyService{keys: keys, servers: servers, installer: installer}
+}
+
+func (s *KeyService) List(ctx context.Context) ([]domain.Key, error) {
+	keys, err := s.keys.ListKeys(ctx)
+	if err != nil {
+		zap.L().Error("failed to list ssh keys", zap.Error(err))
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *KeyService) Get(ctx context.Context, id uint) (domain.Key, error) {
+	key, err := s.keys.GetKey(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get ssh key", zap.Uint("key_id", id), zap.Error(err))
+		return domain.Key{}, err
+	}
+	return key, nil
+}
+
+func (s *KeyService) Generate(ctx context.Context, name, keyType string, bits int, comment string) (domain.Key, error) {
+	if err := validateKeyName(name); err != nil {
+		return domain.Key{}, err
+	}
+	key, err := domain.GenerateKey(keyType, bits, comment)
+	if err != nil {
+		return domain.Key{}, err
+	}
+	return s.add(ctx, name, key)
+}
+
+func (s *KeyService) Import(ctx context.Context, name, privateKey, passphrase, comment string) (domain.Key, error) {
+	if err := validateKeyName(name); err != nil {
+		return domain.Key{}, err
+	}
+	key, err := domain.ParseKey(privateKey, passphrase, comment)
+	if err != nil {
+		zap.L().Warn("failed to parse imported ssh key", zap.String("key_name", name), zap.Error(err))
+		return domain.Key{}, err
+	}
+	return s.add(ctx, name, key)
+}
+
+func (s *KeyService) Delete(ctx context.Context, id uint) error {
+	if err := s.keys.DeleteKey(ctx, id); err != nil {
+		zap.L().Error("failed to delete ssh key", zap.Uint("key_id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Install appends the key's public half to authorized_keys on each target
+// using the server's current credentials. Failures are reported per server.
+func (s *KeyService) Install(ctx context.Context, install KeyInstall) ([]KeyInstallResult, error) {
+	if install.Target.Empty() {
+		return nil, domain.ErrInvalidSelector
+	}
+	key, err := s.keys.GetKey(ctx, install.KeyID)
+	if err != nil {
+		zap.L().Error("failed to get ssh key for install", zap.Uint("key_id", install.KeyID), zap.Error(err))
+		return nil, err
+	}
+	servers, err := s.servers.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to list servers for key install", zap.Error(err))
+		return nil, err
+	}
+	if servers, err = domain.SelectServers(servers, install.Target); err != nil {
+		return nil, err
+	}
+	results := make([]KeyInstallResult, len(servers))
+	semaphore := make(chan struct{}, keyInstallConcurrency)
+	var wait sync.WaitGroup
+	for i, server := range servers {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i] = s.installOne(ctx, key, server, install.SwitchAuth)
+		}()
+	}
+	wait.Wait()
+	zap.L().Info("installed ssh key",
+		zap.Uint("key_id", key.ID),
+		zap.String("fingerprint", key.Fingerprint),
+		zap.Int("servers", len(results)),
+		zap.Bool("switch_auth", install.SwitchAuth),
+	)
+	return results, nil
+}
+
+func (s *KeyService) installOne(ctx context.Context, key domain.Key, server domain.Server, switchAuth bool) KeyInstallResult {
+	result := KeyInstallResult{ServerID: server.ID, Hostname: server.Hostname}
+	ctx, cancel := context.WithTimeout(ctx, keyInstallTimeout)
+	defer cancel()
+	fail := func(message string, err error) KeyInstallResult {
+		zap.L().Warn(message,
+			zap.Uint("key_id", key.ID),
+			zap.Uint("server_id", server.ID),
+			zap.String("ip_address", server.IPAddress),
+			zap.Error(err),
+		)
+		result.Error = err.Error()
+		return result
+	}
+	// List leaves out vault private keys, which the installer may need.
+	server, err := s.servers.Get(ctx, server.ID)
+	if err != nil {
+		return fail("failed to get server for key install", err)
+	}
+	if err := s.installer.Install(ctx, server, key.PublicKey); err != nil {
+		return fail("failed to install ssh key", err)
+	}
+	result.Installed = true
+	if !switchAuth {
+		return result
+	}
+	if err := s.installer.Verify(ctx, server, key); err != nil {
+		return fail("failed to verify installed ssh key", err)
+	}
+	if err := s.keys.UseKey(ctx, server.ID, key.ID); err != nil {
+		return fail("failed to switch server to ssh key", err)
+	}
+	result.Switched = true
+	return result
+}
+
+func (s *KeyService) add(ctx context.Context, name string, key domain.Key) (domain.Key, error) {
+	key.Name = name
+	if err := s.keys.AddKey(ctx, &key); err != nil {
+		zap.L().Error("failed to add ssh key", zap.String("key_name", name), zap.Error(err))
+		return domain.Key{}, err
+	}
+	zap.L().Info("added ssh key",
+		zap.Uint("key_id", key.ID),
+		zap.String("key_name", name),
+		zap.String("key_type", key.Type),
+		zap.String("fingerprint", key.Fingerprint),
+	)
+	return key, nil
+}
+
+func validateKeyName(name string) error {
+	if name == "" || len(name) > maxKeyNameLength {
+		return ErrInvalidKeyName
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+type keyStore struct {
+	mu   sync.Mutex
+	key  domain.Key
+	used map[uint]uint
+}
+
+func (s *keyStore) ListKeys(context.Context) ([]domain.Key, error) { return []domain.Key{s.key}, nil }
+func (s *keyStore) GetKey(context.Context, uint) (domain.Key, error) {
+	return s.key, nil
+}
+func (s *keyStore) AddKey(_ context.Context, key *domain.Key) error {
+	key.ID = 1
+	s.key = *key
+	return nil
+}
+func (s *keyStore) DeleteKey(context.Context, uint) error { return nil }
+func (s *keyStore) UseKey(_ context.Context, serverID, keyID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used[serverID] = keyID
+	return nil
+}
+func (s *keyStore) ReleaseKey(context.Context, uint) error { return nil }
+
+type installerStub struct {
+	installErr map[uint]error
+	verifyErr  map[uint]error
+}
+
+func (i installerStub) Install(_ context.Context, server domain.Server, _ string) error {
+	return i.installErr[server.ID]
+}
+
+func (i installerStub) Verify(_ context.Context, server domain.Server, _ domain.Key) error {
+	return i.verifyErr[server.ID]
+}
+
+func TestKeyInstallSwitchesVerifiedServers(t *testing.T) {
+	servers := &inventoryStore{servers: []domain.Server{
+		{ID: 1, Hostname: "web-1", Groups: []string{"web"}},
+		{ID: 2, Hostname: "web-2", Groups: []string{"web"}},
+		{ID: 3, Hostname: "web-3", Groups: []string{"web"}},
+		{ID: 4, Hostname: "db-1"},
+	}}
+	keys := &keyStore{used: map[uint]uint{}}
+	installer := installerStub{
+		installErr: map[uint]error{2: errors.New("permission denied")},
+		verifyErr:  map[uint]error{3: errors.New("login failed")},
+	}
+	service := NewKeyService(keys, servers, installer)
+	key, err := service.Generate(context.Background(), "deploy", domain.KeyTypeED25519, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := service.Install(context.Background(), KeyInstall{
+		KeyID: key.ID, Target: domain.Target{Group: "web"}, SwitchAuth: true,
+	})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %+v", results)
+	}
+	want := []KeyInstallResult{
+		{ServerID: 1, Hostname: "web-1", Installed: true, Switched: true},
+		{ServerID: 2, Hostname: "web-2", Error: "permission denied"},
+		{ServerID: 3, Hostname: "web-3", Installed: true, Error: "login failed"},
+	}
+	for i, result := range results {
+		if result != want[i] {
+			t.Fatalf("result %d = %+v, want %+v", i, result, want[i])
+		}
+	}
+	if len(keys.used) != 1 || keys.used[1] != key.ID {
+		t.Fatalf("servers switched to the key = %v", keys.used)
+	}
+
+	if _, err := service.Install(context.Background(), KeyInstall{KeyID: key.ID}); !errors.Is(err, domain.ErrInvalidSelector) {
+		t.Fatalf("Install() without target error = %v", err)
+	}
+	if _, err := service.Generate(context.Background(), "", domain.KeyTypeED25519, 0, ""); !errors.Is(err, ErrInvalidKeyName) {
+		t.Fatalf("Generate() without name error = %v", err)
+	}
+}
@@ -2,9 +2,11 @@ package application
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
@@ -21,6 +23,16 @@ type Request struct {
 	AuthType      string
 	Status        string
 	ServerAlias   string
+	Labels        map[string]string
+	// SSHKeyID switches the server to a vault key when set.
+	SSHKeyID *uint
+}
+
+// LabelChange sets and removes labels on every server matched by Target.
+type LabelChange struct {
+	Target domain.Target
+	Set    map[string]string
+	Remove []string
 }
 
 type ServerView struct {
@@ -30,18 +42,20 @@ type ServerView struct {
 
 type Service struct {
 	repository domain.Repository
+	keys       domain.KeyRepository
 	agents     domain.AgentInfoClient
 	ssh        domain.SSHTester
 }
 
-func NewService(repository domain.Repository, agents domain.AgentInfoClient, ssh domain.SSHTester) *Service {
-	return &Service{repository: repository, agents: agents, ssh: ssh}
+func NewService(repository domain.Repository, keys domain.KeyRepository, agents domain.AgentInfoClient, ssh domain.SSHTester) *Service {
+	return &Service{repository: repository, keys: keys, agents: agents, ssh: ssh}
 }
 
-func (s *Service) List(ctx context.Context) ([]ServerView, error) {
-	servers, err := s.repository.List(ctx)
+// List returns the servers matching target; an empty target lists all of
+// them. The agent is only queried for the servers that are returned.
+func (s *Service) List(ctx context.Context, target domain.Target) ([]ServerView, error) {
+	servers, err := s.Select(ctx, target)
 	if err != nil {
-		zap.L().Error("failed to list servers", zap.Error(err))
 		return nil, err
 	}
 	var result []ServerView
@@ -64,6 +78,25 @@ func (s *Service) Get(ctx context.Context, id uint) (ServerView, error) {
 	return ServerView{Server: server, ServerInfo: info}, nil
 }
 
+// Select resolves target to stored servers without contacting the agents.
+func (s *Service) Select(ctx context.Context, target domain.Target) ([]domain.Server, error) {
+	servers, err := s.repository.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to list servers", zap.Error(err))
+		return nil, err
+	}
+	result, err := domain.SelectServers(servers, target)
+	if err != nil {
+		zap.L().Warn("invalid server selector",
+			zap.String("selector", target.Selector),
+			zap.String("group", target.Group),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return result, nil
+}
+
 func (s *Service) GetStored(ctx context.Context, id uint) (domain.Server, error) {
 	server, err := s.repository.Get(ctx, id)
 	if err != nil {
@@ -82,6 +115,12 @@ func (s *Service) Delete(ctx context.Context, id uint) error {
 }
 
 func (s *Service) Add(ctx context.Context, request Request) error {
+	if err := domain.ValidateLabels(request.Labels); err != nil {
+		return err
+	}
+	if err := s.checkKey(ctx, &request); err != nil {
+		return err
+	}
 	server := requestToServer(request)
 	server.UUID = uuid.New().String()
 	if err := s.repository.Add(ctx, &server); err != nil {
@@ -92,10 +131,19 @@ func (s *Service) Add(ctx context.Context, request Request) error {
 		)
 		return err
 	}
-	return nil
+	if err := s.saveKey(ctx, server.ID, request.SSHKeyID); err != nil {
+		return err
+	}
+	return s.saveLabels(ctx, server.ID, request.Labels)
 }
 
 func (s *Service) Update(ctx context.Context, request Request) error {
+	if err := domain.ValidateLabels(request.Labels); err != nil {
+		return err
+	}
+	if err := s.checkKey(ctx, &request); err != nil {
+		return err
+	}
 	existing, err := s.repository.Get(ctx, request.ID)
 	if err != nil {
 		zap.L().Error("failed to get server for update", zap.Uint("server_id", request.ID), zap.Error(err))
@@ -113,6 +161,111 @@ func (s *Service) Update(ctx context.Context, request Request) error {
 		)
 		return err
 	}
+	// Choosing password auth or pasting a key replaces the vault reference;
+	// leaving both untouched keeps it.
+	if request.SSHKeyID == nil && existing.SSHKeyID != nil &&
+		(request.AuthType == domain.AuthTypePassword || request.SSHPrivateKey != "") {
+		if err := s.keys.ReleaseKey(ctx, server.ID); err != nil {
+			zap.L().Error("failed to release server ssh key", zap.Uint("server_id", server.ID), zap.Error(err))
+			return err
+		}
+	}
+	if err := s.saveKey(ctx, server.ID, request.SSHKeyID); err != nil {
+		return err
+	}
+	return s.saveLabels(ctx, server.ID, request.Labels)
+}
+
+// checkKey makes sure a referenced vault key exists and drops the inline
+// credentials it replaces.
+func (s *Service) checkKey(ctx context.Context, request *Request) error {
+	if request.SSHKeyID == nil {
+		return nil
+	}
+	if _, err := s.keys.GetKey(ctx, *request.SSHKeyID); err != nil {
+		zap.L().Warn("failed to get ssh key for server", zap.Uint("key_id", *request.SSHKeyID), zap.Error(err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrKeyNotFound
+		}
+		return err
+	}
+	request.AuthType, request.SSHPassword, request.SSHPrivateKey = domain.AuthTypeKey, "", ""
+	return nil
+}
+
+func (s *Service) saveKey(ctx context.Context, id uint, keyID *uint) error {
+	if keyID == nil {
+		return nil
+	}
+	if err := s.keys.UseKey(ctx, id, *keyID); err != nil {
+		zap.L().Error("failed to save server ssh key", zap.Uint("server_id", id), zap.Uint("key_id", *keyID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// SetLabels replaces all labels of one server.
+func (s *Service) SetLabels(ctx context.Context, id uint, labels map[string]string) error {
+	if err := domain.ValidateLabels(labels); err != nil {
+		return err
+	}
+	if _, err := s.repository.Get(ctx, id); err != nil {
+		zap.L().Error("failed to get server for labels", zap.Uint("server_id", id), zap.Error(err))
+		return err
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return s.saveLabels(ctx, id, labels)
+}
+
+// ChangeLabels applies change to every matched server and returns their IDs.
+func (s *Service) ChangeLabels(ctx context.Context, change LabelChange) ([]uint, error) {
+	if change.Target.Empty() {
+		return nil, domain.ErrInvalidSelector
+	}
+	if err := domain.ValidateLabels(change.Set); err != nil {
+		return nil, err
+	}
+	servers, err := s.Select(ctx, change.Target)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(servers))
+	for _, server := range servers {
+		labels := make(map[string]string, len(server.Labels)+len(change.Set))
+		for key, value := range server.Labels {
+			labels[key] = value
+		}
+		for _, key := range change.Remove {
+			delete(labels, key)
+		}
+		for key, value := range change.Set {
+			labels[key] = value
+		}
+		if err := s.saveLabels(ctx, server.ID, labels); err != nil {
+			return ids, err
+		}
+		ids = append(ids, server.ID)
+	}
+	zap.L().Info("changed server labels",
+		zap.Uints("server_ids", ids),
+		zap.Any("set", change.Set),
+		zap.Strings("remove", change.Remove),
+	)
+	return ids, nil
+}
+
+// saveLabels leaves the stored labels untouched when labels is nil, so that
+// clients which do not know about labels keep them on update.
+func (s *Service) saveLabels(ctx context.Context, id uint, labels map[string]string) error {
+	if labels == nil {
+		return nil
+	}
+	if err := s.repository.SetLabels(ctx, id, labels); err != nil {
+		zap.L().Error("failed to save server labels", zap.Uint("server_id", id), zap.Error(err))
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	KeyTypeED25519 = "ed25519"
+	KeyTypeRSA     = "rsa"
+	KeyTypeECDSA   = "ecdsa"
+
+	defaultRSABits = 4096
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid ssh key")
+	ErrKeyInUse   = errors.New("ssh key is referenced by servers")
+)
+
+// Key is a key pair in the vault. Servers reference it by ID instead of
+// carrying their own copy of the private key.
+type Key struct {
+	ID          uint
+	Name        string
+	Type        string
+	Bits        int
+	Fingerprint string
+	PublicKey   string
+	PrivateKey  string
+	Comment     string
+	CreatedAt   time.Time
+	ServerIDs   []uint
+}
+
+// Cipher encrypts the private keys of the vault at rest.
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+type KeyRepository interface {
+	// ListKeys returns keys without their private halves.
+	ListKeys(context.Context) ([]Key, error)
+	GetKey(context.Context, uint) (Key, error)
+	AddKey(context.Context, *Key) error
+	DeleteKey(context.Context, uint) error
+	// UseKey points a server at a vault key and switches it to key
+	// authentication, dropping any password or inline key it had.
+	UseKey(ctx context.Context, serverID, keyID uint) error
+	ReleaseKey(ctx context.Context, serverID uint) error
+}
+
+// KeyInstaller talks to servers with their current credentials.
+type KeyInstaller interface {
+	// Install appends publicKey to the login user's authorized_keys unless it
+	// is already present.
+	Install(ctx context.Context, server Server, publicKey string) error
+	// Verify logs in with key to prove the installation worked.
+	Verify(ctx context.Context, server Server, key Key) error
+}
+
+// GenerateKey creates a new key pair. bits only applies to RSA keys and
+// defaults to 4096.
+func GenerateKey(keyType string, bits int, comment string) (Key, error) {
+	var private crypto.PrivateKey
+	switch keyType {
+	case KeyTypeED25519, "":
+		_, value, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return Key{}, err
+		}
+		private = value
+	case KeyTypeRSA:
+		if bits == 0 {
+			bits = defaultRSABits
+		}
+		if bits != 2048 && bits != 3072 && bits != 4096 {
+			return Key{}, fmt.Errorf("%w: rsa keys must be 2048, 3072 or 4096 bits", ErrInvalidKey)
+		}
+		value, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return Key{}, err
+		}
+		private = value
+	default:
+		return Key{}, fmt.Errorf("%w: unsupported key type %q", ErrInvalidKey, keyType)
+	}
+	return newKey(private, comment)
+}
+
+// ParseKey imports an existing PEM or OpenSSH private key. Encrypted keys are
+// decrypted with passphrase and stored without it, like the inline keys.
+func ParseKey(privateKey, passphrase, comment string) (Key, error) {
+	var (
+		private any
+		err     error
+	)
+	if passphrase != "" {
+		private, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	} else {
+		private, err = ssh.ParseRawPrivateKey([]byte(privateKey))
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if value, ok := private.(*ed25519.PrivateKey); ok {
+		private = *value
+	}
+	return newKey(private, comment)
+}
+
+func newKey(private crypto.PrivateKey, comment string) (Key, error) {
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, comment)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	key := Key{
+		Fingerprint: ssh.FingerprintSHA256(signer.PublicKey()),
+		PrivateKey:  string(pem.EncodeToMemory(block)),
+		Comment:     comment,
+	}
+	key.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if comment != "" {
+		key.PublicKey += " " + comment
+	}
+	switch value := private.(type) {
+	case ed25519.PrivateKey:
+		key.Type, key.Bits = KeyTypeED25519, 256
+	case *rsa.PrivateKey:
+		key.Type, key.Bits = KeyTypeRSA, value.N.BitLen()
+	case *ecdsa.PrivateKey:
+		key.Type, key.Bits = KeyTypeECDSA, value.Curve.Params().BitSize
+	default:
+		return Key{}, fmt.Errorf("%w: unsupported key type %T", ErrInvalidKey, private)
+	}
+	return key, nil
+}
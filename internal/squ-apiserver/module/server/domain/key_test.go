@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerateKey(t *testing.T) {
+	for _, test := range []struct {
+		keyType string
+		bits    int
+		want    string
+		wantLen int
+	}{
+		{keyType: "", want: KeyTypeED25519, wantLen: 256},
+		{keyType: KeyTypeRSA, bits: 2048, want: KeyTypeRSA, wantLen: 2048},
+	} {
+		key, err := GenerateKey(test.keyType, test.bits, "ops@example")
+		if err != nil {
+			t.Fatalf("GenerateKey(%q) error = %v", test.keyType, err)
+		}
+		if key.Type != test.want || key.Bits != test.wantLen {
+			t.Fatalf("key type = %s/%d, want %s/%d", key.Type, key.Bits, test.want, test.wantLen)
+		}
+		if !strings.HasSuffix(key.PublicKey, " ops@example") || !strings.HasPrefix(key.Fingerprint, "SHA256:") {
+			t.Fatalf("public key = %q, fingerprint = %q", key.PublicKey, key.Fingerprint)
+		}
+		signer, err := ssh.ParsePrivateKey([]byte(key.PrivateKey))
+		if err != nil {
+			t.Fatalf("private key does not parse: %v", err)
+		}
+		if ssh.FingerprintSHA256(signer.PublicKey()) != key.Fingerprint {
+			t.Fatal("private key does not match the fingerprint")
+		}
+	}
+	if _, err := GenerateKey(KeyTypeRSA, 1024, ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("GenerateKey(rsa 1024) error = %v", err)
+	}
+	if _, err := GenerateKey("dsa", 0, ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("GenerateKey(dsa) error = %v", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	generated, err := GenerateKey(KeyTypeED25519, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.ParseRawPrivateKey([]byte(generated.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(signer, "", []byte("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := string(pem.EncodeToMemory(block))
+
+	if _, err := ParseKey(encrypted, "", ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("ParseKey() without passphrase error = %v", err)
+	}
+	imported, err := ParseKey(encrypted, "hunter2", "imported")
+	if err != nil {
+		t.Fatalf("ParseKey() error = %v", err)
+	}
+	if imported.Fingerprint != generated.Fingerprint || imported.Type != KeyTypeED25519 {
+		t.Fatalf("imported = %s %s, want %s", imported.Type, imported.Fingerprint, generated.Fingerprint)
+	}
+	if _, err := ssh.ParsePrivateKey([]byte(imported.PrivateKey)); err != nil {
+		t.Fatalf("imported key is still encrypted: %v", err)
+	}
+	if _, err := ParseKey("not a key", "", ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("ParseKey(garbage) error = %v", err)
+	}
+}
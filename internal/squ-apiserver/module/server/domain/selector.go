@@ -0,0 +1,217 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidSelector = errors.New("invalid label selector")
+	ErrInvalidLabel    = errors.New("invalid label")
+	ErrInvalidGroup    = errors.New("invalid group name")
+
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?)?$`)
+)
+
+const (
+	operatorEquals    = "="
+	operatorNotEquals = "!="
+	operatorIn        = "in"
+	operatorNotIn     = "notin"
+	operatorExists    = "exists"
+	operatorNotExists = "!"
+)
+
+type requirement struct {
+	key      string
+	operator string
+	values   []string
+}
+
+// Selector filters servers by label, using the same syntax as Kubernetes
+// equality and set based selectors: "env=prod,role!=db,tier in (web,api),
+// canary,!legacy". All requirements must match.
+type Selector struct {
+	requirements []requirement
+}
+
+// Target names the servers an operation applies to. Explicit IDs, a group and
+// a selector may be combined; a server must satisfy every part that is set.
+type Target struct {
+	ServerIDs []uint
+	Group     string
+	Selector  string
+}
+
+func (t Target) Empty() bool {
+	return len(t.ServerIDs) == 0 && t.Group == "" && strings.TrimSpace(t.Selector) == ""
+}
+
+// ValidateLabels checks keys and values against the selector grammar so that
+// every stored label can be selected.
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: key %q", ErrInvalidLabel, key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("%w: value %q for key %q", ErrInvalidLabel, value, key)
+		}
+	}
+	return nil
+}
+
+// ValidateGroupName applies the label key rules to group names.
+func ValidateGroupName(name string) error {
+	if !labelKeyPattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidGroup, name)
+	}
+	return nil
+}
+
+func ParseSelector(value string) (Selector, error) {
+	var selector Selector
+	for _, part := range splitRequirements(value) {
+		requirement, err := parseRequirement(part)
+		if err != nil {
+			return Selector{}, err
+		}
+		selector.requirements = append(selector.requirements, requirement)
+	}
+	return selector, nil
+}
+
+func (s Selector) Empty() bool { return len(s.requirements) == 0 }
+
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range s.requirements {
+		value, ok := labels[requirement.key]
+		var matched bool
+		switch requirement.operator {
+		case operatorExists:
+			matched = ok
+		case operatorNotExists:
+			matched = !ok
+		case operatorEquals, operatorIn:
+			matched = ok && slices.Contains(requirement.values, value)
+		case operatorNotEquals, operatorNotIn:
+			matched = !ok || !slices.Contains(requirement.values, value)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectServers returns the servers matching target, in the order given.
+func SelectServers(servers []Server, target Target) ([]Server, error) {
+	selector, err := ParseSelector(target.Selector)
+	if err != nil {
+		return nil, err
+	}
+	var result []Server
+	for _, server := range servers {
+		if len(target.ServerIDs) > 0 && !slices.Contains(target.ServerIDs, server.ID) {
+			continue
+		}
+		if target.Group != "" && !slices.Contains(server.Groups, target.Group) {
+			continue
+		}
+		if !selector.Matches(server.Labels) {
+			continue
+		}
+		result = append(result, server)
+	}
+	return result, nil
+}
+
+// splitRequirements splits on commas that are not inside a set "(a,b)".
+func splitRequirements(value string) []string {
+	var (
+		result []string
+		depth  int
+		start  int
+	)
+	for index, char := range value {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, value[start:index])
+				start = index + 1
+			}
+		}
+	}
+	result = append(result, value[start:])
+	var parts []string
+	for _, part := range result {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func parseRequirement(value string) (requirement, error) {
+	invalid := fmt.Errorf("%w: %q", ErrInvalidSelector, value)
+	if key, ok := strings.CutPrefix(value, "!"); ok {
+		key = strings.TrimSpace(key)
+		if !labelKeyPattern.MatchString(key) {
+			return requirement{}, invalid
+		}
+		return requirement{key: key, operator: operatorNotExists}, nil
+	}
+	for _, operator := range []string{"!=", "==", "="} {
+		key, rawValue, ok := strings.Cut(value, operator)
+		if !ok {
+			continue
+		}
+		key, rawValue = strings.TrimSpace(key), strings.TrimSpace(rawValue)
+		if !labelKeyPattern.MatchString(key) || !labelValuePattern.MatchString(rawValue) {
+			return requirement{}, invalid
+		}
+		if operator == "!=" {
+			return requirement{key: key, operator: operatorNotEquals, values: []string{rawValue}}, nil
+		}
+		return requirement{key: key, operator: operatorEquals, values: []string{rawValue}}, nil
+	}
+	fields := strings.Fields(value)
+	if len(fields) == 1 {
+		if !labelKeyPattern.MatchString(fields[0]) {
+			return requirement{}, invalid
+		}
+		return requirement{key: fields[0], operator: operatorExists}, nil
+	}
+	key, rest, _ := strings.Cut(value, " ")
+	rest = strings.TrimSpace(rest)
+	operator, set, _ := strings.Cut(rest, " ")
+	if operator != operatorIn && operator != operatorNotIn {
+		// Also accept "key in(a,b)" without the space before the set.
+		if index := strings.Index(rest, "("); index > 0 {
+			operator, set = strings.TrimSpace(rest[:index]), rest[index:]
+		}
+	}
+	set = strings.TrimSpace(set)
+	if (operator != operatorIn && operator != operatorNotIn) ||
+		!labelKeyPattern.MatchString(key) ||
+		!strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+		return requirement{}, invalid
+	}
+	var values []string
+	for _, item := range strings.Split(set[1:len(set)-1], ",") {
+		item = strings.TrimSpace(item)
+		if !labelValuePattern.MatchString(item) {
+			return requirement{}, invalid
+		}
+		values = append(values, item)
+	}
+	return requirement{key: key, operator: operator, values: values}, nil
+}
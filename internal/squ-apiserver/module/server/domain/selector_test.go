@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "role": "web", "canary": ""}
+	cases := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"env=prod,role=web", true},
+		{"env==prod", true},
+		{"env=prod,role=db", false},
+		{"env!=staging", true},
+		{"missing!=x", true},
+		{"role in (web, api)", true},
+		{"role notin (web,api)", false},
+		{"tier in (a,b)", false},
+		{"canary", true},
+		{"!canary", false},
+		{"!legacy, env = prod", true},
+	}
+	for _, test := range cases {
+		selector, err := ParseSelector(test.selector)
+		if err != nil {
+			t.Fatalf("ParseSelector(%q): %v", test.selector, err)
+		}
+		if got := selector.Matches(labels); got != test.want {
+			t.Errorf("%q matches = %v, want %v", test.selector, got, test.want)
+		}
+	}
+}
+
+func TestParseSelectorRejectsInvalidInput(t *testing.T) {
+	for _, value := range []string{"env=pr od", "=prod", "role in web", "role has (a)", "-bad"} {
+		if _, err := ParseSelector(value); !errors.Is(err, ErrInvalidSelector) {
+			t.Errorf("ParseSelector(%q) error = %v", value, err)
+		}
+	}
+	if err := ValidateLabels(map[string]string{"env": "prod", "team/app": "a.b-c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLabels(map[string]string{"env": "two words"}); !errors.Is(err, ErrInvalidLabel) {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestSelectServersCombinesTargetParts(t *testing.T) {
+	servers := []Server{
+		{ID: 1, Labels: map[string]string{"env": "prod"}, Groups: []string{"web"}},
+		{ID: 2, Labels: map[string]string{"env": "prod"}},
+		{ID: 3, Labels: map[string]string{"env": "dev"}, Groups: []string{"web"}},
+	}
+	result, err := SelectServers(servers, Target{Group: "web", Selector: "env=prod"})
+	if err != nil || len(result) != 1 || result[0].ID != 1 {
+		t.Fatalf("result = %#v, %v", result, err)
+	}
+	result, err = SelectServers(servers, Target{ServerIDs: []uint{2, 3}, Selector: "env=prod"})
+	if err != nil || len(result) != 1 || result[0].ID != 2 {
+		t.Fatalf("result = %#v, %v", result, err)
+	}
+}
@@ -20,19 +20,44 @@ type Server struct {
 	SSHPassword   *string
 	SSHPrivateKey *string
 	SSHPassphrase *string
-	SSHPort       int
-	AuthType      string
-	ServerAlias   *string
-	Status        string
+	// SSHKeyID references a vault key; SSHPrivateKey then holds its private half.
+	SSHKeyID    *uint
+	SSHPort     int
+	AuthType    string
+	ServerAlias *string
+	Status      string
+	Labels      map[string]string
+	Groups      []string
+}
+
+// Group is a named set of servers that can be used as an operation target.
+type Group struct {
+	ID          uint
+	Name        string
+	Description string
+	ServerIDs   []uint
 }
 
 type Repository interface {
+	// List leaves out the private key of servers that log in with a vault
+	// key; Get decrypts it.
 	List(context.Context) ([]Server, error)
 	Get(context.Context, uint) (Server, error)
 	Delete(context.Context, uint) error
+	// Add stores the server and its labels in one transaction.
 	Add(context.Context, *Server) error
 	Update(context.Context, *Server) error
 	GetByUUID(context.Context, string) (Server, error)
+	SetLabels(context.Context, uint, map[string]string) error
+}
+
+type GroupRepository interface {
+	ListGroups(context.Context) ([]Group, error)
+	GetGroup(context.Context, uint) (Group, error)
+	AddGroup(context.Context, *Group) error
+	UpdateGroup(context.Context, *Group) error
+	DeleteGroup(context.Context, uint) error
+	SetGroupMembers(context.Context, uint, []uint) error
 }
 
 type AgentInfoClient interface {
@@ -0,0 +1,91 @@
+package infra
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+func (r *Repository) ListGroups(ctx context.Context) ([]domain.Group, error) {
+	var models []groupModel
+	if err := r.db.WithContext(ctx).Order("name").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var members []groupMemberModel
+	if err := r.db.WithContext(ctx).Order("server_id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	serverIDs := make(map[uint][]uint)
+	for _, member := range members {
+		serverIDs[member.GroupID] = append(serverIDs[member.GroupID], member.ServerID)
+	}
+	var result []domain.Group
+	for _, model := range models {
+		group := toGroupDomain(model)
+		group.ServerIDs = serverIDs[model.ID]
+		result = append(result, group)
+	}
+	return result, nil
+}
+
+func (r *Repository) GetGroup(ctx context.Context, id uint) (domain.Group, error) {
+	var model groupModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		return domain.Group{}, err
+	}
+	group := toGroupDomain(model)
+	err := r.db.WithContext(ctx).Model(&groupMemberModel{}).
+		Where("group_id = ?", id).Order("server_id").
+		Pluck("server_id", &group.ServerIDs).Error
+	if err != nil {
+		return domain.Group{}, err
+	}
+	return group, nil
+}
+
+func (r *Repository) AddGroup(ctx context.Context, value *domain.Group) error {
+	model := groupModel{Name: value.Name, Description: value.Description}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	value.ID = model.ID
+	return nil
+}
+
+func (r *Repository) UpdateGroup(ctx context.Context, value *domain.Group) error {
+	return r.db.WithContext(ctx).Model(&groupModel{ID: value.ID}).
+		Select("name", "description").
+		Updates(groupModel{Name: value.Name, Description: value.Description}).Error
+}
+
+func (r *Repository) DeleteGroup(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&groupMemberModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&groupModel{}, id).Error
+	})
+}
+
+// SetGroupMembers replaces the member list of a group.
+func (r *Repository) SetGroupMembers(ctx context.Context, id uint, serverIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&groupMemberModel{}).Error; err != nil {
+			return err
+		}
+		if len(serverIDs) == 0 {
+			return nil
+		}
+		models := make([]groupMemberModel, 0, len(serverIDs))
+		for _, serverID := range serverIDs {
+			models = append(models, groupMemberModel{GroupID: id, ServerID: serverID})
+		}
+		return tx.Create(&models).Error
+	})
+}
+
+func toGroupDomain(model groupModel) domain.Group {
+	return domain.Group{ID: model.ID, Name: model.Name, Description: model.Description}
+}
@@ -0,0 +1,99 @@
+package infra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+func TestLabelsAndGroupsAreAttachedToServers(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateGroups(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateKeys(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repository := NewRepository(db, cipherStub{})
+	web := domain.Server{UUID: "web", Hostname: "web-1", IPAddress: "192.0.2.10"}
+	if err := repository.Add(ctx, &web); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.SetLabels(ctx, web.ID, map[string]string{"env": "prod", "role": "web"}); err != nil {
+		t.Fatal(err)
+	}
+	group := domain.Group{Name: "frontend"}
+	if err := repository.AddGroup(ctx, &group); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.SetGroupMembers(ctx, group.ID, []uint{web.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	servers, err := repository.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected, err := domain.SelectServers(servers, domain.Target{Group: "frontend", Selector: "env=prod,role=web"})
+	if err != nil || len(selected) != 1 || selected[0].ID != web.ID {
+		t.Fatalf("selected = %#v, %v", selected, err)
+	}
+	stored, err := repository.GetGroup(ctx, group.ID)
+	if err != nil || len(stored.ServerIDs) != 1 || stored.ServerIDs[0] != web.ID {
+		t.Fatalf("group = %#v, %v", stored, err)
+	}
+
+	if err := repository.Delete(ctx, web.ID); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = repository.GetGroup(ctx, group.ID)
+	if err != nil || len(stored.ServerIDs) != 0 {
+		t.Fatalf("deleted server still in group: %#v, %v", stored, err)
+	}
+}
+
+func TestAddStoresServerWithItsLabels(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateGroups(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	repository := NewRepository(db, cipherStub{})
+	web := domain.Server{UUID: "web", Hostname: "web-1", IPAddress: "192.0.2.10", Labels: map[string]string{"env": "prod"}}
+	if err := repository.Add(ctx, &web); err != nil {
+		t.Fatal(err)
+	}
+	var labels []labelModel
+	if err := db.Where("server_id = ?", web.ID).Find(&labels).Error; err != nil || len(labels) != 1 || labels[0].Value != "prod" {
+		t.Fatalf("labels = %#v, %v", labels, err)
+	}
+
+	if err := db.Migrator().DropTable(&labelModel{}); err != nil {
+		t.Fatal(err)
+	}
+	db2 := domain.Server{UUID: "db", Hostname: "db-1", IPAddress: "192.0.2.11", Labels: map[string]string{"env": "prod"}}
+	if err := repository.Add(ctx, &db2); err == nil {
+		t.Fatal("Add() succeeded without a labels table")
+	}
+	var count int64
+	if err := db.Model(&serverModel{}).Where("hostname = ?", "db-1").Count(&count).Error; err != nil || count != 0 {
+		t.Fatalf("server rows = %d, %v", count, err)
+	}
+}
@@ -0,0 +1,147 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+func (r *Repository) ListKeys(ctx context.Context) ([]domain.Key, error) {
+	var models []keyModel
+	if err := r.db.WithContext(ctx).Order("name").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var links []serverKeyModel
+	if err := r.db.WithContext(ctx).Order("server_id").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	serverIDs := make(map[uint][]uint)
+	for _, link := range links {
+		serverIDs[link.KeyID] = append(serverIDs[link.KeyID], link.ServerID)
+	}
+	var result []domain.Key
+	for _, model := range models {
+		key := toKeyDomain(model)
+		key.ServerIDs = serverIDs[model.ID]
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+func (r *Repository) GetKey(ctx context.Context, id uint) (domain.Key, error) {
+	var model keyModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		return domain.Key{}, err
+	}
+	key := toKeyDomain(model)
+	privateKey, err := r.cipher.Decrypt(model.PrivateKey)
+	if err != nil {
+		return domain.Key{}, fmt.Errorf("ssh key %q: %w", model.Name, err)
+	}
+	key.PrivateKey = privateKey
+	err = r.db.WithContext(ctx).Model(&serverKeyModel{}).
+		Where("key_id = ?", id).Order("server_id").
+		Pluck("server_id", &key.ServerIDs).Error
+	return key, err
+}
+
+func (r *Repository) AddKey(ctx context.Context, value *domain.Key) error {
+	privateKey, err := r.cipher.Encrypt(value.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("encrypt ssh key %q: %w", value.Name, err)
+	}
+	model := keyModel{
+		Name: value.Name, Type: value.Type, Bits: value.Bits, Fingerprint: value.Fingerprint,
+		PublicKey: value.PublicKey, PrivateKey: privateKey, Comment: value.Comment,
+	}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	value.ID, value.CreatedAt = model.ID, model.CreatedAt
+	return nil
+}
+
+// DeleteKey refuses to remove a key that servers still log in with.
+func (r *Repository) DeleteKey(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&serverKeyModel{}).Where("key_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return domain.ErrKeyInUse
+		}
+		result := tx.Delete(&keyModel{}, id)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return result.Error
+	})
+}
+
+func (r *Repository) UseKey(ctx context.Context, serverID, keyID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("server_id = ?", serverID).Delete(&serverKeyModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&serverKeyModel{ServerID: serverID, KeyID: keyID}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&serverModel{}).Where("id = ?", serverID).Updates(map[string]any{
+			"auth_type":          domain.AuthTypeKey,
+			"ssh_password":       nil,
+			"ssh_private_key":    nil,
+			"ssh_key_passphrase": nil,
+		}).Error
+	})
+}
+
+// ReleaseKey removes the vault key reference of a server, if any.
+func (r *Repository) ReleaseKey(ctx context.Context, serverID uint) error {
+	return r.db.WithContext(ctx).Where("server_id = ?", serverID).Delete(&serverKeyModel{}).Error
+}
+
+// attachKeys resolves vault references so that SSH clients built from the
+// server log in with the vault key. Without decrypt only the reference is
+// set.
+func (r *Repository) attachKeys(ctx context.Context, servers []domain.Server, index map[uint]int, ids []uint, decrypt bool) error {
+	var links []struct {
+		ServerID   uint
+		KeyID      uint
+		PrivateKey string
+	}
+	err := r.db.WithContext(ctx).
+		Table("server_ssh_keys").
+		Select("server_ssh_keys.server_id, server_ssh_keys.key_id, ssh_keys.private_key").
+		Joins("JOIN ssh_keys ON ssh_keys.id = server_ssh_keys.key_id").
+		Where("server_ssh_keys.server_id IN ?", ids).
+		Scan(&links).Error
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		server := &servers[index[link.ServerID]]
+		keyID := link.KeyID
+		server.SSHKeyID, server.SSHPrivateKey, server.AuthType = &keyID, nil, domain.AuthTypeKey
+		if !decrypt {
+			continue
+		}
+		privateKey, err := r.cipher.Decrypt(link.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("ssh key of server %d: %w", link.ServerID, err)
+		}
+		server.SSHPrivateKey = &privateKey
+	}
+	return nil
+}
+
+func toKeyDomain(value keyModel) domain.Key {
+	return domain.Key{
+		ID: value.ID, Name: value.Name, Type: value.Type, Bits: value.Bits,
+		Fingerprint: value.Fingerprint, PublicKey: value.PublicKey, Comment: value.Comment,
+		CreatedAt: value.CreatedAt,
+	}
+}
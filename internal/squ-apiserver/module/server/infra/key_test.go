@@ -0,0 +1,96 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+// cipherStub prefixes private keys and refuses values without the prefix.
+type cipherStub struct{}
+
+func (cipherStub) Encrypt(plaintext string) (string, error) { return "enc:" + plaintext, nil }
+func (cipherStub) Decrypt(ciphertext string) (string, error) {
+	value, ok := strings.CutPrefix(ciphertext, "enc:")
+	if !ok {
+		return "", errors.New("malformed ciphertext")
+	}
+	return value, nil
+}
+
+func TestServersUseVaultKeys(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, migrate := range []func(*gorm.DB) error{Migrate, MigrateGroups, MigrateKeys} {
+		if err := migrate(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	repository := NewRepository(db, cipherStub{})
+	password := "secret"
+	web := domain.Server{UUID: "web", Hostname: "web-1", IPAddress: "192.0.2.10", AuthType: domain.AuthTypePassword, SSHPassword: &password}
+	if err := repository.Add(ctx, &web); err != nil {
+		t.Fatal(err)
+	}
+	key := domain.Key{Name: "deploy", Type: domain.KeyTypeED25519, Fingerprint: "SHA256:x", PublicKey: "ssh-ed25519 AAAA", PrivateKey: "PRIVATE"}
+	if err := repository.AddKey(ctx, &key); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.UseKey(ctx, web.ID, key.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var model keyModel
+	if err := db.First(&model, key.ID).Error; err != nil || model.PrivateKey != "enc:PRIVATE" {
+		t.Fatalf("stored private key = %q, %v", model.PrivateKey, err)
+	}
+	if keys, err := repository.ListKeys(ctx); err != nil || len(keys) != 1 || keys[0].PrivateKey != "" {
+		t.Fatalf("ListKeys() = %#v, %v", keys, err)
+	}
+	servers, err := repository.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, server := range servers {
+		if server.ID == web.ID && (server.SSHKeyID == nil || server.SSHPrivateKey != nil) {
+			t.Fatalf("List() server = %#v", server)
+		}
+	}
+
+	stored, err := repository.Get(ctx, web.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.SSHKeyID == nil || *stored.SSHKeyID != key.ID || stored.AuthType != domain.AuthTypeKey {
+		t.Fatalf("server key = %v, auth = %s", stored.SSHKeyID, stored.AuthType)
+	}
+	if stored.SSHPrivateKey == nil || *stored.SSHPrivateKey != "PRIVATE" || stored.SSHPassword != nil {
+		t.Fatalf("server credentials were not replaced by the vault key: %#v", stored)
+	}
+	if err := repository.DeleteKey(ctx, key.ID); !errors.Is(err, domain.ErrKeyInUse) {
+		t.Fatalf("DeleteKey() error = %v, want %v", err, domain.ErrKeyInUse)
+	}
+	listed, err := repository.GetKey(ctx, key.ID)
+	if err != nil || len(listed.ServerIDs) != 1 || listed.ServerIDs[0] != web.ID || listed.PrivateKey != "PRIVATE" {
+		t.Fatalf("key = %#v, %v", listed, err)
+	}
+
+	if err := repository.Delete(ctx, web.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.DeleteKey(ctx, key.ID); err != nil {
+		t.Fatalf("DeleteKey() after the server was removed error = %v", err)
+	}
+	if err := repository.DeleteKey(ctx, key.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("DeleteKey() of a missing key error = %v", err)
+	}
+}
@@ -19,3 +19,21 @@ func Migrate(db *gorm.DB) error {
 }
 
 func Rollback(db *gorm.DB) error { return db.Migrator().DropTable("servers") }
+
+// MigrateGroups adds the label and group tables used for server selection.
+func MigrateGroups(db *gorm.DB) error {
+	return db.AutoMigrate(&labelModel{}, &groupModel{}, &groupMemberModel{})
+}
+
+func RollbackGroups(db *gorm.DB) error {
+	return db.Migrator().DropTable("server_group_members", "server_groups", "server_labels")
+}
+
+// MigrateKeys adds the SSH key vault and the server to key links.
+func MigrateKeys(db *gorm.DB) error {
+	return db.AutoMigrate(&keyModel{}, &serverKeyModel{})
+}
+
+func RollbackKeys(db *gorm.DB) error {
+	return db.Migrator().DropTable("server_ssh_keys", "ssh_keys")
+}
@@ -26,3 +26,53 @@ type serverModel struct {
 }
 
 func (serverModel) TableName() string { return "servers" }
+
+type labelModel struct {
+	ID       uint   `gorm:"primarykey"`
+	ServerID uint   `gorm:"column:server_id;not null;uniqueIndex:idx_server_label;comment:服务器ID"`
+	Key      string `gorm:"column:label_key;type:varchar(63);not null;uniqueIndex:idx_server_label;index:idx_label_key_value;comment:标签键"`
+	Value    string `gorm:"column:label_value;type:varchar(63);not null;index:idx_label_key_value;comment:标签值"`
+}
+
+func (labelModel) TableName() string { return "server_labels" }
+
+type groupModel struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string `gorm:"column:name;type:varchar(63);not null;unique;comment:分组名称"`
+	Description string `gorm:"column:description;type:varchar(255);comment:分组描述"`
+}
+
+func (groupModel) TableName() string { return "server_groups" }
+
+type groupMemberModel struct {
+	GroupID  uint `gorm:"column:group_id;primaryKey;comment:分组ID"`
+	ServerID uint `gorm:"column:server_id;primaryKey;index;comment:服务器ID"`
+}
+
+func (groupMemberModel) TableName() string { return "server_group_members" }
+
+type keyModel struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string `gorm:"column:name;type:varchar(100);not null;unique;comment:密钥名称"`
+	Type        string `gorm:"column:key_type;type:varchar(20);not null;comment:密钥类型"`
+	Bits        int    `gorm:"column:bits;comment:密钥长度"`
+	Fingerprint string `gorm:"column:fingerprint;type:varchar(100);not null;index;comment:公钥指纹"`
+	PublicKey   string `gorm:"column:public_key;type:text;not null;comment:公钥"`
+	PrivateKey  string `gorm:"column:private_key;type:text;not null;comment:私钥（加密存储）"`
+	Comment     string `gorm:"column:comment;type:varchar(255);comment:密钥注释"`
+}
+
+func (keyModel) TableName() string { return "ssh_keys" }
+
+// serverKeyModel links a server to the vault key it logs in with. It is kept
+// apart from servers so that the legacy table stays unchanged.
+type serverKeyModel struct {
+	ServerID uint `gorm:"column:server_id;primaryKey;comment:服务器ID"`
+	KeyID    uint `gorm:"column:key_id;not null;index;comment:密钥ID"`
+}
+
+func (serverKeyModel) TableName() string { return "server_ssh_keys" }
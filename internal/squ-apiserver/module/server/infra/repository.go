@@ -8,9 +8,16 @@ import (
 	"squirrel-dev/internal/squ-apiserver/module/server/domain"
 )
 
-type Repository struct{ db *gorm.DB }
+// Repository stores servers and the key vault. Vault private keys are
+// encrypted by cipher and only decrypted by Get and GetKey.
+type Repository struct {
+	db     *gorm.DB
+	cipher domain.Cipher
+}
 
-func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+func NewRepository(db *gorm.DB, cipher domain.Cipher) *Repository {
+	return &Repository{db: db, cipher: cipher}
+}
 
 func (r *Repository) List(ctx context.Context) ([]domain.Server, error) {
 	var models []serverModel
@@ -21,24 +28,67 @@ func (r *Repository) List(ctx context.Context) ([]domain.Server, error) {
 	for _, model := range models {
 		result = append(result, toDomain(model))
 	}
+	if err := r.attach(ctx, result, false); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// Select lists the servers of repository that match target. Modules that
+// act on several servers resolve their targets through it.
+func Select(ctx context.Context, repository domain.Repository, target domain.Target) ([]domain.Server, error) {
+	servers, err := repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.SelectServers(servers, target)
+}
+
 func (r *Repository) Get(ctx context.Context, id uint) (domain.Server, error) {
 	var model serverModel
 	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
 		return domain.Server{}, err
 	}
-	return toDomain(model), nil
+	result := []domain.Server{toDomain(model)}
+	if err := r.attach(ctx, result, true); err != nil {
+		return domain.Server{}, err
+	}
+	return result[0], nil
 }
 
 func (r *Repository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&serverModel{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("server_id = ?", id).Delete(&labelModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("server_id = ?", id).Delete(&groupMemberModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("server_id = ?", id).Delete(&serverKeyModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&serverModel{}, id).Error
+	})
 }
 
+// Add inserts the server together with its labels, so that a server is
+// never stored without the labels it was added with.
 func (r *Repository) Add(ctx context.Context, value *domain.Server) error {
 	model := toModel(*value)
-	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model).Error; err != nil {
+			return err
+		}
+		if len(value.Labels) == 0 {
+			return nil
+		}
+		labels := make([]labelModel, 0, len(value.Labels))
+		for key, label := range value.Labels {
+			labels = append(labels, labelModel{ServerID: model.ID, Key: key, Value: label})
+		}
+		return tx.Create(&labels).Error
+	})
+	if err != nil {
 		return err
 	}
 	value.ID = model.ID
@@ -57,6 +107,66 @@ func (r *Repository) GetByUUID(ctx context.Context, uuid string) (domain.Server,
 	return toDomain(model), nil
 }
 
+// SetLabels replaces every label of a server.
+func (r *Repository) SetLabels(ctx context.Context, id uint, labels map[string]string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("server_id = ?", id).Delete(&labelModel{}).Error; err != nil {
+			return err
+		}
+		if len(labels) == 0 {
+			return nil
+		}
+		models := make([]labelModel, 0, len(labels))
+		for key, value := range labels {
+			models = append(models, labelModel{ServerID: id, Key: key, Value: value})
+		}
+		return tx.Create(&models).Error
+	})
+}
+
+// attach loads labels, group names and vault keys for the given servers.
+func (r *Repository) attach(ctx context.Context, servers []domain.Server, decryptKeys bool) error {
+	if len(servers) == 0 {
+		return nil
+	}
+	index := make(map[uint]int, len(servers))
+	ids := make([]uint, 0, len(servers))
+	for i, server := range servers {
+		index[server.ID] = i
+		ids = append(ids, server.ID)
+	}
+	var labels []labelModel
+	if err := r.db.WithContext(ctx).Where("server_id IN ?", ids).Find(&labels).Error; err != nil {
+		return err
+	}
+	for _, label := range labels {
+		server := &servers[index[label.ServerID]]
+		if server.Labels == nil {
+			server.Labels = make(map[string]string)
+		}
+		server.Labels[label.Key] = label.Value
+	}
+	var members []struct {
+		ServerID uint
+		Name     string
+	}
+	err := r.db.WithContext(ctx).
+		Table("server_group_members").
+		Select("server_group_members.server_id, server_groups.name").
+		Joins("JOIN server_groups ON server_groups.id = server_group_members.group_id").
+		Where("server_group_members.server_id IN ?", ids).
+		Order("server_groups.name").
+		Scan(&members).Error
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		server := &servers[index[member.ServerID]]
+		server.Groups = append(server.Groups, member.Name)
+	}
+	return r.attachKeys(ctx, servers, index, ids, decryptKeys)
+}
+
 func toModel(value domain.Server) serverModel {
 	return serverModel{
 		ID: value.ID, UUID: value.UUID, Hostname: value.Hostname, IPAddress: value.IPAddress,
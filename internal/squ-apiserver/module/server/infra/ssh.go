@@ -1,12 +1,22 @@
 package infra
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"strings"
 
 	"squirrel-dev/internal/squ-apiserver/module/server/domain"
 	sshClient "squirrel-dev/pkg/ssh"
 )
 
+// installKeyScript reads the public key from stdin so that it never needs
+// shell quoting, and matches existing entries on the key blob alone so that a
+// different comment does not produce a duplicate line.
+const installKeyScript = `umask 077 && mkdir -p ~/.ssh && touch ~/.ssh/authorized_keys && ` +
+	`key=$(cat) && blob=$(printf '%s\n' "$key" | cut -d' ' -f2) && ` +
+	`{ grep -qF "$blob" ~/.ssh/authorized_keys || printf '%s\n' "$key" >> ~/.ssh/authorized_keys; }`
+
 type SSHTester struct{}
 
 func NewSSHTester() *SSHTester { return &SSHTester{} }
@@ -20,10 +30,58 @@ func (SSHTester) Test(_ context.Context, server domain.Server) error {
 	return nil
 }
 
+type KeyInstaller struct{}
+
+func NewKeyInstaller() *KeyInstaller { return &KeyInstaller{} }
+
+func (KeyInstaller) Install(ctx context.Context, server domain.Server, publicKey string) error {
+	client, err := NewSSHClient(server)
+	if err != nil {
+		return fmt.Errorf("ssh connect failed: %w", err)
+	}
+	defer client.Close()
+	session, err := client.Client.NewSession()
+	if err != nil {
+		return fmt.Errorf("ssh session failed: %w", err)
+	}
+	defer session.Close()
+	var output bytes.Buffer
+	session.Stdin = strings.NewReader(publicKey + "\n")
+	session.Stdout, session.Stderr = &output, &output
+	done := make(chan error, 1)
+	go func() { done <- session.Run(installKeyScript) }()
+	select {
+	case <-ctx.Done():
+		client.Close()
+		return ctx.Err()
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("install authorized key failed: %w: %s", err, strings.TrimSpace(output.String()))
+		}
+		return nil
+	}
+}
+
+func (KeyInstaller) Verify(_ context.Context, server domain.Server, key domain.Key) error {
+	server.AuthType, server.SSHPrivateKey, server.SSHPassphrase = domain.AuthTypeKey, &key.PrivateKey, nil
+	client, err := sshClient.NewSsh(machine(server, server.Hostname))
+	if err != nil {
+		return fmt.Errorf("login with the installed key failed: %w", err)
+	}
+	client.Close()
+	return nil
+}
+
 func NewSSHClient(server domain.Server) (*sshClient.Client, error) {
 	return sshClient.NewSsh(machine(server, "test"))
 }
 
+// NewSSHClientContext is NewSSHClient with the connection bounded by ctx
+// instead of the fixed dial timeout.
+func NewSSHClientContext(ctx context.Context, server domain.Server) (*sshClient.Client, error) {
+	return sshClient.NewSshContext(ctx, machine(server, "test"))
+}
+
 func machine(server domain.Server, name string) *sshClient.Machine {
 	password := ""
 	if server.SSHPassword != nil {
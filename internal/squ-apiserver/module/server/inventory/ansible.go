@@ -0,0 +1,448 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+// Variables outside the ansible_ namespace that map to server fields. Other
+// plain variables become labels when they are valid label pairs.
+const (
+	varAgentPort = "squirrel_agent_port"
+	varAlias     = "squirrel_alias"
+)
+
+type ansibleHost struct {
+	line   int
+	vars   map[string]string
+	groups []string
+}
+
+type ansibleGroup struct {
+	vars     map[string]string
+	children []string
+}
+
+// ansibleInventory is the parsed form shared by the INI and YAML readers.
+type ansibleInventory struct {
+	hosts  map[string]*ansibleHost
+	order  []string
+	groups map[string]*ansibleGroup
+}
+
+func newAnsibleInventory() *ansibleInventory {
+	return &ansibleInventory{hosts: map[string]*ansibleHost{}, groups: map[string]*ansibleGroup{}}
+}
+
+func (inventory *ansibleInventory) group(name string) *ansibleGroup {
+	group, ok := inventory.groups[name]
+	if !ok {
+		group = &ansibleGroup{vars: map[string]string{}}
+		inventory.groups[name] = group
+	}
+	return group
+}
+
+func (inventory *ansibleInventory) addHost(name, group string, line int, vars map[string]string) {
+	host, ok := inventory.hosts[name]
+	if !ok {
+		host = &ansibleHost{line: line, vars: map[string]string{}}
+		inventory.hosts[name] = host
+		inventory.order = append(inventory.order, name)
+	}
+	for key, value := range vars {
+		host.vars[key] = value
+	}
+	if group != "" && group != "all" && group != "ungrouped" {
+		host.groups = append(host.groups, group)
+		inventory.group(group)
+	}
+}
+
+// records resolves group membership and variable precedence: "all" first,
+// then ancestors before descendants, then the host's own variables.
+func (inventory *ansibleInventory) records() []Record {
+	parents := make(map[string][]string)
+	for name, group := range inventory.groups {
+		for _, child := range group.children {
+			parents[child] = append(parents[child], name)
+		}
+	}
+	var records []Record
+	for _, name := range inventory.order {
+		host := inventory.hosts[name]
+		var ordered []string
+		seen := map[string]bool{}
+		queue := append([]string(nil), host.groups...)
+		for len(queue) > 0 {
+			group := queue[0]
+			queue = queue[1:]
+			if seen[group] {
+				continue
+			}
+			seen[group] = true
+			ordered = append(ordered, group)
+			queue = append(queue, parents[group]...)
+		}
+		vars := map[string]string{}
+		if all, ok := inventory.groups["all"]; ok {
+			for key, value := range all.vars {
+				vars[key] = value
+			}
+		}
+		for i := len(ordered) - 1; i >= 0; i-- {
+			for key, value := range inventory.groups[ordered[i]].vars {
+				vars[key] = value
+			}
+		}
+		for key, value := range host.vars {
+			vars[key] = value
+		}
+		var groups []string
+		for _, group := range ordered {
+			if group != "all" && group != "ungrouped" {
+				groups = append(groups, group)
+			}
+		}
+		records = append(records, ansibleRecord(name, host.line, vars, groups))
+	}
+	return records
+}
+
+func ansibleRecord(name string, line int, vars map[string]string, groups []string) Record {
+	record := Record{Row: line, Entry: Entry{Hostname: name, IPAddress: name}}
+	entry := &record.Entry
+	port := func(key, value string) int {
+		result, err := strconv.Atoi(value)
+		if err != nil && record.Err == nil {
+			record.Err = fmt.Errorf("%s %q is not a number", key, value)
+		}
+		return result
+	}
+	for _, key := range sortedKeys(vars) {
+		value := vars[key]
+		switch key {
+		case "ansible_host", "ansible_ssh_host":
+			entry.IPAddress = value
+		case "ansible_user", "ansible_ssh_user":
+			entry.SSHUsername = value
+		case "ansible_port", "ansible_ssh_port":
+			entry.SSHPort = port(key, value)
+		case "ansible_password", "ansible_ssh_pass":
+			entry.SSHPassword = value
+			entry.AuthType = domain.AuthTypePassword
+		case "ansible_ssh_private_key_file", "ansible_private_key_file":
+			if entry.AuthType == "" {
+				entry.AuthType = domain.AuthTypeKey
+			}
+			record.Warnings = append(record.Warnings, fmt.Sprintf("%s %q was not imported; attach the key to the server", key, value))
+		case varAgentPort:
+			entry.AgentPort = port(key, value)
+		case varAlias:
+			entry.ServerAlias = value
+		default:
+			if strings.HasPrefix(key, "ansible_") {
+				continue
+			}
+			if err := domain.ValidateLabels(map[string]string{key: value}); err != nil {
+				record.Warnings = append(record.Warnings, fmt.Sprintf("variable %q is not a valid label and was skipped", key))
+				continue
+			}
+			if entry.Labels == nil {
+				entry.Labels = map[string]string{}
+			}
+			entry.Labels[key] = value
+		}
+	}
+	for _, group := range groups {
+		if err := domain.ValidateGroupName(group); err != nil {
+			record.Warnings = append(record.Warnings, fmt.Sprintf("group %q is not a valid group name and was skipped", group))
+			continue
+		}
+		entry.Groups = append(entry.Groups, group)
+	}
+	return record
+}
+
+func decodeAnsibleINI(reader io.Reader) ([]Record, error) {
+	inventory := newAnsibleInventory()
+	var invalid []Record
+	scanner := bufio.NewScanner(reader)
+	section, kind := "ungrouped", "hosts"
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: malformed section %q", line, text)
+			}
+			section, kind = strings.TrimSpace(text[1:len(text)-1]), "hosts"
+			if name, suffix, ok := strings.Cut(section, ":"); ok {
+				section, kind = name, suffix
+			}
+			if kind != "hosts" && kind != "vars" && kind != "children" {
+				return nil, fmt.Errorf("line %d: unknown section type %q", line, kind)
+			}
+			inventory.group(section)
+			continue
+		}
+		fields, err := splitINIFields(text)
+		if err != nil {
+			invalid = append(invalid, Record{Row: line, Err: err})
+			continue
+		}
+		switch kind {
+		case "vars":
+			key, value, ok := strings.Cut(text, "=")
+			if !ok {
+				invalid = append(invalid, Record{Row: line, Err: fmt.Errorf("variable %q is not key=value", text)})
+				continue
+			}
+			inventory.group(section).vars[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+		case "children":
+			group := inventory.group(section)
+			group.children = append(group.children, fields[0])
+			inventory.group(fields[0])
+		default:
+			if strings.ContainsAny(fields[0], "[]") {
+				invalid = append(invalid, Record{Row: line, Entry: Entry{Hostname: fields[0]}, Err: fmt.Errorf("host ranges are not supported")})
+				continue
+			}
+			vars := make(map[string]string, len(fields)-1)
+			for _, field := range fields[1:] {
+				key, value, ok := strings.Cut(field, "=")
+				if !ok {
+					continue
+				}
+				vars[key] = value
+			}
+			inventory.addHost(fields[0], section, line, vars)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return append(inventory.records(), invalid...), nil
+}
+
+// splitINIFields splits on whitespace while keeping quoted values together.
+func splitINIFields(text string) ([]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		quote   rune
+	)
+	for _, char := range text {
+		switch {
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(char)
+		case char == '"' || char == '\'':
+			quote = char
+		case char == ' ' || char == '\t':
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		case char == '#' && current.Len() == 0:
+			return fields, nil
+		default:
+			current.WriteRune(char)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", text)
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// decodeAnsibleYAML walks the YAML inventory tree rooted at "all" (or at the
+// top level groups when "all" is missing).
+func decodeAnsibleYAML(reader io.Reader) ([]Record, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("parse yaml: %w", err)
+	}
+	if len(root.Content) != 1 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse yaml: inventory must be a mapping of groups")
+	}
+	inventory := newAnsibleInventory()
+	top := root.Content[0]
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if err := walkAnsibleGroup(inventory, top.Content[i].Value, top.Content[i+1]); err != nil {
+			return nil, err
+		}
+	}
+	return inventory.records(), nil
+}
+
+func walkAnsibleGroup(inventory *ansibleInventory, name string, node *yaml.Node) error {
+	group := inventory.group(name)
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		switch key {
+		case "vars":
+			vars, err := scalarMap(value)
+			if err != nil {
+				return fmt.Errorf("line %d: group %q vars: %w", value.Line, name, err)
+			}
+			for k, v := range vars {
+				group.vars[k] = v
+			}
+		case "hosts":
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				hostNode, varsNode := value.Content[j], value.Content[j+1]
+				vars, err := scalarMap(varsNode)
+				if err != nil {
+					return fmt.Errorf("line %d: host %q vars: %w", varsNode.Line, hostNode.Value, err)
+				}
+				inventory.addHost(hostNode.Value, name, hostNode.Line, vars)
+			}
+		case "children":
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				child := value.Content[j].Value
+				group.children = append(group.children, child)
+				if err := walkAnsibleGroup(inventory, child, value.Content[j+1]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func scalarMap(node *yaml.Node) (map[string]string, error) {
+	result := map[string]string{}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return result, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping")
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		value := node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			continue
+		}
+		result[node.Content[i].Value] = value.Value
+	}
+	return result, nil
+}
+
+// ansibleVars is the inverse of ansibleRecord.
+func ansibleVars(entry Entry) map[string]string {
+	vars := map[string]string{}
+	for key, value := range entry.Labels {
+		vars[key] = value
+	}
+	if entry.IPAddress != "" && entry.IPAddress != entry.Hostname {
+		vars["ansible_host"] = entry.IPAddress
+	}
+	if entry.SSHUsername != "" {
+		vars["ansible_user"] = entry.SSHUsername
+	}
+	if entry.SSHPort != 0 {
+		vars["ansible_port"] = strconv.Itoa(entry.SSHPort)
+	}
+	if entry.SSHPassword != "" {
+		vars["ansible_password"] = entry.SSHPassword
+	}
+	if entry.AgentPort != 0 {
+		vars[varAgentPort] = strconv.Itoa(entry.AgentPort)
+	}
+	if entry.ServerAlias != "" {
+		vars[varAlias] = entry.ServerAlias
+	}
+	return vars
+}
+
+func entryGroups(entries []Entry) (map[string][]string, []string) {
+	members := map[string][]string{}
+	for _, entry := range entries {
+		for _, group := range entry.Groups {
+			members[group] = append(members[group], entry.Hostname)
+		}
+	}
+	return members, sortedKeys(members)
+}
+
+func encodeAnsibleINI(writer io.Writer, entries []Entry) error {
+	buffer := bufio.NewWriter(writer)
+	for _, entry := range entries {
+		buffer.WriteString(entry.Hostname)
+		vars := ansibleVars(entry)
+		for _, key := range sortedKeys(vars) {
+			value := vars[key]
+			if strings.ContainsAny(value, " \t#'\"") {
+				value = strconv.Quote(value)
+			}
+			fmt.Fprintf(buffer, " %s=%s", key, value)
+		}
+		buffer.WriteString("\n")
+	}
+	members, groups := entryGroups(entries)
+	for _, group := range groups {
+		fmt.Fprintf(buffer, "\n[%s]\n", group)
+		for _, host := range members[group] {
+			buffer.WriteString(host + "\n")
+		}
+	}
+	return buffer.Flush()
+}
+
+func encodeAnsibleYAML(writer io.Writer, entries []Entry) error {
+	hosts := map[string]map[string]string{}
+	for _, entry := range entries {
+		hosts[entry.Hostname] = ansibleVars(entry)
+	}
+	all := map[string]any{"hosts": hosts}
+	members, groups := entryGroups(entries)
+	if len(groups) > 0 {
+		children := map[string]any{}
+		for _, group := range groups {
+			groupHosts := map[string]any{}
+			for _, host := range members[group] {
+				groupHosts[host] = nil
+			}
+			children[group] = map[string]any{"hosts": groupHosts}
+		}
+		all["children"] = children
+	}
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string]any{"all": all}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,124 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var csvColumns = []string{
+	"hostname", "ip_address", "agent_port", "ssh_username", "ssh_port", "auth_type",
+	"ssh_password", "ssh_private_key", "server_alias", "labels", "groups",
+}
+
+// decodeCSV expects a header row naming the columns. Unknown columns are
+// rejected so that a typo does not silently drop data.
+func decodeCSV(reader io.Reader) ([]Record, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		known := false
+		for _, column := range csvColumns {
+			known = known || column == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown csv column %q", name)
+		}
+		index[name] = i
+	}
+	var records []Record
+	for {
+		fields, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		line, _ := csvReader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				records = append(records, Record{Row: parseErr.Line, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		records = append(records, csvRecord(line, index, fields))
+	}
+}
+
+func csvRecord(line int, index map[string]int, fields []string) Record {
+	record := Record{Row: line}
+	cell := func(name string) string {
+		if i, ok := index[name]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+	number := func(name string) int {
+		value := cell(name)
+		if value == "" || record.Err != nil {
+			return 0
+		}
+		result, err := strconv.Atoi(value)
+		if err != nil {
+			record.Err = fmt.Errorf("%s %q is not a number", name, value)
+		}
+		return result
+	}
+	labels, err := parseLabels(cell("labels"))
+	if err != nil {
+		record.Err = err
+	}
+	record.Entry = Entry{
+		Hostname:      cell("hostname"),
+		IPAddress:     cell("ip_address"),
+		AgentPort:     number("agent_port"),
+		SSHUsername:   cell("ssh_username"),
+		SSHPort:       number("ssh_port"),
+		AuthType:      cell("auth_type"),
+		SSHPassword:   cell("ssh_password"),
+		SSHPrivateKey: cell("ssh_private_key"),
+		ServerAlias:   cell("server_alias"),
+		Labels:        labels,
+		Groups:        splitList(cell("groups")),
+	}
+	return record
+}
+
+func encodeCSV(writer io.Writer, entries []Entry) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		row := []string{
+			entry.Hostname, entry.IPAddress, formatPort(entry.AgentPort), entry.SSHUsername,
+			formatPort(entry.SSHPort), entry.AuthType, entry.SSHPassword, entry.SSHPrivateKey,
+			entry.ServerAlias, formatLabels(entry.Labels), strings.Join(entry.Groups, ";"),
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func formatPort(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
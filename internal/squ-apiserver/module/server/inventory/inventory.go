@@ -0,0 +1,158 @@
+// Package inventory converts server lists to and from the inventory formats
+// operators already keep: CSV, YAML, Ansible INI/YAML and OpenSSH config.
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	FormatCSV         = "csv"
+	FormatYAML        = "yaml"
+	FormatAnsibleINI  = "ansible-ini"
+	FormatAnsibleYAML = "ansible-yaml"
+	FormatSSHConfig   = "ssh-config"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported inventory format")
+
+// Entry is one server in an inventory file. Secrets are only filled when the
+// source format carries them and are only written when export asks for them.
+type Entry struct {
+	Hostname      string            `yaml:"hostname"`
+	IPAddress     string            `yaml:"ip_address"`
+	AgentPort     int               `yaml:"agent_port,omitempty"`
+	SSHUsername   string            `yaml:"ssh_username,omitempty"`
+	SSHPort       int               `yaml:"ssh_port,omitempty"`
+	AuthType      string            `yaml:"auth_type,omitempty"`
+	SSHPassword   string            `yaml:"ssh_password,omitempty"`
+	SSHPrivateKey string            `yaml:"ssh_private_key,omitempty"`
+	ServerAlias   string            `yaml:"server_alias,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+	Groups        []string          `yaml:"groups,omitempty"`
+}
+
+// Record is one decoded row. Row is the line or item number in the source so
+// that errors can be reported against it; Warnings do not block the import.
+type Record struct {
+	Row      int
+	Entry    Entry
+	Warnings []string
+	Err      error
+}
+
+func Formats() []string {
+	return []string{FormatCSV, FormatYAML, FormatAnsibleINI, FormatAnsibleYAML, FormatSSHConfig}
+}
+
+// Decode parses an inventory. A malformed file fails as a whole; problems in
+// individual rows are reported on their records.
+func Decode(format string, reader io.Reader) ([]Record, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(reader)
+	case FormatYAML:
+		return decodeYAML(reader)
+	case FormatAnsibleINI:
+		return decodeAnsibleINI(reader)
+	case FormatAnsibleYAML:
+		return decodeAnsibleYAML(reader)
+	case FormatSSHConfig:
+		return decodeSSHConfig(reader)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+func Encode(format string, writer io.Writer, entries []Entry) error {
+	switch format {
+	case FormatCSV:
+		return encodeCSV(writer, entries)
+	case FormatYAML:
+		return encodeYAML(writer, entries)
+	case FormatAnsibleINI:
+		return encodeAnsibleINI(writer, entries)
+	case FormatAnsibleYAML:
+		return encodeAnsibleYAML(writer, entries)
+	case FormatSSHConfig:
+		return encodeSSHConfig(writer, entries)
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// ContentType returns the MIME type used when serving an export.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatYAML, FormatAnsibleYAML:
+		return "application/yaml; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// FileName returns the default download name for an export.
+func FileName(format string) string {
+	switch format {
+	case FormatCSV:
+		return "servers.csv"
+	case FormatYAML:
+		return "servers.yaml"
+	case FormatAnsibleINI:
+		return "inventory.ini"
+	case FormatAnsibleYAML:
+		return "inventory.yaml"
+	}
+	return "ssh_config"
+}
+
+// parseLabels reads "key=value;key=value" as used in CSV cells.
+func parseLabels(value string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, label, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("label %q is not key=value", pair)
+		}
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(label)
+	}
+	return labels, nil
+}
+
+func formatLabels(labels map[string]string) string {
+	keys := sortedKeys(labels)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ";")
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
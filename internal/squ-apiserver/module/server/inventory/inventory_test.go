@@ -0,0 +1,196 @@
+package inventory
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeCSV(t *testing.T) {
+	input := "hostname,ip_address,ssh_port,labels,groups\n" +
+		"web-1,10.0.0.1,2222,env=prod;tier=web,web;prod\n" +
+		"web-2,10.0.0.2,abc,,\n"
+	records, err := Decode(FormatCSV, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2", len(records))
+	}
+	first := records[0]
+	if first.Err != nil || first.Row != 2 || first.Entry.SSHPort != 2222 {
+		t.Fatalf("first record = %+v", first)
+	}
+	if !reflect.DeepEqual(first.Entry.Labels, map[string]string{"env": "prod", "tier": "web"}) {
+		t.Fatalf("labels = %v", first.Entry.Labels)
+	}
+	if !reflect.DeepEqual(first.Entry.Groups, []string{"web", "prod"}) {
+		t.Fatalf("groups = %v", first.Entry.Groups)
+	}
+	if records[1].Err == nil || records[1].Row != 3 {
+		t.Fatalf("second record = %+v, want a row error on row 3", records[1])
+	}
+
+	if _, err := Decode(FormatCSV, strings.NewReader("hostname,password\n")); err == nil {
+		t.Fatal("Decode() with an unknown column error = nil")
+	}
+}
+
+func TestDecodeAnsibleINI(t *testing.T) {
+	input := `
+db-1 ansible_host=10.0.0.9
+
+[web]
+web-1 ansible_host=10.0.0.1 env=prod
+web-2 ansible_host=10.0.0.2 ansible_port=2222 "bad key"=x
+web[03:04]
+
+[web:vars]
+ansible_user=deploy
+ansible_ssh_private_key_file=~/.ssh/id_ed25519
+
+[prod:children]
+web
+
+[prod:vars]
+ansible_user=root
+region=eu
+`
+	records, err := Decode(FormatAnsibleINI, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("records = %d, want 4: %+v", len(records), records)
+	}
+	db := records[0].Entry
+	if db.Hostname != "db-1" || db.IPAddress != "10.0.0.9" || len(db.Groups) != 0 {
+		t.Fatalf("db entry = %+v", db)
+	}
+	web := records[1]
+	if web.Entry.SSHUsername != "deploy" {
+		t.Fatalf("ssh username = %q, want the child group var to win", web.Entry.SSHUsername)
+	}
+	if !reflect.DeepEqual(web.Entry.Groups, []string{"web", "prod"}) {
+		t.Fatalf("groups = %v", web.Entry.Groups)
+	}
+	if !reflect.DeepEqual(web.Entry.Labels, map[string]string{"env": "prod", "region": "eu"}) {
+		t.Fatalf("labels = %v", web.Entry.Labels)
+	}
+	if web.Entry.AuthType != "privatekey" || len(web.Warnings) != 1 {
+		t.Fatalf("key file handling = %q %v", web.Entry.AuthType, web.Warnings)
+	}
+	if records[2].Entry.SSHPort != 2222 {
+		t.Fatalf("ssh port = %d", records[2].Entry.SSHPort)
+	}
+	if records[3].Err == nil || records[3].Row != 7 {
+		t.Fatalf("host range record = %+v", records[3])
+	}
+}
+
+func TestDecodeAnsibleYAML(t *testing.T) {
+	input := `
+all:
+  vars:
+    ansible_user: ops
+  hosts:
+    bastion:
+      ansible_host: 10.0.0.5
+  children:
+    web:
+      vars:
+        env: prod
+      hosts:
+        web-1:
+          ansible_host: 10.0.0.1
+          ansible_port: 2200
+`
+	records, err := Decode(FormatAnsibleYAML, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2", len(records))
+	}
+	web := records[1]
+	if web.Row != 13 || web.Entry.SSHUsername != "ops" || web.Entry.SSHPort != 2200 || web.Entry.Labels["env"] != "prod" {
+		t.Fatalf("web record = %+v", web)
+	}
+	if !reflect.DeepEqual(web.Entry.Groups, []string{"web"}) {
+		t.Fatalf("groups = %v", web.Entry.Groups)
+	}
+}
+
+func TestDecodeSSHConfig(t *testing.T) {
+	input := `
+Host web-1 web1
+    HostName 10.0.0.1
+    Port=2222
+
+Host *.internal
+    User nobody
+
+Host db-1
+    HostName 10.0.0.9
+    User postgres
+    IdentityFile ~/.ssh/db
+
+Host *
+    User admin
+`
+	records, err := Decode(FormatSSHConfig, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2", len(records))
+	}
+	web, db := records[0], records[1]
+	if web.Entry.Hostname != "web-1" || web.Entry.IPAddress != "10.0.0.1" || web.Entry.SSHPort != 2222 || web.Entry.SSHUsername != "admin" {
+		t.Fatalf("web record = %+v", web)
+	}
+	if db.Entry.SSHUsername != "postgres" || db.Entry.AuthType != "privatekey" || len(db.Warnings) != 1 {
+		t.Fatalf("db record = %+v", db)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Hostname: "web-1", IPAddress: "10.0.0.1", SSHUsername: "root", SSHPort: 22, AgentPort: 10750, Labels: map[string]string{"env": "prod"}, Groups: []string{"web"}},
+		{Hostname: "db-1", IPAddress: "10.0.0.9", SSHUsername: "root", SSHPort: 2222, ServerAlias: "primary db"},
+	}
+	for _, format := range Formats() {
+		t.Run(format, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := Encode(format, &buffer, entries); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			records, err := Decode(format, &buffer)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if len(records) != len(entries) {
+				t.Fatalf("records = %d, want %d", len(records), len(entries))
+			}
+			for i, record := range records {
+				if record.Err != nil {
+					t.Fatalf("record %d error = %v", i, record.Err)
+				}
+				want := entries[i]
+				if format == FormatSSHConfig {
+					want = Entry{Hostname: want.Hostname, IPAddress: want.IPAddress, SSHUsername: want.SSHUsername, SSHPort: want.SSHPort}
+				}
+				if !reflect.DeepEqual(record.Entry, want) {
+					t.Fatalf("record %d = %+v, want %+v", i, record.Entry, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeUnsupportedFormat(t *testing.T) {
+	if _, err := Decode("xml", strings.NewReader("")); err == nil {
+		t.Fatal("Decode() error = nil")
+	}
+}
@@ -0,0 +1,128 @@
+package inventory
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"squirrel-dev/internal/squ-apiserver/module/server/domain"
+)
+
+type sshHost struct {
+	line    int
+	name    string
+	options map[string]string
+}
+
+// decodeSSHConfig reads "Host" blocks from an OpenSSH client config. Blocks
+// with wildcard patterns are not servers; "Host *" supplies defaults for the
+// options a concrete block leaves unset, as ssh itself would.
+func decodeSSHConfig(reader io.Reader) ([]Record, error) {
+	var (
+		hosts    []*sshHost
+		defaults = map[string]string{}
+		current  *sshHost
+		skipping bool
+	)
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value := splitSSHOption(text)
+		switch key {
+		case "host":
+			patterns := strings.Fields(value)
+			current, skipping = nil, false
+			if len(patterns) == 0 {
+				return nil, fmt.Errorf("line %d: Host without a pattern", line)
+			}
+			if value == "*" {
+				current = &sshHost{options: defaults}
+				continue
+			}
+			if strings.ContainsAny(value, "*?!") {
+				skipping = true
+				continue
+			}
+			current = &sshHost{line: line, name: patterns[0], options: map[string]string{}}
+			hosts = append(hosts, current)
+		case "match":
+			current, skipping = nil, true
+		default:
+			if skipping || current == nil {
+				continue
+			}
+			// The first value obtained for an option wins.
+			if _, ok := current.options[key]; !ok {
+				current.options[key] = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(hosts))
+	for _, host := range hosts {
+		for key, value := range defaults {
+			if _, ok := host.options[key]; !ok {
+				host.options[key] = value
+			}
+		}
+		records = append(records, sshRecord(host))
+	}
+	return records, nil
+}
+
+func sshRecord(host *sshHost) Record {
+	record := Record{Row: host.line, Entry: Entry{Hostname: host.name, IPAddress: host.name}}
+	if value, ok := host.options["hostname"]; ok {
+		record.Entry.IPAddress = value
+	}
+	record.Entry.SSHUsername = host.options["user"]
+	if value, ok := host.options["port"]; ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			record.Err = fmt.Errorf("port %q is not a number", value)
+		}
+		record.Entry.SSHPort = port
+	}
+	if value, ok := host.options["identityfile"]; ok {
+		record.Entry.AuthType = domain.AuthTypeKey
+		record.Warnings = append(record.Warnings, fmt.Sprintf("IdentityFile %q was not imported; attach the key to the server", value))
+	}
+	return record
+}
+
+// splitSSHOption accepts both "Key value" and "Key=value".
+func splitSSHOption(text string) (string, string) {
+	index := strings.IndexAny(text, " \t=")
+	if index < 0 {
+		return strings.ToLower(text), ""
+	}
+	key := strings.ToLower(text[:index])
+	value := strings.TrimSpace(text[index:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	return key, unquote(value)
+}
+
+func encodeSSHConfig(writer io.Writer, entries []Entry) error {
+	buffer := bufio.NewWriter(writer)
+	for i, entry := range entries {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		fmt.Fprintf(buffer, "Host %s\n", entry.Hostname)
+		fmt.Fprintf(buffer, "    HostName %s\n", entry.IPAddress)
+		if entry.SSHUsername != "" {
+			fmt.Fprintf(buffer, "    User %s\n", entry.SSHUsername)
+		}
+		if entry.SSHPort != 0 {
+			fmt.Fprintf(buffer, "    Port %d\n", entry.SSHPort)
+		}
+	}
+	return buffer.Flush()
+}
@@ -0,0 +1,64 @@
+package inventory
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlDocument struct {
+	Servers []yaml.Node `yaml:"servers"`
+}
+
+// decodeYAML accepts either a top level list of servers or a mapping with a
+// "servers" list. Items are decoded one by one so a bad item only fails its
+// own record.
+func decodeYAML(reader io.Reader) ([]Record, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("parse yaml: %w", err)
+	}
+	var items []yaml.Node
+	switch {
+	case len(root.Content) == 1 && root.Content[0].Kind == yaml.SequenceNode:
+		if err := root.Content[0].Decode(&items); err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+	default:
+		var document yamlDocument
+		if err := root.Decode(&document); err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+		items = document.Servers
+	}
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		record := Record{Row: item.Line}
+		if err := item.Decode(&record.Entry); err != nil {
+			record.Err = err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func encodeYAML(writer io.Writer, entries []Entry) error {
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	if entries == nil {
+		entries = []Entry{}
+	}
+	if err := encoder.Encode(map[string][]Entry{"servers": entries}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
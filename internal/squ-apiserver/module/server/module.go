@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 
 	"squirrel-dev/internal/squ-apiserver/config"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
 	"squirrel-dev/internal/squ-apiserver/module/server/api"
 	"squirrel-dev/internal/squ-apiserver/module/server/api/res"
 	"squirrel-dev/internal/squ-apiserver/module/server/application"
@@ -15,8 +16,10 @@ import (
 )
 
 func buildHandler(conf *config.Config, db *gorm.DB) *api.Handler {
+	repository := infra.NewRepository(db, secretInfra.NewCipher(conf))
 	service := application.NewService(
-		infra.NewRepository(db),
+		repository,
+		repository,
 		infra.NewAgentClient(conf, httpclient.NewClient(3*time.Second)),
 		infra.NewSSHTester(),
 	)
@@ -26,6 +29,10 @@ func buildHandler(conf *config.Config, db *gorm.DB) *api.Handler {
 func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
 	res.RegisterCode()
 	api.RegisterRoutes(group, buildHandler(conf, db))
+	repository := infra.NewRepository(db, secretInfra.NewCipher(conf))
+	api.RegisterGroupRoutes(group, api.NewGroupHandler(application.NewGroupService(repository, repository)))
+	api.RegisterInventoryRoutes(group, api.NewInventoryHandler(application.NewInventoryService(repository, repository)))
+	api.RegisterKeyRoutes(group, api.NewKeyHandler(application.NewKeyService(repository, repository, infra.NewKeyInstaller())))
 }
 
 // RegisterTerminalHTTP keeps the WebSocket route outside the HTTP JWT
@@ -38,3 +45,9 @@ func RegisterTerminalHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.
 
 func Migrate(db *gorm.DB) error  { return infra.Migrate(db) }
 func Rollback(db *gorm.DB) error { return infra.Rollback(db) }
+
+func MigrateGroups(db *gorm.DB) error  { return infra.MigrateGroups(db) }
+func RollbackGroups(db *gorm.DB) error { return infra.RollbackGroups(db) }
+
+func MigrateKeys(db *gorm.DB) error  { return infra.MigrateKeys(db) }
+func RollbackKeys(db *gorm.DB) error { return infra.RollbackKeys(db) }
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/application"
+	"squirrel-dev/pkg/utils"
+)
+
+func bindRequest[T any](c *gin.Context) (T, bool) {
+	var request T
+	if err := c.ShouldBindJSON(&request); err != nil {
+		zap.L().Warn("failed to bind sftp request", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return request, false
+	}
+	return request, true
+}
+
+func serverID(c *gin.Context) (uint, bool) {
+	rawID := c.Param("id")
+	id, err := utils.StringToUint(rawID)
+	if err != nil {
+		zap.L().Warn("failed to parse sftp server ID", zap.String("raw_server_id", rawID), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+func operator(c *gin.Context) (application.Operator, bool) {
+	id, ok := serverID(c)
+	if !ok {
+		return application.Operator{}, false
+	}
+	return application.Operator{ServerID: id, Username: c.GetString("username")}, true
+}
+
+func writeResult(c *gin.Context, data any, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(data))
+}
+
+func writeError(c *gin.Context, err error) {
+	code := res.ErrOperationFailed
+	switch {
+	case errors.Is(err, application.ErrServerNotFound):
+		code = res.ErrServerNotFound
+	case errors.Is(err, application.ErrAccessDenied):
+		code = res.ErrAccessDenied
+	case errors.Is(err, application.ErrInvalidPath):
+		code = res.ErrInvalidPath
+	case errors.Is(err, application.ErrInvalidOffset):
+		code = res.ErrInvalidOffset
+	case errors.Is(err, application.ErrInvalidPermission):
+		code = res.ErrInvalidPermission
+	case errors.Is(err, application.ErrFileNotFound):
+		code = res.ErrFileNotFound
+	case errors.Is(err, application.ErrRemotePermission):
+		code = res.ErrRemotePermission
+	case errors.Is(err, application.ErrConnect):
+		code = res.ErrConnectFailed
+	case errors.Is(err, application.ErrRecord):
+		code = res.ErrRecordFailed
+	}
+	c.JSON(http.StatusOK, response.Error(code))
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/application"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/domain"
+)
+
+type Handler struct {
+	service *application.Service
+}
+
+func NewHandler(service *application.Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	values, err := h.service.List(c.Request.Context(), operator, c.DefaultQuery("path", "/"))
+	result := make([]res.FileInfo, 0, len(values))
+	for _, value := range values {
+		result = append(result, toFileResponse(value))
+	}
+	writeResult(c, result, err)
+}
+
+func (h *Handler) Stat(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Stat(c.Request.Context(), operator, c.Query("path"))
+	writeResult(c, toFileResponse(value), err)
+}
+
+// Download streams a remote file. Range requests are honoured so that an
+// interrupted download can be resumed by the client.
+func (h *Handler) Download(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	err := h.service.Download(c.Request.Context(), operator, c.Query("path"),
+		func(info domain.FileInfo, file domain.File) (int64, error) {
+			writer := &countingWriter{ResponseWriter: c.Writer}
+			c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name}))
+			http.ServeContent(writer, c.Request, info.Name, info.ModTime, file)
+			return writer.written, writer.err
+		})
+	// A download that failed while streaming has already sent its headers.
+	if err != nil && !c.Writer.Written() {
+		writeError(c, err)
+	}
+}
+
+// Upload accepts either a raw request body or a multipart "file" field and
+// writes it at the offset query parameter, which defaults to zero.
+func (h *Handler) Upload(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil {
+		zap.L().Warn("failed to parse sftp upload offset", zap.String("raw_offset", c.Query("offset")), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidOffset))
+		return
+	}
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			zap.L().Warn("failed to read sftp upload form file", zap.Error(err))
+			c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			zap.L().Warn("failed to open sftp upload form file", zap.Error(err))
+			c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	transfer, err := h.service.Upload(c.Request.Context(), operator, c.Query("path"), offset, body)
+	writeResult(c, toTransferResponse(transfer), err)
+}
+
+func (h *Handler) Rename(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Rename](c)
+	if !ok {
+		return
+	}
+	err := h.service.Rename(c.Request.Context(), operator, request.From, request.To)
+	writeResult(c, "success", err)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Delete](c)
+	if !ok {
+		return
+	}
+	err := h.service.Delete(c.Request.Context(), operator, request.Path, request.Recursive)
+	writeResult(c, "success", err)
+}
+
+func (h *Handler) Mkdir(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Mkdir](c)
+	if !ok {
+		return
+	}
+	err := h.service.Mkdir(c.Request.Context(), operator, request.Path, request.Parents)
+	writeResult(c, "success", err)
+}
+
+func (h *Handler) Chmod(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Chmod](c)
+	if !ok {
+		return
+	}
+	mode, err := strconv.ParseUint(request.Mode, 8, 32)
+	if err != nil {
+		zap.L().Warn("failed to parse sftp file mode", zap.String("raw_mode", request.Mode), zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidPermission))
+		return
+	}
+	err = h.service.Chmod(c.Request.Context(), operator, request.Path, os.FileMode(mode))
+	writeResult(c, "success", err)
+}
+
+func (h *Handler) ListTransfers(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	values, err := h.service.ListTransfers(c.Request.Context(), operator)
+	var result []res.Transfer
+	for _, value := range values {
+		result = append(result, toTransferResponse(value))
+	}
+	writeResult(c, result, err)
+}
+
+func (h *Handler) ListAccess(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	values, err := h.service.ListAccess(c.Request.Context(), operator)
+	var result []res.Access
+	for _, value := range values {
+		result = append(result, toAccessResponse(value))
+	}
+	writeResult(c, result, err)
+}
+
+func (h *Handler) GrantAccess(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	request, ok := bindRequest[req.Access](c)
+	if !ok {
+		return
+	}
+	err := h.service.GrantAccess(c.Request.Context(), operator, request.Username, request.Permission)
+	writeResult(c, "success", err)
+}
+
+func (h *Handler) RevokeAccess(c *gin.Context) {
+	operator, ok := operator(c)
+	if !ok {
+		return
+	}
+	err := h.service.RevokeAccess(c.Request.Context(), operator, c.Param("username"))
+	writeResult(c, "success", err)
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	written int64
+	err     error
+}
+
+func (w *countingWriter) Write(value []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(value)
+	w.written += int64(n)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
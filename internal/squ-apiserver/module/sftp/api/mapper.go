@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+
+	"squirrel-dev/internal/squ-apiserver/module/sftp/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/domain"
+)
+
+func toFileResponse(value domain.FileInfo) res.FileInfo {
+	return res.FileInfo{
+		Name:    value.Name,
+		Path:    value.Path,
+		Size:    value.Size,
+		Mode:    fmt.Sprintf("%04o", value.Mode.Perm()),
+		ModTime: value.ModTime.Format("2006-01-02 15:04:05"),
+		IsDir:   value.IsDir,
+		IsLink:  value.IsLink,
+	}
+}
+
+func toTransferResponse(value domain.Transfer) res.Transfer {
+	return res.Transfer{
+		ID:           value.ID,
+		ServerID:     value.ServerID,
+		Username:     value.Username,
+		Direction:    value.Direction,
+		Path:         value.Path,
+		Offset:       value.Offset,
+		Size:         value.Size,
+		Status:       value.Status,
+		ErrorMessage: value.ErrorMessage,
+		CreatedAt:    value.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func toAccessResponse(value domain.Access) res.Access {
+	return res.Access{
+		ServerID:   value.ServerID,
+		Username:   value.Username,
+		Permission: value.Permission,
+	}
+}
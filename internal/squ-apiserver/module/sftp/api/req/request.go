@@ -0,0 +1,31 @@
+package req
+
+// Rename moves a remote file or directory.
+type Rename struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// Delete removes a remote path. Recursive is required for non-empty directories.
+type Delete struct {
+	Path      string `json:"path" binding:"required"`
+	Recursive bool   `json:"recursive"`
+}
+
+// Mkdir creates a remote directory, optionally with its missing parents.
+type Mkdir struct {
+	Path    string `json:"path" binding:"required"`
+	Parents bool   `json:"parents"`
+}
+
+// Chmod changes remote permission bits. Mode is an octal string such as "0644".
+type Chmod struct {
+	Path string `json:"path" binding:"required"`
+	Mode string `json:"mode" binding:"required"`
+}
+
+// Access grants a user read or write access to a server's files.
+type Access struct {
+	Username   string `json:"username" binding:"required"`
+	Permission string `json:"permission" binding:"required"`
+}
@@ -0,0 +1,33 @@
+package res
+
+// FileInfo describes a remote file or directory.
+type FileInfo struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Mode    string `json:"mode"`
+	ModTime string `json:"mod_time"`
+	IsDir   bool   `json:"is_dir"`
+	IsLink  bool   `json:"is_link"`
+}
+
+// Transfer describes a recorded upload or download.
+type Transfer struct {
+	ID           uint   `json:"id"`
+	ServerID     uint   `json:"server_id"`
+	Username     string `json:"username"`
+	Direction    string `json:"direction"`
+	Path         string `json:"path"`
+	Offset       int64  `json:"offset"`
+	Size         int64  `json:"size"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// Access describes a user's permission on a server.
+type Access struct {
+	ServerID   uint   `json:"server_id"`
+	Username   string `json:"username"`
+	Permission string `json:"permission"`
+}
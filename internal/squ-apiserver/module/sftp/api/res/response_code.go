@@ -0,0 +1,33 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrServerNotFound    = 61001
+	ErrAccessDenied      = 61002
+	ErrInvalidParameter  = 61003
+	ErrInvalidPath       = 61004
+	ErrInvalidOffset     = 61005
+	ErrInvalidPermission = 61006
+	ErrFileNotFound      = 61007
+	ErrRemotePermission  = 61008
+
+	ErrConnectFailed   = 61021
+	ErrOperationFailed = 61022
+	ErrRecordFailed    = 61023
+)
+
+func RegisterCode() {
+	response.Register(ErrServerNotFound, "server not found")
+	response.Register(ErrAccessDenied, "sftp access denied")
+	response.Register(ErrInvalidParameter, "invalid parameter")
+	response.Register(ErrInvalidPath, "invalid remote path")
+	response.Register(ErrInvalidOffset, "invalid upload offset")
+	response.Register(ErrInvalidPermission, "invalid sftp permission")
+	response.Register(ErrFileNotFound, "remote file not found")
+	response.Register(ErrRemotePermission, "remote permission denied")
+
+	response.Register(ErrConnectFailed, "sftp connection failed")
+	response.Register(ErrOperationFailed, "sftp operation failed")
+	response.Register(ErrRecordFailed, "sftp record failed")
+}
@@ -0,0 +1,18 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/sftp/:id/list", handler.List)
+	group.GET("/sftp/:id/stat", handler.Stat)
+	group.GET("/sftp/:id/download", handler.Download)
+	group.POST("/sftp/:id/upload", handler.Upload)
+	group.POST("/sftp/:id/rename", handler.Rename)
+	group.POST("/sftp/:id/delete", handler.Delete)
+	group.POST("/sftp/:id/mkdir", handler.Mkdir)
+	group.POST("/sftp/:id/chmod", handler.Chmod)
+	group.GET("/sftp/:id/transfers", handler.ListTransfers)
+	group.GET("/sftp/:id/access", handler.ListAccess)
+	group.POST("/sftp/:id/access", handler.GrantAccess)
+	group.DELETE("/sftp/:id/access/:username", handler.RevokeAccess)
+}
@@ -0,0 +1,39 @@
+package application
+
+import (
+	"errors"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrServerNotFound    = errors.New("server not found")
+	ErrAccessDenied      = errors.New("sftp access denied")
+	ErrInvalidPath       = errors.New("invalid remote path")
+	ErrInvalidOffset     = errors.New("invalid upload offset")
+	ErrInvalidPermission = errors.New("invalid sftp permission")
+	ErrFileNotFound      = errors.New("remote file not found")
+	ErrRemotePermission  = errors.New("remote permission denied")
+	ErrConnect           = errors.New("sftp connection failed")
+	ErrOperation         = errors.New("sftp operation failed")
+	ErrRecord            = errors.New("sftp record failed")
+)
+
+func dialError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrServerNotFound
+	}
+	return ErrConnect
+}
+
+func remoteError(err error) error {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return ErrFileNotFound
+	case errors.Is(err, os.ErrPermission):
+		return ErrRemotePermission
+	default:
+		return ErrOperation
+	}
+}
@@ -0,0 +1,344 @@
+package application
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/squ-apiserver/module/sftp/domain"
+)
+
+// Operator identifies who performs an SFTP operation on which server.
+type Operator struct {
+	ServerID uint
+	Username string
+}
+
+type Service struct {
+	repository domain.Repository
+	dialer     domain.Dialer
+	admins     []string
+}
+
+// NewService creates the SFTP service. admins may manage the access list of
+// every server, including creating the first entry of one.
+func NewService(repository domain.Repository, dialer domain.Dialer, admins []string) *Service {
+	return &Service{repository: repository, dialer: dialer, admins: admins}
+}
+
+func (s *Service) List(ctx context.Context, operator Operator, dir string) ([]domain.FileInfo, error) {
+	var result []domain.FileInfo
+	err := s.run(ctx, operator, domain.PermissionRead, "list", dir, func(session domain.Session, name string) error {
+		values, err := session.ReadDir(name)
+		result = values
+		return err
+	})
+	return result, err
+}
+
+func (s *Service) Stat(ctx context.Context, operator Operator, name string) (domain.FileInfo, error) {
+	var result domain.FileInfo
+	err := s.run(ctx, operator, domain.PermissionRead, "stat", name, func(session domain.Session, name string) error {
+		value, err := session.Stat(name)
+		result = value
+		return err
+	})
+	return result, err
+}
+
+// Download opens name and hands it to write, which streams it to the client
+// and reports how many bytes it sent. The transfer is recorded afterwards.
+func (s *Service) Download(
+	ctx context.Context,
+	operator Operator,
+	name string,
+	write func(domain.FileInfo, domain.File) (int64, error),
+) error {
+	return s.run(ctx, operator, domain.PermissionRead, "download", name, func(session domain.Session, name string) error {
+		info, err := session.Stat(name)
+		if err != nil {
+			return err
+		}
+		if info.IsDir {
+			return os.ErrInvalid
+		}
+		file, err := session.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		written, err := write(info, file)
+		s.recordTransfer(ctx, operator, domain.DirectionDownload, name, 0, written, err)
+		return err
+	})
+}
+
+// Upload writes body to name starting at offset. Large files are sent as a
+// sequence of chunks; an interrupted upload resumes from the size reported by
+// Stat. Every chunk is recorded as its own transfer.
+func (s *Service) Upload(ctx context.Context, operator Operator, name string, offset int64, body io.Reader) (domain.Transfer, error) {
+	if offset < 0 {
+		return domain.Transfer{}, ErrInvalidOffset
+	}
+	var transfer domain.Transfer
+	err := s.run(ctx, operator, domain.PermissionWrite, "upload", name, func(session domain.Session, name string) error {
+		if offset > 0 {
+			info, err := session.Stat(name)
+			if err != nil {
+				return err
+			}
+			if offset > info.Size {
+				return ErrInvalidOffset
+			}
+		}
+		writer, err := session.OpenWriter(name, offset)
+		if err != nil {
+			return err
+		}
+		written, err := io.Copy(writer, body)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		transfer = s.recordTransfer(ctx, operator, domain.DirectionUpload, name, offset, written, err)
+		return err
+	})
+	return transfer, err
+}
+
+func (s *Service) Rename(ctx context.Context, operator Operator, from, to string) error {
+	target, ok := cleanPath(to)
+	if !ok {
+		return ErrInvalidPath
+	}
+	return s.run(ctx, operator, domain.PermissionWrite, "rename", from, func(session domain.Session, name string) error {
+		return session.Rename(name, target)
+	})
+}
+
+func (s *Service) Delete(ctx context.Context, operator Operator, name string, recursive bool) error {
+	return s.run(ctx, operator, domain.PermissionWrite, "delete", name, func(session domain.Session, name string) error {
+		if name == "/" {
+			return ErrInvalidPath
+		}
+		return session.Remove(name, recursive)
+	})
+}
+
+func (s *Service) Mkdir(ctx context.Context, operator Operator, name string, parents bool) error {
+	return s.run(ctx, operator, domain.PermissionWrite, "mkdir", name, func(session domain.Session, name string) error {
+		return session.Mkdir(name, parents)
+	})
+}
+
+func (s *Service) Chmod(ctx context.Context, operator Operator, name string, mode os.FileMode) error {
+	if mode&^os.ModePerm != 0 {
+		return ErrInvalidPermission
+	}
+	return s.run(ctx, operator, domain.PermissionWrite, "chmod", name, func(session domain.Session, name string) error {
+		return session.Chmod(name, mode)
+	})
+}
+
+func (s *Service) ListTransfers(ctx context.Context, operator Operator) ([]domain.Transfer, error) {
+	if err := s.authorize(ctx, operator, domain.PermissionRead); err != nil {
+		return nil, err
+	}
+	values, err := s.repository.ListTransfers(ctx, operator.ServerID)
+	if err != nil {
+		zap.L().Error("failed to list sftp transfers", zap.Uint("server_id", operator.ServerID), zap.Error(err))
+		return nil, ErrRecord
+	}
+	return values, nil
+}
+
+func (s *Service) ListAccess(ctx context.Context, operator Operator) ([]domain.Access, error) {
+	if err := s.authorize(ctx, operator, domain.PermissionRead); err != nil {
+		return nil, err
+	}
+	values, err := s.repository.ListAccess(ctx, operator.ServerID)
+	if err != nil {
+		zap.L().Error("failed to list sftp access", zap.Uint("server_id", operator.ServerID), zap.Error(err))
+		return nil, ErrRecord
+	}
+	return values, nil
+}
+
+// GrantAccess gives username read or write access to the server of
+// operator. Managing access takes write access or an admin; only an admin
+// may create the first entry of a server.
+func (s *Service) GrantAccess(ctx context.Context, operator Operator, username, permission string) error {
+	if username == "" {
+		return ErrInvalidPermission
+	}
+	if permission != domain.PermissionRead && permission != domain.PermissionWrite {
+		return ErrInvalidPermission
+	}
+	if err := s.authorizeManage(ctx, operator); err != nil {
+		return err
+	}
+	value := domain.Access{ServerID: operator.ServerID, Username: username, Permission: permission}
+	if err := s.repository.SaveAccess(ctx, &value); err != nil {
+		zap.L().Error("failed to save sftp access",
+			zap.Uint("server_id", value.ServerID),
+			zap.String("username", value.Username),
+			zap.Error(err),
+		)
+		return ErrRecord
+	}
+	zap.L().Info("sftp access granted",
+		zap.Uint("server_id", value.ServerID),
+		zap.String("username", value.Username),
+		zap.String("permission", value.Permission),
+		zap.String("operator", operator.Username),
+	)
+	return nil
+}
+
+func (s *Service) RevokeAccess(ctx context.Context, operator Operator, username string) error {
+	if err := s.authorizeManage(ctx, operator); err != nil {
+		return err
+	}
+	if err := s.repository.DeleteAccess(ctx, operator.ServerID, username); err != nil {
+		zap.L().Error("failed to delete sftp access",
+			zap.Uint("server_id", operator.ServerID),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return ErrRecord
+	}
+	zap.L().Info("sftp access revoked",
+		zap.Uint("server_id", operator.ServerID),
+		zap.String("username", username),
+		zap.String("operator", operator.Username),
+	)
+	return nil
+}
+
+func (s *Service) run(
+	ctx context.Context,
+	operator Operator,
+	permission, operation, rawPath string,
+	fn func(domain.Session, string) error,
+) error {
+	name, ok := cleanPath(rawPath)
+	if !ok {
+		return ErrInvalidPath
+	}
+	if err := s.authorize(ctx, operator, permission); err != nil {
+		return err
+	}
+	session, err := s.dialer.Dial(ctx, operator.ServerID)
+	if err != nil {
+		zap.L().Error("failed to open sftp session",
+			zap.Uint("server_id", operator.ServerID),
+			zap.String("username", operator.Username),
+			zap.Error(err),
+		)
+		return dialError(err)
+	}
+	defer session.Close()
+	if err := fn(session, name); err != nil {
+		zap.L().Error("sftp operation failed",
+			zap.Uint("server_id", operator.ServerID),
+			zap.String("username", operator.Username),
+			zap.String("operation", operation),
+			zap.String("path", name),
+			zap.Error(err),
+		)
+		switch err {
+		case ErrInvalidPath, ErrInvalidOffset:
+			return err
+		}
+		return remoteError(err)
+	}
+	return nil
+}
+
+// authorize keeps servers without access entries open to every
+// authenticated user. Once entries exist, only listed users may connect and
+// write access implies read access.
+func (s *Service) authorize(ctx context.Context, operator Operator, permission string) error {
+	entries, err := s.repository.ListAccess(ctx, operator.ServerID)
+	if err != nil {
+		zap.L().Error("failed to load sftp access", zap.Uint("server_id", operator.ServerID), zap.Error(err))
+		return ErrRecord
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Username != operator.Username {
+			continue
+		}
+		if entry.Permission == domain.PermissionWrite || permission == domain.PermissionRead {
+			return nil
+		}
+	}
+	zap.L().Warn("sftp access denied",
+		zap.Uint("server_id", operator.ServerID),
+		zap.String("username", operator.Username),
+		zap.String("permission", permission),
+	)
+	return ErrAccessDenied
+}
+
+// authorizeManage differs from authorize for servers without entries: their
+// open access must not let the first caller take over the list.
+func (s *Service) authorizeManage(ctx context.Context, operator Operator) error {
+	if slices.Contains(s.admins, operator.Username) {
+		return nil
+	}
+	entries, err := s.repository.ListAccess(ctx, operator.ServerID)
+	if err != nil {
+		zap.L().Error("failed to load sftp access", zap.Uint("server_id", operator.ServerID), zap.Error(err))
+		return ErrRecord
+	}
+	if len(entries) == 0 {
+		zap.L().Warn("sftp access list can only be created by an admin",
+			zap.Uint("server_id", operator.ServerID),
+			zap.String("username", operator.Username),
+		)
+		return ErrAccessDenied
+	}
+	return s.authorize(ctx, operator, domain.PermissionWrite)
+}
+
+func (s *Service) recordTransfer(
+	ctx context.Context,
+	operator Operator,
+	direction, name string,
+	offset, size int64,
+	transferErr error,
+) domain.Transfer {
+	transfer := domain.Transfer{
+		ServerID: operator.ServerID, Username: operator.Username, Direction: direction,
+		Path: name, Offset: offset, Size: size, Status: domain.TransferSuccess,
+	}
+	if transferErr != nil {
+		transfer.Status = domain.TransferFailed
+		transfer.ErrorMessage = transferErr.Error()
+	}
+	if err := s.repository.AddTransfer(ctx, &transfer); err != nil {
+		zap.L().Error("failed to record sftp transfer",
+			zap.Uint("server_id", operator.ServerID),
+			zap.String("username", operator.Username),
+			zap.String("direction", direction),
+			zap.String("path", name),
+			zap.Int64("size", size),
+			zap.Error(err),
+		)
+	}
+	return transfer
+}
+
+func cleanPath(value string) (string, bool) {
+	if value == "" || !strings.HasPrefix(value, "/") {
+		return "", false
+	}
+	return path.Clean(value), true
+}
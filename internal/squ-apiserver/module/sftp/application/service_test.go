@@ -0,0 +1,264 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/sftp/domain"
+)
+
+type repositoryStub struct {
+	access    []domain.Access
+	transfers []domain.Transfer
+}
+
+func (r *repositoryStub) ListAccess(_ context.Context, serverID uint) ([]domain.Access, error) {
+	var result []domain.Access
+	for _, value := range r.access {
+		if value.ServerID == serverID {
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+func (r *repositoryStub) SaveAccess(_ context.Context, value *domain.Access) error {
+	r.access = append(r.access, *value)
+	return nil
+}
+func (r *repositoryStub) DeleteAccess(_ context.Context, serverID uint, username string) error {
+	r.access = slices.DeleteFunc(r.access, func(value domain.Access) bool {
+		return value.ServerID == serverID && value.Username == username
+	})
+	return nil
+}
+func (r *repositoryStub) AddTransfer(_ context.Context, value *domain.Transfer) error {
+	r.transfers = append(r.transfers, *value)
+	return nil
+}
+func (r *repositoryStub) ListTransfers(context.Context, uint) ([]domain.Transfer, error) {
+	return r.transfers, nil
+}
+
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error { return nil }
+
+type memoryWriter struct {
+	session *memorySession
+	name    string
+	offset  int64
+}
+
+func (w *memoryWriter) Write(value []byte) (int, error) {
+	content := w.session.files[w.name]
+	content = append(content[:w.offset], value...)
+	w.session.files[w.name] = content
+	w.offset += int64(len(value))
+	return len(value), nil
+}
+
+func (w *memoryWriter) Close() error { return nil }
+
+type memorySession struct {
+	files  map[string][]byte
+	closed bool
+}
+
+func (s *memorySession) ReadDir(string) ([]domain.FileInfo, error) {
+	var result []domain.FileInfo
+	for name, content := range s.files {
+		result = append(result, domain.FileInfo{Name: name, Path: name, Size: int64(len(content))})
+	}
+	return result, nil
+}
+func (s *memorySession) Stat(name string) (domain.FileInfo, error) {
+	content, ok := s.files[name]
+	if !ok {
+		return domain.FileInfo{}, os.ErrNotExist
+	}
+	return domain.FileInfo{Name: name, Path: name, Size: int64(len(content))}, nil
+}
+func (s *memorySession) Open(name string) (domain.File, error) {
+	return memoryFile{bytes.NewReader(s.files[name])}, nil
+}
+func (s *memorySession) OpenWriter(name string, offset int64) (io.WriteCloser, error) {
+	if offset == 0 {
+		s.files[name] = nil
+	}
+	return &memoryWriter{session: s, name: name, offset: offset}, nil
+}
+func (s *memorySession) Rename(string, string) error     { return nil }
+func (s *memorySession) Remove(string, bool) error       { return nil }
+func (s *memorySession) Mkdir(string, bool) error        { return nil }
+func (s *memorySession) Chmod(string, os.FileMode) error { return nil }
+func (s *memorySession) Close() error                    { s.closed = true; return nil }
+
+type dialerStub struct {
+	session *memorySession
+	err     error
+}
+
+func (d dialerStub) Dial(context.Context, uint) (domain.Session, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.session, nil
+}
+
+func TestAccessControlPerServer(t *testing.T) {
+	repository := &repositoryStub{}
+	session := &memorySession{files: map[string][]byte{}}
+	service := NewService(repository, dialerStub{session: session}, []string{"admin"})
+	ctx := context.Background()
+	alice := Operator{ServerID: 1, Username: "alice"}
+	bob := Operator{ServerID: 1, Username: "bob"}
+
+	if _, err := service.List(ctx, bob, "/"); err != nil {
+		t.Fatalf("server without access entries must stay open: %v", err)
+	}
+	if err := service.GrantAccess(ctx, Operator{ServerID: 1, Username: "admin"}, "alice", domain.PermissionRead); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.List(ctx, alice, "/"); err != nil {
+		t.Fatalf("read access denied: %v", err)
+	}
+	if err := service.Mkdir(ctx, alice, "/tmp/new", false); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("read-only user wrote: %v", err)
+	}
+	if _, err := service.List(ctx, bob, "/"); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("unlisted user read: %v", err)
+	}
+	if err := service.GrantAccess(ctx, alice, "bob", "admin"); !errors.Is(err, ErrInvalidPermission) {
+		t.Fatalf("invalid permission accepted: %v", err)
+	}
+	if _, err := service.ListAccess(ctx, alice); err != nil {
+		t.Fatalf("reader listed access: %v", err)
+	}
+	if _, err := service.ListAccess(ctx, bob); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("unlisted user listed access: %v", err)
+	}
+	if _, err := service.ListTransfers(ctx, bob); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("unlisted user listed transfers: %v", err)
+	}
+}
+
+func TestManagingAccessTakesWriteAccess(t *testing.T) {
+	repository := &repositoryStub{}
+	service := NewService(repository, dialerStub{session: &memorySession{files: map[string][]byte{}}}, []string{"admin"})
+	ctx := context.Background()
+	admin := Operator{ServerID: 1, Username: "admin"}
+	alice := Operator{ServerID: 1, Username: "alice"}
+	bob := Operator{ServerID: 1, Username: "bob"}
+	carol := Operator{ServerID: 1, Username: "carol"}
+
+	if err := service.GrantAccess(ctx, alice, "alice", domain.PermissionWrite); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("non-admin created the first entry: %v", err)
+	}
+	if err := service.GrantAccess(ctx, admin, "alice", domain.PermissionWrite); err != nil {
+		t.Fatalf("first grant by an admin: %v", err)
+	}
+	if err := service.GrantAccess(ctx, alice, "bob", domain.PermissionRead); err != nil {
+		t.Fatalf("grant by a writer: %v", err)
+	}
+	if err := service.GrantAccess(ctx, bob, "bob", domain.PermissionWrite); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("reader raised own permission: %v", err)
+	}
+	if err := service.GrantAccess(ctx, carol, "carol", domain.PermissionWrite); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("unlisted user granted access: %v", err)
+	}
+	if err := service.RevokeAccess(ctx, bob, "alice"); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("reader revoked a writer: %v", err)
+	}
+	if err := service.GrantAccess(ctx, Operator{ServerID: 2, Username: "admin"}, "carol", domain.PermissionWrite); err != nil {
+		t.Fatalf("entries of another server blocked the first grant: %v", err)
+	}
+	if err := service.RevokeAccess(ctx, alice, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := repository.ListAccess(ctx, 1); len(entries) != 1 || entries[0].Username != "alice" {
+		t.Fatalf("access = %#v", entries)
+	}
+}
+
+func TestResumableUploadRecordsEveryChunk(t *testing.T) {
+	repository := &repositoryStub{}
+	session := &memorySession{files: map[string][]byte{}}
+	service := NewService(repository, dialerStub{session: session}, []string{"admin"})
+	ctx := context.Background()
+	operator := Operator{ServerID: 1, Username: "alice"}
+
+	if _, err := service.Upload(ctx, operator, "/data/file.bin", 0, strings.NewReader("hello ")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Upload(ctx, operator, "/data/file.bin", 10, strings.NewReader("x")); !errors.Is(err, ErrInvalidOffset) {
+		t.Fatalf("offset beyond the remote size accepted: %v", err)
+	}
+	transfer, err := service.Upload(ctx, operator, "/data/./file.bin", 6, strings.NewReader("world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(session.files["/data/file.bin"]); got != "hello world" {
+		t.Fatalf("content = %q", got)
+	}
+	if transfer.Offset != 6 || transfer.Size != 5 || transfer.Username != "alice" {
+		t.Fatalf("transfer = %#v", transfer)
+	}
+	if len(repository.transfers) != 2 || repository.transfers[0].Direction != domain.DirectionUpload {
+		t.Fatalf("transfers = %#v", repository.transfers)
+	}
+	if !session.closed {
+		t.Fatal("session was not closed")
+	}
+
+	var downloaded bytes.Buffer
+	err = service.Download(ctx, operator, "/data/file.bin", func(_ domain.FileInfo, file domain.File) (int64, error) {
+		return io.Copy(&downloaded, file)
+	})
+	if err != nil || downloaded.String() != "hello world" {
+		t.Fatalf("download = %q, %v", downloaded.String(), err)
+	}
+	last := repository.transfers[len(repository.transfers)-1]
+	if last.Direction != domain.DirectionDownload || last.Size != 11 || last.Status != domain.TransferSuccess {
+		t.Fatalf("download transfer = %#v", last)
+	}
+}
+
+func TestFailedDownloadIsReported(t *testing.T) {
+	repository := &repositoryStub{}
+	session := &memorySession{files: map[string][]byte{"/data/file.bin": []byte("hello world")}}
+	service := NewService(repository, dialerStub{session: session}, []string{"admin"})
+	broken := errors.New("connection reset by peer")
+
+	err := service.Download(context.Background(), Operator{ServerID: 1, Username: "alice"}, "/data/file.bin",
+		func(domain.FileInfo, domain.File) (int64, error) { return 5, broken })
+	if !errors.Is(err, ErrOperation) {
+		t.Fatalf("error = %v", err)
+	}
+	if len(repository.transfers) != 1 || repository.transfers[0].Status != domain.TransferFailed || repository.transfers[0].Size != 5 {
+		t.Fatalf("transfers = %#v", repository.transfers)
+	}
+}
+
+func TestRemoteErrorsAreMapped(t *testing.T) {
+	service := NewService(&repositoryStub{}, dialerStub{err: gorm.ErrRecordNotFound}, nil)
+	if _, err := service.Stat(context.Background(), Operator{ServerID: 9}, "/etc"); !errors.Is(err, ErrServerNotFound) {
+		t.Fatalf("error = %v", err)
+	}
+	service = NewService(&repositoryStub{}, dialerStub{session: &memorySession{files: map[string][]byte{}}}, nil)
+	if _, err := service.Stat(context.Background(), Operator{ServerID: 1}, "/missing"); !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("error = %v", err)
+	}
+	if _, err := service.Stat(context.Background(), Operator{ServerID: 1}, "relative"); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("error = %v", err)
+	}
+}
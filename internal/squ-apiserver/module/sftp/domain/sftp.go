@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+
+	DirectionUpload   = "upload"
+	DirectionDownload = "download"
+
+	TransferSuccess = "success"
+	TransferFailed  = "failed"
+)
+
+type FileInfo struct {
+	Name    string
+	Path    string
+	Size    int64
+	Mode    os.FileMode
+	ModTime time.Time
+	IsDir   bool
+	IsLink  bool
+}
+
+// Access grants a user read or write access to the files of one server.
+// A server without any access entries is open to every authenticated user.
+type Access struct {
+	ID         uint
+	ServerID   uint
+	Username   string
+	Permission string
+}
+
+// Transfer records one upload chunk or download served through SFTP.
+type Transfer struct {
+	ID           uint
+	CreatedAt    time.Time
+	ServerID     uint
+	Username     string
+	Direction    string
+	Path         string
+	Offset       int64
+	Size         int64
+	Status       string
+	ErrorMessage string
+}
+
+type File interface {
+	io.ReadSeekCloser
+}
+
+type Session interface {
+	ReadDir(string) ([]FileInfo, error)
+	Stat(string) (FileInfo, error)
+	Open(string) (File, error)
+	OpenWriter(string, int64) (io.WriteCloser, error)
+	Rename(string, string) error
+	Remove(string, bool) error
+	Mkdir(string, bool) error
+	Chmod(string, os.FileMode) error
+	Close() error
+}
+
+type Dialer interface {
+	Dial(context.Context, uint) (Session, error)
+}
+
+type Repository interface {
+	ListAccess(context.Context, uint) ([]Access, error)
+	SaveAccess(context.Context, *Access) error
+	DeleteAccess(context.Context, uint, string) error
+	AddTransfer(context.Context, *Transfer) error
+	ListTransfers(context.Context, uint) ([]Transfer, error)
+}
@@ -0,0 +1,7 @@
+package infra
+
+import "gorm.io/gorm"
+
+func Migrate(db *gorm.DB) error { return db.AutoMigrate(&accessModel{}, &transferModel{}) }
+
+func Rollback(db *gorm.DB) error { return db.Migrator().DropTable("sftp_access", "sftp_transfers") }
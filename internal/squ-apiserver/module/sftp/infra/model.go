@@ -0,0 +1,35 @@
+package infra
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type accessModel struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ServerID   uint   `gorm:"column:server_id;not null;uniqueIndex:idx_sftp_access_server_user;comment:服务器ID"`
+	Username   string `gorm:"column:username;type:varchar(50);not null;uniqueIndex:idx_sftp_access_server_user;comment:用户名"`
+	Permission string `gorm:"column:permission;type:varchar(20);not null;default:'read';comment:权限(read/write)"`
+}
+
+func (accessModel) TableName() string { return "sftp_access" }
+
+type transferModel struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	ServerID     uint           `gorm:"column:server_id;index;comment:服务器ID"`
+	Username     string         `gorm:"column:username;type:varchar(50);comment:操作用户"`
+	Direction    string         `gorm:"column:direction;type:varchar(20);comment:传输方向(upload/download)"`
+	Path         string         `gorm:"column:path;type:varchar(1024);comment:远程文件路径"`
+	Offset       int64          `gorm:"column:offset;comment:起始偏移量"`
+	Size         int64          `gorm:"column:size;comment:传输字节数"`
+	Status       string         `gorm:"column:status;type:varchar(20);comment:状态"`
+	ErrorMessage string         `gorm:"column:error_message;type:text;comment:错误信息"`
+}
+
+func (transferModel) TableName() string { return "sftp_transfers" }
@@ -0,0 +1,75 @@
+package infra
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"squirrel-dev/internal/squ-apiserver/module/sftp/domain"
+)
+
+type Repository struct{ db *gorm.DB }
+
+func NewRepository(db *gorm.DB) *Repository { return &Repository{db: db} }
+
+func (r *Repository) ListAccess(ctx context.Context, serverID uint) ([]domain.Access, error) {
+	var models []accessModel
+	if err := r.db.WithContext(ctx).Where("server_id = ?", serverID).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var result []domain.Access
+	for _, model := range models {
+		result = append(result, domain.Access{
+			ID: model.ID, ServerID: model.ServerID, Username: model.Username, Permission: model.Permission,
+		})
+	}
+	return result, nil
+}
+
+func (r *Repository) SaveAccess(ctx context.Context, value *domain.Access) error {
+	model := accessModel{ServerID: value.ServerID, Username: value.Username, Permission: value.Permission}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "server_id"}, {Name: "username"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission", "updated_at"}),
+	}).Create(&model).Error
+	if err != nil {
+		return err
+	}
+	value.ID = model.ID
+	return nil
+}
+
+func (r *Repository) DeleteAccess(ctx context.Context, serverID uint, username string) error {
+	return r.db.WithContext(ctx).
+		Where("server_id = ? AND username = ?", serverID, username).
+		Delete(&accessModel{}).Error
+}
+
+func (r *Repository) AddTransfer(ctx context.Context, value *domain.Transfer) error {
+	model := transferModel{
+		ServerID: value.ServerID, Username: value.Username, Direction: value.Direction, Path: value.Path,
+		Offset: value.Offset, Size: value.Size, Status: value.Status, ErrorMessage: value.ErrorMessage,
+	}
+	if err := r.db.WithContext(ctx).Create(&model).Error; err != nil {
+		return err
+	}
+	value.ID, value.CreatedAt = model.ID, model.CreatedAt
+	return nil
+}
+
+func (r *Repository) ListTransfers(ctx context.Context, serverID uint) ([]domain.Transfer, error) {
+	var models []transferModel
+	if err := r.db.WithContext(ctx).Where("server_id = ?", serverID).Order("id desc").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	var result []domain.Transfer
+	for _, model := range models {
+		result = append(result, domain.Transfer{
+			ID: model.ID, CreatedAt: model.CreatedAt, ServerID: model.ServerID, Username: model.Username,
+			Direction: model.Direction, Path: model.Path, Offset: model.Offset, Size: model.Size,
+			Status: model.Status, ErrorMessage: model.ErrorMessage,
+		})
+	}
+	return result, nil
+}
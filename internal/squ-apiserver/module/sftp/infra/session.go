@@ -0,0 +1,121 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/pkg/sftp"
+
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/domain"
+	sshClient "squirrel-dev/pkg/ssh"
+)
+
+// Dialer opens SFTP sessions with the SSH credentials stored on the server
+// record, the same connection the terminal uses.
+type Dialer struct {
+	servers serverDomain.Repository
+}
+
+func NewDialer(servers serverDomain.Repository) *Dialer {
+	return &Dialer{servers: servers}
+}
+
+func (d *Dialer) Dial(ctx context.Context, serverID uint) (domain.Session, error) {
+	server, err := d.servers.Get(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := serverInfra.NewSSHClient(server)
+	if err != nil {
+		return nil, fmt.Errorf("ssh connect failed: %w", err)
+	}
+	sftpClient, err := client.NewSftp()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("sftp subsystem failed: %w", err)
+	}
+	return &Session{ssh: client, sftp: sftpClient}, nil
+}
+
+type Session struct {
+	ssh  *sshClient.Client
+	sftp *sftp.Client
+}
+
+func (s *Session) ReadDir(dir string) ([]domain.FileInfo, error) {
+	entries, err := s.sftp.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, toFileInfo(path.Join(dir, entry.Name()), entry))
+	}
+	return result, nil
+}
+
+func (s *Session) Stat(name string) (domain.FileInfo, error) {
+	info, err := s.sftp.Stat(name)
+	if err != nil {
+		return domain.FileInfo{}, err
+	}
+	return toFileInfo(name, info), nil
+}
+
+func (s *Session) Open(name string) (domain.File, error) {
+	return s.sftp.Open(name)
+}
+
+// OpenWriter opens name for writing at offset. A zero offset truncates the
+// file so that a fresh upload never keeps stale trailing bytes.
+func (s *Session) OpenWriter(name string, offset int64) (io.WriteCloser, error) {
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	file, err := s.sftp.OpenFile(name, flags)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *Session) Rename(from, to string) error { return s.sftp.Rename(from, to) }
+
+func (s *Session) Remove(name string, recursive bool) error {
+	if recursive {
+		return s.sftp.RemoveAll(name)
+	}
+	return s.sftp.Remove(name)
+}
+
+func (s *Session) Mkdir(name string, parents bool) error {
+	if parents {
+		return s.sftp.MkdirAll(name)
+	}
+	return s.sftp.Mkdir(name)
+}
+
+func (s *Session) Chmod(name string, mode os.FileMode) error { return s.sftp.Chmod(name, mode) }
+
+func (s *Session) Close() error {
+	err := s.sftp.Close()
+	s.ssh.Close()
+	return err
+}
+
+func toFileInfo(name string, info os.FileInfo) domain.FileInfo {
+	return domain.FileInfo{
+		Name: info.Name(), Path: name, Size: info.Size(), Mode: info.Mode(), ModTime: info.ModTime(),
+		IsDir: info.IsDir(), IsLink: info.Mode()&os.ModeSymlink != 0,
+	}
+}
@@ -0,0 +1,27 @@
+package sftp
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/api"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/application"
+	"squirrel-dev/internal/squ-apiserver/module/sftp/infra"
+)
+
+func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
+	res.RegisterCode()
+	service := application.NewService(
+		infra.NewRepository(db),
+		infra.NewDialer(serverInfra.NewRepository(db, secretInfra.NewCipher(conf))),
+		conf.Auth.Admins,
+	)
+	api.RegisterRoutes(group, api.NewHandler(service))
+}
+
+func Migrate(db *gorm.DB) error  { return infra.Migrate(db) }
+func Rollback(db *gorm.DB) error { return infra.Rollback(db) }
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/application"
+)
+
+func bindRequest[T any](c *gin.Context) (T, bool) {
+	var request T
+	if err := c.ShouldBindJSON(&request); err != nil {
+		zap.L().Warn("failed to bind tunnel request", zap.Error(err))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return request, false
+	}
+	return request, true
+}
+
+func writeResult(c *gin.Context, data any, err error) {
+	if err != nil {
+		c.JSON(http.StatusOK, response.Error(errorCode(err)))
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(data))
+}
+
+func errorCode(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return res.ErrServerNotFound
+	case errors.Is(err, application.ErrTunnelNotFound):
+		return res.ErrTunnelNotFound
+	case errors.Is(err, application.ErrTooManyTunnels):
+		return res.ErrTooManyTunnels
+	case errors.Is(err, application.ErrInvalidEndpoint):
+		return res.ErrInvalidEndpoint
+	case errors.Is(err, application.ErrInvalidTTL):
+		return res.ErrInvalidTTL
+	case errors.Is(err, application.ErrListenFailed):
+		return res.ErrListenFailed
+	}
+	return res.ErrDialFailed
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/api/req"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/application"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/domain"
+)
+
+type Handler struct {
+	service *application.Service
+	tokens  proxyTokens
+}
+
+func NewHandler(service *application.Service, signingKey string) *Handler {
+	return &Handler{
+		service: service,
+		tokens:  newProxyTokens(signingKey),
+	}
+}
+
+func (h *Handler) Open(c *gin.Context) {
+	request, ok := bindRequest[req.Tunnel](c)
+	if !ok {
+		return
+	}
+	value, err := h.service.Open(c.Request.Context(), application.OpenRequest{
+		Endpoint: domain.Endpoint{
+			ServerID: request.ServerID,
+			Host:     request.Host,
+			Port:     request.Port,
+			Via:      request.Via,
+		},
+		ListenPort: request.ListenPort,
+		TTL:        time.Duration(request.TTLSeconds) * time.Second,
+		Username:   c.GetString("username"),
+	})
+	writeResult(c, toTunnelResponse(value), err)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	values := h.service.List()
+	result := make([]res.Tunnel, 0, len(values))
+	for _, value := range values {
+		result = append(result, toTunnelResponse(value))
+	}
+	writeResult(c, result, nil)
+}
+
+func (h *Handler) Close(c *gin.Context) {
+	err := h.service.Close(c.Param("id"))
+	writeResult(c, "success", err)
+}
+
+// ProxyToken issues the token of a proxy link to one port of a server. The
+// token is short lived; opening the link exchanges it for the proxy cookie.
+func (h *Handler) ProxyToken(c *gin.Context) {
+	request, ok := bindRequest[req.ProxyToken](c)
+	if !ok {
+		return
+	}
+	token, expiresAt, err := h.tokens.issue(c.GetString("username"), request.ServerID, request.Port, proxyLinkTTL)
+	writeResult(c, res.ProxyToken{Token: token, ExpiresAt: expiresAt}, err)
+}
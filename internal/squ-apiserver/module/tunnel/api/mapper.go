@@ -0,0 +1,24 @@
+package api
+
+import (
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/domain"
+)
+
+func toTunnelResponse(value domain.Tunnel) res.Tunnel {
+	return res.Tunnel{
+		ID:                value.ID,
+		ServerID:          value.Endpoint.ServerID,
+		Host:              value.Endpoint.Host,
+		Port:              value.Endpoint.Port,
+		Via:               value.Endpoint.Via,
+		ListenAddress:     value.ListenAddress,
+		Username:          value.Username,
+		CreatedAt:         value.CreatedAt,
+		ExpiresAt:         value.ExpiresAt,
+		ActiveConnections: value.ActiveConnections,
+		TotalConnections:  value.TotalConnections,
+		BytesSent:         value.BytesSent,
+		BytesReceived:     value.BytesReceived,
+	}
+}
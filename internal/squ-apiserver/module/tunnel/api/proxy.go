@@ -0,0 +1,172 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/application"
+	"squirrel-dev/pkg/jwt"
+	"squirrel-dev/pkg/utils"
+)
+
+const (
+	// proxyCookie carries the token for the pages and assets a proxied app
+	// loads after the first request, which cannot send an Authorization header.
+	proxyCookie = "squirrel_proxy_token"
+	// proxySandbox runs proxied pages in an opaque origin. They are served
+	// from the console's origin and would otherwise reach its stored session
+	// and call the API as the viewing user.
+	proxySandbox = "sandbox allow-scripts allow-forms allow-popups"
+)
+
+// ProxyHandler serves /proxy/server/:id/:port/* as a reverse proxy to the
+// port on the server's loopback interface. It runs outside the JWT middleware
+// because browsers only send cookies when following links.
+type ProxyHandler struct {
+	service    *application.Service
+	signingKey string
+	tokens     proxyTokens
+}
+
+func NewProxyHandler(service *application.Service, signingKey string) *ProxyHandler {
+	return &ProxyHandler{
+		service:    service,
+		signingKey: signingKey,
+		tokens:     newProxyTokens(signingKey),
+	}
+}
+
+func (h *ProxyHandler) Proxy(c *gin.Context) {
+	rawID, rawPort := c.Param("id"), c.Param("port")
+	id, err := utils.StringToUint(rawID)
+	port, portErr := strconv.Atoi(rawPort)
+	if err != nil || portErr != nil {
+		zap.L().Warn("failed to parse proxy target", zap.String("raw_server_id", rawID), zap.String("raw_port", rawPort))
+		c.JSON(http.StatusOK, response.Error(res.ErrInvalidParameter))
+		return
+	}
+	prefix := strings.TrimSuffix(c.Request.URL.Path, c.Param("path"))
+	username, ok := h.authenticate(c, prefix, id, port)
+	if !ok {
+		return
+	}
+	transport, err := h.service.Transport(id, port)
+	if err != nil {
+		c.JSON(http.StatusOK, response.Error(errorCode(err)))
+		return
+	}
+	target := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	proxy := &httputil.ReverseProxy{
+		Transport: transport,
+		Rewrite: func(request *httputil.ProxyRequest) {
+			request.Out.URL.Scheme = "http"
+			request.Out.URL.Host = target
+			request.Out.URL.Path = c.Param("path")
+			request.Out.URL.RawPath = ""
+			request.Out.Host = target
+			request.SetXForwarded()
+			request.Out.Header.Set("X-Forwarded-Prefix", prefix)
+			request.Out.Header.Del("Authorization")
+			stripCookie(request.Out, proxyCookie)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			// Keep absolute redirects of the app inside the proxy prefix.
+			if location := resp.Header.Get("Location"); strings.HasPrefix(location, "/") && !strings.HasPrefix(location, prefix+"/") {
+				resp.Header.Set("Location", prefix+location)
+			}
+			resp.Header.Add("Content-Security-Policy", proxySandbox)
+			return nil
+		},
+		ErrorHandler: func(writer http.ResponseWriter, request *http.Request, err error) {
+			zap.L().Warn("proxy request failed",
+				zap.Uint("server_id", id),
+				zap.Int("port", port),
+				zap.String("path", request.URL.Path),
+				zap.Error(err),
+			)
+			c.JSON(http.StatusBadGateway, response.Error(res.ErrProxyFailed))
+		},
+	}
+	zap.L().Debug("proxy request",
+		zap.Uint("server_id", id),
+		zap.Int("port", port),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Param("path")),
+		zap.String("username", username),
+	)
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
+
+// authenticate accepts a session token as bearer token, or a proxy token
+// for the server port as cookie or token query parameter. The query form
+// comes from a proxy link: it is exchanged for the cookie and redirected to
+// the same URL without the token so that it does not linger in the address
+// bar.
+func (h *ProxyHandler) authenticate(c *gin.Context, prefix string, id uint, port int) (string, bool) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+			c.JSON(http.StatusUnauthorized, response.Error(response.ErrTokenInvalid))
+			return "", false
+		}
+		claims, err := jwt.New(h.signingKey).ParseToken(parts[1])
+		if err != nil {
+			zap.L().Warn("proxy authentication failed", zap.String("path", c.Request.URL.Path), zap.Error(err))
+			c.JSON(http.StatusUnauthorized, response.Error(response.ErrTokenInvalid))
+			return "", false
+		}
+		return claims.Username, true
+	}
+	token, fromQuery := c.Query("token"), true
+	if token == "" {
+		token, _ = c.Cookie(proxyCookie)
+		fromQuery = false
+	}
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, response.Error(response.ErrMissingAuthHeader))
+		return "", false
+	}
+	claims, err := h.tokens.parse(token, id, port)
+	if err != nil {
+		zap.L().Warn("proxy authentication failed", zap.String("path", c.Request.URL.Path), zap.Error(err))
+		c.JSON(http.StatusUnauthorized, response.Error(response.ErrTokenInvalid))
+		return "", false
+	}
+	if fromQuery {
+		cookie, expiresAt, err := h.tokens.issue(claims.Username, id, port, proxySessionTTL)
+		if err != nil {
+			zap.L().Error("failed to issue proxy token", zap.Uint("server_id", id), zap.Int("port", port), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, response.Error(res.ErrProxyFailed))
+			return "", false
+		}
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name: proxyCookie, Value: cookie, Path: prefix + "/", Expires: expiresAt,
+			HttpOnly: true, Secure: c.Request.TLS != nil, SameSite: http.SameSiteLaxMode,
+		})
+		query := c.Request.URL.Query()
+		query.Del("token")
+		location := *c.Request.URL
+		location.RawQuery = query.Encode()
+		c.Redirect(http.StatusFound, location.RequestURI())
+		return "", false
+	}
+	return claims.Username, true
+}
+
+func stripCookie(request *http.Request, name string) {
+	cookies := request.Cookies()
+	request.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			request.AddCookie(cookie)
+		}
+	}
+}
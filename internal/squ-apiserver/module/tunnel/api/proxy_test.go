@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/application"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/domain"
+	"squirrel-dev/pkg/jwt"
+)
+
+const signingKey = "proxy-test-key"
+
+type backendDialer struct{ address string }
+
+func (d backendDialer) Dial(ctx context.Context, _ domain.Endpoint) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", d.address)
+}
+
+// newProxy serves the proxy over a real listener: httputil.ReverseProxy needs
+// a CloseNotifier, which httptest.ResponseRecorder does not implement.
+func newProxy(t *testing.T) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	response.Init()
+	res.RegisterCode()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.Redirect(w, r, "/home", http.StatusFound)
+			return
+		}
+		_, _ = io.WriteString(w, strings.Join([]string{
+			r.URL.RequestURI(),
+			r.Header.Get("Authorization"),
+			r.Header.Get("X-Forwarded-Prefix"),
+			r.Header.Get("Cookie"),
+		}, "|"))
+	}))
+	t.Cleanup(backend.Close)
+	service := application.NewService(backendDialer{address: strings.TrimPrefix(backend.URL, "http://")}, "", false)
+	engine := gin.New()
+	RegisterProxyRoutes(engine.Group("/api/v1"), NewProxyHandler(service, signingKey))
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func serve(t *testing.T, request *http.Request) *http.Response {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestProxyAuthentication(t *testing.T) {
+	base := newProxy(t)
+	token, err := jwt.New(signingKey).GenToken("admin", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *http.Request {
+		request, err := http.NewRequest(http.MethodGet, base+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return request
+	}
+
+	if resp := serve(t, get("/api/v1/proxy/server/1/8080/")); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("anonymous status = %d", resp.StatusCode)
+	}
+
+	if resp := serve(t, get("/api/v1/proxy/server/1/8080/app?token="+token)); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("session token in query status = %d", resp.StatusCode)
+	}
+	link, _, err := newProxyTokens(signingKey).issue("admin", 1, 8080, proxyLinkTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := serve(t, get("/api/v1/proxy/server/1/9090/app?token="+link)); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("proxy token of another port status = %d", resp.StatusCode)
+	}
+
+	resp := serve(t, get("/api/v1/proxy/server/1/8080/app?token="+link+"&tab=2"))
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/api/v1/proxy/server/1/8080/app?tab=2" {
+		t.Fatalf("token redirect = %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	cookies := resp.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != proxyCookie || cookies[0].Path != "/api/v1/proxy/server/1/8080/" || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %+v", cookies)
+	}
+	if _, err := jwt.New(signingKey).ParseToken(cookies[0].Value); err == nil {
+		t.Fatal("proxy cookie is accepted as a session token")
+	}
+
+	request := get("/api/v1/proxy/server/1/8080/app?tab=2")
+	request.AddCookie(&http.Cookie{Name: proxyCookie, Value: cookies[0].Value})
+	request.AddCookie(&http.Cookie{Name: "session", Value: "app"})
+	resp = serve(t, request)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("cookie status = %d", resp.StatusCode)
+	}
+	if csp := resp.Header.Get("Content-Security-Policy"); csp != proxySandbox {
+		t.Fatalf("content security policy = %q", csp)
+	}
+	if got, want := body(t, resp), "/app?tab=2||/api/v1/proxy/server/1/8080|session=app"; got != want {
+		t.Fatalf("backend saw %q, want %q", got, want)
+	}
+
+	request = get("/api/v1/proxy/server/1/8080/login")
+	request.Header.Set("Authorization", "Bearer "+token)
+	resp = serve(t, request)
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/api/v1/proxy/server/1/8080/home" {
+		t.Fatalf("redirect = %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	request = get("/api/v1/proxy/server/1/8080/")
+	request.Header.Set("Authorization", "Bearer not-a-token")
+	if resp := serve(t, request); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("invalid token status = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,18 @@
+package req
+
+// Tunnel opens a forwarded port. Host defaults to 127.0.0.1 on the server,
+// Via to "auto" (SSH, then agent), ListenPort to a free port and TTLSeconds to
+// one hour.
+type Tunnel struct {
+	ServerID   uint   `json:"server_id" binding:"required"`
+	Host       string `json:"host"`
+	Port       int    `json:"port" binding:"required"`
+	Via        string `json:"via"`
+	ListenPort int    `json:"listen_port"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+type ProxyToken struct {
+	ServerID uint `json:"server_id" binding:"required"`
+	Port     int  `json:"port" binding:"required,min=1,max=65535"`
+}
@@ -0,0 +1,24 @@
+package res
+
+import "time"
+
+type Tunnel struct {
+	ID                string    `json:"id"`
+	ServerID          uint      `json:"server_id"`
+	Host              string    `json:"host"`
+	Port              int       `json:"port"`
+	Via               string    `json:"via"`
+	ListenAddress     string    `json:"listen_address"`
+	Username          string    `json:"username"`
+	CreatedAt         time.Time `json:"created_at"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	ActiveConnections int64     `json:"active_connections"`
+	TotalConnections  int64     `json:"total_connections"`
+	BytesSent         int64     `json:"bytes_sent"`
+	BytesReceived     int64     `json:"bytes_received"`
+}
+
+type ProxyToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
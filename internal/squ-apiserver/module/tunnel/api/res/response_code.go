@@ -0,0 +1,31 @@
+package res
+
+import "squirrel-dev/internal/pkg/response"
+
+const (
+	ErrServerNotFound = 64001
+	ErrTunnelNotFound = 64002
+	ErrTooManyTunnels = 64003
+
+	ErrInvalidParameter = 64021
+	ErrInvalidEndpoint  = 64022
+	ErrInvalidTTL       = 64023
+
+	ErrDialFailed   = 64041
+	ErrListenFailed = 64042
+	ErrProxyFailed  = 64043
+)
+
+func RegisterCode() {
+	response.Register(ErrServerNotFound, "server not found")
+	response.Register(ErrTunnelNotFound, "tunnel not found")
+	response.Register(ErrTooManyTunnels, "too many open tunnels")
+
+	response.Register(ErrInvalidParameter, "invalid parameter")
+	response.Register(ErrInvalidEndpoint, "invalid tunnel endpoint")
+	response.Register(ErrInvalidTTL, "invalid tunnel lifetime")
+
+	response.Register(ErrDialFailed, "tunnel target is unreachable")
+	response.Register(ErrListenFailed, "failed to open tunnel port")
+	response.Register(ErrProxyFailed, "proxy request failed")
+}
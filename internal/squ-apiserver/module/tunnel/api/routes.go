@@ -0,0 +1,16 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(group *gin.RouterGroup, handler *Handler) {
+	group.GET("/tunnel", handler.List)
+	group.POST("/tunnel", handler.Open)
+	group.DELETE("/tunnel/:id", handler.Close)
+	group.POST("/tunnel/proxy-token", handler.ProxyToken)
+}
+
+// RegisterProxyRoutes registers the reverse proxy outside the JWT middleware;
+// ProxyHandler authenticates each request itself.
+func RegisterProxyRoutes(group *gin.RouterGroup, handler *ProxyHandler) {
+	group.Any("/proxy/server/:id/:port/*path", handler.Proxy)
+}
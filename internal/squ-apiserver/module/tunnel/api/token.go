@@ -0,0 +1,69 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	jwtgo "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// proxyLinkTTL bounds the token of a proxy link. It only has to survive
+	// the redirect that turns it into the proxy cookie.
+	proxyLinkTTL = time.Minute
+	// proxySessionTTL bounds the proxy cookie of one server port.
+	proxySessionTTL = 8 * time.Hour
+)
+
+var errProxyScope = errors.New("proxy token is scoped to another target")
+
+type proxyClaims struct {
+	Username string `json:"username"`
+	ServerID uint   `json:"server_id"`
+	Port     int    `json:"port"`
+	jwtgo.RegisteredClaims
+}
+
+// proxyTokens signs tokens that only open one port of one server through
+// the proxy. They are signed with a key derived from the signing key, so
+// that a proxy token that ends up in a log is never accepted as a session
+// token, nor a session token as a proxy link.
+type proxyTokens struct {
+	key []byte
+}
+
+func newProxyTokens(signingKey string) proxyTokens {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte("squirrel proxy token"))
+	return proxyTokens{key: mac.Sum(nil)}
+}
+
+func (t proxyTokens) issue(username string, serverID uint, port int, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims := proxyClaims{
+		Username: username,
+		ServerID: serverID,
+		Port:     port,
+		RegisteredClaims: jwtgo.RegisteredClaims{
+			ExpiresAt: jwtgo.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claims).SignedString(t.key)
+	return token, expiresAt, err
+}
+
+func (t proxyTokens) parse(value string, serverID uint, port int) (proxyClaims, error) {
+	var claims proxyClaims
+	_, err := jwtgo.ParseWithClaims(value, &claims, func(*jwtgo.Token) (any, error) {
+		return t.key, nil
+	}, jwtgo.WithValidMethods([]string{jwtgo.SigningMethodHS256.Alg()}), jwtgo.WithExpirationRequired())
+	if err != nil {
+		return proxyClaims{}, err
+	}
+	if claims.ServerID != serverID || claims.Port != port {
+		return proxyClaims{}, errProxyScope
+	}
+	return claims, nil
+}
@@ -0,0 +1,12 @@
+package application
+
+import "errors"
+
+var (
+	ErrInvalidEndpoint = errors.New("invalid tunnel endpoint")
+	ErrInvalidTTL      = errors.New("invalid tunnel lifetime")
+	ErrTooManyTunnels  = errors.New("too many open tunnels")
+	ErrTunnelNotFound  = errors.New("tunnel not found")
+	ErrDialFailed      = errors.New("tunnel target is unreachable")
+	ErrListenFailed    = errors.New("failed to open tunnel port")
+)
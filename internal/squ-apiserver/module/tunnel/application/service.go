@@ -0,0 +1,386 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/domain"
+)
+
+const (
+	maxTunnels  = 50
+	defaultTTL  = time.Hour
+	maxTTL      = 24 * time.Hour
+	dialTimeout = 10 * time.Second
+	// transportIdleTTL drops the shared proxy transport of an endpoint that
+	// has not been used for a while.
+	transportIdleTTL = 10 * time.Minute
+
+	defaultTargetHost = "127.0.0.1"
+	defaultBindHost   = "127.0.0.1"
+)
+
+type OpenRequest struct {
+	Endpoint   domain.Endpoint
+	ListenPort int
+	TTL        time.Duration
+	Username   string
+}
+
+type Service struct {
+	dialer   domain.Dialer
+	bindHost string
+	bindErr  error
+
+	mu         sync.Mutex
+	tunnels    map[string]*tunnel
+	transports map[domain.Endpoint]*sharedTransport
+}
+
+type sharedTransport struct {
+	transport *http.Transport
+	used      time.Time
+}
+
+// NewService binds tunnel ports on bindHost, which defaults to loopback.
+// Tunnel ports do not authenticate their clients, so any other address is
+// refused unless allowPublic is set.
+func NewService(dialer domain.Dialer, bindHost string, allowPublic bool) *Service {
+	if bindHost == "" {
+		bindHost = defaultBindHost
+	}
+	var bindErr error
+	if !allowPublic && !isLoopback(bindHost) {
+		bindErr = fmt.Errorf("%w: bind address %s is not a loopback address and tunnel.allowPublicBind is off",
+			ErrListenFailed, bindHost)
+	}
+	return &Service{
+		dialer:     dialer,
+		bindHost:   bindHost,
+		bindErr:    bindErr,
+		tunnels:    make(map[string]*tunnel),
+		transports: make(map[domain.Endpoint]*sharedTransport),
+	}
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Open checks that the endpoint is reachable, then listens on the apiserver
+// and forwards every accepted connection until the tunnel is closed or
+// expires.
+func (s *Service) Open(ctx context.Context, request OpenRequest) (domain.Tunnel, error) {
+	endpoint, err := normalizeEndpoint(request.Endpoint)
+	if err != nil {
+		return domain.Tunnel{}, err
+	}
+	if request.ListenPort < 0 || request.ListenPort > 65535 {
+		return domain.Tunnel{}, ErrInvalidEndpoint
+	}
+	ttl := request.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	if ttl < 0 || ttl > maxTTL {
+		return domain.Tunnel{}, ErrInvalidTTL
+	}
+	if s.bindErr != nil {
+		zap.L().Error("refused to open tunnel", zap.Error(s.bindErr))
+		return domain.Tunnel{}, s.bindErr
+	}
+	s.mu.Lock()
+	count := len(s.tunnels)
+	s.mu.Unlock()
+	if count >= maxTunnels {
+		return domain.Tunnel{}, ErrTooManyTunnels
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	probe, err := s.dialer.Dial(probeCtx, endpoint)
+	cancel()
+	if err != nil {
+		zap.L().Warn("tunnel target is unreachable",
+			zap.Uint("server_id", endpoint.ServerID),
+			zap.String("host", endpoint.Host),
+			zap.Int("port", endpoint.Port),
+			zap.String("via", endpoint.Via),
+			zap.Error(err),
+		)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Tunnel{}, err
+		}
+		return domain.Tunnel{}, fmt.Errorf("%w: %v", ErrDialFailed, err)
+	}
+	probe.Close()
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(s.bindHost, strconv.Itoa(request.ListenPort)))
+	if err != nil {
+		zap.L().Error("failed to listen for tunnel", zap.Int("listen_port", request.ListenPort), zap.Error(err))
+		return domain.Tunnel{}, fmt.Errorf("%w: %v", ErrListenFailed, err)
+	}
+	now := time.Now()
+	t := &tunnel{
+		info: domain.Tunnel{
+			ID: uuid.New().String(), Endpoint: endpoint, ListenAddress: listener.Addr().String(),
+			Username: request.Username, CreatedAt: now, ExpiresAt: now.Add(ttl),
+		},
+		listener: listener,
+		dialer:   s.dialer,
+		forget:   s.forgetServer,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.mu.Lock()
+	s.tunnels[t.info.ID] = t
+	t.expiry = time.AfterFunc(ttl, func() {
+		zap.L().Info("tunnel expired", zap.String("tunnel_id", t.info.ID))
+		_ = s.Close(t.info.ID)
+	})
+	s.mu.Unlock()
+	go t.serve()
+	zap.L().Info("tunnel opened",
+		zap.String("tunnel_id", t.info.ID),
+		zap.String("listen_address", t.info.ListenAddress),
+		zap.Uint("server_id", endpoint.ServerID),
+		zap.String("host", endpoint.Host),
+		zap.Int("port", endpoint.Port),
+		zap.String("via", endpoint.Via),
+		zap.String("username", request.Username),
+	)
+	return t.snapshot(), nil
+}
+
+func (s *Service) List() []domain.Tunnel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]domain.Tunnel, 0, len(s.tunnels))
+	for _, t := range s.tunnels {
+		result = append(result, t.snapshot())
+	}
+	slices.SortFunc(result, func(a, b domain.Tunnel) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return result
+}
+
+// Close stops listening and drops the tunnel's open connections.
+func (s *Service) Close(id string) error {
+	s.mu.Lock()
+	t, ok := s.tunnels[id]
+	delete(s.tunnels, id)
+	s.mu.Unlock()
+	if !ok {
+		return ErrTunnelNotFound
+	}
+	t.close()
+	zap.L().Info("tunnel closed", zap.String("tunnel_id", id))
+	return nil
+}
+
+// Transport returns a shared HTTP transport whose connections go to port on
+// the server's loopback interface, so that keep-alive connections are reused
+// across proxied requests. Transports unused for transportIdleTTL are
+// dropped.
+func (s *Service) Transport(serverID uint, port int) (http.RoundTripper, error) {
+	endpoint, err := normalizeEndpoint(domain.Endpoint{ServerID: serverID, Port: port, Via: domain.ViaAuto})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, value := range s.transports {
+		if now.Sub(value.used) > transportIdleTTL {
+			value.transport.CloseIdleConnections()
+			delete(s.transports, key)
+		}
+	}
+	shared, ok := s.transports[endpoint]
+	if !ok {
+		shared = &sharedTransport{transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+				defer cancel()
+				conn, err := s.dialer.Dial(ctx, endpoint)
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					s.forgetServer(endpoint.ServerID)
+				}
+				return conn, err
+			},
+			MaxIdleConnsPerHost: 8,
+			IdleConnTimeout:     90 * time.Second,
+		}}
+		s.transports[endpoint] = shared
+	}
+	shared.used = now
+	return shared.transport, nil
+}
+
+// forgetServer closes the tunnels and drops the transports of a server that
+// no longer exists, which the dialer reports as gorm.ErrRecordNotFound.
+func (s *Service) forgetServer(serverID uint) {
+	var ids []string
+	s.mu.Lock()
+	for id, t := range s.tunnels {
+		if t.info.Endpoint.ServerID == serverID {
+			ids = append(ids, id)
+		}
+	}
+	for endpoint, value := range s.transports {
+		if endpoint.ServerID == serverID {
+			value.transport.CloseIdleConnections()
+			delete(s.transports, endpoint)
+		}
+	}
+	s.mu.Unlock()
+	for _, id := range ids {
+		zap.L().Info("closing tunnel of a deleted server", zap.String("tunnel_id", id), zap.Uint("server_id", serverID))
+		_ = s.Close(id)
+	}
+}
+
+func normalizeEndpoint(endpoint domain.Endpoint) (domain.Endpoint, error) {
+	if endpoint.Host == "" {
+		endpoint.Host = defaultTargetHost
+	}
+	if endpoint.Via == "" {
+		endpoint.Via = domain.ViaAuto
+	}
+	if endpoint.ServerID == 0 || endpoint.Port < 1 || endpoint.Port > 65535 {
+		return endpoint, ErrInvalidEndpoint
+	}
+	switch endpoint.Via {
+	case domain.ViaSSH, domain.ViaAgent, domain.ViaAuto:
+		return endpoint, nil
+	}
+	return endpoint, ErrInvalidEndpoint
+}
+
+type tunnel struct {
+	info     domain.Tunnel
+	listener net.Listener
+	dialer   domain.Dialer
+	forget   func(uint)
+	expiry   *time.Timer
+
+	active, total, sent, received atomic.Int64
+
+	mu     sync.Mutex
+	closed bool
+	conns  map[net.Conn]struct{}
+}
+
+func (t *tunnel) snapshot() domain.Tunnel {
+	info := t.info
+	info.ActiveConnections = t.active.Load()
+	info.TotalConnections = t.total.Load()
+	info.BytesSent = t.sent.Load()
+	info.BytesReceived = t.received.Load()
+	return info
+}
+
+func (t *tunnel) serve() {
+	for {
+		client, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(client)
+	}
+}
+
+func (t *tunnel) forward(client net.Conn) {
+	t.total.Add(1)
+	t.active.Add(1)
+	defer t.active.Add(-1)
+	if !t.track(client) {
+		client.Close()
+		return
+	}
+	defer t.untrack(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	target, err := t.dialer.Dial(ctx, t.info.Endpoint)
+	cancel()
+	if err != nil {
+		zap.L().Warn("failed to dial tunnel target", zap.String("tunnel_id", t.info.ID), zap.Error(err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			go t.forget(t.info.Endpoint.ServerID)
+		}
+		return
+	}
+	if !t.track(target) {
+		target.Close()
+		return
+	}
+	defer t.untrack(target)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, _ := io.Copy(target, client)
+		t.sent.Add(n)
+		closeWrite(target)
+	}()
+	n, _ := io.Copy(client, target)
+	t.received.Add(n)
+	closeWrite(client)
+	<-done
+}
+
+// closeWrite half-closes conn when it supports it so that the peer sees EOF
+// while replies can still arrive; otherwise it closes conn.
+func closeWrite(conn net.Conn) {
+	if writer, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = writer.CloseWrite()
+		return
+	}
+	conn.Close()
+}
+
+func (t *tunnel) track(conn net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return false
+	}
+	t.conns[conn] = struct{}{}
+	return true
+}
+
+func (t *tunnel) untrack(conn net.Conn) {
+	t.mu.Lock()
+	delete(t.conns, conn)
+	t.mu.Unlock()
+	conn.Close()
+}
+
+func (t *tunnel) close() {
+	t.expiry.Stop()
+	t.listener.Close()
+	t.mu.Lock()
+	t.closed = true
+	conns := make([]net.Conn, 0, len(t.conns))
+	for conn := range t.conns {
+		conns = append(conns, conn)
+	}
+	t.mu.Unlock()
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
@@ -0,0 +1,242 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/domain"
+)
+
+// dialerStub connects every endpoint to address and records what was asked.
+type dialerStub struct {
+	address   string
+	err       error
+	endpoints chan domain.Endpoint
+}
+
+func (d *dialerStub) Dial(ctx context.Context, endpoint domain.Endpoint) (net.Conn, error) {
+	if d.endpoints != nil {
+		d.endpoints <- endpoint
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", d.address)
+}
+
+func echoServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestOpenForwardsConnections(t *testing.T) {
+	dialer := &dialerStub{address: echoServer(t), endpoints: make(chan domain.Endpoint, 4)}
+	service := NewService(dialer, "", false)
+	tunnel, err := service.Open(context.Background(), OpenRequest{
+		Endpoint: domain.Endpoint{ServerID: 3, Port: 5432},
+		Username: "admin",
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	probe := <-dialer.endpoints
+	if probe != (domain.Endpoint{ServerID: 3, Host: "127.0.0.1", Port: 5432, Via: domain.ViaAuto}) {
+		t.Fatalf("endpoint = %+v", probe)
+	}
+	if !strings.HasPrefix(tunnel.ListenAddress, "127.0.0.1:") || tunnel.ExpiresAt.Sub(tunnel.CreatedAt) != defaultTTL {
+		t.Fatalf("tunnel = %+v", tunnel)
+	}
+
+	conn, err := net.Dial("tcp", tunnel.ListenAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "hello\n" {
+		t.Fatalf("echo = %q, %v", line, err)
+	}
+	if got := service.List(); len(got) != 1 || got[0].TotalConnections != 1 {
+		t.Fatalf("List() = %+v", got)
+	}
+
+	if err := service.Close(tunnel.ID); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// Closing drops open connections as well as the listener.
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("connection is still open after Close()")
+	}
+	if _, err := net.Dial("tcp", tunnel.ListenAddress); err == nil {
+		t.Fatal("listener is still open after Close()")
+	}
+	if err := service.Close(tunnel.ID); !errors.Is(err, ErrTunnelNotFound) {
+		t.Fatalf("second Close() error = %v", err)
+	}
+}
+
+func TestOpenValidatesRequest(t *testing.T) {
+	service := NewService(&dialerStub{err: errors.New("connection refused")}, "", false)
+	for _, test := range []struct {
+		request OpenRequest
+		want    error
+	}{
+		{OpenRequest{Endpoint: domain.Endpoint{ServerID: 1}}, ErrInvalidEndpoint},
+		{OpenRequest{Endpoint: domain.Endpoint{ServerID: 1, Port: 70000}}, ErrInvalidEndpoint},
+		{OpenRequest{Endpoint: domain.Endpoint{ServerID: 1, Port: 80, Via: "vpn"}}, ErrInvalidEndpoint},
+		{OpenRequest{Endpoint: domain.Endpoint{ServerID: 1, Port: 80}, TTL: 48 * time.Hour}, ErrInvalidTTL},
+		{OpenRequest{Endpoint: domain.Endpoint{ServerID: 1, Port: 80}}, ErrDialFailed},
+	} {
+		if _, err := service.Open(context.Background(), test.request); !errors.Is(err, test.want) {
+			t.Fatalf("Open(%+v) error = %v, want %v", test.request, err, test.want)
+		}
+	}
+	if len(service.List()) != 0 {
+		t.Fatal("failed requests left tunnels behind")
+	}
+}
+
+func TestOpenExpires(t *testing.T) {
+	service := NewService(&dialerStub{address: echoServer(t)}, "", false)
+	tunnel, err := service.Open(context.Background(), OpenRequest{
+		Endpoint: domain.Endpoint{ServerID: 1, Port: 80},
+		TTL:      50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(service.List()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("tunnel %s did not expire", tunnel.ID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTransportDialsServerLoopback(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer backend.Close()
+	dialer := &dialerStub{address: strings.TrimPrefix(backend.URL, "http://"), endpoints: make(chan domain.Endpoint, 1)}
+	service := NewService(dialer, "", false)
+	transport, err := service.Transport(7, 9000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := service.Transport(7, 9000); again != transport {
+		t.Fatal("Transport() is not shared per endpoint")
+	}
+	resp, err := (&http.Client{Transport: transport}).Get("http://127.0.0.1:9000/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "/status" {
+		t.Fatalf("body = %q", body)
+	}
+	if endpoint := <-dialer.endpoints; endpoint != (domain.Endpoint{ServerID: 7, Host: "127.0.0.1", Port: 9000, Via: domain.ViaAuto}) {
+		t.Fatalf("endpoint = %+v", endpoint)
+	}
+}
+
+func TestOpenRefusesPublicBindUnlessAllowed(t *testing.T) {
+	dialer := &dialerStub{address: echoServer(t)}
+	request := OpenRequest{Endpoint: domain.Endpoint{ServerID: 1, Port: 80}}
+	if _, err := NewService(dialer, "0.0.0.0", false).Open(context.Background(), request); !errors.Is(err, ErrListenFailed) {
+		t.Fatalf("Open() on 0.0.0.0 error = %v", err)
+	}
+	for _, host := range []string{"", "localhost", "::1"} {
+		service := NewService(dialer, host, false)
+		tunnel, err := service.Open(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Open() on %q error = %v", host, err)
+		}
+		_ = service.Close(tunnel.ID)
+	}
+	service := NewService(dialer, "0.0.0.0", true)
+	tunnel, err := service.Open(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Open() with public binds allowed error = %v", err)
+	}
+	_ = service.Close(tunnel.ID)
+}
+
+// deletableDialer reports gorm.ErrRecordNotFound once the server is deleted.
+type deletableDialer struct {
+	dialerStub
+	deleted atomic.Bool
+}
+
+func (d *deletableDialer) Dial(ctx context.Context, endpoint domain.Endpoint) (net.Conn, error) {
+	if d.deleted.Load() {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return d.dialerStub.Dial(ctx, endpoint)
+}
+
+func TestDeletedServerIsForgotten(t *testing.T) {
+	dialer := &deletableDialer{dialerStub: dialerStub{address: echoServer(t)}}
+	service := NewService(dialer, "", false)
+	tunnel, err := service.Open(context.Background(), OpenRequest{Endpoint: domain.Endpoint{ServerID: 4, Port: 80}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err := service.Transport(4, 9000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialer.deleted.Store(true)
+
+	if _, err := (&http.Client{Transport: transport}).Get("http://127.0.0.1:9000/"); err == nil {
+		t.Fatal("request to a deleted server succeeded")
+	}
+	if again, _ := service.Transport(4, 9000); again == transport {
+		t.Fatal("transport of a deleted server was kept")
+	}
+	conn, err := net.Dial("tcp", tunnel.ListenAddress)
+	if err == nil {
+		conn.Close()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(service.List()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("tunnel %s of a deleted server is still open", tunnel.ID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+const (
+	// ViaSSH opens a direct-tcpip channel over the server's stored SSH login.
+	ViaSSH = "ssh"
+	// ViaAgent relays through the agent's WebSocket tunnel endpoint.
+	ViaAgent = "agent"
+	// ViaAuto tries SSH first and falls back to the agent.
+	ViaAuto = "auto"
+)
+
+// Endpoint is a TCP address as seen from a managed server.
+type Endpoint struct {
+	ServerID uint
+	Host     string
+	Port     int
+	Via      string
+}
+
+// Tunnel is a port on the apiserver that forwards every accepted connection
+// to Endpoint. Tunnels live in memory and end with the apiserver process.
+type Tunnel struct {
+	ID                string
+	Endpoint          Endpoint
+	ListenAddress     string
+	Username          string
+	CreatedAt         time.Time
+	ExpiresAt         time.Time
+	ActiveConnections int64
+	TotalConnections  int64
+	BytesSent         int64
+	BytesReceived     int64
+}
+
+type Dialer interface {
+	Dial(context.Context, Endpoint) (net.Conn, error)
+}
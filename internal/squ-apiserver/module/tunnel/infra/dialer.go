@@ -0,0 +1,189 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	gossh "golang.org/x/crypto/ssh"
+
+	"squirrel-dev/internal/pkg/response"
+	"squirrel-dev/internal/squ-apiserver/config"
+	serverDomain "squirrel-dev/internal/squ-apiserver/module/server/domain"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/domain"
+	sshClient "squirrel-dev/pkg/ssh"
+	"squirrel-dev/pkg/utils"
+)
+
+// sshIdleTimeout is how long an SSH login is kept after its last forwarded
+// connection closes, so that bursts of proxy requests share one login.
+const sshIdleTimeout = 2 * time.Minute
+
+// Dialer opens connections to endpoints on managed servers.
+type Dialer struct {
+	servers serverDomain.Repository
+	conf    *config.Config
+
+	mu      sync.Mutex
+	clients map[uint]*pooledClient
+}
+
+type pooledClient struct {
+	client *sshClient.Client
+	active int
+	idle   *time.Timer
+}
+
+func NewDialer(conf *config.Config, servers serverDomain.Repository) *Dialer {
+	return &Dialer{servers: servers, conf: conf, clients: make(map[uint]*pooledClient)}
+}
+
+func (d *Dialer) Dial(ctx context.Context, endpoint domain.Endpoint) (net.Conn, error) {
+	server, err := d.servers.Get(ctx, endpoint.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	switch endpoint.Via {
+	case domain.ViaSSH:
+		return d.dialSSH(ctx, server, endpoint)
+	case domain.ViaAgent:
+		return d.dialAgent(ctx, server, endpoint)
+	}
+	conn, sshErr := d.dialSSH(ctx, server, endpoint)
+	if sshErr == nil {
+		return conn, nil
+	}
+	conn, agentErr := d.dialAgent(ctx, server, endpoint)
+	if agentErr != nil {
+		return nil, errors.Join(sshErr, agentErr)
+	}
+	return conn, nil
+}
+
+func (d *Dialer) dialSSH(ctx context.Context, server serverDomain.Server, endpoint domain.Endpoint) (net.Conn, error) {
+	address := net.JoinHostPort(endpoint.Host, strconv.Itoa(endpoint.Port))
+	for attempt := 0; ; attempt++ {
+		pooled, err := d.acquire(server)
+		if err != nil {
+			return nil, fmt.Errorf("ssh connect failed: %w", err)
+		}
+		conn, err := pooled.client.Client.DialContext(ctx, "tcp", address)
+		if err == nil {
+			return &pooledConn{Conn: conn, release: func() { d.release(server.ID, pooled) }}, nil
+		}
+		d.release(server.ID, pooled)
+		// A rejected channel means the login works but the target does not;
+		// anything else suggests a dead connection that is worth one retry.
+		var openErr *gossh.OpenChannelError
+		if errors.As(err, &openErr) || ctx.Err() != nil || attempt > 0 {
+			return nil, fmt.Errorf("ssh forward to %s failed: %w", address, err)
+		}
+		d.discard(server.ID, pooled)
+	}
+}
+
+func (d *Dialer) acquire(server serverDomain.Server) (*pooledClient, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pooled, ok := d.clients[server.ID]
+	if !ok {
+		client, err := serverInfra.NewSSHClient(server)
+		if err != nil {
+			return nil, err
+		}
+		pooled = &pooledClient{client: client}
+		d.clients[server.ID] = pooled
+	}
+	if pooled.idle != nil {
+		pooled.idle.Stop()
+		pooled.idle = nil
+	}
+	pooled.active++
+	return pooled, nil
+}
+
+func (d *Dialer) release(serverID uint, pooled *pooledClient) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pooled.active--
+	if pooled.active > 0 || d.clients[serverID] != pooled {
+		return
+	}
+	pooled.idle = time.AfterFunc(sshIdleTimeout, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if pooled.active == 0 && d.clients[serverID] == pooled {
+			delete(d.clients, serverID)
+			pooled.client.Close()
+		}
+	})
+}
+
+func (d *Dialer) discard(serverID uint, pooled *pooledClient) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.clients[serverID] == pooled {
+		delete(d.clients, serverID)
+		if pooled.idle != nil {
+			pooled.idle.Stop()
+		}
+		pooled.client.Close()
+	}
+}
+
+func (d *Dialer) dialAgent(ctx context.Context, server serverDomain.Server, endpoint domain.Endpoint) (net.Conn, error) {
+	scheme := "ws"
+	if d.conf.Agent.Http.Scheme == "https" {
+		scheme = "wss"
+	}
+	query := url.Values{"host": {endpoint.Host}, "port": {strconv.Itoa(endpoint.Port)}}
+	agentURL := utils.GenAgentUrl(scheme, server.IPAddress, server.AgentPort, d.conf.Agent.Http.BaseUrl, "/tunnel") + "?" + query.Encode()
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, agentURL, nil)
+	if err != nil {
+		// The agent answers a rejected target with a regular JSON response.
+		if resp != nil && errors.Is(err, websocket.ErrBadHandshake) {
+			defer resp.Body.Close()
+			var body response.Response
+			if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body) == nil && body.Message != "" {
+				err = fmt.Errorf("%s (code %d)", body.Message, body.Code)
+			}
+		}
+		zap.L().Warn("failed to open agent tunnel",
+			zap.String("url", agentURL),
+			zap.Uint("server_id", server.ID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("agent tunnel failed: %w", err)
+	}
+	return &wsConn{conn: conn}, nil
+}
+
+// pooledConn releases its SSH login when the forwarded connection closes.
+type pooledConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *pooledConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+func (c *pooledConn) CloseWrite() error {
+	if writer, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return writer.CloseWrite()
+	}
+	return c.Close()
+}
@@ -0,0 +1,30 @@
+package tunnel
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"squirrel-dev/internal/squ-apiserver/config"
+	secretInfra "squirrel-dev/internal/squ-apiserver/module/secret/infra"
+	serverInfra "squirrel-dev/internal/squ-apiserver/module/server/infra"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/api"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/api/res"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/application"
+	"squirrel-dev/internal/squ-apiserver/module/tunnel/infra"
+)
+
+func buildService(conf *config.Config, db *gorm.DB) *application.Service {
+	return application.NewService(infra.NewDialer(conf, serverInfra.NewRepository(db, secretInfra.NewCipher(conf))), conf.Tunnel.BindAddress, conf.Tunnel.AllowPublicBind)
+}
+
+func RegisterHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
+	res.RegisterCode()
+	api.RegisterRoutes(group, api.NewHandler(buildService(conf, db), conf.Auth.Jwt.SigningKey))
+}
+
+// RegisterProxyHTTP keeps the reverse proxy outside the HTTP JWT middleware so
+// that browsers can authenticate with the proxy cookie.
+func RegisterProxyHTTP(group *gin.RouterGroup, conf *config.Config, db *gorm.DB) {
+	res.RegisterCode()
+	api.RegisterProxyRoutes(group, api.NewProxyHandler(buildService(conf, db), conf.Auth.Jwt.SigningKey))
+}
@@ -0,0 +1,217 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	memory "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// FieldManager owns the fields squirrel applies with server-side apply.
+const FieldManager = "squirrel"
+
+// Object identifies an object applied from a manifest. Resource is kept so
+// that the object can be found again without discovery.
+type Object struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Resource  string `json:"resource"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (o Object) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+func (o Object) gvr() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: o.Group, Version: o.Version, Resource: o.Resource}
+}
+
+// NewFromKubeconfig builds a client from the content of a kubeconfig file.
+func NewFromKubeconfig(kubeconfig []byte) (*Client, error) {
+	config, err := RESTConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewForConfig(config)
+}
+
+// NewForConfig is New for callers that need the error instead of a log line.
+func NewForConfig(config *rest.Config) (*Client, error) {
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{ClientSet: clientSet, DynamicClient: dynamicClient}, nil
+}
+
+// RESTConfig parses a kubeconfig and returns the config of its current
+// context.
+func RESTConfig(kubeconfig []byte) (*rest.Config, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	return config, nil
+}
+
+// Apply server-side applies every document of manifest, in order, and returns
+// the applied objects. Namespaced objects without a namespace are applied to
+// namespace. Conflicts with other field managers are forced, so the manifest
+// wins like kubectl apply --server-side --force-conflicts.
+func (c *Client) Apply(ctx context.Context, manifest, namespace string) ([]Object, error) {
+	documents, err := c.decode(manifest, namespace)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]Object, 0, len(documents))
+	for _, document := range documents {
+		if _, err := c.resource(document.object).Apply(ctx, document.object.Name, document.value, metav1.ApplyOptions{
+			FieldManager: FieldManager,
+			Force:        true,
+		}); err != nil {
+			return objects, fmt.Errorf("apply %s: %w", document.object, err)
+		}
+		objects = append(objects, document.object)
+	}
+	return objects, nil
+}
+
+// Objects returns the objects of manifest without applying them, with the
+// namespace of namespaced objects defaulted like Apply does.
+func (c *Client) Objects(manifest, namespace string) ([]Object, error) {
+	documents, err := c.decode(manifest, namespace)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]Object, 0, len(documents))
+	for _, document := range documents {
+		objects = append(objects, document.object)
+	}
+	return objects, nil
+}
+
+type document struct {
+	object Object
+	value  *unstructured.Unstructured
+}
+
+func (c *Client) decode(manifest, namespace string) ([]document, error) {
+	raw, err := splitManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("manifest contains no objects")
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.ClientSet.Discovery()))
+	decoder := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
+	documents := make([]document, 0, len(raw))
+	for _, data := range raw {
+		obj := &unstructured.Unstructured{}
+		_, gvk, err := decoder.Decode(data, nil, obj)
+		if err != nil {
+			return nil, fmt.Errorf("decode manifest: %w", err)
+		}
+		if obj.GetName() == "" {
+			return nil, fmt.Errorf("%s without metadata.name", gvk.Kind)
+		}
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("unknown kind %s: %w", gvk.String(), err)
+		}
+		object := Object{
+			Group: mapping.Resource.Group, Version: mapping.Resource.Version, Resource: mapping.Resource.Resource,
+			Kind: gvk.Kind, Name: obj.GetName(),
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(namespace)
+			}
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(metav1.NamespaceDefault)
+			}
+			object.Namespace = obj.GetNamespace()
+		}
+		documents = append(documents, document{object: object, value: obj})
+	}
+	return documents, nil
+}
+
+// Delete deletes objects in reverse order with background propagation.
+// Objects that are already gone are skipped.
+func (c *Client) Delete(ctx context.Context, objects []Object) error {
+	propagation := metav1.DeletePropagationBackground
+	var errs []error
+	for i := len(objects) - 1; i >= 0; i-- {
+		object := objects[i]
+		err := c.resource(object).Delete(ctx, object.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("delete %s: %w", object, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) resource(object Object) dynamic.ResourceInterface {
+	resource := c.DynamicClient.Resource(object.gvr())
+	if object.Namespace != "" {
+		return resource.Namespace(object.Namespace)
+	}
+	return resource
+}
+
+// splitManifest splits a multi-document YAML manifest and drops empty
+// documents.
+func splitManifest(manifest string) ([][]byte, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(manifest)))
+	var documents [][]byte
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read manifest: %w", err)
+		}
+		if isEmptyDocument(document) {
+			continue
+		}
+		documents = append(documents, document)
+	}
+}
+
+func isEmptyDocument(document []byte) bool {
+	for _, line := range strings.Split(string(document), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && line != "---" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
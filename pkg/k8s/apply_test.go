@@ -0,0 +1,203 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsV1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testManifest = `
+# app
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: other
+---
+`
+
+func fakeClient(objects ...runtime.Object) (*Client, *dynamicfake.FakeDynamicClient) {
+	clientSet := fake.NewClientset(objects...)
+	clientSet.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "namespaces", Kind: "Namespace"},
+				{Name: "services", Kind: "Service", Namespaced: true},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+			},
+		},
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	return &Client{ClientSet: clientSet, DynamicClient: dynamicClient}, dynamicClient
+}
+
+func TestApplyUsesServerSideApply(t *testing.T) {
+	client, dynamicClient := fakeClient()
+	var patches []k8stesting.PatchActionImpl
+	dynamicClient.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchActionImpl)
+		patches = append(patches, patch)
+		return true, &unstructured.Unstructured{}, nil
+	})
+
+	objects, err := client.Apply(context.Background(), testManifest, "shop")
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []Object{
+		{Version: "v1", Resource: "namespaces", Kind: "Namespace", Name: "shop"},
+		{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "shop", Name: "web"},
+		{Version: "v1", Resource: "services", Kind: "Service", Namespace: "other", Name: "web"},
+	}
+	if len(objects) != len(want) {
+		t.Fatalf("Apply() objects = %+v, want %+v", objects, want)
+	}
+	for i := range want {
+		if objects[i] != want[i] {
+			t.Errorf("object %d = %+v, want %+v", i, objects[i], want[i])
+		}
+	}
+	if len(patches) != len(want) {
+		t.Fatalf("got %d patches, want %d", len(patches), len(want))
+	}
+	for i, patch := range patches {
+		if patch.GetPatchType() != types.ApplyPatchType {
+			t.Errorf("patch %d type = %s, want %s", i, patch.GetPatchType(), types.ApplyPatchType)
+		}
+		if patch.PatchOptions.FieldManager != FieldManager {
+			t.Errorf("patch %d field manager = %q, want %q", i, patch.PatchOptions.FieldManager, FieldManager)
+		}
+		if patch.PatchOptions.Force == nil || !*patch.PatchOptions.Force {
+			t.Errorf("patch %d is not forced", i)
+		}
+		if patch.GetNamespace() != want[i].Namespace || patch.GetName() != want[i].Name {
+			t.Errorf("patch %d target = %s/%s, want %s", i, patch.GetNamespace(), patch.GetName(), want[i])
+		}
+	}
+}
+
+func TestApplyRejectsUnknownKind(t *testing.T) {
+	client, _ := fakeClient()
+	manifest := "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n"
+	if _, err := client.Apply(context.Background(), manifest, "default"); err == nil {
+		t.Fatal("Apply() of an unknown kind succeeded")
+	}
+	if _, err := client.Apply(context.Background(), "---\n# nothing\n", "default"); err == nil {
+		t.Fatal("Apply() of an empty manifest succeeded")
+	}
+}
+
+func TestDeleteRemovesObjects(t *testing.T) {
+	service := &unstructured.Unstructured{}
+	service.SetAPIVersion("v1")
+	service.SetKind("Service")
+	service.SetNamespace("shop")
+	service.SetName("web")
+	client := &Client{DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), service)}
+	objects := []Object{
+		{Version: "v1", Resource: "services", Kind: "Service", Namespace: "shop", Name: "web"},
+		{Version: "v1", Resource: "services", Kind: "Service", Namespace: "shop", Name: "gone"},
+	}
+
+	if err := client.Delete(context.Background(), objects); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	_, err := client.resource(objects[0]).Get(context.Background(), "web", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("service still exists: %v", err)
+	}
+}
+
+func TestCheckObjectsReadiness(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsV1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Generation: 2},
+		Spec:       appsV1.DeploymentSpec{Replicas: &replicas},
+		Status: appsV1.DeploymentStatus{
+			ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1,
+		},
+	}
+	job := &batchV1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "shop"},
+		Status: batchV1.JobStatus{Conditions: []batchV1.JobCondition{
+			{Type: batchV1.JobFailed, Status: v1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+		}},
+	}
+	client, _ := fakeClient(deployment, job)
+	deploymentObject := Object{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "shop", Name: "web"}
+	jobObject := Object{Group: "batch", Version: "v1", Resource: "jobs", Kind: "Job", Namespace: "shop", Name: "migrate"}
+	ctx := context.Background()
+
+	readiness, err := client.CheckObjectsReadiness(ctx, []Object{deploymentObject})
+	if err != nil {
+		t.Fatalf("CheckObjectsReadiness() error = %v", err)
+	}
+	if readiness.Ready || readiness.Failed {
+		t.Fatalf("readiness = %+v, want progressing", readiness)
+	}
+
+	deployment.Status.AvailableReplicas = 2
+	if _, err := client.ClientSet.AppsV1().Deployments("shop").UpdateStatus(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	readiness, err = client.CheckObjectsReadiness(ctx, []Object{deploymentObject})
+	if err != nil {
+		t.Fatalf("CheckObjectsReadiness() error = %v", err)
+	}
+	if !readiness.Ready {
+		t.Fatalf("readiness = %+v, want ready", readiness)
+	}
+
+	readiness, err = client.CheckObjectsReadiness(ctx, []Object{deploymentObject, jobObject})
+	if err != nil {
+		t.Fatalf("CheckObjectsReadiness() error = %v", err)
+	}
+	if readiness.Ready || !readiness.Failed {
+		t.Fatalf("readiness = %+v, want failed", readiness)
+	}
+}
+
+func TestObjectsDoesNotApply(t *testing.T) {
+	client, dynamicClient := fakeClient()
+
+	objects, err := client.Objects(testManifest, "")
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+	if len(objects) != 3 || objects[1].Namespace != metav1.NamespaceDefault || objects[2].Namespace != "other" {
+		t.Fatalf("Objects() = %+v", objects)
+	}
+	if actions := dynamicClient.Actions(); len(actions) != 0 {
+		t.Fatalf("Objects() sent %d requests", len(actions))
+	}
+}
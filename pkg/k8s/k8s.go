@@ -17,9 +17,11 @@ import (
 	"k8s.io/client-go/restmapper"
 )
 
+// Client wraps the typed and dynamic clients of one cluster. Tests can build
+// it from the client-go fake clientsets.
 type Client struct {
-	ClientSet     *kubernetes.Clientset
-	DynamicClient *dynamic.DynamicClient
+	ClientSet     kubernetes.Interface
+	DynamicClient dynamic.Interface
 }
 
 func New(config *rest.Config) *Client {
@@ -52,7 +54,7 @@ func (c *Client) CreateByYaml(yamlString string) (err error) {
 			return err
 		}
 
-		mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.ClientSet))
+		mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.ClientSet.Discovery()))
 
 		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 		if err != nil {
@@ -10,8 +10,8 @@ import (
 
 func TestClient_CreatePersistentVolume(t *testing.T) {
 	type fields struct {
-		ClientSet     *kubernetes.Clientset
-		DynamicClient *dynamic.DynamicClient
+		ClientSet     kubernetes.Interface
+		DynamicClient dynamic.Interface
 	}
 	type args struct {
 		name             string
@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	appsV1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Readiness is the combined state of the workloads of a set of objects.
+// Message explains the first workload that is not ready.
+type Readiness struct {
+	Ready   bool
+	Failed  bool
+	Message string
+}
+
+// CheckObjectsReadiness reports whether the Deployments, StatefulSets,
+// DaemonSets, Jobs and Pods among objects are ready. Other kinds are ready
+// once they exist. A workload that cannot make progress marks the result
+// failed.
+func (c *Client) CheckObjectsReadiness(ctx context.Context, objects []Object) (Readiness, error) {
+	for _, object := range objects {
+		ready, failed, message, err := c.objectReadiness(ctx, object)
+		if err != nil {
+			return Readiness{}, fmt.Errorf("get %s: %w", object, err)
+		}
+		if !ready {
+			return Readiness{Failed: failed, Message: fmt.Sprintf("%s: %s", object, message)}, nil
+		}
+	}
+	return Readiness{Ready: true}, nil
+}
+
+func (c *Client) objectReadiness(ctx context.Context, object Object) (bool, bool, string, error) {
+	apps := c.ClientSet.AppsV1()
+	switch {
+	case object.Group == "apps" && object.Kind == "Deployment":
+		deployment, err := apps.Deployments(object.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, false, "", err
+		}
+		ready, failed, message := deploymentReady(deployment)
+		return ready, failed, message, nil
+	case object.Group == "apps" && object.Kind == "StatefulSet":
+		statefulSet, err := apps.StatefulSets(object.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, false, "", err
+		}
+		ready, message := statefulSetReady(statefulSet)
+		return ready, false, message, nil
+	case object.Group == "apps" && object.Kind == "DaemonSet":
+		daemonSet, err := apps.DaemonSets(object.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, false, "", err
+		}
+		ready, message := daemonSetReady(daemonSet)
+		return ready, false, message, nil
+	case object.Group == "batch" && object.Kind == "Job":
+		job, err := c.ClientSet.BatchV1().Jobs(object.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, false, "", err
+		}
+		ready, failed, message := jobReady(job)
+		return ready, failed, message, nil
+	case object.Group == "" && object.Kind == "Pod":
+		pod, err := c.ClientSet.CoreV1().Pods(object.Namespace).Get(ctx, object.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, false, "", err
+		}
+		if pod.Status.Phase == v1.PodFailed {
+			return false, true, "pod failed", nil
+		}
+		if pod.Status.Phase == v1.PodSucceeded || isPodReady(pod) && pod.Status.Phase == v1.PodRunning {
+			return true, false, "", nil
+		}
+		return false, false, fmt.Sprintf("pod is %s", pod.Status.Phase), nil
+	default:
+		_, err := c.resource(object).Get(ctx, object.Name, metav1.GetOptions{})
+		return err == nil, false, "", err
+	}
+}
+
+func deploymentReady(deployment *appsV1.Deployment) (bool, bool, string) {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsV1.DeploymentProgressing && condition.Status == v1.ConditionFalse &&
+			condition.Reason == "ProgressDeadlineExceeded" {
+			return false, true, "progress deadline exceeded"
+		}
+	}
+	replicas := replicaCount(deployment.Spec.Replicas)
+	status := deployment.Status
+	switch {
+	case status.ObservedGeneration < deployment.Generation:
+		return false, false, "waiting for the controller to observe the update"
+	case status.UpdatedReplicas < replicas:
+		return false, false, fmt.Sprintf("%d of %d replicas updated", status.UpdatedReplicas, replicas)
+	case status.Replicas > status.UpdatedReplicas:
+		return false, false, fmt.Sprintf("%d old replicas pending termination", status.Replicas-status.UpdatedReplicas)
+	case status.AvailableReplicas < replicas:
+		return false, false, fmt.Sprintf("%d of %d replicas available", status.AvailableReplicas, replicas)
+	}
+	return true, false, ""
+}
+
+func statefulSetReady(statefulSet *appsV1.StatefulSet) (bool, string) {
+	replicas := replicaCount(statefulSet.Spec.Replicas)
+	status := statefulSet.Status
+	switch {
+	case status.ObservedGeneration < statefulSet.Generation:
+		return false, "waiting for the controller to observe the update"
+	case status.ReadyReplicas < replicas:
+		return false, fmt.Sprintf("%d of %d replicas ready", status.ReadyReplicas, replicas)
+	case statefulSet.Spec.UpdateStrategy.Type != appsV1.OnDeleteStatefulSetStrategyType &&
+		status.UpdateRevision != "" && status.CurrentRevision != status.UpdateRevision:
+		return false, fmt.Sprintf("%d of %d replicas updated", status.UpdatedReplicas, replicas)
+	}
+	return true, ""
+}
+
+func daemonSetReady(daemonSet *appsV1.DaemonSet) (bool, string) {
+	status := daemonSet.Status
+	switch {
+	case status.ObservedGeneration < daemonSet.Generation:
+		return false, "waiting for the controller to observe the update"
+	case status.UpdatedNumberScheduled < status.DesiredNumberScheduled:
+		return false, fmt.Sprintf("%d of %d pods updated", status.UpdatedNumberScheduled, status.DesiredNumberScheduled)
+	case status.NumberReady < status.DesiredNumberScheduled:
+		return false, fmt.Sprintf("%d of %d pods ready", status.NumberReady, status.DesiredNumberScheduled)
+	}
+	return true, ""
+}
+
+func jobReady(job *batchV1.Job) (bool, bool, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchV1.JobComplete:
+			return true, false, ""
+		case batchV1.JobFailed:
+			return false, true, fmt.Sprintf("job failed: %s", condition.Reason)
+		}
+	}
+	return false, false, fmt.Sprintf("%d pods succeeded", job.Status.Succeeded)
+}
+
+func replicaCount(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -13,8 +13,8 @@ import (
 
 func TestClient_CreateSecretByFile(t *testing.T) {
 	type fields struct {
-		ClientSet     *kubernetes.Clientset
-		DynamicClient *dynamic.DynamicClient
+		ClientSet     kubernetes.Interface
+		DynamicClient dynamic.Interface
 	}
 	type args struct {
 		namespace  string
@@ -64,8 +64,8 @@ func TestClient_CreateSecretByFile(t *testing.T) {
 
 func TestClient_GenerateTLSSecret(t *testing.T) {
 	type fields struct {
-		ClientSet     *kubernetes.Clientset
-		DynamicClient *dynamic.DynamicClient
+		ClientSet     kubernetes.Interface
+		DynamicClient dynamic.Interface
 	}
 	type args struct {
 		name       string
@@ -0,0 +1,9 @@
+package ssh
+
+import "github.com/pkg/sftp"
+
+// NewSftp opens an SFTP subsystem on the established SSH connection. Closing
+// the returned client does not close the underlying SSH connection.
+func (c *Client) NewSftp() (*sftp.Client, error) {
+	return sftp.NewClient(c.Client)
+}
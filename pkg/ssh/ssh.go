@@ -30,10 +30,17 @@ type Client struct {
 }
 
 func NewSsh(machine *Machine) (s *Client, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return NewSshContext(ctx, machine)
+}
+
+// NewSshContext connects to machine, bounding the dial and the handshake by
+// the deadline of ctx.
+func NewSshContext(ctx context.Context, machine *Machine) (s *Client, err error) {
 
 	config := &gossh.ClientConfig{
 		User:            machine.User,
-		Timeout:         5 * time.Second,
 		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
 	}
 
@@ -54,14 +61,32 @@ func NewSsh(machine *Machine) (s *Client, err error) {
 	if strings.Contains(machine.IpAddress, ":") {
 		proto = "tcp6"
 	}
-	client, err := gossh.Dial(proto, hostport, config)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, proto, hostport)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	sshConn, chans, reqs, err := gossh.NewClientConn(conn, hostport, config)
+	if !stop() {
+		if err == nil {
+			sshConn.Close()
+		}
+		return nil, ctx.Err()
+	}
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
+	_ = conn.SetDeadline(time.Time{})
+	client := gossh.NewClient(sshConn, chans, reqs)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	clientCtx, cancel := context.WithCancel(context.Background())
 
-	s = &Client{Client: client, Ctx: ctx, Cancel: cancel, Name: machine.Name}
+	s = &Client{Client: client, Ctx: clientCtx, Cancel: cancel, Name: machine.Name}
 	return s, err
 }
 